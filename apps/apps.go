@@ -0,0 +1,49 @@
+// Package apps lists the programs installed on the machine
+package apps
+
+import (
+	"sort"
+	"strings"
+)
+
+// Program is an installed program, as registered for Apps & Features
+type Program struct {
+	Name      string
+	Version   string
+	Publisher string
+	Size      int64  // Estimated size in bytes, 0 if unknown
+	Location  string // Install directory, may be empty
+	Uninstall string // Uninstaller command line
+}
+
+// List returns the installed programs of the machine and the current user,
+// sorted by name
+func List() ([]Program, error) {
+	programs, err := list()
+	if err != nil {
+		return nil, err
+	}
+	return dedupe(programs), nil
+}
+
+// Uninstall starts the uninstaller of a program without waiting for it
+func Uninstall(p Program) error {
+	return uninstall(p)
+}
+
+// dedupe sorts programs by name and drops programs registered more than once
+// (32-bit and 64-bit views, machine and user)
+func dedupe(programs []Program) []Program {
+	sort.SliceStable(programs, func(a, b int) bool {
+		return strings.ToLower(programs[a].Name) < strings.ToLower(programs[b].Name)
+	})
+
+	var unique []Program
+	for i, p := range programs {
+		if i > 0 && strings.EqualFold(programs[i-1].Name, p.Name) && programs[i-1].Version == p.Version {
+			continue
+		}
+		unique = append(unique, p)
+	}
+	return unique
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package apps
+
+import "errors"
+
+// errUnsupported is returned where there is no uninstall registry
+var errUnsupported = errors.New("installed programs are only listed on Windows")
+
+func list() ([]Program, error) {
+	return nil, errUnsupported
+}
+
+func uninstall(p Program) error {
+	return errUnsupported
+}
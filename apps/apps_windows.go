@@ -0,0 +1,96 @@
+//go:build windows
+
+package apps
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// uninstallKey lists the programs shown in Apps & Features
+const uninstallKey = `SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall`
+
+// uninstallRoots are the registry locations programs register themselves in
+var uninstallRoots = []struct {
+	Root registry.Key
+	Path string
+}{
+	{registry.LOCAL_MACHINE, uninstallKey},
+	{registry.LOCAL_MACHINE, `SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\Uninstall`},
+	{registry.CURRENT_USER, uninstallKey},
+}
+
+func list() ([]Program, error) {
+	var programs []Program
+	var lastErr error
+	opened := 0
+	for _, root := range uninstallRoots {
+		key, err := registry.OpenKey(root.Root, root.Path, registry.ENUMERATE_SUB_KEYS|registry.QUERY_VALUE)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		opened++
+		names, _ := key.ReadSubKeyNames(-1)
+		for _, name := range names {
+			if p, ok := readProgram(key, name); ok {
+				programs = append(programs, p)
+			}
+		}
+		key.Close()
+	}
+	if opened == 0 {
+		return nil, lastErr
+	}
+	return programs, nil
+}
+
+// readProgram reads an uninstall entry, skipping updates, system components and
+// entries without a name
+func readProgram(parent registry.Key, name string) (Program, bool) {
+	key, err := registry.OpenKey(parent, name, registry.QUERY_VALUE)
+	if err != nil {
+		return Program{}, false
+	}
+	defer key.Close()
+
+	str := func(value string) string {
+		s, _, _ := key.GetStringValue(value)
+		return s
+	}
+	num := func(value string) uint64 {
+		n, _, _ := key.GetIntegerValue(value)
+		return n
+	}
+
+	if num("SystemComponent") == 1 || str("ParentKeyName") != "" {
+		return Program{}, false
+	}
+	if release := str("ReleaseType"); release == "Update" || release == "Hotfix" || release == "Security Update" {
+		return Program{}, false
+	}
+	p := Program{
+		Name:      str("DisplayName"),
+		Version:   str("DisplayVersion"),
+		Publisher: str("Publisher"),
+		Size:      int64(num("EstimatedSize")) * 1024,
+		Location:  strings.Trim(str("InstallLocation"), `"`),
+		Uninstall: str("UninstallString"),
+	}
+	return p, p.Name != ""
+}
+
+func uninstall(p Program) error {
+	if p.Uninstall == "" {
+		return errors.New("no uninstaller is registered")
+	}
+	// The uninstall string is a complete command line with its own quoting, so
+	// it is passed to start as is
+	cmd := exec.Command("cmd")
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: `cmd /c start "" ` + p.Uninstall}
+	return cmd.Run()
+}
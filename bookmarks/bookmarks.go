@@ -0,0 +1,91 @@
+// Package bookmarks keeps the user's named directory bookmarks across runs
+package bookmarks
+
+import (
+	"path/filepath"
+	"slices"
+
+	"github.com/HolyStarGazer/windows-tui-go/config"
+)
+
+// bookmarksFile is the state file holding the bookmarks, in their order
+const bookmarksFile = "bookmarks.json"
+
+// Bookmark is a named directory
+type Bookmark struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// Load returns the bookmarks in the order the user gave them
+func Load() ([]Bookmark, error) {
+	var bookmarks []Bookmark
+	if err := config.Load(bookmarksFile, &bookmarks); err != nil {
+		return nil, err
+	}
+	return bookmarks, nil
+}
+
+// Add bookmarks a directory at the end of the list, or renames its bookmark
+// if it already has one, and returns the bookmarks
+func Add(name, path string) ([]Bookmark, error) {
+	path = filepath.Clean(path)
+	return update(func(bookmarks []Bookmark) []Bookmark {
+		if i := index(bookmarks, path); i >= 0 {
+			bookmarks[i].Name = name
+			return bookmarks
+		}
+		return append(bookmarks, Bookmark{Name: name, Path: path})
+	})
+}
+
+// Rename gives the bookmark of path a new name
+func Rename(path, name string) ([]Bookmark, error) {
+	return update(func(bookmarks []Bookmark) []Bookmark {
+		if i := index(bookmarks, path); i >= 0 {
+			bookmarks[i].Name = name
+		}
+		return bookmarks
+	})
+}
+
+// Delete removes the bookmark of path
+func Delete(path string) ([]Bookmark, error) {
+	return update(func(bookmarks []Bookmark) []Bookmark {
+		return slices.DeleteFunc(bookmarks, func(b Bookmark) bool { return b.Path == path })
+	})
+}
+
+// Move moves the bookmark of path up (delta < 0) or down the list, stopping
+// at either end
+func Move(path string, delta int) ([]Bookmark, error) {
+	return update(func(bookmarks []Bookmark) []Bookmark {
+		i := index(bookmarks, path)
+		if i < 0 {
+			return bookmarks
+		}
+		to := min(max(i+delta, 0), len(bookmarks)-1)
+		b := bookmarks[i]
+		bookmarks = slices.Delete(bookmarks, i, i+1)
+		return slices.Insert(bookmarks, to, b)
+	})
+}
+
+// index returns the position of the bookmark of path, or -1
+func index(bookmarks []Bookmark, path string) int {
+	return slices.IndexFunc(bookmarks, func(b Bookmark) bool { return b.Path == path })
+}
+
+// update changes the saved bookmarks and returns them. Reading them again
+// first keeps changes made by other windows.
+func update(change func([]Bookmark) []Bookmark) ([]Bookmark, error) {
+	bookmarks, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	bookmarks = change(bookmarks)
+	if err := config.Save(bookmarksFile, bookmarks); err != nil {
+		return nil, err
+	}
+	return bookmarks, nil
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/config"
+	"github.com/HolyStarGazer/windows-tui-go/ops"
+	"github.com/HolyStarGazer/windows-tui-go/search"
+	"github.com/HolyStarGazer/windows-tui-go/types"
+	"github.com/HolyStarGazer/windows-tui-go/ui"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+)
+
+// listEntry is a file or directory printed by ls --json
+type listEntry struct {
+	Name     string    `json:"name"`
+	Path     string    `json:"path"`
+	Dir      bool      `json:"dir"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Mode     string    `json:"mode"`
+	Hidden   bool      `json:"hidden"`
+	Link     string    `json:"link,omitempty"` // Target of a link or junction
+	MIMEType string    `json:"mime,omitempty"`
+}
+
+// searchResult is a matching line printed by search --json
+type searchResult struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// runCommand runs the subcommand named by the first argument and returns its
+// exit status; ok is false if there is no subcommand
+func runCommand(args []string) (status int, ok bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	switch args[0] {
+	case "ls":
+		return listCommand(args[1:]), true
+	case "search":
+		return searchCommand(args[1:]), true
+	}
+	return 0, false
+}
+
+// listCommand prints the entries of a directory the way the browser lists
+// them: directories first, then files (ls [flags] [path])
+func listCommand(args []string) int {
+	flags := flag.NewFlagSet("ls", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the entries as a JSON array")
+	hidden := flags.Bool("hidden", true, "include hidden files and dot files")
+	sortBy := flags.String("sort", "name", "order of the entries: "+strings.Join(types.SorterNames(), ", "))
+	glob := flags.String("filter", "", "only list files whose name matches a glob pattern, such as *.log")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s ls [flags] [path]\n\n", filepath.Base(os.Args[0]))
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	sorter, ok := types.SorterNamed(*sortBy)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown sort order '%s'\n", *sortBy)
+		return 1
+	}
+	var filter types.Filter = types.Glob(*glob)
+	if !*hidden {
+		filter = types.All(types.NotHidden, filter)
+	}
+	path := flags.Arg(0)
+	if path == "" {
+		path = "."
+	}
+
+	items, err := listItems(path, filter, sorter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if *asJSON {
+		entries := make([]listEntry, len(items))
+		for i, item := range items {
+			entries[i] = listEntry{
+				Name:     item.Name,
+				Path:     item.Path,
+				Dir:      item.IsDir,
+				Size:     item.Size,
+				Modified: item.ModTime,
+				Mode:     item.Mode.String(),
+				Hidden:   item.IsHidden(),
+				Link:     item.LinkTarget,
+				MIMEType: item.MIMEType,
+			}
+		}
+		return printJSON(entries)
+	}
+	for _, item := range items {
+		size := ""
+		if !item.IsDir {
+			size = ui.FormatSize(item.Size)
+		}
+		fmt.Printf("%s  %10s  %s  %s\n", item.Mode, size, item.ModTime.Format("2006-01-02 15:04"), item.DisplayName())
+	}
+	return 0
+}
+
+// listItems reads the entries of a directory, or describes path itself if it
+// is a file
+func listItems(path string, filter types.Filter, sorter types.Sorter) ([]types.FileItem, error) {
+	info, err := vfs.Default.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	if item := types.NewFileItem(path, info); !item.IsDir {
+		return []types.FileItem{item}, nil
+	}
+
+	entries, err := vfs.Default.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var dirs, files []types.FileItem
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		item := types.NewFileItem(filepath.Join(path, entry.Name()), info)
+		switch {
+		case !filter.Keep(item):
+		case item.IsDir:
+			dirs = append(dirs, item)
+		default:
+			files = append(files, item)
+		}
+	}
+	types.Sort(dirs, sorter)
+	types.Sort(files, sorter)
+	return append(dirs, files...), nil
+}
+
+// searchCommand prints the lines of the files below a directory that contain
+// a text, ignoring case, as :grep finds them (search [flags] <text> [path]).
+// Like grep, it exits with 1 if nothing matched and 2 on errors.
+func searchCommand(args []string) int {
+	flags := flag.NewFlagSet("search", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the matching lines as a JSON array")
+	maxResults := flags.Int("max", 0, "stop after this many matching lines (0 for no limit)")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s search [flags] <text> [path]\n\n", filepath.Base(os.Args[0]))
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 || flags.NArg() > 2 {
+		flags.Usage()
+		return 2
+	}
+	root := flags.Arg(1)
+	if root == "" {
+		root = "."
+	}
+	if info, err := vfs.Default.Stat(root); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	} else if !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "Error: %s is not a directory\n", root)
+		return 2
+	}
+
+	// Search on as many workers as the browser would
+	settings, err := config.LoadSettings()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading settings: %v\n", err)
+	}
+	ops.SetLimits(settings.Workers, 0)
+
+	matches, truncated, err := search.Grep(root, flags.Arg(0), search.Options{
+		SkipDirs:   search.DefaultSkipDirs,
+		MaxResults: *maxResults,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if truncated {
+		fmt.Fprintf(os.Stderr, "Stopped after %d matching lines\n", *maxResults)
+	}
+
+	if *asJSON {
+		results := make([]searchResult, len(matches))
+		for i, match := range matches {
+			results[i] = searchResult(match)
+		}
+		if status := printJSON(results); status != 0 {
+			return 2
+		}
+	} else {
+		for _, match := range matches {
+			fmt.Printf("%s:%d:%s\n", match.Path, match.Line, match.Text)
+		}
+	}
+	if len(matches) == 0 {
+		return 1
+	}
+	return 0
+}
+
+// printJSON writes v to standard output as indented JSON
+func printJSON(v any) int {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,12 @@
+// Package clipboard reads and writes text on the system clipboard
+package clipboard
+
+// Text returns the text on the clipboard
+func Text() (string, error) {
+	return text()
+}
+
+// SetText replaces the clipboard contents with text
+func SetText(s string) error {
+	return setText(s)
+}
@@ -0,0 +1,54 @@
+//go:build !windows
+
+package clipboard
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// errNoTool is returned when none of the clipboard commands is installed
+var errNoTool = errors.New("no clipboard tool found (install wl-clipboard, xclip or xsel)")
+
+// pasteCommands print the clipboard on macOS, Wayland and X11
+var pasteCommands = [][]string{
+	{"pbpaste"},
+	{"wl-paste", "--no-newline"},
+	{"xclip", "-selection", "clipboard", "-o"},
+	{"xsel", "--clipboard", "--output"},
+}
+
+// copyCommands set the clipboard from their input
+var copyCommands = [][]string{
+	{"pbcopy"},
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard", "-i"},
+	{"xsel", "--clipboard", "--input"},
+}
+
+func text() (string, error) {
+	for _, args := range pasteCommands {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		out, err := exec.Command(args[0], args[1:]...).Output()
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	}
+	return "", errNoTool
+}
+
+func setText(s string) error {
+	for _, args := range copyCommands {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin = strings.NewReader(s)
+		return cmd.Run()
+	}
+	return errNoTool
+}
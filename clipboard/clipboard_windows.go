@@ -0,0 +1,111 @@
+//go:build windows
+
+package clipboard
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// cfUnicodeText is the UTF-16 text clipboard format
+const cfUnicodeText = 13
+
+// gmemMoveable allocates memory the clipboard can take over
+const gmemMoveable = 0x0002
+
+var (
+	user32               = windows.NewLazySystemDLL("user32.dll")
+	kernel32             = windows.NewLazySystemDLL("kernel32.dll")
+	procOpenClipboard    = user32.NewProc("OpenClipboard")
+	procCloseClipboard   = user32.NewProc("CloseClipboard")
+	procGetClipboardData = user32.NewProc("GetClipboardData")
+	procSetClipboardData = user32.NewProc("SetClipboardData")
+	procEmptyClipboard   = user32.NewProc("EmptyClipboard")
+	procGlobalAlloc      = kernel32.NewProc("GlobalAlloc")
+	procGlobalFree       = kernel32.NewProc("GlobalFree")
+	procGlobalLock       = kernel32.NewProc("GlobalLock")
+	procGlobalUnlock     = kernel32.NewProc("GlobalUnlock")
+)
+
+// openClipboard opens the clipboard for the calling thread, which must be
+// locked to its OS thread until the clipboard is closed
+func openClipboard() error {
+	// Another program may hold the clipboard for a moment
+	var err error
+	for range 10 {
+		var ok uintptr
+		if ok, _, err = procOpenClipboard.Call(0); ok != 0 {
+			return nil
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return fmt.Errorf("cannot open the clipboard: %v", err)
+}
+
+func text() (string, error) {
+	// The clipboard is opened per thread
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := openClipboard(); err != nil {
+		return "", err
+	}
+	defer procCloseClipboard.Call()
+
+	handle, _, _ := procGetClipboardData.Call(cfUnicodeText)
+	if handle == 0 {
+		return "", errors.New("the clipboard holds no text")
+	}
+	ptr, _, err := procGlobalLock.Call(handle)
+	if ptr == 0 {
+		return "", fmt.Errorf("cannot read the clipboard: %v", err)
+	}
+	defer procGlobalUnlock.Call(handle)
+	// The locked memory belongs to the clipboard, not the Go heap
+	data := *(**uint16)(unsafe.Pointer(&ptr))
+	return windows.UTF16PtrToString(data), nil
+}
+
+func setText(s string) error {
+	data, err := windows.UTF16FromString(s)
+	if err != nil {
+		return err
+	}
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := openClipboard(); err != nil {
+		return err
+	}
+	defer procCloseClipboard.Call()
+	if ok, _, err := procEmptyClipboard.Call(); ok == 0 {
+		return fmt.Errorf("cannot empty the clipboard: %v", err)
+	}
+
+	size := uintptr(len(data)) * unsafe.Sizeof(data[0])
+	handle, _, err := procGlobalAlloc.Call(gmemMoveable, size)
+	if handle == 0 {
+		return fmt.Errorf("cannot allocate clipboard memory: %v", err)
+	}
+	ptr, _, err := procGlobalLock.Call(handle)
+	if ptr == 0 {
+		procGlobalFree.Call(handle)
+		return fmt.Errorf("cannot write the clipboard: %v", err)
+	}
+	dst := unsafe.Slice(*(**uint16)(unsafe.Pointer(&ptr)), len(data))
+	copy(dst, data)
+	procGlobalUnlock.Call(handle)
+
+	// The clipboard owns the memory once it is set
+	if ok, _, err := procSetClipboardData.Call(cfUnicodeText, handle); ok == 0 {
+		procGlobalFree.Call(handle)
+		return fmt.Errorf("cannot write the clipboard: %v", err)
+	}
+	return nil
+}
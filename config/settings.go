@@ -0,0 +1,243 @@
+package config
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// settingsFile is the user settings file in the configuration directory
+const settingsFile = "config.json"
+
+// HighlightRule colors lines matching a regular expression
+type HighlightRule struct {
+	Pattern string `json:"pattern"` // Regular expression matched against each line
+	Color   string `json:"color"`   // Foreground color, e.g. "#FF5F5F" or "9"
+}
+
+// FileRule styles browser files by age or size. Every condition that is set
+// must hold; the first matching rule wins.
+type FileRule struct {
+	ModifiedWithin string `json:"modified_within"` // Duration such as "24h"
+	MinSize        string `json:"min_size"`        // Size such as "1GB"
+	MaxSize        string `json:"max_size"`        // Size such as "0" for empty files
+	Color          string `json:"color"`           // Foreground color, e.g. "#FFD75F" or "11"
+	Bold           bool   `json:"bold"`
+	Faint          bool   `json:"faint"`
+}
+
+// Settings holds the user preferences read from config.json
+type Settings struct {
+	// HighlightRules color matching lines of live (followed or piped) content
+	HighlightRules []HighlightRule `json:"highlight_rules"`
+
+	// Filetypes maps file extensions (".ps1xml") or file names ("Jenkinsfile") to
+	// the syntax highlighting language to use for them
+	Filetypes map[string]string `json:"filetypes"`
+
+	// FileRules style files in the browser, such as recently changed or huge files
+	FileRules []FileRule `json:"file_rules"`
+
+	// HeaderFormat and StatusFormat lay out the browser's header line and status
+	// bar. Placeholders such as {path}, {free}, {git_branch} and {items} are
+	// replaced with their current values.
+	HeaderFormat string `json:"header_format"`
+	StatusFormat string `json:"status_format"`
+
+	// Quit sets what q does in the browser: "q" quits, "double" needs q pressed
+	// twice in a row and "command" leaves quitting to :q
+	Quit string `json:"quit"`
+
+	// ConfirmQuit asks before quitting while a task is running or items are
+	// marked
+	ConfirmQuit bool `json:"confirm_quit"`
+
+	// MaxViewSize is the largest file the viewer reads whole, e.g. "50MB"; larger
+	// files are read a window at a time as they are scrolled. "0" reads files of
+	// any size whole.
+	MaxViewSize string `json:"max_view_size"`
+
+	// IdleLock blanks the screen after this long without input, e.g. "15m";
+	// empty never does
+	IdleLock string `json:"idle_lock"`
+
+	// LockPasswordHash is the salted hash of the password that unlocks the
+	// blanked screen (see HashPassword); without it any key does
+	LockPasswordHash string `json:"lock_password_hash"`
+
+	// IdleSuspend holds back the timers that poll files and refresh panels
+	// after this long without input, e.g. "5m", until a key is pressed; empty
+	// keeps them running
+	IdleSuspend string `json:"idle_suspend"`
+
+	// MaxFPS caps how many times a second the screen is redrawn, 1 to 120; 0
+	// uses 30
+	MaxFPS int `json:"max_fps"`
+
+	// CleanMinAge is how old temporary files and cache entries must be for
+	// :clean to delete them, e.g. "7d" or "48h"; "0" deletes them at any age
+	CleanMinAge string `json:"clean_min_age"`
+
+	// Workers is how many background jobs (copying, searching, folder counts,
+	// directory sizes) run at once; 0 uses the number of CPUs
+	Workers int `json:"workers"`
+
+	// IOLimit caps how much background jobs read per second in total, e.g.
+	// "20MB"; empty or "0" does not limit them
+	IOLimit string `json:"io_limit"`
+
+	// VerifyCopies hashes every copied file and its copy, stopping the copy or
+	// move at a file whose copy differs
+	VerifyCopies bool `json:"verify_copies"`
+
+	// SpellCheck underlines misspelled words in text and Markdown files
+	SpellCheck bool `json:"spell_check"`
+
+	// SlideshowDelay is how long the image slideshow shows each image, e.g.
+	// "5s"
+	SlideshowDelay string `json:"slideshow_delay"`
+
+	// Keys binds actions such as "browser.up" or "viewer.quit" to lists of
+	// keys, replacing their default keys
+	Keys map[string][]string `json:"keys,omitempty"`
+
+	// Theme names the colors of the TUI: dark, light, high-contrast,
+	// solarized-dark or solarized-light
+	Theme string `json:"theme"`
+
+	// Preview shows the entry under the cursor beside the listing at start
+	// (P or :preview toggles it)
+	Preview bool `json:"preview"`
+
+	// PreviewTypes maps file extensions (".md") or file names to how the
+	// preview pane shows them: "view" as the viewer does, "text" for the
+	// source, "image", "archive", "summary" or "none"
+	PreviewTypes map[string]string `json:"preview_types,omitempty"`
+
+	// Colorscheme names the chroma style the viewer highlights code with, e.g.
+	// "dracula"; empty or "auto" uses the theme's
+	Colorscheme string `json:"colorscheme"`
+}
+
+// DefaultSettings returns the settings used when no config.json exists
+func DefaultSettings() Settings {
+	return Settings{
+		HighlightRules: []HighlightRule{
+			{Pattern: `(?i)\b(error|fatal|panic|exception)\b`, Color: "#FF5F5F"},
+			{Pattern: `(?i)\b(warn|warning)\b`, Color: "#FFD75F"},
+		},
+		Filetypes: map[string]string{
+			".ps1xml":  "xml",
+			".props":   "xml",
+			".targets": "xml",
+		},
+		FileRules: []FileRule{
+			{MinSize: "1GB", Color: "#FFD75F"},
+			{MaxSize: "0", Faint: true},
+			{ModifiedWithin: "24h", Color: "#FFFFFF", Bold: true},
+		},
+		HeaderFormat:   "Current Path: {path}",
+		StatusFormat:   "{cursor}/{items} items",
+		MaxViewSize:    "10MB",
+		Quit:           "q",
+		ConfirmQuit:    true,
+		IdleSuspend:    "10m",
+		CleanMinAge:    "7d",
+		SlideshowDelay: "5s",
+		Theme:          "dark",
+	}
+}
+
+// passwordScheme names the hashing of lock_password_hash, which is written as
+// pbkdf2-sha256$<iterations>$<salt>$<key> with the salt and key in base64
+const passwordScheme = "pbkdf2-sha256"
+
+// passwordIterations is the PBKDF2 iteration count of new password hashes,
+// slowing down guesses at a hash copied from config.json
+const passwordIterations = 600000
+
+// maxPasswordIterations bounds the iteration count read from a hash, so a
+// mistyped one cannot hang the lock
+const maxPasswordIterations = 10000000
+
+// passwordSaltSize and passwordKeySize are the sizes in bytes of the random
+// salt and the derived key
+const (
+	passwordSaltSize = 16
+	passwordKeySize  = 32
+)
+
+// errPasswordHash reports a lock_password_hash that HashPassword did not make
+var errPasswordHash = errors.New("invalid lock_password_hash (run with --hash-password to make one)")
+
+// HashPassword returns a salted PBKDF2 hash of a password, as stored in
+// lock_password_hash
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, passwordKeySize)
+	if err != nil {
+		return "", err
+	}
+	encoding := base64.RawStdEncoding
+	return fmt.Sprintf("%s$%d$%s$%s", passwordScheme, passwordIterations, encoding.EncodeToString(salt), encoding.EncodeToString(key)), nil
+}
+
+// parsePasswordHash splits a hash made by HashPassword into its iteration
+// count, salt and key
+func parsePasswordHash(hash string) (int, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimSpace(hash), "$")
+	if len(parts) != 4 || parts[0] != passwordScheme {
+		return 0, nil, nil, errPasswordHash
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 || iterations > maxPasswordIterations {
+		return 0, nil, nil, errPasswordHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil || len(salt) == 0 {
+		return 0, nil, nil, errPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(key) == 0 {
+		return 0, nil, nil, errPasswordHash
+	}
+	return iterations, salt, key, nil
+}
+
+// CheckPasswordHash reports a hash that CheckPassword cannot check against
+func CheckPasswordHash(hash string) error {
+	_, _, _, err := parsePasswordHash(hash)
+	return err
+}
+
+// CheckPassword reports whether password matches the stored hash, comparing
+// the keys in constant time
+func CheckPassword(hash, password string) bool {
+	iterations, salt, want, err := parsePasswordHash(hash)
+	if err != nil {
+		return false
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, want) == 1
+}
+
+// LoadSettings reads config.json, using defaults for anything it does not set
+func LoadSettings() (Settings, error) {
+	settings := DefaultSettings()
+	if err := Load(settingsFile, &settings); err != nil {
+		return DefaultSettings(), err
+	}
+	return settings, nil
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestHashPassword checks a password hash is salted, matches only its
+// password and is refused when malformed
+func TestHashPassword(t *testing.T) {
+	first, err := HashPassword("hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := HashPassword("hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Errorf("two hashes of one password are both %q, want different salts", first)
+	}
+	if !strings.HasPrefix(first, passwordScheme+"$") {
+		t.Errorf("hash %q does not name its scheme", first)
+	}
+	for _, hash := range []string{first, second, " " + first + "\n"} {
+		if err := CheckPasswordHash(hash); err != nil {
+			t.Errorf("CheckPasswordHash(%q): %v", hash, err)
+		}
+		if !CheckPassword(hash, "hunter2") {
+			t.Errorf("the password does not match %q", hash)
+		}
+		if CheckPassword(hash, "hunter3") || CheckPassword(hash, "") {
+			t.Errorf("a wrong password matches %q", hash)
+		}
+	}
+
+	for _, hash := range []string{
+		"f52fbd32b2b3b86ff88ef6c490628285f482af15ddcb29541f94bcf526a3f6c7",
+		"pbkdf2-sha256$600000$c2FsdA",
+		"pbkdf2-sha256$0$c2FsdA$a2V5",
+		"pbkdf2-sha256$99999999999$c2FsdA$a2V5",
+		"pbkdf2-sha256$600000$$a2V5",
+		"pbkdf2-sha256$600000$c2FsdA$not base64",
+		"scrypt$600000$c2FsdA$a2V5",
+	} {
+		if err := CheckPasswordHash(hash); err == nil {
+			t.Errorf("CheckPasswordHash(%q) accepted a malformed hash", hash)
+		}
+		if CheckPassword(hash, "hunter2") {
+			t.Errorf("CheckPassword(%q) matched a malformed hash", hash)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+// Package diff compares texts line by line
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxEdits bounds the line diff; texts needing more edits than this are shown
+// as one replaced block, as finding the shortest edit costs memory growing with
+// its square
+const maxEdits = 2000
+
+// opKind is what happens to a line
+type opKind int
+
+const (
+	opKeep opKind = iota
+	opDelete
+	opInsert
+)
+
+// op is a line kept, deleted from a or inserted from b
+type op struct {
+	Kind opKind
+	Text string
+}
+
+// Unified returns a unified diff turning a into b with context lines around each
+// change, or "" if the texts are the same. Line endings are normalized and a
+// missing final newline is ignored.
+func Unified(nameA, nameB, a, b string, context int) string {
+	ops := lineOps(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	lineA, lineB := 1, 1
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == opKeep {
+			i++
+			lineA++
+			lineB++
+			continue
+		}
+
+		// A hunk runs from context lines before the change until context lines
+		// after the last change closer than 2*context lines
+		start := max(0, i-context)
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].Kind != opKeep {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+		end = min(len(ops), end+context)
+
+		hunkA, hunkB := lineA-(i-start), lineB-(i-start)
+		var body strings.Builder
+		countA, countB := 0, 0
+		for _, o := range ops[start:end] {
+			switch o.Kind {
+			case opKeep:
+				body.WriteString(" " + o.Text + "\n")
+				countA++
+				countB++
+			case opDelete:
+				body.WriteString("-" + o.Text + "\n")
+				countA++
+			case opInsert:
+				body.WriteString("+" + o.Text + "\n")
+				countB++
+			}
+		}
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", nameA, nameB)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(hunkA, countA), hunkRange(hunkB, countB))
+		out.WriteString(body.String())
+
+		// Continue after the hunk, counting its lines
+		for _, o := range ops[i:end] {
+			if o.Kind != opInsert {
+				lineA++
+			}
+			if o.Kind != opDelete {
+				lineB++
+			}
+		}
+		i = end
+	}
+	return out.String()
+}
+
+// hunkRange formats the start and length of a hunk side; empty sides start at
+// the line before them
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits text into lines, ignoring \r and a final newline
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// lineOps returns the edits turning a into b. The common start and end are
+// matched first, as texts compared this way are usually nearly the same.
+func lineOps(a, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []op
+	for _, line := range a[:prefix] {
+		ops = append(ops, op{opKeep, line})
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if middle, ok := shortestEdit(midA, midB); ok {
+		ops = append(ops, middle...)
+	} else {
+		for _, line := range midA {
+			ops = append(ops, op{opDelete, line})
+		}
+		for _, line := range midB {
+			ops = append(ops, op{opInsert, line})
+		}
+	}
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, op{opKeep, line})
+	}
+	return ops
+}
+
+// shortestEdit finds the fewest deletions and insertions turning a into b with
+// Myers' algorithm, giving up after maxEdits
+func shortestEdit(a, b []string) ([]op, bool) {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+
+	// trace[d] holds the furthest x reached on diagonals -d..d after d edits
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		if d > maxEdits {
+			return nil, false
+		}
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // Insertion: down from diagonal k+1
+			} else {
+				x = v[offset+k-1] + 1 // Deletion: right from diagonal k-1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, d), true
+			}
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+	}
+	return nil, false
+}
+
+// backtrack walks the trace of an edit of length edits from the end of both
+// texts back to their start
+func backtrack(a, b []string, trace [][]int, edits int) []op {
+	at := func(d, k int) int { return trace[d][k+d] }
+
+	var ops []op
+	x, y := len(a), len(b)
+	for d := edits; d > 0; d-- {
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(d-1, k-1) < at(d-1, k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(d-1, prevK)
+		prevY := prevX - prevK
+
+		// Matching lines after the edit
+		editX := prevX
+		if prevK == k-1 {
+			editX++
+		}
+		for x > editX {
+			x--
+			y--
+			ops = append(ops, op{opKeep, a[x]})
+		}
+		if prevK == k+1 {
+			ops = append(ops, op{opInsert, b[prevY]})
+		} else {
+			ops = append(ops, op{opDelete, a[prevX]})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, op{opKeep, a[x]})
+	}
+
+	// Reverse into reading order
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
@@ -0,0 +1,111 @@
+// Package disk reports information about the volumes files live on and how
+// files are stored on them
+package disk
+
+import (
+	"errors"
+	"io/fs"
+	"time"
+)
+
+// ErrNoCompression is returned by SetCompression where NTFS compression is
+// not available
+var ErrNoCompression = errors.New("NTFS compression is only available on Windows")
+
+// ErrNoLinkNames is returned by LinkNames where the system cannot list the
+// names of a file, so they have to be searched for
+var ErrNoLinkNames = errors.New("the names of hard links cannot be listed here")
+
+// ErrNoShares is returned by Shares where the shares of a server cannot be
+// listed
+var ErrNoShares = errors.New("network shares can only be listed on Windows")
+
+// ErrNoCreationTime is returned by SetCreationTime where files have no
+// creation time that can be set
+var ErrNoCreationTime = errors.New("creation times can only be set on Windows")
+
+// Free returns the number of bytes available to the current user on the
+// volume containing path
+func Free(path string) (uint64, error) {
+	return free(path)
+}
+
+// Usage returns the size of the volume containing path and the number of
+// bytes available to the current user on it
+func Usage(path string) (total, available uint64, err error) {
+	return usage(path)
+}
+
+// Drive is a volume the browser can switch to
+type Drive struct {
+	Root       string // Root path, e.g. C:\ or /mnt/data
+	Label      string // Volume label, or the device on Unix
+	Kind       string // "fixed", "removable", "network", "cd-rom" or "ram disk"
+	FileSystem string // e.g. NTFS or ext4
+	Ready      bool   // Whether there is a volume to browse, false for an empty CD drive
+}
+
+// Drives lists every drive letter in use, or the mounted device-backed
+// volumes on Unix, including drives without media that Volumes leaves out
+func Drives() []Drive {
+	return drives()
+}
+
+// Share is a folder a server shares on the network
+type Share struct {
+	Name   string // Share name, browsed as \\server\name
+	Remark string // Description given by the server, if any
+}
+
+// Shares lists the folders a server shares, e.g. for the server \\fileserver.
+// Administrative shares such as C$ are left out.
+func Shares(server string) ([]Share, error) {
+	return shares(server)
+}
+
+// Volumes returns the root paths of the mounted fixed, removable and network
+// volumes, e.g. C:\ and D:\
+func Volumes() []string {
+	return volumes()
+}
+
+// SizeOnDisk returns the bytes a file takes on its volume, which is less than
+// its size if it is compressed or sparse
+func SizeOnDisk(path string) (int64, error) {
+	return sizeOnDisk(path)
+}
+
+// SetCompression turns NTFS compression of a file or directory on or off. New
+// files created in a compressed directory are compressed.
+func SetCompression(path string, on bool) error {
+	return setCompression(path, on)
+}
+
+// LinkCount returns the number of hard links to a file, its names in all
+// directories
+func LinkCount(path string) (int, error) {
+	return linkCount(path)
+}
+
+// Device identifies the volume holding the entry described by info, if the
+// system reports it with the entry
+func Device(info fs.FileInfo) (uint64, bool) {
+	return device(info)
+}
+
+// LinkNames returns the paths of all hard links to a file on its volume,
+// including path itself
+func LinkNames(path string) ([]string, error) {
+	return linkNames(path)
+}
+
+// CreationTime returns when the entry described by info was created, if the
+// system reports it with the entry
+func CreationTime(info fs.FileInfo) (time.Time, bool) {
+	return creationTime(info)
+}
+
+// SetCreationTime changes when a file or directory was created
+func SetCreationTime(path string, t time.Time) error {
+	return setCreationTime(path, t)
+}
@@ -0,0 +1,117 @@
+//go:build !windows
+
+package disk
+
+import (
+	"io/fs"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func free(path string) (uint64, error) {
+	_, available, err := usage(path)
+	return available, err
+}
+
+func usage(path string) (uint64, uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	return uint64(st.Blocks) * uint64(st.Bsize), uint64(st.Bavail) * uint64(st.Bsize), nil
+}
+
+func volumes() []string {
+	// Device-backed mounts from /proc/mounts, where it exists
+	data, err := os.ReadFile("/proc/mounts")
+	if err != nil {
+		return []string{"/"}
+	}
+	var roots []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "/dev/") || seen[fields[0]] {
+			continue
+		}
+		seen[fields[0]] = true
+		roots = append(roots, fields[1])
+	}
+	if len(roots) == 0 {
+		roots = []string{"/"}
+	}
+	return roots
+}
+
+func drives() []Drive {
+	data, err := os.ReadFile("/proc/mounts")
+	if err != nil {
+		return []Drive{{Root: "/", Kind: "fixed", Ready: true}}
+	}
+	var list []Drive
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.HasPrefix(fields[0], "/dev/") || seen[fields[0]] {
+			continue
+		}
+		seen[fields[0]] = true
+		kind := "fixed"
+		if strings.HasPrefix(fields[1], "/media/") || strings.HasPrefix(fields[1], "/run/media/") {
+			kind = "removable"
+		}
+		// /proc/mounts escapes spaces in mount points as \040
+		root := strings.ReplaceAll(fields[1], `\040`, " ")
+		list = append(list, Drive{Root: root, Label: fields[0], Kind: kind, FileSystem: fields[2], Ready: true})
+	}
+	if len(list) == 0 {
+		list = []Drive{{Root: "/", Kind: "fixed", Ready: true}}
+	}
+	return list
+}
+
+func shares(string) ([]Share, error) {
+	return nil, ErrNoShares
+}
+
+func sizeOnDisk(path string) (int64, error) {
+	var st syscall.Stat_t
+	if err := syscall.Lstat(path, &st); err != nil {
+		return 0, err
+	}
+	// Blocks are counted in 512-byte units whatever the block size
+	return int64(st.Blocks) * 512, nil
+}
+
+func setCompression(string, bool) error {
+	return ErrNoCompression
+}
+
+func linkCount(path string) (int, error) {
+	var st syscall.Stat_t
+	if err := syscall.Lstat(path, &st); err != nil {
+		return 0, err
+	}
+	return int(st.Nlink), nil
+}
+
+func linkNames(string) ([]string, error) {
+	return nil, ErrNoLinkNames
+}
+
+func device(info fs.FileInfo) (uint64, bool) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Dev), true
+	}
+	return 0, false
+}
+
+func creationTime(fs.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func setCreationTime(string, time.Time) error {
+	return ErrNoCreationTime
+}
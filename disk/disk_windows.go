@@ -0,0 +1,305 @@
+//go:build windows
+
+package disk
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32                   = windows.NewLazySystemDLL("kernel32.dll")
+	netapi32                   = windows.NewLazySystemDLL("netapi32.dll")
+	procGetCompressedFileSizeW = kernel32.NewProc("GetCompressedFileSizeW")
+	procFindFirstFileNameW     = kernel32.NewProc("FindFirstFileNameW")
+	procFindNextFileNameW      = kernel32.NewProc("FindNextFileNameW")
+	procNetShareEnum           = netapi32.NewProc("NetShareEnum")
+)
+
+// longPathLimit is the length from which paths need the \\?\ prefix; as in
+// package os, it leaves room for the 8.3 name CreateDirectory appends
+const longPathLimit = 248
+
+// Share types of NetShareEnum
+const (
+	stypeMask    = 0xFF
+	stypeDisk    = 0
+	stypeSpecial = 0x80000000
+)
+
+// maxPreferredLength asks NetShareEnum for all entries at once
+const maxPreferredLength = 0xFFFFFFFF
+
+// shareInfo1 is SHARE_INFO_1
+type shareInfo1 struct {
+	netname *uint16
+	typ     uint32
+	remark  *uint16
+}
+
+// longPath adds the \\?\ prefix to long absolute paths, which the API calls
+// here otherwise reject past MAX_PATH. Package os does the same for its calls.
+func longPath(path string) string {
+	if len(path) < longPathLimit || strings.HasPrefix(path, `\\?\`) || strings.HasPrefix(path, `\\.\`) {
+		return path
+	}
+	if rest, ok := strings.CutPrefix(path, `\\`); ok {
+		return `\\?\UNC\` + rest
+	}
+	if filepath.IsAbs(path) {
+		return `\\?\` + path
+	}
+	return path
+}
+
+// invalidFileSize is returned by GetCompressedFileSizeW on failure
+const invalidFileSize = 0xFFFFFFFF
+
+// Formats of FSCTL_SET_COMPRESSION
+const (
+	compressionFormatNone    = 0
+	compressionFormatDefault = 1
+)
+
+func free(path string) (uint64, error) {
+	available, _, err := spaceOf(path)
+	return available, err
+}
+
+func usage(path string) (uint64, uint64, error) {
+	available, total, err := spaceOf(path)
+	return total, available, err
+}
+
+// spaceOf returns the bytes available to the current user and the size of the
+// volume containing path
+func spaceOf(path string) (uint64, uint64, error) {
+	p, err := windows.UTF16PtrFromString(longPath(path))
+	if err != nil {
+		return 0, 0, err
+	}
+	var available, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &available, &total, &totalFree); err != nil {
+		return 0, 0, err
+	}
+	return available, total, nil
+}
+
+func volumes() []string {
+	mask, err := windows.GetLogicalDrives()
+	if err != nil {
+		return nil
+	}
+	var roots []string
+	for i := 0; i < 26; i++ {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+		root := string(rune('A'+i)) + `:\`
+		p, _ := windows.UTF16PtrFromString(root)
+		// CD drives without a disc would only report errors
+		switch windows.GetDriveType(p) {
+		case windows.DRIVE_FIXED, windows.DRIVE_REMOVABLE, windows.DRIVE_REMOTE, windows.DRIVE_RAMDISK:
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// driveKinds names the results of GetDriveType
+var driveKinds = map[uint32]string{
+	windows.DRIVE_REMOVABLE: "removable",
+	windows.DRIVE_FIXED:     "fixed",
+	windows.DRIVE_REMOTE:    "network",
+	windows.DRIVE_CDROM:     "cd-rom",
+	windows.DRIVE_RAMDISK:   "ram disk",
+}
+
+func drives() []Drive {
+	mask, err := windows.GetLogicalDrives()
+	if err != nil {
+		return nil
+	}
+	var list []Drive
+	for i := 0; i < 26; i++ {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+		d := Drive{Root: string(rune('A'+i)) + `:\`}
+		p, _ := windows.UTF16PtrFromString(d.Root)
+		d.Kind = driveKinds[windows.GetDriveType(p)]
+		// Fails for drives without media and disconnected network drives
+		label := make([]uint16, windows.MAX_PATH+1)
+		fsName := make([]uint16, windows.MAX_PATH+1)
+		if windows.GetVolumeInformation(p, &label[0], uint32(len(label)), nil, nil, nil, &fsName[0], uint32(len(fsName))) == nil {
+			d.Label = windows.UTF16ToString(label)
+			d.FileSystem = windows.UTF16ToString(fsName)
+			d.Ready = true
+		}
+		list = append(list, d)
+	}
+	return list
+}
+
+func shares(server string) ([]Share, error) {
+	name, err := windows.UTF16PtrFromString(server)
+	if err != nil {
+		return nil, err
+	}
+	var buf *byte
+	var read, total, resume uint32
+	r, _, _ := procNetShareEnum.Call(uintptr(unsafe.Pointer(name)), 1, uintptr(unsafe.Pointer(&buf)),
+		maxPreferredLength, uintptr(unsafe.Pointer(&read)), uintptr(unsafe.Pointer(&total)), uintptr(unsafe.Pointer(&resume)))
+	if buf != nil {
+		defer windows.NetApiBufferFree(buf)
+	}
+	if r != 0 {
+		return nil, &fs.PathError{Op: "list shares", Path: server, Err: windows.Errno(r)}
+	}
+	infos := unsafe.Slice((*shareInfo1)(unsafe.Pointer(buf)), read)
+	var list []Share
+	for _, info := range infos {
+		// Administrative shares such as C$ are left out, as Explorer does
+		if info.typ&stypeMask != stypeDisk || info.typ&stypeSpecial != 0 {
+			continue
+		}
+		list = append(list, Share{
+			Name:   windows.UTF16PtrToString(info.netname),
+			Remark: windows.UTF16PtrToString(info.remark),
+		})
+	}
+	return list, nil
+}
+
+func sizeOnDisk(path string) (int64, error) {
+	p, err := windows.UTF16PtrFromString(longPath(path))
+	if err != nil {
+		return 0, err
+	}
+	var high uint32
+	low, _, callErr := procGetCompressedFileSizeW.Call(uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&high)))
+	// INVALID_FILE_SIZE is also a valid low half, so the error decides
+	if uint32(low) == invalidFileSize {
+		if errno, ok := callErr.(windows.Errno); ok && errno != 0 {
+			return 0, &fs.PathError{Op: "size on disk", Path: path, Err: errno}
+		}
+	}
+	return int64(high)<<32 | int64(uint32(low)), nil
+}
+
+func setCompression(path string, on bool) error {
+	p, err := windows.UTF16PtrFromString(longPath(path))
+	if err != nil {
+		return err
+	}
+	// Backup semantics open directories as well as files
+	h, err := windows.CreateFile(p, windows.GENERIC_READ|windows.GENERIC_WRITE,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE,
+		nil, windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return &fs.PathError{Op: "compress", Path: path, Err: err}
+	}
+	defer windows.CloseHandle(h)
+
+	format := uint16(compressionFormatNone)
+	if on {
+		format = compressionFormatDefault
+	}
+	var returned uint32
+	err = windows.DeviceIoControl(h, windows.FSCTL_SET_COMPRESSION,
+		(*byte)(unsafe.Pointer(&format)), uint32(unsafe.Sizeof(format)), nil, 0, &returned, nil)
+	if err != nil {
+		return &fs.PathError{Op: "compress", Path: path, Err: err}
+	}
+	return nil
+}
+
+func linkCount(path string) (int, error) {
+	p, err := windows.UTF16PtrFromString(longPath(path))
+	if err != nil {
+		return 0, err
+	}
+	// Opening for no access reads the information without sharing conflicts
+	h, err := windows.CreateFile(p, 0, windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE,
+		nil, windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return 0, &fs.PathError{Op: "open", Path: path, Err: err}
+	}
+	defer windows.CloseHandle(h)
+
+	var info windows.ByHandleFileInformation
+	if err := windows.GetFileInformationByHandle(h, &info); err != nil {
+		return 0, &fs.PathError{Op: "link count", Path: path, Err: err}
+	}
+	return int(info.NumberOfLinks), nil
+}
+
+func linkNames(path string) ([]string, error) {
+	p, err := windows.UTF16PtrFromString(longPath(path))
+	if err != nil {
+		return nil, err
+	}
+	// The names are relative to the root of the volume
+	volume := filepath.VolumeName(path)
+	buf := make([]uint16, windows.MAX_LONG_PATH)
+	size := uint32(len(buf))
+	h, _, callErr := procFindFirstFileNameW.Call(uintptr(unsafe.Pointer(p)), 0,
+		uintptr(unsafe.Pointer(&size)), uintptr(unsafe.Pointer(&buf[0])))
+	if windows.Handle(h) == windows.InvalidHandle {
+		return nil, &fs.PathError{Op: "link names", Path: path, Err: callErr}
+	}
+	defer windows.FindClose(windows.Handle(h))
+
+	var names []string
+	for {
+		names = append(names, volume+windows.UTF16ToString(buf[:size]))
+		size = uint32(len(buf))
+		ok, _, callErr := procFindNextFileNameW.Call(h, uintptr(unsafe.Pointer(&size)), uintptr(unsafe.Pointer(&buf[0])))
+		if ok != 0 {
+			continue
+		}
+		if errors.Is(callErr, windows.ERROR_HANDLE_EOF) {
+			return names, nil
+		}
+		return names, &fs.PathError{Op: "link names", Path: path, Err: callErr}
+	}
+}
+
+func device(fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+func creationTime(info fs.FileInfo) (time.Time, bool) {
+	if data, ok := info.Sys().(*syscall.Win32FileAttributeData); ok {
+		return time.Unix(0, data.CreationTime.Nanoseconds()), true
+	}
+	return time.Time{}, false
+}
+
+func setCreationTime(path string, t time.Time) error {
+	p, err := windows.UTF16PtrFromString(longPath(path))
+	if err != nil {
+		return err
+	}
+	h, err := windows.CreateFile(p, windows.FILE_WRITE_ATTRIBUTES,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE,
+		nil, windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return &fs.PathError{Op: "set creation time", Path: path, Err: err}
+	}
+	defer windows.CloseHandle(h)
+
+	// Nil times are left as they are
+	created := windows.NsecToFiletime(t.UnixNano())
+	if err := windows.SetFileTime(h, &created, nil, nil); err != nil {
+		return &fs.PathError{Op: "set creation time", Path: path, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+// Package eventlog reads entries from the Windows event logs
+package eventlog
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// Event levels as recorded by Windows
+const (
+	LevelAlways      = 0 // Recorded regardless of level, shown as information
+	LevelCritical    = 1
+	LevelError       = 2
+	LevelWarning     = 3
+	LevelInformation = 4
+	LevelVerbose     = 5
+)
+
+// Event is a single entry of an event log
+type Event struct {
+	Time     time.Time
+	Level    int
+	Provider string // Source of the event
+	ID       int
+	Computer string
+	Message  string // Formatted message, or the event data when the source has none
+	XML      string // Full event XML
+}
+
+// LevelName returns the name Event Viewer shows for a level
+func LevelName(level int) string {
+	switch level {
+	case LevelCritical:
+		return "Critical"
+	case LevelError:
+		return "Error"
+	case LevelWarning:
+		return "Warning"
+	case LevelVerbose:
+		return "Verbose"
+	default:
+		return "Information"
+	}
+}
+
+// Read returns up to max events of a channel (such as "Application" or
+// "System") recorded after since, newest first. A zero since reads from the
+// start of the log.
+func Read(channel string, since time.Time, max int) ([]Event, error) {
+	return read(channel, since, max)
+}
+
+// timeQuery returns the XPath query selecting events recorded after since
+func timeQuery(since time.Time) string {
+	if since.IsZero() {
+		return "*"
+	}
+	return "*[System[TimeCreated[@SystemTime>='" + since.UTC().Format("2006-01-02T15:04:05.000Z") + "']]]"
+}
+
+// eventXML is the part of the event schema shown in the list
+type eventXML struct {
+	System struct {
+		Provider struct {
+			Name string `xml:"Name,attr"`
+		}
+		EventID     int
+		Level       int
+		TimeCreated struct {
+			SystemTime string `xml:"SystemTime,attr"`
+		}
+		Computer string
+	}
+	EventData struct {
+		Data []string `xml:"Data"`
+	}
+}
+
+// parseEvent fills an event from its XML, using the event data as the message
+func parseEvent(data string) (Event, error) {
+	var parsed eventXML
+	if err := xml.Unmarshal([]byte(data), &parsed); err != nil {
+		return Event{}, err
+	}
+
+	ev := Event{
+		Level:    parsed.System.Level,
+		Provider: parsed.System.Provider.Name,
+		ID:       parsed.System.EventID,
+		Computer: parsed.System.Computer,
+		XML:      data,
+	}
+	if t, err := time.Parse(time.RFC3339Nano, parsed.System.TimeCreated.SystemTime); err == nil {
+		ev.Time = t.Local()
+	}
+	var values []string
+	for _, value := range parsed.EventData.Data {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	ev.Message = strings.Join(values, "; ")
+	return ev, nil
+}
+
+// IndentXML formats XML with one element per line, returning the input
+// unchanged if it cannot be parsed
+func IndentXML(data string) string {
+	decoder := xml.NewDecoder(strings.NewReader(data))
+	var buf bytes.Buffer
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	for {
+		tok, err := decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return data
+		}
+		// Whitespace between elements is replaced by the indentation
+		if text, ok := tok.(xml.CharData); ok && len(bytes.TrimSpace(text)) == 0 {
+			continue
+		}
+		if err := encoder.EncodeToken(tok); err != nil {
+			return data
+		}
+	}
+	if err := encoder.Flush(); err != nil {
+		return data
+	}
+	return buf.String()
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package eventlog
+
+import (
+	"errors"
+	"time"
+)
+
+func read(channel string, since time.Time, max int) ([]Event, error) {
+	return nil, errors.New("event logs are only available on Windows")
+}
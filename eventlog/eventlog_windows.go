@@ -0,0 +1,161 @@
+//go:build windows
+
+package eventlog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	wevtapi                      = windows.NewLazySystemDLL("wevtapi.dll")
+	procEvtQuery                 = wevtapi.NewProc("EvtQuery")
+	procEvtNext                  = wevtapi.NewProc("EvtNext")
+	procEvtRender                = wevtapi.NewProc("EvtRender")
+	procEvtFormatMessage         = wevtapi.NewProc("EvtFormatMessage")
+	procEvtOpenPublisherMetadata = wevtapi.NewProc("EvtOpenPublisherMetadata")
+	procEvtClose                 = wevtapi.NewProc("EvtClose")
+)
+
+const (
+	evtQueryChannelPath      = 0x1
+	evtQueryReverseDirection = 0x200
+	evtRenderEventXML        = 1
+	evtFormatMessageEvent    = 1
+
+	// batchSize is the number of event handles fetched per EvtNext call
+	batchSize = 64
+)
+
+func read(channel string, since time.Time, max int) ([]Event, error) {
+	if err := wevtapi.Load(); err != nil {
+		return nil, err
+	}
+	path, err := windows.UTF16PtrFromString(channel)
+	if err != nil {
+		return nil, err
+	}
+	query, err := windows.UTF16PtrFromString(timeQuery(since))
+	if err != nil {
+		return nil, err
+	}
+
+	results, _, err := procEvtQuery.Call(0, uintptr(unsafe.Pointer(path)), uintptr(unsafe.Pointer(query)),
+		evtQueryChannelPath|evtQueryReverseDirection)
+	if results == 0 {
+		return nil, fmt.Errorf("%s: %w", channel, err)
+	}
+	defer evtClose(results)
+
+	// Message templates are looked up once per source
+	publishers := make(map[string]uintptr)
+	defer func() {
+		for _, publisher := range publishers {
+			if publisher != 0 {
+				evtClose(publisher)
+			}
+		}
+	}()
+
+	var events []Event
+	handles := make([]uintptr, batchSize)
+	for len(events) < max {
+		var returned uint32
+		ok, _, err := procEvtNext.Call(results, batchSize, uintptr(unsafe.Pointer(&handles[0])),
+			windows.INFINITE, 0, uintptr(unsafe.Pointer(&returned)))
+		if ok == 0 {
+			if errors.Is(err, windows.ERROR_NO_MORE_ITEMS) {
+				break
+			}
+			return events, err
+		}
+		for _, handle := range handles[:returned] {
+			if len(events) < max {
+				if ev, err := renderEvent(handle, publishers); err == nil {
+					events = append(events, ev)
+				}
+			}
+			evtClose(handle)
+		}
+	}
+	return events, nil
+}
+
+// renderEvent reads the XML and formatted message of an event
+func renderEvent(handle uintptr, publishers map[string]uintptr) (Event, error) {
+	data, err := renderXML(handle)
+	if err != nil {
+		return Event{}, err
+	}
+	ev, err := parseEvent(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	publisher, ok := publishers[ev.Provider]
+	if !ok {
+		publisher = openPublisher(ev.Provider)
+		publishers[ev.Provider] = publisher
+	}
+	if publisher != 0 {
+		if message := strings.TrimSpace(formatMessage(publisher, handle)); message != "" {
+			ev.Message = message
+		}
+	}
+	return ev, nil
+}
+
+// renderXML renders an event as XML, growing the buffer as needed
+func renderXML(handle uintptr) (string, error) {
+	buf := make([]uint16, 4096)
+	for {
+		var used, properties uint32
+		ok, _, err := procEvtRender.Call(0, handle, evtRenderEventXML, uintptr(len(buf)*2),
+			uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&used)), uintptr(unsafe.Pointer(&properties)))
+		if ok != 0 {
+			return windows.UTF16ToString(buf[:used/2]), nil
+		}
+		if !errors.Is(err, windows.ERROR_INSUFFICIENT_BUFFER) {
+			return "", err
+		}
+		buf = make([]uint16, used/2+1)
+	}
+}
+
+// openPublisher opens the message templates of an event source, returning 0 if
+// the source is not registered on this machine
+func openPublisher(name string) uintptr {
+	p, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return 0
+	}
+	publisher, _, _ := procEvtOpenPublisherMetadata.Call(0, uintptr(unsafe.Pointer(p)), 0, 0, 0)
+	return publisher
+}
+
+// formatMessage returns the message text of an event, or "" if it has none
+func formatMessage(publisher, handle uintptr) string {
+	buf := make([]uint16, 1024)
+	for {
+		var used uint32
+		ok, _, err := procEvtFormatMessage.Call(publisher, handle, 0, 0, 0, evtFormatMessageEvent,
+			uintptr(len(buf)), uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&used)))
+		if ok != 0 {
+			return windows.UTF16ToString(buf[:used])
+		}
+		if !errors.Is(err, windows.ERROR_INSUFFICIENT_BUFFER) || used == 0 {
+			return ""
+		}
+		buf = make([]uint16, used)
+	}
+}
+
+// evtClose releases an event log handle
+func evtClose(handle uintptr) {
+	procEvtClose.Call(handle)
+}
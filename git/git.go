@@ -0,0 +1,208 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// fieldSep separates fields in formatted git output
+const fieldSep = "\x1f"
+
+// Commit represents a single line of commit history output
+type Commit struct {
+	Graph   string // Graph characters drawn by git log --graph
+	Hash    string // Abbreviated commit hash (empty for graph-only lines)
+	Author  string
+	Date    string
+	Subject string
+}
+
+// run executes git with the given arguments inside dir and returns stdout
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		// The last line of git's stderr carries the actual error
+		msg := strings.TrimSpace(stderr.String())
+		if i := strings.LastIndex(msg, "\n"); i >= 0 {
+			msg = strings.TrimSpace(msg[i+1:])
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+
+	return stdout.String(), nil
+}
+
+// Root returns the top-level directory of the repository containing dir
+func Root(dir string) (string, error) {
+	out, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Branch returns the branch checked out in the repository containing dir, or the
+// abbreviated commit hash when HEAD is detached
+func Branch(dir string) (string, error) {
+	if out, err := run(dir, "symbolic-ref", "--short", "-q", "HEAD"); err == nil {
+		return strings.TrimSpace(out), nil
+	}
+	out, err := run(dir, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Log returns the commit history of the repository containing dir.
+// If path is not empty only commits touching that path are returned.
+func Log(dir, path string, limit int) ([]Commit, error) {
+	args := []string{
+		"log", "--graph", "--date=short",
+		"--format=" + fieldSep + "%h" + fieldSep + "%an" + fieldSep + "%ad" + fieldSep + "%s",
+	}
+	if limit > 0 {
+		args = append(args, fmt.Sprintf("-n%d", limit))
+	}
+	if path != "" {
+		args = append(args, "--", path)
+	}
+
+	out, err := run(dir, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+
+		parts := strings.Split(line, fieldSep)
+		if len(parts) < 5 {
+			// Graph-only line connecting commits
+			commits = append(commits, Commit{Graph: line})
+			continue
+		}
+
+		commits = append(commits, Commit{
+			Graph:   parts[0],
+			Hash:    parts[1],
+			Author:  parts[2],
+			Date:    parts[3],
+			Subject: strings.Join(parts[4:], fieldSep),
+		})
+	}
+
+	return commits, nil
+}
+
+// Show returns the commit message and diff for the given commit.
+// If path is not empty the diff is restricted to that path.
+func Show(dir, hash, path string) (string, error) {
+	args := []string{"show", "--stat", "--patch", "--no-color", hash}
+	if path != "" {
+		args = append(args, "--", path)
+	}
+	return run(dir, args...)
+}
+
+// StatusEntry is a single path reported by git status
+type StatusEntry struct {
+	Index    byte   // Status in the index (staged)
+	Worktree byte   // Status in the working tree (unstaged)
+	Path     string // Path relative to the repository root
+	OrigPath string // Original path for renames and copies
+}
+
+// IsStaged reports whether the entry has changes in the index
+func (e StatusEntry) IsStaged() bool {
+	return e.Index != ' ' && e.Index != '?' && e.Index != '!'
+}
+
+// IsUnstaged reports whether the entry has changes in the working tree
+func (e StatusEntry) IsUnstaged() bool {
+	return e.Worktree != ' '
+}
+
+// Status returns the working tree status of the repository at root
+func Status(root string) ([]StatusEntry, error) {
+	out, err := run(root, "status", "--porcelain=v1", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []StatusEntry
+	fields := strings.Split(out, "\x00")
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if len(field) < 4 {
+			continue
+		}
+
+		entry := StatusEntry{
+			Index:    field[0],
+			Worktree: field[1],
+			Path:     field[3:],
+		}
+
+		// Renames and copies are followed by the original path
+		if (entry.Index == 'R' || entry.Index == 'C') && i+1 < len(fields) {
+			i++
+			entry.OrigPath = fields[i]
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Stage adds the given paths to the index
+func Stage(root string, paths ...string) error {
+	_, err := run(root, append([]string{"add", "--"}, paths...)...)
+	return err
+}
+
+// Unstage removes the given paths from the index, keeping working tree changes.
+// Before the first commit there is no HEAD to restore from, so the paths are
+// dropped from the index instead.
+func Unstage(root string, paths ...string) error {
+	args := []string{"restore", "--staged", "--"}
+	if _, err := run(root, "rev-parse", "--verify", "-q", "HEAD"); err != nil {
+		args = []string{"rm", "--cached", "-r", "-f", "-q", "--"}
+	}
+	_, err := run(root, append(args, paths...)...)
+	return err
+}
+
+// CommitStaged records the staged changes with the given message
+func CommitStaged(root, message string) (string, error) {
+	out, err := run(root, "commit", "-m", message)
+	if err != nil {
+		return "", err
+	}
+	// First line is "[branch hash] subject"
+	return strings.TrimSpace(strings.SplitN(out, "\n", 2)[0]), nil
+}
+
+// Diff returns the diff of path against the index, or of the index against HEAD if staged
+func Diff(root, path string, staged bool) (string, error) {
+	args := []string{"diff", "--no-color"}
+	if staged {
+		args = append(args, "--cached")
+	}
+	return run(root, append(args, "--", path)...)
+}
@@ -0,0 +1,60 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/HolyStarGazer/windows-tui-go/uitest"
+)
+
+// TestUnstageWithoutCommits checks Unstage works in a repository with no
+// HEAD yet, keeping the files and their changes in the working tree
+func TestUnstageWithoutCommits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	uitest.IsolateConfig(t)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	root := t.TempDir()
+	if _, err := run(root, "init", "-q"); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{"notes.txt": "notes\n", "src/main.go": "package main\n"}
+	for name, data := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Stage(root, "notes.txt", "src"); err != nil {
+		t.Fatal(err)
+	}
+	// A change made after staging must survive unstaging
+	if err := os.WriteFile(filepath.Join(root, "notes.txt"), []byte("more notes\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Unstage(root, "notes.txt", "src"); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Status(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(files) {
+		t.Errorf("status lists %d entries, want %d: %+v", len(entries), len(files), entries)
+	}
+	for _, entry := range entries {
+		if entry.Index != '?' {
+			t.Errorf("%s is still staged: %c%c", entry.Path, entry.Index, entry.Worktree)
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "notes.txt")); err != nil || string(data) != "more notes\n" {
+		t.Errorf("notes.txt holds %q: %v", data, err)
+	}
+}
@@ -0,0 +1,9 @@
+// Package launch opens URLs and files with the program the system associates
+// with them, such as the default web browser
+package launch
+
+// Open hands target, a URL or a file path, to its default program without
+// waiting for it
+func Open(target string) error {
+	return open(target)
+}
@@ -0,0 +1,27 @@
+//go:build !windows
+
+package launch
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+func open(target string) error {
+	// Linux has an unrelated open command (openvt), so only macOS uses it
+	name := "xdg-open"
+	if runtime.GOOS == "darwin" {
+		name = "open"
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return fmt.Errorf("%s is not installed", name)
+	}
+	cmd := exec.Command(name, target)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Reap the opener once it hands the target over
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package launch
+
+import "golang.org/x/sys/windows"
+
+func open(target string) error {
+	verb, err := windows.UTF16PtrFromString("open")
+	if err != nil {
+		return err
+	}
+	file, err := windows.UTF16PtrFromString(target)
+	if err != nil {
+		return err
+	}
+	return windows.ShellExecute(0, verb, file, nil, nil, windows.SW_SHOWNORMAL)
+}
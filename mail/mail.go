@@ -0,0 +1,28 @@
+// Package mail opens new messages in the default mail program, with files
+// attached where the system offers a way to attach them
+package mail
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/HolyStarGazer/windows-tui-go/launch"
+)
+
+// ErrNoAttachments is returned by Draft when no mail program can be handed
+// attachments, so a Compose draft is the most that can be opened
+var ErrNoAttachments = errors.New("no mail program accepts attachments")
+
+// Draft opens a new message with a subject and the files attached, for the
+// user to address and send. On Windows it may wait until the message is sent
+// or discarded.
+func Draft(subject string, files []string) error {
+	return draft(subject, files)
+}
+
+// Compose opens a new message with a subject and body through a mailto: link,
+// which cannot carry attachments
+func Compose(subject, body string) error {
+	link := "mailto:?subject=" + url.PathEscape(subject) + "&body=" + url.PathEscape(body)
+	return launch.Open(link)
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package mail
+
+import "os/exec"
+
+func draft(subject string, files []string) error {
+	// xdg-email hands attachments to the desktop's mail program
+	if _, err := exec.LookPath("xdg-email"); err != nil {
+		return ErrNoAttachments
+	}
+	args := []string{"--subject", subject}
+	for _, file := range files {
+		args = append(args, "--attach", file)
+	}
+	cmd := exec.Command("xdg-email", args...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Reap xdg-email once it hands the message over
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,93 @@
+//go:build windows
+
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// MAPISendMailW flags and results
+const (
+	mapiLogonUI   = 0x1
+	mapiDialog    = 0x8
+	mapiUserAbort = 1
+)
+
+var (
+	mapi32            = windows.NewLazySystemDLL("mapi32.dll")
+	procMAPISendMailW = mapi32.NewProc("MAPISendMailW")
+)
+
+// errDiscarded is returned when the user closes the draft without sending it
+var errDiscarded = errors.New("the message was not sent")
+
+// mapiMessage is MapiMessageW
+type mapiMessage struct {
+	reserved       uint32
+	subject        *uint16
+	noteText       *uint16
+	messageType    *uint16
+	dateReceived   *uint16
+	conversationID *uint16
+	flags          uint32
+	originator     uintptr
+	recipCount     uint32
+	recips         uintptr
+	fileCount      uint32
+	files          *mapiFileDesc
+}
+
+// mapiFileDesc is MapiFileDescW
+type mapiFileDesc struct {
+	reserved uint32
+	flags    uint32
+	position uint32
+	pathName *uint16
+	fileName *uint16
+	fileType uintptr
+}
+
+func draft(subject string, files []string) error {
+	// Simple MAPI has taken Unicode strings since Windows 8
+	if procMAPISendMailW.Find() != nil {
+		return ErrNoAttachments
+	}
+	msg := mapiMessage{fileCount: uint32(len(files))}
+	var err error
+	if msg.subject, err = windows.UTF16PtrFromString(subject); err != nil {
+		return err
+	}
+	descs := make([]mapiFileDesc, len(files))
+	for i, file := range files {
+		descs[i].position = 0xFFFFFFFF // Not placed in the body text
+		if descs[i].pathName, err = windows.UTF16PtrFromString(file); err != nil {
+			return err
+		}
+		if descs[i].fileName, err = windows.UTF16PtrFromString(filepath.Base(file)); err != nil {
+			return err
+		}
+	}
+	if len(descs) > 0 {
+		msg.files = &descs[0]
+	}
+
+	// The mail program's dialog belongs to the calling thread
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	r, _, _ := procMAPISendMailW.Call(0, 0, uintptr(unsafe.Pointer(&msg)), mapiLogonUI|mapiDialog, 0)
+	runtime.KeepAlive(descs)
+	switch r {
+	case 0:
+		return nil
+	case mapiUserAbort:
+		return errDiscarded
+	}
+	// No mail program is registered for Simple MAPI, or it failed to start
+	return fmt.Errorf("%w (MAPI error %d)", ErrNoAttachments, r)
+}
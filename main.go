@@ -1,17 +1,125 @@
 package main
 
 import (
-	"fmt" // Package for formatting I/O
-	"os"  // Package for OS functions
+	"bufio"
+	"flag" // Package for command-line flag parsing
+	"fmt"  // Package for formatting I/O
+	"os"   // Package for OS functions
+	"path/filepath"
+	"strings"
 
+	"github.com/HolyStarGazer/windows-tui-go/config"
 	"github.com/HolyStarGazer/windows-tui-go/ui"
 	tea "github.com/charmbracelet/bubbletea" // Package for building terminal user interfaces
+	"github.com/charmbracelet/x/term"
 )
 
 func main() {
-	p := tea.NewProgram(ui.NewModel(), tea.WithAltScreen())
+	if status, ok := runCommand(os.Args[1:]); ok {
+		os.Exit(status)
+	}
+
+	lang := flag.String("lang", "", "force the syntax highlighting language (e.g. go, json, diff)")
+	profile := flag.Bool("profile", false, "serve pprof on localhost and show frame times in a debug overlay")
+	share := flag.String("share", "", "serve a read-only live view of the screen on this address (e.g. 7000 for this machine only, 0.0.0.0:7000 for the network) to telnet, nc or a browser")
+	web := flag.String("web", "", "serve a read-only web page for browsing and viewing files on this address (e.g. 8080 for this machine only, 0.0.0.0:8080 for the network) instead of starting the TUI")
+	hashPassword := flag.Bool("hash-password", false, "read a password from standard input and print the lock_password_hash setting for it")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] [path | -]\n\n", filepath.Base(os.Args[0]))
+		fmt.Fprintln(os.Stderr, "  path  directory to browse or file to view")
+		fmt.Fprintln(os.Stderr, "  -     view standard input (e.g. somecommand | wintui -)")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintf(os.Stderr, "       %s ls [--json] [path]\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "       %s search [--json] <text> [path]\n", filepath.Base(os.Args[0]))
+		fmt.Fprintln(os.Stderr, "  print a listing or the lines containing a text, as text or JSON (-h after the command for its flags)")
+		fmt.Fprintln(os.Stderr)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *hashPassword {
+		hash, err := readPasswordHash()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(hash)
+		return
+	}
+
+	if *profile {
+		addr, err := startProfiling()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		ui.EnableProfiling(addr)
+	}
+
+	if *web != "" {
+		if err := serveWeb(*web, flag.Arg(0)); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *share != "" {
+		if err := ui.EnableSharing(*share); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	model, options, err := initialModel(flag.Arg(0), *lang)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	p := tea.NewProgram(model, append(options, tea.WithAltScreen(), tea.WithFPS(ui.MaxFPS()))...)
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// readPasswordHash hashes the first line of standard input for the
+// lock_password_hash setting, reading it without echo from a console
+func readPasswordHash() (string, error) {
+	if term.IsTerminal(os.Stdin.Fd()) {
+		fmt.Fprint(os.Stderr, "Password: ")
+		password, _ := term.ReadPassword(os.Stdin.Fd())
+		fmt.Fprintln(os.Stderr)
+		return config.HashPassword(string(password))
+	}
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return config.HashPassword(strings.TrimRight(line, "\r\n"))
+}
+
+// initialModel builds the starting model from the positional argument
+func initialModel(arg, lang string) (tea.Model, []tea.ProgramOption, error) {
+	switch arg {
+	case "":
+		return ui.NewModel(), nil, nil
+
+	case "-":
+		model, err := ui.NewStreamPagerModel("stdin", os.Stdin, lang)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Stdin is the pipe, so read keys from the console instead
+		return model, []tea.ProgramOption{tea.WithInputTTY()}, nil
+	}
+
+	info, err := os.Stat(arg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		return ui.NewModelAt(arg), nil, nil
+	}
+
+	model, err := ui.NewFileModel(arg, lang)
+	return model, nil, err
+}
@@ -0,0 +1,61 @@
+package media
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// maxCommentSize is the most of a Vorbis comment block read
+const maxCommentSize = 1 << 20
+
+// probeFLAC reads the stream information and Vorbis comments of a FLAC file
+// from its metadata blocks
+func probeFLAC(r io.ReaderAt, size int64) (Info, error) {
+	info := Info{Format: "FLAC", AudioCodec: "FLAC"}
+	off := int64(4)
+	for off+4 <= size {
+		header, err := readAt(r, off, 4)
+		if err != nil {
+			return info, err
+		}
+		if len(header) < 4 {
+			break
+		}
+		last, kind := header[0]&0x80 != 0, header[0]&0x7F
+		length := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+		off += 4
+		switch kind {
+		case 0:
+			block, err := readAt(r, off, 18)
+			if err != nil {
+				return info, err
+			}
+			if len(block) == 18 {
+				info.readStreamInfo(block)
+			}
+		case 4:
+			block, err := readAt(r, off, int(min(length, maxCommentSize)))
+			if err != nil {
+				return info, err
+			}
+			info.readVorbisComments(block)
+		}
+		off += length
+		if last {
+			break
+		}
+	}
+	return info, nil
+}
+
+// readStreamInfo reads the sample rate, channels and length from a FLAC
+// STREAMINFO block
+func (info *Info) readStreamInfo(block []byte) {
+	// Bits from byte 10: 20 of sample rate, 3 of channels less one, 5 of bits
+	// per sample less one and 36 of total samples
+	bits := binary.BigEndian.Uint64(block[10:18])
+	info.SampleRate = int(bits >> 44)
+	info.Channels = int(bits>>41&7) + 1
+	samples := bits & (1<<36 - 1)
+	info.Duration = seconds(samples, uint64(info.SampleRate))
+}
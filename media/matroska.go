@@ -0,0 +1,290 @@
+package media
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"strings"
+)
+
+// Matroska element IDs, with their length markers
+const (
+	mkvEBML       = 0x1A45DFA3
+	mkvDocType    = 0x4282
+	mkvSegment    = 0x18538067
+	mkvInfo       = 0x1549A966
+	mkvTimescale  = 0x2AD7B1
+	mkvDuration   = 0x4489
+	mkvTitle      = 0x7BA9
+	mkvTracks     = 0x1654AE6B
+	mkvTrackEntry = 0xAE
+	mkvTrackType  = 0x83
+	mkvCodecID    = 0x86
+	mkvVideo      = 0xE0
+	mkvWidth      = 0xB0
+	mkvHeight     = 0xBA
+	mkvAudio      = 0xE1
+	mkvRate       = 0xB5
+	mkvChannels   = 0x9F
+	mkvTags       = 0x1254C367
+	mkvTag        = 0x7373
+	mkvSimpleTag  = 0x67C8
+	mkvTagName    = 0x45A3
+	mkvTagString  = 0x4487
+)
+
+// mkvElement is an EBML element: its ID and where its data lies. Size is -1
+// for elements of unknown size, which run to the end of their parent.
+type mkvElement struct {
+	ID    uint32
+	Start int64
+	Size  int64
+}
+
+// mkvCodecs names the codec IDs of Matroska tracks, matched by prefix
+var mkvCodecs = []struct{ Prefix, Name string }{
+	{"V_MPEG4/ISO/AVC", "H.264"}, {"V_MPEGH/ISO/HEVC", "H.265 (HEVC)"},
+	{"V_AV1", "AV1"}, {"V_VP8", "VP8"}, {"V_VP9", "VP9"},
+	{"V_MPEG4/ISO", "MPEG-4 Visual"}, {"V_MPEG2", "MPEG-2"}, {"V_THEORA", "Theora"},
+	{"V_MS/VFW/FOURCC", "VfW"}, {"A_AAC", "AAC"}, {"A_OPUS", "Opus"},
+	{"A_VORBIS", "Vorbis"}, {"A_EAC3", "E-AC-3"}, {"A_AC3", "AC-3"},
+	{"A_DTS", "DTS"}, {"A_FLAC", "FLAC"}, {"A_MPEG/L3", "MP3"},
+	{"A_MPEG/L2", "MP2"}, {"A_PCM", "PCM"}, {"A_TRUEHD", "TrueHD"},
+}
+
+// mkvTagNames maps the names of Matroska simple tags to tag names
+var mkvTagNames = map[string]string{
+	"TITLE": "Title", "ARTIST": "Artist", "ALBUM": "Album",
+	"DATE_RELEASED": "Year", "DATE_RECORDED": "Year", "PART_NUMBER": "Track",
+	"GENRE": "Genre", "COMPOSER": "Composer", "COMMENT": "Comment",
+}
+
+// maxMKVElements is the most top-level elements of a segment looked at,
+// skipping clusters, for tags written at the end
+const maxMKVElements = 20000
+
+// readVint reads an EBML variable-length integer at off, returning it with
+// its length in bytes. The length marker is kept when id is set, as element
+// IDs are written with it.
+func readVint(r io.ReaderAt, off int64, id bool) (uint64, int, bool) {
+	buf, err := readAt(r, off, 8)
+	if err != nil || len(buf) == 0 || buf[0] == 0 {
+		return 0, 0, false
+	}
+	length := 1
+	for buf[0]&(0x80>>(length-1)) == 0 {
+		length++
+	}
+	if length > len(buf) {
+		return 0, 0, false
+	}
+	value := uint64(buf[0])
+	if !id {
+		value &= uint64(0xFF >> length)
+	}
+	for _, b := range buf[1:length] {
+		value = value<<8 | uint64(b)
+	}
+	return value, length, true
+}
+
+// readMKVElement reads the header of the element at off
+func readMKVElement(r io.ReaderAt, off int64) (mkvElement, bool) {
+	id, idLen, ok := readVint(r, off, true)
+	if !ok || idLen > 4 {
+		return mkvElement{}, false
+	}
+	size, sizeLen, ok := readVint(r, off+int64(idLen), false)
+	if !ok {
+		return mkvElement{}, false
+	}
+	e := mkvElement{ID: uint32(id), Start: off + int64(idLen+sizeLen), Size: int64(size)}
+	if size == 1<<(7*sizeLen)-1 {
+		// All ones: unknown size
+		e.Size = -1
+	}
+	return e, true
+}
+
+// mkvChildren calls fn with each element between start and end, stopping at
+// one of unknown size after calling fn with it
+func mkvChildren(r io.ReaderAt, start, end int64, limit int, fn func(mkvElement)) {
+	for off, n := start, 0; off < end && n < limit; n++ {
+		e, ok := readMKVElement(r, off)
+		if !ok {
+			return
+		}
+		fn(e)
+		if e.Size < 0 {
+			return
+		}
+		off = e.Start + e.Size
+	}
+}
+
+// mkvData reads the data of a small element
+func mkvData(r io.ReaderAt, e mkvElement) []byte {
+	if e.Size < 0 || e.Size > 4096 {
+		return nil
+	}
+	data, _ := readAt(r, e.Start, int(e.Size))
+	return data
+}
+
+// mkvUint decodes an unsigned integer element
+func mkvUint(data []byte) uint64 {
+	var value uint64
+	for _, b := range data {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
+
+// mkvFloat decodes a float element, of four or eight bytes
+func mkvFloat(data []byte) float64 {
+	switch len(data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data))
+	}
+	return 0
+}
+
+// probeMatroska reads the segment information, tracks and tags of a
+// Matroska or WebM file
+func probeMatroska(r io.ReaderAt, size int64) (Info, error) {
+	info := Info{Format: "Matroska"}
+	mkvChildren(r, 0, size, 16, func(e mkvElement) {
+		end := size
+		if e.Size >= 0 {
+			end = min(e.Start+e.Size, size)
+		}
+		switch e.ID {
+		case mkvEBML:
+			mkvChildren(r, e.Start, end, 64, func(c mkvElement) {
+				if c.ID == mkvDocType && string(mkvData(r, c)) == "webm" {
+					info.Format = "WebM"
+				}
+			})
+		case mkvSegment:
+			info.readSegment(r, e.Start, end)
+		}
+	})
+	return info, nil
+}
+
+// readSegment reads the Info, Tracks and Tags elements of a segment,
+// skipping its clusters
+func (info *Info) readSegment(r io.ReaderAt, start, end int64) {
+	scale := uint64(1_000_000)
+	var duration float64
+	mkvChildren(r, start, end, maxMKVElements, func(e mkvElement) {
+		if e.Size < 0 {
+			// A cluster of unknown size cannot be skipped, and tags rarely
+			// follow one
+			return
+		}
+		elementEnd := e.Start + e.Size
+		switch e.ID {
+		case mkvInfo:
+			mkvChildren(r, e.Start, elementEnd, 256, func(c mkvElement) {
+				switch c.ID {
+				case mkvTimescale:
+					scale = mkvUint(mkvData(r, c))
+				case mkvDuration:
+					duration = mkvFloat(mkvData(r, c))
+				case mkvTitle:
+					info.setTag("Title", string(mkvData(r, c)))
+				}
+			})
+		case mkvTracks:
+			mkvChildren(r, e.Start, elementEnd, 256, func(c mkvElement) {
+				if c.ID == mkvTrackEntry && c.Size >= 0 {
+					info.readTrackEntry(r, c)
+				}
+			})
+		case mkvTags:
+			mkvChildren(r, e.Start, elementEnd, 1024, func(c mkvElement) {
+				if c.ID == mkvTag && c.Size >= 0 {
+					info.readMKVTag(r, c)
+				}
+			})
+		}
+	})
+	info.Duration = seconds(uint64(duration*float64(scale)), 1_000_000_000)
+}
+
+// readTrackEntry reads the codec of a track, with the picture size of a
+// video track and the rate and channels of an audio track. The first track
+// of each kind is kept.
+func (info *Info) readTrackEntry(r io.ReaderAt, entry mkvElement) {
+	var kind uint64
+	var codec string
+	var width, height, channels int
+	var rate float64
+	mkvChildren(r, entry.Start, entry.Start+entry.Size, 256, func(c mkvElement) {
+		switch c.ID {
+		case mkvTrackType:
+			kind = mkvUint(mkvData(r, c))
+		case mkvCodecID:
+			codec = mkvCodecName(string(mkvData(r, c)))
+		case mkvVideo:
+			mkvChildren(r, c.Start, c.Start+c.Size, 64, func(v mkvElement) {
+				switch v.ID {
+				case mkvWidth:
+					width = int(mkvUint(mkvData(r, v)))
+				case mkvHeight:
+					height = int(mkvUint(mkvData(r, v)))
+				}
+			})
+		case mkvAudio:
+			channels = 1
+			mkvChildren(r, c.Start, c.Start+c.Size, 64, func(a mkvElement) {
+				switch a.ID {
+				case mkvRate:
+					rate = mkvFloat(mkvData(r, a))
+				case mkvChannels:
+					channels = int(mkvUint(mkvData(r, a)))
+				}
+			})
+		}
+	})
+	switch {
+	case kind == 1 && info.VideoCodec == "":
+		info.VideoCodec, info.Width, info.Height = codec, width, height
+	case kind == 2 && info.AudioCodec == "":
+		info.AudioCodec, info.SampleRate, info.Channels = codec, int(rate), channels
+	}
+}
+
+// readMKVTag reads the simple tags of a Tag element
+func (info *Info) readMKVTag(r io.ReaderAt, tag mkvElement) {
+	mkvChildren(r, tag.Start, tag.Start+tag.Size, 1024, func(c mkvElement) {
+		if c.ID != mkvSimpleTag || c.Size < 0 {
+			return
+		}
+		var name, value string
+		mkvChildren(r, c.Start, c.Start+c.Size, 64, func(s mkvElement) {
+			switch s.ID {
+			case mkvTagName:
+				name = string(mkvData(r, s))
+			case mkvTagString:
+				value = string(mkvData(r, s))
+			}
+		})
+		if tagName, ok := mkvTagNames[strings.ToUpper(name)]; ok {
+			info.setTag(tagName, value)
+		}
+	})
+}
+
+// mkvCodecName names a Matroska codec ID, or returns it
+func mkvCodecName(id string) string {
+	for _, codec := range mkvCodecs {
+		if strings.HasPrefix(id, codec.Prefix) {
+			return codec.Name
+		}
+	}
+	return id
+}
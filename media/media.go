@@ -0,0 +1,225 @@
+// Package media reads the length, codecs, picture size and tags of audio and
+// video files from their headers, without decoding them. MP3, FLAC, Ogg,
+// WAV, AVI, MPEG-4 and QuickTime, and Matroska and WebM files are read.
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrUnknown is returned by Probe for files in none of the formats read
+var ErrUnknown = errors.New("not a known audio or video format")
+
+// Tag is a piece of metadata, such as the title, named as shown
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// Info is what the headers of a media file tell. Fields the format does not
+// record are left zero.
+type Info struct {
+	Format     string // Container, e.g. "MP3" or "Matroska"
+	Duration   time.Duration
+	Bitrate    int    // Bits per second, over the whole file
+	VideoCodec string // e.g. "H.264"
+	Width      int
+	Height     int
+	AudioCodec string // e.g. "AAC"
+	SampleRate int    // Samples per second
+	Channels   int
+	Tags       []Tag // In the order of tagNames
+}
+
+// tagNames are the tags kept, in the order they are listed
+var tagNames = []string{"Title", "Artist", "Album", "Year", "Track", "Genre", "Composer", "Comment"}
+
+// extensions are the file name extensions of the formats read
+var extensions = map[string]bool{
+	".mp3": true, ".flac": true, ".ogg": true, ".oga": true, ".opus": true,
+	".wav": true, ".avi": true, ".mp4": true, ".m4a": true, ".m4v": true,
+	".m4b": true, ".mov": true, ".3gp": true, ".mkv": true, ".mka": true,
+	".webm": true,
+}
+
+// mp4Starts are the types of the boxes an MPEG-4 or QuickTime file starts
+// with
+var mp4Starts = map[string]bool{"ftyp": true, "moov": true, "mdat": true, "wide": true, "free": true, "skip": true}
+
+// IsMedia reports whether a file name has the extension of an audio or video
+// format that can be read
+func IsMedia(name string) bool {
+	return extensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// Probe reads the headers of a media file of size bytes, telling the format
+// from its first bytes
+func Probe(r io.ReaderAt, size int64) (Info, error) {
+	head := make([]byte, 12)
+	n, err := r.ReadAt(head, 0)
+	if n < len(head) {
+		if err == nil || err == io.EOF {
+			err = ErrUnknown
+		}
+		return Info{}, err
+	}
+
+	var info Info
+	switch {
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		info, err = probeFLAC(r, size)
+	case bytes.HasPrefix(head, []byte("OggS")):
+		info, err = probeOgg(r, size)
+	case bytes.HasPrefix(head, []byte("RIFF")) && string(head[8:12]) == "WAVE":
+		info, err = probeWAV(r, size)
+	case bytes.HasPrefix(head, []byte("RIFF")) && string(head[8:12]) == "AVI ":
+		info, err = probeAVI(r, size)
+	case mp4Starts[string(head[4:8])]:
+		info, err = probeMP4(r, size)
+	case bytes.HasPrefix(head, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		info, err = probeMatroska(r, size)
+	case bytes.HasPrefix(head, []byte("ID3")) || head[0] == 0xFF && head[1]&0xE0 == 0xE0:
+		info, err = probeMP3(r, size)
+	default:
+		return Info{}, ErrUnknown
+	}
+	if err != nil {
+		return Info{}, err
+	}
+	if info.Bitrate == 0 && info.Duration > 0 {
+		info.Bitrate = int(float64(size*8) / info.Duration.Seconds())
+	}
+	return info, nil
+}
+
+// setTag records a tag under one of tagNames, keeping the first value found
+// and the order of tagNames
+func (info *Info) setTag(name, value string) {
+	value = strings.TrimSpace(strings.TrimRight(value, "\x00"))
+	rank := indexOf(name)
+	if value == "" || rank == len(tagNames) {
+		return
+	}
+	at := len(info.Tags)
+	for i, tag := range info.Tags {
+		if tag.Name == name {
+			return
+		}
+		if at == len(info.Tags) && indexOf(tag.Name) > rank {
+			at = i
+		}
+	}
+	info.Tags = slices.Insert(info.Tags, at, Tag{name, value})
+}
+
+// indexOf returns the position of a name in tagNames
+func indexOf(name string) int {
+	for i, n := range tagNames {
+		if n == name {
+			return i
+		}
+	}
+	return len(tagNames)
+}
+
+// vorbisTags maps the field names of Vorbis comments, used by FLAC and Ogg,
+// to tag names
+var vorbisTags = map[string]string{
+	"TITLE": "Title", "ARTIST": "Artist", "ALBUM": "Album", "DATE": "Year",
+	"YEAR": "Year", "TRACKNUMBER": "Track", "GENRE": "Genre",
+	"COMPOSER": "Composer", "COMMENT": "Comment", "DESCRIPTION": "Comment",
+}
+
+// readVorbisComments reads the tags of a Vorbis comment block: a vendor
+// string and a list of NAME=value fields, all with little-endian lengths.
+// A block cut short keeps the fields read before the cut.
+func (info *Info) readVorbisComments(data []byte) {
+	if len(data) < 8 {
+		return
+	}
+	vendor := int(binary.LittleEndian.Uint32(data))
+	if 4+vendor+4 > len(data) {
+		return
+	}
+	data = data[4+vendor:]
+	count := int(binary.LittleEndian.Uint32(data))
+	data = data[4:]
+	for range count {
+		if len(data) < 4 {
+			return
+		}
+		length := int(binary.LittleEndian.Uint32(data))
+		if 4+length > len(data) {
+			return
+		}
+		field := string(data[4 : 4+length])
+		data = data[4+length:]
+		if key, value, ok := strings.Cut(field, "="); ok {
+			if name, ok := vorbisTags[strings.ToUpper(key)]; ok {
+				info.setTag(name, value)
+			}
+		}
+	}
+}
+
+// readAt reads n bytes at off, or fewer at the end of the file
+func readAt(r io.ReaderAt, off int64, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	read, err := r.ReadAt(buf, off)
+	if read > 0 && err == io.EOF {
+		err = nil
+	}
+	return buf[:read], err
+}
+
+// latin1 decodes ISO-8859-1 text. Many taggers write UTF-8 where
+// ISO-8859-1 is declared, so text that is valid UTF-8 is taken as it is.
+func latin1(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// utf16Text decodes UTF-16 text, little-endian unless bigEndian or a byte
+// order mark says otherwise
+func utf16Text(data []byte, bigEndian bool) string {
+	if len(data) >= 2 {
+		switch {
+		case data[0] == 0xFF && data[1] == 0xFE:
+			data, bigEndian = data[2:], false
+		case data[0] == 0xFE && data[1] == 0xFF:
+			data, bigEndian = data[2:], true
+		}
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = binary.BigEndian.Uint16(data[i*2:])
+		} else {
+			units[i] = binary.LittleEndian.Uint16(data[i*2:])
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// seconds converts a count of units of 1/scale second to a duration
+func seconds(units uint64, scale uint64) time.Duration {
+	if scale == 0 {
+		return 0
+	}
+	return time.Duration(float64(units) / float64(scale) * float64(time.Second))
+}
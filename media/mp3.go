@@ -0,0 +1,369 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxID3Size is the most of an ID3v2 tag read; cover art can make tags
+// megabytes long, and the text frames usually come first
+const maxID3Size = 1 << 20
+
+// mp3SyncSearch is how far past the tags the first MPEG frame is looked for
+const mp3SyncSearch = 64 << 10
+
+// id3Frames maps the IDs of ID3v2 text frames, in versions 2.3 and 2.4 and
+// the three-letter ones of 2.2, to tag names
+var id3Frames = map[string]string{
+	"TIT2": "Title", "TT2": "Title",
+	"TPE1": "Artist", "TP1": "Artist",
+	"TALB": "Album", "TAL": "Album",
+	"TYER": "Year", "TYE": "Year", "TDRC": "Year",
+	"TRCK": "Track", "TRK": "Track",
+	"TCON": "Genre", "TCO": "Genre",
+	"TCOM": "Composer", "TCM": "Composer",
+	"COMM": "Comment", "COM": "Comment",
+}
+
+// id3Genres are the genres of ID3v1, which ID3v2 refers to as "(17)"
+var id3Genres = []string{
+	"Blues", "Classic Rock", "Country", "Dance", "Disco", "Funk", "Grunge",
+	"Hip-Hop", "Jazz", "Metal", "New Age", "Oldies", "Other", "Pop", "R&B",
+	"Rap", "Reggae", "Rock", "Techno", "Industrial", "Alternative", "Ska",
+	"Death Metal", "Pranks", "Soundtrack", "Euro-Techno", "Ambient",
+	"Trip-Hop", "Vocal", "Jazz+Funk", "Fusion", "Trance", "Classical",
+	"Instrumental", "Acid", "House", "Game", "Sound Clip", "Gospel", "Noise",
+	"Alternative Rock", "Bass", "Soul", "Punk", "Space", "Meditative",
+	"Instrumental Pop", "Instrumental Rock", "Ethnic", "Gothic", "Darkwave",
+	"Techno-Industrial", "Electronic", "Pop-Folk", "Eurodance", "Dream",
+	"Southern Rock", "Comedy", "Cult", "Gangsta", "Top 40", "Christian Rap",
+	"Pop/Funk", "Jungle", "Native American", "Cabaret", "New Wave",
+	"Psychedelic", "Rave", "Showtunes", "Trailer", "Lo-Fi", "Tribal",
+	"Acid Punk", "Acid Jazz", "Polka", "Retro", "Musical", "Rock & Roll",
+	"Hard Rock",
+}
+
+// genreRef matches a reference to an ID3v1 genre, e.g. "(17)" or "17"
+var genreRef = regexp.MustCompile(`^\((\d+)\)|^(\d+)$`)
+
+// mpegBitrates are the bitrates in kb/s by index, for MPEG-1 layers I, II
+// and III and MPEG-2 layer I and layers II and III
+var mpegBitrates = [5][16]int{
+	{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+	{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+	{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+	{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+}
+
+// mpegSampleRates are the sample rates of MPEG-1 by index; MPEG-2 halves
+// them and MPEG-2.5 quarters them
+var mpegSampleRates = [3]int{44100, 48000, 32000}
+
+// mpegFrame is a parsed MPEG audio frame header
+type mpegFrame struct {
+	Version    int // 1, 2, or 25 for MPEG-2.5
+	Layer      int
+	Bitrate    int // Bits per second
+	SampleRate int
+	Mono       bool
+	Length     int // Bytes in the frame, header included
+	Samples    int // Samples per channel in the frame
+}
+
+// parseMPEGFrame parses the 4-byte header of an MPEG audio frame
+func parseMPEGFrame(h []byte) (mpegFrame, bool) {
+	if len(h) < 4 || h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return mpegFrame{}, false
+	}
+	var f mpegFrame
+	switch h[1] >> 3 & 3 {
+	case 0:
+		f.Version = 25
+	case 2:
+		f.Version = 2
+	case 3:
+		f.Version = 1
+	default:
+		return f, false
+	}
+	f.Layer = 4 - int(h[1]>>1&3)
+	bitrateIndex, rateIndex := int(h[2]>>4), int(h[2]>>2&3)
+	if f.Layer == 4 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return f, false
+	}
+	table := f.Layer - 1
+	if f.Version != 1 {
+		table = 3
+		if f.Layer > 1 {
+			table = 4
+		}
+	}
+	f.Bitrate = mpegBitrates[table][bitrateIndex] * 1000
+	f.SampleRate = mpegSampleRates[rateIndex]
+	switch f.Version {
+	case 2:
+		f.SampleRate /= 2
+	case 25:
+		f.SampleRate /= 4
+	}
+	f.Mono = h[3]>>6 == 3
+	padding := int(h[2] >> 1 & 1)
+	switch {
+	case f.Layer == 1:
+		f.Samples = 384
+		f.Length = (12*f.Bitrate/f.SampleRate + padding) * 4
+	case f.Layer == 3 && f.Version != 1:
+		f.Samples = 576
+		f.Length = 72*f.Bitrate/f.SampleRate + padding
+	default:
+		f.Samples = 1152
+		f.Length = 144*f.Bitrate/f.SampleRate + padding
+	}
+	return f, f.Length > 4
+}
+
+// probeMP3 reads the ID3 tags of an MP3 file and the first frame, with the
+// frame count a variable bitrate encoder puts there
+func probeMP3(r io.ReaderAt, size int64) (Info, error) {
+	info := Info{Format: "MP3"}
+	start, err := info.readID3v2(r)
+	if err != nil {
+		return info, err
+	}
+	end := size
+	if size >= 128 {
+		if tail, err := readAt(r, size-128, 128); err == nil && bytes.HasPrefix(tail, []byte("TAG")) {
+			info.readID3v1(tail)
+			end -= 128
+		}
+	}
+
+	data, err := readAt(r, start, mp3SyncSearch)
+	if err != nil {
+		return info, err
+	}
+	for i := 0; i+4 <= len(data); i++ {
+		frame, ok := parseMPEGFrame(data[i:])
+		if !ok {
+			continue
+		}
+		// A second frame right after the first rules out a chance match
+		if next := i + frame.Length; next+4 <= len(data) {
+			if _, ok := parseMPEGFrame(data[next:]); !ok {
+				continue
+			}
+		}
+		info.readMPEGFrame(frame, data[i:], end-start-int64(i))
+		return info, nil
+	}
+	return info, nil
+}
+
+// readMPEGFrame fills in the stream's details from its first frame. A Xing,
+// Info or VBRI header there gives the number of frames; without one the
+// bitrate is taken to be constant.
+func (info *Info) readMPEGFrame(f mpegFrame, data []byte, audioBytes int64) {
+	layer := [4]string{"", "I", "II", "III"}[f.Layer]
+	version := strconv.Itoa(f.Version)
+	if f.Version == 25 {
+		version = "2.5"
+	}
+	info.AudioCodec = "MPEG-" + version + " Layer " + layer
+	info.SampleRate = f.SampleRate
+	info.Channels = 2
+	if f.Mono {
+		info.Channels = 1
+	}
+
+	// The Xing header follows the side information
+	side := 32
+	switch {
+	case f.Version == 1 && f.Mono:
+		side = 17
+	case f.Version != 1 && !f.Mono:
+		side = 17
+	case f.Version != 1:
+		side = 9
+	}
+	frames := 0
+	if x := 4 + side; x+12 <= len(data) {
+		tag := string(data[x : x+4])
+		if (tag == "Xing" || tag == "Info") && binary.BigEndian.Uint32(data[x+4:])&1 != 0 {
+			frames = int(binary.BigEndian.Uint32(data[x+8:]))
+		}
+	}
+	if v := 4 + 32; v+18 <= len(data) && string(data[v:v+4]) == "VBRI" {
+		frames = int(binary.BigEndian.Uint32(data[v+14:]))
+	}
+
+	if frames > 0 {
+		info.Duration = seconds(uint64(frames)*uint64(f.Samples), uint64(f.SampleRate))
+		if info.Duration > 0 {
+			info.Bitrate = int(float64(audioBytes*8) / info.Duration.Seconds())
+		}
+		return
+	}
+	info.Bitrate = f.Bitrate
+	info.Duration = time.Duration(float64(audioBytes*8) / float64(f.Bitrate) * float64(time.Second))
+}
+
+// readID3v2 reads the tags of an ID3v2 tag at the start of the file,
+// returning where the audio starts
+func (info *Info) readID3v2(r io.ReaderAt) (int64, error) {
+	header, err := readAt(r, 0, 10)
+	if err != nil || len(header) < 10 || string(header[:3]) != "ID3" {
+		return 0, err
+	}
+	version, flags := header[3], header[5]
+	size := int64(syncsafe(header[6:10]))
+	start := 10 + size
+	if flags&0x10 != 0 {
+		// A footer repeats the header after the tag
+		start += 10
+	}
+	if version < 2 || version > 4 {
+		return start, nil
+	}
+
+	data, err := readAt(r, 10, int(min(size, maxID3Size)))
+	if err != nil {
+		return start, err
+	}
+	if flags&0x80 != 0 && version < 4 {
+		// Unsynchronisation put a zero after every 0xFF
+		data = bytes.ReplaceAll(data, []byte{0xFF, 0x00}, []byte{0xFF})
+	}
+	if flags&0x40 != 0 && version >= 3 && len(data) >= 4 {
+		// Skip the extended header
+		skip := int(binary.BigEndian.Uint32(data)) + 4
+		if version == 4 {
+			skip = int(syncsafe(data[:4]))
+		}
+		data = data[min(skip, len(data)):]
+	}
+
+	idLen, headerLen := 4, 10
+	if version == 2 {
+		idLen, headerLen = 3, 6
+	}
+	for len(data) >= headerLen && data[0] != 0 {
+		id := string(data[:idLen])
+		var frameSize int
+		switch version {
+		case 2:
+			frameSize = int(data[3])<<16 | int(data[4])<<8 | int(data[5])
+		case 3:
+			frameSize = int(binary.BigEndian.Uint32(data[4:8]))
+		default:
+			frameSize = int(syncsafe(data[4:8]))
+		}
+		if frameSize <= 0 || headerLen+frameSize > len(data) {
+			break
+		}
+		if name, ok := id3Frames[id]; ok {
+			body := data[headerLen : headerLen+frameSize]
+			switch name {
+			case "Comment":
+				info.setTag(name, id3Comment(body))
+			case "Genre":
+				info.setTag(name, genreName(id3Text(body)))
+			default:
+				info.setTag(name, id3Text(body))
+			}
+		}
+		data = data[headerLen+frameSize:]
+	}
+	return start, nil
+}
+
+// readID3v1 reads the fixed-width tags of the ID3v1 tag ending the file,
+// for tags ID3v2 did not give
+func (info *Info) readID3v1(tag []byte) {
+	field := func(from, to int) string {
+		value := tag[from:to]
+		if i := bytes.IndexByte(value, 0); i >= 0 {
+			value = value[:i]
+		}
+		return latin1(value)
+	}
+	info.setTag("Title", field(3, 33))
+	info.setTag("Artist", field(33, 63))
+	info.setTag("Album", field(63, 93))
+	info.setTag("Year", field(93, 97))
+	info.setTag("Comment", field(97, 127))
+	if tag[125] == 0 && tag[126] != 0 {
+		// ID3v1.1 keeps the track number in the last byte of the comment
+		info.setTag("Track", strconv.Itoa(int(tag[126])))
+	}
+	if int(tag[127]) < len(id3Genres) {
+		info.setTag("Genre", id3Genres[tag[127]])
+	}
+}
+
+// id3Text decodes the text of an ID3v2 text frame: an encoding byte, then
+// the text. Several values, separated by zeros, are joined with "; ".
+func id3Text(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	text := id3Decode(body[0], body[1:])
+	values := strings.FieldsFunc(text, func(r rune) bool { return r == 0 })
+	return strings.Join(values, "; ")
+}
+
+// id3Comment decodes an ID3v2 comment frame: an encoding byte, a language,
+// a description and the comment, separated by a zero
+func id3Comment(body []byte) string {
+	if len(body) < 4 {
+		return ""
+	}
+	text := id3Decode(body[0], body[4:])
+	if _, comment, ok := strings.Cut(text, "\x00"); ok {
+		return strings.TrimLeft(comment, "\x00")
+	}
+	return text
+}
+
+// id3Decode decodes ID3v2 text in one of its encodings: ISO-8859-1, UTF-16
+// with a byte order mark, UTF-16BE or UTF-8
+func id3Decode(encoding byte, data []byte) string {
+	switch encoding {
+	case 1:
+		return utf16Text(data, false)
+	case 2:
+		return utf16Text(data, true)
+	case 3:
+		return string(data)
+	default:
+		return latin1(data)
+	}
+}
+
+// genreName replaces references to ID3v1 genres with their names
+func genreName(genre string) string {
+	m := genreRef.FindStringSubmatch(genre)
+	if m == nil {
+		return genre
+	}
+	n, _ := strconv.Atoi(m[1] + m[2])
+	if n >= len(id3Genres) {
+		return genre
+	}
+	if rest := strings.TrimSpace(genre[len(m[0]):]); rest != "" {
+		// "(4)Eurodisco" refines the genre
+		return rest
+	}
+	return id3Genres[n]
+}
+
+// syncsafe decodes a 28-bit integer stored in the low seven bits of four
+// bytes
+func syncsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7F)<<21 | uint32(b[1]&0x7F)<<14 | uint32(b[2]&0x7F)<<7 | uint32(b[3]&0x7F)
+}
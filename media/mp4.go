@@ -0,0 +1,242 @@
+package media
+
+import (
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// mp4Box is a box of an MPEG-4 or QuickTime file: its type and where its
+// content lies
+type mp4Box struct {
+	Type  string
+	Start int64
+	End   int64
+}
+
+// mp4Boxes lists the boxes between start and end
+func mp4Boxes(r io.ReaderAt, start, end int64) []mp4Box {
+	var boxes []mp4Box
+	for off := start; off+8 <= end && len(boxes) < 1000; {
+		header, err := readAt(r, off, 16)
+		if err != nil || len(header) < 8 {
+			break
+		}
+		size := int64(binary.BigEndian.Uint32(header))
+		box := mp4Box{Type: string(header[4:8]), Start: off + 8}
+		switch size {
+		case 0:
+			// The last box runs to the end of the file
+			size = end - off
+		case 1:
+			if len(header) < 16 {
+				return boxes
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			box.Start += 8
+		}
+		if size < box.Start-off {
+			break
+		}
+		box.End = min(off+size, end)
+		boxes = append(boxes, box)
+		off += size
+	}
+	return boxes
+}
+
+// mp4Child returns the first box of a type below parent
+func mp4Child(r io.ReaderAt, parent mp4Box, kind string) (mp4Box, bool) {
+	for _, box := range mp4Boxes(r, parent.Start, parent.End) {
+		if box.Type == kind {
+			return box, true
+		}
+	}
+	return mp4Box{}, false
+}
+
+// mp4Codecs names the sample entry formats of MPEG-4 and QuickTime tracks
+var mp4Codecs = map[string]string{
+	"avc1": "H.264", "avc3": "H.264", "hvc1": "H.265 (HEVC)", "hev1": "H.265 (HEVC)",
+	"av01": "AV1", "vp08": "VP8", "vp09": "VP9", "mp4v": "MPEG-4 Visual",
+	"jpeg": "Motion JPEG", "apch": "ProRes 422 HQ", "apcn": "ProRes 422",
+	"apcs": "ProRes 422 LT", "apco": "ProRes 422 Proxy", "ap4h": "ProRes 4444",
+	"mp4a": "AAC", "ac-3": "AC-3", "ec-3": "E-AC-3", "Opus": "Opus",
+	"alac": "ALAC", "fLaC": "FLAC", ".mp3": "MP3", "lpcm": "PCM", "sowt": "PCM",
+	"twos": "PCM", "samr": "AMR",
+}
+
+// mp4Tags maps the items of an iTunes-style ilst box to tag names
+var mp4Tags = map[string]string{
+	"\xa9nam": "Title", "\xa9ART": "Artist", "\xa9alb": "Album", "\xa9day": "Year",
+	"trkn": "Track", "\xa9gen": "Genre", "gnre": "Genre", "\xa9wrt": "Composer",
+	"\xa9cmt": "Comment",
+}
+
+// probeMP4 reads the length, tracks and tags of an MPEG-4 or QuickTime
+// file from its moov box, which may come after the media data
+func probeMP4(r io.ReaderAt, size int64) (Info, error) {
+	info := Info{Format: "MPEG-4"}
+	for _, box := range mp4Boxes(r, 0, size) {
+		switch box.Type {
+		case "ftyp":
+			if brand, err := readAt(r, box.Start, 4); err == nil && len(brand) == 4 {
+				switch string(brand) {
+				case "qt  ":
+					info.Format = "QuickTime"
+				case "M4A ", "M4B ":
+					info.Format = "MPEG-4 audio"
+				case "3gp4", "3gp5", "3gp6", "3g2a":
+					info.Format = "3GPP"
+				}
+			}
+		case "moov":
+			info.readMoov(r, box)
+		}
+	}
+	return info, nil
+}
+
+// readMoov reads the movie header, the tracks and the tags of a moov box
+func (info *Info) readMoov(r io.ReaderAt, moov mp4Box) {
+	for _, box := range mp4Boxes(r, moov.Start, moov.End) {
+		switch box.Type {
+		case "mvhd":
+			if scale, duration, ok := mp4Duration(r, box); ok {
+				info.Duration = seconds(duration, scale)
+			}
+		case "trak":
+			info.readTrak(r, box)
+		case "udta":
+			if meta, ok := mp4Child(r, box, "meta"); ok {
+				info.readMeta(r, meta)
+			}
+		case "meta":
+			info.readMeta(r, box)
+		}
+	}
+}
+
+// mp4Duration reads the time scale and the duration of an mvhd or mdhd box
+func mp4Duration(r io.ReaderAt, box mp4Box) (uint64, uint64, bool) {
+	data, err := readAt(r, box.Start, 32)
+	if err != nil || len(data) < 20 {
+		return 0, 0, false
+	}
+	if data[0] == 1 {
+		// Version 1 has 64-bit times
+		if len(data) < 32 {
+			return 0, 0, false
+		}
+		return uint64(binary.BigEndian.Uint32(data[20:24])), binary.BigEndian.Uint64(data[24:32]), true
+	}
+	return uint64(binary.BigEndian.Uint32(data[12:16])), uint64(binary.BigEndian.Uint32(data[16:20])), true
+}
+
+// readTrak reads the codec of a track, with the picture size of a video
+// track and the channels and sample rate of a sound track. The first track
+// of each kind is kept.
+func (info *Info) readTrak(r io.ReaderAt, trak mp4Box) {
+	mdia, ok := mp4Child(r, trak, "mdia")
+	if !ok {
+		return
+	}
+	hdlr, ok := mp4Child(r, mdia, "hdlr")
+	if !ok {
+		return
+	}
+	handler, err := readAt(r, hdlr.Start+8, 4)
+	if err != nil || len(handler) < 4 {
+		return
+	}
+	kind := string(handler)
+	if kind == "vide" && info.VideoCodec != "" || kind == "soun" && info.AudioCodec != "" || kind != "vide" && kind != "soun" {
+		return
+	}
+	minf, ok := mp4Child(r, mdia, "minf")
+	if !ok {
+		return
+	}
+	stbl, ok := mp4Child(r, minf, "stbl")
+	if !ok {
+		return
+	}
+	stsd, ok := mp4Child(r, stbl, "stsd")
+	if !ok {
+		return
+	}
+	// A full box header and an entry count come before the first entry
+	entry, err := readAt(r, stsd.Start+8, 36)
+	if err != nil || len(entry) < 36 {
+		return
+	}
+	format := string(entry[4:8])
+	codec, ok := mp4Codecs[format]
+	if !ok {
+		codec = strings.TrimSpace(format)
+	}
+	if kind == "vide" {
+		info.VideoCodec = codec
+		info.Width = int(binary.BigEndian.Uint16(entry[32:34]))
+		info.Height = int(binary.BigEndian.Uint16(entry[34:36]))
+		return
+	}
+	info.AudioCodec = codec
+	info.Channels = int(binary.BigEndian.Uint16(entry[24:26]))
+	// A 16.16 fixed-point rate; the integer part suffices
+	info.SampleRate = int(binary.BigEndian.Uint16(entry[32:34]))
+	if mdhd, ok := mp4Child(r, mdia, "mdhd"); ok && info.SampleRate == 0 {
+		// Rates over 65535 do not fit; the track's time scale is the rate
+		if scale, _, ok := mp4Duration(r, mdhd); ok {
+			info.SampleRate = int(scale)
+		}
+	}
+}
+
+// readMeta reads the tags of the ilst box of a meta box. In MPEG-4 files
+// meta is a full box, with a version and flags before its children, while
+// QuickTime's is not.
+func (info *Info) readMeta(r io.ReaderAt, meta mp4Box) {
+	if head, err := readAt(r, meta.Start, 8); err == nil && len(head) == 8 && string(head[4:8]) != "hdlr" {
+		meta.Start += 4
+	}
+	ilst, ok := mp4Child(r, meta, "ilst")
+	if !ok {
+		return
+	}
+	for _, item := range mp4Boxes(r, ilst.Start, ilst.End) {
+		name, ok := mp4Tags[item.Type]
+		if !ok {
+			continue
+		}
+		data, ok := mp4Child(r, item, "data")
+		if !ok || data.End-data.Start < 8 || data.End-data.Start > 4096 {
+			continue
+		}
+		// A type and a locale come before the value
+		value, err := readAt(r, data.Start+8, int(data.End-data.Start-8))
+		if err != nil {
+			continue
+		}
+		switch item.Type {
+		case "trkn":
+			if len(value) >= 6 {
+				track := strconv.Itoa(int(binary.BigEndian.Uint16(value[2:4])))
+				if total := binary.BigEndian.Uint16(value[4:6]); total > 0 {
+					track += "/" + strconv.Itoa(int(total))
+				}
+				info.setTag(name, track)
+			}
+		case "gnre":
+			// An ID3v1 genre, counted from one
+			if len(value) >= 2 {
+				if n := int(binary.BigEndian.Uint16(value)); n >= 1 && n <= len(id3Genres) {
+					info.setTag(name, id3Genres[n-1])
+				}
+			}
+		default:
+			info.setTag(name, string(value))
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// oggTailSearch is how much of the end of an Ogg file is searched for the
+// last page, whose position gives the length
+const oggTailSearch = 64 << 10
+
+// oggPage is the header of an Ogg page
+type oggPage struct {
+	Granule  uint64 // Position in the stream at the end of the page
+	Serial   uint32 // Stream the page belongs to
+	Segments []byte // Lengths of the segments of the page's packets
+	Body     int64  // Where the segments start
+}
+
+// readOggPage reads the header of the page at off
+func readOggPage(r io.ReaderAt, off int64) (oggPage, bool) {
+	header, err := readAt(r, off, 27)
+	if err != nil || len(header) < 27 || string(header[:4]) != "OggS" {
+		return oggPage{}, false
+	}
+	segments, err := readAt(r, off+27, int(header[26]))
+	if err != nil || len(segments) < int(header[26]) {
+		return oggPage{}, false
+	}
+	return oggPage{
+		Granule:  binary.LittleEndian.Uint64(header[6:14]),
+		Serial:   binary.LittleEndian.Uint32(header[14:18]),
+		Segments: segments,
+		Body:     off + 27 + int64(len(segments)),
+	}, true
+}
+
+// oggPackets returns the first n packets of the first stream of an Ogg file,
+// each cut at limit bytes
+func oggPackets(r io.ReaderAt, n, limit int) ([][]byte, uint32) {
+	var packets [][]byte
+	var packet []byte
+	var serial uint32
+	off := int64(0)
+	for first := true; len(packets) < n; first = false {
+		page, ok := readOggPage(r, off)
+		if !ok {
+			break
+		}
+		if first {
+			serial = page.Serial
+		}
+		total := 0
+		for _, length := range page.Segments {
+			total += int(length)
+		}
+		var body []byte
+		if page.Serial == serial {
+			data, err := readAt(r, page.Body, total)
+			if err != nil || len(data) < total {
+				return packets, serial
+			}
+			body = data
+		}
+		for _, length := range page.Segments {
+			if page.Serial != serial {
+				break
+			}
+			segment := body[:length]
+			body = body[length:]
+			packet = append(packet, segment[:min(len(segment), max(limit-len(packet), 0))]...)
+			if length < 255 {
+				packets = append(packets, packet)
+				packet = nil
+				if len(packets) == n {
+					break
+				}
+			}
+		}
+		off = page.Body + int64(total)
+	}
+	return packets, serial
+}
+
+// probeOgg reads the identification and comment headers of the first stream
+// of an Ogg file (Vorbis, Opus, FLAC or Theora), and its length from the
+// last page
+func probeOgg(r io.ReaderAt, size int64) (Info, error) {
+	info := Info{Format: "Ogg"}
+	packets, serial := oggPackets(r, 2, maxCommentSize)
+	if len(packets) == 0 {
+		return info, nil
+	}
+
+	ident := packets[0]
+	var rate, skip uint64
+	switch {
+	case bytes.HasPrefix(ident, []byte("\x01vorbis")) && len(ident) >= 28:
+		info.AudioCodec = "Vorbis"
+		info.Channels = int(ident[11])
+		info.SampleRate = int(binary.LittleEndian.Uint32(ident[12:16]))
+		rate = uint64(info.SampleRate)
+		if len(packets) > 1 && bytes.HasPrefix(packets[1], []byte("\x03vorbis")) {
+			info.readVorbisComments(packets[1][7:])
+		}
+	case bytes.HasPrefix(ident, []byte("OpusHead")) && len(ident) >= 16:
+		info.AudioCodec = "Opus"
+		info.Channels = int(ident[9])
+		info.SampleRate = int(binary.LittleEndian.Uint32(ident[12:16]))
+		// Opus positions count at 48 kHz whatever the input rate was, from the
+		// end of the pre-skip
+		rate, skip = 48000, uint64(binary.LittleEndian.Uint16(ident[10:12]))
+		if len(packets) > 1 && bytes.HasPrefix(packets[1], []byte("OpusTags")) {
+			info.readVorbisComments(packets[1][8:])
+		}
+	case bytes.HasPrefix(ident, []byte("\x7FFLAC")) && len(ident) >= 13+4+18:
+		info.AudioCodec = "FLAC"
+		info.readStreamInfo(ident[17:])
+		rate = uint64(info.SampleRate)
+		if len(packets) > 1 && len(packets[1]) > 4 && packets[1][0]&0x7F == 4 {
+			info.readVorbisComments(packets[1][4:])
+		}
+	case bytes.HasPrefix(ident, []byte("\x80theora")):
+		info.VideoCodec = "Theora"
+		if len(ident) >= 20 {
+			info.Width = int(ident[14])<<16 | int(ident[15])<<8 | int(ident[16])
+			info.Height = int(ident[17])<<16 | int(ident[18])<<8 | int(ident[19])
+		}
+	}
+
+	if rate > 0 {
+		if granule, ok := lastGranule(r, size, serial); ok && granule > skip {
+			info.Duration = seconds(granule-skip, rate)
+		}
+	}
+	return info, nil
+}
+
+// lastGranule finds the position at the end of the last page of a stream
+func lastGranule(r io.ReaderAt, size int64, serial uint32) (uint64, bool) {
+	start := max(size-oggTailSearch, 0)
+	tail, err := readAt(r, start, int(size-start))
+	if err != nil {
+		return 0, false
+	}
+	for i := bytes.LastIndex(tail, []byte("OggS")); i >= 0; i = bytes.LastIndex(tail[:i], []byte("OggS")) {
+		page, ok := readOggPage(r, start+int64(i))
+		// Pages that end no packet have no position
+		if ok && page.Serial == serial && page.Granule != ^uint64(0) {
+			return page.Granule, true
+		}
+	}
+	return 0, false
+}
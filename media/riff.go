@@ -0,0 +1,189 @@
+package media
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// waveFormats names the audio formats of WAV files and AVI streams
+var waveFormats = map[uint16]string{
+	0x0001: "PCM", 0x0003: "PCM (float)", 0x0006: "A-law", 0x0007: "µ-law",
+	0x0011: "IMA ADPCM", 0x0050: "MPEG", 0x0055: "MP3", 0x00FF: "AAC",
+	0x2000: "AC-3", 0x2001: "DTS", 0x0161: "WMA",
+}
+
+// riffInfoTags maps the IDs of a RIFF INFO list to tag names
+var riffInfoTags = map[string]string{
+	"INAM": "Title", "IART": "Artist", "IPRD": "Album", "ICRD": "Year",
+	"ITRK": "Track", "IPRT": "Track", "IGNR": "Genre", "ICMT": "Comment",
+	"IMUS": "Composer",
+}
+
+// riffChunk is a chunk of a RIFF file: its ID and where its data lies
+type riffChunk struct {
+	ID    string
+	Start int64
+	Size  int64
+}
+
+// riffChunks lists the chunks between start and end. LIST chunks report
+// their list type as their ID, e.g. "INFO", with Start past it.
+func riffChunks(r io.ReaderAt, start, end int64) []riffChunk {
+	var chunks []riffChunk
+	for off := start; off+8 <= end && len(chunks) < 1000; {
+		header, err := readAt(r, off, 12)
+		if err != nil || len(header) < 8 {
+			break
+		}
+		chunk := riffChunk{ID: string(header[:4]), Start: off + 8, Size: int64(binary.LittleEndian.Uint32(header[4:8]))}
+		if chunk.ID == "LIST" && len(header) == 12 {
+			chunk.ID, chunk.Start, chunk.Size = string(header[8:12]), chunk.Start+4, chunk.Size-4
+		}
+		chunks = append(chunks, chunk)
+		// Chunks are padded to an even length
+		off = off + 8 + int64(binary.LittleEndian.Uint32(header[4:8]))
+		off += off & 1
+	}
+	return chunks
+}
+
+// probeWAV reads the format, length and INFO tags of a WAV file
+func probeWAV(r io.ReaderAt, size int64) (Info, error) {
+	info := Info{Format: "WAV"}
+	var byteRate, dataSize int64
+	for _, chunk := range riffChunks(r, 12, size) {
+		switch chunk.ID {
+		case "fmt ":
+			data, err := readAt(r, chunk.Start, 16)
+			if err != nil {
+				return info, err
+			}
+			if len(data) < 16 {
+				continue
+			}
+			info.setWaveFormat(data)
+			byteRate = int64(binary.LittleEndian.Uint32(data[8:12]))
+		case "data":
+			// Files still being written may claim more than there is
+			dataSize = min(chunk.Size, size-chunk.Start)
+		case "INFO":
+			info.readRIFFInfo(r, chunk)
+		}
+	}
+	if byteRate > 0 {
+		info.Duration = seconds(uint64(dataSize), uint64(byteRate))
+		info.Bitrate = int(byteRate * 8)
+	}
+	return info, nil
+}
+
+// setWaveFormat reads the codec, channels and sample rate of a WAVEFORMAT
+// structure
+func (info *Info) setWaveFormat(data []byte) {
+	tag := binary.LittleEndian.Uint16(data)
+	if tag == 0xFFFE && len(data) >= 26 {
+		// WAVE_FORMAT_EXTENSIBLE gives the format in its subformat GUID
+		tag = binary.LittleEndian.Uint16(data[24:26])
+	}
+	info.AudioCodec = waveFormats[tag]
+	if info.AudioCodec == "" {
+		info.AudioCodec = fmt.Sprintf("format 0x%04X", tag)
+	}
+	info.Channels = int(binary.LittleEndian.Uint16(data[2:4]))
+	info.SampleRate = int(binary.LittleEndian.Uint32(data[4:8]))
+}
+
+// readRIFFInfo reads the tags of an INFO list
+func (info *Info) readRIFFInfo(r io.ReaderAt, list riffChunk) {
+	for _, chunk := range riffChunks(r, list.Start, list.Start+list.Size) {
+		name, ok := riffInfoTags[chunk.ID]
+		if !ok || chunk.Size > 4096 {
+			continue
+		}
+		if data, err := readAt(r, chunk.Start, int(chunk.Size)); err == nil {
+			info.setTag(name, latin1(data))
+		}
+	}
+}
+
+// probeAVI reads the main header and the stream headers of an AVI file
+func probeAVI(r io.ReaderAt, size int64) (Info, error) {
+	info := Info{Format: "AVI"}
+	for _, chunk := range riffChunks(r, 12, size) {
+		switch chunk.ID {
+		case "hdrl":
+			info.readAVIHeaders(r, chunk)
+		case "INFO":
+			info.readRIFFInfo(r, chunk)
+		}
+	}
+	return info, nil
+}
+
+// readAVIHeaders reads the length and picture size from the avih chunk and
+// the codecs from the strl lists of an AVI's hdrl list
+func (info *Info) readAVIHeaders(r io.ReaderAt, hdrl riffChunk) {
+	for _, chunk := range riffChunks(r, hdrl.Start, hdrl.Start+hdrl.Size) {
+		switch chunk.ID {
+		case "avih":
+			data, err := readAt(r, chunk.Start, 40)
+			if err != nil || len(data) < 40 {
+				continue
+			}
+			perFrame := uint64(binary.LittleEndian.Uint32(data[0:4]))
+			frames := uint64(binary.LittleEndian.Uint32(data[16:20]))
+			info.Duration = seconds(frames*perFrame, 1_000_000)
+			info.Width = int(binary.LittleEndian.Uint32(data[32:36]))
+			info.Height = int(binary.LittleEndian.Uint32(data[36:40]))
+		case "strl":
+			info.readAVIStream(r, chunk)
+		}
+	}
+}
+
+// readAVIStream reads the codec of one stream of an AVI file from its strh
+// and strf chunks
+func (info *Info) readAVIStream(r io.ReaderAt, strl riffChunk) {
+	kind := ""
+	for _, chunk := range riffChunks(r, strl.Start, strl.Start+strl.Size) {
+		data, err := readAt(r, chunk.Start, int(min(chunk.Size, 64)))
+		if err != nil {
+			continue
+		}
+		switch {
+		case chunk.ID == "strh" && len(data) >= 8:
+			kind = string(data[:4])
+			if kind == "vids" && info.VideoCodec == "" {
+				info.VideoCodec = fourCC(data[4:8])
+			}
+		case chunk.ID == "strf" && kind == "vids" && len(data) >= 20:
+			// BITMAPINFOHEADER's compression names the codec better than the
+			// stream header's handler, which is often empty
+			if codec := fourCC(data[16:20]); codec != "" {
+				info.VideoCodec = codec
+			}
+		case chunk.ID == "strf" && kind == "auds" && len(data) >= 16 && info.AudioCodec == "":
+			info.setWaveFormat(data)
+		}
+	}
+}
+
+// videoFourCCs names the common video codecs of AVI files
+var videoFourCCs = map[string]string{
+	"H264": "H.264", "X264": "H.264", "AVC1": "H.264", "HEVC": "H.265 (HEVC)",
+	"H265": "H.265 (HEVC)", "XVID": "MPEG-4 Visual (Xvid)", "DIVX": "MPEG-4 Visual (DivX)",
+	"DX50": "MPEG-4 Visual (DivX)", "FMP4": "MPEG-4 Visual", "MJPG": "Motion JPEG",
+	"MP42": "MS MPEG-4 v2", "DIV3": "MS MPEG-4 v3", "WMV3": "WMV", "VP80": "VP8",
+	"VP90": "VP9", "AV01": "AV1",
+}
+
+// fourCC names the codec of a four-character code, or returns the code
+func fourCC(code []byte) string {
+	key := strings.TrimRight(string(code), " \x00")
+	if name, ok := videoFourCCs[strings.ToUpper(key)]; ok {
+		return name
+	}
+	return key
+}
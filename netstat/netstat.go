@@ -0,0 +1,113 @@
+// Package netstat lists the TCP and UDP connections of this machine with the
+// processes that own them
+package netstat
+
+import (
+	"encoding/csv"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Conn is a TCP connection or UDP endpoint
+type Conn struct {
+	Proto   string // TCP, TCPv6, UDP or UDPv6
+	Local   string // Local address and port, e.g. 0.0.0.0:135 or [::]:445
+	Remote  string // Remote address and port, *:* for UDP
+	State   string // TCP state as netstat prints it, empty for UDP
+	PID     int
+	Process string // Image name of the owning process, if known
+}
+
+// Listening reports whether the connection waits for peers: a listening TCP
+// socket or a UDP endpoint. The state text is translated on localized systems,
+// so listening sockets are recognized by their unspecified remote address.
+func (c Conn) Listening() bool {
+	if c.IsUDP() {
+		return true
+	}
+	host, port := SplitAddr(c.Remote)
+	return port == 0 && (host == "0.0.0.0" || host == "::")
+}
+
+// IsUDP reports whether the connection is a UDP endpoint
+func (c Conn) IsUDP() bool {
+	return strings.HasPrefix(c.Proto, "UDP")
+}
+
+// SplitAddr splits an address as netstat prints it into host and port; the
+// port is 0 when it is missing or *
+func SplitAddr(addr string) (string, int) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr, 0
+	}
+	n, _ := strconv.Atoi(port)
+	return host, n
+}
+
+// List returns the active connections and listening ports
+func List() ([]Conn, error) {
+	out, err := run("netstat", "-ano")
+	if err != nil {
+		return nil, err
+	}
+	conns := parseConnections(out)
+
+	// Connections are still useful without process names
+	if out, err := run("tasklist", "/fo", "csv", "/nh"); err == nil {
+		names := parseProcesses(out)
+		for i := range conns {
+			conns[i].Process = names[conns[i].PID]
+		}
+	}
+	return conns, nil
+}
+
+// parseConnections parses the output of netstat -ano; the header lines are
+// translated on localized systems, so rows are recognized by their protocol
+func parseConnections(out string) []Conn {
+	var conns []Conn
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		proto := strings.ToUpper(fields[0])
+		if proto != "TCP" && proto != "UDP" {
+			continue
+		}
+
+		conn := Conn{Proto: proto, Local: fields[1], Remote: fields[2]}
+		pid := fields[len(fields)-1]
+		if proto == "TCP" && len(fields) >= 5 {
+			conn.State = strings.Join(fields[3:len(fields)-1], " ")
+		}
+		var err error
+		if conn.PID, err = strconv.Atoi(pid); err != nil {
+			continue
+		}
+		if strings.HasPrefix(conn.Local, "[") {
+			conn.Proto += "v6"
+		}
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// parseProcesses maps process IDs to image names from tasklist /fo csv /nh
+func parseProcesses(out string) map[int]string {
+	names := make(map[int]string)
+	r := csv.NewReader(strings.NewReader(out))
+	r.FieldsPerRecord = -1
+	records, _ := r.ReadAll()
+	for _, record := range records {
+		if len(record) < 2 {
+			continue
+		}
+		if pid, err := strconv.Atoi(record[1]); err == nil {
+			names[pid] = record[0]
+		}
+	}
+	return names
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package netstat
+
+import "errors"
+
+func run(name string, args ...string) (string, error) {
+	return "", errors.New("network connections are only listed on Windows")
+}
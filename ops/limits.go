@@ -0,0 +1,115 @@
+package ops
+
+import (
+	"io"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// chunkSize is the most a throttled read takes at once, so waits stay short
+// and bandwidth is shared evenly between jobs
+const chunkSize = 64 << 10
+
+// limits are the worker and bandwidth limits shared by all background jobs
+var limits = struct {
+	sync.Mutex
+	workers int           // Jobs that may run at once
+	slots   chan struct{} // Held by running jobs
+	rate    int64         // Bytes per second, 0 for no limit
+	next    time.Time     // When the bandwidth budget allows the next read
+}{
+	workers: runtime.NumCPU(),
+	slots:   make(chan struct{}, runtime.NumCPU()),
+}
+
+// SetLimits sets how many background jobs run at once (the number of CPUs if
+// workers is 0 or less) and how many bytes per second they may read in total
+// (no limit if rate is 0 or less)
+func SetLimits(workers int, rate int64) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if rate < 0 {
+		rate = 0
+	}
+
+	limits.Lock()
+	defer limits.Unlock()
+	if workers != limits.workers {
+		// Jobs already running release the slots of the old channel
+		limits.workers = workers
+		limits.slots = make(chan struct{}, workers)
+	}
+	limits.rate = rate
+}
+
+// Workers returns how many background jobs may run at once
+func Workers() int {
+	limits.Lock()
+	defer limits.Unlock()
+	return limits.workers
+}
+
+// Work runs fn once a worker is free, blocking until then
+func Work(fn func()) {
+	limits.Lock()
+	slots := limits.slots
+	limits.Unlock()
+
+	slots <- struct{}{}
+	defer func() { <-slots }()
+	fn()
+}
+
+// Parallel calls fn for each index below n, on as many workers as the limit
+// allows, and returns when all calls have finished
+func Parallel(n int, fn func(i int)) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Work(func() { fn(i) })
+		}()
+	}
+	wg.Wait()
+}
+
+// Throttle returns a reader that keeps the reads of all background jobs within
+// the bandwidth limit
+func Throttle(r io.Reader) io.Reader {
+	return &throttledReader{r: r}
+}
+
+// throttledReader waits for the bandwidth budget before each read
+type throttledReader struct {
+	r io.Reader
+}
+
+// Read reads at most chunkSize bytes once the budget allows it
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > chunkSize {
+		p = p[:chunkSize]
+	}
+	wait(len(p))
+	return t.r.Read(p)
+}
+
+// wait sleeps until n more bytes fit in the bandwidth limit
+func wait(n int) {
+	limits.Lock()
+	if limits.rate == 0 {
+		limits.Unlock()
+		return
+	}
+	now := time.Now()
+	if limits.next.Before(now) {
+		limits.next = now
+	}
+	delay := limits.next.Sub(now)
+	limits.next = limits.next.Add(time.Duration(int64(n) * int64(time.Second) / limits.rate))
+	limits.Unlock()
+
+	time.Sleep(delay)
+}
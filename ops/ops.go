@@ -0,0 +1,337 @@
+// Package ops performs the file operations of the browser: copying, moving,
+// renaming, deleting, setting times, zipping, and creating directories and
+// hard links. Background jobs share the worker and bandwidth limits set with
+// SetLimits.
+package ops
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/disk"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+)
+
+// PartialSuffix is added to the name of a file while it is copied. A copy that
+// fails leaves the partial file behind for ResumeCopy to continue.
+const PartialSuffix = ".partial"
+
+// Copy copies a file or directory tree into the directory dest, keeping its
+// name. Links are copied as links. The copied bytes and files are counted in
+// progress, which may be nil.
+func Copy(src, dest string, progress *Progress) error {
+	target, err := targetPath(src, dest)
+	if err != nil {
+		return err
+	}
+	return copyPath(src, target, false, progress)
+}
+
+// ResumeCopy continues a Copy that stopped early. Files already copied are
+// skipped and partial files are continued where they end; the rest is copied.
+func ResumeCopy(src, dest string, progress *Progress) error {
+	target, err := destPath(src, dest)
+	if err != nil {
+		return err
+	}
+	return copyPath(src, target, true, progress)
+}
+
+// Move moves a file or directory into the directory dest, keeping its name.
+// Moves between volumes copy the tree, counted in progress, and then remove
+// the original; any other failure to rename leaves both as they were.
+func Move(src, dest string, progress *Progress) error {
+	target, err := targetPath(src, dest)
+	if err != nil {
+		return err
+	}
+	if err := vfs.Default.Rename(src, target); !crossDevice(err) {
+		return err
+	}
+	if err := copyPath(src, target, false, progress); err != nil {
+		return err
+	}
+	return vfs.Default.RemoveAll(src)
+}
+
+// ResumeMove continues a Move between volumes that stopped early, copying
+// what is left of the tree like ResumeCopy and then removing the original
+func ResumeMove(src, dest string, progress *Progress) error {
+	target, err := destPath(src, dest)
+	if err != nil {
+		return err
+	}
+	if _, err := vfs.Default.Lstat(target); errors.Is(err, os.ErrNotExist) {
+		if err := vfs.Default.Rename(src, target); !crossDevice(err) {
+			return err
+		}
+	}
+	if err := copyPath(src, target, true, progress); err != nil {
+		return err
+	}
+	return vfs.Default.RemoveAll(src)
+}
+
+// Delete removes a file, link or directory tree. Links are removed, not the
+// files they point to.
+func Delete(path string) error {
+	if _, err := vfs.Default.Lstat(path); err != nil {
+		return err
+	}
+	return vfs.Default.RemoveAll(path)
+}
+
+// Rename gives a file or directory a new name in the same directory and returns
+// its new path. Changing only the case of a name is allowed.
+func Rename(path, name string) (string, error) {
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+	path = filepath.Clean(path)
+	target := filepath.Join(filepath.Dir(path), name)
+	if target == path {
+		return path, nil
+	}
+	if info, err := vfs.Default.Lstat(target); err == nil {
+		// On case-insensitive file systems the new name may find the file itself
+		if orig, err := vfs.Default.Lstat(path); err != nil || !os.SameFile(info, orig) {
+			return "", fmt.Errorf("%s already exists", target)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	if err := vfs.Default.Rename(path, target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// Mkdir creates a directory in dir and returns its path. The name is of one
+// directory, so nothing is created outside dir.
+func Mkdir(dir, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+	target := filepath.Join(dir, name)
+	if _, err := vfs.Default.Lstat(target); err == nil {
+		return "", fmt.Errorf("%s already exists", target)
+	}
+	if err := vfs.Default.Mkdir(target, 0o755); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// Link creates a hard link to the file src in the directory dest, with the
+// same name, and returns its path. Both must be on the same volume.
+func Link(src, dest string) (string, error) {
+	info, err := vfs.Default.Lstat(src)
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a file; hard links can only be made to files", filepath.Base(src))
+	}
+	target, err := targetPath(src, dest)
+	if err != nil {
+		return "", err
+	}
+	if err := vfs.Default.Link(src, target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// Touch sets the access and modification times of a file or directory to t,
+// and its creation time too if created is set. The creation time is set
+// first, so nothing changes where it cannot be.
+func Touch(path string, t time.Time, created bool) error {
+	if created {
+		if err := disk.SetCreationTime(path, t); err != nil {
+			return err
+		}
+	}
+	return vfs.Default.Chtimes(path, t, t)
+}
+
+// checkName refuses names that are empty or would leave the directory
+func checkName(name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return errors.New("no name given")
+	case name == "." || name == "..":
+		return fmt.Errorf("%s is not a valid name", name)
+	case strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("%s contains a path separator", name)
+	}
+	return nil
+}
+
+// targetPath returns the path src takes in the directory dest, refusing to
+// replace an existing entry or to put a directory inside itself
+func targetPath(src, dest string) (string, error) {
+	target, err := destPath(src, dest)
+	if err != nil {
+		return "", err
+	}
+	if _, err := vfs.Default.Lstat(target); err == nil {
+		return "", fmt.Errorf("%s already exists", target)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	return target, nil
+}
+
+// destPath returns the path src takes in the directory dest, refusing to put
+// a directory inside itself
+func destPath(src, dest string) (string, error) {
+	src, dest = filepath.Clean(src), filepath.Clean(dest)
+	if info, err := vfs.Default.Stat(dest); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("no such directory: %s", dest)
+	}
+	target := filepath.Join(dest, filepath.Base(src))
+	if target == src {
+		return "", fmt.Errorf("%s is already in %s", filepath.Base(src), dest)
+	}
+	if dest == src || strings.HasPrefix(dest, src+string(filepath.Separator)) {
+		return "", fmt.Errorf("cannot put %s inside itself", filepath.Base(src))
+	}
+	return target, nil
+}
+
+// copyPath copies src to target, which does not exist yet unless resuming an
+// earlier copy
+func copyPath(src, target string, resume bool, progress *Progress) error {
+	if err := progress.Check(); err != nil {
+		return err
+	}
+	info, err := vfs.Default.Lstat(src)
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := vfs.Default.Readlink(src)
+		if err != nil {
+			return err
+		}
+		if resume {
+			if existing, err := vfs.Default.Readlink(target); err == nil && existing == link {
+				return nil
+			}
+		}
+		return vfs.Default.Symlink(link, target)
+
+	case info.IsDir():
+		entries, err := vfs.Default.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		if err := vfs.Default.Mkdir(target, info.Mode().Perm()); err != nil {
+			if existing, statErr := vfs.Default.Lstat(target); !resume || statErr != nil || !existing.IsDir() {
+				return err
+			}
+		}
+		for _, entry := range entries {
+			if err := copyPath(filepath.Join(src, entry.Name()), filepath.Join(target, entry.Name()), resume, progress); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return copyFile(src, target, info, resume, progress)
+	}
+}
+
+// copyFile copies the contents, permissions and modification time of a file.
+// The contents go to a partial file that takes the target's name once
+// complete. When resuming, a target that was already copied is skipped and a
+// partial file is continued from where it ends. With SetVerify the copy is
+// hashed and compared with the original before it takes the target's name.
+func copyFile(src, target string, info os.FileInfo, resume bool, progress *Progress) error {
+	partial := target + PartialSuffix
+	if resume {
+		if existing, err := vfs.Default.Lstat(target); err == nil {
+			if existing.Size() != info.Size() || !existing.ModTime().Equal(info.ModTime()) {
+				return fmt.Errorf("%s already exists", target)
+			}
+			progress.AddBytes(info.Size())
+			progress.AddItem()
+			return nil
+		}
+	}
+
+	in, err := vfs.Default.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var out io.WriteCloser
+	var offset int64
+	if existing, err := vfs.Default.Lstat(partial); resume && err == nil && existing.Size() <= info.Size() {
+		if offset, err = in.Seek(existing.Size(), io.SeekStart); err != nil {
+			return err
+		}
+		if out, err = vfs.Default.Append(partial); err != nil {
+			return err
+		}
+		progress.AddBytes(offset)
+	} else {
+		// Left over from an earlier copy that is not being resumed
+		vfs.Default.Remove(partial)
+		if out, err = vfs.Default.Create(partial, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+
+	// Hash the original as it is read, unless part of it was copied before
+	verifying := Verifying()
+	reader := progress.Reader(Throttle(in))
+	var sum hash.Hash
+	if verifying && offset == 0 {
+		sum = sha256.New()
+		reader = io.TeeReader(reader, sum)
+	}
+
+	if _, err := io.Copy(out, reader); err != nil {
+		out.Close()
+		if errors.Is(err, ErrCanceled) {
+			vfs.Default.Remove(partial)
+		}
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if verifying {
+		if err := verifyCopy(src, partial, sum, progress); err != nil {
+			var mismatch *MismatchError
+			if errors.As(err, &mismatch) {
+				// Name the copy as it would have been, and copy it anew on :resume
+				mismatch.Copy = target
+				vfs.Default.Remove(partial)
+			} else if errors.Is(err, ErrCanceled) {
+				vfs.Default.Remove(partial)
+			}
+			return err
+		}
+	}
+	if err := vfs.Default.Chtimes(partial, info.ModTime(), info.ModTime()); err != nil {
+		return err
+	}
+	if err := vfs.Default.Rename(partial, target); err != nil {
+		return err
+	}
+	progress.AddItem()
+	return nil
+}
@@ -0,0 +1,46 @@
+package ops
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"testing/fstest"
+
+	"github.com/HolyStarGazer/windows-tui-go/uitest"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+)
+
+// TestMkdirStaysInDir checks Mkdir creates one directory and refuses names
+// that would reach outside the directory or create several levels
+func TestMkdirStaysInDir(t *testing.T) {
+	root := uitest.Mount(t, fstest.MapFS{"work/notes.txt": {Data: []byte("notes\n")}})
+	dir := filepath.Join(root, "work")
+
+	path, err := Mkdir(dir, " build ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := vfs.Default.Stat(path); err != nil || !info.IsDir() || path != filepath.Join(dir, "build") {
+		t.Errorf("Mkdir made %s: %v", path, err)
+	}
+	for _, name := range []string{"", "..", ".", "../escape", "a/b", `a\b`, "a/../../escape"} {
+		if path, err := Mkdir(dir, name); err == nil {
+			t.Errorf("Mkdir(%q) made %s", name, path)
+		}
+	}
+	if _, err := vfs.Default.Stat(filepath.Join(root, "escape")); !os.IsNotExist(err) {
+		t.Errorf("a directory was made outside %s: %v", dir, err)
+	}
+}
+
+// TestCrossDevice checks a rename that worked or was refused does not count
+// as one between file systems, so Move does not copy and delete for it
+func TestCrossDevice(t *testing.T) {
+	if crossDevice(nil) {
+		t.Error("a rename that worked counts as between file systems")
+	}
+	if crossDevice(&os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.EACCES}) {
+		t.Error("a refused rename counts as between file systems")
+	}
+}
@@ -0,0 +1,188 @@
+package ops
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCanceled is returned by jobs stopped with Progress.Cancel
+var ErrCanceled = errors.New("canceled")
+
+// Progress counts the work a background job has done, so that its elapsed
+// time, throughput and remaining time can be shown while it runs. The job
+// updates it from its goroutines while the UI reads it, and may pause or
+// cancel it. A nil Progress counts nothing.
+type Progress struct {
+	start    time.Time
+	total    atomic.Int64 // Bytes the job will process, 0 until known
+	bytes    atomic.Int64 // Bytes processed so far
+	items    atomic.Int64 // Files processed so far
+	canceled atomic.Bool
+
+	mu       sync.Mutex
+	resumed  chan struct{} // Closed when a paused job resumes, nil while it runs
+	pausedAt time.Time     // When the job was paused
+	idle     time.Duration // Time spent paused before pausedAt
+}
+
+// ProgressStats is a snapshot of a job's progress
+type ProgressStats struct {
+	Elapsed time.Duration // Time spent running, not counting pauses
+	Paused  bool
+	Bytes   int64
+	Total   int64         // 0 if not known
+	Items   int64         // Files processed
+	Rate    float64       // Bytes per second since the start
+	ETA     time.Duration // Time left at the current rate, or -1 if unknown
+}
+
+// NewProgress starts tracking a job
+func NewProgress() *Progress {
+	return &Progress{start: time.Now()}
+}
+
+// SetTotal sets how many bytes the job will process, once known
+func (p *Progress) SetTotal(n int64) {
+	if p != nil {
+		p.total.Store(n)
+	}
+}
+
+// AddBytes counts processed bytes
+func (p *Progress) AddBytes(n int64) {
+	if p != nil {
+		p.bytes.Add(n)
+	}
+}
+
+// AddItem counts a processed file
+func (p *Progress) AddItem() {
+	if p != nil {
+		p.items.Add(1)
+	}
+}
+
+// Cancel asks the job to stop; it returns ErrCanceled at its next check,
+// even while paused
+func (p *Progress) Cancel() {
+	if p != nil {
+		p.canceled.Store(true)
+		p.Resume()
+	}
+}
+
+// Pause holds the job at its next check until Resume
+func (p *Progress) Pause() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resumed == nil {
+		p.resumed = make(chan struct{})
+		p.pausedAt = time.Now()
+	}
+}
+
+// Resume lets a paused job continue
+func (p *Progress) Resume() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resumed != nil {
+		close(p.resumed)
+		p.resumed = nil
+		p.idle += time.Since(p.pausedAt)
+	}
+}
+
+// Paused reports whether the job is paused
+func (p *Progress) Paused() bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.resumed != nil
+}
+
+// Check waits while the job is paused and returns ErrCanceled once it is
+// canceled. Jobs call it between steps, such as files.
+func (p *Progress) Check() error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	resumed := p.resumed
+	p.mu.Unlock()
+	if resumed != nil {
+		<-resumed
+	}
+	if p.Canceled() {
+		return ErrCanceled
+	}
+	return nil
+}
+
+// Canceled reports whether the job was asked to stop
+func (p *Progress) Canceled() bool {
+	return p != nil && p.canceled.Load()
+}
+
+// Stats returns the job's progress so far
+func (p *Progress) Stats() ProgressStats {
+	p.mu.Lock()
+	elapsed := time.Since(p.start) - p.idle
+	paused := p.resumed != nil
+	if paused {
+		elapsed -= time.Since(p.pausedAt)
+	}
+	p.mu.Unlock()
+
+	stats := ProgressStats{
+		Elapsed: elapsed,
+		Paused:  paused,
+		Bytes:   p.bytes.Load(),
+		Total:   p.total.Load(),
+		Items:   p.items.Load(),
+		ETA:     -1,
+	}
+	if seconds := stats.Elapsed.Seconds(); seconds > 0 {
+		stats.Rate = float64(stats.Bytes) / seconds
+	}
+	if stats.Total > 0 && stats.Rate > 0 {
+		left := float64(max(stats.Total-stats.Bytes, 0)) / stats.Rate
+		stats.ETA = time.Duration(left * float64(time.Second))
+	}
+	return stats
+}
+
+// Reader counts the bytes read from r, waiting while the job is paused and
+// failing with ErrCanceled once it is canceled
+func (p *Progress) Reader(r io.Reader) io.Reader {
+	if p == nil {
+		return r
+	}
+	return &progressReader{r: r, p: p}
+}
+
+// progressReader counts the bytes read through it
+type progressReader struct {
+	r io.Reader
+	p *Progress
+}
+
+// Read reads from the underlying reader and counts the bytes
+func (pr *progressReader) Read(b []byte) (int, error) {
+	if err := pr.p.Check(); err != nil {
+		return 0, err
+	}
+	n, err := pr.r.Read(b)
+	pr.p.AddBytes(int64(n))
+	return n, err
+}
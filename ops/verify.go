@@ -0,0 +1,93 @@
+package ops
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"sync/atomic"
+
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+)
+
+// verify is whether copied files are checked against their originals
+var verify atomic.Bool
+
+// SetVerify sets whether Copy and Move hash each copied file and its copy and
+// fail if they differ
+func SetVerify(on bool) {
+	verify.Store(on)
+}
+
+// Verifying reports whether copies are verified
+func Verifying() bool {
+	return verify.Load()
+}
+
+// MismatchError reports a copy whose contents differ from the original
+type MismatchError struct {
+	Path string // The original
+	Copy string
+	Want string // Hex SHA-256 of the original
+	Got  string // Hex SHA-256 of the copy
+}
+
+// Error describes the mismatch with the start of both hashes
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("verification failed: %s differs from %s (SHA-256 %.12s, copy %.12s)", e.Copy, e.Path, e.Want, e.Got)
+}
+
+// verifyCopy compares the SHA-256 of a copy with that of the original, which
+// is read again unless sum already hashed it during the copy
+func verifyCopy(src, copy string, sum hash.Hash, progress *Progress) error {
+	var want []byte
+	if sum != nil {
+		want = sum.Sum(nil)
+	} else {
+		var err error
+		if want, err = hashFile(src, progress); err != nil {
+			return err
+		}
+	}
+	got, err := hashFile(copy, progress)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(want, got) {
+		return &MismatchError{Path: src, Copy: copy, Want: hex.EncodeToString(want), Got: hex.EncodeToString(got)}
+	}
+	return nil
+}
+
+// hashFile returns the SHA-256 of a file, read within the bandwidth limit.
+// The bytes are not counted in progress, which may still pause or cancel it.
+func hashFile(path string, progress *Progress) ([]byte, error) {
+	f, err := vfs.Default.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sum := sha256.New()
+	if _, err := io.Copy(sum, &checkReader{r: Throttle(f), p: progress}); err != nil {
+		return nil, err
+	}
+	return sum.Sum(nil), nil
+}
+
+// checkReader waits while a job is paused and fails once it is canceled,
+// without counting what it reads
+type checkReader struct {
+	r io.Reader
+	p *Progress
+}
+
+// Read reads from the underlying reader after checking the job
+func (cr *checkReader) Read(b []byte) (int, error) {
+	if err := cr.p.Check(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(b)
+}
@@ -0,0 +1,97 @@
+package ops
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+)
+
+// Zip writes the files and directory trees at paths into a new zip archive at
+// target, each under its own name. Links and other special files are left
+// out. The archive is written to a partial file that takes the target's name
+// once complete, and the bytes and files read are counted in progress.
+func Zip(paths []string, target string, progress *Progress) error {
+	if _, err := vfs.Default.Lstat(target); err == nil {
+		return fmt.Errorf("%s already exists", target)
+	}
+	partial := target + PartialSuffix
+	// Left over from an earlier archive that failed
+	vfs.Default.Remove(partial)
+	out, err := vfs.Default.Create(partial, 0o644)
+	if err != nil {
+		return err
+	}
+	w := zip.NewWriter(out)
+	for _, path := range paths {
+		if err = zipTree(w, path, partial, progress); err != nil {
+			break
+		}
+	}
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		vfs.Default.Remove(partial)
+		return err
+	}
+	return vfs.Default.Rename(partial, target)
+}
+
+// zipTree adds a file, or a directory and everything below it, named relative
+// to the directory holding it. skip is the archive being written, in case it
+// is inside the tree.
+func zipTree(w *zip.Writer, root, skip string, progress *Progress) error {
+	parent := filepath.Dir(root)
+	return vfs.WalkDir(vfs.Default, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := progress.Check(); err != nil {
+			return err
+		}
+		if path == skip || !d.IsDir() && !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(parent, path)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if d.IsDir() {
+			header.Name += "/"
+			_, err := w.CreateHeader(header)
+			return err
+		}
+		header.Method = zip.Deflate
+
+		entry, err := w.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		in, err := vfs.Default.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		if _, err := io.Copy(entry, progress.Reader(Throttle(in))); err != nil {
+			return err
+		}
+		progress.AddItem()
+		return nil
+	})
+}
@@ -0,0 +1,95 @@
+package picture
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// kittyCell is the size in pixels an image is sent at for each cell; the
+// terminal scales it to its own cell size
+const (
+	kittyCellWidth  = 10
+	kittyCellHeight = 20
+)
+
+// kittyChunk is the most base64 data sent in one escape sequence
+const kittyChunk = 4096
+
+// kittyPlaceholder stands for a cell of an image placed with Unicode
+// placeholders, and kittyRow0 is the diacritic giving row or column 0
+const (
+	kittyPlaceholder = "\U0010EEEE"
+	kittyRow0        = "\u0305"
+)
+
+// nextImageID numbers the images sent, starting somewhere random since other
+// programs in the same window number theirs too
+var nextImageID atomic.Uint32
+
+// kittyOnce detects kitty graphics the first time they are needed
+var (
+	kittyOnce sync.Once
+	kittyOn   bool
+)
+
+// Kitty reports whether the terminal draws images sent with the kitty
+// graphics protocol and its Unicode placeholders, as kitty and Ghostty do.
+// Inside tmux or screen the escape sequences would not reach the terminal.
+func Kitty() bool {
+	kittyOnce.Do(func() {
+		if os.Getenv("TMUX") != "" || strings.HasPrefix(os.Getenv("TERM"), "screen") {
+			return
+		}
+		kittyOn = os.Getenv("KITTY_WINDOW_ID") != "" || os.Getenv("TERM") == "xterm-kitty" ||
+			os.Getenv("TERM_PROGRAM") == "ghostty"
+	})
+	return kittyOn
+}
+
+// kittyLines draws an image in cols by rows cells with the kitty graphics
+// protocol. Each row is sent as an image of its own placed with Unicode
+// placeholders, which are ordinary text to the rest of the program, so a
+// line can be redrawn or clipped like any other. Only the first cell of a row
+// needs its row and column given; the rest follow on from it.
+func kittyLines(img image.Image, cols, rows int) []string {
+	small := scale(img, cols*kittyCellWidth, rows*kittyCellHeight)
+	nextImageID.CompareAndSwap(0, rand.Uint32N(1<<23)+1)
+	lines := make([]string, rows)
+	for y := range rows {
+		strip := small.SubImage(image.Rect(0, y*kittyCellHeight, cols*kittyCellWidth, (y+1)*kittyCellHeight))
+		var data bytes.Buffer
+		if err := png.Encode(&data, strip); err != nil {
+			return Blocks(img, cols, rows)
+		}
+		// Image IDs are given by the placeholders' 24-bit foreground color
+		id := nextImageID.Add(1) & 0xFFFFFF
+		var b strings.Builder
+		encoded := base64.StdEncoding.EncodeToString(data.Bytes())
+		for start := 0; start < len(encoded); start += kittyChunk {
+			chunk := encoded[start:min(start+kittyChunk, len(encoded))]
+			more := 0
+			if start+kittyChunk < len(encoded) {
+				more = 1
+			}
+			if start == 0 {
+				fmt.Fprintf(&b, "\x1b_Ga=T,U=1,f=100,q=2,i=%d,c=%d,r=1,m=%d;%s\x1b\\", id, cols, more, chunk)
+			} else {
+				fmt.Fprintf(&b, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+			}
+		}
+		fmt.Fprintf(&b, "\x1b[38;2;%d;%d;%dm", id>>16&0xFF, id>>8&0xFF, id&0xFF)
+		b.WriteString(kittyPlaceholder + kittyRow0 + kittyRow0)
+		b.WriteString(strings.Repeat(kittyPlaceholder, cols-1))
+		b.WriteString("\x1b[39m")
+		lines[y] = b.String()
+	}
+	return lines
+}
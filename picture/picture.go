@@ -0,0 +1,128 @@
+// Package picture decodes images and draws them in the terminal, with the
+// kitty graphics protocol where the terminal supports it and as colored
+// half blocks elsewhere
+package picture
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"  // Register the GIF decoder
+	_ "image/jpeg" // Register the JPEG decoder
+	_ "image/png"  // Register the PNG decoder
+	"path/filepath"
+	"strings"
+)
+
+// maxPixels is the largest image decoded, so a huge scan cannot take
+// gigabytes of memory
+const maxPixels = 64_000_000
+
+// ErrTooLarge is returned by Decode for images over maxPixels
+var ErrTooLarge = errors.New("image too large to show")
+
+// extensions are the image formats that can be decoded
+var extensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true}
+
+// IsImage reports whether a file name has the extension of an image that can
+// be decoded
+func IsImage(name string) bool {
+	return extensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// Decode decodes a PNG, JPEG or GIF image, the first frame of an animation
+func Decode(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("%w (%d×%d)", ErrTooLarge, config.Width, config.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// Fit returns the size in cells of an image drawn as large as fits in cols
+// by rows without changing its shape, given that a cell is twice as tall as
+// it is wide
+func Fit(img image.Image, cols, rows int) (int, int) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w == 0 || h == 0 || cols <= 0 || rows <= 0 {
+		return 0, 0
+	}
+	// Each cell is two pixels of height
+	if w*rows*2 > h*cols {
+		return cols, max(h*cols/(w*2), 1)
+	}
+	return max(w*rows*2/h, 1), rows
+}
+
+// Draw returns the lines of an image drawn in cols by rows cells, stretched
+// to fill them; see Fit
+func Draw(img image.Image, cols, rows int) []string {
+	if Kitty() {
+		return kittyLines(img, cols, rows)
+	}
+	return Blocks(img, cols, rows)
+}
+
+// Blocks draws an image in cols by rows cells of colored half blocks, two
+// pixels to a cell
+func Blocks(img image.Image, cols, rows int) []string {
+	small := scale(img, cols, rows*2)
+	lines := make([]string, rows)
+	var b strings.Builder
+	for y := range rows {
+		b.Reset()
+		var fg, bg color.RGBA
+		for x := range cols {
+			top := small.RGBAAt(x, y*2)
+			bottom := small.RGBAAt(x, y*2+1)
+			if x == 0 || top != fg {
+				fmt.Fprintf(&b, "\x1b[38;2;%d;%d;%dm", top.R, top.G, top.B)
+			}
+			if x == 0 || bottom != bg {
+				fmt.Fprintf(&b, "\x1b[48;2;%d;%d;%dm", bottom.R, bottom.G, bottom.B)
+			}
+			fg, bg = top, bottom
+			b.WriteString("▀")
+		}
+		b.WriteString("\x1b[0m")
+		lines[y] = b.String()
+	}
+	return lines
+}
+
+// scale resizes an image to w by h pixels, averaging the pixels each one
+// covers. Transparent parts are drawn over black.
+func scale(img image.Image, w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	bounds := img.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	if sw == 0 || sh == 0 {
+		return dst
+	}
+	for y := range h {
+		y0 := bounds.Min.Y + y*sh/h
+		y1 := max(bounds.Min.Y+(y+1)*sh/h, y0+1)
+		for x := range w {
+			x0 := bounds.Min.X + x*sw/w
+			x1 := max(bounds.Min.X+(x+1)*sw/w, x0+1)
+			// Sample at most 8×8 pixels of the area for large reductions
+			stepX, stepY := max((x1-x0)/8, 1), max((y1-y0)/8, 1)
+			var r, g, b, n uint64
+			for sy := y0; sy < y1; sy += stepY {
+				for sx := x0; sx < x1; sx += stepX {
+					// Premultiplied by alpha, which blends over black
+					pr, pg, pb, _ := img.At(sx, sy).RGBA()
+					r, g, b, n = r+uint64(pr), g+uint64(pg), b+uint64(pb), n+1
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{uint8(r / n >> 8), uint8(g / n >> 8), uint8(b / n >> 8), 255})
+		}
+	}
+	return dst
+}
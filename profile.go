@@ -0,0 +1,23 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	_ "net/http/pprof" // Registers the /debug/pprof handlers
+)
+
+// profileAddr is where --profile serves pprof; another free port is used if it is taken
+const profileAddr = "localhost:6060"
+
+// startProfiling serves the pprof handlers on a local port and returns its address
+func startProfiling() (string, error) {
+	listener, err := net.Listen("tcp", profileAddr)
+	if err != nil {
+		listener, err = net.Listen("tcp", "localhost:0")
+		if err != nil {
+			return "", err
+		}
+	}
+	go http.Serve(listener, nil)
+	return listener.Addr().String(), nil
+}
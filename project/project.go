@@ -0,0 +1,97 @@
+package project
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/config"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+)
+
+// recentFile is the state file holding recently used projects
+const recentFile = "projects.json"
+
+// maxRecent caps the number of remembered projects
+const maxRecent = 20
+
+// Marker is a file or directory whose presence identifies a project root
+type Marker struct {
+	Name string // File or directory name to look for
+	Kind string // Short project kind shown in the UI
+}
+
+// Markers lists the recognized project root markers in priority order
+var Markers = []Marker{
+	{Name: "go.mod", Kind: "go"},
+	{Name: "package.json", Kind: "node"},
+	{Name: ".git", Kind: "git"},
+}
+
+// Project describes a detected project root
+type Project struct {
+	Root     string    `json:"root"`
+	Name     string    `json:"name"`
+	Kinds    []string  `json:"kinds"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// Detect walks up from path and returns the nearest directory containing a project marker
+func Detect(path string) (Project, bool) {
+	dir := filepath.Clean(path)
+	for {
+		if kinds := markersIn(dir); len(kinds) > 0 {
+			return Project{
+				Root:  dir,
+				Name:  filepath.Base(dir),
+				Kinds: kinds,
+			}, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return Project{}, false
+		}
+		dir = parent
+	}
+}
+
+// markersIn returns the kinds of all markers present in dir
+func markersIn(dir string) []string {
+	var kinds []string
+	for _, marker := range Markers {
+		if _, err := vfs.Default.Stat(filepath.Join(dir, marker.Name)); err == nil {
+			kinds = append(kinds, marker.Kind)
+		}
+	}
+	return kinds
+}
+
+// LoadRecent returns the recently used projects, most recent first
+func LoadRecent() ([]Project, error) {
+	var projects []Project
+	if err := config.Load(recentFile, &projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// Touch records p as the most recently used project
+func Touch(p Project) error {
+	projects, err := LoadRecent()
+	if err != nil {
+		projects = nil
+	}
+
+	p.LastUsed = time.Now()
+	updated := []Project{p}
+	for _, existing := range projects {
+		if existing.Root != p.Root {
+			updated = append(updated, existing)
+		}
+	}
+	if len(updated) > maxRecent {
+		updated = updated[:maxRecent]
+	}
+
+	return config.Save(recentFile, updated)
+}
@@ -0,0 +1,222 @@
+// Package scheduler lists and controls Windows Task Scheduler tasks
+package scheduler
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Columns of schtasks /query /fo csv /v. Headers are translated on localized
+// systems, so columns are found by position.
+const (
+	columnName       = 1
+	columnNextRun    = 2
+	columnStatus     = 3
+	columnLastRun    = 5
+	columnLastResult = 6
+	columnState      = 11
+	columnCount      = 12
+)
+
+// Task is a scheduled task
+type Task struct {
+	Name       string // Full path, e.g. \Microsoft\Windows\Defrag\ScheduledDefrag
+	Status     string // Ready, Running, Disabled, ...
+	NextRun    string
+	LastRun    string
+	LastResult int64
+	Enabled    bool
+}
+
+// resultNames describes the common task result codes
+var resultNames = map[int64]string{
+	0:          "Success",
+	0x41300:    "Ready",
+	0x41301:    "Running",
+	0x41302:    "Disabled",
+	0x41303:    "Not run yet",
+	0x41306:    "Terminated",
+	0x8004131F: "Already running",
+	0x800710E0: "Refused",
+}
+
+// ResultText describes a task result code
+func ResultText(code int64) string {
+	if name, ok := resultNames[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%X", code)
+}
+
+// Failed reports whether a result code is an error rather than a state
+func Failed(code int64) bool {
+	_, known := resultNames[code]
+	return !known
+}
+
+// List returns the scheduled tasks of this machine
+func List() ([]Task, error) {
+	out, err := run("/query", "/fo", "csv", "/v", "/nh")
+	if err != nil {
+		return nil, err
+	}
+	return parseTasks(out)
+}
+
+// Run starts a task now
+func Run(name string) error {
+	_, err := run("/run", "/tn", name)
+	return err
+}
+
+// SetEnabled enables or disables a task
+func SetEnabled(name string, enabled bool) error {
+	flag := "/disable"
+	if enabled {
+		flag = "/enable"
+	}
+	_, err := run("/change", "/tn", name, flag)
+	return err
+}
+
+// parseTasks parses the verbose CSV listing of schtasks. Tasks with several
+// triggers are listed once per trigger; only the first row is kept.
+func parseTasks(out string) ([]Task, error) {
+	reader := csv.NewReader(strings.NewReader(out))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []Task
+	seen := make(map[string]bool)
+	for _, record := range records {
+		if len(record) < columnCount || !strings.HasPrefix(record[columnName], `\`) {
+			// Header rows repeated for each folder
+			continue
+		}
+		name := record[columnName]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		// Results are printed as signed decimals
+		result, _ := strconv.ParseInt(strings.TrimSpace(record[columnLastResult]), 10, 64)
+		tasks = append(tasks, Task{
+			Name:       name,
+			Status:     record[columnStatus],
+			NextRun:    record[columnNextRun],
+			LastRun:    record[columnLastRun],
+			LastResult: int64(uint32(result)),
+			Enabled:    !strings.EqualFold(strings.TrimSpace(record[columnState]), "Disabled"),
+		})
+	}
+	return tasks, nil
+}
+
+// StartupTask is a task that runs when a user logs on or the system starts
+type StartupTask struct {
+	Name    string
+	Command string // Program and arguments of the first action
+	Boot    bool   // Triggered at system start rather than logon
+	Enabled bool
+}
+
+// StartupTasks returns the tasks with logon or boot triggers
+func StartupTasks() ([]StartupTask, error) {
+	out, err := run("/query", "/xml", "ONE")
+	if err != nil {
+		return nil, err
+	}
+	return parseStartupTasks(out)
+}
+
+// taskXML is the part of the task schema needed to find startup tasks
+type taskXML struct {
+	Settings struct {
+		Enabled string
+	}
+	Triggers struct {
+		Logon []triggerXML `xml:"LogonTrigger"`
+		Boot  []triggerXML `xml:"BootTrigger"`
+	}
+	Actions struct {
+		Exec []struct {
+			Command   string
+			Arguments string
+		}
+	}
+}
+
+// triggerXML is a task trigger; triggers are enabled unless stated otherwise
+type triggerXML struct {
+	Enabled string
+}
+
+// parseStartupTasks parses the tasks of schtasks /query /xml ONE, where each
+// task is preceded by a comment with its name
+func parseStartupTasks(out string) ([]StartupTask, error) {
+	decoder := xml.NewDecoder(strings.NewReader(out))
+	// The declaration names the console's encoding, which the text already is in
+	decoder.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) { return r, nil }
+
+	var tasks []StartupTask
+	name := ""
+	for {
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.Comment:
+			name = strings.TrimSpace(string(tok))
+		case xml.StartElement:
+			if tok.Name.Local != "Task" {
+				continue
+			}
+			var task taskXML
+			if err := decoder.DecodeElement(&task, &tok); err != nil {
+				return nil, err
+			}
+			if st, ok := startupTask(name, task); ok {
+				tasks = append(tasks, st)
+			}
+		}
+	}
+	return tasks, nil
+}
+
+// startupTask converts a task with an enabled logon or boot trigger
+func startupTask(name string, task taskXML) (StartupTask, bool) {
+	enabled := func(triggers []triggerXML) bool {
+		for _, trigger := range triggers {
+			if trigger.Enabled != "false" {
+				return true
+			}
+		}
+		return false
+	}
+	logon, boot := enabled(task.Triggers.Logon), enabled(task.Triggers.Boot)
+	if !logon && !boot {
+		return StartupTask{}, false
+	}
+
+	st := StartupTask{Name: name, Boot: !logon, Enabled: task.Settings.Enabled != "false"}
+	if len(task.Actions.Exec) > 0 {
+		exec := task.Actions.Exec[0]
+		st.Command = strings.TrimSpace(exec.Command + " " + exec.Arguments)
+	}
+	return st, true
+}
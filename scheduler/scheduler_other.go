@@ -0,0 +1,9 @@
+//go:build !windows
+
+package scheduler
+
+import "errors"
+
+func run(args ...string) (string, error) {
+	return "", errors.New("scheduled tasks are only available on Windows")
+}
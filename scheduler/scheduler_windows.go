@@ -0,0 +1,31 @@
+//go:build windows
+
+package scheduler
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// run executes schtasks and returns its output, turning its error text into
+// the error
+func run(args ...string) (string, error) {
+	cmd := exec.Command("schtasks", args...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		// schtasks prints "ERROR: Access is denied." and similar
+		msg := strings.TrimSpace(stderr.String())
+		msg = strings.TrimSpace(strings.TrimPrefix(msg, "ERROR:"))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("schtasks %s: %s", strings.TrimPrefix(args[0], "/"), msg)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,114 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Scores of the fuzzy matcher. Every matched character earns scoreMatch;
+// characters at the start of a word or right after the previous match earn
+// more, so "fb" prefers "foo_bar" to "fizzbuzz". Gaps between matches cost
+// a little, the longer the more.
+const (
+	scoreMatch       = 16
+	bonusSeparator   = 10 // After a path separator
+	bonusBoundary    = 8  // After "_", "-", ".", a space or at a camelCase hump
+	bonusConsecutive = 6
+	penaltyGapStart  = 3
+	penaltyGapExtend = 1
+)
+
+// Fuzzy reports whether the characters of pattern appear in text in order,
+// ignoring case, and scores the match: higher scores are better. positions
+// are the indexes of the matched runes in text. Matches in the last path
+// element are tried first, so file names beat the directories above them.
+func Fuzzy(pattern, text string) (score int, positions []int, ok bool) {
+	needle := lowerRunes(pattern)
+	if len(needle) == 0 {
+		return 0, nil, true
+	}
+	runes := []rune(text)
+	lower := lowerRunes(text)
+
+	base := len([]rune(text[:strings.LastIndexAny(text, `/\`)+1]))
+	for _, from := range []int{base, 0} {
+		found, matched := align(needle, lower, from)
+		if !matched {
+			continue
+		}
+		if s := scorePositions(runes, found); positions == nil || s > score {
+			score, positions = s, found
+		}
+		if from == 0 {
+			break
+		}
+	}
+	return score, positions, positions != nil
+}
+
+// lowerRunes returns the runes of s in lower case, one for each rune of s
+func lowerRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+// align finds the shortest run of text, starting the search at from, that
+// contains needle in order: it scans forward to the earliest full match and then
+// backward from its end, giving the tightest start
+func align(needle, text []rune, from int) ([]int, bool) {
+	n := 0
+	end := -1
+	for i := from; i < len(text); i++ {
+		if text[i] == needle[n] {
+			n++
+			if n == len(needle) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return nil, false
+	}
+
+	positions := make([]int, len(needle))
+	n = len(needle) - 1
+	for i := end; i >= from && n >= 0; i-- {
+		if text[i] == needle[n] {
+			positions[n] = i
+			n--
+		}
+	}
+	return positions, true
+}
+
+// scorePositions scores the matched positions of text
+func scorePositions(text []rune, positions []int) int {
+	score := 0
+	for n, i := range positions {
+		score += scoreMatch
+		if i == 0 {
+			score += bonusSeparator
+		} else {
+			prev := text[i-1]
+			switch {
+			case prev == '/' || prev == '\\':
+				score += bonusSeparator
+			case strings.ContainsRune("_-. ", prev),
+				unicode.IsLower(prev) && unicode.IsUpper(text[i]):
+				score += bonusBoundary
+			}
+		}
+		if n > 0 {
+			if gap := i - positions[n-1] - 1; gap == 0 {
+				score += bonusConsecutive
+			} else {
+				score -= penaltyGapStart + (gap-1)*penaltyGapExtend
+			}
+		}
+	}
+	return score
+}
@@ -0,0 +1,155 @@
+// Package search finds the lines containing a text in the files below a
+// directory, reading the files on several workers
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/HolyStarGazer/windows-tui-go/ops"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+)
+
+// sniffSize is how much of a file is checked for NUL bytes to detect binary
+// files, which are not searched
+const sniffSize = 8 << 10
+
+// maxLineLength is the longest line that is searched; longer lines, as found
+// in minified files, end the search of their file
+const maxLineLength = 1 << 20
+
+// DefaultSkipDirs are directory names recursive searches never descend into
+var DefaultSkipDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	".vs":          true,
+	".idea":        true,
+}
+
+// Match is a line containing the searched text
+type Match struct {
+	Path string
+	Line int    // Line number, starting at 1
+	Text string // The line, without its line ending
+}
+
+// Options control what a search looks at
+type Options struct {
+	SkipDirs   map[string]bool // Directory names not descended into, such as ".git"
+	MaxResults int             // Matches after which the search stops, 0 for no limit
+	Progress   *ops.Progress   // Counts the files and bytes searched; canceling it stops the search
+}
+
+// Grep returns the lines of the files below root that contain text, ignoring
+// case, ordered by path and line. Unreadable files and directories are skipped.
+// truncated reports whether the search stopped at MaxResults. A canceled
+// search returns ops.ErrCanceled with the matches found so far.
+func Grep(root, text string, opts Options) (matches []Match, truncated bool, err error) {
+	needle := []byte(strings.ToLower(text))
+	paths := make(chan string)
+	found := make(chan []Match)
+	stop := make(chan struct{})
+
+	var workers sync.WaitGroup
+	for i := 0; i < ops.Workers(); i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for path := range paths {
+				var fileMatches []Match
+				ops.Work(func() { fileMatches = grepFile(path, needle, opts.Progress) })
+				if len(fileMatches) > 0 {
+					found <- fileMatches
+				}
+			}
+		}()
+	}
+	go func() {
+		workers.Wait()
+		close(found)
+	}()
+
+	var walkErr error
+	go func() {
+		defer close(paths)
+		walkErr = vfs.WalkDir(vfs.Default, root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// Skip unreadable entries instead of aborting the walk
+				if d != nil && d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				if path != root && opts.SkipDirs[d.Name()] {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			if opts.Progress.Check() != nil {
+				return fs.SkipAll
+			}
+			select {
+			case paths <- path:
+				return nil
+			case <-stop:
+				return fs.SkipAll
+			}
+		})
+	}()
+
+	for fileMatches := range found {
+		if truncated {
+			continue // Drain the workers
+		}
+		matches = append(matches, fileMatches...)
+		if opts.MaxResults > 0 && len(matches) >= opts.MaxResults {
+			truncated = true
+			close(stop)
+		}
+	}
+
+	sort.SliceStable(matches, func(a, b int) bool { return matches[a].Path < matches[b].Path })
+	if truncated {
+		matches = matches[:opts.MaxResults]
+	}
+	if walkErr == nil && opts.Progress.Canceled() {
+		walkErr = ops.ErrCanceled
+	}
+	return matches, truncated, walkErr
+}
+
+// grepFile returns the lines of a file that contain needle, which is lower
+// case, counting the bytes read in progress. Binary files have no matches.
+func grepFile(path string, needle []byte, progress *ops.Progress) []Match {
+	f, err := vfs.Default.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	defer progress.AddItem()
+
+	reader := bufio.NewReaderSize(progress.Reader(ops.Throttle(f)), sniffSize)
+	if head, _ := reader.Peek(sniffSize); bytes.IndexByte(head, 0) >= 0 {
+		return nil
+	}
+
+	var matches []Match
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLineLength)
+	for line := 1; scanner.Scan(); line++ {
+		if bytes.Contains(bytes.ToLower(scanner.Bytes()), needle) {
+			text := strings.TrimSuffix(scanner.Text(), "\r")
+			matches = append(matches, Match{Path: path, Line: line, Text: text})
+		}
+	}
+	return matches
+}
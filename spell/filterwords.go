@@ -0,0 +1,125 @@
+//go:build ignore
+
+// filterwords keeps in words.txt only the entries that are words: those
+// Vim's English spell dictionary knows, short ones from a fixed list, and a
+// few technical words common in prose. Dump the dictionary with
+//
+//	vim -u NONE -c 'set spell spelllang=en' -c spelldump -c 'w dump.txt' -c 'qa!'
+//
+// and run go run filterwords.go dump.txt in spell.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// twoLetterWords are the two-letter entries kept, as the dictionary lists
+// many abbreviations of two letters and writes OK in capitals
+var twoLetterWords = strings.Fields(`ad ah am an as at aw ax be by do eh ex go ha he hi ho id if
+	in is it me my no of oh ok on or ow ox pa pi re so to uh um up us we ye yo`)
+
+// technicalWords are kept though the dictionary lacks them, as they are
+// common in the notes and READMEs the checker reads
+var technicalWords = strings.Fields(`config configs timestamp timestamps unicode stdin stdout
+	stderr localhost namespace namespaces subdirectory subdirectories`)
+
+// calendarWords are the months and days in lower case
+var calendarWords = func() map[string]bool {
+	words := make(map[string]bool)
+	for month := time.January; month <= time.December; month++ {
+		words[strings.ToLower(month.String())] = true
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		words[strings.ToLower(day.String())] = true
+	}
+	return words
+}()
+
+// main filters words.txt with the dictionary dumped to the file named by the
+// argument
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: go run filterwords.go <spelldump file>")
+		os.Exit(2)
+	}
+	if err := filterWords("words.txt", os.Args[1]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// filterWords rewrites the word list with the entries that pass isWord,
+// sorted and without duplicates
+func filterWords(list, dump string) error {
+	known, err := readDump(dump)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(list)
+	if err != nil {
+		return err
+	}
+	words := append(strings.Fields(string(data)), technicalWords...)
+	var kept []string
+	for _, word := range words {
+		if isWord(word, known) {
+			kept = append(kept, word)
+		}
+	}
+	slices.Sort(kept)
+	kept = slices.Compact(kept)
+	fmt.Printf("Kept %d of %d words\n", len(kept), len(strings.Fields(string(data))))
+	return os.WriteFile(list, []byte(strings.Join(kept, "\n")+"\n"), 0o644)
+}
+
+// readDump reads the words of a :spelldump, which lists one word a line
+// followed by its regions after a slash
+func readDump(name string) (map[string]bool, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	known := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == '/' || line[0] == '#' {
+			continue
+		}
+		word, _, _ := strings.Cut(line, "/")
+		known[word] = true
+	}
+	return known, scanner.Err()
+}
+
+// isWord reports whether an entry is kept: a listed short word, a month or
+// day, which the dictionary knows capitalized, or a longer word it knows in
+// lower case that is not a run of one letter or without a vowel
+func isWord(word string, known map[string]bool) bool {
+	switch {
+	case slices.Contains(technicalWords, word) || calendarWords[word]:
+		return true
+	case len(word) == 1:
+		return word == "a" || word == "i"
+	case len(word) == 2:
+		return slices.Contains(twoLetterWords, word)
+	}
+	return known[word] && !hasRun(word) && strings.ContainsAny(word, "aeiouy")
+}
+
+// hasRun reports whether a word has the same letter three times in a row,
+// as in placeholders such as xxx
+func hasRun(word string) bool {
+	for i := 2; i < len(word); i++ {
+		if word[i] == word[i-1] && word[i] == word[i-2] {
+			return true
+		}
+	}
+	return false
+}
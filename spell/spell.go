@@ -0,0 +1,290 @@
+// Package spell finds misspelled words in text, using a bundled English word
+// list and the user's own words
+package spell
+
+import (
+	_ "embed"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/HolyStarGazer/windows-tui-go/config"
+)
+
+// userFile is the state file holding the words the user added
+const userFile = "words.json"
+
+// bundled is the English word list, one word a line. It was collected for
+// this project from the words used at least three times in two of: the
+// English man pages of a Debian system, the doc comments of the Go standard
+// library and the docstrings of the Python standard library, with a
+// hand-written list of everyday words. filterwords.go then kept the short
+// words of a fixed list, the months and days and a few technical words, and
+// otherwise only the words Vim's English spell dictionary also has. It is
+// covered by the project's license.
+//
+//go:embed words.txt
+var bundled string
+
+// Range is the byte range of a misspelled word in a line
+type Range struct {
+	Start, End int
+}
+
+// contractions are the common contractions, which the suffix rules do not cover
+var contractions = map[string]bool{
+	"ain't": true, "aren't": true, "can't": true, "couldn't": true, "didn't": true,
+	"doesn't": true, "don't": true, "hadn't": true, "hasn't": true, "haven't": true,
+	"isn't": true, "mustn't": true, "needn't": true, "shan't": true, "shouldn't": true,
+	"wasn't": true, "weren't": true, "won't": true, "wouldn't": true, "let's": true,
+}
+
+// Checker tells known words from misspelled ones
+type Checker struct {
+	mu    sync.RWMutex
+	words map[string]bool
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultChecker *Checker
+)
+
+// Default returns the checker with the bundled words and those the user added.
+// The word lists are read when it is first used.
+func Default() *Checker {
+	defaultOnce.Do(func() {
+		defaultChecker = New(strings.Fields(bundled))
+		if words, err := LoadUserWords(); err == nil {
+			defaultChecker.Add(words...)
+		}
+	})
+	return defaultChecker
+}
+
+// New creates a checker knowing the given words
+func New(words []string) *Checker {
+	c := &Checker{words: make(map[string]bool, len(words))}
+	c.Add(words...)
+	return c
+}
+
+// Add teaches the checker more words
+func (c *Checker) Add(words ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, word := range words {
+		c.words[strings.ToLower(word)] = true
+	}
+}
+
+// LoadUserWords returns the words the user added
+func LoadUserWords() ([]string, error) {
+	var words []string
+	if err := config.Load(userFile, &words); err != nil {
+		return nil, err
+	}
+	return words, nil
+}
+
+// AddUserWord adds a word to the user's word list and to the default checker
+func AddUserWord(word string) error {
+	words, err := LoadUserWords()
+	if err != nil {
+		return err
+	}
+	word = strings.ToLower(word)
+	if !slices.Contains(words, word) {
+		words = append(words, word)
+		slices.Sort(words)
+		if err := config.Save(userFile, words); err != nil {
+			return err
+		}
+	}
+	Default().Add(word)
+	return nil
+}
+
+// Known reports whether word is spelled correctly. Acronyms, words with
+// capitals inside (identifiers such as camelCase) and single letters are not
+// checked. Plurals, past tenses and other common inflections of known words
+// are accepted.
+func (c *Checker) Known(word string) bool {
+	if utf8.RuneCountInString(word) < 2 {
+		return true
+	}
+	for i, r := range word {
+		if i > 0 && unicode.IsUpper(r) {
+			return true
+		}
+	}
+	word = strings.ToLower(strings.ReplaceAll(word, "’", "'"))
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if contractions[word] {
+		return true
+	}
+	if base, suffix, ok := strings.Cut(word, "'"); ok {
+		// Possessives and contractions such as "it's", "we'll" and "you're"
+		switch suffix {
+		case "", "s", "d", "ll", "m", "re", "ve":
+			return c.known(base, 2)
+		}
+		return false
+	}
+	return c.known(word, 2)
+}
+
+// suffixes are the inflections removed to find a known stem. Each replacement
+// is tried in turn: "tries" may be "try" + "es", "flies" "fly" + "es".
+var suffixes = []struct {
+	suffix  string
+	replace []string
+}{
+	{"ies", []string{"y"}},
+	{"ied", []string{"y"}},
+	{"ier", []string{"y"}},
+	{"iest", []string{"y"}},
+	{"ily", []string{"y"}},
+	{"iness", []string{"y"}},
+	{"es", []string{"", "e"}},
+	{"s", []string{""}},
+	{"ed", []string{"", "e"}},
+	{"ing", []string{"", "e"}},
+	{"er", []string{"", "e"}},
+	{"ers", []string{"", "e"}},
+	{"est", []string{"", "e"}},
+	{"ly", []string{"", "le"}},
+	{"ness", []string{""}},
+	{"ment", []string{""}},
+	{"able", []string{"", "e"}},
+	{"ably", []string{"", "e"}},
+	{"ability", []string{"", "e"}},
+	{"ation", []string{"e", ""}},
+	{"ations", []string{"e", ""}},
+}
+
+// prefixes are removed to find a known word, as in "unmounted" or "reload"
+var prefixes = []string{"un", "re", "non", "pre", "sub", "over", "under", "multi", "auto", "co"}
+
+// known looks word up, removing up to depth affixes to find a known stem
+func (c *Checker) known(word string, depth int) bool {
+	if c.words[word] {
+		return true
+	}
+	if depth == 0 || len(word) < 4 {
+		return false
+	}
+	for _, s := range suffixes {
+		stem, ok := strings.CutSuffix(word, s.suffix)
+		if !ok || len(stem) < 2 {
+			continue
+		}
+		for _, r := range s.replace {
+			if c.known(stem+r, depth-1) {
+				return true
+			}
+		}
+		// A doubled final consonant, as in "stopped" or "running"
+		if n := len(stem); n >= 3 && stem[n-1] == stem[n-2] && !strings.ContainsRune("aeiou", rune(stem[n-1])) {
+			if c.known(stem[:n-1], depth-1) {
+				return true
+			}
+		}
+	}
+	for _, p := range prefixes {
+		if stem, ok := strings.CutPrefix(word, p); ok && len(stem) >= 3 {
+			if c.known(strings.TrimPrefix(stem, "-"), depth-1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// skipField reports whether a whitespace-separated field is not prose but a
+// path, address, identifier or piece of code, which are not checked
+func skipField(field string) bool {
+	if strings.ContainsAny(field, `/\_@=<>{}$|~^*+`) {
+		return true
+	}
+	// A dot between letters, as in file names and "e.g."
+	for i := 1; i < len(field)-1; i++ {
+		if field[i] == '.' && isLetter(field[i-1]) && isLetter(field[i+1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLetter reports whether an ASCII byte is a letter
+func isLetter(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// Check returns the misspelled words of a line of prose. Fields that look like
+// paths or code are skipped, as are words with digits or letters outside ASCII.
+func (c *Checker) Check(line string) []Range {
+	var ranges []Range
+	pos := 0
+	for _, field := range strings.Fields(line) {
+		start := pos + strings.Index(line[pos:], field)
+		pos = start + len(field)
+		if skipField(field) {
+			continue
+		}
+		for _, w := range words(field) {
+			if !w.plain || c.Known(field[w.Start:w.End]) {
+				continue
+			}
+			ranges = append(ranges, Range{start + w.Start, start + w.End})
+		}
+	}
+	return ranges
+}
+
+// word is a run of letters in a field, with apostrophes inside it
+type word struct {
+	Range
+	plain bool // Only ASCII letters and apostrophes, not touching digits
+}
+
+// words splits a field into its words
+func words(field string) []word {
+	var found []word
+	for i := 0; i < len(field); {
+		r, size := utf8.DecodeRuneInString(field[i:])
+		if !unicode.IsLetter(r) {
+			i += size
+			continue
+		}
+		w := word{Range: Range{Start: i}, plain: true}
+		if i > 0 && unicode.IsDigit(rune(field[i-1])) {
+			w.plain = false
+		}
+		for i < len(field) {
+			r, size = utf8.DecodeRuneInString(field[i:])
+			if r == '\'' || r == '’' {
+				// An apostrophe belongs to the word only if a letter follows
+				next, _ := utf8.DecodeRuneInString(field[i+size:])
+				if !unicode.IsLetter(next) {
+					break
+				}
+			} else if !unicode.IsLetter(r) {
+				break
+			} else if r >= utf8.RuneSelf {
+				w.plain = false
+			}
+			i += size
+		}
+		w.End = i
+		if i < len(field) && unicode.IsDigit(rune(field[i])) {
+			w.plain = false
+		}
+		found = append(found, w)
+	}
+	return found
+}
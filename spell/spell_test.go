@@ -0,0 +1,44 @@
+package spell
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestBundledWords checks the bundled list holds words, sorted and once each,
+// and no placeholders or abbreviations that would hide short typos
+func TestBundledWords(t *testing.T) {
+	words := strings.Fields(bundled)
+	if !slices.IsSorted(words) || len(slices.Compact(slices.Clone(words))) != len(words) {
+		t.Error("words.txt is not sorted without duplicates (go run filterwords.go sorts it)")
+	}
+	for _, word := range words {
+		run := false
+		for i := 2; i < len(word); i++ {
+			run = run || word[i] == word[i-1] && word[i] == word[i-2]
+		}
+		switch {
+		case strings.ToLower(word) != word || strings.ContainsFunc(word, func(r rune) bool { return r < 'a' || r > 'z' }):
+			t.Errorf("%q is not a lower-case word", word)
+		case len(word) == 1 && word != "a" && word != "i":
+			t.Errorf("%q is a single letter", word)
+		case run:
+			t.Errorf("%q repeats a letter three times", word)
+		case len(word) > 2 && !strings.ContainsAny(word, "aeiouy"):
+			t.Errorf("%q has no vowel", word)
+		}
+	}
+
+	checker := New(words)
+	for _, typo := range []string{"teh", "adn", "wiht", "aaaaa", "zzz", "sb", "xx", "occurences"} {
+		if checker.Known(typo) {
+			t.Errorf("%q is taken for a word", typo)
+		}
+	}
+	for _, word := range []string{"the", "and", "with", "an", "ok", "january", "config", "running", "files"} {
+		if !checker.Known(word) {
+			t.Errorf("%q is taken for a typo", word)
+		}
+	}
+}
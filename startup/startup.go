@@ -0,0 +1,73 @@
+// Package startup lists the programs Windows starts automatically
+package startup
+
+import (
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Item is an auto-start entry
+type Item struct {
+	Name    string
+	Command string // Command line, or the file of a startup folder entry
+	Source  string // Where the entry is registered, e.g. "HKCU Run"
+	Path    string // File the entry starts, if it could be found
+	Enabled bool
+}
+
+// List returns the auto-start entries of the Run keys, the startup folders and
+// the scheduled tasks triggered at logon or boot; tasks installed by Windows
+// are included only with all. If the tasks cannot be read, the other entries
+// are returned with the error.
+func List(all bool) ([]Item, error) {
+	return list(all)
+}
+
+// percentVar matches %NAME% environment references
+var percentVar = regexp.MustCompile(`%([^%]+)%`)
+
+// expandVars expands %NAME% references, leaving unknown names as they are
+func expandVars(s string) string {
+	return percentVar.ReplaceAllStringFunc(s, func(ref string) string {
+		if value, ok := os.LookupEnv(ref[1 : len(ref)-1]); ok {
+			return value
+		}
+		return ref
+	})
+}
+
+// isFile reports whether path names an existing file
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// executableOf finds the existing file a command line starts: the quoted first
+// argument, or the longest run of leading words naming a file, as paths with
+// spaces are often left unquoted
+func executableOf(command string) string {
+	command = strings.TrimSpace(expandVars(command))
+	if strings.HasPrefix(command, `"`) {
+		if end := strings.Index(command[1:], `"`); end >= 0 && isFile(command[1:end+1]) {
+			return command[1 : end+1]
+		}
+		return ""
+	}
+
+	words := strings.Fields(command)
+	for n := len(words); n > 0; n-- {
+		if candidate := strings.Join(words[:n], " "); isFile(candidate) {
+			return candidate
+		}
+	}
+	if len(words) == 0 {
+		return ""
+	}
+	// Programs run by name are found on the path
+	if found, err := exec.LookPath(words[0]); err == nil {
+		return found
+	}
+	return ""
+}
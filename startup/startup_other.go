@@ -0,0 +1,9 @@
+//go:build !windows
+
+package startup
+
+import "errors"
+
+func list(all bool) ([]Item, error) {
+	return nil, errors.New("startup items are only listed on Windows")
+}
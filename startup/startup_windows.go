@@ -0,0 +1,146 @@
+//go:build windows
+
+package startup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/scheduler"
+	"golang.org/x/sys/windows/registry"
+)
+
+// approvedKey holds the enabled state Task Manager keeps for Run keys and
+// startup folders
+const approvedKey = `Software\Microsoft\Windows\CurrentVersion\Explorer\StartupApproved\`
+
+// runKeys are the registry keys whose values are started at logon
+var runKeys = []struct {
+	Source   string
+	Root     registry.Key
+	Path     string
+	Approved string // Subkey of approvedKey, empty if Task Manager does not manage it
+}{
+	{"HKCU Run", registry.CURRENT_USER, `Software\Microsoft\Windows\CurrentVersion\Run`, "Run"},
+	{"HKCU RunOnce", registry.CURRENT_USER, `Software\Microsoft\Windows\CurrentVersion\RunOnce`, ""},
+	{"HKLM Run", registry.LOCAL_MACHINE, `SOFTWARE\Microsoft\Windows\CurrentVersion\Run`, "Run"},
+	{"HKLM Run (32-bit)", registry.LOCAL_MACHINE, `SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\Run`, "Run32"},
+	{"HKLM RunOnce", registry.LOCAL_MACHINE, `SOFTWARE\Microsoft\Windows\CurrentVersion\RunOnce`, ""},
+}
+
+func list(all bool) ([]Item, error) {
+	var items []Item
+	for _, run := range runKeys {
+		items = append(items, readRunKey(run.Source, run.Root, run.Path, run.Approved)...)
+	}
+
+	folders := []struct {
+		Source string
+		Root   registry.Key
+		Base   string
+	}{
+		{"Startup folder", registry.CURRENT_USER, os.Getenv("APPDATA")},
+		{"Startup folder (all users)", registry.LOCAL_MACHINE, os.Getenv("ProgramData")},
+	}
+	for _, folder := range folders {
+		if folder.Base == "" {
+			continue
+		}
+		dir := filepath.Join(folder.Base, "Microsoft", "Windows", "Start Menu", "Programs", "Startup")
+		items = append(items, readStartupFolder(folder.Source, folder.Root, dir)...)
+	}
+
+	tasks, err := scheduler.StartupTasks()
+	for _, task := range tasks {
+		if !all && strings.HasPrefix(task.Name, `\Microsoft\`) {
+			continue
+		}
+		source := "Task (logon)"
+		if task.Boot {
+			source = "Task (boot)"
+		}
+		items = append(items, Item{
+			Name:    task.Name,
+			Command: task.Command,
+			Source:  source,
+			Path:    executableOf(task.Command),
+			Enabled: task.Enabled,
+		})
+	}
+	return items, err
+}
+
+// disabledEntries returns the names Task Manager has disabled in a
+// StartupApproved subkey; the first byte of each value is odd when disabled
+func disabledEntries(root registry.Key, subkey string) map[string]bool {
+	disabled := make(map[string]bool)
+	if subkey == "" {
+		return disabled
+	}
+	key, err := registry.OpenKey(root, approvedKey+subkey, registry.QUERY_VALUE)
+	if err != nil {
+		return disabled
+	}
+	defer key.Close()
+
+	names, _ := key.ReadValueNames(-1)
+	for _, name := range names {
+		if data, _, err := key.GetBinaryValue(name); err == nil && len(data) > 0 && data[0]&1 == 1 {
+			disabled[strings.ToLower(name)] = true
+		}
+	}
+	return disabled
+}
+
+// readRunKey lists the values of a Run key
+func readRunKey(source string, root registry.Key, path, approved string) []Item {
+	key, err := registry.OpenKey(root, path, registry.QUERY_VALUE)
+	if err != nil {
+		return nil
+	}
+	defer key.Close()
+
+	disabled := disabledEntries(root, approved)
+	names, _ := key.ReadValueNames(-1)
+	var items []Item
+	for _, name := range names {
+		command, _, err := key.GetStringValue(name)
+		if err != nil || name == "" {
+			continue
+		}
+		items = append(items, Item{
+			Name:    name,
+			Command: command,
+			Source:  source,
+			Path:    executableOf(command),
+			Enabled: !disabled[strings.ToLower(name)],
+		})
+	}
+	return items
+}
+
+// readStartupFolder lists the files of a startup folder
+func readStartupFolder(source string, root registry.Key, dir string) []Item {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	disabled := disabledEntries(root, "StartupFolder")
+	var items []Item
+	for _, entry := range entries {
+		if entry.IsDir() || strings.EqualFold(entry.Name(), "desktop.ini") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		items = append(items, Item{
+			Name:    strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())),
+			Command: path,
+			Source:  source,
+			Path:    path,
+			Enabled: !disabled[strings.ToLower(entry.Name())],
+		})
+	}
+	return items
+}
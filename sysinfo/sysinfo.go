@@ -0,0 +1,51 @@
+// Package sysinfo reports the operating system, uptime, processor and memory
+// of this machine
+package sysinfo
+
+import (
+	"os"
+	"runtime"
+	"time"
+)
+
+// CPUTimes are the processor times summed over all cores since boot, in
+// platform units; CPU usage is the share of non-idle time between two samples
+type CPUTimes struct {
+	Idle  uint64
+	Total uint64
+}
+
+// Info describes this machine
+type Info struct {
+	OS           string // e.g. "Windows 11 Pro 23H2 (build 22631.3007)"
+	Hostname     string
+	Uptime       time.Duration
+	CPU          string // Processor model
+	Cores        int    // Logical processors
+	MemTotal     uint64
+	MemAvailable uint64
+	CPUTimes     CPUTimes
+}
+
+// Read returns the current information; fields that cannot be read are left
+// empty
+func Read() Info {
+	info := read()
+	info.Hostname, _ = os.Hostname()
+	info.Cores = runtime.NumCPU()
+	return info
+}
+
+// CPUUsage returns the percentage of processor time spent working between two
+// samples, or -1 if no time passed
+func CPUUsage(prev, cur CPUTimes) float64 {
+	if cur.Total <= prev.Total {
+		return -1
+	}
+	total := cur.Total - prev.Total
+	idle := cur.Idle - prev.Idle
+	if idle > total {
+		idle = total
+	}
+	return float64(total-idle) * 100 / float64(total)
+}
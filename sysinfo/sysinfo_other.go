@@ -0,0 +1,77 @@
+//go:build !windows
+
+package sysinfo
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// read gathers what /proc and /etc/os-release offer; systems without them get
+// only the platform name
+func read() Info {
+	info := Info{OS: runtime.GOOS}
+	if data, err := os.ReadFile("/etc/os-release"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if value, ok := strings.CutPrefix(line, "PRETTY_NAME="); ok {
+				info.OS = strings.Trim(value, `"`)
+			}
+		}
+	}
+	if release, err := os.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		info.OS += " (kernel " + strings.TrimSpace(string(release)) + ")"
+	}
+
+	if data, err := os.ReadFile("/proc/uptime"); err == nil {
+		if fields := strings.Fields(string(data)); len(fields) > 0 {
+			seconds, _ := strconv.ParseFloat(fields[0], 64)
+			info.Uptime = time.Duration(seconds * float64(time.Second))
+		}
+	}
+
+	if data, err := os.ReadFile("/proc/cpuinfo"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if name, value, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(name) == "model name" {
+				info.CPU = strings.TrimSpace(value)
+				break
+			}
+		}
+	}
+
+	if data, err := os.ReadFile("/proc/meminfo"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			kb, _ := strconv.ParseUint(fields[1], 10, 64)
+			switch fields[0] {
+			case "MemTotal:":
+				info.MemTotal = kb * 1024
+			case "MemAvailable:":
+				info.MemAvailable = kb * 1024
+			}
+		}
+	}
+
+	// The first line of /proc/stat sums the times of all cores; idle and
+	// iowait are the 4th and 5th values, and guest time is already counted as
+	// user time
+	if data, err := os.ReadFile("/proc/stat"); err == nil {
+		line, _, _ := strings.Cut(string(data), "\n")
+		fields := strings.Fields(line)
+		if len(fields) > 5 && fields[0] == "cpu" {
+			for i, field := range fields[1:min(len(fields), 9)] {
+				n, _ := strconv.ParseUint(field, 10, 64)
+				info.CPUTimes.Total += n
+				if i == 3 || i == 4 {
+					info.CPUTimes.Idle += n
+				}
+			}
+		}
+	}
+	return info
+}
@@ -0,0 +1,104 @@
+//go:build windows
+
+package sysinfo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+var (
+	kernel32                 = windows.NewLazySystemDLL("kernel32.dll")
+	procGetTickCount64       = kernel32.NewProc("GetTickCount64")
+	procGlobalMemoryStatusEx = kernel32.NewProc("GlobalMemoryStatusEx")
+	procGetSystemTimes       = kernel32.NewProc("GetSystemTimes")
+)
+
+// memoryStatusEx is MEMORYSTATUSEX
+type memoryStatusEx struct {
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
+func read() Info {
+	var info Info
+	info.OS = osVersion()
+	info.CPU = cpuName()
+
+	if ms, _, _ := procGetTickCount64.Call(); ms != 0 {
+		info.Uptime = time.Duration(ms) * time.Millisecond
+	}
+
+	status := memoryStatusEx{Length: uint32(unsafe.Sizeof(memoryStatusEx{}))}
+	if ok, _, _ := procGlobalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&status))); ok != 0 {
+		info.MemTotal = status.TotalPhys
+		info.MemAvailable = status.AvailPhys
+	}
+
+	// Kernel time includes idle time
+	var idle, kernel, user windows.Filetime
+	if ok, _, _ := procGetSystemTimes.Call(
+		uintptr(unsafe.Pointer(&idle)), uintptr(unsafe.Pointer(&kernel)), uintptr(unsafe.Pointer(&user))); ok != 0 {
+		info.CPUTimes = CPUTimes{
+			Idle:  filetimeTicks(idle),
+			Total: filetimeTicks(kernel) + filetimeTicks(user),
+		}
+	}
+	return info
+}
+
+// filetimeTicks returns a FILETIME as a count of 100ns units
+func filetimeTicks(ft windows.Filetime) uint64 {
+	return uint64(ft.HighDateTime)<<32 | uint64(ft.LowDateTime)
+}
+
+// osVersion describes Windows as Settings does, from the registry
+func osVersion() string {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SOFTWARE\Microsoft\Windows NT\CurrentVersion`, registry.QUERY_VALUE)
+	if err != nil {
+		return "Windows"
+	}
+	defer key.Close()
+
+	name, _, _ := key.GetStringValue("ProductName")
+	release, _, _ := key.GetStringValue("DisplayVersion")
+	build, _, _ := key.GetStringValue("CurrentBuild")
+	revision, _, _ := key.GetIntegerValue("UBR")
+
+	// Windows 11 still reports itself as Windows 10 in ProductName
+	var number int
+	fmt.Sscan(build, &number)
+	if number >= 22000 {
+		name = strings.Replace(name, "Windows 10", "Windows 11", 1)
+	}
+	if release != "" {
+		name += " " + release
+	}
+	if build != "" {
+		name += fmt.Sprintf(" (build %s.%d)", build, revision)
+	}
+	return strings.TrimSpace(name)
+}
+
+// cpuName returns the processor model of the first core
+func cpuName() string {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `HARDWARE\DESCRIPTION\System\CentralProcessor\0`, registry.QUERY_VALUE)
+	if err != nil {
+		return ""
+	}
+	defer key.Close()
+	name, _, _ := key.GetStringValue("ProcessorNameString")
+	return strings.TrimSpace(name)
+}
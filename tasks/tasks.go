@@ -0,0 +1,245 @@
+package tasks
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Task is a runnable command discovered in a project
+type Task struct {
+	Name    string   // Display name, e.g. "build"
+	Source  string   // Where the task was found, e.g. "Makefile"
+	Command string   // Executable to run
+	Args    []string // Arguments passed to the executable
+	Dir     string   // Working directory
+
+	shellLine string // Command line typed for the shell, passed to cmd.exe as it is
+}
+
+// CommandLine returns the task as it would be typed in a shell
+func (t Task) CommandLine() string {
+	return strings.Join(append([]string{t.Command}, t.Args...), " ")
+}
+
+// makeTargetPattern matches explicit Makefile targets such as "build:" or "test-all:"
+var makeTargetPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_.\-/]*)\s*:([^=]|$)`)
+
+// Discover returns the tasks defined in the project at root
+func Discover(root string) []Task {
+	var tasks []Task
+	tasks = append(tasks, makefileTasks(root)...)
+	tasks = append(tasks, packageJSONTasks(root)...)
+	tasks = append(tasks, goTasks(root)...)
+	return tasks
+}
+
+// makefileTasks lists the explicit targets of a Makefile in root
+func makefileTasks(root string) []Task {
+	var path string
+	for _, name := range []string{"Makefile", "makefile", "GNUmakefile"} {
+		if _, err := os.Stat(filepath.Join(root, name)); err == nil {
+			path = filepath.Join(root, name)
+			break
+		}
+	}
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var tasks []Task
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		match := makeTargetPattern.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		target := match[1]
+		// Skip special targets like .PHONY and duplicate rules
+		if strings.HasPrefix(target, ".") || seen[target] {
+			continue
+		}
+		seen[target] = true
+		tasks = append(tasks, Task{
+			Name:    target,
+			Source:  filepath.Base(path),
+			Command: "make",
+			Args:    []string{target},
+			Dir:     root,
+		})
+	}
+
+	return tasks
+}
+
+// packageJSONTasks lists the npm scripts of a package.json in root
+func packageJSONTasks(root string) []Task {
+	data, err := os.ReadFile(filepath.Join(root, "package.json"))
+	if err != nil {
+		return nil
+	}
+
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(pkg.Scripts))
+	for name := range pkg.Scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var tasks []Task
+	for _, name := range names {
+		tasks = append(tasks, Task{
+			Name:    name,
+			Source:  "package.json",
+			Command: "npm",
+			Args:    []string{"run", name},
+			Dir:     root,
+		})
+	}
+	return tasks
+}
+
+// goTasks lists the standard go commands for a module in root
+func goTasks(root string) []Task {
+	if _, err := os.Stat(filepath.Join(root, "go.mod")); err != nil {
+		return nil
+	}
+
+	var tasks []Task
+	for _, sub := range []string{"build", "test", "vet"} {
+		tasks = append(tasks, Task{
+			Name:    "go " + sub,
+			Source:  "go.mod",
+			Command: "go",
+			Args:    []string{sub, "./..."},
+			Dir:     root,
+		})
+	}
+	return tasks
+}
+
+// Run is a running task whose combined output is delivered line by line
+type Run struct {
+	Task  Task
+	Lines <-chan string // Closed when the output ends
+	cmd   *exec.Cmd
+	done  chan error
+
+	mu        sync.Mutex
+	group     *processGroup // The task and the processes it starts
+	exited    bool
+	discarded chan struct{} // Closed by Discard
+	discard   sync.Once
+}
+
+// Start launches the task with stdout and stderr merged into Lines
+func Start(task Task) (*Run, error) {
+	cmd := exec.Command(task.Command, task.Args...)
+	cmd.Dir = task.Dir
+	prepare(cmd, task)
+
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+
+	if err := cmd.Start(); err != nil {
+		writer.Close()
+		return nil, err
+	}
+
+	lines := make(chan string, 256)
+	run := &Run{Task: task, Lines: lines, cmd: cmd, done: make(chan error, 1), group: startGroup(cmd), discarded: make(chan struct{})}
+
+	// Close the pipe once the process exits so the reader sees EOF. Wait
+	// returns once every process holding the output open has exited.
+	go func() {
+		err := cmd.Wait()
+		run.mu.Lock()
+		run.exited = true
+		run.group.release()
+		run.mu.Unlock()
+		writer.Close()
+		run.done <- err
+	}()
+
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	scan:
+		for scanner.Scan() {
+			select {
+			case lines <- strings.TrimRight(scanner.Text(), "\r"):
+			case <-run.discarded:
+				break scan
+			}
+		}
+		// Keep draining after a scan error or Discard so the process never
+		// blocks on a full pipe
+		io.Copy(io.Discard, reader)
+	}()
+
+	return run, nil
+}
+
+// Wait blocks until the task exits and returns its error, if any.
+// It must be called exactly once.
+func (r *Run) Wait() error {
+	return <-r.done
+}
+
+// Kill terminates the task and the processes it started, such as the
+// commands run by a shell
+func (r *Run) Kill() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cmd.Process == nil || r.exited {
+		return nil
+	}
+	return r.group.kill(r.cmd)
+}
+
+// Discard kills the task and drops its output from then on, closing Lines, for
+// a task whose output is no longer read
+func (r *Run) Discard() error {
+	r.discard.Do(func() { close(r.discarded) })
+	return r.Kill()
+}
+
+// Shell returns a task that runs commandLine through the platform shell in dir
+func Shell(commandLine, dir string) Task {
+	task := Task{
+		Name:    commandLine,
+		Source:  "shell",
+		Command: "sh",
+		Args:    []string{"-c", commandLine},
+		Dir:     dir,
+	}
+	if runtime.GOOS == "windows" {
+		task.Command = "cmd"
+		task.Args = []string{"/C", commandLine}
+		task.shellLine = commandLine
+	}
+	return task
+}
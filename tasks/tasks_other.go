@@ -0,0 +1,31 @@
+//go:build !windows
+
+package tasks
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// processGroup stands for the process group a task leads
+type processGroup struct{}
+
+// prepare starts the task in a process group of its own, so that the
+// processes it starts can be killed with it
+func prepare(cmd *exec.Cmd, task Task) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// startGroup returns the process group of a started task, which has the
+// task's process ID
+func startGroup(cmd *exec.Cmd) *processGroup {
+	return &processGroup{}
+}
+
+// kill ends every process in the task's group
+func (g *processGroup) kill(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// release does nothing; the group goes away with its processes
+func (g *processGroup) release() {}
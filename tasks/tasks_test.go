@@ -0,0 +1,64 @@
+package tasks
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestKillEndsChildren kills a shell task whose command left a process
+// running in the background, which must end too: it holds the output open,
+// so Wait would not return otherwise
+func TestKillEndsChildren(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	run, err := Start(Shell("sleep 60 & sleep 60", t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err := run.Kill(); err != nil {
+		t.Fatal(err)
+	}
+
+	waited := make(chan error, 1)
+	go func() { waited <- run.Wait() }()
+	select {
+	case <-waited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the task's background process kept running after Kill")
+	}
+	for range run.Lines {
+	}
+	if err := run.Kill(); err != nil {
+		t.Errorf("Kill after exit: %v", err)
+	}
+}
+
+// TestDiscardUnreadOutput discards a task with more output waiting than Lines
+// holds, and never reads it, which must still let the task be waited for and
+// close Lines
+func TestDiscardUnreadOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	run, err := Start(Shell("i=0; while [ $i -lt 5000 ]; do echo line $i; i=$((i+1)); done; sleep 60", t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if err := run.Discard(); err != nil {
+		t.Fatal(err)
+	}
+
+	waited := make(chan error, 1)
+	go func() { waited <- run.Wait() }()
+	select {
+	case <-waited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a discarded task was never done")
+	}
+	for range run.Lines {
+	}
+}
@@ -0,0 +1,102 @@
+//go:build windows
+
+package tasks
+
+import (
+	"os/exec"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// processGroup is the job object holding a task and the processes it starts
+type processGroup struct {
+	job windows.Handle // 0 if the task could not be put in one
+}
+
+// prepare starts the task suspended, so that startGroup can put it in a job
+// before it runs anything. A shell task's line goes to cmd.exe as typed after
+// cmd /C: cmd does not undo the quoting Go gives each argument, so quotes in
+// the line would reach the command escaped.
+func prepare(cmd *exec.Cmd, task Task) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: windows.CREATE_SUSPENDED}
+	if task.shellLine != "" {
+		cmd.SysProcAttr.CmdLine = "cmd /C " + task.shellLine
+	}
+}
+
+// startGroup puts a task started suspended in a new job object, then lets it
+// run. Every process it starts joins the job too, so killing the job ends
+// them all. A task that cannot be resumed is killed rather than left waiting.
+func startGroup(cmd *exec.Cmd) *processGroup {
+	group := newGroup(uint32(cmd.Process.Pid))
+	if err := resume(uint32(cmd.Process.Pid)); err != nil {
+		group.kill(cmd)
+	}
+	return group
+}
+
+// newGroup puts a process in a new job object
+func newGroup(pid uint32) *processGroup {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return &processGroup{}
+	}
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, pid)
+	if err != nil {
+		windows.CloseHandle(job)
+		return &processGroup{}
+	}
+	defer windows.CloseHandle(process)
+	if err := windows.AssignProcessToJobObject(job, process); err != nil {
+		windows.CloseHandle(job)
+		return &processGroup{}
+	}
+	return &processGroup{job: job}
+}
+
+// resume runs the threads of a process started suspended. os/exec closes the
+// main thread's handle, so the process's threads are looked up by ID.
+func resume(pid uint32) error {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPTHREAD, 0)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(snapshot)
+	entry := windows.ThreadEntry32{Size: uint32(unsafe.Sizeof(windows.ThreadEntry32{}))}
+	for err = windows.Thread32First(snapshot, &entry); err == nil; err = windows.Thread32Next(snapshot, &entry) {
+		if entry.OwnerProcessID != pid {
+			continue
+		}
+		thread, openErr := windows.OpenThread(windows.THREAD_SUSPEND_RESUME, false, entry.ThreadID)
+		if openErr != nil {
+			return openErr
+		}
+		_, resumeErr := windows.ResumeThread(thread)
+		windows.CloseHandle(thread)
+		if resumeErr != nil {
+			return resumeErr
+		}
+	}
+	if err == windows.ERROR_NO_MORE_FILES {
+		return nil
+	}
+	return err
+}
+
+// kill ends the task and every process in its job
+func (g *processGroup) kill(cmd *exec.Cmd) error {
+	if g.job == 0 {
+		return cmd.Process.Kill()
+	}
+	return windows.TerminateJobObject(g.job, 1)
+}
+
+// release closes the job object once the task has exited
+func (g *processGroup) release() {
+	if g.job != 0 {
+		windows.CloseHandle(g.job)
+		g.job = 0
+	}
+}
@@ -0,0 +1,71 @@
+package types
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Filter decides which file items a listing shows
+type Filter interface {
+	Keep(item FileItem) bool
+}
+
+// FilterFunc adapts a function to a Filter
+type FilterFunc func(item FileItem) bool
+
+// Keep calls the function
+func (f FilterFunc) Keep(item FileItem) bool { return f(item) }
+
+// NotHidden keeps items that are neither dot files nor hidden
+var NotHidden = FilterFunc(func(item FileItem) bool { return !item.IsHidden() })
+
+// Glob keeps directories and the files whose name matches a glob pattern,
+// ignoring case. An empty pattern keeps everything.
+func Glob(pattern string) Filter {
+	pattern = strings.ToLower(pattern)
+	return FilterFunc(func(item FileItem) bool {
+		if pattern == "" || item.IsDir {
+			return true
+		}
+		ok, _ := filepath.Match(pattern, strings.ToLower(item.Name))
+		return ok
+	})
+}
+
+// NameContains keeps items whose name contains text, ignoring case
+func NameContains(text string) Filter {
+	text = strings.ToLower(text)
+	return FilterFunc(func(item FileItem) bool {
+		return strings.Contains(strings.ToLower(item.Name), text)
+	})
+}
+
+// DirsOnly keeps directories
+var DirsOnly = FilterFunc(func(item FileItem) bool { return item.IsDir })
+
+// FilesOnly keeps everything but directories
+var FilesOnly = FilterFunc(func(item FileItem) bool { return !item.IsDir })
+
+// Extensions keeps directories and the files with one of the extensions,
+// given with or without the dot and ignoring case
+func Extensions(exts ...string) Filter {
+	keep := make(map[string]bool, len(exts))
+	for _, ext := range exts {
+		keep["."+strings.TrimPrefix(strings.ToLower(ext), ".")] = true
+	}
+	return FilterFunc(func(item FileItem) bool {
+		return item.IsDir || keep[item.Ext()]
+	})
+}
+
+// All keeps the items every filter keeps; nil filters are skipped
+func All(filters ...Filter) Filter {
+	return FilterFunc(func(item FileItem) bool {
+		for _, f := range filters {
+			if f != nil && !f.Keep(item) {
+				return false
+			}
+		}
+		return true
+	})
+}
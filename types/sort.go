@@ -0,0 +1,73 @@
+package types
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Sorter is an order of file items, such as the browser's sort option.
+// Implementations registered with RegisterSorter can be chosen by name.
+type Sorter interface {
+	// Name is the value of the sort option that selects the order
+	Name() string
+	// Compare returns a negative number when a sorts before b, a positive
+	// number when after and 0 when their order should be kept
+	Compare(a, b FileItem) int
+}
+
+// SortFunc adapts a comparison function to a named Sorter
+type SortFunc struct {
+	ID   string
+	Func func(a, b FileItem) int
+}
+
+// Name returns the sorter's name
+func (s SortFunc) Name() string { return s.ID }
+
+// Compare calls the comparison function
+func (s SortFunc) Compare(a, b FileItem) int { return s.Func(a, b) }
+
+// Built-in orders
+var (
+	ByName = SortFunc{"name", func(a, b FileItem) int { return cmp.Compare(a.Name, b.Name) }}
+	BySize = SortFunc{"size", func(a, b FileItem) int { return cmp.Compare(b.Size, a.Size) }}
+	ByTime = SortFunc{"time", func(a, b FileItem) int { return b.ModTime.Compare(a.ModTime) }}
+	ByExt  = SortFunc{"ext", func(a, b FileItem) int { return cmp.Compare(a.Ext(), b.Ext()) }}
+)
+
+// sorters are the orders that can be chosen by name, in registration order
+var sorters = []Sorter{ByName, BySize, ByTime, ByExt}
+
+// RegisterSorter makes an order available by name, replacing any registered
+// under the same name
+func RegisterSorter(s Sorter) {
+	if i := slices.IndexFunc(sorters, func(o Sorter) bool { return o.Name() == s.Name() }); i >= 0 {
+		sorters[i] = s
+		return
+	}
+	sorters = append(sorters, s)
+}
+
+// SorterNamed returns the registered order called name
+func SorterNamed(name string) (Sorter, bool) {
+	for _, s := range sorters {
+		if s.Name() == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// SorterNames returns the names of the registered orders
+func SorterNames() []string {
+	names := make([]string, len(sorters))
+	for i, s := range sorters {
+		names[i] = s.Name()
+	}
+	return names
+}
+
+// Sort orders items by s, keeping the existing order of items it ranks equal
+func Sort(items []FileItem, s Sorter) {
+	slices.SortStableFunc(items, s.Compare)
+}
@@ -1,9 +1,122 @@
 package types
 
+import (
+	"io/fs"
+	"mime"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+)
+
+// File attribute bits reported on Windows, 0 elsewhere
+const (
+	AttrReadOnly   = 0x1
+	AttrHidden     = 0x2
+	AttrSystem     = 0x4
+	AttrArchive    = 0x20
+	AttrSparse     = 0x200
+	AttrCompressed = 0x800
+)
+
 // FileItem represents a file or directory in the file system
 type FileItem struct {
-	Name  string
-	Path  string
-	IsDir bool
-	Size  int64
+	Name       string
+	Path       string
+	IsDir      bool // Also set for links to directories
+	Size       int64
+	ModTime    time.Time
+	Mode       fs.FileMode // Permission and type bits of the entry itself
+	Attributes uint32      // Windows file attributes (Attr*), 0 elsewhere
+	Links      int         // Number of hard links, 0 if not known (on Windows until read)
+	LinkTarget string      // Target of a symbolic link or junction, empty otherwise
+	MIMEType   string      // Guessed from the extension, empty if unknown
+}
+
+// NewFileItem describes the entry at path from its Lstat information. Links
+// are followed for whether they lead to a directory and for their size.
+func NewFileItem(path string, info fs.FileInfo) FileItem {
+	item := FileItem{
+		Name:       info.Name(),
+		Path:       path,
+		IsDir:      info.IsDir(),
+		Size:       info.Size(),
+		ModTime:    info.ModTime(),
+		Mode:       info.Mode(),
+		Attributes: fileAttributes(info),
+		Links:      linkCount(info),
+	}
+	if info.Mode()&(fs.ModeSymlink|fs.ModeIrregular) != 0 {
+		if target, err := vfs.Default.Readlink(path); err == nil {
+			item.LinkTarget = target
+			if target, err := vfs.Default.Stat(path); err == nil {
+				item.IsDir = target.IsDir()
+				item.Size = target.Size()
+			}
+		}
+	}
+	if !item.IsDir {
+		item.MIMEType = mime.TypeByExtension(item.Ext())
+	}
+	return item
+}
+
+// Ext returns the lowercase extension of the name, such as ".go"
+func (f FileItem) Ext() string {
+	return strings.ToLower(filepath.Ext(f.Name))
+}
+
+// IsLink reports whether the entry is a symbolic link or junction
+func (f FileItem) IsLink() bool {
+	return f.LinkTarget != ""
+}
+
+// IsHidden reports whether the entry is a dot file or has the hidden attribute
+func (f FileItem) IsHidden() bool {
+	return (strings.HasPrefix(f.Name, ".") && f.Name != "..") || f.Attributes&AttrHidden != 0
+}
+
+// IsExecutable reports whether the file can be run: by its extension on
+// Windows, by its permission bits elsewhere
+func (f FileItem) IsExecutable() bool {
+	return !f.IsDir && executable(f)
+}
+
+// DisplayName returns the name as listed, with a separator after directories
+func (f FileItem) DisplayName() string {
+	if f.IsDir {
+		return f.Name + "/"
+	}
+	return f.Name
+}
+
+// Icon returns the emoji shown before the entry in listings
+func (f FileItem) Icon() string {
+	switch {
+	case f.IsLink():
+		return "🔗"
+	case f.IsDir:
+		return "📁"
+	case f.IsExecutable():
+		return "⚡"
+	}
+	kind, _, _ := strings.Cut(f.MIMEType, "/")
+	switch {
+	case kind == "image":
+		return "🎨"
+	case kind == "audio":
+		return "🎵"
+	case kind == "video":
+		return "🎬"
+	case archiveExts[f.Ext()]:
+		return "📦"
+	}
+	return "📄"
+}
+
+// archiveExts are the extensions shown with the archive icon
+var archiveExts = map[string]bool{
+	".zip": true, ".7z": true, ".rar": true, ".tar": true, ".gz": true,
+	".tgz": true, ".bz2": true, ".xz": true, ".zst": true, ".cab": true,
 }
@@ -0,0 +1,26 @@
+//go:build !windows
+
+package types
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileAttributes returns 0; attributes only exist on Windows
+func fileAttributes(fs.FileInfo) uint32 {
+	return 0
+}
+
+// linkCount returns the number of hard links from the stat information
+func linkCount(info fs.FileInfo) int {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return int(st.Nlink)
+	}
+	return 0
+}
+
+// executable reports whether any execute permission bit is set
+func executable(f FileItem) bool {
+	return f.Mode&0o111 != 0
+}
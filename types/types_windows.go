@@ -0,0 +1,44 @@
+//go:build windows
+
+package types
+
+import (
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// executableExts are run by the shell when no PATHEXT is set
+var executableExts = ".COM;.EXE;.BAT;.CMD;.VBS;.JS;.WS;.MSC;.PS1"
+
+// fileAttributes returns the Windows attributes of an entry
+func fileAttributes(info fs.FileInfo) uint32 {
+	if data, ok := info.Sys().(*syscall.Win32FileAttributeData); ok {
+		return data.FileAttributes
+	}
+	return 0
+}
+
+// linkCount returns 0; the number of links needs the file opened, see
+// disk.LinkCount
+func linkCount(fs.FileInfo) int {
+	return 0
+}
+
+// pathExts returns the extensions the shell runs, from PATHEXT
+var pathExts = sync.OnceValue(func() []string {
+	exts := os.Getenv("PATHEXT")
+	if exts == "" {
+		exts = executableExts
+	}
+	return strings.Split(strings.ToLower(exts), ";")
+})
+
+// executable reports whether the extension is one the shell runs
+func executable(f FileItem) bool {
+	ext := f.Ext()
+	return ext != "" && slices.Contains(pathExts(), ext)
+}
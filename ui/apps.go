@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/apps"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// appUninstallMsg launches the uninstaller of a program after confirmation
+type appUninstallMsg struct {
+	Program apps.Program
+}
+
+// appUninstalledMsg reports whether an uninstaller could be started
+type appUninstalledMsg struct {
+	Name string
+	Err  error
+}
+
+// appsCmd lists the installed programs in the background, keeping those whose
+// name or publisher contains filter
+func appsCmd(filter string) tea.Cmd {
+	return func() tea.Msg {
+		programs, err := apps.List()
+		if err != nil {
+			return errorMsg{err}
+		}
+
+		needle := strings.ToLower(filter)
+		var entries []ListEntry
+		var total int64
+		for _, p := range programs {
+			if !strings.Contains(strings.ToLower(p.Name), needle) && !strings.Contains(strings.ToLower(p.Publisher), needle) {
+				continue
+			}
+			total += p.Size
+			entries = append(entries, appEntry(p))
+		}
+
+		title := "📦 Installed Programs"
+		if filter != "" {
+			title += ": " + filter
+		}
+		panel := NewListPanel(title, entries)
+		panel.Subtitle = fmt.Sprintf("%d programs, %s", len(entries), FormatSize(total))
+		panel.Actions = []ListAction{{
+			Key:  "u",
+			Desc: "uninstall",
+			Msg: func(entry ListEntry) tea.Msg {
+				p, ok := entry.Data.(apps.Program)
+				if !ok {
+					return nil
+				}
+				return confirmPrompt(fmt.Sprintf("Run the uninstaller of %s?", p.Name), appUninstallMsg{Program: p})
+			},
+		}}
+		return openListMsg{panel}
+	}
+}
+
+// appEntry shows a program; Enter browses its install directory
+func appEntry(p apps.Program) ListEntry {
+	size := ""
+	if p.Size > 0 {
+		size = FormatSize(p.Size)
+	}
+	label := fmt.Sprintf("%-44s %-18s %-28s %9s",
+		truncateAtVisualWidth(p.Name, 44),
+		truncateAtVisualWidth(p.Version, 18),
+		truncateAtVisualWidth(p.Publisher, 28),
+		size)
+	if p.Location != "" {
+		label += "  " + theme.Dim.Render(p.Location)
+	}
+
+	entry := ListEntry{Label: label, Data: p}
+	if p.Location != "" {
+		entry.Msg = openPathMsg{Path: p.Location}
+	} else {
+		entry.Msg = errorMsg{fmt.Errorf("%s has no recorded install directory", p.Name)}
+	}
+	return entry
+}
+
+// uninstallCmd starts an uninstaller
+func uninstallCmd(p apps.Program) tea.Cmd {
+	return func() tea.Msg {
+		return appUninstalledMsg{Name: p.Name, Err: apps.Uninstall(p)}
+	}
+}
+
+// updateApps handles the installed programs panel
+func (m Model) updateApps(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case appUninstallMsg:
+		return m, uninstallCmd(msg.Program)
+
+	case appUninstalledMsg:
+		if msg.Err != nil {
+			m.setStatus(fmt.Sprintf("Error: %v", msg.Err))
+			return m, nil
+		}
+		m.setStatus(fmt.Sprintf("Started the uninstaller of %s (run :apps again once it finishes)", msg.Name))
+	}
+	return m, nil
+}
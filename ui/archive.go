@@ -0,0 +1,218 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/clipboard"
+	"github.com/HolyStarGazer/windows-tui-go/mail"
+	"github.com/HolyStarGazer/windows-tui-go/ops"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// mailDirKeep is how long archives made for mail drafts are kept, since the
+// mail program may read them only when the message is sent
+const mailDirKeep = 24 * time.Hour
+
+// zipMsg zips paths into an archive named Name in Dir. With Mail set the
+// archive goes to a new temporary directory and is attached to a mail draft.
+type zipMsg struct {
+	Paths []string
+	Dir   string
+	Name  string
+	Mail  bool
+}
+
+// zipDoneMsg reports a finished archive
+type zipDoneMsg struct {
+	Paths   []string
+	Archive string
+	Mail    bool
+	Err     error
+}
+
+// mailDraftMsg reports how a mail draft was opened. Fallback means no mail
+// program took the attachment, so a plain draft was opened and Copied tells
+// whether the archive's path was put on the clipboard instead.
+type mailDraftMsg struct {
+	Archive  string
+	Fallback bool
+	Copied   bool
+	Err      error
+}
+
+// archiveName is the default name of an archive of paths: the first item's
+// name without its extension, as Explorer's "Compressed (zipped) folder" does
+func archiveName(paths []string) string {
+	name := filepath.Base(paths[0])
+	if info, err := vfs.Default.Lstat(paths[0]); err == nil && !info.IsDir() {
+		if stem := strings.TrimSuffix(name, filepath.Ext(name)); stem != "" {
+			name = stem
+		}
+	}
+	return name + ".zip"
+}
+
+// unusedName returns name, or name with " (2)", " (3)" and so on before its
+// extension if dir already holds something by that name
+func unusedName(dir, name string) string {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for n := 2; ; n++ {
+		if _, err := vfs.Default.Lstat(filepath.Join(dir, name)); err != nil {
+			return name
+		}
+		name = fmt.Sprintf("%s (%d)%s", stem, n, ext)
+	}
+}
+
+// zipCommand zips the marked items, or the one under the cursor, into the
+// current directory (:zip [name]). Without a name it asks for one, offering
+// the first item's name.
+func (m *Model) zipCommand(args []string) tea.Cmd {
+	paths := m.operandPaths()
+	if len(paths) == 0 {
+		m.StatusMessage = "Nothing to zip"
+		return nil
+	}
+	dir := m.CurrentPath
+	submit := func(value string) tea.Msg {
+		name := strings.TrimSpace(value)
+		if name == "" {
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(name), ".zip") {
+			name += ".zip"
+		}
+		return zipMsg{Paths: paths, Dir: dir, Name: name}
+	}
+	if len(args) > 0 {
+		msg := submit(strings.Join(args, " "))
+		return func() tea.Msg { return msg }
+	}
+	return func() tea.Msg {
+		return openPromptMsg{Prompt{
+			Label:  fmt.Sprintf("Zip %s as: ", describePaths(paths)),
+			Buffer: unusedName(dir, archiveName(paths)),
+			Submit: submit,
+		}}
+	}
+}
+
+// mailCommand zips the marked items, or the one under the cursor, and opens
+// a mail draft with the archive attached (:mail). A single zip file is
+// attached as it is.
+func (m *Model) mailCommand() tea.Cmd {
+	paths := m.operandPaths()
+	if len(paths) == 0 {
+		m.StatusMessage = "Nothing to mail"
+		return nil
+	}
+	if len(paths) == 1 && strings.EqualFold(filepath.Ext(paths[0]), ".zip") {
+		if info, err := vfs.Default.Stat(paths[0]); err == nil && info.Mode().IsRegular() {
+			m.setStatus(fmt.Sprintf("Opening a mail draft with %s attached...", filepath.Base(paths[0])))
+			return mailDraftCmd(paths[0])
+		}
+	}
+	return m.zipCmd(zipMsg{Paths: paths, Name: archiveName(paths), Mail: true})
+}
+
+// zipCmd writes the archive in the background, as a job whose progress is
+// measured against the size of the files
+func (m *Model) zipCmd(msg zipMsg) tea.Cmd {
+	m.setStatus("")
+	cmd := m.startJob(fmt.Sprintf("Zipping %s into %s", describePaths(msg.Paths), msg.Name), func(progress *ops.Progress) tea.Msg {
+		done := zipDoneMsg{Paths: msg.Paths, Mail: msg.Mail}
+		ops.Work(func() {
+			dir := msg.Dir
+			if msg.Mail {
+				if dir, done.Err = mailDir(); done.Err != nil {
+					return
+				}
+			}
+			done.Archive = filepath.Join(dir, msg.Name)
+			progress.SetTotal(pathsSize(msg.Paths))
+			done.Err = ops.Zip(msg.Paths, done.Archive, progress)
+		})
+		if errors.Is(done.Err, ops.ErrCanceled) {
+			return jobCanceledMsg{}
+		}
+		return done
+	})
+	m.refreshJobsPanel()
+	return cmd
+}
+
+// mailDir makes a new temporary directory for an archive to mail, removing
+// the ones left by earlier drafts once they are old enough
+func mailDir() (string, error) {
+	base := filepath.Join(os.TempDir(), "windows-tui-go-mail")
+	if err := os.MkdirAll(base, 0o700); err != nil {
+		return "", err
+	}
+	if entries, err := os.ReadDir(base); err == nil {
+		for _, entry := range entries {
+			if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > mailDirKeep {
+				os.RemoveAll(filepath.Join(base, entry.Name()))
+			}
+		}
+	}
+	return os.MkdirTemp(base, "draft-")
+}
+
+// finishZip shows the new archive, or opens the mail draft it was made for
+func (m *Model) finishZip(msg zipDoneMsg) tea.Cmd {
+	if msg.Err != nil {
+		m.setStatus(fmt.Sprintf("Error: %v", msg.Err))
+		return nil
+	}
+	size := ""
+	if info, err := vfs.Default.Stat(msg.Archive); err == nil {
+		size = FormatSize(info.Size())
+	}
+	if msg.Mail {
+		m.setStatus(fmt.Sprintf("Opening a mail draft with %s (%s) attached...", filepath.Base(msg.Archive), size))
+		return mailDraftCmd(msg.Archive)
+	}
+
+	m.reloadDirectory()
+	if m.DualPane {
+		m.reloadOtherPane()
+	}
+	m.selectPath(msg.Archive)
+	m.setStatus(fmt.Sprintf("Zipped %s into %s (%s)", describePaths(msg.Paths), filepath.Base(msg.Archive), size))
+	return nil
+}
+
+// mailDraftCmd opens a mail draft with the archive attached. Without a mail
+// program that takes attachments it opens a mailto: draft instead and puts
+// the archive's path on the clipboard to attach by hand.
+func mailDraftCmd(archive string) tea.Cmd {
+	return func() tea.Msg {
+		subject := filepath.Base(archive)
+		err := mail.Draft(subject, []string{archive})
+		if !errors.Is(err, mail.ErrNoAttachments) {
+			return mailDraftMsg{Archive: archive, Err: err}
+		}
+		copied := clipboard.SetText(archive) == nil
+		err = mail.Compose(subject, fmt.Sprintf("Attached: %s", subject))
+		return mailDraftMsg{Archive: archive, Fallback: true, Copied: copied, Err: err}
+	}
+}
+
+// showMailDraft reports a draft that could not take the attachment
+func (m *Model) showMailDraft(msg mailDraftMsg) {
+	switch {
+	case msg.Err != nil:
+		m.setStatus(fmt.Sprintf("Error: %v", msg.Err))
+	case msg.Fallback && msg.Copied:
+		m.setStatus(fmt.Sprintf("No mail program accepts attachments: attach %s to the new message (its path is on the clipboard)", msg.Archive))
+	case msg.Fallback:
+		m.setStatus(fmt.Sprintf("No mail program accepts attachments: attach %s to the new message", msg.Archive))
+	}
+}
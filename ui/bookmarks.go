@@ -0,0 +1,350 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// bookmarkContext is the number of lines shown around each bookmark in an export
+const bookmarkContext = 2
+
+// bookmarkReadSize is how much of a windowed file is read on each side of a
+// bookmark for the lines around it
+const bookmarkReadSize = 4 << 10
+
+// Bookmark is an annotated line in the file viewer
+type Bookmark struct {
+	Line   int    // Line of the file (0-based)
+	Offset int64  // File offset of the line, in files loaded as they are scrolled
+	Note   string // Annotation, may be empty
+}
+
+// before reports whether the bookmark is on a line above o
+func (b Bookmark) before(o Bookmark) bool {
+	if b.Offset != o.Offset {
+		return b.Offset < o.Offset
+	}
+	return b.Line < o.Line
+}
+
+// bookmarkJumpMsg moves a viewer to a bookmarked line
+type bookmarkJumpMsg struct {
+	Viewer *FileViewer
+	At     Bookmark
+}
+
+// bookmarkDeleteMsg removes a bookmark and refreshes the bookmark panel
+type bookmarkDeleteMsg struct {
+	Viewer *FileViewer
+	At     Bookmark
+}
+
+// parseNote strips surrounding quotes from a bookmark note
+func parseNote(arg string) string {
+	arg = strings.TrimSpace(arg)
+	if len(arg) >= 2 && (arg[0] == '"' || arg[0] == '\'') && arg[len(arg)-1] == arg[0] {
+		arg = arg[1 : len(arg)-1]
+	}
+	return arg
+}
+
+// bookmarkAt returns where loaded line i is, for placing and finding
+// bookmarks. In a windowed file the line number is -1 until the index has
+// reached it.
+func (fv *FileViewer) bookmarkAt(i int) Bookmark {
+	if fv.window == nil {
+		return Bookmark{Line: i}
+	}
+	return Bookmark{Line: addLines(fv.firstLine(), i), Offset: fv.lineOffset(i)}
+}
+
+// bookmarkIndex returns the index of the bookmark on the line at, or -1.
+// Windowed files match by offset, which is known before the line number.
+func (fv *FileViewer) bookmarkIndex(at Bookmark) int {
+	for i, bm := range fv.Bookmarks {
+		if fv.window != nil && bm.Offset == at.Offset || fv.window == nil && bm.Line == at.Line {
+			return i
+		}
+	}
+	return -1
+}
+
+// addBookmark bookmarks the line at the top of the view, replacing the note of an
+// existing bookmark on that line
+func (fv *FileViewer) addBookmark(note string) {
+	if len(fv.Content) == 0 {
+		return
+	}
+	if fv.window != nil && fv.hex {
+		fv.StatusMessage = "Bookmarks are not available in the hex dump of a file loaded as you scroll"
+		return
+	}
+	at := fv.bookmarkAt(fv.lineAt(fv.ScrollPos))
+	if i := fv.bookmarkIndex(at); i >= 0 {
+		fv.Bookmarks[i].Note = note
+		fv.StatusMessage = fmt.Sprintf("Updated bookmark on line %d", fv.Bookmarks[i].Line+1)
+		return
+	}
+	if at.Line < 0 {
+		fv.StatusMessage = "Line numbers are still being counted here; try again once indexing reaches this line"
+		return
+	}
+
+	at.Note = note
+	fv.Bookmarks = append(fv.Bookmarks, at)
+	sort.Slice(fv.Bookmarks, func(a, b int) bool { return fv.Bookmarks[a].before(fv.Bookmarks[b]) })
+	fv.StatusMessage = fmt.Sprintf("Bookmarked line %d (%d bookmarks)", at.Line+1, len(fv.Bookmarks))
+}
+
+// deleteBookmark removes the bookmark on the line at, returning it
+func (fv *FileViewer) deleteBookmark(at Bookmark) (Bookmark, bool) {
+	i := fv.bookmarkIndex(at)
+	if i < 0 {
+		return Bookmark{}, false
+	}
+	bm := fv.Bookmarks[i]
+	fv.Bookmarks = append(fv.Bookmarks[:i], fv.Bookmarks[i+1:]...)
+	return bm, true
+}
+
+// jumpBookmark moves to the next (dir > 0) or previous (dir < 0) bookmark
+func (fv *FileViewer) jumpBookmark(dir int) {
+	if len(fv.Bookmarks) == 0 {
+		fv.StatusMessage = "No bookmarks (add one with :bm \"note\")"
+		return
+	}
+
+	current := fv.bookmarkAt(fv.lineAt(fv.ScrollPos))
+	var target *Bookmark
+	if dir > 0 {
+		for i := range fv.Bookmarks {
+			if current.before(fv.Bookmarks[i]) {
+				target = &fv.Bookmarks[i]
+				break
+			}
+		}
+	} else {
+		for i := len(fv.Bookmarks) - 1; i >= 0; i-- {
+			if fv.Bookmarks[i].before(current) {
+				target = &fv.Bookmarks[i]
+				break
+			}
+		}
+	}
+	if target == nil {
+		fv.StatusMessage = "No more bookmarks"
+		return
+	}
+
+	fv.gotoBookmark(*target)
+}
+
+// gotoBookmark scrolls to a bookmark and shows its note
+func (fv *FileViewer) gotoBookmark(bm Bookmark) {
+	i, err := fv.bookmarkLine(bm)
+	if err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	fv.ScrollPos = fv.rowOf(i)
+	fv.StatusMessage = fmt.Sprintf("Bookmark line %d", bm.Line+1)
+	if bm.Note != "" {
+		fv.StatusMessage += ": " + bm.Note
+	}
+}
+
+// bookmarkLine returns the loaded line a bookmark is on, first loading the
+// part of a windowed file it is in if need be
+func (fv *FileViewer) bookmarkLine(bm Bookmark) (int, error) {
+	if fv.window == nil {
+		return bm.Line, nil
+	}
+	if bm.Offset < fv.part.Start || bm.Offset >= fv.part.Start+fv.part.Size {
+		start, first := bm.Offset, bm.Line
+		if fv.hex {
+			start, first = start/hexBytesPerLine*hexBytesPerLine, -1
+		}
+		if err := fv.loadWindow(start, first); err != nil {
+			return 0, err
+		}
+	}
+	if fv.hex {
+		return int((bm.Offset - fv.part.Start) / hexBytesPerLine), nil
+	}
+	offsets := fv.window.offsets
+	return sort.Search(len(offsets), func(i int) bool { return offsets[i] > bm.Offset }) - 1, nil
+}
+
+// bookmarkLines returns the lines around a bookmark and the number of the
+// first one. Windowed files are read around the bookmark's offset, since its
+// lines need not be loaded.
+func (fv *FileViewer) bookmarkLines(bm Bookmark) (int, []string) {
+	if fv.window == nil {
+		start := max(bm.Line-bookmarkContext, 0)
+		end := min(bm.Line+bookmarkContext+1, len(fv.Content))
+		if start >= end {
+			return bm.Line, nil
+		}
+		return start, fv.Content[start:end]
+	}
+
+	from, end := max(bm.Offset-bookmarkReadSize, 0), min(bm.Offset+bookmarkReadSize, fv.part.Total)
+	data, err := fv.readRange(from, end)
+	if err != nil || int64(len(data)) < bm.Offset-from {
+		return bm.Line, nil
+	}
+	// The text before the offset ends at a line break; its first line may be
+	// cut off by the read, as may the last line after it
+	before := strings.Split(string(data[:bm.Offset-from]), "\n")
+	before = before[:len(before)-1]
+	if from > 0 && len(before) > 0 {
+		before = before[1:]
+	}
+	before = before[max(len(before)-bookmarkContext, 0):]
+	after := strings.Split(string(data[bm.Offset-from:]), "\n")
+	if len(after) > 1 && (from+int64(len(data)) < fv.part.Total || after[len(after)-1] == "") {
+		after = after[:len(after)-1]
+	}
+	after = after[:min(len(after), bookmarkContext+1)]
+
+	lines := append(before, after...)
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return bm.Line - len(before), lines
+}
+
+// bookmarkPanel lists the viewer's bookmarks; Enter jumps and d deletes
+func (fv *FileViewer) bookmarkPanel() ListPanel {
+	entries := make([]ListEntry, 0, len(fv.Bookmarks))
+	for _, bm := range fv.Bookmarks {
+		text := ""
+		if first, lines := fv.bookmarkLines(bm); bm.Line-first >= 0 && bm.Line-first < len(lines) {
+			text = strings.TrimSpace(lines[bm.Line-first])
+		}
+		label := fmt.Sprintf("%5d │ %s", bm.Line+1, theme.Directory.Render(bm.Note))
+		if text != "" {
+			label += "  " + theme.Dim.Render(truncateAtVisualWidth(text, 60))
+		}
+		entries = append(entries, ListEntry{
+			Label: label,
+			Data:  bm,
+			Msg:   bookmarkJumpMsg{Viewer: fv, At: bm},
+		})
+	}
+
+	panel := NewListPanel(fmt.Sprintf("🔖 Bookmarks: %s", fv.FileName), entries)
+	panel.Subtitle = fmt.Sprintf("%d bookmarks", len(fv.Bookmarks))
+	panel.Actions = []ListAction{{
+		Key:  "d",
+		Desc: "delete",
+		Msg: func(entry ListEntry) tea.Msg {
+			bm, ok := entry.Data.(Bookmark)
+			if !ok {
+				return nil
+			}
+			return bookmarkDeleteMsg{Viewer: fv, At: bm}
+		},
+	}}
+	return panel
+}
+
+// showBookmarks asks the model to open the bookmark panel
+func (fv *FileViewer) showBookmarks() {
+	if len(fv.Bookmarks) == 0 {
+		fv.StatusMessage = "No bookmarks (add one with :bm \"note\")"
+		return
+	}
+	panel := fv.bookmarkPanel()
+	fv.pendingCmd = func() tea.Msg { return openListMsg{panel} }
+}
+
+// exportBookmarks writes each bookmark with its note and surrounding lines to a
+// text report. Relative paths are resolved against the viewed file's directory.
+func (fv *FileViewer) exportBookmarks(path string) {
+	if len(fv.Bookmarks) == 0 {
+		fv.StatusMessage = "No bookmarks to export"
+		return
+	}
+	path = fv.resolvePath(path)
+
+	var b strings.Builder
+	source := fv.FileName
+	if fv.FilePath != "" {
+		source = fv.FilePath
+	}
+	fmt.Fprintf(&b, "Bookmarks in %s\n", source)
+	fmt.Fprintf(&b, "Exported %s\n", time.Now().Format("2006-01-02 15:04:05"))
+
+	for _, bm := range fv.Bookmarks {
+		b.WriteString("\n")
+		fmt.Fprintf(&b, "== Line %d", bm.Line+1)
+		if bm.Note != "" {
+			fmt.Fprintf(&b, ": %s", bm.Note)
+		}
+		b.WriteString("\n")
+
+		first, lines := fv.bookmarkLines(bm)
+		for i, line := range lines {
+			marker := " "
+			if first+i == bm.Line {
+				marker = ">"
+			}
+			fmt.Fprintf(&b, "%s %5d │ %s\n", marker, first+i+1, line)
+		}
+	}
+
+	if err := vfs.Default.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	fv.StatusMessage = fmt.Sprintf("Exported %d bookmarks to %s", len(fv.Bookmarks), path)
+}
+
+// takePendingCmd returns and clears the command a viewer command asked the model to run
+func (fv *FileViewer) takePendingCmd() tea.Cmd {
+	cmd := fv.pendingCmd
+	fv.pendingCmd = nil
+	return cmd
+}
+
+// updateBookmarks handles bookmark panel messages
+func (m Model) updateBookmarks(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case bookmarkJumpMsg:
+		if msg.Viewer != m.FileViewer {
+			return m, nil
+		}
+		m.closeList()
+		if i := m.FileViewer.bookmarkIndex(msg.At); i >= 0 {
+			m.FileViewer.gotoBookmark(m.FileViewer.Bookmarks[i])
+		}
+
+	case bookmarkDeleteMsg:
+		if msg.Viewer != m.FileViewer {
+			return m, nil
+		}
+		deleted, ok := m.FileViewer.deleteBookmark(msg.At)
+		if !ok {
+			return m, nil
+		}
+		if len(m.FileViewer.Bookmarks) == 0 {
+			m.closeList()
+			m.FileViewer.StatusMessage = "Deleted the last bookmark"
+			return m, nil
+		}
+		cursor := m.List.Cursor
+		panel := m.FileViewer.bookmarkPanel()
+		panel.Width, panel.Height = m.List.Width, m.List.Height
+		panel.SetCursor(cursor)
+		panel.StatusMessage = fmt.Sprintf("Deleted bookmark on line %d", deleted.Line+1)
+		m.List = &panel
+	}
+
+	return m, nil
+}
@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// buffersTitle is the title of the buffer list
+const buffersTitle = "📚 Buffers"
+
+// listBuffersMsg shows the open buffers (:ls)
+type listBuffersMsg struct{}
+
+// cycleBufferMsg shows the next (1) or previous (-1) buffer (:bn / :bp)
+type cycleBufferMsg struct {
+	Step int
+}
+
+// showBufferMsg shows a buffer picked from the list
+type showBufferMsg struct {
+	Viewer *FileViewer
+}
+
+// numberBuffer gives a newly shown viewer the next buffer number, so buffers
+// keep their order however they are visited
+func (m *Model) numberBuffer() {
+	if m.FileViewer == nil || m.FileViewer.buffer != 0 || m.PagerMode {
+		return
+	}
+	m.lastBuffer++
+	m.FileViewer.buffer = m.lastBuffer
+}
+
+// shownViewer returns the viewer on screen, or under a list opened from it
+func (m *Model) shownViewer() *FileViewer {
+	if m.Mode == FileViewMode || (m.Mode == ListMode && m.ListReturnMode == FileViewMode) {
+		return m.FileViewer
+	}
+	return nil
+}
+
+// buffers returns the open viewers by buffer number: the one shown and those
+// kept in the jump list
+func (m *Model) buffers() []*FileViewer {
+	var viewers []*FileViewer
+	for _, loc := range m.jumps {
+		if loc.Viewer != nil && loc.Viewer.buffer != 0 && !slices.Contains(viewers, loc.Viewer) {
+			viewers = append(viewers, loc.Viewer)
+		}
+	}
+	if shown := m.shownViewer(); shown != nil && shown.buffer != 0 && !slices.Contains(viewers, shown) {
+		viewers = append(viewers, shown)
+	}
+	slices.SortFunc(viewers, func(a, b *FileViewer) int { return a.buffer - b.buffer })
+	return viewers
+}
+
+// bufferPanel lists the open buffers, marking the one shown with %
+func (m *Model) bufferPanel() ListPanel {
+	viewers := m.buffers()
+	shown := m.shownViewer()
+	entries := make([]ListEntry, 0, len(viewers))
+	current := 0
+	for i, fv := range viewers {
+		flag := " "
+		if fv == shown {
+			flag = "%"
+			current = i
+		}
+		name := fv.FileName + fv.goneLabel()
+		label := fmt.Sprintf("%3d %s %-30s %s", fv.buffer, flag, name,
+			theme.Dim.Render(fmt.Sprintf("line %d", fv.lineAt(fv.ScrollPos)+1)))
+		entries = append(entries, ListEntry{Label: label, Msg: showBufferMsg{Viewer: fv}})
+	}
+
+	panel := NewListPanel(buffersTitle, entries)
+	panel.SetCursor(current)
+	if len(entries) == 0 {
+		panel.StatusMessage = "No open buffers"
+	}
+	return panel
+}
+
+// cycleBuffer shows the buffer after or before the current one, wrapping around
+func (m *Model) cycleBuffer(step int) tea.Cmd {
+	viewers := m.buffers()
+	switch {
+	case len(viewers) == 0:
+		m.setStatus("No open buffers")
+		return nil
+	case len(viewers) == 1 && viewers[0] == m.shownViewer():
+		m.setStatus("Only one buffer is open")
+		return nil
+	}
+	// From the browser, the first or last buffer is next
+	index := slices.Index(viewers, m.shownViewer())
+	if index < 0 && step < 0 {
+		index = len(viewers)
+	}
+	index = (index + step + len(viewers)) % len(viewers)
+	return m.showBuffer(viewers[index])
+}
+
+// showBuffer resumes a buffer where it was left. Unlike moving through the jump
+// list, the location left is recorded as a new jump, so no buffer is dropped.
+func (m *Model) showBuffer(fv *FileViewer) tea.Cmd {
+	for i := len(m.jumps) - 1; i >= 0; i-- {
+		if m.jumps[i].Viewer != fv {
+			continue
+		}
+		m.jumpPos = len(m.jumps)
+		cmd := m.gotoLocation(m.jumps[i])
+		m.jumped = false
+		return cmd
+	}
+	m.setStatus("Buffer is no longer open")
+	return nil
+}
+
+// updateBuffers handles the buffer commands
+func (m Model) updateBuffers(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case listBuffersMsg:
+		m.openList(m.bufferPanel())
+
+	case cycleBufferMsg:
+		return m, m.cycleBuffer(msg.Step)
+
+	case showBufferMsg:
+		if m.Mode == ListMode {
+			m.closeList()
+		}
+		if msg.Viewer == m.shownViewer() {
+			return m, nil
+		}
+		return m, m.showBuffer(msg.Viewer)
+	}
+	return m, nil
+}
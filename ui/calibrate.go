@@ -0,0 +1,264 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/config"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/rivo/uniseg"
+)
+
+// calibrationFile is the state file holding the results of :calibrate
+const calibrationFile = "calibration.json"
+
+// Calibration is what :calibrate found out about how the terminal draws
+// characters and colors
+type Calibration struct {
+	EmojiWidth     int  `json:"emoji_width"`     // Columns an emoji takes: 1 or 2
+	AmbiguousWidth int  `json:"ambiguous_width"` // Columns box drawing and other East Asian ambiguous characters take: 1 or 2
+	BoxDrawing     bool `json:"box_drawing"`     // Whether box drawing characters show; panes get ASCII borders if not
+	TrueColor      bool `json:"truecolor"`       // Whether 24-bit colors show; 256 colors are used if not
+}
+
+// defaultCalibration is assumed until :calibrate has been run: a terminal
+// such as Windows Terminal
+func defaultCalibration() Calibration {
+	return Calibration{EmojiWidth: 2, AmbiguousWidth: 1, BoxDrawing: true, TrueColor: true}
+}
+
+// calibration is the calibration in use
+var calibration = defaultCalibration()
+
+// loadCalibration applies the saved results of :calibrate, if any
+func loadCalibration() error {
+	var c Calibration
+	if err := config.Load(calibrationFile, &c); err != nil {
+		return fmt.Errorf("%s: %v", calibrationFile, err)
+	}
+	if c == (Calibration{}) {
+		return nil
+	}
+	if c.EmojiWidth != 1 && c.EmojiWidth != 2 || c.AmbiguousWidth != 1 && c.AmbiguousWidth != 2 {
+		return fmt.Errorf("%s: widths must be 1 or 2 (run :calibrate again)", calibrationFile)
+	}
+	applyCalibration(c)
+	return nil
+}
+
+// applyCalibration makes widths and colors follow c. Widths measured by
+// lipgloss and Bubble Tea's renderer follow the ambiguous width too. The
+// theme has to be built again for the colors and borders to change.
+func applyCalibration(c Calibration) {
+	calibration = c
+	uniseg.EastAsianAmbiguousWidth = c.AmbiguousWidth
+	switch {
+	case c.TrueColor:
+		// Terminals that do not say they show 24-bit colors often do
+		lipgloss.SetColorProfile(termenv.TrueColor)
+	case lipgloss.ColorProfile() == termenv.TrueColor:
+		lipgloss.SetColorProfile(termenv.ANSI256)
+	}
+}
+
+// clusterWidth returns the columns a grapheme cluster takes, given its width
+// by Unicode's rules
+func clusterWidth(cluster string, width int) int {
+	switch {
+	case width == 2 && calibration.EmojiWidth == 1 && isEmoji(cluster):
+		return 1
+	case width == 0 && cluster[0] < ' ':
+		// Tabs and other control characters count as a column
+		return 1
+	}
+	return width
+}
+
+// isEmoji reports whether a grapheme cluster is an emoji, such as the icons
+// of the listing, rather than a wide letter
+func isEmoji(cluster string) bool {
+	r := []rune(cluster)[0]
+	return r >= 0x1F000 && r <= 0x1FAFF || r >= 0x2600 && r <= 0x27BF || strings.ContainsRune(cluster, 0xFE0F)
+}
+
+// calibrationAnswer is a possible answer to a test pattern
+type calibrationAnswer struct {
+	Key   string
+	Label string
+	Apply func(*Calibration)
+}
+
+// calibrationTest is a test pattern and the question asked about it
+type calibrationTest struct {
+	Name     string
+	Pattern  func() []string
+	Question string
+	Answers  []calibrationAnswer
+}
+
+// calibrationTests are the tests of :calibrate, in order
+var calibrationTests = []calibrationTest{
+	{
+		Name: "Emoji width",
+		Pattern: func() []string {
+			return []string{
+				"  ........|",
+				"a 📁📄🎵⚡|",
+				"b 📁📄🎵⚡    |",
+			}
+		},
+		Question: "Which row's | lines up with the one on the dotted row?",
+		Answers: []calibrationAnswer{
+			{"a", "row a: emoji take two columns", func(c *Calibration) { c.EmojiWidth = 2 }},
+			{"b", "row b: emoji take one column", func(c *Calibration) { c.EmojiWidth = 1 }},
+		},
+	},
+	{
+		Name: "Box drawing",
+		Pattern: func() []string {
+			return []string{
+				"  ..........|",
+				"a ┌────────┐|",
+				"b ┌───┐|",
+			}
+		},
+		Question: "Which row's | lines up with the one on the dotted row?",
+		Answers: []calibrationAnswer{
+			{"a", "row a: lines take one column", func(c *Calibration) { c.AmbiguousWidth, c.BoxDrawing = 1, true }},
+			{"b", "row b: lines take two columns", func(c *Calibration) { c.AmbiguousWidth, c.BoxDrawing = 2, true }},
+			{"c", "neither: the lines are broken or show as boxes or question marks", func(c *Calibration) { c.AmbiguousWidth, c.BoxDrawing = 1, false }},
+		},
+	},
+	{
+		Name: "Colors",
+		Pattern: func() []string {
+			bar := "  " + colorBar(48)
+			return []string{bar, bar}
+		},
+		Question: "Does the bar fade smoothly from red through green to blue?",
+		Answers: []calibrationAnswer{
+			{"a", "yes: 24-bit colors show", func(c *Calibration) { c.TrueColor = true }},
+			{"b", "no: it shows bands, other colors or none", func(c *Calibration) { c.TrueColor = false }},
+		},
+	},
+}
+
+// colorBar draws a gradient of width cells in 24-bit background colors,
+// written out directly, as lipgloss would lower them to the colors the
+// terminal is thought to show
+func colorBar(width int) string {
+	var b strings.Builder
+	for i := range width {
+		// Hue from red (0°) to blue (240°)
+		hue := 240 * float64(i) / float64(width-1)
+		r, g, bl := hueRGB(hue)
+		fmt.Fprintf(&b, "\x1b[48;2;%d;%d;%dm ", r, g, bl)
+	}
+	b.WriteString("\x1b[0m")
+	return b.String()
+}
+
+// hueRGB returns the fully saturated color of a hue in degrees
+func hueRGB(hue float64) (int, int, int) {
+	channel := func(offset float64) int {
+		k := math.Mod(offset+hue/60, 6)
+		return int(math.Round(255 * (1 - max(0, min(k, 4-k, 1)))))
+	}
+	return channel(5), channel(3), channel(1)
+}
+
+// Calibrator is the :calibrate screen: it draws test patterns and asks what
+// they look like, one at a time, then saves the answers
+type Calibrator struct {
+	step   int
+	result Calibration
+}
+
+// openCalibrator starts :calibrate
+func (m *Model) openCalibrator() {
+	m.Calibrator = &Calibrator{result: calibration}
+}
+
+// updateCalibrator handles keys while calibrating: the key of an answer
+// moves on to the next test, and Esc cancels without saving
+func (m *Model) updateCalibrator(msg tea.KeyMsg) tea.Cmd {
+	c := m.Calibrator
+	switch msg.String() {
+	case "esc", "q":
+		m.Calibrator = nil
+		m.setStatus("Calibration canceled")
+		return nil
+	case "ctrl+c":
+		return m.quit()
+	case "backspace":
+		c.step = max(c.step-1, 0)
+		return nil
+	}
+	for _, answer := range calibrationTests[c.step].Answers {
+		if msg.String() == answer.Key {
+			answer.Apply(&c.result)
+			c.step++
+			break
+		}
+	}
+	if c.step == len(calibrationTests) {
+		m.Calibrator = nil
+		m.finishCalibration(c.result)
+	}
+	return nil
+}
+
+// finishCalibration saves and applies the results of :calibrate, drawing
+// the theme and the views whose colors are baked into their lines again
+func (m *Model) finishCalibration(c Calibration) {
+	applyCalibration(c)
+	_ = setTheme(theme.Name)
+	m.restyleViewers()
+	if err := config.Save(calibrationFile, c); err != nil {
+		m.setStatus(fmt.Sprintf("Error: %v", err))
+		return
+	}
+	m.setStatus("Calibrated: " + c.String())
+}
+
+// String describes a calibration for the status line
+func (c Calibration) String() string {
+	plural := func(n int) string {
+		if n == 1 {
+			return "1 column"
+		}
+		return fmt.Sprintf("%d columns", n)
+	}
+	boxes := "box drawing " + plural(c.AmbiguousWidth)
+	if !c.BoxDrawing {
+		boxes = "no box drawing"
+	}
+	colors := "24-bit color"
+	if !c.TrueColor {
+		colors = "256 colors"
+	}
+	return fmt.Sprintf("emoji %s, %s, %s", plural(c.EmojiWidth), boxes, colors)
+}
+
+// View renders the current test
+func (c Calibrator) View() string {
+	test := calibrationTests[c.step]
+	var b strings.Builder
+	b.WriteString(theme.Title.Render(fmt.Sprintf("Calibrate: %s (%d of %d)", test.Name, c.step+1, len(calibrationTests))) + "\n")
+	for _, line := range test.Pattern() {
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("\n" + test.Question + "\n\n")
+	for _, answer := range test.Answers {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", theme.Match.Render(answer.Key), answer.Label))
+	}
+	keys := make([]string, len(test.Answers))
+	for i, answer := range test.Answers {
+		keys[i] = answer.Key
+	}
+	b.WriteString(theme.Help.Render(fmt.Sprintf("%s: answer • Backspace: previous test • Esc: cancel", strings.Join(keys, "/"))))
+	return b.String()
+}
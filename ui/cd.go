@@ -0,0 +1,293 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/disk"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// percentVar matches %NAME% environment references in typed paths
+var percentVar = regexp.MustCompile(`%([^%]+)%`)
+
+// changeDirMsg browses a typed path, sent from the Ctrl+G prompt
+type changeDirMsg struct {
+	Path string
+}
+
+// cleanPath tidies a typed or pasted path: surrounding quotes are removed,
+// forward slashes become separators, ~ and environment variables are expanded
+// and relative paths are taken from base
+func cleanPath(raw, base string) string {
+	path := strings.TrimSpace(raw)
+	if len(path) >= 2 && (path[0] == '"' || path[0] == '\'') && path[len(path)-1] == path[0] {
+		path = path[1 : len(path)-1]
+	}
+
+	path = percentVar.ReplaceAllStringFunc(path, func(ref string) string {
+		if value, ok := os.LookupEnv(ref[1 : len(ref)-1]); ok {
+			return value
+		}
+		return ref
+	})
+	path = os.ExpandEnv(path)
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = home + path[1:]
+		}
+	}
+
+	path = filepath.FromSlash(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	return filepath.Clean(path)
+}
+
+// resolvePath finds the existing path a cleaned path means, matching each
+// component case-insensitively and otherwise by the closest directory name.
+// Names take the case they have on disk, which Windows would otherwise keep as
+// typed. guessed reports whether a component was replaced by a near miss
+// rather than matched.
+func resolvePath(path string) (resolved string, guessed bool, err error) {
+	volume := filepath.VolumeName(path)
+	if len(volume) == 2 && volume[1] == ':' {
+		volume = strings.ToUpper(volume)
+	}
+	rest := strings.TrimPrefix(path[len(volume):], string(filepath.Separator))
+	resolved = volume + string(filepath.Separator)
+	for _, part := range strings.Split(rest, string(filepath.Separator)) {
+		if part == "" {
+			continue
+		}
+		next := filepath.Join(resolved, part)
+		name, near := closestEntry(resolved, part)
+		if name == "" {
+			// Directories that cannot be listed may still be entered
+			if _, err := vfs.Default.Stat(next); err == nil {
+				resolved = next
+				continue
+			}
+			return "", false, fmt.Errorf("no such directory: %s", next)
+		}
+		guessed = guessed || near
+		resolved = filepath.Join(resolved, name)
+	}
+	return resolved, guessed, nil
+}
+
+// closestEntry returns the entry of dir named name, one whose name differs
+// only in case or, failing that, the directory whose name is fewest edits
+// away, if it is close enough to be a typo. near reports the latter.
+func closestEntry(dir, name string) (match string, near bool) {
+	entries, err := vfs.Default.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		if entry.Name() == name {
+			return name, false
+		}
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Name(), name) {
+			return entry.Name(), false
+		}
+	}
+
+	// Allow about one typo per four characters
+	best := max(1, len([]rune(name))/4) + 1
+	lower := strings.ToLower(name)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if d := editDistance(lower, strings.ToLower(entry.Name())); d < best {
+			best = d
+			match = entry.Name()
+		}
+	}
+	return match, match != ""
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// changeDirectory handles :cd, tolerating the forms pasted paths come in. A
+// path to a file browses its directory with the file selected, and a near miss
+// asks before going to the closest directory.
+func (m *Model) changeDirectory(raw string) tea.Cmd {
+	switch strings.TrimSpace(raw) {
+	case "":
+		raw = "~"
+	case "-":
+		m.toggleDirectory()
+		return nil
+	}
+
+	path := cleanPath(raw, m.CurrentPath)
+	if isServerPath(path) {
+		// A server alone is not a directory, but its shares can be picked
+		return sharesCmd(serverOf(path), "")
+	}
+	path, guessed, err := resolvePath(path)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return nil
+	}
+
+	var msg tea.Msg = openPathMsg{Path: path}
+	if info, err := vfs.Default.Stat(path); err == nil && !info.IsDir() {
+		msg = revealPathMsg{Path: path}
+	}
+	if guessed {
+		return func() tea.Msg {
+			return confirmPrompt(fmt.Sprintf("No such directory, go to %s?", path), msg)
+		}
+	}
+	return func() tea.Msg { return msg }
+}
+
+// gotoPathKey asks for a path to browse, completing directory names with Tab
+// (Ctrl+G)
+func (m *Model) gotoPathKey() tea.Cmd {
+	base := m.CurrentPath
+	return func() tea.Msg {
+		return openPromptMsg{Prompt{
+			Label: "Go to: ",
+			Submit: func(value string) tea.Msg {
+				if strings.TrimSpace(value) == "" {
+					return nil
+				}
+				return changeDirMsg{Path: value}
+			},
+			Complete: func(value string) []string { return completePath(value, base) },
+		}}
+	}
+}
+
+// completePath lists the completions of the last component of a typed path:
+// the directories it starts, matched case-insensitively, or the environment
+// variables an unclosed %NAME starts. What was typed before the component is
+// kept as typed, so references stay unexpanded.
+func completePath(value, base string) []string {
+	cut := strings.LastIndexAny(value, `/\`) + 1
+	if filepath.Separator == '/' {
+		cut = strings.LastIndex(value, "/") + 1
+	}
+	typed, part := value[:cut], value[cut:]
+
+	if strings.Count(part, "%")%2 == 1 {
+		return completeEnvVar(typed, part)
+	}
+	if typed == "" && part != "" && filepath.VolumeName(part) == part {
+		// A bare drive such as C: means its root
+		return []string{part + string(filepath.Separator)}
+	}
+
+	dir := base
+	if typed != "" {
+		dir = cleanPath(typed, base)
+	}
+	if isServerPath(dir) {
+		return completeShare(typed, part, serverOf(dir))
+	}
+	entries, err := vfs.Default.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	sep := string(filepath.Separator)
+	if strings.HasSuffix(typed, "/") {
+		sep = "/"
+	}
+	lower := strings.ToLower(part)
+	var candidates []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(strings.ToLower(name), lower) || !isDirEntry(dir, entry) {
+			continue
+		}
+		// Hidden directories only when asked for
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(part, ".") {
+			continue
+		}
+		candidates = append(candidates, typed+name+sep)
+	}
+	slices.SortFunc(candidates, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+	return candidates
+}
+
+// completeShare completes the name of a share of a server typed as
+// \\server\part
+func completeShare(typed, part, server string) []string {
+	list, err := disk.Shares(server)
+	if err != nil {
+		return nil
+	}
+	lower := strings.ToLower(part)
+	var candidates []string
+	for _, share := range list {
+		if strings.HasPrefix(strings.ToLower(share.Name), lower) {
+			candidates = append(candidates, typed+share.Name+`\`)
+		}
+	}
+	slices.SortFunc(candidates, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+	return candidates
+}
+
+// isDirEntry reports whether an entry of dir is a directory or a link to one
+func isDirEntry(dir string, entry os.DirEntry) bool {
+	if entry.IsDir() {
+		return true
+	}
+	if entry.Type()&os.ModeSymlink == 0 {
+		return false
+	}
+	info, err := vfs.Default.Stat(filepath.Join(dir, entry.Name()))
+	return err == nil && info.IsDir()
+}
+
+// completeEnvVar completes the name of the %NAME% reference left open at the
+// end of part, matched case-insensitively as Windows does
+func completeEnvVar(typed, part string) []string {
+	open := strings.LastIndex(part, "%")
+	lower := strings.ToLower(part[open+1:])
+	var candidates []string
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		if name != "" && strings.HasPrefix(strings.ToLower(name), lower) {
+			candidates = append(candidates, typed+part[:open+1]+name+"%")
+		}
+	}
+	slices.Sort(candidates)
+	return slices.Compact(candidates)
+}
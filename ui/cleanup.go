@@ -0,0 +1,431 @@
+package ui
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/ops"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// junkLocation is a kind of disposable files, found in the directories
+// matching its glob patterns
+type junkLocation struct {
+	Name     string
+	Patterns []string
+}
+
+// junkScan is a scanned junk location
+type junkScan struct {
+	Name     string
+	Dirs     []string
+	Entries  []junkEntry // What cleaning deletes
+	Size     int64
+	Files    int
+	Kept     int      // Entries too recent, or holding sockets, links or other special files
+	Refused  []string // Directories never cleaned, with the reason
+	Selected bool
+}
+
+// junkEntry is a file or directory inside a junk location that is old enough
+// to delete
+type junkEntry struct {
+	Path    string
+	Size    int64
+	Files   int
+	ModTime time.Time // Newest modification in the entry
+}
+
+// junkScannedMsg delivers the scanned junk locations
+type junkScannedMsg struct {
+	Results []junkScan
+	Status  string // Shown in the panel, e.g. the result of a cleanup
+}
+
+// junkToggleMsg selects or deselects a location for cleaning
+type junkToggleMsg struct {
+	Index int
+}
+
+// junkReviewMsg lists what cleaning the selected locations deletes
+type junkReviewMsg struct{}
+
+// junkCleanMsg requests that the selected locations be emptied
+type junkCleanMsg struct{}
+
+// junkCleanedMsg reports the result of a cleanup
+type junkCleanedMsg struct {
+	Freed  int64
+	Failed int // Entries that could not be deleted, usually because they are in use
+}
+
+// under joins parts below base, or returns "" if base is unknown
+func under(base string, parts ...string) string {
+	if base == "" {
+		return ""
+	}
+	return filepath.Join(append([]string{base}, parts...)...)
+}
+
+// junkPanelTitle and junkReviewTitle are the titles of the cleanup panel and
+// of the list of what it deletes
+const (
+	junkPanelTitle  = "🧹 Disk cleanup"
+	junkReviewTitle = "🧹 To be deleted"
+)
+
+// defaultCleanAge is how old entries must be for :clean to delete them when
+// the clean_min_age setting is empty
+const defaultCleanAge = 7 * 24 * time.Hour
+
+// compileCleanAge parses the clean_min_age setting: a duration such as "48h",
+// a number of days such as "7d", or "0" for entries of any age
+func compileCleanAge(setting string) (time.Duration, error) {
+	if setting == "" {
+		return defaultCleanAge, nil
+	}
+	if days, ok := strings.CutSuffix(setting, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	if d, err := time.ParseDuration(setting); err == nil && d >= 0 {
+		return d, nil
+	}
+	return defaultCleanAge, fmt.Errorf("invalid clean_min_age %q (use a duration such as 7d or 48h)", setting)
+}
+
+// refuseJunkDir returns why a junk location must never be emptied, or "" if
+// it may be. A temporary folder that resolves to a volume root, a home or
+// profile folder, or a folder above one, as when TEMP is unset on Windows, is
+// refused.
+func refuseJunkDir(dir string) string {
+	dir = resolvedPath(dir)
+	if filepath.Dir(dir) == dir {
+		return "a volume root"
+	}
+	home, _ := os.UserHomeDir()
+	for _, h := range []string{home, os.Getenv("USERPROFILE"), os.Getenv("HOME")} {
+		if h == "" {
+			continue
+		}
+		if rel, err := filepath.Rel(dir, resolvedPath(h)); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "a home folder or one above it"
+		}
+	}
+	return ""
+}
+
+// resolvedPath returns the absolute path of path with its links resolved, as
+// far as they can be
+func resolvedPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if resolved, err := vfs.Default.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return filepath.Clean(path)
+}
+
+// junkEntryAt checks that a file or directory in a junk location may be
+// deleted: a regular file, or a directory holding only regular files and
+// directories, last modified before cutoff. Sockets, pipes, devices and links,
+// such as the X11 and agent sockets in /tmp, are kept with whatever holds
+// them, as is anything in use recently.
+func junkEntryAt(path string, cutoff time.Time, progress *ops.Progress) (junkEntry, bool) {
+	entry := junkEntry{Path: path}
+	ok := true
+	vfs.WalkDir(vfs.Default, path, func(p string, d fs.DirEntry, err error) error {
+		if progress.Check() != nil {
+			ok = false
+			return fs.SkipAll
+		}
+		if err != nil {
+			ok = false
+			return fs.SkipAll
+		}
+		info, err := d.Info()
+		if err != nil || !info.IsDir() && !info.Mode().IsRegular() || !info.ModTime().Before(cutoff) {
+			ok = false
+			return fs.SkipAll
+		}
+		entry.ModTime = maxTime(entry.ModTime, info.ModTime())
+		if info.Mode().IsRegular() {
+			entry.Size += info.Size()
+			entry.Files++
+			progress.AddBytes(info.Size())
+			progress.AddItem()
+		}
+		return nil
+	})
+	return entry, ok
+}
+
+// maxTime returns the later of two times
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// scanJunkDir lists the entries of a junk location that cleaning deletes,
+// counting the ones it keeps
+func scanJunkDir(scan *junkScan, dir string, cutoff time.Time, progress *ops.Progress) {
+	entries, err := vfs.Default.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		entry, ok := junkEntryAt(filepath.Join(dir, e.Name()), cutoff, progress)
+		if !ok {
+			scan.Kept++
+			continue
+		}
+		scan.Entries = append(scan.Entries, entry)
+		scan.Size += entry.Size
+		scan.Files += entry.Files
+	}
+}
+
+// scanJunkCmd sizes what can be cleaned in the junk locations that exist on
+// this machine
+func (m *Model) scanJunkCmd(status string) tea.Cmd {
+	cutoff := time.Now().Add(-m.cleanAge)
+	return m.startJob("Scanning temporary files and caches", func(progress *ops.Progress) tea.Msg {
+		var results []junkScan
+		seen := make(map[string]bool)
+		for _, location := range junkLocations() {
+			scan := junkScan{Name: location.Name}
+			for _, pattern := range location.Patterns {
+				if pattern == "" {
+					continue
+				}
+				matches, _ := filepath.Glob(pattern)
+				for _, dir := range matches {
+					if info, err := vfs.Default.Stat(dir); err != nil || !info.IsDir() || seen[dir] {
+						continue
+					}
+					seen[dir] = true
+					if reason := refuseJunkDir(dir); reason != "" {
+						scan.Refused = append(scan.Refused, fmt.Sprintf("%s is %s", dir, reason))
+						continue
+					}
+					scan.Dirs = append(scan.Dirs, dir)
+					scanJunkDir(&scan, dir, cutoff, progress)
+				}
+			}
+			if len(scan.Dirs) > 0 || len(scan.Refused) > 0 {
+				results = append(results, scan)
+			}
+		}
+		sort.SliceStable(results, func(a, b int) bool { return results[a].Size > results[b].Size })
+		return junkScannedMsg{Results: results, Status: status}
+	})
+}
+
+// cleanJunkCmd deletes the scanned entries of the selected locations, keeping
+// the locations themselves. Each entry is checked again first, so one used
+// since the scan is kept, and entries that fail to delete, usually because
+// they are in use, are skipped.
+func (m *Model) cleanJunkCmd() tea.Cmd {
+	cutoff := time.Now().Add(-m.cleanAge)
+	var entries []junkEntry
+	for _, scan := range m.junk {
+		if scan.Selected {
+			entries = append(entries, scan.Entries...)
+		}
+	}
+	return func() tea.Msg {
+		var result junkCleanedMsg
+		for _, scanned := range entries {
+			entry, ok := junkEntryAt(scanned.Path, cutoff, nil)
+			if !ok {
+				result.Failed++
+				continue
+			}
+			if err := vfs.Default.RemoveAll(entry.Path); err != nil {
+				result.Failed++
+				// Part of a directory may be gone
+				left, _ := treeSize(entry.Path, nil)
+				result.Freed += max(entry.Size-left, 0)
+				continue
+			}
+			result.Freed += entry.Size
+		}
+		return result
+	}
+}
+
+// selectedJunk returns the number of selected locations, and the number and
+// total size of the entries cleaning them deletes
+func (m *Model) selectedJunk() (count, entries int, size int64) {
+	for _, scan := range m.junk {
+		if scan.Selected {
+			count++
+			entries += len(scan.Entries)
+			size += scan.Size
+		}
+	}
+	return count, entries, size
+}
+
+// junkPanel lists the scanned locations; Space selects and D cleans the selection
+func (m *Model) junkPanel() ListPanel {
+	entries := make([]ListEntry, 0, len(m.junk))
+	for i, scan := range m.junk {
+		check := "[ ]"
+		if scan.Selected {
+			check = "[x]"
+		}
+		label := fmt.Sprintf("%s %-26s %10s %8d files  ", check, scan.Name, FormatSize(scan.Size), scan.Files)
+		notes := scan.Dirs
+		if scan.Kept > 0 {
+			notes = append(slices.Clip(notes), fmt.Sprintf("%d recent or special entries kept", scan.Kept))
+		}
+		label += theme.Dim.Render(strings.Join(notes, ", "))
+		for _, refused := range scan.Refused {
+			label += " " + theme.Levels[levelWarn].Render("never cleaned: "+refused)
+		}
+		entry := ListEntry{Label: label, Data: i}
+		if len(scan.Dirs) > 0 {
+			entry.Msg = openPathMsg{Path: scan.Dirs[0]}
+		}
+		entries = append(entries, entry)
+	}
+
+	panel := NewListPanel(junkPanelTitle, entries)
+	count, _, size := m.selectedJunk()
+	panel.Subtitle = fmt.Sprintf("%d of %d selected, %s older than %s", count, len(m.junk), FormatSize(size), formatCleanAge(m.cleanAge))
+	if len(entries) == 0 {
+		panel.StatusMessage = "No junk locations found"
+		return panel
+	}
+	panel.Actions = []ListAction{
+		{
+			Key:  " ",
+			Desc: "select",
+			Msg: func(entry ListEntry) tea.Msg {
+				i, ok := entry.Data.(int)
+				if !ok {
+					return nil
+				}
+				return junkToggleMsg{Index: i}
+			},
+		},
+		{
+			Key:  "D",
+			Desc: "review and clean selected",
+			Msg: func(ListEntry) tea.Msg {
+				if count == 0 {
+					return nil
+				}
+				return junkReviewMsg{}
+			},
+		},
+	}
+	return panel
+}
+
+// formatCleanAge describes the clean_min_age setting, e.g. "7 days"
+func formatCleanAge(d time.Duration) string {
+	switch {
+	case d == 24*time.Hour:
+		return "1 day"
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%d days", d/(24*time.Hour))
+	}
+	return d.String()
+}
+
+// junkReviewPanel lists every entry cleaning the selected locations deletes;
+// D asks to delete them
+func (m *Model) junkReviewPanel() ListPanel {
+	var entries []ListEntry
+	for _, scan := range m.junk {
+		if !scan.Selected {
+			continue
+		}
+		for _, entry := range scan.Entries {
+			label := fmt.Sprintf("%10s  %s  %s", FormatSize(entry.Size), entry.ModTime.Format("2006-01-02"), entry.Path)
+			entries = append(entries, ListEntry{Label: label, Msg: revealPathMsg{Path: entry.Path}})
+		}
+	}
+	count, total, size := m.selectedJunk()
+	panel := NewListPanel(junkReviewTitle, entries)
+	panel.Subtitle = fmt.Sprintf("%d entries, %s, in %d locations", total, FormatSize(size), count)
+	if len(entries) == 0 {
+		panel.StatusMessage = "Nothing old enough to delete"
+		return panel
+	}
+	panel.StatusMessage = "D deletes these entries, Esc goes back"
+	panel.Actions = []ListAction{{
+		Key:  "D",
+		Desc: "delete",
+		Msg: func(ListEntry) tea.Msg {
+			return confirmPrompt(fmt.Sprintf("Delete these %d entries (%s)?", total, FormatSize(size)), junkCleanMsg{})
+		},
+	}}
+	return panel
+}
+
+// showJunkPanel replaces the cleanup panel, keeping its cursor and size
+func (m *Model) showJunkPanel(status string) {
+	panel := m.junkPanel()
+	panel.StatusMessage = status
+	if m.Mode == ListMode && m.List != nil && m.List.Title == junkPanelTitle {
+		panel.Width, panel.Height = m.List.Width, m.List.Height
+		panel.SetCursor(m.List.Cursor)
+		m.List = &panel
+		return
+	}
+	m.openList(panel)
+}
+
+// updateJunk handles the cleanup panel
+func (m Model) updateJunk(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case junkScannedMsg:
+		m.setStatus("")
+		m.junk = msg.Results
+		m.showJunkPanel(msg.Status)
+
+	case junkToggleMsg:
+		if msg.Index < len(m.junk) {
+			m.junk[msg.Index].Selected = !m.junk[msg.Index].Selected
+			m.showJunkPanel("")
+		}
+
+	case junkReviewMsg:
+		// Esc returns to the cleanup panel
+		parent := m.List
+		m.openList(m.junkReviewPanel())
+		m.parentList, m.ListReturnMode = parent, ListMode
+
+	case junkCleanMsg:
+		if m.Mode == ListMode && m.List != nil && m.List.Title == junkReviewTitle {
+			m.closeList()
+		}
+		m.setStatus("Cleaning...")
+		return m, m.cleanJunkCmd()
+
+	case junkCleanedMsg:
+		status := fmt.Sprintf("Freed %s", FormatSize(msg.Freed))
+		if msg.Failed > 0 {
+			status += fmt.Sprintf(" (%d entries in use or protected were skipped)", msg.Failed)
+		}
+		// Rescan so the sizes reflect what is left
+		m.setStatus(status + ", rescanning...")
+		return m, m.scanJunkCmd(status)
+	}
+	return m, nil
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package ui
+
+import "os"
+
+// junkLocations returns the well-known locations of temporary and cached files
+func junkLocations() []junkLocation {
+	cache, _ := os.UserCacheDir()
+
+	return []junkLocation{
+		{Name: "Temporary files", Patterns: []string{os.TempDir()}},
+		{Name: "Chrome cache", Patterns: []string{
+			under(cache, "google-chrome", "*", "Cache"),
+			under(cache, "Google", "Chrome", "*", "Cache"),
+		}},
+		{Name: "Chromium cache", Patterns: []string{under(cache, "chromium", "*", "Cache")}},
+		{Name: "Firefox cache", Patterns: []string{
+			under(cache, "mozilla", "firefox", "*", "cache2"),
+			under(cache, "Firefox", "Profiles", "*", "cache2"),
+		}},
+	}
+}
@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/uitest"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+)
+
+// TestCleanUsesVFS checks :clean checks and deletes the entries it scanned
+// on the file system it scanned them on
+func TestCleanUsesVFS(t *testing.T) {
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	root := uitest.Mount(t, fstest.MapFS{
+		"tmp/old.log":       {Data: []byte("old\n"), ModTime: old},
+		"tmp/build/out.obj": {Data: []byte("obj\n"), ModTime: old},
+		"tmp/build":         {Mode: fs.ModeDir | 0o755, ModTime: old},
+		"tmp/keep.txt":      {Data: []byte("keep\n"), ModTime: old},
+	})
+	tmp := filepath.Join(root, "tmp")
+	m := Model{cleanAge: 7 * 24 * time.Hour}
+	m.junk = []junkScan{{Name: "Temporary files", Selected: true, Entries: []junkEntry{
+		{Path: filepath.Join(tmp, "old.log")},
+		{Path: filepath.Join(tmp, "build")},
+	}}}
+
+	result, ok := m.cleanJunkCmd()().(junkCleanedMsg)
+	if !ok || result.Failed != 0 {
+		t.Fatalf("cleaning: %#v", result)
+	}
+	for _, name := range []string{"old.log", "build"} {
+		if _, err := vfs.Default.Lstat(filepath.Join(tmp, name)); err == nil {
+			t.Errorf("%s was not deleted", name)
+		}
+	}
+	if _, err := vfs.Default.Lstat(filepath.Join(tmp, "keep.txt")); err != nil {
+		t.Errorf("keep.txt, which was not scanned, is gone: %v", err)
+	}
+}
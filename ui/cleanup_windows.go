@@ -0,0 +1,31 @@
+//go:build windows
+
+package ui
+
+import "os"
+
+// junkLocations returns the well-known locations of temporary and cached files
+func junkLocations() []junkLocation {
+	local := os.Getenv("LOCALAPPDATA")
+	windir := os.Getenv("WINDIR")
+	programData := os.Getenv("PROGRAMDATA")
+
+	return []junkLocation{
+		{Name: "Temporary files", Patterns: []string{os.TempDir()}},
+		{Name: "Windows temporary files", Patterns: []string{under(windir, "Temp")}},
+		{Name: "Windows Update downloads", Patterns: []string{under(windir, "SoftwareDistribution", "Download")}},
+		{Name: "Windows error reports", Patterns: []string{
+			under(programData, "Microsoft", "Windows", "WER", "ReportArchive"),
+			under(programData, "Microsoft", "Windows", "WER", "ReportQueue"),
+		}},
+		{Name: "Chrome cache", Patterns: []string{
+			under(local, "Google", "Chrome", "User Data", "*", "Cache"),
+			under(local, "Google", "Chrome", "User Data", "*", "Code Cache"),
+		}},
+		{Name: "Edge cache", Patterns: []string{
+			under(local, "Microsoft", "Edge", "User Data", "*", "Cache"),
+			under(local, "Microsoft", "Edge", "User Data", "*", "Code Cache"),
+		}},
+		{Name: "Firefox cache", Patterns: []string{under(local, "Mozilla", "Firefox", "Profiles", "*", "cache2")}},
+	}
+}
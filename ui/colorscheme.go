@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// colorschemeMsg switches the colorscheme to one picked from the list
+type colorschemeMsg struct {
+	Name string
+}
+
+// setColorscheme makes the named colorscheme the one the viewer highlights
+// with; "auto" or an empty name follows the theme again
+func setColorscheme(name string) error {
+	if name == "auto" || name == "" {
+		colorscheme = ""
+		return nil
+	}
+	for _, known := range colorschemeNames() {
+		if strings.EqualFold(known, name) {
+			colorscheme = known
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown colorscheme %q (see :set colorscheme)", name)
+}
+
+// colorschemeStatus describes the colorscheme in use for the status line
+func colorschemeStatus() string {
+	if colorscheme == "" {
+		return fmt.Sprintf("Colorscheme: %s (from the %s theme)", theme.Syntax, theme.Name)
+	}
+	return fmt.Sprintf("Colorscheme: %s", colorscheme)
+}
+
+// colorschemePanel lists the colorschemes with the theme's first, marking the
+// one in use with *
+func colorschemePanel() ListPanel {
+	names := append([]string{"auto"}, colorschemeNames()...)
+	entries := make([]ListEntry, len(names))
+	current := 0
+	for i, name := range names {
+		flag := " "
+		if name == colorscheme || name == "auto" && colorscheme == "" {
+			flag = "*"
+			current = i
+		}
+		label := flag + " " + name
+		if name == "auto" {
+			label += theme.Dim.Render(fmt.Sprintf("  %s, from the %s theme", theme.Syntax, theme.Name))
+		}
+		entries[i] = ListEntry{Label: label, Msg: colorschemeMsg{Name: name}}
+	}
+	panel := NewListPanel("Colorschemes", entries)
+	panel.Subtitle = fmt.Sprintf("%d colorschemes", len(names)-1)
+	panel.SetCursor(current)
+	return panel
+}
+
+// setColorschemeOption handles :set colorscheme=<name> and :set colorscheme
+// <name>. Without a name it lists the colorschemes to pick from. The switch
+// itself is left to the model, which restyles every open buffer.
+func (fv *FileViewer) setColorschemeOption(name string) {
+	if name == "" {
+		panel := colorschemePanel()
+		fv.pendingCmd = func() tea.Msg { return openListMsg{panel} }
+		return
+	}
+	fv.pendingCmd = func() tea.Msg { return colorschemeMsg{Name: name} }
+}
+
+// pickColorscheme switches to a colorscheme picked from the list or set with
+// :set colorscheme, and renders the code blocks of open Markdown viewers
+// again in its colors
+func (m *Model) pickColorscheme(msg colorschemeMsg) {
+	if m.Mode == ListMode {
+		m.closeList()
+	}
+	if err := setColorscheme(msg.Name); err != nil {
+		m.setStatus(fmt.Sprintf("Error: %v", err))
+		return
+	}
+	m.restyleViewers()
+	m.setStatus(colorschemeStatus())
+}
@@ -0,0 +1,419 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// errorMsg reports an error from a background command
+type errorMsg struct {
+	Err error
+}
+
+// openListMsg requests that a list panel be shown
+type openListMsg struct {
+	Panel ListPanel
+}
+
+// openPromptMsg requests that a text prompt be shown
+type openPromptMsg struct {
+	Prompt Prompt
+}
+
+// reloadMsg requests that the current directory be re-read
+type reloadMsg struct{}
+
+// updateCommand handles keyboard input while the browser is in command mode
+func (m Model) updateCommand(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	if key == "tab" || key == "shift+tab" {
+		m.completeCommand(key == "shift+tab")
+		return m, nil
+	}
+	m.cmdCompletion = completion{}
+
+	switch key {
+	case "enter":
+		// Execute command
+		cmd := m.CommandBuffer
+		m.CommandMode = false
+		m.CommandBuffer = ""
+		return m.executeCommand(cmd)
+
+	case "esc", "ctrl+c":
+		// Cancel command
+		m.CommandMode = false
+		m.CommandBuffer = ""
+		m.StatusMessage = ""
+
+	case "backspace":
+		// Delete last character
+		m.CommandBuffer = trimLastRune(m.CommandBuffer)
+
+	default:
+		// Add typed or pasted text (only printable characters)
+		m.CommandBuffer += inputText(msg)
+	}
+
+	return m, nil
+}
+
+// completeCommand completes the directory names of a :cd path and the names
+// of :theme with Tab
+func (m *Model) completeCommand(backward bool) {
+	command, arg, ok := strings.Cut(m.CommandBuffer, " ")
+	if !ok {
+		return
+	}
+	var complete func(string) []string
+	switch command {
+	case "cd":
+		base := m.CurrentPath
+		complete = func(value string) []string { return completePath(value, base) }
+	case "theme":
+		complete = completeTheme
+	default:
+		return
+	}
+	delta := 1
+	if backward {
+		delta = -1
+	}
+	arg = strings.TrimLeft(arg, " ")
+	m.CommandBuffer = command + " " + m.cmdCompletion.next(arg, delta, complete)
+}
+
+// executeCommand parses and executes a browser command
+func (m Model) executeCommand(cmd string) (tea.Model, tea.Cmd) {
+	cmd = strings.TrimSpace(cmd)
+
+	if cmd == "" {
+		return m, nil
+	}
+
+	// Handle shell commands: :!! runs interactively, :! captures output
+	if strings.HasPrefix(cmd, "!") {
+		interactive := strings.HasPrefix(cmd, "!!")
+		line := strings.TrimSpace(strings.TrimLeft(cmd, "!"))
+		if line == "" {
+			m.StatusMessage = "Usage: :!<command> or :!!<command>"
+			return m, nil
+		}
+		if interactive {
+			return m, m.execShellCmd(line)
+		}
+		return m, m.runShellCmd(line)
+	}
+
+	// Split command into parts
+	parts := strings.Fields(cmd)
+	command := parts[0]
+	args := parts[1:]
+
+	switch command {
+	case "q", "quit":
+		return m, m.quit()
+
+	case "log":
+		return m, m.gitLogCmd(args)
+
+	case "eventlog", "events":
+		m.StatusMessage = "Reading event log..."
+		return m, eventLogCmd(args)
+
+	case "sysinfo":
+		m.StatusMessage = "Reading system information..."
+		return m, sysinfoCmd()
+
+	case "netstat":
+		m.StatusMessage = "Reading network connections..."
+		return m, netstatCmd(netstatMsg{Sort: "port", Filter: strings.Join(args, " ")})
+
+	case "startup":
+		m.StatusMessage = "Reading startup items..."
+		return m, startupCmd(len(args) > 0 && args[0] == "all")
+
+	case "apps":
+		m.StatusMessage = "Reading installed programs..."
+		return m, appsCmd(strings.Join(args, " "))
+
+	case "schtasks":
+		m.StatusMessage = "Loading scheduled tasks..."
+		return m, loadSchedTasksCmd(len(args) > 0 && args[0] == "all", "")
+
+	case "git", "status":
+		return m, gitStatusCmd(m.CurrentPath, 0)
+
+	case "tail":
+		lines, err := parseTailLines(args)
+		if err != nil {
+			m.StatusMessage = fmt.Sprintf("Error: %v", err)
+			return m, nil
+		}
+		m.tailSelected(lines)
+
+	case "hex":
+		m.hexSelected()
+
+	case "cd":
+		return m, m.changeDirectory(strings.TrimSpace(strings.TrimPrefix(cmd, command)))
+
+	case "root":
+		if m.Project == nil {
+			m.StatusMessage = "Not inside a project"
+			return m, nil
+		}
+		m.CurrentPath = m.Project.Root
+		m.loadDirectory()
+
+	case "projects":
+		return m, projectsCmd()
+
+	case "ls", "buffers":
+		m.openList(m.bufferPanel())
+
+	case "bn", "bnext":
+		return m, m.cycleBuffer(1)
+
+	case "bp", "bprev", "bprevious":
+		return m, m.cycleBuffer(-1)
+
+	case "find", "pfind":
+		if len(args) == 0 {
+			m.StatusMessage = fmt.Sprintf("Usage: :%s <pattern>", command)
+			return m, nil
+		}
+		scope := m.CurrentPath
+		if command == "pfind" {
+			if m.Project == nil {
+				m.StatusMessage = "Not inside a project"
+				return m, nil
+			}
+			scope = m.Project.Root
+		}
+		m.StatusMessage = "Searching..."
+		return m, findFilesCmd(scope, strings.Join(args, " "))
+
+	case "grep", "pgrep":
+		text := strings.TrimSpace(strings.TrimPrefix(cmd, command))
+		if text == "" {
+			m.StatusMessage = fmt.Sprintf("Usage: :%s <text>", command)
+			return m, nil
+		}
+		scope := m.CurrentPath
+		if command == "pgrep" {
+			if m.Project == nil {
+				m.StatusMessage = "Not inside a project"
+				return m, nil
+			}
+			scope = m.Project.Root
+		}
+		m.StatusMessage = ""
+		return m, m.grepCmd(scope, text)
+
+	case "tasks":
+		return m, m.tasksCmd()
+
+	case "merge":
+		paths, err := m.mergeTargets(args)
+		if err != nil {
+			m.StatusMessage = fmt.Sprintf("Error: %v", err)
+			return m, nil
+		}
+		if len(paths) < 2 {
+			m.StatusMessage = "Mark two or more files with Space, or use :merge <pattern>..."
+			return m, nil
+		}
+		m.StatusMessage = fmt.Sprintf("Merging %d files...", len(paths))
+		return m, mergeLogsCmd(paths)
+
+	case "clean":
+		m.StatusMessage = ""
+		return m, m.scanJunkCmd("")
+
+	case "prune":
+		m.StatusMessage = "Searching for empty directories..."
+		return m, findEmptyDirsCmd(m.CurrentPath)
+
+	case "sort":
+		m.sortCommand(args)
+
+	case "preview":
+		m.togglePreview()
+
+	case "set", "setlocal":
+		m.setOptions(args, command == "setlocal")
+
+	case "theme":
+		m.themeCommand(args)
+
+	case "calibrate":
+		m.openCalibrator()
+
+	case "screenshot":
+		if len(args) == 0 {
+			return m, screenshotPrompt
+		}
+		m.takeScreenshot(strings.Join(args, " "))
+
+	case "output":
+		if m.Output == nil {
+			m.StatusMessage = "No command output yet"
+			return m, nil
+		}
+		m.focusOutput()
+
+	case "lock":
+		m.lock = &screenLock{}
+
+	case "cancel":
+		m.cancelJobs()
+
+	case "jobs":
+		m.openList(m.jobsPanel())
+
+	case "pause":
+		m.pauseJobs()
+
+	case "resume":
+		return m, m.resumeJobs()
+
+	case "verify":
+		m.toggleVerify()
+
+	case "info":
+		m.showProperties()
+
+	case "play":
+		return m, m.playCommand()
+
+	case "compress":
+		return m, m.compressKey(true)
+
+	case "uncompress":
+		return m, m.compressKey(false)
+
+	case "links":
+		return m, m.linkNamesKey()
+
+	case "flatten":
+		m.flattenCommand(args)
+
+	case "drives":
+		return m, drivesCmd(m.CurrentPath)
+
+	case "zip":
+		return m, m.zipCommand(args)
+
+	case "slideshow":
+		return m, m.slideshowCommand(args)
+
+	case "mail":
+		return m, m.mailCommand()
+
+	case "touch":
+		return m, m.touchCommand(args)
+
+	case "close":
+		m.blurOutput(true)
+
+	case "help", "h":
+		m.StatusMessage = "Commands: :log [%] | :git | :eventlog [log] [since] | :schtasks [all] | :apps [filter] | :startup [all] | :netstat [filter] | :sysinfo | :tail [lines] | :hex | :cd [path] | :root | :projects | :ls | :bn | :bp | :find/:pfind <pattern> | :grep/:pgrep <text> | :tasks | :merge [pattern...] | :set[local] [option...] | :sort [order] [asc|desc] | :preview | :theme [name] | :calibrate | :prune | :clean | :jobs | :pause | :resume | :cancel | :verify | :info | :play | :compress | :uncompress | :links | :touch [-b] [-t time] | :screenshot [file] | :output | :close | :lock | :!<cmd> | :!!<cmd> | :help | :q"
+
+	default:
+		m.StatusMessage = fmt.Sprintf("Unknown command '%s' (try :help)", command)
+	}
+
+	return m, nil
+}
+
+// setStatus sets the status message on whichever component is visible
+func (m *Model) setStatus(status string) {
+	switch {
+	case m.Mode == ListMode && m.List != nil:
+		m.List.StatusMessage = status
+	case m.Mode == FileViewMode && m.FileViewer != nil:
+		m.FileViewer.StatusMessage = status
+	case m.Mode == OutputMode && m.Output != nil:
+		m.Output.StatusMessage = status
+	default:
+		m.StatusMessage = status
+	}
+}
+
+// selectedItemPath returns the path of the item under the cursor, if any
+func (m *Model) selectedItemPath() (string, bool) {
+	if m.Cursor < 0 || m.Cursor >= len(m.Items) {
+		return "", false
+	}
+	return m.Items[m.Cursor].Path, true
+}
+
+// markedPaths returns the marked files in directory order
+func (m *Model) markedPaths() []string {
+	var paths []string
+	for _, item := range m.Items {
+		if m.Marked[item.Path] && !item.IsDir {
+			paths = append(paths, item.Path)
+		}
+	}
+	return paths
+}
+
+// openList shows a list panel sized to the window
+func (m *Model) openList(panel ListPanel) {
+	panel.Width = m.Width
+	panel.Height = m.Height
+	if m.Mode != ListMode {
+		m.ListReturnMode = BrowseMode
+		m.parentList = nil
+		if m.Mode == FileViewMode {
+			// Keep the list the viewer was opened from, if any
+			m.ListReturnMode = FileViewMode
+			m.parentList = m.List
+		}
+	}
+	m.List = &panel
+	m.Mode = ListMode
+}
+
+// closeList leaves the list panel for the mode it was opened from
+func (m *Model) closeList() {
+	m.Mode = m.ListReturnMode
+	m.List = m.parentList
+	m.parentList = nil
+	m.ListReturnMode = BrowseMode
+}
+
+// openViewer shows the given viewer, returning to the current mode when closed.
+// A viewer opened from another one returns to it.
+func (m *Model) openViewer(viewer *FileViewer) {
+	viewer.Height = m.Height
+	viewer.Width = m.Width
+	viewer.fitMarkdown()
+	viewer.HighlightRules = m.highlightRules
+	if viewer.Language == "" {
+		if lang := m.filetypeFor(viewer.FileName); lang != "" {
+			viewer.configLanguage = lang
+			if err := viewer.SetLanguage(lang); err != nil {
+				viewer.StatusMessage = fmt.Sprintf("Error: filetypes: %v", err)
+			}
+		}
+	}
+	if m.Mode == FileViewMode && m.FileViewer != nil {
+		m.parentViewer = m.FileViewer
+	} else {
+		m.ReturnMode = m.Mode
+		m.parentViewer = nil
+	}
+	m.FileViewer = viewer
+	m.Mode = FileViewMode
+}
+
+// openContent shows in-memory content in the file viewer
+func (m *Model) openContent(name, content string) {
+	viewer := NewContentViewer(name, content)
+	m.openViewer(&viewer)
+}
@@ -0,0 +1,61 @@
+//go:build ignore
+
+// copystyles refreshes ui/colorschemes from the styles of the chroma module in
+// go.mod. Run it with go generate in ui after upgrading chroma.
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// chromaModule is the module whose bundled styles are copied
+const chromaModule = "github.com/alecthomas/chroma/v2"
+
+// main replaces the embedded colorschemes with chroma's styles
+func main() {
+	if err := copyStyles("colorschemes"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// copyStyles replaces the .xml files in dir with those of chroma's styles
+// package, so styles chroma dropped go too
+func copyStyles(dir string) error {
+	out, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", chromaModule).Output()
+	if err != nil {
+		return fmt.Errorf("finding %s: %v", chromaModule, err)
+	}
+	sources, err := filepath.Glob(filepath.Join(strings.TrimSpace(string(out)), "styles", "*.xml"))
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("no styles found in %s", chromaModule)
+	}
+
+	old, err := filepath.Glob(filepath.Join(dir, "*.xml"))
+	if err != nil {
+		return err
+	}
+	for _, name := range old {
+		if err := os.Remove(name); err != nil {
+			return err
+		}
+	}
+	for _, source := range sources {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(source)), data, 0o644); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Copied %d styles to %s\n", len(sources), dir)
+	return nil
+}
@@ -0,0 +1,475 @@
+package ui
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxColumnWidth is the widest a table column is drawn; longer cells are cut
+// with an ellipsis
+const maxColumnWidth = 40
+
+// delimiterLines is how many lines of a CSV file are read to guess its
+// delimiter
+const delimiterLines = 20
+
+// tableDelimiters are the delimiters CSV files are checked for, in order of
+// preference
+var tableDelimiters = []rune{',', ';', '\t', '|'}
+
+// tableRow is a record of a CSV file
+type tableRow struct {
+	Cells []string
+	Line  int // Line of the file the record starts on, from 0
+}
+
+// csvTable is a CSV or TSV file shown as an aligned table rather than as its
+// text. The header row stays above the rows as they scroll.
+type csvTable struct {
+	Source    string // Text of the file, shown again with :set raw
+	Delimiter rune
+	header    []string
+	rows      []tableRow // Records after the header, in file order
+	shown     []tableRow // Records in the order shown
+	widths    []int      // Width of each column
+	numeric   []bool     // Columns whose cells are all numbers, aligned right
+	offset    int        // First column shown, moved by ←/→
+	sortCol   int        // Column sorted by, from 1, or 0 for the file order
+	desc      bool       // Whether the sort is descending
+}
+
+// isTable reports whether a file name has a CSV or TSV extension
+func isTable(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".csv" || ext == ".tsv"
+}
+
+// detectDelimiter guesses the delimiter of CSV text: the candidate found the
+// same number of times, outside quotes, on the most of its first lines
+func detectDelimiter(source string) rune {
+	lines := strings.SplitN(source, "\n", delimiterLines+1)
+	if len(lines) > delimiterLines {
+		lines = lines[:delimiterLines]
+	}
+	best, bestScore, bestCount := ',', 0, 0
+	for _, delim := range tableDelimiters {
+		first, score := -1, 0
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			count := countOutsideQuotes(line, delim)
+			if first < 0 {
+				first = count
+			}
+			if count == first && count > 0 {
+				score++
+			}
+		}
+		if score > bestScore || score == bestScore && first > bestCount {
+			best, bestScore, bestCount = delim, score, first
+		}
+	}
+	return best
+}
+
+// countOutsideQuotes counts the occurrences of delim in a line that are not
+// inside double quotes
+func countOutsideQuotes(line string, delim rune) int {
+	count, quoted := 0, false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == delim && !quoted:
+			count++
+		}
+	}
+	return count
+}
+
+// parseTable reads the records of CSV text. Quotes may be missing or stray,
+// and records may have any number of fields.
+func parseTable(source string, delim rune) (header []string, rows []tableRow, err error) {
+	reader := csv.NewReader(strings.NewReader(strings.TrimPrefix(source, "\ufeff")))
+	reader.Comma = delim
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if header == nil {
+			header = record
+			continue
+		}
+		rows = append(rows, tableRow{Cells: record, Line: line - 1})
+	}
+	return header, rows, nil
+}
+
+// newCSVTable parses a CSV or TSV file; ok is false if it has no records
+func newCSVTable(name, source string) (table *csvTable, ok bool, err error) {
+	delim := '\t'
+	if strings.EqualFold(filepath.Ext(name), ".csv") {
+		delim = detectDelimiter(source)
+	}
+	header, rows, err := parseTable(source, delim)
+	if err != nil || header == nil {
+		return nil, false, err
+	}
+	t := &csvTable{Source: source, Delimiter: delim, header: header, rows: rows}
+	t.measure()
+	t.sort()
+	return t, true, nil
+}
+
+// columns returns the number of columns of the widest record
+func (t *csvTable) columns() int {
+	n := len(t.header)
+	for _, row := range t.rows {
+		n = max(n, len(row.Cells))
+	}
+	return n
+}
+
+// measure finds the width of each column and which ones hold numbers
+func (t *csvTable) measure() {
+	n := t.columns()
+	t.widths = make([]int, n)
+	t.numeric = make([]bool, n)
+	for col := range n {
+		t.widths[col] = utf8.RuneCountInString(cellText(t.header, col))
+		t.numeric[col] = true
+		values := 0
+		for _, row := range t.rows {
+			text := cellText(row.Cells, col)
+			t.widths[col] = max(t.widths[col], utf8.RuneCountInString(text))
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+			values++
+			if _, ok := cellNumber(text); !ok {
+				t.numeric[col] = false
+			}
+		}
+		t.numeric[col] = t.numeric[col] && values > 0
+		t.widths[col] = min(t.widths[col], maxColumnWidth)
+	}
+}
+
+// cellText returns a cell as it is drawn, with line breaks and tabs shown on
+// one line; missing cells are empty
+func cellText(cells []string, col int) string {
+	if col >= len(cells) {
+		return ""
+	}
+	text := strings.ReplaceAll(cells[col], "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\n", "⏎")
+	return strings.ReplaceAll(text, "\t", " ")
+}
+
+// cellNumber parses a cell holding a number
+func cellNumber(text string) (float64, bool) {
+	n, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	return n, err == nil
+}
+
+// sort orders the shown rows by the sort column, comparing numbers as numbers
+// and text ignoring case, or restores the file order
+func (t *csvTable) sort() {
+	t.shown = slices.Clone(t.rows)
+	if t.sortCol == 0 {
+		return
+	}
+	col := t.sortCol - 1
+	slices.SortStableFunc(t.shown, func(a, b tableRow) int {
+		x, y := cellText(a.Cells, col), cellText(b.Cells, col)
+		var c int
+		if t.numeric[col] {
+			// Empty cells sort first
+			nx, okx := cellNumber(x)
+			ny, oky := cellNumber(y)
+			c = cmp.Or(cmp.Compare(boolInt(okx), boolInt(oky)), cmp.Compare(nx, ny))
+		} else {
+			c = cmp.Compare(strings.ToLower(x), strings.ToLower(y))
+		}
+		if t.desc {
+			return -c
+		}
+		return c
+	})
+}
+
+// boolInt returns 1 for true and 0 for false
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// render returns the header line and a plain and a styled line per row, from
+// the first column shown
+func (t *csvTable) render() (header string, plain, styled []string) {
+	header = theme.TableHeader + t.line(t.header, nil) + "\x1b[0m"
+	for _, row := range t.shown {
+		var rules []int
+		plain = append(plain, t.line(row.Cells, &rules))
+		styled = append(styled, styleRules(plain[len(plain)-1], rules))
+	}
+	return header, plain, styled
+}
+
+// line lays out the cells of a record in their columns, separated by │, and
+// collects the byte offsets of the separators in rules
+func (t *csvTable) line(cells []string, rules *[]int) string {
+	var b strings.Builder
+	for col := t.offset; col < len(t.widths); col++ {
+		if col > t.offset {
+			if rules != nil {
+				*rules = append(*rules, b.Len()+1)
+			}
+			b.WriteString(" │ ")
+		}
+		text := cellText(cells, col)
+		width := t.widths[col]
+		if n := utf8.RuneCountInString(text); n > width {
+			text = string([]rune(text)[:width-1]) + "…"
+		}
+		pad := strings.Repeat(" ", width-utf8.RuneCountInString(text))
+		if t.numeric[col] {
+			b.WriteString(pad + text)
+		} else if col < len(t.widths)-1 {
+			b.WriteString(text + pad)
+		} else {
+			b.WriteString(text)
+		}
+	}
+	return b.String()
+}
+
+// styleRules dims the column separators at the given byte offsets of a line
+func styleRules(line string, rules []int) string {
+	var b strings.Builder
+	last := 0
+	for _, at := range rules {
+		b.WriteString(line[last:at])
+		b.WriteString(theme.TableRule + "│" + "\x1b[0m")
+		last = at + len("│")
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
+
+// rowLine returns the line of the file shown row i starts on
+func (t *csvTable) rowLine(i int) int {
+	if i < 0 || i >= len(t.shown) {
+		return 0
+	}
+	return t.shown[i].Line
+}
+
+// lineRow returns the shown row holding line of the file
+func (t *csvTable) lineRow(line int) int {
+	best, bestLine := 0, -1
+	for i, row := range t.shown {
+		if row.Line <= line && row.Line > bestLine {
+			best, bestLine = i, row.Line
+		}
+	}
+	return best
+}
+
+// describe summarizes the table for the viewer's info line
+func (t *csvTable) describe() string {
+	info := fmt.Sprintf("Table: %d rows × %d columns", len(t.rows), len(t.widths))
+	if t.offset > 0 {
+		info += fmt.Sprintf(", from column %d", t.offset+1)
+	}
+	if t.sortCol > 0 {
+		order := "ascending"
+		if t.desc {
+			order = "descending"
+		}
+		info += fmt.Sprintf(", sorted by %s %s", t.columnName(t.sortCol-1), order)
+	}
+	return info + " (←/→ columns, :col sort <n>, :set raw for the text)"
+}
+
+// columnName returns the header of a column, or its number if it has none
+func (t *csvTable) columnName(col int) string {
+	if name := strings.TrimSpace(cellText(t.header, col)); name != "" {
+		return name
+	}
+	return strconv.Itoa(col + 1)
+}
+
+// findColumn returns the column given by number, from 1, or by header name
+func (t *csvTable) findColumn(arg string) (int, error) {
+	if n, err := strconv.Atoi(arg); err == nil {
+		if n < 1 || n > len(t.widths) {
+			return 0, fmt.Errorf("column %d is not between 1 and %d", n, len(t.widths))
+		}
+		return n - 1, nil
+	}
+	for col := range t.widths {
+		if strings.EqualFold(strings.TrimSpace(cellText(t.header, col)), arg) {
+			return col, nil
+		}
+	}
+	return 0, fmt.Errorf("no column named %s", arg)
+}
+
+// setTableContent shows CSV or TSV text as a table; ok is false if it has no
+// records to show
+func (fv *FileViewer) setTableContent(source string) (ok bool, err error) {
+	table, ok, err := newCSVTable(fv.FileName, source)
+	if !ok {
+		return false, err
+	}
+	fv.table = table
+	fv.showTable()
+	fv.Sections = nil
+	fv.spans = nil
+	fv.highlighter = nil
+	fv.fileType = "CSV"
+	return true, nil
+}
+
+// showTable lays out the rows of the table again
+func (fv *FileViewer) showTable() {
+	fv.table.sort()
+	fv.tableHeader, fv.Content, fv.styledLines = fv.table.render()
+}
+
+// setRawTable shows a CSV or TSV file as its text, or as a table again
+// (:set raw / :set noraw)
+func (fv *FileViewer) setRawTable(raw bool) {
+	if fv.hex || fv.window != nil {
+		fv.StatusMessage = "Only whole CSV and TSV files are shown as tables"
+		return
+	}
+	if raw == fv.RawTable {
+		return
+	}
+	fv.RawTable = raw
+	if raw {
+		line := 0
+		if fv.table != nil {
+			line = fv.table.rowLine(fv.lineAt(fv.ScrollPos))
+		}
+		fv.replaceContent(fv.table.Source, func() int { return line })
+		fv.StatusMessage = "Showing the text of the table"
+		return
+	}
+	line := fv.lineAt(fv.ScrollPos)
+	fv.replaceContent(strings.Join(fv.Content, "\n"), func() int {
+		if fv.table == nil {
+			return line
+		}
+		return fv.table.lineRow(line)
+	})
+	if fv.table != nil {
+		fv.StatusMessage = "Showing the table"
+	}
+}
+
+// scrollColumns moves the first column shown by delta
+func (fv *FileViewer) scrollColumns(delta int) {
+	offset := max(min(fv.table.offset+delta, len(fv.table.widths)-1), 0)
+	if offset == fv.table.offset {
+		return
+	}
+	fv.table.offset = offset
+	fv.refreshTable()
+}
+
+// refreshTable shows the table again after scrolling or sorting, keeping the
+// search and filter
+func (fv *FileViewer) refreshTable() {
+	fv.showTable()
+	fv.resetLinks()
+	fv.refilter(0)
+	if fv.searchRe != nil {
+		fv.findMatches()
+	}
+}
+
+// columnCommand sorts the table by a column or scrolls to it
+// (:col sort <n> [desc], :col sort to restore the file order, :col <n>)
+func (fv *FileViewer) columnCommand(args []string) {
+	if fv.table == nil {
+		fv.StatusMessage = "Only CSV and TSV files are shown as tables"
+		return
+	}
+	if len(args) == 0 {
+		fv.StatusMessage = "Usage: :col sort <n|name> [desc] | :col sort | :col <n|name>"
+		return
+	}
+	t := fv.table
+	if args[0] != "sort" {
+		col, err := t.findColumn(strings.Join(args, " "))
+		if err != nil {
+			fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+			return
+		}
+		t.offset = col
+		fv.refreshTable()
+		return
+	}
+
+	args = args[1:]
+	if len(args) == 0 {
+		t.sortCol, t.desc = 0, false
+		fv.refreshTable()
+		fv.ScrollPos = 0
+		fv.StatusMessage = "Rows in file order"
+		return
+	}
+	desc := false
+	if last := strings.ToLower(args[len(args)-1]); len(args) > 1 && (last == "desc" || last == "asc") {
+		desc = last == "desc"
+		args = args[:len(args)-1]
+	}
+	col, err := t.findColumn(strings.Join(args, " "))
+	if err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	t.sortCol, t.desc = col+1, desc
+	fv.refreshTable()
+	fv.ScrollPos = 0
+	order := "ascending"
+	if desc {
+		order = "descending"
+	}
+	kind := "text"
+	if t.numeric[col] {
+		kind = "numbers"
+	}
+	fv.StatusMessage = fmt.Sprintf("Sorted by %s, %s (%s)", t.columnName(col), order, kind)
+}
+
+// tableHeaderLine returns the sticky header of a table, lined up with the
+// columns of the rows under it
+func (fv *FileViewer) tableHeaderLine() string {
+	gutter := strings.Repeat(" ", len(fv.lineLabel(fv.lineAt(fv.ScrollPos)))+3+fv.gutterWidth())
+	header := fv.tableHeader
+	if available := fv.Width - 10 - fv.gutterWidth(); !fv.WrapLines && available > 0 && visualLength(header) > available {
+		header = truncateAtVisualWidth(header, available-3) + "\x1b[0m..."
+	}
+	return gutter + header
+}
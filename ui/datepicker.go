@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// datePickerLayout is how the picked time is shown, one field per group
+const datePickerLayout = "2006-01-02 15:04:05"
+
+// Fields of the date picker, in the order they are shown
+const (
+	pickYear = iota
+	pickMonth
+	pickDay
+	pickHour
+	pickMinute
+	pickSecond
+	pickFields
+)
+
+// pickFieldSpans are the start and end of each field in datePickerLayout
+var pickFieldSpans = [pickFields][2]int{{0, 4}, {5, 7}, {8, 10}, {11, 13}, {14, 16}, {17, 19}}
+
+// DatePicker is a prompt for a local date and time, shown at the bottom of
+// the screen. The arrows pick and change a field and digits type it.
+type DatePicker struct {
+	Label  string                    // Text shown before the time
+	Time   time.Time                 // Time picked so far
+	Field  int                       // Field the arrows and digits change
+	Submit func(t time.Time) tea.Msg // Builds the message sent on Enter
+	digits string                    // Digits typed into the field so far
+}
+
+// openDatePickerMsg requests that a date picker be shown
+type openDatePickerMsg struct {
+	Picker DatePicker
+}
+
+// fieldValue returns the value of a field of t
+func fieldValue(t time.Time, field int) int {
+	switch field {
+	case pickYear:
+		return t.Year()
+	case pickMonth:
+		return int(t.Month())
+	case pickDay:
+		return t.Day()
+	case pickHour:
+		return t.Hour()
+	case pickMinute:
+		return t.Minute()
+	}
+	return t.Second()
+}
+
+// withField returns t with a field set to value, or false if the value is
+// out of range for the field
+func withField(t time.Time, field, value int) (time.Time, bool) {
+	parts := [pickFields]int{}
+	for f := range parts {
+		parts[f] = fieldValue(t, f)
+	}
+	parts[field] = value
+	set := time.Date(parts[pickYear], time.Month(parts[pickMonth]), parts[pickDay],
+		parts[pickHour], parts[pickMinute], parts[pickSecond], 0, time.Local)
+	// time.Date normalizes values out of range, such as February 30
+	return set, fieldValue(set, field) == value
+}
+
+// step moves a field up or down by one, carrying into the other fields
+func (dp *DatePicker) step(delta int) {
+	switch dp.Field {
+	case pickYear:
+		dp.Time = dp.Time.AddDate(delta, 0, 0)
+	case pickMonth:
+		dp.Time = dp.Time.AddDate(0, delta, 0)
+	case pickDay:
+		dp.Time = dp.Time.AddDate(0, 0, delta)
+	case pickHour:
+		dp.Time = dp.Time.Add(time.Duration(delta) * time.Hour)
+	case pickMinute:
+		dp.Time = dp.Time.Add(time.Duration(delta) * time.Minute)
+	default:
+		dp.Time = dp.Time.Add(time.Duration(delta) * time.Second)
+	}
+}
+
+// moveField selects the field delta places away, stopping at either end
+func (dp *DatePicker) moveField(delta int) {
+	dp.Field = min(max(dp.Field+delta, 0), pickFields-1)
+	dp.digits = ""
+}
+
+// typeDigit adds a digit to the field, which is set once all its digits are
+// typed and then the next field is selected
+func (dp *DatePicker) typeDigit(digit string) {
+	dp.digits += digit
+	span := pickFieldSpans[dp.Field]
+	if len(dp.digits) < span[1]-span[0] {
+		return
+	}
+	value, _ := strconv.Atoi(dp.digits)
+	dp.digits = ""
+	if t, ok := withField(dp.Time, dp.Field, value); ok {
+		dp.Time = t
+		dp.moveField(1)
+	}
+}
+
+// Update handles keyboard input for the date picker and reports whether it
+// is finished
+func (dp *DatePicker) Update(msg tea.KeyMsg) (bool, tea.Cmd) {
+	switch key := msg.String(); key {
+	case "enter":
+		t := dp.Time
+		submit := dp.Submit
+		return true, func() tea.Msg { return submit(t) }
+
+	case "esc", "ctrl+c":
+		return true, nil
+
+	case "left", "h", "shift+tab":
+		dp.moveField(-1)
+
+	case "right", "l", "tab":
+		dp.moveField(1)
+
+	case "up", "k", "+":
+		dp.digits = ""
+		dp.step(1)
+
+	case "down", "j", "-":
+		dp.digits = ""
+		dp.step(-1)
+
+	case "n":
+		// Now, to the second
+		dp.Time = time.Now().Truncate(time.Second)
+		dp.digits = ""
+
+	case "backspace":
+		dp.digits = trimLastRune(dp.digits)
+
+	default:
+		if len(key) == 1 && key[0] >= '0' && key[0] <= '9' {
+			dp.typeDigit(key)
+		}
+	}
+	return false, nil
+}
+
+// View renders the picker line with the selected field highlighted
+func (dp DatePicker) View() string {
+	text := dp.Time.Format(datePickerLayout)
+	span := pickFieldSpans[dp.Field]
+	field := text[span[0]:span[1]]
+	if dp.digits != "" {
+		// Typed digits replace the start of the field
+		field = dp.digits + strings.Repeat("_", len(field)-len(dp.digits))
+	}
+	help := theme.Dim.Render("  ←/→ field │ ↑/↓ change │ 0-9 type │ n now │ Enter set │ Esc cancel")
+	return fmt.Sprintf("%s%s%s%s%s", dp.Label, text[:span[0]], theme.Selected.Render(field), text[span[1]:], help)
+}
@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timingNames orders the timings shown in the debug overlay
+var timingNames = []string{"render", "update", "search", "highlight"}
+
+// heapSampleInterval limits how often the overlay reads heap statistics, as
+// reading them briefly stops the program
+const heapSampleInterval = time.Second
+
+// profiler collects timings for the debug overlay; nil unless profiling is enabled
+var profiler *profileStats
+
+// timing summarizes the durations of one kind of operation
+type timing struct {
+	Last time.Duration
+	Avg  time.Duration // Moving average, weighted towards recent runs
+	Max  time.Duration
+}
+
+// profileStats holds the timings shown in the debug overlay. Highlighting is
+// timed in background commands, so access is locked.
+type profileStats struct {
+	Addr     string // pprof endpoint
+	mu       sync.Mutex
+	timings  map[string]*timing
+	heap     uint64
+	heapRead time.Time
+}
+
+// EnableProfiling shows a debug overlay with render, update, search and
+// highlighting times; addr is the pprof endpoint listed in it
+func EnableProfiling(addr string) {
+	profiler = &profileStats{Addr: addr, timings: make(map[string]*timing)}
+}
+
+// measure starts timing an operation; call the returned function when it ends
+func measure(name string) func() {
+	if profiler == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() { profiler.record(name, time.Since(start)) }
+}
+
+// record adds a duration to the timing of an operation
+func (p *profileStats) record(name string, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	t, ok := p.timings[name]
+	if !ok {
+		t = &timing{Avg: d}
+		p.timings[name] = t
+	}
+	t.Last = d
+	t.Avg = (t.Avg*7 + d) / 8
+	t.Max = max(t.Max, d)
+}
+
+// formatDuration shows a duration in milliseconds
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+}
+
+// overlay renders the debug line shown under every screen
+func (p *profileStats) overlay() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if time.Since(p.heapRead) >= heapSampleInterval {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		p.heap = stats.HeapAlloc
+		p.heapRead = time.Now()
+	}
+
+	var parts []string
+	for _, name := range timingNames {
+		t, ok := p.timings[name]
+		if !ok {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %s (avg %s, max %s)",
+			name, formatDuration(t.Last), formatDuration(t.Avg), formatDuration(t.Max)))
+	}
+	parts = append(parts, "heap "+FormatSize(int64(p.heap)))
+	if p.Addr != "" {
+		parts = append(parts, fmt.Sprintf("pprof http://%s/debug/pprof/", p.Addr))
+	}
+	return theme.Dim.Render("⏱ " + strings.Join(parts, " │ "))
+}
@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/clipboard"
+	"github.com/HolyStarGazer/windows-tui-go/diff"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// clipboardDiffMsg delivers the differences between a viewer's file and the
+// clipboard
+type clipboardDiffMsg struct {
+	Viewer *FileViewer
+	Diff   string // Unified diff, empty if they match
+	Err    error
+}
+
+// clipboardDiffCmd compares the viewed file with the text on the clipboard in
+// the background. Files are compared as they are on disk, before tabs are
+// expanded for display, unless only part of one is shown.
+func (fv *FileViewer) clipboardDiffCmd() tea.Cmd {
+	path, name := fv.FilePath, fv.FileName
+	if fv.part.Total > 0 {
+		// Only part of the file was read
+		path = ""
+	}
+	content := strings.Join(fv.Content, "\n")
+	return func() tea.Msg {
+		clip, err := clipboard.Text()
+		if err != nil {
+			return clipboardDiffMsg{Viewer: fv, Err: err}
+		}
+		if path != "" {
+			data, err := vfs.ReadFile(vfs.Default, path)
+			if err != nil {
+				return clipboardDiffMsg{Viewer: fv, Err: err}
+			}
+			content = string(data)
+		}
+		return clipboardDiffMsg{Viewer: fv, Diff: diff.Unified(name, "clipboard", content, clip, diffContext)}
+	}
+}
+
+// showClipboardDiff opens the diff over the viewer it was made for; q returns
+// to the file
+func (m *Model) showClipboardDiff(msg clipboardDiffMsg) {
+	if msg.Viewer != m.FileViewer {
+		return
+	}
+	switch {
+	case msg.Err != nil:
+		msg.Viewer.StatusMessage = fmt.Sprintf("Error: %v", msg.Err)
+	case msg.Diff == "":
+		msg.Viewer.StatusMessage = "The clipboard matches the file"
+	default:
+		m.openContent(msg.Viewer.FileName+" vs clipboard.diff", msg.Diff)
+	}
+}
@@ -0,0 +1,224 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/HolyStarGazer/windows-tui-go/bookmarks"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// dirBookmarksTitle is the title of the directory bookmark picker, used to
+// refresh it in place
+const dirBookmarksTitle = "🔖 Bookmarks"
+
+// dirBookmarksMsg delivers the saved directory bookmarks after loading or
+// changing them
+type dirBookmarksMsg struct {
+	Bookmarks []bookmarks.Bookmark
+	Select    string // Path of the bookmark to put the cursor on, if any
+	Status    string
+	Open      bool // Open the picker if it is not open already
+}
+
+// addDirBookmarkMsg bookmarks a directory under a name
+type addDirBookmarkMsg struct {
+	Path string
+	Name string
+}
+
+// renameDirBookmarkMsg renames the bookmark of a directory
+type renameDirBookmarkMsg struct {
+	Path string
+	Name string
+}
+
+// deleteDirBookmarkMsg removes the bookmark of a directory
+type deleteDirBookmarkMsg struct {
+	Path string
+}
+
+// moveDirBookmarkMsg moves a bookmark up or down the picker
+type moveDirBookmarkMsg struct {
+	Path  string
+	Delta int
+}
+
+// bookmarkDirKey asks for a name to bookmark the browsed directory under (b)
+func (m *Model) bookmarkDirKey() tea.Cmd {
+	dir := m.CurrentPath
+	name := filepath.Base(dir)
+	if name == string(filepath.Separator) || name == "." {
+		name = dir
+	}
+	return func() tea.Msg {
+		return openPromptMsg{Prompt{
+			Label:  fmt.Sprintf("Bookmark %s as: ", dir),
+			Buffer: name,
+			Submit: func(value string) tea.Msg {
+				if value == "" {
+					return nil
+				}
+				return addDirBookmarkMsg{Path: dir, Name: value}
+			},
+		}}
+	}
+}
+
+// dirBookmarksCmd loads the bookmarks into the picker (B)
+func dirBookmarksCmd() tea.Cmd {
+	return func() tea.Msg {
+		list, err := bookmarks.Load()
+		if err != nil {
+			return errorMsg{fmt.Errorf("loading bookmarks: %w", err)}
+		}
+		return dirBookmarksMsg{Bookmarks: list, Open: true}
+	}
+}
+
+// editDirBookmarksCmd changes the saved bookmarks in the background and
+// reports the result with status, selecting the bookmark of path
+func editDirBookmarksCmd(edit func() ([]bookmarks.Bookmark, error), path, status string) tea.Cmd {
+	return func() tea.Msg {
+		list, err := edit()
+		if err != nil {
+			return errorMsg{fmt.Errorf("saving bookmarks: %w", err)}
+		}
+		return dirBookmarksMsg{Bookmarks: list, Select: path, Status: status}
+	}
+}
+
+// dirBookmarksPanel lists the bookmarks in their order; Enter browses one, r
+// renames, d deletes and K/J move it up or down
+func dirBookmarksPanel(list []bookmarks.Bookmark) ListPanel {
+	entries := make([]ListEntry, 0, len(list))
+	for _, b := range list {
+		label := fmt.Sprintf("%s %s", theme.Directory.Render(fmt.Sprintf("%-24s", b.Name)), b.Path)
+		if _, err := vfs.Default.Stat(b.Path); err != nil {
+			label += "  " + theme.Dim.Render("(missing)")
+		}
+		entries = append(entries, ListEntry{
+			Label: label,
+			Data:  b,
+			Msg:   openPathMsg{Path: b.Path},
+		})
+	}
+
+	panel := NewListPanel(dirBookmarksTitle, entries)
+	panel.Subtitle = fmt.Sprintf("%d bookmarks", len(list))
+	if len(entries) == 0 {
+		panel.StatusMessage = "No bookmarks yet (b bookmarks the current directory)"
+		return panel
+	}
+	selected := func(entry ListEntry, msg func(bookmarks.Bookmark) tea.Msg) tea.Msg {
+		b, ok := entry.Data.(bookmarks.Bookmark)
+		if !ok {
+			return nil
+		}
+		return msg(b)
+	}
+	panel.Actions = []ListAction{
+		{
+			Key:  "r",
+			Desc: "rename",
+			Msg: func(entry ListEntry) tea.Msg {
+				return selected(entry, func(b bookmarks.Bookmark) tea.Msg {
+					return openPromptMsg{Prompt{
+						Label:  fmt.Sprintf("Rename bookmark %s to: ", b.Name),
+						Buffer: b.Name,
+						Submit: func(value string) tea.Msg {
+							if value == "" || value == b.Name {
+								return nil
+							}
+							return renameDirBookmarkMsg{Path: b.Path, Name: value}
+						},
+					}}
+				})
+			},
+		},
+		{
+			Key:  "d",
+			Desc: "delete",
+			Msg: func(entry ListEntry) tea.Msg {
+				return selected(entry, func(b bookmarks.Bookmark) tea.Msg {
+					return deleteDirBookmarkMsg{Path: b.Path}
+				})
+			},
+		},
+		{
+			Key:  "K",
+			Desc: "move up",
+			Msg: func(entry ListEntry) tea.Msg {
+				return selected(entry, func(b bookmarks.Bookmark) tea.Msg {
+					return moveDirBookmarkMsg{Path: b.Path, Delta: -1}
+				})
+			},
+		},
+		{
+			Key:  "J",
+			Desc: "move down",
+			Msg: func(entry ListEntry) tea.Msg {
+				return selected(entry, func(b bookmarks.Bookmark) tea.Msg {
+					return moveDirBookmarkMsg{Path: b.Path, Delta: 1}
+				})
+			},
+		},
+	}
+	return panel
+}
+
+// showDirBookmarks replaces the open picker, keeping its size and putting the
+// cursor on the bookmark of path or where it was. Otherwise it opens the
+// picker if open is set, or shows the status in the browser.
+func (m *Model) showDirBookmarks(msg dirBookmarksMsg) {
+	panel := dirBookmarksPanel(msg.Bookmarks)
+	if msg.Status != "" {
+		panel.StatusMessage = msg.Status
+	}
+	if m.Mode == ListMode && m.List != nil && m.List.Title == dirBookmarksTitle {
+		panel.Width, panel.Height = m.List.Width, m.List.Height
+		cursor := m.List.Cursor
+		if i := slices.IndexFunc(msg.Bookmarks, func(b bookmarks.Bookmark) bool { return b.Path == msg.Select }); i >= 0 {
+			cursor = i
+		}
+		panel.SetCursor(cursor)
+		m.List = &panel
+		return
+	}
+	if msg.Open {
+		m.openList(panel)
+		return
+	}
+	m.setStatus(msg.Status)
+}
+
+// updateDirBookmarks handles the directory bookmark picker
+func (m Model) updateDirBookmarks(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case dirBookmarksMsg:
+		m.showDirBookmarks(msg)
+
+	case addDirBookmarkMsg:
+		return m, editDirBookmarksCmd(func() ([]bookmarks.Bookmark, error) {
+			return bookmarks.Add(msg.Name, msg.Path)
+		}, msg.Path, fmt.Sprintf("Bookmarked %s as %s (B lists bookmarks)", msg.Path, msg.Name))
+
+	case renameDirBookmarkMsg:
+		return m, editDirBookmarksCmd(func() ([]bookmarks.Bookmark, error) {
+			return bookmarks.Rename(msg.Path, msg.Name)
+		}, msg.Path, fmt.Sprintf("Renamed bookmark to %s", msg.Name))
+
+	case deleteDirBookmarkMsg:
+		return m, editDirBookmarksCmd(func() ([]bookmarks.Bookmark, error) {
+			return bookmarks.Delete(msg.Path)
+		}, "", fmt.Sprintf("Deleted bookmark of %s", msg.Path))
+
+	case moveDirBookmarkMsg:
+		return m, editDirBookmarksCmd(func() ([]bookmarks.Bookmark, error) {
+			return bookmarks.Move(msg.Path, msg.Delta)
+		}, msg.Path, "")
+	}
+	return m, nil
+}
@@ -0,0 +1,191 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/disk"
+	"github.com/HolyStarGazer/windows-tui-go/ops"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// drivesTitle is the title of the drive picker
+const drivesTitle = "💽 Drives"
+
+// sharesMsg delivers the shares of a server for the share picker
+type sharesMsg struct {
+	Server string
+	Shares []disk.Share
+	From   string // Directory the picker was opened from, whose share is selected
+	Err    error
+}
+
+// driveSpace is a drive with the space on it
+type driveSpace struct {
+	disk.Drive
+	Total     uint64
+	Available uint64
+	Err       error
+}
+
+// drivesMsg delivers the drives for the picker
+type drivesMsg struct {
+	Drives []driveSpace
+	From   string // Directory the picker was opened from, whose drive is selected
+}
+
+// drivesCmd lists the drives and the space on them in the background (D);
+// network drives may take a moment to answer. from is the browsed directory.
+func drivesCmd(from string) tea.Cmd {
+	return func() tea.Msg {
+		list := disk.Drives()
+		drives := make([]driveSpace, len(list))
+		ops.Parallel(len(list), func(i int) {
+			drives[i].Drive = list[i]
+			if list[i].Ready {
+				drives[i].Total, drives[i].Available, drives[i].Err = disk.Usage(list[i].Root)
+			}
+		})
+		return drivesMsg{Drives: drives, From: from}
+	}
+}
+
+// driveOf returns the index of the drive holding path: the one with the
+// longest root that path is below, or -1
+func driveOf(drives []driveSpace, path string) int {
+	found, length := -1, 0
+	for i, d := range drives {
+		root := strings.TrimSuffix(d.Root, string(filepath.Separator))
+		if !hasPathPrefix(path, root) || len(root) < length {
+			continue
+		}
+		found, length = i, len(root)
+	}
+	return found
+}
+
+// hasPathPrefix reports whether path is root or below it, ignoring case on
+// Windows
+func hasPathPrefix(path, root string) bool {
+	if len(path) < len(root) {
+		return false
+	}
+	head, rest := path[:len(root)], path[len(root):]
+	if runtime.GOOS == "windows" {
+		head, root = strings.ToLower(head), strings.ToLower(root)
+	}
+	return head == root && (rest == "" || rest[0] == filepath.Separator)
+}
+
+// driveLabel describes a drive on one line, e.g.
+// "C:\  Windows  NTFS fixed  ████░░  83%  80 GB free of 476 GB"
+func driveLabel(d driveSpace, rootWidth, labelWidth int) string {
+	label := fmt.Sprintf("%s  %-*s", theme.Directory.Render(fmt.Sprintf("%-*s", rootWidth, d.Root)), labelWidth, d.Label)
+	details := strings.TrimSpace(d.FileSystem + " " + d.Kind)
+	switch {
+	case !d.Ready && d.Kind == "network":
+		return label + "  " + theme.Dim.Render(details+", disconnected")
+	case !d.Ready:
+		return label + "  " + theme.Dim.Render(details+", no media")
+	case d.Err != nil:
+		return label + "  " + theme.Dim.Render(fmt.Sprintf("%s, Error: %v", details, d.Err))
+	case d.Total == 0:
+		return label + "  " + theme.Dim.Render(details)
+	}
+	used := d.Total - d.Available
+	return fmt.Sprintf("%s  %-14s  %s  %s free of %s", label, details,
+		usageBar(float64(used)*100/float64(d.Total)), FormatSize(int64(d.Available)), FormatSize(int64(d.Total)))
+}
+
+// showDrives opens the drive picker with the cursor on the browsed drive.
+// Enter browses the root of a drive.
+func (m *Model) showDrives(msg drivesMsg) {
+	rootWidth, labelWidth := 3, 0
+	for _, d := range msg.Drives {
+		rootWidth = max(rootWidth, visualLength(d.Root))
+		labelWidth = max(labelWidth, visualLength(d.Label))
+	}
+	entries := make([]ListEntry, 0, len(msg.Drives))
+	for _, d := range msg.Drives {
+		entries = append(entries, ListEntry{
+			Label: driveLabel(d, rootWidth, labelWidth),
+			Data:  d,
+			Msg:   openPathMsg{Path: d.Root},
+		})
+	}
+
+	panel := NewListPanel(drivesTitle, entries)
+	panel.Subtitle = fmt.Sprintf("%d drives", len(msg.Drives))
+	if len(entries) == 0 {
+		panel.StatusMessage = "No drives found"
+	}
+	if i := driveOf(msg.Drives, msg.From); i >= 0 {
+		panel.SetCursor(i)
+	}
+	m.openList(panel)
+}
+
+// serverOf returns the server of a UNC path, e.g. \\fileserver for
+// \\fileserver\share\dir, or "" for other paths
+func serverOf(path string) string {
+	if filepath.Separator != '\\' || !strings.HasPrefix(path, `\\`) || strings.HasPrefix(path, `\\?\`) || strings.HasPrefix(path, `\\.\`) {
+		return ""
+	}
+	name, _, _ := strings.Cut(path[2:], `\`)
+	if name == "" {
+		return ""
+	}
+	return `\\` + name
+}
+
+// isServerPath reports whether path names only a server, such as
+// \\fileserver, which holds shares rather than files
+func isServerPath(path string) bool {
+	server := serverOf(path)
+	return server != "" && strings.TrimRight(path, `\`) == server
+}
+
+// sharesCmd lists the shares of a server in the background, for the picker
+// shown on going up from the root of a share or browsing \\server
+func sharesCmd(server, from string) tea.Cmd {
+	return func() tea.Msg {
+		list, err := disk.Shares(server)
+		return sharesMsg{Server: server, Shares: list, From: from, Err: err}
+	}
+}
+
+// showShares opens the share picker with the cursor on the share browsed
+// before; Enter browses a share
+func (m *Model) showShares(msg sharesMsg) {
+	if msg.Err != nil {
+		m.setStatus(fmt.Sprintf("Error: %v", msg.Err))
+		return
+	}
+	width := 0
+	for _, share := range msg.Shares {
+		width = max(width, visualLength(share.Name))
+	}
+	entries := make([]ListEntry, 0, len(msg.Shares))
+	cursor := 0
+	for i, share := range msg.Shares {
+		root := msg.Server + `\` + share.Name + `\`
+		if hasPathPrefix(msg.From, strings.TrimSuffix(root, `\`)) {
+			cursor = i
+		}
+		entries = append(entries, ListEntry{
+			Label: fmt.Sprintf("%s  %s", theme.Directory.Render(fmt.Sprintf("%-*s", width, share.Name)), theme.Dim.Render(share.Remark)),
+			Data:  share,
+			Msg:   openPathMsg{Path: root},
+		})
+	}
+
+	panel := NewListPanel("🖧 Shares on "+msg.Server, entries)
+	panel.Subtitle = fmt.Sprintf("%d shares", len(msg.Shares))
+	if len(entries) == 0 {
+		panel.StatusMessage = "The server shares no folders (hidden shares such as C$ can be typed with :cd)"
+	}
+	panel.SetCursor(cursor)
+	m.openList(panel)
+}
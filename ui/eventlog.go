@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/eventlog"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// eventLogLimit caps the number of events loaded into the event log panel
+const eventLogLimit = 1000
+
+// eventChannels maps the names accepted by :eventlog to event log channels;
+// other names are used as channel paths as given
+var eventChannels = map[string]string{
+	"application": "Application",
+	"app":         "Application",
+	"system":      "System",
+	"sys":         "System",
+	"security":    "Security",
+	"setup":       "Setup",
+}
+
+// showEventMsg opens the details of an event in the viewer
+type showEventMsg struct {
+	Channel string
+	Event   eventlog.Event
+}
+
+// parseSince parses the time filter of :eventlog: a duration back from now
+// ("30m", "24h", "7d") or a local date and time ("2024-01-02", "2024-01-02 14:00")
+func parseSince(arg string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(arg, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(arg); err == nil && d > 0 {
+		return now.Add(-d), nil
+	}
+	layouts := []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, arg, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time '%s' (use 30m, 24h, 7d or YYYY-MM-DD [HH:MM])", arg)
+}
+
+// eventLogCmd reads an event log in the background for
+// :eventlog [application|system|<channel>] [since]
+func eventLogCmd(args []string) tea.Cmd {
+	channel := "Application"
+	now := time.Now()
+	if len(args) > 0 {
+		if _, err := parseSince(args[0], now); err != nil {
+			channel = args[0]
+			if name, ok := eventChannels[strings.ToLower(channel)]; ok {
+				channel = name
+			}
+			args = args[1:]
+		}
+	}
+
+	var since time.Time
+	if len(args) > 0 {
+		var err error
+		since, err = parseSince(strings.Join(args, " "), now)
+		if err != nil {
+			return func() tea.Msg { return errorMsg{err} }
+		}
+	}
+
+	return func() tea.Msg {
+		events, err := eventlog.Read(channel, since, eventLogLimit)
+		if err != nil {
+			return errorMsg{err}
+		}
+
+		panel := NewListPanel(fmt.Sprintf("🪟 Event Log: %s", channel), eventEntries(channel, events))
+		if since.IsZero() {
+			panel.Subtitle = fmt.Sprintf("Newest %d events", len(events))
+		} else {
+			panel.Subtitle = fmt.Sprintf("%d events since %s", len(events), since.Format("2006-01-02 15:04"))
+		}
+		return openListMsg{panel}
+	}
+}
+
+// eventLevel maps a Windows event level to the log level used for coloring
+func eventLevel(level int) logLevel {
+	switch level {
+	case eventlog.LevelCritical:
+		return levelFatal
+	case eventlog.LevelError:
+		return levelError
+	case eventlog.LevelWarning:
+		return levelWarn
+	case eventlog.LevelVerbose:
+		return levelDebug
+	default:
+		return levelInfo
+	}
+}
+
+// eventEntries converts events into list entries that open the event details
+func eventEntries(channel string, events []eventlog.Event) []ListEntry {
+	entries := make([]ListEntry, 0, len(events))
+	for _, ev := range events {
+		message, _, _ := strings.Cut(ev.Message, "\n")
+		level := theme.Levels[eventLevel(ev.Level)].Render(fmt.Sprintf("%-11s", eventlog.LevelName(ev.Level)))
+		label := fmt.Sprintf("%s %s %-24s %5d  %s",
+			ev.Time.Format("2006-01-02 15:04:05"),
+			level,
+			truncateAtVisualWidth(ev.Provider, 24),
+			ev.ID,
+			strings.TrimSpace(message))
+		entries = append(entries, ListEntry{
+			Label: label,
+			Data:  ev,
+			Msg:   showEventMsg{Channel: channel, Event: ev},
+		})
+	}
+	return entries
+}
+
+// eventDetails shows the summary and message of an event as an XML comment
+// above the event's XML, so the whole document highlights as XML
+func eventDetails(channel string, ev eventlog.Event) string {
+	var b strings.Builder
+	b.WriteString("<!--\n")
+	fmt.Fprintf(&b, "  Log:       %s\n", channel)
+	fmt.Fprintf(&b, "  Source:    %s\n", ev.Provider)
+	fmt.Fprintf(&b, "  Event ID:  %d\n", ev.ID)
+	fmt.Fprintf(&b, "  Level:     %s\n", eventlog.LevelName(ev.Level))
+	fmt.Fprintf(&b, "  Logged:    %s\n", ev.Time.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "  Computer:  %s\n", ev.Computer)
+	if ev.Message != "" {
+		b.WriteString("\n")
+		for _, line := range strings.Split(strings.ReplaceAll(ev.Message, "\r\n", "\n"), "\n") {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+	b.WriteString("-->\n")
+	b.WriteString(eventlog.IndentXML(ev.XML))
+	b.WriteString("\n")
+	return b.String()
+}
+
+// eventFileName names the viewer of an event
+func eventFileName(ev eventlog.Event) string {
+	name := strings.NewReplacer("/", "-", "\\", "-").Replace(ev.Provider)
+	return fmt.Sprintf("%s %d.xml", name, ev.ID)
+}
@@ -0,0 +1,189 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+)
+
+// resolvePath resolves a relative output path against the viewed file's directory
+func (fv *FileViewer) resolvePath(path string) string {
+	if !filepath.IsAbs(path) && fv.FilePath != "" {
+		return filepath.Join(filepath.Dir(fv.FilePath), path)
+	}
+	return path
+}
+
+// exportedLines returns the content lines currently shown, after filtering
+func (fv *FileViewer) exportedLines() []int {
+	lines := make([]int, 0, fv.rowCount())
+	for row := 0; row < fv.rowCount(); row++ {
+		lines = append(lines, fv.lineAt(row))
+	}
+	return lines
+}
+
+// exportView writes the given content lines with their highlighting to path as
+// standalone HTML or raw ANSI
+func (fv *FileViewer) exportView(format, path string, lines []int) {
+	var data []byte
+	var err error
+	switch format {
+	case "html":
+		data, err = fv.renderHTML(lines)
+	case "ansi":
+		data = fv.renderANSI(lines)
+	default:
+		fv.StatusMessage = "Usage: :export html|ansi <file>"
+		return
+	}
+	if err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+
+	path = fv.resolvePath(path)
+	if err := vfs.Default.WriteFile(path, data, 0o644); err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	fv.StatusMessage = fmt.Sprintf("Exported %d lines to %s", len(lines), path)
+}
+
+// saveAs writes a copy of the viewed file to path. Files are copied as they
+// are on disk; content that has no file, such as command output, is written as
+// shown.
+func (fv *FileViewer) saveAs(path string) {
+	path = fv.resolvePath(path)
+	data := []byte(strings.Join(fv.Content, "\n"))
+	if fv.FilePath != "" && len(fv.sources) == 0 {
+		src, err := vfs.Default.Stat(fv.FilePath)
+		if err != nil {
+			fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+			return
+		}
+		if dst, err := vfs.Default.Stat(path); err == nil && os.SameFile(src, dst) {
+			fv.StatusMessage = "Error: cannot save a file over itself"
+			return
+		}
+		if data, err = vfs.ReadFile(vfs.Default, fv.FilePath); err != nil {
+			fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+			return
+		}
+	}
+	if err := vfs.Default.WriteFile(path, data, 0o644); err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	fv.StatusMessage = fmt.Sprintf("Saved %s to %s", FormatSize(int64(len(data))), path)
+}
+
+// visibleLines returns the content lines on screen, counting the rows that
+// wrapped lines take
+func (fv *FileViewer) visibleLines() []int {
+	var lines []int
+	rows := fv.Height - 6
+	for row := fv.ScrollPos; row < fv.rowCount() && rows > 0; row++ {
+		i := fv.lineAt(row)
+		if i >= len(fv.Content) {
+			break
+		}
+		lines = append(lines, i)
+		if fv.WrapLines {
+			rows -= max(1, len(wrapLine(fv.Content[i], fv.wrapWidth())))
+		} else {
+			rows--
+		}
+	}
+	return lines
+}
+
+// writeVisible writes the lines as plain text to path: every line the filter
+// shows while one is active, otherwise the lines on screen
+func (fv *FileViewer) writeVisible(path string) {
+	if fv.filterActive() {
+		fv.writeLines(path, fv.exportedLines())
+	} else {
+		fv.writeLines(path, fv.visibleLines())
+	}
+}
+
+// writeLines writes content lines to path as plain text
+func (fv *FileViewer) writeLines(path string, lines []int) {
+	var b strings.Builder
+	for _, i := range lines {
+		if i < len(fv.Content) {
+			b.WriteString(fv.Content[i])
+		}
+		b.WriteString("\n")
+	}
+
+	path = fv.resolvePath(path)
+	if err := vfs.Default.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	fv.StatusMessage = fmt.Sprintf("Wrote %d lines to %s", len(lines), path)
+}
+
+// renderANSI renders lines exactly as the viewer colors them
+func (fv *FileViewer) renderANSI(lines []int) []byte {
+	var b strings.Builder
+	for _, i := range lines {
+		if i < len(fv.Content) {
+			b.WriteString(fv.renderLine(i))
+		}
+		b.WriteString("\x1b[0m\n")
+	}
+	return []byte(b.String())
+}
+
+// renderHTML renders lines with the chroma HTML formatter, marking lines that
+// match the current search
+func (fv *FileViewer) renderHTML(lines []int) ([]byte, error) {
+	plain := make([]string, len(lines))
+	for row, i := range lines {
+		plain[row] = fv.Content[i]
+	}
+	source := strings.Join(plain, "\n")
+
+	// Highlight search matches by their position in the exported lines
+	var ranges [][2]int
+	for _, match := range fv.SearchMatches {
+		if row, found := slices.BinarySearch(lines, match); found {
+			ranges = append(ranges, [2]int{row + 1, row + 1})
+		}
+	}
+
+	style := highlightStyle()
+
+	// Line numbers only make sense when no lines in between are hidden
+	numbered, base := false, 1
+	if len(lines) > 0 {
+		numbered = lines[len(lines)-1]-lines[0] == len(lines)-1
+		base = lines[0] + 1
+	}
+	formatter := chromahtml.New(
+		chromahtml.Standalone(true),
+		chromahtml.WithLineNumbers(numbered),
+		chromahtml.BaseLineNumber(base),
+		chromahtml.HighlightLines(ranges),
+		chromahtml.TabWidth(4),
+	)
+
+	iterator, err := fv.lexerFor(source).Tokenise(nil, source)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := formatter.Format(&buf, style, iterator); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
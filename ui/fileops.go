@@ -0,0 +1,395 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/ops"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// setLimits applies the workers and io_limit settings to background jobs
+func setLimits(workers int, ioLimit string) error {
+	var rate int64
+	if ioLimit != "" {
+		var err error
+		if rate, err = parseSize(ioLimit); err != nil {
+			return fmt.Errorf("io_limit: %v", err)
+		}
+	}
+	ops.SetLimits(workers, rate)
+	return nil
+}
+
+// transferMsg copies or moves paths into a directory, sent once confirmed
+type transferMsg struct {
+	Move   bool
+	Paths  []string
+	Dest   string
+	Resume bool // Continue a transfer that stopped early (:resume)
+}
+
+// transferDoneMsg reports how many paths a transfer got through
+type transferDoneMsg struct {
+	Move     bool
+	Done     int
+	Dest     string
+	Left     []string // Paths not transferred, starting with the one that failed
+	Verified bool     // Whether the copies were checked against the originals
+	Err      error
+}
+
+// deleteMsg deletes paths, sent once confirmed
+type deleteMsg struct {
+	Paths []string
+}
+
+// deleteDoneMsg reports how many paths were deleted
+type deleteDoneMsg struct {
+	Done int
+	Err  error
+}
+
+// renameMsg gives a file or directory a new name
+type renameMsg struct {
+	Path string
+	Name string
+}
+
+// mkdirMsg creates a directory in the browsed directory
+type mkdirMsg struct {
+	Dir  string
+	Name string
+}
+
+// updateFileOps handles the messages of file operations
+func (m Model) updateFileOps(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case transferMsg:
+		return m, m.startTransfer(msg)
+
+	case transferDoneMsg:
+		m.finishTransfer(msg)
+
+	case deleteMsg:
+		m.setStatus(fmt.Sprintf("Deleting %d items...", len(msg.Paths)))
+		return m, deleteCmd(msg.Paths)
+
+	case deleteDoneMsg:
+		// Stay at the same position rather than going back to the top
+		cursor := m.Cursor
+		m.reloadDirectory()
+		if len(m.Items) > 0 && m.Cursor == 0 {
+			m.Cursor = min(cursor, len(m.Items)-1)
+		}
+		if m.DualPane {
+			m.reloadOtherPane()
+		}
+		switch {
+		case msg.Err != nil && msg.Done > 0:
+			m.setStatus(fmt.Sprintf("Error: %v (deleted %d items first)", msg.Err, msg.Done))
+		case msg.Err != nil:
+			m.setStatus(fmt.Sprintf("Error: %v", msg.Err))
+		default:
+			m.setStatus(fmt.Sprintf("Deleted %d items", msg.Done))
+		}
+
+	case renameMsg:
+		path, err := ops.Rename(msg.Path, msg.Name)
+		if err != nil {
+			m.setStatus(fmt.Sprintf("Error: %v", err))
+			return m, nil
+		}
+		m.loadDirectory()
+		m.selectPath(path)
+		m.setStatus(fmt.Sprintf("Renamed %s to %s", filepath.Base(msg.Path), filepath.Base(path)))
+
+	case mkdirMsg:
+		path, err := ops.Mkdir(msg.Dir, msg.Name)
+		if err != nil {
+			m.setStatus(fmt.Sprintf("Error: %v", err))
+			return m, nil
+		}
+		m.reloadDirectory()
+		// Select the new directory, or the first level of a nested one
+		if rel, err := filepath.Rel(m.CurrentPath, path); err == nil {
+			first, _, _ := strings.Cut(rel, string(filepath.Separator))
+			m.selectPath(filepath.Join(m.CurrentPath, first))
+		}
+		m.setStatus(fmt.Sprintf("Created %s", path))
+	}
+
+	return m, nil
+}
+
+// operandPaths returns the marked items, or the one under the cursor, or the
+// items of the collapsed group under it
+func (m *Model) operandPaths() []string {
+	var paths []string
+	for _, item := range m.Items {
+		if m.Marked[item.Path] {
+			paths = append(paths, item.Path)
+		}
+	}
+	if g, ok := m.groupAt(m.Cursor); ok && len(paths) == 0 && m.groups.Collapsed[g.Key] {
+		return m.groupPaths(g)
+	}
+	if len(paths) == 0 && m.Cursor < len(m.Items) && m.Items[m.Cursor].Name != ".." {
+		paths = append(paths, m.Items[m.Cursor].Path)
+	}
+	return paths
+}
+
+// describePaths names a single path or counts several
+func describePaths(paths []string) string {
+	if len(paths) == 1 {
+		return filepath.Base(paths[0])
+	}
+	return fmt.Sprintf("%d items", len(paths))
+}
+
+// transferKey copies or moves the marked items, or the one under the cursor.
+// With two panes it asks before using the other pane's directory; otherwise it
+// asks for the destination. Copies can be made as hard links with Ctrl+L.
+func (m *Model) transferKey(move bool) tea.Cmd {
+	verb := "Copy"
+	if move {
+		verb = "Move"
+	}
+	paths := m.operandPaths()
+	if len(paths) == 0 {
+		m.StatusMessage = fmt.Sprintf("Nothing to %s", strings.ToLower(verb))
+		return nil
+	}
+
+	what := describePaths(paths)
+	if m.DualPane {
+		msg := transferMsg{Move: move, Paths: paths, Dest: m.otherPane.CurrentPath}
+		return func() tea.Msg {
+			if move {
+				return confirmPrompt(fmt.Sprintf("%s %s to %s?", verb, what, msg.Dest), msg)
+			}
+			prompt := confirmPrompt("", msg).(openPromptMsg)
+			prompt.Prompt.Label = fmt.Sprintf("%s %s to %s? (y/N, Ctrl+L: hard link): ", verb, what, msg.Dest)
+			prompt.Prompt.Keys = map[string]func(string) tea.Msg{
+				"ctrl+l": func(string) tea.Msg { return hardLinkMsg{Paths: paths, Dest: msg.Dest} },
+			}
+			return prompt
+		}
+	}
+
+	base := m.CurrentPath
+	dest := func(value string) (string, bool) {
+		if strings.TrimSpace(value) == "" {
+			return "", false
+		}
+		return cleanPath(value, base), true
+	}
+	prompt := Prompt{
+		Label:  fmt.Sprintf("%s %s to: ", verb, what),
+		Buffer: base + string(filepath.Separator),
+		Submit: func(value string) tea.Msg {
+			if dest, ok := dest(value); ok {
+				return transferMsg{Move: move, Paths: paths, Dest: dest}
+			}
+			return nil
+		},
+	}
+	if !move {
+		prompt.Label = fmt.Sprintf("%s %s (Ctrl+L: hard link) to: ", verb, what)
+		prompt.Keys = map[string]func(string) tea.Msg{
+			"ctrl+l": func(value string) tea.Msg {
+				if dest, ok := dest(value); ok {
+					return hardLinkMsg{Paths: paths, Dest: dest}
+				}
+				return nil
+			},
+		}
+	}
+	return func() tea.Msg { return openPromptMsg{prompt} }
+}
+
+// transferLabel describes a transfer, e.g. "Copying 3 items to D:\Backup"
+func transferLabel(msg transferMsg) string {
+	verb := "Copying"
+	switch {
+	case msg.Resume && msg.Move:
+		verb = "Resuming the move of"
+	case msg.Resume:
+		verb = "Resuming the copy of"
+	case msg.Move:
+		verb = "Moving"
+	}
+	label := fmt.Sprintf("%s %d items to %s", verb, len(msg.Paths), msg.Dest)
+	if ops.Verifying() {
+		label += ", verifying"
+	}
+	return label
+}
+
+// toggleVerify turns checking copies against their originals on or off
+// (:verify)
+func (m *Model) toggleVerify() {
+	ops.SetVerify(!ops.Verifying())
+	if ops.Verifying() {
+		m.StatusMessage = "Copies are verified: each copied file is hashed with SHA-256 and compared with the original"
+	} else {
+		m.StatusMessage = "Copies are not verified"
+	}
+}
+
+// startTransfer copies or moves the files in the background, as a job whose
+// progress is measured against the size of the files. Resuming skips what
+// was already transferred and continues partially copied files.
+func (m *Model) startTransfer(msg transferMsg) tea.Cmd {
+	m.setStatus("")
+	if msg.Resume {
+		m.interrupted = nil
+	}
+	cmd := m.startJob(transferLabel(msg), func(progress *ops.Progress) tea.Msg {
+		done := transferDoneMsg{Move: msg.Move, Dest: msg.Dest, Verified: ops.Verifying()}
+		ops.Work(func() {
+			progress.SetTotal(pathsSize(msg.Paths))
+			for i, path := range msg.Paths {
+				var err error
+				switch {
+				case msg.Move && msg.Resume:
+					err = ops.ResumeMove(path, msg.Dest, progress)
+				case msg.Move:
+					err = ops.Move(path, msg.Dest, progress)
+				case msg.Resume:
+					err = ops.ResumeCopy(path, msg.Dest, progress)
+				default:
+					err = ops.Copy(path, msg.Dest, progress)
+				}
+				if err != nil {
+					done.Err = err
+					done.Left = msg.Paths[i:]
+					return
+				}
+				done.Done++
+			}
+		})
+		return done
+	})
+	m.refreshJobsPanel()
+	return cmd
+}
+
+// pathsSize adds up the sizes of files and the trees below directories
+func pathsSize(paths []string) int64 {
+	var total int64
+	for _, path := range paths {
+		info, err := vfs.Default.Lstat(path)
+		switch {
+		case err != nil:
+		case info.IsDir():
+			size, _ := treeSize(path, nil)
+			total += size
+		case info.Mode().IsRegular():
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// finishTransfer shows the result of a copy or move in both panes
+func (m *Model) finishTransfer(msg transferDoneMsg) {
+	m.reloadDirectory()
+	if m.DualPane {
+		m.reloadOtherPane()
+	}
+
+	verb := "Copied"
+	if msg.Move {
+		verb = "Moved"
+	}
+	if msg.Err != nil {
+		// Keep what is left for :resume
+		m.interrupted = &transferMsg{Move: msg.Move, Paths: msg.Left, Dest: msg.Dest, Resume: true}
+		m.refreshJobsPanel()
+	}
+	switch {
+	case errors.Is(msg.Err, ops.ErrCanceled):
+		m.setStatus(fmt.Sprintf("Canceled after %s %d items (:resume continues)", strings.ToLower(verb), msg.Done))
+	case msg.Err != nil && msg.Done > 0:
+		m.setStatus(fmt.Sprintf("Error: %v (%s %d items first, :resume continues where it stopped)", msg.Err, strings.ToLower(verb), msg.Done))
+	case msg.Err != nil:
+		m.setStatus(fmt.Sprintf("Error: %v (:resume continues where it stopped)", msg.Err))
+	case msg.Verified:
+		m.setStatus(fmt.Sprintf("%s %d items to %s, verified", verb, msg.Done, msg.Dest))
+	default:
+		m.setStatus(fmt.Sprintf("%s %d items to %s", verb, msg.Done, msg.Dest))
+	}
+}
+
+// deleteKey asks before deleting the marked items, or the one under the cursor
+func (m *Model) deleteKey() tea.Cmd {
+	paths := m.operandPaths()
+	if len(paths) == 0 {
+		m.StatusMessage = "Nothing to delete"
+		return nil
+	}
+	label := fmt.Sprintf("Permanently delete %s?", describePaths(paths))
+	return func() tea.Msg { return confirmPrompt(label, deleteMsg{Paths: paths}) }
+}
+
+// deleteCmd deletes paths in the background, stopping at the first error
+func deleteCmd(paths []string) tea.Cmd {
+	return func() tea.Msg {
+		var done deleteDoneMsg
+		for _, path := range paths {
+			if err := ops.Delete(path); err != nil {
+				done.Err = err
+				return done
+			}
+			done.Done++
+		}
+		return done
+	}
+}
+
+// renameKey asks for a new name for the item under the cursor
+func (m *Model) renameKey() tea.Cmd {
+	if m.Cursor >= len(m.Items) || m.Items[m.Cursor].Name == ".." {
+		m.StatusMessage = "Nothing to rename"
+		return nil
+	}
+	if m.collapsedGroupStatus() {
+		return nil
+	}
+	// Flattened listings name items by their relative path
+	item := m.Items[m.Cursor]
+	name := filepath.Base(item.Path)
+	return func() tea.Msg {
+		return openPromptMsg{Prompt{
+			Label:  fmt.Sprintf("Rename %s to: ", name),
+			Buffer: name,
+			Submit: func(value string) tea.Msg {
+				if value == "" || value == name {
+					return nil
+				}
+				return renameMsg{Path: item.Path, Name: value}
+			},
+		}}
+	}
+}
+
+// mkdirKey asks for the name of a directory to create in the browsed directory
+func (m *Model) mkdirKey() tea.Cmd {
+	dir := m.CurrentPath
+	return func() tea.Msg {
+		return openPromptMsg{Prompt{
+			Label: "New directory: ",
+			Submit: func(value string) tea.Msg {
+				if strings.TrimSpace(value) == "" {
+					return nil
+				}
+				return mkdirMsg{Dir: dir, Name: value}
+			},
+		}}
+	}
+}
@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/config"
+	"github.com/HolyStarGazer/windows-tui-go/types"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// fileRule is a compiled config.FileRule; negative sizes and a zero age are unset
+type fileRule struct {
+	Within  time.Duration
+	MinSize int64
+	MaxSize int64
+	Style   lipgloss.Style
+}
+
+// sizeUnits are the suffixes accepted by parseSize, matching FormatSize
+var sizeUnits = []struct {
+	Suffix string
+	Size   int64
+}{
+	{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1},
+}
+
+// parseSize parses sizes such as "0", "512KB" or "1.5 GB"
+func parseSize(text string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(text))
+	unit := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(s, u.Suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, u.Suffix))
+			unit = u.Size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", text)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+// compileFileRule parses the conditions and style of one rule
+func compileFileRule(rule config.FileRule) (fileRule, error) {
+	compiled := fileRule{MinSize: -1, MaxSize: -1}
+	var err error
+	if rule.ModifiedWithin != "" {
+		if compiled.Within, err = time.ParseDuration(rule.ModifiedWithin); err != nil {
+			return compiled, err
+		}
+	}
+	if rule.MinSize != "" {
+		if compiled.MinSize, err = parseSize(rule.MinSize); err != nil {
+			return compiled, err
+		}
+	}
+	if rule.MaxSize != "" {
+		if compiled.MaxSize, err = parseSize(rule.MaxSize); err != nil {
+			return compiled, err
+		}
+	}
+	if compiled.Within == 0 && compiled.MinSize < 0 && compiled.MaxSize < 0 {
+		return compiled, fmt.Errorf("no condition set")
+	}
+
+	compiled.Style = theme.File.Bold(rule.Bold).Faint(rule.Faint)
+	if rule.Color != "" {
+		compiled.Style = compiled.Style.Foreground(lipgloss.Color(rule.Color))
+	}
+	return compiled, nil
+}
+
+// compileFileRules compiles configured file rules, skipping invalid ones
+func compileFileRules(rules []config.FileRule) ([]fileRule, error) {
+	var compiled []fileRule
+	var firstErr error
+	for i, rule := range rules {
+		c, err := compileFileRule(rule)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("file rule %d: %w", i+1, err)
+			}
+			continue
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, firstErr
+}
+
+// matches reports whether a file meets every condition of the rule
+func (r fileRule) matches(item types.FileItem, now time.Time) bool {
+	if r.Within > 0 && now.Sub(item.ModTime) > r.Within {
+		return false
+	}
+	if r.MinSize >= 0 && item.Size < r.MinSize {
+		return false
+	}
+	if r.MaxSize >= 0 && item.Size > r.MaxSize {
+		return false
+	}
+	return true
+}
+
+// fileStyleFor returns the style of the first file rule matching item
+func (m *Model) fileStyleFor(item types.FileItem) lipgloss.Style {
+	now := time.Now()
+	for _, rule := range m.fileRules {
+		if rule.matches(item, now) {
+			return rule.Style
+		}
+	}
+	return theme.File
+}
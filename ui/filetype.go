@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// interpreterLanguages maps shebang interpreters that chroma does not know by name
+var interpreterLanguages = map[string]string{
+	"node": "javascript",
+	"deno": "typescript",
+	"dash": "bash",
+	"ksh":  "bash",
+	"php":  "php",
+}
+
+// shebangLexer picks a lexer from a "#!" line such as "#!/usr/bin/env python3"
+func shebangLexer(content string) chroma.Lexer {
+	line, _, _ := strings.Cut(content, "\n")
+	if !strings.HasPrefix(line, "#!") {
+		return nil
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) == 0 {
+		return nil
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		// Skip env options such as -S
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				interpreter = filepath.Base(field)
+				break
+			}
+		}
+	}
+
+	// python3.12 -> python
+	interpreter = strings.TrimRight(interpreter, "0123456789.")
+	if lang, ok := interpreterLanguages[interpreter]; ok {
+		interpreter = lang
+	}
+	if interpreter == "" {
+		return nil
+	}
+	return lexers.Get(interpreter)
+}
+
+// filetypeFor returns the configured language override for a file name, if any.
+// Exact file names take precedence over extensions.
+func (m *Model) filetypeFor(fileName string) string {
+	return typeRule(m.Settings.Filetypes, fileName)
+}
+
+// typeRule returns the value a setting keyed by file names and extensions,
+// such as filetypes, gives fileName. Keys match regardless of case, and exact
+// file names take precedence over extensions.
+func typeRule(rules map[string]string, fileName string) string {
+	for pattern, value := range rules {
+		if strings.EqualFold(pattern, fileName) {
+			return value
+		}
+	}
+	ext := filepath.Ext(fileName)
+	if ext == "" {
+		return ""
+	}
+	for pattern, value := range rules {
+		if strings.EqualFold(pattern, ext) {
+			return value
+		}
+	}
+	return ""
+}
+
+// setFiletype handles :set filetype=<lang>; "auto" or an empty value returns to
+// the configured or detected language
+func (fv *FileViewer) setFiletype(lang string) {
+	if lang == "auto" || lang == "" {
+		lang = fv.configLanguage
+	}
+	if err := fv.SetLanguage(lang); err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	if lang == fv.configLanguage {
+		fv.StatusMessage = fv.detectedFiletype()
+	} else {
+		fv.StatusMessage = fmt.Sprintf("Filetype set to %s", lang)
+	}
+}
+
+// detectedFiletype describes the detected language, which is not known yet
+// while it is being guessed from the content
+func (fv *FileViewer) detectedFiletype() string {
+	if fv.fileType == "" && fv.highlighter != nil {
+		return "Filetype: detecting from the content"
+	}
+	return fmt.Sprintf("Filetype: %s (detected)", fv.fileType)
+}
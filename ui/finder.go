@@ -0,0 +1,345 @@
+package ui
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/search"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxFinderFiles caps the number of files the finder indexes
+const maxFinderFiles = 200000
+
+// finderBatch and finderBatchWait bound how many indexed files are delivered
+// at once and how long the indexer collects them, so results are ranked in a
+// few large batches rather than many small ones
+const (
+	finderBatch     = 8192
+	finderBatchWait = 50 * time.Millisecond
+)
+
+// finderFilesMsg delivers a batch of files indexed for a finder
+type finderFilesMsg struct {
+	Finder *Finder
+	Paths  []string
+	Done   bool
+}
+
+// finderResult is an indexed file matching the query
+type finderResult struct {
+	Path      string // Relative to the finder's root
+	Score     int
+	Positions []int // Indexes of the matched runes in Path
+}
+
+// Finder narrows the files below a directory down to those fuzzily matching
+// the typed query. It is shown over the browser (Ctrl+P).
+type Finder struct {
+	Root   string
+	Query  string
+	Cursor int
+	Width  int
+	Height int
+
+	files     []string       // Indexed paths, relative to Root
+	results   []finderResult // Files matching Query, best first
+	indexing  bool           // Whether files are still being indexed
+	truncated bool           // Whether indexing stopped at maxFinderFiles
+	stream    <-chan string  // Paths found by the indexer
+	stop      chan struct{}  // Closed to stop the indexer
+}
+
+// newFinder starts indexing the files below root
+func newFinder(root string, width, height int) *Finder {
+	stop := make(chan struct{})
+	return &Finder{
+		Root:     root,
+		Width:    width,
+		Height:   height,
+		indexing: true,
+		stream:   indexFiles(root, stop),
+		stop:     stop,
+	}
+}
+
+// indexFiles walks the files below root in the background, sending their
+// paths relative to root until stop is closed
+func indexFiles(root string, stop <-chan struct{}) <-chan string {
+	paths := make(chan string, 256)
+	go func() {
+		defer close(paths)
+		vfs.WalkDir(vfs.Default, root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// Skip unreadable entries instead of aborting the walk
+				if d != nil && d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				if path != root && skippedDirs[d.Name()] {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return nil
+			}
+			select {
+			case paths <- rel:
+				return nil
+			case <-stop:
+				return fs.SkipAll
+			}
+		})
+	}()
+	return paths
+}
+
+// waitForFiles reads the next batch of indexed files
+func (f *Finder) waitForFiles() tea.Cmd {
+	stream := f.stream
+	return func() tea.Msg {
+		path, ok := <-stream
+		if !ok {
+			return finderFilesMsg{Finder: f, Done: true}
+		}
+		paths := []string{path}
+		timeout := time.After(finderBatchWait)
+		for len(paths) < finderBatch {
+			select {
+			case path, ok := <-stream:
+				if !ok {
+					return finderFilesMsg{Finder: f, Paths: paths, Done: true}
+				}
+				paths = append(paths, path)
+			case <-timeout:
+				return finderFilesMsg{Finder: f, Paths: paths}
+			}
+		}
+		return finderFilesMsg{Finder: f, Paths: paths}
+	}
+}
+
+// close stops indexing, if it is still running
+func (f *Finder) close() {
+	if f.stop != nil {
+		close(f.stop)
+		f.stop = nil
+	}
+}
+
+// addFiles adds a batch of indexed files and matches them against the query
+func (f *Finder) addFiles(msg finderFilesMsg) tea.Cmd {
+	if room := maxFinderFiles - len(f.files); len(msg.Paths) > room {
+		msg.Paths = msg.Paths[:room]
+		f.truncated = true
+	}
+	f.files = append(f.files, msg.Paths...)
+	var added []finderResult
+	for _, path := range msg.Paths {
+		if result, ok := f.match(path); ok {
+			added = append(added, result)
+		}
+	}
+	sortResults(added)
+	f.results = mergeResults(f.results, added)
+
+	if msg.Done || f.truncated {
+		f.indexing = false
+		if f.truncated {
+			f.close()
+		}
+		return nil
+	}
+	return f.waitForFiles()
+}
+
+// match scores a path against the query
+func (f *Finder) match(path string) (finderResult, bool) {
+	score, positions, ok := search.Fuzzy(f.Query, path)
+	return finderResult{Path: path, Score: score, Positions: positions}, ok
+}
+
+// setQuery changes the query and matches the files again. A query extending
+// the previous one only needs to look at the files that matched it.
+func (f *Finder) setQuery(query string) {
+	candidates := f.files
+	if f.Query != "" && strings.HasPrefix(query, f.Query) {
+		candidates = make([]string, len(f.results))
+		for i, result := range f.results {
+			candidates[i] = result.Path
+		}
+	}
+	f.Query = query
+
+	f.results = f.results[:0]
+	for _, path := range candidates {
+		if result, ok := f.match(path); ok {
+			f.results = append(f.results, result)
+		}
+	}
+	sortResults(f.results)
+	f.Cursor = 0
+}
+
+// betterResult reports whether a ranks above b: higher scores first, then
+// shorter paths
+func betterResult(a, b finderResult) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if len(a.Path) != len(b.Path) {
+		return len(a.Path) < len(b.Path)
+	}
+	return a.Path < b.Path
+}
+
+// sortResults orders results best first
+func sortResults(results []finderResult) {
+	sort.Slice(results, func(a, b int) bool { return betterResult(results[a], results[b]) })
+}
+
+// mergeResults merges two sorted result lists, so batches of indexed files are
+// ranked without sorting everything again
+func mergeResults(a, b []finderResult) []finderResult {
+	if len(b) == 0 {
+		return a
+	}
+	merged := make([]finderResult, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if betterResult(b[0], a[0]) {
+			merged, b = append(merged, b[0]), b[1:]
+		} else {
+			merged, a = append(merged, a[0]), a[1:]
+		}
+	}
+	merged = append(merged, a...)
+	return append(merged, b...)
+}
+
+// Update handles keyboard input for the finder and reports whether it is
+// finished
+func (f *Finder) Update(msg tea.KeyMsg) (bool, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c", "ctrl+p":
+		return true, nil
+
+	case "up", "ctrl+k":
+		if f.Cursor > 0 {
+			f.Cursor--
+		}
+
+	case "down", "ctrl+j":
+		if f.Cursor < len(f.results)-1 {
+			f.Cursor++
+		}
+
+	case "enter", "tab":
+		if f.Cursor >= len(f.results) {
+			return false, nil
+		}
+		path := filepath.Join(f.Root, f.results[f.Cursor].Path)
+		if msg.String() == "tab" {
+			return true, func() tea.Msg { return revealPathMsg{Path: path} }
+		}
+		return true, func() tea.Msg { return openPathMsg{Path: path} }
+
+	case "backspace":
+		f.setQuery(trimLastRune(f.Query))
+
+	default:
+		if text := inputText(msg); text != "" {
+			f.setQuery(f.Query + text)
+		}
+	}
+	return false, nil
+}
+
+// View renders the query, the best results and the indexing progress
+func (f Finder) View() string {
+	var b strings.Builder
+	b.WriteString(theme.Title.Render("🔭 Find file") + "\n")
+	b.WriteString(fmt.Sprintf("Scope: %s\n\n", f.Root))
+	b.WriteString("> " + f.Query + "█\n\n")
+
+	maxVisible := max(f.Height-9, 1)
+	start := 0
+	if f.Cursor >= maxVisible {
+		start = f.Cursor - maxVisible + 1
+	}
+	end := min(start+maxVisible, len(f.results))
+	for i := start; i < end; i++ {
+		result := f.results[i]
+		if i == f.Cursor {
+			label := result.Path
+			if f.Width > 4 && visualLength(label) > f.Width-4 {
+				label = truncateAtVisualWidth(label, f.Width-7) + "..."
+			}
+			b.WriteString(theme.Selected.Render("> "+label) + "\n")
+			continue
+		}
+		label := highlightPositions(result.Path, result.Positions)
+		if f.Width > 4 && visualLength(label) > f.Width-4 {
+			label = truncateAtVisualWidth(label, f.Width-7) + "..."
+		}
+		b.WriteString("  " + label + "\n")
+	}
+
+	status := fmt.Sprintf("%d/%d files", len(f.results), len(f.files))
+	switch {
+	case f.indexing:
+		status += " (indexing...)"
+	case f.truncated:
+		status += fmt.Sprintf(" (indexing stopped at %d files)", maxFinderFiles)
+	}
+	b.WriteString(theme.Status.Render(status) + "\n")
+	b.WriteString(theme.Help.Render("↑/↓: select | Enter: view | Tab: show in browser | Esc: close"))
+	return b.String()
+}
+
+// highlightPositions renders text with the runes at positions emphasized
+func highlightPositions(text string, positions []int) string {
+	var b strings.Builder
+	var plain []rune
+	next := 0
+	for i, r := range []rune(text) {
+		if next < len(positions) && positions[next] == i {
+			if len(plain) > 0 {
+				b.WriteString(theme.File.Render(string(plain)))
+				plain = plain[:0]
+			}
+			b.WriteString(theme.Match.Render(string(r)))
+			next++
+			continue
+		}
+		plain = append(plain, r)
+	}
+	if len(plain) > 0 {
+		b.WriteString(theme.File.Render(string(plain)))
+	}
+	return b.String()
+}
+
+// openFinder shows the fuzzy finder over the current directory
+func (m *Model) openFinder() tea.Cmd {
+	m.Finder = newFinder(m.CurrentPath, m.Width, m.Height)
+	return m.Finder.waitForFiles()
+}
+
+// updateFinder handles keys while the finder is shown
+func (m *Model) updateFinder(msg tea.KeyMsg) tea.Cmd {
+	done, cmd := m.Finder.Update(msg)
+	if done {
+		m.Finder.close()
+		m.Finder = nil
+	}
+	return cmd
+}
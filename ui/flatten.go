@@ -0,0 +1,143 @@
+package ui
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/types"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+)
+
+// maxFlatItems is the most files a flattened listing holds
+const maxFlatItems = 100_000
+
+// flatView lists every file below a directory in one list, named by their
+// paths relative to it
+type flatView struct {
+	Root      string // Directory flattened, empty when off; browsing elsewhere ends the view
+	Depth     int    // Levels of directories listed, 0 for all
+	Truncated bool   // Whether files past maxFlatItems were left out
+}
+
+// flattenCommand lists the files below the current directory in one list
+// (:flatten [depth]); :flatten off, or :flatten again, goes back to the
+// directory's own listing
+func (m *Model) flattenCommand(args []string) {
+	if len(args) == 0 && m.flat.Root != "" || len(args) > 0 && args[0] == "off" {
+		m.setFlatten(flatView{})
+		return
+	}
+	depth := 0
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			m.StatusMessage = fmt.Sprintf("Error: invalid depth '%s' (use a number of levels from 1)", args[0])
+			return
+		}
+		depth = n
+	}
+	m.setFlatten(flatView{Root: m.CurrentPath, Depth: depth})
+}
+
+// setFlatten turns the flattened listing on or off, keeping the cursor on the
+// same item where it is still listed
+func (m *Model) setFlatten(flat flatView) {
+	m.flat = flat
+	m.reloadDirectory()
+	switch {
+	case m.Err != nil:
+	case flat.Root == "":
+		m.StatusMessage = "Listing the directory"
+	case m.flat.Truncated:
+		m.StatusMessage = fmt.Sprintf("Showing the first %d files below %s", maxFlatItems, filepath.Base(flat.Root))
+	default:
+		m.StatusMessage = fmt.Sprintf("Showing %d files below %s (Esc lists the directory)", m.fileCount(), filepath.Base(flat.Root))
+	}
+}
+
+// fileCount counts the listed items other than ".."
+func (m *Model) fileCount() int {
+	if len(m.Items) > 0 && m.Items[0].Name == ".." {
+		return len(m.Items) - 1
+	}
+	return len(m.Items)
+}
+
+// loadFlat lists the files below the current directory, down to the depth of
+// the view. Directories that searches skip, such as .git, are left out, and
+// hidden directories are too unless hidden files are shown.
+func (m *Model) loadFlat(filter types.Filter) {
+	root := m.CurrentPath
+	m.flat.Truncated = false
+	var files []types.FileItem
+	err := vfs.WalkDir(vfs.Default, root, func(path string, d fs.DirEntry, err error) error {
+		if path == root {
+			return err
+		}
+		if err != nil {
+			// Unreadable directories are left out
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		item := types.NewFileItem(path, info)
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			depth := strings.Count(rel, string(filepath.Separator)) + 1
+			if skippedDirs[d.Name()] || !m.Options.ShowHidden && item.IsHidden() || m.flat.Depth > 0 && depth >= m.flat.Depth {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !filter.Keep(item) {
+			return nil
+		}
+		if len(files) == maxFlatItems {
+			m.flat.Truncated = true
+			return fs.SkipAll
+		}
+		item.Name = rel
+		files = append(files, item)
+		return nil
+	})
+	if err != nil {
+		m.Err = err
+		return
+	}
+	m.Options.sortItems(files)
+	m.Items = append(m.Items, files...)
+}
+
+// flatBadge shows the flattened listing in the header, or nothing
+func (m *Model) flatBadge() string {
+	if m.flat.Root == "" {
+		return ""
+	}
+	depth := "all levels"
+	if m.flat.Depth == 1 {
+		depth = "1 level"
+	} else if m.flat.Depth > 1 {
+		depth = fmt.Sprintf("%d levels", m.flat.Depth)
+	}
+	return theme.Banner.Render(fmt.Sprintf(" flattened, %s ", depth))
+}
+
+// listingBadges shows how the listing differs from the directory's entries,
+// such as a flattened view or a quick filter
+func (m *Model) listingBadges() string {
+	var badges []string
+	for _, badge := range []string{m.flatBadge(), m.Options.quickFilterBadge()} {
+		if badge != "" {
+			badges = append(badges, badge)
+		}
+	}
+	return strings.Join(badges, " ")
+}
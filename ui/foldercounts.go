@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/ops"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// folderCount is the cached number of entries in a directory. A directory's
+// modification time changes when entries are added or removed, so a count is
+// valid while the time matches.
+type folderCount struct {
+	ModTime time.Time
+	Items   int // Number of entries, or -1 if the directory could not be read
+	Done    bool
+}
+
+// folderCountsMsg delivers counted directories, keyed by path
+type folderCountsMsg struct {
+	Counts map[string]folderCount
+}
+
+// countFoldersCmd counts the entries of dirs in the background
+func countFoldersCmd(dirs map[string]time.Time) tea.Cmd {
+	return func() tea.Msg {
+		paths := make([]string, 0, len(dirs))
+		for path := range dirs {
+			paths = append(paths, path)
+		}
+		found := make([]folderCount, len(paths))
+		ops.Parallel(len(paths), func(i int) {
+			found[i] = folderCount{ModTime: dirs[paths[i]], Items: -1, Done: true}
+			if entries, err := vfs.Default.ReadDir(paths[i]); err == nil {
+				found[i].Items = len(entries)
+			}
+		})
+
+		counts := make(map[string]folderCount, len(paths))
+		for i, path := range paths {
+			counts[path] = found[i]
+		}
+		return folderCountsMsg{Counts: counts}
+	}
+}
+
+// startCounting requests counts for the listed directories that have none,
+// when the counts option is on
+func (m *Model) startCounting() tea.Cmd {
+	if !m.Options.ShowCounts {
+		return nil
+	}
+
+	dirs := make(map[string]time.Time)
+	for _, item := range m.Items {
+		if !item.IsDir || item.Name == ".." {
+			continue
+		}
+		if count, ok := m.folderCounts[item.Path]; ok && count.ModTime.Equal(item.ModTime) {
+			continue
+		}
+		dirs[item.Path] = item.ModTime
+	}
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	// Record the requests so they are not repeated while counting
+	if m.folderCounts == nil {
+		m.folderCounts = make(map[string]folderCount)
+	}
+	for path, modTime := range dirs {
+		m.folderCounts[path] = folderCount{ModTime: modTime}
+	}
+	return countFoldersCmd(dirs)
+}
+
+// updateFolderCounts caches counted directories
+func (m *Model) updateFolderCounts(msg folderCountsMsg) {
+	for path, count := range msg.Counts {
+		m.folderCounts[path] = count
+	}
+}
+
+// folderCountLabel describes the number of entries in a listed directory
+func (m *Model) folderCountLabel(path string) string {
+	count, ok := m.folderCounts[path]
+	switch {
+	case !ok || !count.Done:
+		return ""
+	case count.Items < 0:
+		return " (?)"
+	case count.Items == 0:
+		return " (empty)"
+	case count.Items == 1:
+		return " (1 item)"
+	}
+	return fmt.Sprintf(" (%d items)", count.Items)
+}
@@ -0,0 +1,321 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/config"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// followPollInterval is how often a followed file is checked for new data
+const followPollInterval = 500 * time.Millisecond
+
+// rotationGrace is how long a followed file may be missing before it is taken
+// to be deleted. Rotated logs are renamed first and created again after.
+const rotationGrace = 5 * time.Second
+
+// lineRule is a compiled highlight rule
+type lineRule struct {
+	Pattern *regexp.Regexp
+	Style   lipgloss.Style
+}
+
+// compileHighlightRules compiles configured highlight rules, skipping invalid ones
+func compileHighlightRules(rules []config.HighlightRule) ([]lineRule, error) {
+	var compiled []lineRule
+	var firstErr error
+	for _, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("highlight rule %q: %w", rule.Pattern, err)
+			}
+			continue
+		}
+		compiled = append(compiled, lineRule{
+			Pattern: re,
+			Style:   lipgloss.NewStyle().Foreground(lipgloss.Color(rule.Color)),
+		})
+	}
+	return compiled, firstErr
+}
+
+// viewerLinesMsg delivers lines read from a streamed source such as stdin
+type viewerLinesMsg struct {
+	Viewer *FileViewer
+	Lines  []string
+	Done   bool
+}
+
+// followTickMsg triggers a check of a followed file for new data
+type followTickMsg struct {
+	Viewer *FileViewer
+}
+
+// streamLines reads r line by line in the background
+func streamLines(r io.Reader) <-chan string {
+	lines := make(chan string, 256)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return lines
+}
+
+// waitForViewerLines reads the next batch of streamed lines for a viewer
+func waitForViewerLines(fv *FileViewer) tea.Cmd {
+	stream := fv.stream
+	return func() tea.Msg {
+		lines, ok := readLineBatch(stream)
+		return viewerLinesMsg{Viewer: fv, Lines: lines, Done: !ok}
+	}
+}
+
+// followTickCmd schedules the next poll of a followed file
+func followTickCmd(fv *FileViewer) tea.Cmd {
+	return tea.Tick(followPollInterval, func(time.Time) tea.Msg {
+		return followTickMsg{Viewer: fv}
+	})
+}
+
+// appendText adds text to the end of the content, continuing a partial last line,
+// and highlights only the changed tail
+func (fv *FileViewer) appendText(text string) {
+	if fv.hex {
+		fv.setHexContent(append(fv.raw, text...))
+		return
+	}
+	text = normalizeText(text)
+	if text == "" {
+		return
+	}
+
+	parts := strings.Split(text, "\n")
+	if hasOverstrike(text) {
+		fv.overstrike = true
+	}
+	if len(fv.Content) == 0 {
+		fv.Content = []string{""}
+	}
+	first := len(fv.Content) - 1
+	fv.highlightLimit = min(fv.highlightLimit, first)
+	fv.truncateSpelling(first)
+	fv.forgetLinks(first)
+	fv.Content[first] += parts[0]
+	fv.Content = append(fv.Content, parts[1:]...)
+
+	// Re-highlight from the first modified line
+	if fv.overstrike {
+		if len(fv.styledLines) > first {
+			fv.styledLines = fv.styledLines[:first]
+		}
+		// Lines before the first overstrike text are shown plain
+		for len(fv.styledLines) < first {
+			fv.styledLines = append(fv.styledLines, fv.Content[len(fv.styledLines)])
+		}
+		for i := first; i < len(fv.Content); i++ {
+			plain, styled := renderOverstrike(fv.Content[i])
+			fv.Content[i] = plain
+			fv.styledLines = append(fv.styledLines, styled)
+		}
+	} else if fv.UseSyntaxHighlight && len(fv.spans) >= first {
+		tail := *fv
+		tail.spans = nil
+		tail.Content = fv.Content[first:]
+		tail.applySyntaxHighlighting(strings.Join(tail.Content, "\n"))
+		fv.spans = append(fv.spans[:first], tail.spans...)
+	}
+
+	if fv.LogMode {
+		fv.detectLevels(first)
+		fv.detectTimes(first)
+	}
+	fv.refilter(first)
+
+	if fv.Following {
+		fv.scrollToBottom()
+	}
+}
+
+// scrollToBottom moves the scroll position so the last line is visible
+func (fv *FileViewer) scrollToBottom() {
+	maxScroll := fv.rowCount() - (fv.Height - 6)
+	if maxScroll < 0 {
+		maxScroll = 0
+	}
+	fv.ScrollPos = maxScroll
+}
+
+// pauseFollow stops following while the user scrolls back, until G
+func (fv *FileViewer) pauseFollow() {
+	if fv.Following {
+		fv.Following, fv.followPaused = false, true
+	}
+}
+
+// toggleFollow turns follow mode on or off
+func (fv *FileViewer) toggleFollow() {
+	if fv.stream == nil && fv.FilePath == "" {
+		fv.StatusMessage = "Follow is only available for files and piped input"
+		return
+	}
+	if fv.window != nil {
+		// Only the end of the file is needed to follow it
+		if fv.followWindowed(); fv.window != nil {
+			return
+		}
+	}
+	if fv.part.Total > 0 && !fv.part.Tail {
+		fv.StatusMessage = "Only the start of the file is shown, open its end to follow it"
+		return
+	}
+
+	fv.Following = !fv.Following
+	fv.followPaused = false
+	if fv.Following {
+		fv.Live = true
+		fv.scrollToBottom()
+		fv.StatusMessage = "Following (F to stop)"
+	} else {
+		fv.StatusMessage = "Stopped following"
+	}
+}
+
+// pollFile appends data written to the file since it was last read. Like
+// tail -F, it starts over when the file is truncated or replaced by a new file
+// of the same name, as happens when logs are rotated.
+func (fv *FileViewer) pollFile() {
+	info, err := vfs.Default.Stat(fv.FilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		fv.waitForFile()
+		return
+	}
+	if err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	fv.missingSince = time.Time{}
+
+	size := info.Size()
+	switch {
+	case fv.replacedBy(info):
+		fv.restartFollow(info, "File was replaced, reloading")
+	case size < fv.readOffset:
+		fv.restartFollow(info, "File truncated, reloading")
+	}
+	if size == fv.readOffset {
+		return
+	}
+
+	file, err := vfs.Default.Open(fv.FilePath)
+	if err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	defer file.Close()
+
+	data := make([]byte, size-fv.readOffset)
+	n, err := file.ReadAt(data, fv.readOffset)
+	if err != nil && err != io.EOF {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	fv.readOffset += int64(n)
+	fv.appendText(string(data[:n]))
+}
+
+// replacedBy reports whether the file now at the viewed path is another file
+// than the one read so far. File systems without file identities never report
+// a replacement.
+func (fv *FileViewer) replacedBy(info fs.FileInfo) bool {
+	if fv.fileInfo == nil || fv.fileInfo.Sys() == nil || info.Sys() == nil {
+		return false
+	}
+	return !os.SameFile(fv.fileInfo, info)
+}
+
+// restartFollow drops the content to read the file again from its start
+func (fv *FileViewer) restartFollow(info fs.FileInfo, status string) {
+	fv.fileInfo = info
+	fv.readOffset = 0
+	fv.Content = nil
+	fv.spans = nil
+	fv.styledLines = nil
+	fv.levels = nil
+	fv.times = nil
+	fv.filtered = nil
+	fv.resetSpelling()
+	fv.resetLinks()
+	fv.StatusMessage = status
+}
+
+// waitForFile keeps following a file that vanished until rotationGrace has
+// passed, then reports it deleted
+func (fv *FileViewer) waitForFile() {
+	if fv.missingSince.IsZero() {
+		fv.missingSince = time.Now()
+		fv.StatusMessage = fmt.Sprintf("%s is missing, waiting for it to be recreated", fv.FileName)
+		return
+	}
+	if time.Since(fv.missingSince) > rotationGrace {
+		fv.missingSince = time.Time{}
+		fv.markGone()
+	}
+}
+
+// styleLiveLine applies the first matching highlight rule to line i of live content
+func (fv *FileViewer) styleLiveLine(i int) (string, bool) {
+	for _, rule := range fv.HighlightRules {
+		if rule.Pattern.MatchString(fv.Content[i]) {
+			return rule.Style.Render(fv.Content[i]), true
+		}
+	}
+	return "", false
+}
+
+// startFollowing begins polling the viewed file if follow mode was just enabled
+func (fv *FileViewer) startFollowing() tea.Cmd {
+	if !fv.Following || fv.polling || fv.FilePath == "" {
+		return nil
+	}
+	fv.polling = true
+	return followTickCmd(fv)
+}
+
+// readStream waits for the next batch of streamed lines, unless already waiting
+func (fv *FileViewer) readStream() tea.Cmd {
+	if fv.stream == nil || fv.reading {
+		return nil
+	}
+	fv.reading = true
+	return waitForViewerLines(fv)
+}
+
+// receiveLines adds a batch of streamed lines to the content
+func (fv *FileViewer) receiveLines(msg viewerLinesMsg) {
+	fv.reading = false
+	if !msg.Done {
+		fv.appendText(strings.Join(msg.Lines, "\n") + "\n")
+		return
+	}
+	fv.stream = nil
+	fv.StatusMessage = "End of input"
+	// Piped help and man output usually has headings worth navigating
+	if fv.Language == "" {
+		fv.Sections = detectSections(fv.Content)
+	}
+}
@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/disk"
+	"github.com/HolyStarGazer/windows-tui-go/git"
+)
+
+// formatPlaceholders are the placeholders available in the header and status
+// bar format strings
+var formatPlaceholders = []string{"path", "free", "git_branch", "items", "cursor", "marked", "marked_size", "project", "sort"}
+
+// usesPlaceholder reports whether the header or status format refers to name
+func (m *Model) usesPlaceholder(name string) bool {
+	token := "{" + name + "}"
+	return strings.Contains(m.Settings.HeaderFormat, token) || strings.Contains(m.Settings.StatusFormat, token)
+}
+
+// refreshFormatInfo looks up the values that are too slow to compute on every
+// render, skipping the ones no format string uses
+func (m *Model) refreshFormatInfo() {
+	m.freeSpace = ""
+	if m.usesPlaceholder("free") {
+		if free, err := disk.Free(m.CurrentPath); err == nil {
+			m.freeSpace = FormatSize(int64(free))
+		}
+	}
+
+	m.gitBranch = ""
+	if m.usesPlaceholder("git_branch") {
+		if branch, err := git.Branch(m.CurrentPath); err == nil {
+			m.gitBranch = branch
+		}
+	}
+}
+
+// formatValue returns the current value of a placeholder
+func (m *Model) formatValue(name string) string {
+	switch name {
+	case "path":
+		return m.CurrentPath
+	case "free":
+		return m.freeSpace
+	case "git_branch":
+		return m.gitBranch
+	case "items":
+		return fmt.Sprint(len(m.Items))
+	case "cursor":
+		return fmt.Sprint(m.Cursor + 1)
+	case "marked":
+		return fmt.Sprint(len(m.Marked))
+	case "marked_size":
+		return FormatSize(m.markedSize())
+	case "project":
+		if m.Project != nil {
+			return m.Project.Name
+		}
+	case "sort":
+		return m.Options.sortLabel()
+	}
+	return ""
+}
+
+// expandFormat replaces the placeholders in format; unknown placeholders are
+// left as written
+func (m *Model) expandFormat(format string) string {
+	pairs := make([]string, 0, 2*len(formatPlaceholders))
+	for _, name := range formatPlaceholders {
+		pairs = append(pairs, "{"+name+"}", m.formatValue(name))
+	}
+	return strings.NewReplacer(pairs...).Replace(format)
+}
@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/HolyStarGazer/windows-tui-go/git"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// gitLogLimit caps the number of commits loaded into the log browser
+const gitLogLimit = 500
+
+// showCommitMsg requests the diff of a commit
+type showCommitMsg struct {
+	Dir  string
+	Hash string
+	Path string
+}
+
+// commitLoadedMsg delivers the diff of a commit
+type commitLoadedMsg struct {
+	Hash    string
+	Content string
+	Err     error
+}
+
+// gitLogCmd loads the commit history for the repository or, with "%", the selected item
+func (m Model) gitLogCmd(args []string) tea.Cmd {
+	dir := m.CurrentPath
+	path := ""
+	if len(args) > 0 && args[0] == "%" {
+		selected, ok := m.selectedItemPath()
+		if !ok {
+			return func() tea.Msg { return errorMsg{fmt.Errorf("no item selected")} }
+		}
+		path = selected
+	}
+
+	return func() tea.Msg {
+		commits, err := git.Log(dir, path, gitLogLimit)
+		if err != nil {
+			return errorMsg{err}
+		}
+
+		title := "📜 Git Log"
+		if path != "" {
+			title = fmt.Sprintf("📜 Git Log: %s", filepath.Base(path))
+		}
+
+		panel := NewListPanel(title, commitEntries(dir, path, commits))
+		panel.Subtitle = fmt.Sprintf("Repository: %s", dir)
+		return openListMsg{panel}
+	}
+}
+
+// commitEntries converts commits into list entries that open the commit diff
+func commitEntries(dir, path string, commits []git.Commit) []ListEntry {
+	entries := make([]ListEntry, 0, len(commits))
+	for _, c := range commits {
+		if c.Hash == "" {
+			entries = append(entries, ListEntry{Label: c.Graph, Separator: true})
+			continue
+		}
+
+		label := fmt.Sprintf("%s%s %s %-16s %s",
+			c.Graph,
+			theme.Directory.Render(c.Hash),
+			c.Date,
+			truncateAtVisualWidth(c.Author, 16),
+			c.Subject)
+		entries = append(entries, ListEntry{
+			Label: label,
+			Data:  c,
+			Msg:   showCommitMsg{Dir: dir, Hash: c.Hash, Path: path},
+		})
+	}
+	return entries
+}
+
+// loadCommitCmd runs git show in the background
+func loadCommitCmd(msg showCommitMsg) tea.Cmd {
+	return func() tea.Msg {
+		content, err := git.Show(msg.Dir, msg.Hash, msg.Path)
+		return commitLoadedMsg{Hash: msg.Hash, Content: content, Err: err}
+	}
+}
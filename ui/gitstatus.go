@@ -0,0 +1,191 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/git"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// gitStageMsg requests staging or unstaging a path
+type gitStageMsg struct {
+	Root   string
+	Path   string
+	Stage  bool
+	Cursor int
+}
+
+// gitCommitMsg requests a commit of the staged changes
+type gitCommitMsg struct {
+	Root    string
+	Message string
+}
+
+// gitDiffMsg requests the diff of a single path
+type gitDiffMsg struct {
+	Root   string
+	Path   string
+	Staged bool
+}
+
+// gitStatusCmd loads the status of the repository containing dir into a git panel
+func gitStatusCmd(dir string, cursor int) tea.Cmd {
+	return func() tea.Msg {
+		root, err := git.Root(dir)
+		if err != nil {
+			return errorMsg{err}
+		}
+		root = filepath.FromSlash(root)
+
+		entries, err := git.Status(root)
+		if err != nil {
+			return errorMsg{err}
+		}
+
+		panel := newGitPanel(root, entries)
+		if cursor > 0 {
+			panel.SetCursor(cursor)
+		}
+		return openListMsg{panel}
+	}
+}
+
+// newGitPanel builds the git panel list from status entries
+func newGitPanel(root string, entries []git.StatusEntry) ListPanel {
+	var staged, unstaged []ListEntry
+
+	for _, e := range entries {
+		if e.IsStaged() {
+			staged = append(staged, gitStatusEntry(root, e, true))
+		}
+		if e.IsUnstaged() {
+			unstaged = append(unstaged, gitStatusEntry(root, e, false))
+		}
+	}
+
+	var items []ListEntry
+	items = append(items, ListEntry{Label: theme.Dim.Render(fmt.Sprintf("Staged changes (%d)", len(staged))), Separator: true})
+	items = append(items, staged...)
+	items = append(items, ListEntry{Label: "", Separator: true})
+	items = append(items, ListEntry{Label: theme.Dim.Render(fmt.Sprintf("Unstaged changes (%d)", len(unstaged))), Separator: true})
+	items = append(items, unstaged...)
+
+	panel := NewListPanel("🌿 Git Status", items)
+	panel.Subtitle = fmt.Sprintf("Repository: %s", root)
+	if len(staged)+len(unstaged) == 0 {
+		panel.StatusMessage = "Nothing to commit, working tree clean"
+	}
+
+	panel.Actions = []ListAction{
+		{Key: "a", Desc: "stage", Msg: func(entry ListEntry) tea.Msg {
+			e, ok := entry.Data.(gitPanelItem)
+			if !ok {
+				return nil
+			}
+			return gitStageMsg{Root: root, Path: e.Entry.Path, Stage: true}
+		}},
+		{Key: "u", Desc: "unstage", Msg: func(entry ListEntry) tea.Msg {
+			e, ok := entry.Data.(gitPanelItem)
+			if !ok {
+				return nil
+			}
+			return gitStageMsg{Root: root, Path: e.Entry.Path, Stage: false}
+		}},
+		{Key: "c", Desc: "commit", Msg: func(entry ListEntry) tea.Msg {
+			return openPromptMsg{Prompt{
+				Label: "Commit message: ",
+				Submit: func(value string) tea.Msg {
+					return gitCommitMsg{Root: root, Message: value}
+				},
+			}}
+		}},
+	}
+
+	return panel
+}
+
+// gitPanelItem is the payload of a git panel entry
+type gitPanelItem struct {
+	Entry  git.StatusEntry
+	Staged bool
+}
+
+// gitStatusEntry renders a single status line for the git panel
+func gitStatusEntry(root string, e git.StatusEntry, staged bool) ListEntry {
+	code := e.Worktree
+	style := theme.File
+	if staged {
+		code = e.Index
+		style = theme.Directory
+	}
+
+	label := fmt.Sprintf("%c  %s", code, e.Path)
+	if e.OrigPath != "" && staged {
+		label = fmt.Sprintf("%c  %s -> %s", code, e.OrigPath, e.Path)
+	}
+
+	return ListEntry{
+		Label: style.Render(label),
+		Data:  gitPanelItem{Entry: e, Staged: staged},
+		Msg:   gitDiffMsg{Root: root, Path: e.Path, Staged: staged},
+	}
+}
+
+// updateGit handles git panel messages
+func (m Model) updateGit(msg tea.Msg) (tea.Model, tea.Cmd) {
+	cursor := 0
+	if m.List != nil {
+		cursor = m.List.Cursor
+	}
+
+	switch msg := msg.(type) {
+	case gitStageMsg:
+		return m, func() tea.Msg {
+			var err error
+			if msg.Stage {
+				err = git.Stage(msg.Root, msg.Path)
+			} else {
+				err = git.Unstage(msg.Root, msg.Path)
+			}
+			if err != nil {
+				return errorMsg{err}
+			}
+			return gitStatusCmd(msg.Root, cursor)()
+		}
+
+	case gitCommitMsg:
+		message := strings.TrimSpace(msg.Message)
+		if message == "" {
+			m.setStatus("Commit aborted: empty message")
+			return m, nil
+		}
+		return m, func() tea.Msg {
+			summary, err := git.CommitStaged(msg.Root, message)
+			if err != nil {
+				return errorMsg{err}
+			}
+			next := gitStatusCmd(msg.Root, 0)()
+			if open, ok := next.(openListMsg); ok {
+				open.Panel.StatusMessage = summary
+				return open
+			}
+			return next
+		}
+
+	case gitDiffMsg:
+		return m, func() tea.Msg {
+			content, err := git.Diff(msg.Root, msg.Path, msg.Staged)
+			if err != nil {
+				return errorMsg{err}
+			}
+			if content == "" {
+				return errorMsg{fmt.Errorf("no diff for %s (untracked file?)", msg.Path)}
+			}
+			return commitLoadedMsg{Hash: filepath.Base(msg.Path), Content: content}
+		}
+	}
+
+	return m, nil
+}
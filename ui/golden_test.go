@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/uitest"
+)
+
+// goldenTree is the folder the golden frames are rendered from
+var goldenTree = func() fstest.MapFS {
+	stamp := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	return fstest.MapFS{
+		"docs/guide.md": {Data: []byte("# Guide\n\nStart with **one** step:\n\n- open a folder\n- press `enter`\n"), ModTime: stamp},
+		"docs/old.txt":  {Data: []byte("kept for reference\n"), ModTime: stamp},
+		"main.go":       {Data: []byte("package main\n\nimport \"fmt\"\n\n// main greets whoever runs it, in a comment long enough to wrap at eighty columns\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n"), ModTime: stamp},
+		"notes.txt":     {Data: []byte("first line\nsecond line\nthird line\n"), ModTime: stamp},
+	}
+}()
+
+// goldenDriver starts the explorer on goldenTree, served from memory, with
+// its settings in an empty folder, and returns the driver with the tree's path
+func goldenDriver(t *testing.T) (*uitest.Driver, string) {
+	t.Helper()
+	uitest.IsolateConfig(t)
+	dir := uitest.Mount(t, goldenTree)
+	return uitest.New(NewModelAt(dir), 80, 16), dir
+}
+
+// goldenFrame masks the tree's path, and the separators that differ by
+// platform, in the frame of d
+func goldenFrame(d *uitest.Driver, dir string) string {
+	return d.Frame(dir, "<dir>", string(filepath.Separator), "/")
+}
+
+// TestBrowserGolden renders the listing of a folder and of a subfolder
+func TestBrowserGolden(t *testing.T) {
+	d, dir := goldenDriver(t)
+	uitest.Golden(t, "browser", goldenFrame(d, dir))
+
+	d.Keys("j", "enter")
+	uitest.Golden(t, "browser-subfolder", goldenFrame(d, dir))
+
+	d.Keys("backspace", "s", "s")
+	uitest.Golden(t, "browser-by-size", goldenFrame(d, dir))
+}
+
+// TestPreviewGolden renders the preview pane beside the listing, for a
+// folder, highlighted source and rendered Markdown
+func TestPreviewGolden(t *testing.T) {
+	d, dir := goldenDriver(t)
+	d.Keys("P", "j")
+	uitest.Golden(t, "preview-folder", goldenFrame(d, dir))
+
+	d.Keys("j")
+	uitest.Golden(t, "preview-source", goldenFrame(d, dir))
+
+	d.Keys("k", "enter", "j")
+	uitest.Golden(t, "preview-markdown", goldenFrame(d, dir))
+}
+
+// TestViewerGolden renders a source file as opened from the listing, wrapped,
+// and a markdown file
+func TestViewerGolden(t *testing.T) {
+	d, dir := goldenDriver(t)
+	d.Keys("j", "j", "enter")
+	uitest.Golden(t, "viewer", goldenFrame(d, dir))
+
+	d.Command("set wrap")
+	uitest.Golden(t, "viewer-wrap", goldenFrame(d, dir))
+
+	d.Keys("q", "k", "enter", "j", "enter")
+	uitest.Golden(t, "viewer-markdown", goldenFrame(d, dir))
+}
@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// isLineNumber reports whether s is a plain line number, such as the 123 of :123
+func isLineNumber(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// parsePercent reads a percentage such as 50%, reporting whether s is one
+func parsePercent(s string) (int, bool) {
+	number, found := strings.CutSuffix(s, "%")
+	if !found || !isLineNumber(number) {
+		return 0, false
+	}
+	pct, err := strconv.Atoi(number)
+	return pct, err == nil
+}
+
+// gotoTarget jumps to a line number or a percentage of the file (:123, :50%);
+// it reports whether arg was either
+func (fv *FileViewer) gotoTarget(arg string) bool {
+	if pct, ok := parsePercent(arg); ok {
+		fv.gotoPercent(pct)
+		return true
+	}
+	if !isLineNumber(arg) {
+		return false
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		fv.StatusMessage = fmt.Sprintf("Line %s is out of range", arg)
+		return true
+	}
+	fv.gotoLine(n)
+	return true
+}
+
+// lineCount returns the number of lines in the file, or -1 while a file over
+// max_view_size is still being indexed
+func (fv *FileViewer) lineCount() int {
+	switch {
+	case fv.window == nil:
+		return len(fv.Content)
+	case fv.hex:
+		return int((fv.part.Total + hexBytesPerLine - 1) / hexBytesPerLine)
+	}
+	return fv.window.totalLines(fv.part.Total)
+}
+
+// gotoLine jumps to line n, counted from 1, loading it first in a file over
+// max_view_size
+func (fv *FileViewer) gotoLine(n int) {
+	total := fv.lineCount()
+	switch {
+	case n < 1:
+		fv.StatusMessage = fmt.Sprintf("Line %d is out of range: lines are counted from 1", n)
+		return
+	case total >= 0 && n > total:
+		fv.StatusMessage = fmt.Sprintf("Line %d is out of range: the file has %d lines", n, total)
+		return
+	}
+	if fv.window != nil && !fv.loadLine(n-1) {
+		return
+	}
+
+	first := max(fv.firstLine(), 0)
+	i := n - 1 - first
+	if i < 0 || i >= len(fv.Content) {
+		fv.StatusMessage = fmt.Sprintf("Line %d is out of range: lines %d-%d are loaded", n, first+1, first+len(fv.Content))
+		return
+	}
+	fv.ScrollPos = fv.rowOf(i)
+	if shown := fv.lineAt(fv.ScrollPos); shown != i {
+		fv.StatusMessage = fmt.Sprintf("Line %d is hidden by the filter", n)
+		return
+	}
+	if total < 0 {
+		fv.StatusMessage = fmt.Sprintf("Line %d", n)
+		return
+	}
+	fv.StatusMessage = fmt.Sprintf("Line %d of %d", n, total)
+}
+
+// loadLine loads the window of a file over max_view_size at line, counted
+// from 0, unless it is loaded already. The line index must have reached it.
+func (fv *FileViewer) loadLine(line int) bool {
+	first := fv.firstLine()
+	if first >= 0 && line >= first && line < first+len(fv.Content) {
+		return true
+	}
+
+	var offset int64
+	if fv.hex {
+		offset = int64(line) * hexBytesPerLine
+	} else {
+		w := fv.window
+		k := line / indexStep
+		if k >= len(w.marks) {
+			fv.StatusMessage = fmt.Sprintf("Line %d is not indexed yet (indexing %d%%)", line+1, w.indexed*100/max(fv.part.Total, 1))
+			return false
+		}
+		// Count the line breaks after the nearest indexed line
+		offset = w.marks[k]
+		for skip := line % indexStep; skip > 0; {
+			data, err := fv.readRange(offset, min(offset+indexChunkSize, fv.part.Total))
+			if err != nil {
+				fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+				return false
+			}
+			if len(data) == 0 {
+				break
+			}
+			for skip > 0 {
+				i := bytes.IndexByte(data, '\n')
+				if i < 0 {
+					offset += int64(len(data))
+					break
+				}
+				offset += int64(i + 1)
+				data = data[i+1:]
+				skip--
+			}
+		}
+	}
+
+	if err := fv.loadWindow(offset, line); err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return false
+	}
+	return true
+}
+
+// gotoPercent jumps to a percentage of the way through the file, as in vim's
+// 50%. A file over max_view_size whose lines are not all counted yet is
+// jumped through by size instead.
+func (fv *FileViewer) gotoPercent(pct int) {
+	if pct > 100 {
+		fv.StatusMessage = fmt.Sprintf("%d%% is out of range: use 0-100%%", pct)
+		return
+	}
+	if total := fv.lineCount(); total >= 0 {
+		fv.gotoLine(max((pct*total+99)/100, 1))
+		return
+	}
+
+	from := fv.part.Total * int64(pct) / 100
+	if from > 0 {
+		// Start at the next line
+		if data, err := fv.readRange(from, min(from+tailReadSize, fv.part.Total)); err == nil {
+			if i := bytes.IndexByte(data, '\n'); i >= 0 {
+				from += int64(i + 1)
+			}
+		}
+	}
+	if from >= fv.part.Total {
+		fv.loadEnd()
+	} else if err := fv.loadWindow(from, -1); err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	} else {
+		fv.ScrollPos = 0
+	}
+	fv.StatusMessage = fmt.Sprintf("Jumped to %d%% of the file", pct)
+}
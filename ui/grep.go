@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/ops"
+	"github.com/HolyStarGazer/windows-tui-go/search"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxGrepResults caps the number of lines returned by a content search
+const maxGrepResults = 2000
+
+// openMatchMsg views a file at a line found by a content search, with the
+// searched text highlighted
+type openMatchMsg struct {
+	Path string
+	Line int // Line number, starting at 1
+	Text string
+}
+
+// grepCmd searches the files below root for lines containing text and lists
+// the matching lines
+func (m *Model) grepCmd(root, text string) tea.Cmd {
+	return m.startJob(fmt.Sprintf("Searching file contents for '%s'", text), func(progress *ops.Progress) tea.Msg {
+		matches, truncated, err := search.Grep(root, text, search.Options{
+			SkipDirs:   skippedDirs,
+			MaxResults: maxGrepResults,
+			Progress:   progress,
+		})
+		if errors.Is(err, ops.ErrCanceled) {
+			return jobCanceledMsg{}
+		}
+		if err != nil {
+			return errorMsg{err}
+		}
+
+		items := make([]ListEntry, 0, len(matches))
+		for _, match := range matches {
+			rel, _ := filepath.Rel(root, match.Path)
+			line := strings.TrimSpace(strings.ReplaceAll(match.Text, "\t", "    "))
+			items = append(items, ListEntry{
+				Label: theme.File.Render(rel) + theme.Dim.Render(fmt.Sprintf(":%d: ", match.Line)) + line,
+				Msg:   openMatchMsg{Path: match.Path, Line: match.Line, Text: text},
+			})
+		}
+
+		panel := NewListPanel(fmt.Sprintf("🔎 Grep: %s", text), items)
+		panel.Subtitle = fmt.Sprintf("Scope: %s", root)
+		switch {
+		case len(items) == 0:
+			panel.StatusMessage = fmt.Sprintf("No lines containing '%s'", text)
+		case truncated:
+			panel.StatusMessage = fmt.Sprintf("Showing first %d matches", maxGrepResults)
+		}
+		return openListMsg{panel}
+	})
+}
+
+// openMatch views a file found by a content search, searching it for the same
+// text and scrolling to the matching line
+func (m *Model) openMatch(msg openMatchMsg) {
+	viewer := NewFileViewer(msg.Path, filepath.Base(msg.Path))
+	m.openViewer(&viewer)
+	if viewer.Err != nil {
+		return
+	}
+
+	viewer.performSearch(msg.Text)
+	line := msg.Line - 1
+	for i, match := range viewer.SearchMatches {
+		if match == line {
+			viewer.CurrentMatchIndex = i
+			viewer.StatusMessage = fmt.Sprintf("Match %d of %d - n: next, N: prev", i+1, len(viewer.SearchMatches))
+			break
+		}
+	}
+	if line < len(viewer.Content) {
+		viewer.ScrollPos = viewer.rowOf(line)
+	}
+}
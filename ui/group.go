@@ -0,0 +1,338 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// groupings are the values of the group option
+var groupings = []string{"ext", "day", "size"}
+
+// sizeBuckets are the size groups, largest first, as Explorer names them
+var sizeBuckets = []struct {
+	Min   int64
+	Label string
+}{
+	{4 << 30, "Gigantic (over 4 GB)"},
+	{1 << 30, "Huge (1 - 4 GB)"},
+	{128 << 20, "Large (128 MB - 1 GB)"},
+	{1 << 20, "Medium (1 - 128 MB)"},
+	{16 << 10, "Small (16 KB - 1 MB)"},
+	{1, "Tiny (up to 16 KB)"},
+	{0, "Empty"},
+}
+
+// itemGroup is a run of listed items under one header
+type itemGroup struct {
+	Key   string // Identifies the group, for keeping it collapsed across reloads
+	Label string
+	Start int // Index of the first item
+	End   int // Index after the last item
+	Size  int64
+	Files int
+}
+
+// groupView is the grouping of the listing by the group option
+type groupView struct {
+	Dir       string          // Directory grouped; browsing elsewhere expands every group
+	Groups    []itemGroup     // Groups in listing order, empty when not grouped
+	Collapsed map[string]bool // Keys of collapsed groups
+}
+
+// groupKey places an item in a group: groups are ordered by rank, then key
+type groupKey struct {
+	Rank  int
+	Key   string
+	Label string
+}
+
+// groupKeyOf returns the group of an item under a grouping, relative to today
+// for the day grouping
+func groupKeyOf(grouping string, item types.FileItem, today time.Time) groupKey {
+	switch grouping {
+	case "ext":
+		if item.IsDir {
+			return groupKey{0, "", "Folders"}
+		}
+		if ext := item.Ext(); ext != "" {
+			return groupKey{1, ext, ext}
+		}
+		return groupKey{2, "", "No extension"}
+
+	case "day":
+		t := item.ModTime.Local()
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+		label := day.Format("Mon 2006-01-02")
+		switch day {
+		case today:
+			label = "Today"
+		case today.AddDate(0, 0, -1):
+			label = "Yesterday"
+		}
+		// Newest first
+		return groupKey{-int(day.Unix() / 86400), day.Format("2006-01-02"), label}
+
+	case "size":
+		if item.IsDir {
+			return groupKey{math.MinInt, "", "Folders"}
+		}
+		for i, bucket := range sizeBuckets {
+			if item.Size >= bucket.Min {
+				return groupKey{i, "", bucket.Label}
+			}
+		}
+	}
+	return groupKey{}
+}
+
+// groupItems orders the listed items by the group option, keeping the sort
+// order within each group, and finds the groups. ".." stays on top, outside
+// any group. A thumbnail grid is not grouped.
+func (m *Model) groupItems() {
+	if m.groups.Dir != m.CurrentPath {
+		m.groups = groupView{Dir: m.CurrentPath}
+	}
+	m.groups.Groups = nil
+	if m.Options.Group == "" || m.showsGrid() {
+		return
+	}
+
+	start := 0
+	if len(m.Items) > 0 && m.Items[0].Name == ".." {
+		start = 1
+	}
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	items := m.Items[start:]
+	keys := make(map[string]groupKey, len(items))
+	for _, item := range items {
+		keys[item.Path] = groupKeyOf(m.Options.Group, item, today)
+	}
+	slices.SortStableFunc(items, func(a, b types.FileItem) int {
+		ka, kb := keys[a.Path], keys[b.Path]
+		if ka.Rank != kb.Rank {
+			if ka.Rank < kb.Rank {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(ka.Key, kb.Key)
+	})
+
+	for i, item := range items {
+		key := keys[item.Path]
+		id := fmt.Sprintf("%s %d %s", m.Options.Group, key.Rank, key.Key)
+		if n := len(m.groups.Groups); n == 0 || m.groups.Groups[n-1].Key != id {
+			m.groups.Groups = append(m.groups.Groups, itemGroup{Key: id, Label: key.Label, Start: start + i})
+		}
+		g := &m.groups.Groups[len(m.groups.Groups)-1]
+		g.End = start + i + 1
+		if !item.IsDir {
+			g.Size += item.Size
+			g.Files++
+		}
+	}
+}
+
+// groupAt returns the group holding item i, if the listing is grouped
+func (m *Model) groupAt(i int) (itemGroup, bool) {
+	for _, g := range m.groups.Groups {
+		if i >= g.Start && i < g.End {
+			return g, true
+		}
+	}
+	return itemGroup{}, false
+}
+
+// isCollapsedAt reports whether item i is in a collapsed group, and so shown
+// only by the group's header. With the cursor on any of its items the cursor
+// is on the header.
+func (m *Model) isCollapsedAt(i int) bool {
+	g, ok := m.groupAt(i)
+	return ok && m.groups.Collapsed[g.Key]
+}
+
+// collapsedGroupStatus refuses a command on the item under the cursor when
+// the cursor is on a collapsed group, reporting true
+func (m *Model) collapsedGroupStatus() bool {
+	if !m.isCollapsedAt(m.Cursor) {
+		return false
+	}
+	m.StatusMessage = "The cursor is on a collapsed group: expand it first (Enter or za)"
+	return true
+}
+
+// moveCursor moves the cursor up or down one row of the listing, passing over
+// the items of collapsed groups in one step
+func (m *Model) moveCursor(delta int) {
+	if len(m.Items) == 0 {
+		return
+	}
+	i := m.Cursor
+	if g, ok := m.groupAt(i); ok && m.groups.Collapsed[g.Key] {
+		i = g.Start
+		if delta > 0 {
+			i = g.End - 1
+		}
+	}
+	i += delta
+	if i < 0 || i >= len(m.Items) {
+		return
+	}
+	if g, ok := m.groupAt(i); ok && m.groups.Collapsed[g.Key] {
+		i = g.Start
+	}
+	m.Cursor = i
+}
+
+// setCollapsed collapses or expands a group. Collapsing the group under the
+// cursor leaves the cursor on its header.
+func (m *Model) setCollapsed(g itemGroup, collapsed bool) {
+	if m.groups.Collapsed == nil {
+		m.groups.Collapsed = make(map[string]bool)
+	}
+	if collapsed {
+		m.groups.Collapsed[g.Key] = true
+		if m.Cursor >= g.Start && m.Cursor < g.End {
+			m.Cursor = g.Start
+		}
+	} else {
+		delete(m.groups.Collapsed, g.Key)
+	}
+}
+
+// groupKeyCmd handles the key after z in a grouped listing: za collapses or
+// expands the group under the cursor, zM collapses every group and zR
+// expands them all
+func (m *Model) groupKeyCmd(key string) tea.Cmd {
+	if len(m.groups.Groups) == 0 {
+		m.StatusMessage = "The listing is not grouped (:setlocal group=ext, day or size)"
+		return nil
+	}
+	switch key {
+	case "a":
+		g, ok := m.groupAt(m.Cursor)
+		if !ok {
+			return nil
+		}
+		m.setCollapsed(g, !m.groups.Collapsed[g.Key])
+	case "M":
+		for _, g := range m.groups.Groups {
+			m.setCollapsed(g, true)
+		}
+	case "R":
+		m.groups.Collapsed = nil
+	case "esc":
+		m.StatusMessage = ""
+	default:
+		m.StatusMessage = fmt.Sprintf("No group key z%s (za collapses or expands, zM collapses all, zR expands all)", key)
+	}
+	return nil
+}
+
+// groupPaths returns the paths of the items in a group
+func (m *Model) groupPaths(g itemGroup) []string {
+	paths := make([]string, 0, g.End-g.Start)
+	for _, item := range m.Items[g.Start:g.End] {
+		paths = append(paths, item.Path)
+	}
+	return paths
+}
+
+// toggleGroupMarks marks every item of a collapsed group, or unmarks them if
+// all are marked, and moves past the group
+func (m *Model) toggleGroupMarks(g itemGroup) tea.Cmd {
+	if g.End < len(m.Items) {
+		m.Cursor = g.End
+	}
+	all := true
+	for _, item := range m.Items[g.Start:g.End] {
+		all = all && m.Marked[item.Path]
+	}
+	if all {
+		for _, item := range m.Items[g.Start:g.End] {
+			delete(m.Marked, item.Path)
+		}
+		return nil
+	}
+	if m.Marked == nil {
+		m.Marked = make(map[string]bool)
+	}
+	var cmds []tea.Cmd
+	for _, item := range m.Items[g.Start:g.End] {
+		m.Marked[item.Path] = true
+		if _, ok := m.dirSizes[item.Path]; item.IsDir && !ok {
+			if m.dirSizes == nil {
+				m.dirSizes = make(map[string]int64)
+			}
+			m.dirSizes[item.Path] = sizing
+			cmds = append(cmds, m.dirSizeCmd(item.Path))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// groupHeader renders the header row of a group, e.g.
+// "▾ .pdf — 12 items, 30.2 MB"
+func (m Model) groupHeader(g itemGroup, focused bool) string {
+	collapsed := m.groups.Collapsed[g.Key]
+	cursor, arrow := " ", "▾"
+	if collapsed {
+		arrow = "▸"
+		if m.Cursor >= g.Start && m.Cursor < g.End {
+			cursor = ">"
+		}
+	}
+	mark := " "
+	if slices.ContainsFunc(m.Items[g.Start:g.End], func(item types.FileItem) bool { return m.Marked[item.Path] }) {
+		mark = "*"
+	}
+	count := fmt.Sprintf("%d items", g.End-g.Start)
+	if g.End-g.Start == 1 {
+		count = "1 item"
+	}
+	if g.Files > 0 {
+		count += ", " + FormatSize(g.Size)
+	}
+	line := fmt.Sprintf("%s%s %s %s", cursor, mark, theme.Group.Render(arrow+" "+g.Label), theme.Dim.Render("— "+count))
+	if cursor == ">" && focused {
+		line = theme.Selected.Render(fmt.Sprintf("%s%s %s %s — %s", cursor, mark, arrow, g.Label, count))
+	}
+	return line
+}
+
+// renderGroupedItems renders a grouped listing like renderItems, with a
+// header over each group and only the header of a collapsed one
+func (m Model) renderGroupedItems(maxVisible int, focused bool) []string {
+	var rows []string
+	cursorRow := 0
+	for i := 0; i < m.groups.Groups[0].Start; i++ {
+		if i == m.Cursor {
+			cursorRow = len(rows)
+		}
+		rows = append(rows, m.renderItem(i, focused))
+	}
+	for _, g := range m.groups.Groups {
+		if m.Cursor >= g.Start && m.Cursor < g.End {
+			cursorRow = len(rows)
+		}
+		rows = append(rows, m.groupHeader(g, focused))
+		if m.groups.Collapsed[g.Key] {
+			continue
+		}
+		for i := g.Start; i < g.End; i++ {
+			if i == m.Cursor {
+				cursorRow = len(rows)
+			}
+			rows = append(rows, m.renderItem(i, focused))
+		}
+	}
+	start, end := visibleWindow(len(rows), cursorRow, maxVisible)
+	return rows[start:end]
+}
@@ -0,0 +1,244 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/HolyStarGazer/windows-tui-go/disk"
+	"github.com/HolyStarGazer/windows-tui-go/ops"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// linkCountsMsg delivers the number of hard links of the files of a directory,
+// keyed by path
+type linkCountsMsg struct {
+	Dir    string
+	Counts map[string]int
+}
+
+// hardLinkMsg creates hard links to files in a directory, sent from the copy
+// prompt with Ctrl+L
+type hardLinkMsg struct {
+	Paths []string
+	Dest  string
+}
+
+// linkNamesMsg delivers the names of a file's hard links
+type linkNamesMsg struct {
+	Path     string
+	Links    int      // Number of links the file has
+	Names    []string // Paths of the links, including Path
+	Searched string   // Directory searched for the names, empty if they were listed
+	Err      error
+}
+
+// startLinkCounting reads the link counts of the listed files that the
+// listing did not report, as on Windows where each file has to be opened
+func (m *Model) startLinkCounting() tea.Cmd {
+	if m.linksRequested == m.CurrentPath {
+		return nil
+	}
+	m.linksRequested = m.CurrentPath
+	var paths []string
+	for _, item := range m.Items {
+		if !item.IsDir && item.Links == 0 && !item.IsLink() {
+			paths = append(paths, item.Path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	dir := m.CurrentPath
+	return func() tea.Msg {
+		found := make([]int, len(paths))
+		ops.Parallel(len(paths), func(i int) {
+			found[i], _ = disk.LinkCount(paths[i])
+		})
+		counts := make(map[string]int, len(paths))
+		for i, path := range paths {
+			if found[i] > 0 {
+				counts[path] = found[i]
+			}
+		}
+		return linkCountsMsg{Dir: dir, Counts: counts}
+	}
+}
+
+// updateLinkCounts fills in the link counts of the listed files
+func (m *Model) updateLinkCounts(msg linkCountsMsg) {
+	if msg.Dir != m.CurrentPath {
+		return
+	}
+	for i := range m.Items {
+		if count, ok := msg.Counts[m.Items[i].Path]; ok {
+			m.Items[i].Links = count
+		}
+	}
+}
+
+// hardLinksLabel describes the hard links of a listed file, e.g. ", 3 links"
+func hardLinksLabel(links int) string {
+	if links < 2 {
+		return ""
+	}
+	return fmt.Sprintf(", %d links", links)
+}
+
+// createHardLinks links the files into the destination, stopping at the
+// first that fails
+func (m *Model) createHardLinks(msg hardLinkMsg) {
+	done := 0
+	var last string
+	var err error
+	for _, path := range msg.Paths {
+		if last, err = ops.Link(path, msg.Dest); err != nil {
+			break
+		}
+		done++
+	}
+	m.reloadDirectory()
+	if m.DualPane {
+		m.reloadOtherPane()
+	}
+	switch {
+	case err != nil && done > 0:
+		m.setStatus(fmt.Sprintf("Error: %v (linked %d files first)", err, done))
+	case err != nil:
+		m.setStatus(fmt.Sprintf("Error: %v", err))
+	case done == 1:
+		m.setStatus(fmt.Sprintf("Created hard link %s", last))
+	default:
+		m.setStatus(fmt.Sprintf("Created %d hard links in %s", done, msg.Dest))
+	}
+}
+
+// linkNamesKey finds the other names of the file under the cursor (:links).
+// Windows lists them; elsewhere the file's volume is searched in the
+// background.
+func (m *Model) linkNamesKey() tea.Cmd {
+	if m.Cursor >= len(m.Items) || m.Items[m.Cursor].IsDir {
+		m.StatusMessage = "No file selected"
+		return nil
+	}
+	if m.collapsedGroupStatus() {
+		return nil
+	}
+	path := m.Items[m.Cursor].Path
+	return m.startJob("Finding the links of "+filepath.Base(path), func(progress *ops.Progress) tea.Msg {
+		msg := linkNamesMsg{Path: path}
+		ops.Work(func() { findLinkNames(&msg, progress) })
+		if progress.Canceled() {
+			return jobCanceledMsg{}
+		}
+		return msg
+	})
+}
+
+// findLinkNames lists the names of msg.Path, or searches its volume for them
+// until as many as it has links are found
+func findLinkNames(msg *linkNamesMsg, progress *ops.Progress) {
+	if msg.Links, msg.Err = disk.LinkCount(msg.Path); msg.Err != nil {
+		return
+	}
+	if msg.Links < 2 {
+		msg.Names = []string{msg.Path}
+		return
+	}
+	msg.Names, msg.Err = disk.LinkNames(msg.Path)
+	if !errors.Is(msg.Err, disk.ErrNoLinkNames) {
+		return
+	}
+
+	target, err := vfs.Default.Stat(msg.Path)
+	if err != nil {
+		msg.Err = err
+		return
+	}
+	msg.Err = nil
+	msg.Searched, msg.Names = volumeRoot(msg.Path, target), nil
+	volume, _ := disk.Device(target)
+	vfs.WalkDir(vfs.Default, msg.Searched, func(path string, d fs.DirEntry, err error) error {
+		if progress.Check() != nil {
+			return fs.SkipAll
+		}
+		if err != nil || !d.IsDir() && !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		// Other volumes mounted below the root hold none of the links
+		if d.IsDir() {
+			if dev, ok := disk.Device(info); ok && dev != volume {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		progress.AddItem()
+		if os.SameFile(info, target) {
+			msg.Names = append(msg.Names, path)
+			if len(msg.Names) == msg.Links {
+				return fs.SkipAll
+			}
+		}
+		return nil
+	})
+}
+
+// volumeRoot returns the top directory of the volume holding the file at
+// path: the highest directory above it on the same device
+func volumeRoot(path string, info fs.FileInfo) string {
+	volume, ok := disk.Device(info)
+	if !ok {
+		return filepath.VolumeName(path) + string(filepath.Separator)
+	}
+	dir := filepath.Dir(path)
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		parentInfo, err := vfs.Default.Stat(parent)
+		if err != nil {
+			return dir
+		}
+		if dev, _ := disk.Device(parentInfo); dev != volume {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// showLinkNames lists the names of a file; Enter shows one in the browser
+func (m *Model) showLinkNames(msg linkNamesMsg) {
+	if msg.Err != nil {
+		m.setStatus(fmt.Sprintf("Error: %v", msg.Err))
+		return
+	}
+	entries := make([]ListEntry, 0, len(msg.Names))
+	for _, name := range msg.Names {
+		label := theme.File.Render(name)
+		if name == msg.Path {
+			label += "  " + theme.Dim.Render("(this name)")
+		}
+		entries = append(entries, ListEntry{Label: label, Msg: revealPathMsg{Path: name}})
+	}
+
+	panel := NewListPanel("🔗 Hard Links: "+filepath.Base(msg.Path), entries)
+	panel.Subtitle = fmt.Sprintf("%d links", msg.Links)
+	switch {
+	case msg.Links < 2:
+		panel.StatusMessage = "The file has no other names"
+	case msg.Searched != "" && len(msg.Names) < msg.Links:
+		panel.StatusMessage = fmt.Sprintf("Found %d of %d names below %s; the others are not readable", len(msg.Names), msg.Links, msg.Searched)
+	case msg.Searched != "":
+		panel.StatusMessage = fmt.Sprintf("Found by searching %s", msg.Searched)
+	}
+	m.openList(panel)
+}
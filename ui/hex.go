@@ -0,0 +1,223 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+)
+
+// hexBytesPerLine is the number of bytes in each line of a hex dump
+const hexBytesPerLine = 16
+
+// binarySniffSize is how much of the content is looked at to tell binary
+// files from text
+const binarySniffSize = 8 << 10
+
+// isBinary reports whether content looks like binary data rather than text: it
+// contains NUL bytes, or more than a tenth of it is control characters or bytes
+// that are not valid UTF-8. Text in legacy code pages has few such bytes.
+func isBinary(content string) bool {
+	sample := content[:min(len(content), binarySniffSize)]
+	if strings.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+
+	bad := 0
+	for i := 0; i < len(sample); {
+		r, size := utf8.DecodeRuneInString(sample[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			// A character cut off by the end of the sample is not an error
+			if len(sample) == len(content) || len(sample)-i >= utf8.UTFMax {
+				bad++
+			}
+		case r < 0x20 && !strings.ContainsRune("\t\n\r\f\v\b\x1b", r):
+			bad++
+		}
+		i += size
+	}
+	return bad*10 > len(sample)
+}
+
+// hexDump formats data as lines of offset, hex bytes and printable characters.
+// base is the file offset of the first byte.
+func hexDump(data []byte, base int64) []string {
+	const digits = "0123456789abcdef"
+	lines := make([]string, 0, (len(data)+hexBytesPerLine-1)/hexBytesPerLine)
+	line := make([]byte, 0, 80)
+	for start := 0; start < len(data); start += hexBytesPerLine {
+		row := data[start:min(start+hexBytesPerLine, len(data))]
+		offset := strconv.FormatInt(base+int64(start), 16)
+		line = append(line[:0], "00000000"[min(len(offset), 8):]...)
+		line = append(line, offset...)
+		line = append(line, "  "...)
+		for i := 0; i < hexBytesPerLine; i++ {
+			if i == hexBytesPerLine/2 {
+				line = append(line, ' ')
+			}
+			if i < len(row) {
+				line = append(line, digits[row[i]>>4], digits[row[i]&0xf], ' ')
+			} else {
+				line = append(line, "   "...)
+			}
+		}
+		line = append(line, " |"...)
+		for _, c := range row {
+			if c < 0x20 || c > 0x7e {
+				c = '.'
+			}
+			line = append(line, c)
+		}
+		line = append(line, '|')
+		lines = append(lines, string(line))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, fmt.Sprintf("%08x", base))
+	}
+	return lines
+}
+
+// setHexContent shows data as a hex dump
+func (fv *FileViewer) setHexContent(data []byte) {
+	fv.hex = true
+	fv.raw = data
+	fv.Content = hexDump(data, fv.part.Start)
+	fv.spans = nil
+	fv.styledLines = nil
+	fv.overstrike = false
+	fv.markdown = nil
+	fv.tree = nil
+	fv.table = nil
+	fv.highlighter = nil
+	fv.fileType = ""
+}
+
+// NewHexViewer creates a viewer showing a file as a hex dump, whatever it
+// holds. Files over maxViewSize are read a window at a time, as in text.
+func NewHexViewer(filePath, fileName string) FileViewer {
+	fv := FileViewer{
+		FilePath:           filePath,
+		FileName:           fileName,
+		UseSyntaxHighlight: true,
+		SearchMatches:      []int{},
+		CurrentMatchIndex:  -1,
+		hex:                true,
+	}
+	fv.loadFile()
+	return fv
+}
+
+// toggleHex switches between the hex dump and the text of the content (:hex)
+func (fv *FileViewer) toggleHex() {
+	if fv.window != nil {
+		fv.toggleWindowedHex()
+		return
+	}
+	fv.ScrollPos = 0
+	fv.clearSearch()
+
+	if fv.hex {
+		// Show the bytes as text, even though they looked binary
+		fv.hex = false
+		fv.forceText = true
+		content := string(fv.raw)
+		fv.raw = nil
+		fv.setContent(content)
+		fv.StatusMessage = "Showing text (:hex for the hex dump)"
+		return
+	}
+
+	data, err := fv.rawBytes()
+	if err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	fv.forceText = false
+	fv.setHexContent(data)
+	fv.StatusMessage = "Showing hex dump (:hex for text)"
+}
+
+// toggleWindowedHex switches a file loaded as it is scrolled between the hex
+// dump and its text, reloading the window where the top line of the view is:
+// at its row of the dump, or at the first line of text starting in the row
+func (fv *FileViewer) toggleWindowedHex() {
+	at := fv.lineOffset(fv.lineAt(fv.ScrollPos))
+	fv.clearSearch()
+	fv.hex = !fv.hex
+	fv.forceText = !fv.hex
+	start := at / hexBytesPerLine * hexBytesPerLine
+	if !fv.hex {
+		start = fv.rowLineStart(at)
+	}
+	if err := fv.loadWindow(start, -1); err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	fv.ScrollPos = 0
+	if fv.hex {
+		fv.StatusMessage = "Showing hex dump (:hex for text)"
+	} else {
+		fv.StatusMessage = "Showing text (:hex for the hex dump)"
+	}
+}
+
+// rowLineStart returns where the first line of text starting in the hex dump
+// row at offset starts, or the start of the line the row is in if none does
+func (fv *FileViewer) rowLineStart(row int64) int64 {
+	if row == 0 {
+		return 0
+	}
+	// A line starts in the row after a line break from the byte before it on
+	data, err := fv.readRange(row-1, min(row+hexBytesPerLine-1, fv.part.Total))
+	if err == nil {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			return row + int64(i)
+		}
+	}
+	return fv.lineStart(row)
+}
+
+// lineStart returns the offset of the start of the line of text holding the
+// byte at offset, looking back up to tailReadSize bytes for it
+func (fv *FileViewer) lineStart(offset int64) int64 {
+	from := max(0, offset-tailReadSize)
+	data, err := fv.readRange(from, offset)
+	if err != nil {
+		return offset
+	}
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		return from + int64(i+1)
+	}
+	if from == 0 {
+		return 0
+	}
+	return offset
+}
+
+// rawBytes returns the bytes of the content as stored: the file, or the part of
+// it shown, or the text of in-memory content
+func (fv *FileViewer) rawBytes() ([]byte, error) {
+	if fv.FilePath == "" {
+		return []byte(strings.Join(fv.Content, "\n")), nil
+	}
+	if fv.part.Total == 0 {
+		return vfs.ReadFile(vfs.Default, fv.FilePath)
+	}
+
+	file, err := vfs.Default.Open(fv.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data := make([]byte, fv.part.Size)
+	n, err := file.ReadAt(data, fv.part.Start)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return data[:n], nil
+}
@@ -0,0 +1,360 @@
+package ui
+
+import (
+	"bytes"
+	"embed"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// highlightChunkLines is the number of lines highlighted per chunk
+const highlightChunkLines = 500
+
+// prewarmFiles is the number of files in the starting directory whose lexers are
+// looked up and compiled ahead of the first file view
+const prewarmFiles = 50
+
+// syntaxStyles are the viewer's colorschemes, named after their files, copied
+// from the chroma module by go generate; TestColorschemesMatchChroma fails
+// once they differ from the chroma in go.mod. Importing chroma's style
+// registry would parse every bundled style at startup.
+//
+//go:generate go run copystyles.go
+//go:embed colorschemes/*.xml
+var syntaxStyles embed.FS
+
+// parsedStyles caches the parsed syntax styles by name
+var parsedStyles sync.Map
+
+// colorscheme is the chroma style picked with :set colorscheme or the
+// colorscheme setting; empty follows the theme
+var colorscheme string
+
+// highlightStyle returns the chroma style the viewer highlights with
+func highlightStyle() *chroma.Style {
+	return syntaxStyle(activeColorscheme())
+}
+
+// activeColorscheme names the colorscheme in use
+func activeColorscheme() string {
+	if colorscheme != "" {
+		return colorscheme
+	}
+	return theme.Syntax
+}
+
+// syntaxStyle returns an embedded chroma style, parsing it on first use
+func syntaxStyle(name string) *chroma.Style {
+	if cached, ok := parsedStyles.Load(name); ok {
+		return cached.(*chroma.Style)
+	}
+	style := chroma.MustNewStyle("plain", chroma.StyleEntries{})
+	if data, err := syntaxStyles.ReadFile("colorschemes/" + name + ".xml"); err == nil {
+		if parsed, err := chroma.NewXMLStyle(bytes.NewReader(data)); err == nil {
+			style = parsed
+		}
+	}
+	parsedStyles.Store(name, style)
+	return style
+}
+
+// colorschemeNames returns the names of the embedded colorschemes, sorted
+func colorschemeNames() []string {
+	entries, _ := syntaxStyles.ReadDir("colorschemes")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".xml"))
+	}
+	return names
+}
+
+// colorProfile returns the colors the terminal shows, as detected or as
+// :calibrate found. Output that is not a terminal keeps 24-bit colors.
+func colorProfile() termenv.Profile {
+	if profile := lipgloss.ColorProfile(); profile != termenv.Ascii {
+		return profile
+	}
+	return termenv.TrueColor
+}
+
+// highlightFormatter returns the chroma formatter for the colors the terminal
+// shows: 24-bit, 256 or 16 colors
+func highlightFormatter() chroma.Formatter {
+	name := "terminal16m"
+	switch colorProfile() {
+	case termenv.ANSI256:
+		name = "terminal256"
+	case termenv.ANSI:
+		name = "terminal16"
+	}
+	formatter := formatters.Get(name)
+	if formatter == nil {
+		formatter = formatters.Fallback
+	}
+	return formatter
+}
+
+// lexerMatches caches lexers.Match by file name, as it tries every file pattern
+// of every lexer
+var lexerMatches sync.Map
+
+// matchLexer returns the lexer for a file name, or nil
+func matchLexer(name string) chroma.Lexer {
+	name = filepath.Base(name)
+	if cached, ok := lexerMatches.Load(name); ok {
+		lexer, _ := cached.(chroma.Lexer)
+		return lexer
+	}
+	lexer := lexers.Match(name)
+	lexerMatches.Store(name, lexer)
+	return lexer
+}
+
+// prewarmCmd parses the style and looks up and compiles the lexers of the
+// files in dir in the background, so the first file view does not wait for
+// them
+func prewarmCmd(dir string) tea.Cmd {
+	syntax := activeColorscheme()
+	return func() tea.Msg {
+		syntaxStyle(syntax)
+
+		entries, _ := vfs.Default.ReadDir(dir)
+		compiled := make(map[string]bool)
+		files := 0
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			if files++; files > prewarmFiles {
+				break
+			}
+			lexer := matchLexer(entry.Name())
+			if lexer == nil || compiled[lexer.Config().Name] {
+				continue
+			}
+			compiled[lexer.Config().Name] = true
+			// Lexer rules are compiled on first use
+			lexer.Tokenise(nil, "")
+		}
+		return nil
+	}
+}
+
+// span is a run of a line drawn in one token style. Lines keep only their spans
+// and are formatted when displayed, as formatted lines take several times the
+// memory of the text.
+type span struct {
+	Len  int32 // Length in bytes
+	Type int32 // chroma.TokenType
+}
+
+// highlighter splits a token stream into the spans of each line, a chunk of
+// lines at a time. The lexer's iterator is lazy, so each chunk only tokenizes
+// as far as it needs. Nothing is tokenized until the first chunk, so a viewer
+// can create its highlighter without waiting for the lexer.
+type highlighter struct {
+	lexer    chroma.Lexer // Picked from the content on the first chunk when nil
+	content  string       // Content to tokenize, until the first chunk
+	fileType string       // Name of the lexer, once picked
+	next     chroma.Iterator
+	line     []span        // Spans of the line being built
+	carry    *chroma.Token // Rest of a token that spans several lines
+	start    int           // Index of the next line to produce
+}
+
+// highlightChunkMsg delivers highlighted lines produced in the background
+type highlightChunkMsg struct {
+	Viewer      *FileViewer
+	Highlighter *highlighter
+	Start       int
+	Lines       [][]span
+	Done        bool
+	FileType    string // Name of the lexer used
+}
+
+// newHighlighter prepares to highlight content with lexer, or with one picked
+// from the content if lexer is nil
+func newHighlighter(lexer chroma.Lexer, content string) *highlighter {
+	return &highlighter{lexer: lexer, content: content}
+}
+
+// begin picks the lexer if there is none yet and starts tokenizing
+func (h *highlighter) begin() error {
+	if h.lexer == nil {
+		h.lexer = analyseLexer(h.content)
+	}
+	h.fileType = lexerName(h.lexer)
+	iterator, err := h.lexer.Tokenise(nil, h.content)
+	h.content = ""
+	if err != nil {
+		return err
+	}
+	h.next = iterator
+	return nil
+}
+
+// nextChunk highlights up to n more lines; done reports the end of the content.
+// If tokenizing fails, the content is done and stays plain.
+func (h *highlighter) nextChunk(n int) (start int, lines [][]span, done bool) {
+	start = h.start
+	if h.next == nil {
+		if err := h.begin(); err != nil {
+			return start, nil, true
+		}
+	}
+	for len(lines) < n {
+		var tok chroma.Token
+		if h.carry != nil {
+			tok = *h.carry
+			h.carry = nil
+		} else {
+			tok = h.next()
+		}
+		if tok == chroma.EOF {
+			lines = append(lines, h.endLine())
+			done = true
+			break
+		}
+
+		idx := strings.IndexByte(tok.Value, '\n')
+		if idx < 0 {
+			h.addSpan(tok.Type, len(tok.Value))
+			continue
+		}
+		h.addSpan(tok.Type, idx)
+		lines = append(lines, h.endLine())
+		if rest := tok.Value[idx+1:]; rest != "" {
+			h.carry = &chroma.Token{Type: tok.Type, Value: rest}
+		}
+	}
+	h.start += len(lines)
+	return start, lines, done
+}
+
+// addSpan adds n bytes of a token type to the current line, extending the last
+// span when it has the same type. Spans are not merged by how they look, as
+// that depends on the colorscheme, which can change after they are made.
+func (h *highlighter) addSpan(t chroma.TokenType, n int) {
+	if n == 0 {
+		return
+	}
+	if last := len(h.line) - 1; last >= 0 && chroma.TokenType(h.line[last].Type) == t {
+		h.line[last].Len += int32(n)
+		return
+	}
+	h.line = append(h.line, span{Len: int32(n), Type: int32(t)})
+}
+
+// endLine returns the spans of the current line and starts a new one
+func (h *highlighter) endLine() []span {
+	line := h.line
+	h.line = nil
+	return line
+}
+
+// formatSpans renders a line with the styles of its spans
+func formatSpans(text string, spans []span) string {
+	tokens := make([]chroma.Token, 0, len(spans)+1)
+	pos := 0
+	for _, sp := range spans {
+		end := min(pos+int(sp.Len), len(text))
+		tokens = append(tokens, chroma.Token{Type: chroma.TokenType(sp.Type), Value: text[pos:end]})
+		pos = end
+	}
+	if pos < len(text) {
+		tokens = append(tokens, chroma.Token{Type: chroma.Text, Value: text[pos:]})
+	}
+
+	var buf bytes.Buffer
+	if err := highlightFormatter().Format(&buf, highlightStyle(), chroma.Literator(tokens...)); err != nil {
+		return text
+	}
+	return buf.String()
+}
+
+// chunk highlights the next chunk of a viewer's content
+func (h *highlighter) chunk(fv *FileViewer) highlightChunkMsg {
+	start, lines, done := h.nextChunk(highlightChunkLines)
+	return highlightChunkMsg{Viewer: fv, Highlighter: h, Start: start, Lines: lines, Done: done, FileType: h.fileType}
+}
+
+// highlightNextChunk highlights the next chunk of a viewer's content in the background
+func highlightNextChunk(fv *FileViewer, h *highlighter) tea.Cmd {
+	return func() tea.Msg {
+		defer measure("highlight")()
+		return h.chunk(fv)
+	}
+}
+
+// highlightContent shows the content plain and leaves highlighting it to the
+// background, so opening a file never waits for chroma. A lexer known from the
+// language, name or shebang is named in the header at once; guessing one from
+// the content waits for the first chunk.
+func (fv *FileViewer) highlightContent(content string) {
+	lexer := fv.knownLexer(content)
+	fv.fileType = ""
+	if lexer != nil {
+		fv.fileType = lexerName(lexer)
+	}
+
+	// Lines are shown plain until their spans arrive
+	fv.spans = make([][]span, len(fv.Content))
+	fv.highlightLimit = len(fv.Content)
+	fv.highlightedLines = 0
+	fv.highlighter = newHighlighter(lexer, content)
+	fv.highlighting = false
+}
+
+// startHighlighting requests the next background chunk if highlighting is
+// pending and no chunk is already being highlighted
+func (fv *FileViewer) startHighlighting() tea.Cmd {
+	if fv.highlighter == nil || fv.highlighting {
+		return nil
+	}
+	fv.highlighting = true
+	return highlightNextChunk(fv, fv.highlighter)
+}
+
+// mergeHighlighted replaces plain lines with highlighted ones, leaving lines that
+// changed since highlighting started (appended by follow mode) alone
+func (fv *FileViewer) mergeHighlighted(start int, lines [][]span) {
+	for i, line := range lines {
+		idx := start + i
+		if idx >= fv.highlightLimit || idx >= len(fv.spans) {
+			break
+		}
+		fv.spans[idx] = line
+	}
+	fv.highlightedLines = start + len(lines)
+}
+
+// highlightProgress returns how much of the content has been highlighted, in percent
+func (fv *FileViewer) highlightProgress() int {
+	if fv.highlighter == nil || len(fv.Content) == 0 {
+		return 100
+	}
+	return min(fv.highlightedLines*100/len(fv.Content), 99)
+}
+
+// receiveHighlight merges a highlighted chunk, dropping chunks of replaced
+// highlighters
+func (fv *FileViewer) receiveHighlight(msg highlightChunkMsg) {
+	if msg.Highlighter != fv.highlighter {
+		return
+	}
+	fv.mergeHighlighted(msg.Start, msg.Lines)
+	fv.fileType = msg.FileType
+	fv.highlighting = false
+	if msg.Done {
+		fv.highlighter = nil
+	}
+}
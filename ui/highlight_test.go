@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// TestSpansFollowColorscheme highlights code under one colorscheme, switches
+// to others and checks each line is drawn as if it had been highlighted
+// under the new one
+func TestSpansFollowColorscheme(t *testing.T) {
+	defer setColorscheme("")
+	code := strings.Join([]string{
+		"package main",
+		"",
+		"import \"fmt\"",
+		"",
+		"// greet says hello",
+		"func greet(name string) (int, error) {",
+		"\tvar count int = 0x1f + 2",
+		"\treturn fmt.Println(\"hello\", name, count, true, nil)",
+		"}",
+	}, "\n")
+	lines := strings.Split(code, "\n")
+	highlight := func() [][]span {
+		_, spans, _ := newHighlighter(lexers.Get("go"), code).nextChunk(len(lines) + 1)
+		return spans
+	}
+
+	for _, first := range []string{"monokai", "github", "dracula"} {
+		if err := setColorscheme(first); err != nil {
+			t.Fatal(err)
+		}
+		spans := highlight()
+		for _, next := range []string{"monokai", "github", "dracula", "nord", "solarized-light", "vim"} {
+			if err := setColorscheme(next); err != nil {
+				t.Fatal(err)
+			}
+			fresh := highlight()
+			for i, line := range lines {
+				if got, want := formatSpans(line, spans[i]), formatSpans(line, fresh[i]); got != want {
+					t.Errorf("%s then %s, line %d: drawn as %q, want %q", first, next, i+1, got, want)
+				}
+			}
+		}
+	}
+}
+
+// TestColorschemesMatchChroma checks the embedded colorschemes are the styles
+// of the chroma in go.mod, so an upgrade that changes them is not missed.
+// go generate in ui copies them again.
+func TestColorschemesMatchChroma(t *testing.T) {
+	names := colorschemeNames()
+	if len(names) != len(styles.Registry) {
+		t.Errorf("%d colorschemes embedded, chroma has %d styles (run go generate in ui)", len(names), len(styles.Registry))
+	}
+	for _, name := range names {
+		embedded := syntaxStyle(name)
+		bundled, ok := styles.Registry[embedded.Name]
+		if !ok {
+			t.Errorf("colorscheme %s is not a chroma style (run go generate in ui)", name)
+			continue
+		}
+		types := append(embedded.Types(), bundled.Types()...)
+		types = append(types, chroma.Background, chroma.LineNumbers, chroma.LineHighlight)
+		for _, tokenType := range types {
+			if got, want := embedded.Get(tokenType), bundled.Get(tokenType); got != want {
+				t.Errorf("colorscheme %s styles %v as %q, chroma as %q (run go generate in ui)", name, tokenType, got, want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package ui
+
+import "slices"
+
+// maxHistory is the number of directories kept to go back to
+const maxHistory = 50
+
+// dirHistory is the back and forward stacks of a pane, like a web browser's.
+// Unlike the jump list, every directory change is kept in order, and going
+// somewhere new drops the forward stack.
+type dirHistory struct {
+	back    []jumpLocation // Directories left, the most recent last
+	forward []jumpLocation // Directories gone back from, the most recent last
+}
+
+// push records a directory that was left for somewhere new
+func (h *dirHistory) push(loc jumpLocation) {
+	back := append(slices.Clip(h.back), loc)
+	if len(back) > maxHistory {
+		back = back[len(back)-maxHistory:]
+	}
+	h.back = back
+	h.forward = nil
+}
+
+// historyBack returns to the directory browsed before this one, selecting the
+// item that was selected there (Alt+Left / H)
+func (m *Model) historyBack() {
+	if len(m.history.back) == 0 {
+		m.setStatus("No earlier directory in the history")
+		return
+	}
+	last := len(m.history.back) - 1
+	loc := m.history.back[last]
+	m.history.back = m.history.back[:last]
+	m.history.forward = append(slices.Clip(m.history.forward), m.location())
+	m.gotoHistory(loc)
+}
+
+// historyForward goes to the directory left with historyBack (Alt+Right / L)
+func (m *Model) historyForward() {
+	if len(m.history.forward) == 0 {
+		m.setStatus("No later directory in the history")
+		return
+	}
+	last := len(m.history.forward) - 1
+	loc := m.history.forward[last]
+	m.history.forward = m.history.forward[:last]
+	m.history.back = append(slices.Clip(m.history.back), m.location())
+	m.gotoHistory(loc)
+}
+
+// gotoHistory browses a directory from the history without recording it
+func (m *Model) gotoHistory(loc jumpLocation) {
+	m.historyMoved = true
+	m.StatusMessage = ""
+	m.CurrentPath = loc.Dir
+	m.loadDirectory()
+	m.selectPath(loc.Item)
+}
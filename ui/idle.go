@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultMaxFPS is the frame rate used when the max_fps setting is 0
+const defaultMaxFPS = 30
+
+// maxFPS is the compiled max_fps setting
+var maxFPS = defaultMaxFPS
+
+// MaxFPS returns the most frames a second the screen is redrawn at, from the
+// max_fps setting
+func MaxFPS() int {
+	return maxFPS
+}
+
+// setMaxFPS applies the max_fps setting: 1 to 120 frames a second, or 0 for
+// the default
+func setMaxFPS(fps int) error {
+	switch {
+	case fps == 0:
+		maxFPS = defaultMaxFPS
+	case fps < 0 || fps > 120:
+		maxFPS = defaultMaxFPS
+		return fmt.Errorf("invalid max_fps %d (use 1 to 120)", fps)
+	default:
+		maxFPS = fps
+	}
+	return nil
+}
+
+// compileIdleSuspend parses the idle_suspend setting
+func compileIdleSuspend(setting string) (time.Duration, error) {
+	if setting == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(setting)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid idle_suspend %q (use a duration such as 5m)", setting)
+	}
+	return d, nil
+}
+
+// frameCache holds the last frame drawn, so updates that change nothing do
+// not draw it again. It is shared by the copies of the model.
+type frameCache struct {
+	view  string
+	valid bool
+}
+
+// idle reports whether no key has been pressed for the idle_suspend time
+func (m *Model) idle() bool {
+	return m.idleSuspend > 0 && time.Since(m.lastInput) >= m.idleSuspend
+}
+
+// suspendWhileIdle holds back the timers that poll in the background while
+// no key has been pressed for a while: checks of viewed files, the system
+// information panel and the progress of jobs. A followed file keeps being
+// polled, as it is meant to be watched without typing. The idle check stops
+// too once the screen is locked. Held back ticks are handled when a key is
+// pressed again, which catches up with whatever changed meanwhile.
+func (m *Model) suspendWhileIdle(msg tea.Msg) bool {
+	switch msg := msg.(type) {
+	case watchTickMsg:
+		if msg.Viewer.Following {
+			return false
+		}
+	case followTickMsg:
+		if msg.Viewer.Following {
+			return false
+		}
+	case sysinfoMsg, jobTickMsg:
+	case idleCheckMsg:
+		if m.lock == nil {
+			return false
+		}
+	default:
+		return false
+	}
+	if !m.idle() {
+		return false
+	}
+	m.suspended = append(m.suspended, msg)
+	return true
+}
+
+// resumeTimers handles the ticks held back while idle
+func (m *Model) resumeTimers() tea.Cmd {
+	if len(m.suspended) == 0 {
+		return nil
+	}
+	cmds := make([]tea.Cmd, len(m.suspended))
+	for i, msg := range m.suspended {
+		cmds[i] = func() tea.Msg { return msg }
+	}
+	m.suspended = nil
+	return tea.Batch(cmds...)
+}
@@ -0,0 +1,173 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/picture"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// imageLoadedMsg delivers the decoded image of a preview
+type imageLoadedMsg struct {
+	Preview *ImagePreview
+	Image   image.Image
+	Size    int64
+	Err     error
+}
+
+// ImagePreview shows an image as large as the window allows, over the
+// browser (Enter in the thumbnail grid, :slideshow)
+type ImagePreview struct {
+	Path      string
+	Index     int // Position among the listed images
+	Count     int // Number of listed images
+	Width     int
+	Height    int
+	Slideshow bool          // Whether the next image follows after Delay
+	Delay     time.Duration // Time each image of a slideshow is shown
+
+	img    image.Image // Decoded image, nil while loading
+	size   int64       // Size of the file
+	err    error
+	lines  []string // Image drawn for Width and Height
+	seq    int      // Counts scheduled slideshow ticks so stale ones are ignored
+	status string   // Message shown under the image
+}
+
+// openImagePreview shows the image at path, which is listed in the browser,
+// decoding it in the background
+func (m *Model) openImagePreview(path string) tea.Cmd {
+	p := &ImagePreview{Path: path, Width: m.Width, Height: m.Height}
+	for _, item := range m.Items {
+		if item.IsDir || !picture.IsImage(item.Name) {
+			continue
+		}
+		if item.Path == path {
+			p.Index = p.Count
+		}
+		p.Count++
+	}
+	m.Preview = p
+	return func() tea.Msg {
+		data, err := vfs.ReadFile(vfs.Default, path)
+		if err != nil {
+			return imageLoadedMsg{Preview: p, Err: err}
+		}
+		img, err := picture.Decode(data)
+		return imageLoadedMsg{Preview: p, Image: img, Size: int64(len(data)), Err: err}
+	}
+}
+
+// showImage fills in the preview a decoded image was loaded for, if it is
+// still shown. In a slideshow the next image is due a delay after this one
+// appears, however long it took to load.
+func (m *Model) showImage(msg imageLoadedMsg) tea.Cmd {
+	if msg.Preview != m.Preview {
+		return nil
+	}
+	m.Preview.img, m.Preview.size, m.Preview.err = msg.Image, msg.Size, msg.Err
+	m.Preview.draw()
+	if m.Preview.Slideshow {
+		return m.Preview.nextSlide()
+	}
+	return nil
+}
+
+// resize draws the image again for a new window size
+func (p *ImagePreview) resize(width, height int) {
+	p.Width, p.Height = width, height
+	p.draw()
+}
+
+// draw draws the image as large as fits between the title and the help line
+func (p *ImagePreview) draw() {
+	p.lines = nil
+	if p.img == nil {
+		return
+	}
+	// Two lines are left for the slideshow's status
+	cols, rows := picture.Fit(p.img, p.Width, p.Height-7)
+	if cols == 0 {
+		return
+	}
+	left := strings.Repeat(" ", (p.Width-cols)/2)
+	for _, line := range picture.Draw(p.img, cols, rows) {
+		p.lines = append(p.lines, left+line)
+	}
+}
+
+// updatePreview handles keys while an image is previewed: n and p (or Right
+// and Left) show the next and previous listed image, s starts or stops the
+// slideshow, and Esc, q or Enter go back to the browser
+func (m *Model) updatePreview(msg tea.KeyMsg) tea.Cmd {
+	m.Preview.status = ""
+	switch keymap.translate("preview", msg.String()) {
+	case "esc", "q", "enter":
+		m.Preview = nil
+	case "ctrl+c":
+		return m.quit()
+	case "n", "right", "l", " ":
+		return m.stepImage(1)
+	case "p", "left", "h", "backspace":
+		return m.stepImage(-1)
+	case "s":
+		return m.toggleSlideshow()
+	case "+", "=":
+		return m.changeSlideDelay(time.Second)
+	case "-":
+		return m.changeSlideDelay(-time.Second)
+	}
+	return nil
+}
+
+// stepImage moves the browser cursor to the next listed image in direction
+// delta, wrapping around, and previews it. A running slideshow carries on
+// from there.
+func (m *Model) stepImage(delta int) tea.Cmd {
+	prev := m.Preview
+	n := len(m.Items)
+	for step := 1; step <= n; step++ {
+		i := ((m.Cursor+delta*step)%n + n) % n
+		if item := m.Items[i]; !item.IsDir && picture.IsImage(item.Name) {
+			m.Cursor = i
+			cmd := m.openImagePreview(item.Path)
+			m.Preview.Slideshow, m.Preview.Delay = prev.Slideshow, prev.Delay
+			return cmd
+		}
+	}
+	return nil
+}
+
+// View renders the preview
+func (p ImagePreview) View() string {
+	var b strings.Builder
+	title := fmt.Sprintf("🖼 %s", filepath.Base(p.Path))
+	if p.Count > 1 {
+		title += fmt.Sprintf(" (%d of %d)", p.Index+1, p.Count)
+	}
+	b.WriteString(theme.Title.Render(title) + "\n")
+
+	switch {
+	case p.err != nil:
+		b.WriteString(fmt.Sprintf("Error: %v\n", p.err))
+	case p.img == nil:
+		b.WriteString(theme.Dim.Render("Loading...") + "\n")
+	default:
+		bounds := p.img.Bounds()
+		b.WriteString(theme.Dim.Render(fmt.Sprintf("%d×%d, %s", bounds.Dx(), bounds.Dy(), FormatSize(p.size))) + "\n")
+		for _, line := range p.lines {
+			b.WriteString(line + "\n")
+		}
+	}
+
+	if label := p.slideshowLabel(); label != "" || p.status != "" {
+		b.WriteString(theme.Status.Render(strings.TrimSpace(label+"  "+p.status)) + "\n")
+	}
+	b.WriteString(theme.Help.Render(keymap.help(previewHelp...)))
+	return b.String()
+}
@@ -0,0 +1,373 @@
+package ui
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+)
+
+// includeRule finds the file references of one kind of include, import or
+// require statement
+type includeRule struct {
+	exts    []string                       // File extensions or names the rule applies to
+	pattern *regexp.Regexp                 // Statement, with the reference as the last group that matched
+	resolve func(dir, ref string) []string // Paths the reference may name, most likely first
+}
+
+// includeRules are the statements gf follows, by language
+var includeRules = []includeRule{
+	// C and C++: #include "x.h" and <x.h>
+	{
+		exts:    []string{".c", ".h", ".cc", ".cpp", ".cxx", ".hpp", ".hh", ".hxx", ".inl", ".rc", ".m", ".mm"},
+		pattern: regexp.MustCompile(`^\s*#\s*(?:include|import)\s*[<"]([^>"]+)[>"]`),
+		resolve: func(dir, ref string) []string {
+			return ancestorPaths(dir, ref, filepath.Join("include", ref))
+		},
+	},
+	// Go: import "path", alias "path" and the lines of an import block
+	{
+		exts:    []string{".go"},
+		pattern: regexp.MustCompile(`^\s*(?:import\s+)?(?:[\w.]+\s+)?"([\w./~-]+)"\s*(?://.*)?$`),
+		resolve: goPackagePaths,
+	},
+	// Python: import a.b, from a.b import c, from . import c
+	{
+		exts:    []string{".py", ".pyw", ".pyi"},
+		pattern: regexp.MustCompile(`^\s*(?:from\s+(\.*[\w.]*)\s+import\b|import\s+([\w.]+))`),
+		resolve: pythonModulePaths,
+	},
+	// JavaScript and TypeScript: import ... from 'x', import('x'), require('x'), export ... from 'x'
+	{
+		exts:    []string{".js", ".mjs", ".cjs", ".jsx", ".ts", ".mts", ".cts", ".tsx", ".vue", ".svelte"},
+		pattern: regexp.MustCompile(`(?:\bfrom\s*|\bimport\s*\(?\s*|\brequire\s*\(\s*)['"]([^'"]+)['"]`),
+		resolve: scriptModulePaths,
+	},
+	// CSS, SCSS and Less: @import "x", @use "x", @import url(x)
+	{
+		exts:    []string{".css", ".scss", ".sass", ".less"},
+		pattern: regexp.MustCompile(`^\s*@(?:import|use|forward)\s+(?:url\(\s*)?['"]?([^'")\s;]+)`),
+		resolve: stylesheetPaths,
+	},
+	// Rust: mod name;
+	{
+		exts:    []string{".rs"},
+		pattern: regexp.MustCompile(`^\s*(?:pub(?:\([\w:]+\))?\s+)?mod\s+(\w+)\s*;`),
+		resolve: func(dir, ref string) []string {
+			return []string{filepath.Join(dir, ref+".rs"), filepath.Join(dir, ref, "mod.rs")}
+		},
+	},
+	// Lua: require("a.b")
+	{
+		exts:    []string{".lua"},
+		pattern: regexp.MustCompile(`\brequire\s*\(?\s*['"]([\w.]+)['"]`),
+		resolve: func(dir, ref string) []string {
+			base := filepath.FromSlash(strings.ReplaceAll(ref, ".", "/"))
+			return ancestorPaths(dir, base+".lua", filepath.Join(base, "init.lua"))
+		},
+	},
+	// Ruby: require_relative 'x', require 'x'
+	{
+		exts:    []string{".rb", ".rake", "Gemfile", "Rakefile"},
+		pattern: regexp.MustCompile(`^\s*require(?:_relative)?\s*\(?\s*['"]([^'"]+)['"]`),
+		resolve: func(dir, ref string) []string {
+			return withExtensions(filepath.Join(dir, filepath.FromSlash(ref)), ".rb")
+		},
+	},
+	// Shell: source file, . file
+	{
+		exts:    []string{".sh", ".bash", ".zsh", ".ksh", ".bashrc", ".zshrc", ".profile", ".bash_profile"},
+		pattern: regexp.MustCompile(`^\s*(?:source|\.)\s+['"]?([^'"\s;&|]+)`),
+		resolve: relativePath,
+	},
+	// PowerShell: . .\script.ps1, Import-Module .\Module.psm1
+	{
+		exts:    []string{".ps1", ".psm1", ".psd1"},
+		pattern: regexp.MustCompile(`(?i)^\s*(?:\.|import-module(?:\s+-name)?)\s+['"]?([^'"\s;]+)`),
+		resolve: func(dir, ref string) []string {
+			ref = strings.TrimPrefix(ref, "$PSScriptRoot")
+			return withExtensions(filepath.Join(dir, slashPath(ref)), ".ps1", ".psm1", ".psd1")
+		},
+	},
+	// Batch files: call other.cmd
+	{
+		exts:    []string{".bat", ".cmd"},
+		pattern: regexp.MustCompile(`(?i)^\s*@?call\s+"?([^"\s:][^"\s]*)`),
+		resolve: func(dir, ref string) []string {
+			ref = strings.TrimPrefix(ref, "%~dp0")
+			return withExtensions(filepath.Join(dir, slashPath(ref)), ".cmd", ".bat")
+		},
+	},
+	// MSBuild: <Import Project="x.props"/>, ProjectReference and solution projects
+	{
+		exts:    []string{".csproj", ".vbproj", ".fsproj", ".vcxproj", ".proj", ".props", ".targets", ".sln"},
+		pattern: regexp.MustCompile(`(?:\b(?:Project|Include)="|, ")([^"*;]+\.(?:\w+proj|props|targets|vcxitems|projitems))"`),
+		resolve: func(dir, ref string) []string {
+			ref = strings.TrimPrefix(ref, "$(MSBuildThisFileDirectory)")
+			ref = strings.TrimPrefix(ref, "$(SolutionDir)")
+			return []string{filepath.Join(dir, slashPath(ref))}
+		},
+	},
+	// HTML: <script src="x.js">, <link href="x.css">
+	{
+		exts:    []string{".html", ".htm", ".xhtml"},
+		pattern: regexp.MustCompile(`(?i)<(?:script|link|img|iframe)\b[^>]*\b(?:src|href)\s*=\s*['"]([^'"#?]+)`),
+		resolve: relativePath,
+	},
+	// Makefiles and configuration files: include file, Include file
+	{
+		exts:    []string{"Makefile", "makefile", "GNUmakefile", ".mk", ".mak", ".conf", ".cfg", "config"},
+		pattern: regexp.MustCompile(`(?i)^\s*-?s?include\s+['"]?([^'"\s;]+)`),
+		resolve: relativePath,
+	},
+}
+
+// includeRulesFor returns the rules of a file's language
+func includeRulesFor(name string) []*includeRule {
+	base := filepath.Base(name)
+	ext := strings.ToLower(filepath.Ext(base))
+	var rules []*includeRule
+	for i := range includeRules {
+		for _, e := range includeRules[i].exts {
+			if e == base || (strings.HasPrefix(e, ".") && e == ext) {
+				rules = append(rules, &includeRules[i])
+				break
+			}
+		}
+	}
+	return rules
+}
+
+// findIncludes returns the references of the include statements in a line
+func findIncludes(line string, rules []*includeRule) []link {
+	var links []link
+	for _, rule := range rules {
+		m := rule.pattern.FindStringSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+		// The reference is the last group that matched
+		for g := len(m)/2 - 1; g > 0; g-- {
+			if m[2*g] < 0 {
+				continue
+			}
+			ref := line[m[2*g]:m[2*g+1]]
+			if !strings.Contains(ref, "://") && !strings.HasPrefix(ref, "//") {
+				links = append(links, link{Start: m[2*g], End: m[2*g+1], Target: ref, rule: rule})
+			}
+			break
+		}
+		break
+	}
+	return links
+}
+
+// withIncludes adds the include references of a line to its links, replacing
+// the paths found inside them
+func withIncludes(links, includes []link) []link {
+	if len(includes) == 0 {
+		return links
+	}
+	var kept []link
+	for _, l := range links {
+		overlaps := false
+		for _, inc := range includes {
+			if l.Start < inc.End && inc.Start < l.End {
+				overlaps = true
+			}
+		}
+		if !overlaps {
+			kept = append(kept, l)
+		}
+	}
+	return mergeLinks(kept, includes)
+}
+
+// resolveInclude returns the first existing file or directory an include
+// reference names, relative to the viewed file
+func (fv *FileViewer) resolveInclude(l link) (string, bool) {
+	dir := "."
+	if fv.FilePath != "" {
+		dir = filepath.Dir(fv.FilePath)
+	}
+	for _, path := range l.rule.resolve(dir, l.Target) {
+		if _, err := vfs.Default.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// followInclude opens the file of the selected link, or of the first include
+// statement on screen (gf)
+func (fv *FileViewer) followInclude() {
+	if fv.selectedLink == nil {
+		for _, i := range fv.visibleLines() {
+			if index := includeIndex(fv.linksOf(i)); index >= 0 {
+				fv.selectedLink = &linkRef{Line: i, Index: index}
+				break
+			}
+		}
+	}
+	if fv.selectedLink == nil {
+		fv.StatusMessage = "No include or import statement on screen"
+		return
+	}
+	fv.openLink()
+}
+
+// includeIndex returns the index of the first include reference among links,
+// or -1
+func includeIndex(links []link) int {
+	for i, l := range links {
+		if l.rule != nil {
+			return i
+		}
+	}
+	return -1
+}
+
+// scrollMark is where the view was before g jumped to the top, so that gf can
+// return there before following an include
+type scrollMark struct {
+	First int // First line loaded, which changes if g loaded another window
+	Row   int
+}
+
+// slashPath converts the backslashes of a Windows path to the separator of this
+// system
+func slashPath(path string) string {
+	if filepath.Separator == '/' {
+		return strings.ReplaceAll(path, `\`, "/")
+	}
+	return path
+}
+
+// relativePath resolves a reference relative to the file's directory
+func relativePath(dir, ref string) []string {
+	return []string{filepath.Join(dir, slashPath(ref))}
+}
+
+// withExtensions returns path, then path with each extension added
+func withExtensions(path string, exts ...string) []string {
+	paths := []string{path}
+	for _, ext := range exts {
+		paths = append(paths, path+ext)
+	}
+	return paths
+}
+
+// ancestorPaths looks for each reference in dir and the directories above it,
+// as include paths and module roots are usually further up
+func ancestorPaths(dir string, refs ...string) []string {
+	var paths []string
+	for d := dir; ; d = filepath.Dir(d) {
+		for _, ref := range refs {
+			paths = append(paths, filepath.Join(d, ref))
+		}
+		if filepath.Dir(d) == d {
+			return paths
+		}
+	}
+}
+
+// pythonModulePaths resolves a module name: leading dots count package levels
+// up from the file, otherwise the module is looked for from the file upward
+func pythonModulePaths(dir, ref string) []string {
+	name := strings.TrimLeft(ref, ".")
+	base := filepath.FromSlash(strings.ReplaceAll(name, ".", "/"))
+	refs := []string{base + ".py", filepath.Join(base, "__init__.py"), base + ".pyi"}
+	if name == "" {
+		refs = []string{"__init__.py"}
+	}
+	if dots := len(ref) - len(name); dots > 0 {
+		for range dots - 1 {
+			dir = filepath.Dir(dir)
+		}
+		paths := make([]string, len(refs))
+		for i, r := range refs {
+			paths[i] = filepath.Join(dir, r)
+		}
+		return paths
+	}
+	return ancestorPaths(dir, refs...)
+}
+
+// scriptModuleExtensions are tried after a JavaScript or TypeScript module path
+var scriptModuleExtensions = []string{".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs", ".mts", ".cts", ".json", ".vue", ".svelte"}
+
+// scriptModulePaths resolves a relative module path as written or with the
+// usual extensions and index files, and a package in the nearest node_modules
+func scriptModulePaths(dir, ref string) []string {
+	if !strings.HasPrefix(ref, ".") && !strings.HasPrefix(ref, "/") {
+		return ancestorPaths(dir, filepath.Join("node_modules", filepath.FromSlash(ref)))
+	}
+	base := filepath.Join(dir, filepath.FromSlash(ref))
+	paths := withExtensions(base, scriptModuleExtensions...)
+	for _, ext := range scriptModuleExtensions {
+		paths = append(paths, filepath.Join(base, "index"+ext))
+	}
+	// A directory without an index file is browsed
+	return append(paths[1:], base)
+}
+
+// stylesheetPaths resolves a stylesheet import, including Sass partials
+// (_name.scss) and imports without an extension
+func stylesheetPaths(dir, ref string) []string {
+	base := filepath.Join(dir, filepath.FromSlash(ref))
+	partial := filepath.Join(filepath.Dir(base), "_"+filepath.Base(base))
+	var paths []string
+	for _, p := range []string{base, partial} {
+		paths = append(paths, withExtensions(p, ".scss", ".sass", ".css", ".less")...)
+	}
+	return paths
+}
+
+// goPackagePaths resolves an import path to the directory of the package: in
+// the module the file belongs to, its vendor directory, or the standard library
+func goPackagePaths(dir, ref string) []string {
+	var paths []string
+	if root, module := goModule(dir); root != "" {
+		if rest, ok := strings.CutPrefix(ref, module); ok && (rest == "" || rest[0] == '/') {
+			paths = append(paths, filepath.Join(root, filepath.FromSlash(rest)))
+		}
+		paths = append(paths, filepath.Join(root, "vendor", filepath.FromSlash(ref)))
+	}
+	if root := goRoot(); root != "" {
+		paths = append(paths, filepath.Join(root, "src", filepath.FromSlash(ref)))
+	}
+	return paths
+}
+
+// goRoot returns the standard library's root, asking the installed go command
+// on first use
+var goRoot = sync.OnceValue(func() string {
+	out, err := exec.Command("go", "env", "GOROOT").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+})
+
+// goModule finds the go.mod above dir, returning its directory and module path
+func goModule(dir string) (string, string) {
+	for d := dir; ; d = filepath.Dir(d) {
+		if data, err := vfs.ReadFile(vfs.Default, filepath.Join(d, "go.mod")); err == nil {
+			scanner := bufio.NewScanner(bytes.NewReader(data))
+			for scanner.Scan() {
+				if module, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+					return d, strings.Trim(strings.TrimSpace(module), `"`)
+				}
+			}
+			return d, ""
+		}
+		if filepath.Dir(d) == d {
+			return "", ""
+		}
+	}
+}
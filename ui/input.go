@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxPasteLength limits how much pasted text a single-line input accepts
+const maxPasteLength = 4096
+
+// inputText returns the text a key message adds to a single-line input: typed
+// characters, or pasted text with line breaks and tabs turned into spaces and
+// other control characters removed. It returns "" for keys that insert nothing.
+func inputText(msg tea.KeyMsg) string {
+	switch msg.Type {
+	case tea.KeySpace:
+		return " "
+	case tea.KeyRunes:
+		if msg.Alt {
+			return ""
+		}
+	default:
+		return ""
+	}
+
+	text := string(msg.Runes)
+	if !msg.Paste && utf8.RuneCountInString(text) == 1 {
+		if unicode.IsControl([]rune(text)[0]) {
+			return ""
+		}
+		return text
+	}
+	return sanitizePaste(text)
+}
+
+// sanitizePaste makes pasted text safe for a single-line input
+func sanitizePaste(text string) string {
+	text = stripANSI(text)
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.TrimRight(text, "\r\n")
+
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\n' || r == '\r' || r == '\t':
+			b.WriteRune(' ')
+		case unicode.IsControl(r) || r == utf8.RuneError:
+			// Drop other control characters
+		default:
+			b.WriteRune(r)
+		}
+		if b.Len() >= maxPasteLength {
+			break
+		}
+	}
+	return b.String()
+}
+
+// trimLastRune removes the last character of s, keeping multi-byte characters whole
+func trimLastRune(s string) string {
+	if s == "" {
+		return s
+	}
+	_, size := utf8.DecodeLastRuneInString(s)
+	return s[:len(s)-size]
+}
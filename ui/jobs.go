@@ -0,0 +1,274 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/ops"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// jobTickInterval is how often the progress of running jobs is redrawn
+const jobTickInterval = time.Second
+
+// maxJobLines is how many running jobs are listed above the status line; the
+// rest are counted
+const maxJobLines = 3
+
+// jobsPanelTitle is the title of the :jobs panel, used to refresh it in place
+const jobsPanelTitle = "⏳ Jobs"
+
+// job is a background operation whose progress is shown in the browser
+type job struct {
+	Label    string // What the job does, e.g. "Copying 3 items"
+	Progress *ops.Progress
+}
+
+// jobDoneMsg delivers the result of a job once it ends
+type jobDoneMsg struct {
+	Progress *ops.Progress
+	Msg      tea.Msg
+}
+
+// jobCanceledMsg is the result of a job stopped before it had one to deliver
+type jobCanceledMsg struct{}
+
+// jobTickMsg redraws the progress of running jobs
+type jobTickMsg struct{}
+
+// jobPauseMsg pauses a running job or resumes a paused one (p in :jobs)
+type jobPauseMsg struct {
+	Progress *ops.Progress
+}
+
+// jobCancelMsg cancels a running job (x in :jobs)
+type jobCancelMsg struct {
+	Progress *ops.Progress
+}
+
+// jobTickCmd schedules the next redraw of the running jobs
+func jobTickCmd() tea.Cmd {
+	return tea.Tick(jobTickInterval, func(time.Time) tea.Msg {
+		return jobTickMsg{}
+	})
+}
+
+// startJob runs work in the background, listing its progress under the
+// browser until it returns the message to handle
+func (m *Model) startJob(label string, work func(*ops.Progress) tea.Msg) tea.Cmd {
+	progress := ops.NewProgress()
+	m.jobs = append(slices.Clip(m.jobs), &job{Label: label, Progress: progress})
+	cmd := func() tea.Msg {
+		return jobDoneMsg{Progress: progress, Msg: work(progress)}
+	}
+	if m.jobTicking {
+		return cmd
+	}
+	m.jobTicking = true
+	return tea.Batch(cmd, jobTickCmd())
+}
+
+// updateJobs handles the end of a job and the redraws while jobs run
+func (m Model) updateJobs(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case jobDoneMsg:
+		label := "job"
+		if i := slices.IndexFunc(m.jobs, func(j *job) bool { return j.Progress == msg.Progress }); i >= 0 {
+			label = m.jobs[i].Label
+			m.jobs = slices.Delete(slices.Clone(m.jobs), i, i+1)
+		}
+		m.refreshJobsPanel()
+		if _, ok := msg.Msg.(jobCanceledMsg); ok {
+			m.setStatus(fmt.Sprintf("Canceled: %s", label))
+			return m, nil
+		}
+		if msg.Msg != nil {
+			return m.update(msg.Msg)
+		}
+
+	case jobTickMsg:
+		m.refreshJobsPanel()
+		if len(m.jobs) == 0 {
+			m.jobTicking = false
+			return m, nil
+		}
+		return m, jobTickCmd()
+
+	case jobPauseMsg:
+		if msg.Progress.Paused() {
+			msg.Progress.Resume()
+		} else {
+			msg.Progress.Pause()
+		}
+		m.refreshJobsPanel()
+
+	case jobCancelMsg:
+		msg.Progress.Cancel()
+		m.refreshJobsPanel()
+	}
+	return m, nil
+}
+
+// cancelJobs asks the running jobs to stop (:cancel)
+func (m *Model) cancelJobs() {
+	if len(m.jobs) == 0 {
+		m.StatusMessage = "No jobs are running"
+		return
+	}
+	for _, j := range m.jobs {
+		j.Progress.Cancel()
+	}
+	m.StatusMessage = fmt.Sprintf("Canceling %d jobs...", len(m.jobs))
+}
+
+// pauseJobs holds the running jobs where they are (:pause)
+func (m *Model) pauseJobs() {
+	if len(m.jobs) == 0 {
+		m.StatusMessage = "No jobs are running"
+		return
+	}
+	for _, j := range m.jobs {
+		j.Progress.Pause()
+	}
+	m.StatusMessage = fmt.Sprintf("Paused %d jobs (:resume to continue)", len(m.jobs))
+}
+
+// resumeJobs lets paused jobs continue or, if none are paused, continues the
+// last transfer that stopped early (:resume)
+func (m *Model) resumeJobs() tea.Cmd {
+	paused := 0
+	for _, j := range m.jobs {
+		if j.Progress.Paused() {
+			j.Progress.Resume()
+			paused++
+		}
+	}
+	switch {
+	case paused > 0:
+		m.StatusMessage = fmt.Sprintf("Resumed %d jobs", paused)
+	case m.interrupted != nil:
+		return m.startTransfer(*m.interrupted)
+	default:
+		m.StatusMessage = "Nothing to resume"
+	}
+	return nil
+}
+
+// jobsPanel lists the running jobs and the transfer that stopped early, if
+// any; p pauses or resumes a job, x cancels it and Enter continues the
+// transfer
+func (m *Model) jobsPanel() ListPanel {
+	var entries []ListEntry
+	for _, j := range m.jobs {
+		entries = append(entries, ListEntry{
+			Label: jobLine(j),
+			Data:  j.Progress,
+		})
+	}
+	if t := m.interrupted; t != nil {
+		entries = append(entries,
+			ListEntry{Label: theme.Dim.Render("Stopped early, Enter to continue:"), Separator: true},
+			ListEntry{Label: "⏹ " + transferLabel(*t), Msg: *t},
+		)
+	}
+
+	panel := NewListPanel(jobsPanelTitle, entries)
+	panel.Subtitle = fmt.Sprintf("%d running", len(m.jobs))
+	if len(entries) == 0 {
+		panel.Subtitle = "No jobs are running"
+		return panel
+	}
+	panel.Actions = []ListAction{
+		{
+			Key:  "p",
+			Desc: "pause/resume",
+			Msg: func(entry ListEntry) tea.Msg {
+				if progress, ok := entry.Data.(*ops.Progress); ok {
+					return jobPauseMsg{Progress: progress}
+				}
+				return nil
+			},
+		},
+		{
+			Key:  "x",
+			Desc: "cancel",
+			Msg: func(entry ListEntry) tea.Msg {
+				if progress, ok := entry.Data.(*ops.Progress); ok {
+					return jobCancelMsg{Progress: progress}
+				}
+				return nil
+			},
+		},
+	}
+	return panel
+}
+
+// refreshJobsPanel redraws the :jobs panel, if shown, keeping its cursor,
+// size and status
+func (m *Model) refreshJobsPanel() {
+	if m.Mode != ListMode || m.List == nil || m.List.Title != jobsPanelTitle {
+		return
+	}
+	panel := m.jobsPanel()
+	panel.Width, panel.Height = m.List.Width, m.List.Height
+	panel.SetCursor(m.List.Cursor)
+	panel.StatusMessage = m.List.StatusMessage
+	m.List = &panel
+}
+
+// renderJobs returns a line per running job with its progress, e.g.
+// "⏳ Copying 3 items: 1.2 GB of 4.0 GB (30%) │ 45.3 MB/s │ 26s elapsed │ ETA 1m2s"
+func (m Model) renderJobs() []string {
+	var lines []string
+	for i, j := range m.jobs {
+		if i == maxJobLines {
+			lines = append(lines, fmt.Sprintf("   and %d more jobs", len(m.jobs)-maxJobLines))
+			break
+		}
+		line := jobLine(j)
+		switch {
+		case j.Progress.Canceled():
+		case j.Progress.Paused():
+			line += " (:resume to continue)"
+		case i == 0:
+			line += " (:cancel to stop, :jobs to pause)"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// jobLine describes a job and its progress
+func jobLine(j *job) string {
+	icon, note := "⏳ ", ""
+	if j.Progress.Canceled() {
+		note = " │ canceling..."
+	} else if j.Progress.Paused() {
+		icon, note = "⏸ ", " │ paused"
+	}
+	return icon + j.Label + ": " + describeProgress(j.Progress.Stats()) + note
+}
+
+// describeProgress formats the amount done, throughput, elapsed time and, if
+// the total is known, the remaining time. The rate is left out for the first
+// second.
+func describeProgress(stats ops.ProgressStats) string {
+	done := FormatSize(stats.Bytes)
+	if stats.Total > 0 {
+		done = fmt.Sprintf("%s of %s (%d%%)", done, FormatSize(stats.Total), min(stats.Bytes*100/stats.Total, 100))
+	}
+	if stats.Items > 0 {
+		done += fmt.Sprintf(" in %d files", stats.Items)
+	}
+	if stats.Elapsed < jobTickInterval {
+		// Too early for the rate to mean anything
+		return done
+	}
+	parts := []string{done, FormatSize(int64(stats.Rate)) + "/s", fmt.Sprintf("%s elapsed", stats.Elapsed.Round(time.Second))}
+	if stats.ETA >= 0 {
+		parts = append(parts, fmt.Sprintf("ETA %s", stats.ETA.Round(time.Second)))
+	}
+	return strings.Join(parts, " │ ")
+}
@@ -0,0 +1,314 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// jsonKind is the type of a value in a JSON tree
+type jsonKind int
+
+const (
+	jsonScalar jsonKind = iota
+	jsonObject
+	jsonArray
+)
+
+// jsonNode is a value of a JSON document shown as a line of the tree
+type jsonNode struct {
+	Key      string // Quoted member name or array index, empty for the root
+	Value    string // JSON text of a string, number, boolean or null
+	Kind     jsonKind
+	Children []*jsonNode
+	Folded   bool // Whether the children are hidden
+	parent   *jsonNode
+	depth    int
+}
+
+// foldable reports whether the node has children to hide
+func (n *jsonNode) foldable() bool {
+	return len(n.Children) > 0
+}
+
+// summary describes a container, e.g. "{3 keys}" or "[1 item]"
+func (n *jsonNode) summary() string {
+	count := len(n.Children)
+	switch {
+	case n.Kind == jsonObject && count == 0:
+		return "{}"
+	case n.Kind == jsonArray && count == 0:
+		return "[]"
+	case n.Kind == jsonObject:
+		if count == 1 {
+			return "{1 key}"
+		}
+		return fmt.Sprintf("{%d keys}", count)
+	case n.Kind == jsonArray:
+		if count == 1 {
+			return "[1 item]"
+		}
+		return fmt.Sprintf("[%d items]", count)
+	}
+	return n.Value
+}
+
+// jsonTree is a JSON file shown as a tree of foldable values rather than as
+// its text
+type jsonTree struct {
+	Source string // Text of the file, shown again with :set notree
+	root   *jsonNode
+	nodes  []*jsonNode // Node shown on each line
+}
+
+// isJSON reports whether a file name has a JSON extension
+func isJSON(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".json")
+}
+
+// parseJSONTree parses a JSON document, keeping the order of object members.
+// Errors give the line they were found on.
+func parseJSONTree(source string) (*jsonNode, error) {
+	dec := json.NewDecoder(strings.NewReader(source))
+	dec.UseNumber()
+	root, err := parseJSONValue(dec, "", nil)
+	if err == nil {
+		if _, err = dec.Token(); err == nil {
+			err = errors.New("more than one value in the document")
+		} else if err == io.EOF {
+			return root, nil
+		}
+	}
+
+	offset := dec.InputOffset()
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		offset = syntaxErr.Offset
+	}
+	line := 1 + strings.Count(source[:min(int(offset), len(source))], "\n")
+	return nil, fmt.Errorf("invalid JSON on line %d: %v", line, err)
+}
+
+// parseJSONValue reads the next value of a document and the values inside it
+func parseJSONValue(dec *json.Decoder, key string, parent *jsonNode) (*jsonNode, error) {
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	node := &jsonNode{Key: key, parent: parent}
+	if parent != nil {
+		node.depth = parent.depth + 1
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '{' {
+			node.Kind = jsonObject
+		} else {
+			node.Kind = jsonArray
+		}
+		for dec.More() {
+			childKey := strconv.Itoa(len(node.Children))
+			if node.Kind == jsonObject {
+				name, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				childKey = strconv.Quote(name.(string))
+			}
+			child, err := parseJSONValue(dec, childKey, node)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, child)
+		}
+		// The closing brace or bracket
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	case string:
+		node.Value = strconv.Quote(tok)
+	case json.Number:
+		node.Value = tok.String()
+	case bool:
+		node.Value = strconv.FormatBool(tok)
+	case nil:
+		node.Value = "null"
+	}
+	return node, nil
+}
+
+// render returns a plain and a styled line per visible node, indented by
+// depth with ▾ before unfolded and ▸ before folded containers
+func (t *jsonTree) render() (plain, styled []string) {
+	t.nodes = t.nodes[:0]
+	var walk func(n *jsonNode)
+	walk = func(n *jsonNode) {
+		p, s := n.line()
+		plain = append(plain, p)
+		styled = append(styled, s)
+		t.nodes = append(t.nodes, n)
+		if !n.Folded {
+			for _, child := range n.Children {
+				walk(child)
+			}
+		}
+	}
+	walk(t.root)
+	return plain, styled
+}
+
+// line returns the plain and styled line of a node, e.g. `▾ "users": [3 items]`
+func (n *jsonNode) line() (plain, styled string) {
+	indent := strings.Repeat("  ", n.depth)
+	marker := "  "
+	switch {
+	case !n.foldable():
+	case n.Folded:
+		marker = "▸ "
+	default:
+		marker = "▾ "
+	}
+
+	var p, s strings.Builder
+	p.WriteString(indent + marker)
+	s.WriteString(indent + theme.JSONDim + marker + "\x1b[0m")
+	if n.Key != "" {
+		p.WriteString(n.Key + ": ")
+		if n.parent != nil && n.parent.Kind == jsonArray {
+			s.WriteString(theme.JSONDim + n.Key + "\x1b[0m: ")
+		} else {
+			s.WriteString(theme.JSONKey + n.Key + "\x1b[0m: ")
+		}
+	}
+	value := n.summary()
+	p.WriteString(value)
+	color := theme.JSONDim
+	if n.Kind == jsonScalar {
+		switch value[0] {
+		case '"':
+			color = theme.JSONString
+		case 't', 'f', 'n':
+			color = theme.JSONLiteral
+		default:
+			color = theme.JSONNumber
+		}
+	}
+	s.WriteString(color + value + "\x1b[0m")
+	return p.String(), s.String()
+}
+
+// visibleAncestor returns n, or the closest of its parents that is shown if
+// n is inside a folded node
+func (n *jsonNode) visibleAncestor() *jsonNode {
+	shown := n
+	for p := n.parent; p != nil; p = p.parent {
+		if p.Folded {
+			shown = p
+		}
+	}
+	return shown
+}
+
+// setFolded folds or unfolds n and all the containers inside it
+func (n *jsonNode) setFolded(folded bool) {
+	n.Folded = folded && n.foldable()
+	for _, child := range n.Children {
+		child.setFolded(folded)
+	}
+}
+
+// setTreeContent shows JSON text as a tree, failing if it does not parse
+func (fv *FileViewer) setTreeContent(source string) error {
+	root, err := parseJSONTree(source)
+	if err != nil {
+		return err
+	}
+	fv.tree = &jsonTree{Source: source, root: root}
+	fv.Content, fv.styledLines = fv.tree.render()
+	fv.Sections = nil
+	fv.spans = nil
+	fv.highlighter = nil
+	fv.fileType = "JSON"
+	return nil
+}
+
+// setJSONTree shows a JSON file as a tree, or as its text again
+// (:set tree / :set notree)
+func (fv *FileViewer) setJSONTree(on bool) {
+	if !isJSON(fv.FileName) || fv.hex || fv.window != nil {
+		fv.StatusMessage = "Only JSON files are shown as a tree"
+		return
+	}
+	if on == fv.JSONTree {
+		return
+	}
+	fv.JSONTree = on
+	if !on {
+		fv.replaceContent(fv.tree.Source, func() int { return 0 })
+		fv.StatusMessage = "Showing the JSON text"
+		return
+	}
+	fv.replaceContent(strings.Join(fv.Content, "\n"), func() int { return 0 })
+	if fv.tree != nil {
+		fv.StatusMessage = "Showing the JSON tree: Enter or Space folds, zM folds all, zR unfolds all"
+	}
+}
+
+// toggleFold folds or unfolds the node on the top line, or folds the node
+// containing it if it has no children
+func (fv *FileViewer) toggleFold() {
+	line := fv.lineAt(fv.ScrollPos)
+	if line < 0 || line >= len(fv.tree.nodes) {
+		return
+	}
+	node := fv.tree.nodes[line]
+	if !node.foldable() {
+		if node.parent == nil {
+			return
+		}
+		node = node.parent
+	}
+	node.Folded = !node.Folded
+	fv.refreshTree(node)
+}
+
+// foldAll folds every container below the root (zM), or unfolds them all (zR)
+func (fv *FileViewer) foldAll(folded bool) {
+	top := fv.tree.root
+	if line := fv.lineAt(fv.ScrollPos); line >= 0 && line < len(fv.tree.nodes) {
+		top = fv.tree.nodes[line]
+	}
+	for _, child := range fv.tree.root.Children {
+		child.setFolded(folded)
+	}
+	fv.tree.root.Folded = false
+	fv.refreshTree(top.visibleAncestor())
+}
+
+// refreshTree shows the tree again after folding, keeping the search and
+// filter, with the line of node at the top
+func (fv *FileViewer) refreshTree(node *jsonNode) {
+	fv.Content, fv.styledLines = fv.tree.render()
+	fv.resetSpelling()
+	fv.resetLinks()
+	fv.refilter(0)
+	if fv.searchRe != nil {
+		fv.findMatches()
+	}
+	line := 0
+	for i, n := range fv.tree.nodes {
+		if n == node {
+			line = i
+			break
+		}
+	}
+	fv.ScrollPos = fv.rowOf(line)
+}
@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxJumps is the number of locations kept in the jump list. Closed viewers stay
+// in memory while they are listed, so they resume where they were left.
+const maxJumps = 20
+
+// jumpLocation is a place in the jump list: a directory, or a viewer opened from it
+type jumpLocation struct {
+	Dir    string      // Browsed directory
+	Item   string      // Path of the selected item
+	Viewer *FileViewer // Open viewer, nil for the browser
+}
+
+// location returns where the model currently is
+func (m *Model) location() jumpLocation {
+	item, _ := m.selectedItemPath()
+	return jumpLocation{Dir: m.CurrentPath, Item: item, Viewer: m.FileViewer}
+}
+
+// samePlace reports whether two locations show the same directory and viewer
+func (l jumpLocation) samePlace(other jumpLocation) bool {
+	return l.Dir == other.Dir && l.Viewer == other.Viewer
+}
+
+// pushJump records a location that was left, dropping locations ahead of the
+// current position in the list. Each place is listed once, at its latest visit,
+// so bouncing between two places keeps them next to each other.
+func (m *Model) pushJump(loc jumpLocation) {
+	jumps := slices.DeleteFunc(m.jumps[:m.jumpPos], loc.samePlace)
+	jumps = append(jumps, loc)
+	if len(jumps) > maxJumps {
+		jumps = jumps[len(jumps)-maxJumps:]
+	}
+	m.jumps = jumps
+	m.jumpPos = len(jumps)
+}
+
+// jumpBack returns to the previous location in the jump list (Ctrl+O)
+func (m *Model) jumpBack() tea.Cmd {
+	if m.jumpPos == 0 {
+		m.setStatus("Already at the oldest location")
+		return nil
+	}
+	if m.jumpPos == len(m.jumps) {
+		// Remember the newest location so Ctrl+I can return to it
+		m.pushJump(m.location())
+		m.jumpPos = len(m.jumps) - 1
+	}
+	m.jumpPos--
+	return m.gotoLocation(m.jumps[m.jumpPos])
+}
+
+// jumpForward moves to the next location in the jump list (Ctrl+I / Tab)
+func (m *Model) jumpForward() tea.Cmd {
+	if m.jumpPos >= len(m.jumps)-1 {
+		m.setStatus("Already at the newest location")
+		return nil
+	}
+	m.jumpPos++
+	return m.gotoLocation(m.jumps[m.jumpPos])
+}
+
+// toggleDirectory switches to the directory browsed before the current one,
+// selecting the item that was selected there; repeating it switches back
+func (m *Model) toggleDirectory() {
+	if m.lastDir.Dir == "" {
+		m.setStatus("No previous directory")
+		return
+	}
+	loc := m.lastDir
+	m.CurrentPath = loc.Dir
+	m.loadDirectory()
+	m.selectPath(loc.Item)
+}
+
+// gotoLocation shows a location from the jump list, resuming its viewer
+func (m *Model) gotoLocation(loc jumpLocation) tea.Cmd {
+	m.jumped = true
+	m.StatusMessage = ""
+	if m.CurrentPath != loc.Dir {
+		m.CurrentPath = loc.Dir
+		m.loadDirectory()
+	}
+	m.selectPath(loc.Item)
+
+	if loc.Viewer == nil {
+		m.FileViewer = nil
+		m.Mode = BrowseMode
+		return nil
+	}
+	fv := loc.Viewer
+	fv.Width, fv.Height = m.Width, m.Height
+	m.FileViewer = fv
+	m.parentViewer = nil
+	m.ReturnMode = BrowseMode
+	m.Mode = FileViewMode
+	return fv.Init()
+}
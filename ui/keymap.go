@@ -0,0 +1,334 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// keyAction is something a key does that can be bound to other keys with the
+// keys setting. The key handlers match the first of its default keys, which
+// the keys bound to it are translated to.
+type keyAction struct {
+	Name string   // "<mode>.<action>", e.g. "browser.up"
+	Keys []string // Default keys
+}
+
+// keyActions are the actions that can be rebound. Ctrl+C always quits, and
+// two-key sequences such as ]f and za follow from their first key.
+var keyActions = []keyAction{
+	{"browser.up", []string{"up", "k"}},
+	{"browser.down", []string{"down", "j"}},
+	{"browser.open", []string{"enter", "l", "right"}},
+	{"browser.parent", []string{"h", "backspace", "left"}},
+	{"browser.top", []string{"g"}},
+	{"browser.bottom", []string{"G"}},
+	{"browser.mark", []string{" "}},
+	{"browser.invert", []string{"*"}},
+	{"browser.jump_back", []string{"ctrl+o"}},
+	{"browser.jump_forward", []string{"tab"}},
+	{"browser.last_dir", []string{"-"}},
+	{"browser.back", []string{"alt+left", "H"}},
+	{"browser.forward", []string{"alt+right", "L"}},
+	{"browser.find", []string{"ctrl+p"}},
+	{"browser.goto", []string{"ctrl+g"}},
+	{"browser.new_tab", []string{"ctrl+t"}},
+	{"browser.close_tab", []string{"ctrl+w"}},
+	{"browser.next_tab", []string{"ctrl+pgdown"}},
+	{"browser.prev_tab", []string{"ctrl+pgup"}},
+	{"browser.two_panes", []string{"f3"}},
+	{"browser.preview", []string{"P"}},
+	{"browser.rename", []string{"f2"}},
+	{"browser.copy", []string{"f5"}},
+	{"browser.move", []string{"f6"}},
+	{"browser.mkdir", []string{"f7"}},
+	{"browser.delete", []string{"f8", "delete"}},
+	{"browser.output", []string{"`"}},
+	{"browser.tail", []string{"T"}},
+	{"browser.bookmark", []string{"b"}},
+	{"browser.bookmarks", []string{"B"}},
+	{"browser.drives", []string{"D"}},
+	{"browser.quick_filter", []string{"f"}},
+	{"browser.groups", []string{"z"}},
+	{"browser.sort", []string{"s"}},
+	{"browser.clear", []string{"esc"}},
+	{"browser.command", []string{":"}},
+	{"browser.quit", []string{"q"}},
+
+	{"viewer.up", []string{"up", "k"}},
+	{"viewer.down", []string{"down", "j"}},
+	{"viewer.top", []string{"g"}},
+	{"viewer.bottom", []string{"G"}},
+	{"viewer.page_up", []string{"ctrl+u", "pgup"}},
+	{"viewer.page_down", []string{"ctrl+d", "pgdown"}},
+	{"viewer.left", []string{"left", "h"}},
+	{"viewer.right", []string{"right", "l"}},
+	{"viewer.follow", []string{"F"}},
+	{"viewer.fold", []string{"enter", " "}},
+	{"viewer.search", []string{"/"}},
+	{"viewer.next_match", []string{"n"}},
+	{"viewer.prev_match", []string{"N"}},
+	{"viewer.open_link", []string{"o"}},
+	{"viewer.jump_back", []string{"ctrl+o"}},
+	{"viewer.jump_forward", []string{"tab"}},
+	{"viewer.command", []string{":"}},
+	{"viewer.quit", []string{"q", "esc"}},
+
+	{"preview.next", []string{"n", "right", "l", " "}},
+	{"preview.previous", []string{"p", "left", "h", "backspace"}},
+	{"preview.slideshow", []string{"s"}},
+	{"preview.longer", []string{"+", "="}},
+	{"preview.shorter", []string{"-"}},
+	{"preview.quit", []string{"esc", "q", "enter"}},
+}
+
+// keyActionsByName indexes keyActions
+var keyActionsByName = func() map[string]keyAction {
+	byName := make(map[string]keyAction, len(keyActions))
+	for _, action := range keyActions {
+		byName[action.Name] = action
+	}
+	return byName
+}()
+
+// keymap holds the key bindings from the keys setting
+var keymap keyMap
+
+// keyMap translates keys bound with the keys setting to the keys the handlers
+// match. The zero keyMap keeps the default keys.
+type keyMap struct {
+	bound map[string]map[string]string // Mode, then key, to the key handled; "" for none
+	keys  map[string][]string          // Keys of the rebound actions
+}
+
+// keyAliases are other names accepted in the keys setting for the keys Bubble
+// Tea reports
+var keyAliases = map[string]string{"space": " ", "pageup": "pgup", "pagedown": "pgdown"}
+
+// compileKeymap compiles the keys setting, which maps action names to lists
+// of keys. Binding an action replaces its default keys, and a key bound to
+// one action is taken from any other of the same mode. Invalid entries are
+// reported and left out.
+func compileKeymap(bindings map[string][]string) (keyMap, error) {
+	k := keyMap{bound: make(map[string]map[string]string), keys: make(map[string][]string)}
+	names := make([]string, 0, len(bindings))
+	for name := range bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	owners := make(map[string]string) // Mode and key to the action bound to them
+	for _, name := range names {
+		action, ok := keyActionsByName[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("unknown action %q", name))
+			continue
+		}
+		mode, _, _ := strings.Cut(name, ".")
+		keys := []string{}
+		for _, key := range bindings[name] {
+			if alias, ok := keyAliases[key]; ok {
+				key = alias
+			}
+			owner, taken := owners[mode+" "+key]
+			switch {
+			case key == "":
+				problems = append(problems, fmt.Sprintf("empty key for %s", name))
+			case key == "ctrl+c":
+				problems = append(problems, "ctrl+c always quits")
+			case taken && owner == name:
+				// Listed twice
+			case taken:
+				problems = append(problems, fmt.Sprintf("%q is bound to both %s and %s", key, owner, name))
+			default:
+				owners[mode+" "+key] = name
+				keys = append(keys, key)
+			}
+		}
+		k.keys[name] = keys
+
+		if k.bound[mode] == nil {
+			k.bound[mode] = make(map[string]string)
+		}
+		for _, key := range action.Keys {
+			if _, ok := k.bound[mode][key]; !ok {
+				k.bound[mode][key] = ""
+			}
+		}
+	}
+
+	// Bound keys win over the default keys their actions gave up
+	for _, name := range names {
+		mode, _, _ := strings.Cut(name, ".")
+		for _, key := range k.keys[name] {
+			k.bound[mode][key] = keyActionsByName[name].Keys[0]
+		}
+	}
+
+	if len(problems) > 0 {
+		return k, errors.New("keys: " + strings.Join(problems, "; "))
+	}
+	return k, nil
+}
+
+// translate returns the key the handlers of mode match for a pressed key: the
+// first default key of the action it is bound to, "" for a default key its
+// action no longer has, or the key itself
+func (k keyMap) translate(mode, key string) string {
+	if handled, ok := k.bound[mode][key]; ok {
+		return handled
+	}
+	return key
+}
+
+// keysOf returns the keys that do an action
+func (k keyMap) keysOf(name string) []string {
+	if keys, ok := k.keys[name]; ok {
+		return keys
+	}
+	action := keyActionsByName[name]
+	mode, _, _ := strings.Cut(name, ".")
+	var keys []string
+	for _, key := range action.Keys {
+		// Left out when bound to another action
+		if handled, ok := k.bound[mode][key]; !ok || handled == action.Keys[0] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// helpEntry is an item of a help line: the keys of its actions followed by a
+// label. An entry of one action shows two of its keys, and one of several
+// actions the first key of each.
+type helpEntry struct {
+	Actions []string
+	Label   string
+	Keys    string // Fixed keys, for sequences that cannot be rebound
+}
+
+// help renders a help line from groups of entries with the bound keys,
+// leaving out entries whose actions have no keys
+func (k keyMap) help(groups ...[]helpEntry) string {
+	var parts []string
+	for _, group := range groups {
+		var entries []string
+		for _, entry := range group {
+			if keys := k.entryKeys(entry); keys != "" {
+				entries = append(entries, keys+": "+entry.Label)
+			}
+		}
+		if len(entries) > 0 {
+			parts = append(parts, strings.Join(entries, "  "))
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+// entryKeys returns the keys shown for a help entry, or "" if an action of it
+// has none
+func (k keyMap) entryKeys(entry helpEntry) string {
+	if entry.Keys != "" {
+		return entry.Keys
+	}
+	var labels []string
+	for _, name := range entry.Actions {
+		keys := k.keysOf(name)
+		if len(keys) == 0 {
+			return ""
+		}
+		if len(entry.Actions) == 1 {
+			keys = keys[:min(len(keys), 2)]
+		} else {
+			keys = keys[:1]
+		}
+		for _, key := range keys {
+			labels = append(labels, keyLabel(key))
+		}
+	}
+	return strings.Join(labels, "/")
+}
+
+// keyNames are the names shown for keys that are not shown as they are typed
+var keyNames = map[string]string{
+	"up": "↑", "down": "↓", "left": "←", "right": "→", " ": "Space",
+	"enter": "Enter", "esc": "Esc", "tab": "Tab", "backspace": "Backspace",
+	"delete": "Del", "pgup": "PgUp", "pgdown": "PgDn", "home": "Home",
+	"end": "End", "insert": "Ins",
+}
+
+// keyLabel returns the name of a key as shown in help lines, such as Ctrl+O
+// for ctrl+o and ↑ for up
+func keyLabel(key string) string {
+	if name, ok := keyNames[key]; ok {
+		return name
+	}
+	if len(key) == 1 {
+		return key
+	}
+	parts := strings.Split(key, "+")
+	if key[len(key)-1] == '+' {
+		// A modifier with the + key itself
+		parts = append(parts[:len(parts)-2], "+")
+	}
+	for i, part := range parts {
+		switch name, ok := keyNames[part]; {
+		case ok:
+			parts[i] = name
+		case len(part) > 1 || i < len(parts)-1 || strings.HasPrefix(key, "ctrl+"):
+			// Control keys are the same for either case of the letter
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return strings.Join(parts, "+")
+}
+
+// browserHelp, dualPaneHelp, viewerHelp and previewHelp are the help lines
+// of the browser with one and two panes, the viewer and the image preview
+var (
+	browserHelp = [][]helpEntry{
+		{{Actions: []string{"browser.up"}, Label: "Up"}, {Actions: []string{"browser.down"}, Label: "Down"},
+			{Actions: []string{"browser.open"}, Label: "Open"}, {Actions: []string{"browser.parent"}, Label: "Back"}},
+		{{Actions: []string{"browser.mark"}, Label: "Mark"}, {Actions: []string{"browser.invert"}, Label: "Invert"}},
+		{{Actions: []string{"browser.jump_back", "browser.jump_forward"}, Label: "Jump"},
+			{Actions: []string{"browser.last_dir"}, Label: "Last dir"}},
+		{{Actions: []string{"browser.find"}, Label: "Find file"}},
+		{{Actions: []string{"browser.top"}, Label: "Top"}},
+		{{Actions: []string{"browser.bottom"}, Label: "Bottom"}},
+		{{Actions: []string{"browser.tail"}, Label: "Tail"}},
+		{{Actions: []string{"browser.copy", "browser.move"}, Label: "Copy/Move"},
+			{Actions: []string{"browser.delete"}, Label: "Delete"}},
+		{{Actions: []string{"browser.two_panes"}, Label: "Two panes"}},
+		{{Actions: []string{"browser.command"}, Label: "Command"}},
+		{{Actions: []string{"browser.quit"}, Label: "Quit"}},
+	}
+	dualPaneHelp = [][]helpEntry{
+		{{Actions: []string{"browser.up"}, Label: "Up"}, {Actions: []string{"browser.down"}, Label: "Down"},
+			{Actions: []string{"browser.open"}, Label: "Open"}, {Actions: []string{"browser.parent"}, Label: "Back"}},
+		{{Actions: []string{"browser.mark"}, Label: "Mark"}},
+		{{Actions: []string{"browser.jump_forward"}, Label: "Switch pane"}},
+		{{Actions: []string{"browser.copy"}, Label: "Copy"}, {Actions: []string{"browser.move"}, Label: "Move"}},
+		{{Actions: []string{"browser.two_panes"}, Label: "One pane"}},
+		{{Actions: []string{"browser.command"}, Label: "Command"}},
+		{{Actions: []string{"browser.quit"}, Label: "Quit"}},
+	}
+	viewerHelp = [][]helpEntry{
+		{{Actions: []string{"viewer.up"}, Label: "up"}},
+		{{Actions: []string{"viewer.down"}, Label: "down"}},
+		{{Actions: []string{"viewer.top"}, Label: "top"}},
+		{{Actions: []string{"viewer.bottom"}, Label: "bottom"}},
+		{{Actions: []string{"viewer.page_up", "viewer.page_down"}, Label: "page"}},
+		{{Actions: []string{"viewer.search"}, Label: "search"}},
+		{{Keys: "]f/[f", Label: "next/prev file"}},
+		{{Actions: []string{"viewer.command"}, Label: "command"}},
+		{{Actions: []string{"viewer.quit"}, Label: "back"}},
+	}
+	previewHelp = [][]helpEntry{
+		{{Actions: []string{"preview.next"}, Label: "Next"}, {Actions: []string{"preview.previous"}, Label: "Previous"}},
+		{{Actions: []string{"preview.slideshow"}, Label: "Slideshow"},
+			{Actions: []string{"preview.longer", "preview.shorter"}, Label: "Delay"}},
+		{{Actions: []string{"preview.quit"}, Label: "Back"}},
+	}
+)
@@ -0,0 +1,334 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+)
+
+// defaultMaxViewSize is the largest file read whole when max_view_size is not set
+const defaultMaxViewSize = 10 << 20
+
+// maxViewSize is the largest file the viewer reads whole, from the
+// max_view_size setting; larger files are read a window at a time as they are
+// scrolled. 0 means no limit.
+var maxViewSize int64 = defaultMaxViewSize
+
+// defaultTailLines is how many lines T and :tail open
+const defaultTailLines = 1000
+
+// tailReadSize is the block size used when searching backwards for line starts
+const tailReadSize = 64 << 10
+
+// earlierChunkSize is how much more of the file scrolling above the start of
+// a tail loads
+const earlierChunkSize = 1 << 20
+
+// filePart describes the part of a large file a viewer shows
+type filePart struct {
+	Tail  bool  // The end of the file rather than the start
+	Start int64 // Offset of the first line shown
+	Size  int64 // Bytes shown
+	Total int64 // Size of the file, 0 when the whole file is shown
+}
+
+// openTailMsg opens the last lines of a file
+type openTailMsg struct {
+	Path  string
+	Lines int
+}
+
+// setMaxViewSize applies the max_view_size setting
+func setMaxViewSize(text string) error {
+	if text == "" {
+		maxViewSize = defaultMaxViewSize
+		return nil
+	}
+	size, err := parseSize(text)
+	if err != nil {
+		return fmt.Errorf("max_view_size: %v", err)
+	}
+	maxViewSize = size
+	return nil
+}
+
+// NewPartialViewer creates a viewer for the first or last maxViewSize bytes of
+// a file. Lines cut off at the edge of the part are left out.
+func NewPartialViewer(filePath, fileName string, tail bool) FileViewer {
+	fv := FileViewer{
+		FilePath:           filePath,
+		FileName:           fileName,
+		UseSyntaxHighlight: true,
+		SearchMatches:      []int{},
+		CurrentMatchIndex:  -1,
+	}
+	fv.loadPart(tail)
+	if isLogFile(fileName) {
+		fv.setLogMode(true)
+	}
+	return fv
+}
+
+// loadPart reads the start or end of the file into memory
+func (fv *FileViewer) loadPart(tail bool) {
+	file, err := vfs.Default.Open(fv.FilePath)
+	if err != nil {
+		fv.Err = err
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		fv.Err = err
+		return
+	}
+
+	total := info.Size()
+	size := total
+	if maxViewSize > 0 {
+		size = min(total, maxViewSize)
+	}
+	offset := int64(0)
+	if tail {
+		offset = total - size
+	}
+	data := make([]byte, size)
+	n, err := file.ReadAt(data, offset)
+	if err != nil && err != io.EOF {
+		fv.Err = err
+		return
+	}
+	data = data[:n]
+
+	// Drop the partial lines at the cut
+	if size < total {
+		if tail {
+			if i := bytes.IndexByte(data, '\n'); i >= 0 {
+				data = data[i+1:]
+				offset += int64(i + 1)
+			}
+		} else if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+			data = data[:i+1]
+		}
+		fv.part = filePart{Tail: tail, Start: offset, Size: int64(len(data)), Total: total}
+	}
+
+	// Following continues from the end of the part
+	fv.readOffset = fv.part.Start + fv.part.Size
+	if fv.part.Total == 0 {
+		fv.readOffset = int64(n)
+	}
+	fv.setContent(string(data))
+}
+
+// NewTailViewer creates a viewer for the last lines of a file, found by
+// reading backwards from its end so the size of the file does not matter
+func NewTailViewer(filePath, fileName string, lines int) FileViewer {
+	fv := FileViewer{
+		FilePath:           filePath,
+		FileName:           fileName,
+		UseSyntaxHighlight: true,
+		SearchMatches:      []int{},
+		CurrentMatchIndex:  -1,
+	}
+	fv.loadTail(lines)
+	if isLogFile(fileName) {
+		fv.setLogMode(true)
+	}
+	return fv
+}
+
+// loadTail reads the last lines of the file into memory
+func (fv *FileViewer) loadTail(lines int) {
+	file, err := vfs.Default.Open(fv.FilePath)
+	if err != nil {
+		fv.Err = err
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		fv.Err = err
+		return
+	}
+
+	// Find the start of the last lines, not counting a final line break
+	total := info.Size()
+	start := int64(0)
+	buf := make([]byte, tailReadSize)
+	count := 0
+search:
+	for pos := total; pos > 0; {
+		n := min(int64(len(buf)), pos)
+		pos -= n
+		if _, err := file.ReadAt(buf[:n], pos); err != nil && err != io.EOF {
+			fv.Err = err
+			return
+		}
+		for i := n - 1; i >= 0; i-- {
+			if buf[i] != '\n' || pos+i == total-1 {
+				continue
+			}
+			if count++; count == lines {
+				start = pos + i + 1
+				break search
+			}
+		}
+	}
+
+	data := make([]byte, total-start)
+	n, err := file.ReadAt(data, start)
+	if err != nil && err != io.EOF {
+		fv.Err = err
+		return
+	}
+	if start > 0 {
+		fv.part = filePart{Tail: true, Start: start, Size: int64(n), Total: total}
+	}
+	fv.readOffset = start + int64(n)
+	fv.setContent(string(data[:n]))
+}
+
+// loadEarlier puts the chunk of the file before a tail above the lines shown,
+// keeping the view where it was. It reports whether there was more to load.
+func (fv *FileViewer) loadEarlier() bool {
+	if fv.window != nil {
+		return fv.loadBefore()
+	}
+	if !fv.part.Tail || fv.part.Start == 0 || fv.hex {
+		return false
+	}
+	file, err := vfs.Default.Open(fv.FilePath)
+	if err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return false
+	}
+	defer file.Close()
+
+	// Read lines whole, taking more when one line is longer than a chunk
+	var data []byte
+	from := fv.part.Start
+	for chunk := int64(earlierChunkSize); ; chunk *= 2 {
+		from = max(0, fv.part.Start-chunk)
+		data = make([]byte, fv.part.Start-from)
+		if _, err := file.ReadAt(data, from); err != nil && err != io.EOF {
+			fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+			return false
+		}
+		if from == 0 {
+			break
+		}
+		if i := bytes.IndexByte(data, '\n'); i >= 0 && i < len(data)-1 {
+			data = data[i+1:]
+			from += int64(i + 1)
+			break
+		}
+	}
+
+	top := fv.lineAt(fv.ScrollPos)
+	before := len(fv.Content)
+	fv.styledLines = nil
+	fv.setContent(string(data) + strings.Join(fv.Content, "\n"))
+	added := len(fv.Content) - before
+
+	// Line numbers moved down by the lines added above
+	if fv.LogMode {
+		fv.setLogMode(true)
+	}
+	fv.refilter(0)
+	for i := range fv.Bookmarks {
+		fv.Bookmarks[i].Line += added
+	}
+	if fv.searchRe != nil {
+		var matches []int
+		for i := range added {
+			if fv.lineMatches(i) {
+				matches = append(matches, i)
+			}
+		}
+		for _, match := range fv.SearchMatches {
+			matches = append(matches, match+added)
+		}
+		if fv.CurrentMatchIndex >= 0 {
+			fv.CurrentMatchIndex += len(matches) - len(fv.SearchMatches)
+		}
+		fv.SearchMatches = matches
+	}
+	fv.ScrollPos = fv.rowOf(top + added)
+
+	fv.part.Size += fv.part.Start - from
+	fv.part.Start = from
+	if from == 0 {
+		fv.part = filePart{}
+		fv.StatusMessage = fmt.Sprintf("Loaded %d earlier lines, the whole file is shown", added)
+	} else {
+		fv.StatusMessage = fmt.Sprintf("Loaded %d earlier lines", added)
+	}
+	return true
+}
+
+// partInfo describes the part of the file shown for the viewer header
+func (fv *FileViewer) partInfo() string {
+	if fv.part.Total == 0 || fv.window != nil {
+		return ""
+	}
+	if fv.part.Tail {
+		return fmt.Sprintf("Last %s of %s (↑ at the top loads more)", FormatSize(fv.part.Size), FormatSize(fv.part.Total))
+	}
+	return fmt.Sprintf("First %s of %s", FormatSize(fv.part.Size), FormatSize(fv.part.Total))
+}
+
+// openTail opens the last lines of a file; asked from the viewer, it takes the
+// viewer's place
+func (m *Model) openTail(msg openTailMsg) {
+	viewer := NewTailViewer(msg.Path, filepath.Base(msg.Path), msg.Lines)
+	parent := m.parentViewer
+	replace := m.Mode == FileViewMode
+	m.openViewer(&viewer)
+	if replace {
+		m.parentViewer = parent
+	}
+}
+
+// tailSelected opens the last lines of the file under the browser cursor
+func (m *Model) tailSelected(lines int) {
+	if m.Cursor >= len(m.Items) || m.Items[m.Cursor].IsDir {
+		m.StatusMessage = "Select a file to open its tail"
+		return
+	}
+	if m.collapsedGroupStatus() {
+		return
+	}
+	m.openTail(openTailMsg{Path: m.Items[m.Cursor].Path, Lines: lines})
+}
+
+// hexSelected opens the file under the browser cursor as a hex dump (:hex)
+func (m *Model) hexSelected() {
+	if m.Cursor >= len(m.Items) || m.Items[m.Cursor].IsDir {
+		m.StatusMessage = "Select a file to open its hex dump"
+		return
+	}
+	if m.collapsedGroupStatus() {
+		return
+	}
+	item := m.Items[m.Cursor]
+	viewer := NewHexViewer(item.Path, item.Name)
+	m.openViewer(&viewer)
+}
+
+// parseTailLines reads the optional line count of :tail
+func parseTailLines(args []string) (int, error) {
+	if len(args) == 0 {
+		return defaultTailLines, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid line count %q", args[0])
+	}
+	return n, nil
+}
@@ -0,0 +1,379 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/launch"
+	"github.com/HolyStarGazer/windows-tui-go/spell"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reverseOn and reverseOff mark the selected link
+const (
+	reverseOn  = "\x1b[7m"
+	reverseOff = "\x1b[27m"
+)
+
+// urlPattern matches web and file URLs, and bare www. addresses
+var urlPattern = regexp.MustCompile("(?i)\\b(?:(?:https?|ftp|file)://|www\\.)[^\\s<>\"'`]+")
+
+// pathPattern matches words that may be file paths: a drive, UNC, home,
+// relative or root prefix, then names separated by slashes or backslashes, then
+// an optional line number as in file.go:12:5 or File.cs(12,5)
+var pathPattern = regexp.MustCompile(`((?:[A-Za-z]:[\\/]|\\\\|~[\\/]|\.\.?[\\/]|/)?[\w.$+@~-]+(?:[\\/][\w.$+@~-]+)*)(?::(\d+)(?::\d+)?|\((\d+)(?:,\d+)?\))?`)
+
+// rootedPath matches the prefixes of paths that are links without an extension
+var rootedPath = regexp.MustCompile(`^(?:[A-Za-z]:[\\/]|\\\\|~[\\/]|\.\.?[\\/]|/)`)
+
+// fileExtension matches the extension of a file name
+var fileExtension = regexp.MustCompile(`\.[A-Za-z][A-Za-z0-9]*$`)
+
+// link is a URL or file path found in a line
+type link struct {
+	Start, End int    // Byte range in the line, including any line number
+	Target     string // URL, or path as written
+	URL        bool
+	Line       int          // Line number given after a path, or 0
+	rule       *includeRule // Include statement the reference was found in, if any
+}
+
+// linkRef selects a link: the content line and the link's index in it
+type linkRef struct {
+	Line, Index int
+}
+
+// linkCache holds the links of the lines looked at so far. It is shared by
+// copies of the viewer, so lines scanned while rendering stay scanned.
+type linkCache struct {
+	lines    map[int][]link
+	includes []*includeRule // Include statements of the file's language
+}
+
+// openLinkMsg views a file or browses a directory named in a viewed file
+type openLinkMsg struct {
+	Path string
+	Line int // Line number to show, starting at 1, or 0
+}
+
+// urlOpenedMsg reports whether a URL was handed to the browser
+type urlOpenedMsg struct {
+	URL string
+	Err error
+}
+
+// findLinks returns the URLs and likely file paths in a line, in order
+func findLinks(line string) []link {
+	if !strings.ContainsAny(line, `/\.`) {
+		return nil
+	}
+
+	var links []link
+	for _, m := range urlPattern.FindAllStringIndex(line, -1) {
+		start, end := m[0], trimLinkEnd(line, m[0], m[1])
+		target := line[start:end]
+		if strings.HasPrefix(strings.ToLower(target), "www.") {
+			target = "http://" + target
+		}
+		links = append(links, link{Start: start, End: end, Target: target, URL: true})
+	}
+
+	// Paths are looked for outside the URLs
+	rest := []byte(line)
+	for _, l := range links {
+		for i := l.Start; i < l.End; i++ {
+			rest[i] = ' '
+		}
+	}
+	var paths []link
+	for _, m := range pathPattern.FindAllSubmatchIndex(rest, -1) {
+		if p, ok := pathLink(line, m); ok {
+			paths = append(paths, p)
+		}
+	}
+	if len(paths) == 0 {
+		return links
+	}
+	return mergeLinks(links, paths)
+}
+
+// trimLinkEnd drops punctuation that ends a sentence, or closes brackets opened
+// before the URL, from the end of a match
+func trimLinkEnd(line string, start, end int) int {
+	for end > start {
+		c := line[end-1]
+		switch {
+		case strings.IndexByte(".,;:!?", c) >= 0:
+		case c == ')' && strings.Count(line[start:end], "(") < strings.Count(line[start:end], ")"):
+		case c == ']' && strings.Count(line[start:end], "[") < strings.Count(line[start:end], "]"):
+		default:
+			return end
+		}
+		end--
+	}
+	return end
+}
+
+// pathLink turns a match of pathPattern into a link, if it looks enough like a
+// path: rooted, or a relative path or file:line reference to a file with an
+// extension
+func pathLink(line string, m []int) (link, bool) {
+	start, end := m[0], m[1]
+	if start > 0 && !strings.ContainsRune(" \t\"'(=[,:", rune(line[start-1])) {
+		return link{}, false
+	}
+	path := line[m[2]:m[3]]
+	lineNum := 0
+	for _, g := range []int{4, 6} {
+		if m[g] >= 0 {
+			lineNum, _ = strconv.Atoi(line[m[g]:m[g+1]])
+		}
+	}
+	if lineNum == 0 {
+		trimmed := strings.TrimRight(path, ".-")
+		end -= len(path) - len(trimmed)
+		path = trimmed
+	}
+
+	separated := strings.ContainsAny(path, `/\`)
+	switch {
+	case rootedPath.MatchString(path):
+		if len(path) < 2 {
+			return link{}, false
+		}
+	case separated:
+		if !fileExtension.MatchString(path) {
+			return link{}, false
+		}
+	default:
+		if lineNum == 0 || !fileExtension.MatchString(path) {
+			return link{}, false
+		}
+	}
+	return link{Start: start, End: end, Target: path, Line: lineNum}, true
+}
+
+// mergeLinks combines two sorted lists of links that do not overlap
+func mergeLinks(a, b []link) []link {
+	merged := make([]link, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if a[0].Start < b[0].Start {
+			merged, a = append(merged, a[0]), a[1:]
+		} else {
+			merged, b = append(merged, b[0]), b[1:]
+		}
+	}
+	return append(append(merged, a...), b...)
+}
+
+// resetLinks drops the links found in the content, as it changed
+func (fv *FileViewer) resetLinks() {
+	fv.links = &linkCache{lines: make(map[int][]link), includes: includeRulesFor(fv.FileName)}
+	fv.selectedLink = nil
+}
+
+// forgetLinks drops the links found from line start onward, for lines that
+// changed
+func (fv *FileViewer) forgetLinks(start int) {
+	if fv.links == nil {
+		return
+	}
+	for i := range fv.links.lines {
+		if i >= start {
+			delete(fv.links.lines, i)
+		}
+	}
+	if fv.selectedLink != nil && fv.selectedLink.Line >= start {
+		fv.selectedLink = nil
+	}
+}
+
+// linksOf returns the links of line i, finding them on first use
+func (fv *FileViewer) linksOf(i int) []link {
+	if fv.links == nil || fv.hex || i < 0 || i >= len(fv.Content) {
+		return nil
+	}
+	links, ok := fv.links.lines[i]
+	if !ok {
+		links = withIncludes(findLinks(fv.Content[i]), findIncludes(fv.Content[i], fv.links.includes))
+		fv.links.lines[i] = links
+	}
+	return links
+}
+
+// markLinks underlines the links of line i in its styled text, showing the
+// selected one in reverse video
+func (fv *FileViewer) markLinks(i int, styled string) string {
+	links := fv.linksOf(i)
+	if len(links) == 0 {
+		return styled
+	}
+	ranges := make([]spell.Range, len(links))
+	for n, l := range links {
+		ranges[n] = spell.Range{Start: l.Start, End: l.End}
+	}
+	styled = markRanges(styled, ranges, underlineOn, underlineOff)
+	if sel := fv.selectedLink; sel != nil && sel.Line == i && sel.Index < len(ranges) {
+		styled = markRanges(styled, ranges[sel.Index:sel.Index+1], reverseOn, reverseOff)
+	}
+	return styled
+}
+
+// screenLines returns the first and last content lines on screen
+func (fv *FileViewer) screenLines() (int, int) {
+	lines := fv.visibleLines()
+	if len(lines) == 0 {
+		return 0, 0
+	}
+	return lines[0], lines[len(lines)-1]
+}
+
+// jumpLink selects the next (dir > 0) or previous (dir < 0) link, scrolling to
+// it when it is off screen. Without a selection, the search starts from the
+// top or bottom of the screen.
+func (fv *FileViewer) jumpLink(dir int) {
+	top, bottom := fv.screenLines()
+	from := linkRef{Line: top, Index: -1}
+	if dir < 0 {
+		from = linkRef{Line: bottom, Index: len(fv.linksOf(bottom))}
+	}
+	if fv.selectedLink != nil {
+		from = *fv.selectedLink
+	}
+
+	index := from.Index + dir
+	for i := from.Line; i >= 0 && i < len(fv.Content); i += dir {
+		if i != from.Line {
+			if fv.filterActive() && !fv.filterMatches(fv.Filter, i) {
+				continue
+			}
+			index = 0
+			if dir < 0 {
+				index = len(fv.linksOf(i)) - 1
+			}
+		}
+		if links := fv.linksOf(i); index >= 0 && index < len(links) {
+			fv.selectedLink = &linkRef{Line: i, Index: index}
+			if i < top || i > bottom {
+				fv.ScrollPos = fv.rowOf(i)
+			}
+			fv.StatusMessage = fmt.Sprintf("Link: %s (o: open)", links[index].Target)
+			return
+		}
+	}
+	fv.StatusMessage = "No more links"
+}
+
+// openLink opens the selected link, or the first one on screen: URLs in the
+// default browser, paths in the viewer or browser
+func (fv *FileViewer) openLink() {
+	if fv.selectedLink == nil {
+		for _, i := range fv.visibleLines() {
+			if len(fv.linksOf(i)) > 0 {
+				fv.selectedLink = &linkRef{Line: i}
+				break
+			}
+		}
+	}
+	sel := fv.selectedLink
+	if sel == nil || sel.Index >= len(fv.linksOf(sel.Line)) {
+		fv.StatusMessage = "No link on screen (]u/[u: next/prev link)"
+		return
+	}
+
+	l := fv.linksOf(sel.Line)[sel.Index]
+	switch {
+	case l.URL:
+		fv.StatusMessage = fmt.Sprintf("Opening %s", l.Target)
+		fv.pendingCmd = openURLCmd(l.Target)
+	case l.rule != nil:
+		path, ok := fv.resolveInclude(l)
+		if !ok {
+			fv.StatusMessage = fmt.Sprintf("Cannot find %s next to the file or in the folders above", l.Target)
+			return
+		}
+		fv.pendingCmd = func() tea.Msg { return openLinkMsg{Path: path} }
+	default:
+		path, ok := fv.resolveLink(l.Target)
+		if !ok {
+			fv.StatusMessage = fmt.Sprintf("Not found: %s", l.Target)
+			return
+		}
+		fv.pendingCmd = func() tea.Msg { return openLinkMsg{Path: path, Line: l.Line} }
+	}
+}
+
+// resolveLink finds the file a path refers to. Relative paths are looked up
+// next to the viewed file, then in the working directory.
+func (fv *FileViewer) resolveLink(path string) (string, bool) {
+	if filepath.Separator == '/' {
+		path = strings.ReplaceAll(path, `\`, "/")
+	}
+	if rest, ok := strings.CutPrefix(path, "~"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+
+	candidates := []string{path}
+	if !filepath.IsAbs(path) && !strings.HasPrefix(path, `\\`) {
+		candidates = nil
+		if fv.FilePath != "" {
+			candidates = append(candidates, filepath.Join(filepath.Dir(fv.FilePath), path))
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			candidates = append(candidates, abs)
+		}
+	}
+	for _, candidate := range candidates {
+		if _, err := vfs.Default.Stat(candidate); err == nil {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// openURLCmd hands a URL to the default browser in the background
+func openURLCmd(url string) tea.Cmd {
+	return func() tea.Msg {
+		return urlOpenedMsg{URL: url, Err: launch.Open(url)}
+	}
+}
+
+// openLink views or browses a path found in a viewed file, at the line given
+// with it. Closing the viewer returns to the file the link was in.
+func (m *Model) openLink(msg openLinkMsg) {
+	m.openPath(msg.Path)
+	fv := m.FileViewer
+	if msg.Line <= 0 || m.Mode != FileViewMode || fv == nil || fv.FilePath != msg.Path {
+		return
+	}
+	line := msg.Line - 1 - max(fv.firstLine(), 0)
+	if line < 0 || line >= len(fv.Content) {
+		fv.StatusMessage = fmt.Sprintf("Line %d is not loaded", msg.Line)
+		return
+	}
+	fv.ScrollPos = fv.rowOf(line)
+	fv.StatusMessage = fmt.Sprintf("Line %d", msg.Line)
+}
+
+// updateLinks handles opening the links of viewed files
+func (m Model) updateLinks(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case openLinkMsg:
+		m.openLink(msg)
+
+	case urlOpenedMsg:
+		if msg.Err != nil {
+			m.setStatus(fmt.Sprintf("Error: %v", msg.Err))
+			return m, nil
+		}
+		m.setStatus(fmt.Sprintf("Opened %s in the browser", msg.URL))
+	}
+	return m, nil
+}
@@ -0,0 +1,225 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ListEntry is a single row in a ListPanel
+type ListEntry struct {
+	Label     string      // Rendered text of the row
+	Data      interface{} // Arbitrary payload used by actions
+	Msg       tea.Msg     // Message emitted when the entry is chosen with Enter
+	Separator bool        // Non-selectable row (headers, graph lines)
+}
+
+// ListAction maps a key in a ListPanel to a message built from the selected entry
+type ListAction struct {
+	Key  string
+	Desc string
+	Msg  func(entry ListEntry) tea.Msg
+}
+
+// ListPanel is a scrollable, selectable list used by picker-style modes
+type ListPanel struct {
+	Title         string
+	Subtitle      string
+	Items         []ListEntry
+	Actions       []ListAction
+	Cursor        int
+	Width         int
+	Height        int
+	StatusMessage string
+}
+
+// NewListPanel creates a list panel with the cursor on the first selectable entry
+func NewListPanel(title string, items []ListEntry) ListPanel {
+	lp := ListPanel{
+		Title: title,
+		Items: items,
+	}
+	lp.Cursor = lp.nextSelectable(-1, 1)
+	if lp.Cursor < 0 {
+		lp.Cursor = 0
+	}
+	return lp
+}
+
+// Selected returns the entry under the cursor
+func (lp *ListPanel) Selected() (ListEntry, bool) {
+	if lp.Cursor < 0 || lp.Cursor >= len(lp.Items) || lp.Items[lp.Cursor].Separator {
+		return ListEntry{}, false
+	}
+	return lp.Items[lp.Cursor], true
+}
+
+// SetCursor moves the cursor to index, snapping to the nearest selectable entry
+func (lp *ListPanel) SetCursor(index int) {
+	if index >= len(lp.Items) {
+		index = len(lp.Items) - 1
+	}
+	if index < 0 {
+		index = 0
+	}
+	lp.Cursor = index
+	if index < len(lp.Items) && !lp.Items[index].Separator {
+		return
+	}
+	if next := lp.nextSelectable(index, 1); next >= 0 {
+		lp.Cursor = next
+	} else if prev := lp.nextSelectable(index, -1); prev >= 0 {
+		lp.Cursor = prev
+	}
+}
+
+// nextSelectable finds the next selectable index from start in the given direction
+func (lp *ListPanel) nextSelectable(start, dir int) int {
+	for i := start + dir; i >= 0 && i < len(lp.Items); i += dir {
+		if !lp.Items[i].Separator {
+			return i
+		}
+	}
+	return -1
+}
+
+// moveCursor moves the cursor by delta entries, skipping separators
+func (lp *ListPanel) moveCursor(delta int) {
+	dir := 1
+	if delta < 0 {
+		dir = -1
+		delta = -delta
+	}
+	for ; delta > 0; delta-- {
+		next := lp.nextSelectable(lp.Cursor, dir)
+		if next < 0 {
+			break
+		}
+		lp.Cursor = next
+	}
+}
+
+// Update handles keyboard input for the list panel
+func (lp *ListPanel) Update(msg tea.KeyMsg) tea.Cmd {
+	maxVisible := lp.Height - 7
+
+	switch msg.String() {
+	case "up", "k":
+		lp.moveCursor(-1)
+
+	case "down", "j":
+		lp.moveCursor(1)
+
+	case "g":
+		if first := lp.nextSelectable(-1, 1); first >= 0 {
+			lp.Cursor = first
+		}
+
+	case "G":
+		if last := lp.nextSelectable(len(lp.Items), -1); last >= 0 {
+			lp.Cursor = last
+		}
+
+	case "pgup", "ctrl+u":
+		lp.moveCursor(-maxVisible / 2)
+
+	case "pgdown", "ctrl+d":
+		lp.moveCursor(maxVisible / 2)
+
+	case "enter":
+		if entry, ok := lp.Selected(); ok && entry.Msg != nil {
+			return func() tea.Msg { return entry.Msg }
+		}
+
+	default:
+		for _, action := range lp.Actions {
+			if action.Key != msg.String() {
+				continue
+			}
+			// Actions receive an empty entry when nothing is selected
+			entry, _ := lp.Selected()
+			if m := action.Msg(entry); m != nil {
+				return func() tea.Msg { return m }
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
+// View renders the list panel
+func (lp ListPanel) View() string {
+	var b strings.Builder
+
+	// Title
+	b.WriteString(theme.Title.Render(lp.Title) + "\n")
+	if lp.Subtitle != "" {
+		b.WriteString(lp.Subtitle + "\n")
+	}
+	b.WriteString("\n")
+
+	if len(lp.Items) == 0 {
+		b.WriteString(theme.Dim.Render("(empty)") + "\n")
+	}
+
+	// Calculate visible window around the cursor
+	visibleStart := 0
+	visibleEnd := len(lp.Items)
+	maxVisible := lp.Height - 7
+
+	if maxVisible > 0 && len(lp.Items) > maxVisible {
+		if lp.Cursor >= maxVisible/2 {
+			visibleStart = lp.Cursor - maxVisible/2
+		}
+		visibleEnd = visibleStart + maxVisible
+		if visibleEnd > len(lp.Items) {
+			visibleEnd = len(lp.Items)
+			visibleStart = visibleEnd - maxVisible
+			if visibleStart < 0 {
+				visibleStart = 0
+			}
+		}
+	}
+
+	for i := visibleStart; i < visibleEnd; i++ {
+		item := lp.Items[i]
+		label := item.Label
+		if lp.Width > 4 && visualLength(label) > lp.Width-4 {
+			label = truncateAtVisualWidth(label, lp.Width-7) + "..."
+		}
+
+		if item.Separator {
+			b.WriteString("  " + label + "\n")
+			continue
+		}
+
+		line := "  " + label
+		if i == lp.Cursor {
+			line = theme.Selected.Render("> " + label)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	// Status bar
+	if lp.StatusMessage != "" {
+		b.WriteString(theme.Status.Render(lp.StatusMessage) + "\n")
+	} else if len(lp.Items) > 0 {
+		b.WriteString(theme.Status.Render(fmt.Sprintf("%d/%d", lp.Cursor+1, len(lp.Items))) + "\n")
+	}
+
+	// Help text
+	help := "↑/k: up | ↓/j: down | Enter: open"
+	for _, action := range lp.Actions {
+		key := action.Key
+		if key == " " {
+			key = "Space"
+		}
+		help += fmt.Sprintf(" | %s: %s", key, action.Desc)
+	}
+	help += " | q/Esc: back"
+	b.WriteString(theme.Help.Render(help))
+
+	return b.String()
+}
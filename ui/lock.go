@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/config"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// idleCheckInterval is how often the time since the last key is checked
+const idleCheckInterval = 10 * time.Second
+
+// idleCheckMsg checks whether the screen should be locked
+type idleCheckMsg struct{}
+
+// screenLock is the state of the blanked screen
+type screenLock struct {
+	Buffer string // Password typed so far
+	Wrong  bool   // Whether the last password was wrong
+}
+
+// idleCheckCmd schedules the next idle check
+func idleCheckCmd() tea.Cmd {
+	return tea.Tick(idleCheckInterval, func(time.Time) tea.Msg {
+		return idleCheckMsg{}
+	})
+}
+
+// checkIdle locks the screen once the idle_lock time has passed without input
+func (m *Model) checkIdle() tea.Cmd {
+	if m.idleLock <= 0 {
+		return nil
+	}
+	if m.lock == nil && time.Since(m.lastInput) >= m.idleLock {
+		m.lock = &screenLock{}
+	}
+	return idleCheckCmd()
+}
+
+// updateLock handles keys while the screen is locked. Without a password any
+// key unlocks; with one, quitting is not possible either, as it would leave
+// the console open.
+func (m *Model) updateLock(msg tea.KeyMsg) {
+	if m.Settings.LockPasswordHash == "" {
+		m.lock = nil
+		return
+	}
+
+	switch msg.String() {
+	case "enter":
+		if config.CheckPassword(m.Settings.LockPasswordHash, m.lock.Buffer) {
+			m.lock = nil
+			return
+		}
+		m.lock.Buffer = ""
+		m.lock.Wrong = true
+
+	case "esc":
+		m.lock.Buffer = ""
+
+	case "backspace":
+		m.lock.Buffer = trimLastRune(m.lock.Buffer)
+
+	default:
+		m.lock.Buffer += inputText(msg)
+	}
+}
+
+// lockView renders the blanked screen
+func (m Model) lockView() string {
+	text := "🔒 Locked\n\n" + theme.Dim.Render("Press any key to resume")
+	if m.Settings.LockPasswordHash != "" {
+		text = "🔒 Locked\n\nPassword: " + strings.Repeat("•", len([]rune(m.lock.Buffer))) + "█"
+		if m.lock.Wrong {
+			text += "\n\n" + theme.Levels[levelError].Render("Wrong password")
+		}
+	}
+	return lipgloss.Place(m.Width, m.Height, lipgloss.Center, lipgloss.Center, text)
+}
+
+// compileIdleLock parses the idle_lock setting
+func compileIdleLock(setting string) (time.Duration, error) {
+	if setting == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(setting)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid idle_lock %q (use a duration such as 15m)", setting)
+	}
+	return d, nil
+}
@@ -0,0 +1,244 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// logLevel is the severity detected on a log line
+type logLevel int
+
+const (
+	levelNone logLevel = iota
+	levelTrace
+	levelDebug
+	levelInfo
+	levelWarn
+	levelError
+	levelFatal
+)
+
+// logLevelNames maps level keywords found in logs to levels
+var logLevelNames = map[string]logLevel{
+	"trace":    levelTrace,
+	"debug":    levelDebug,
+	"dbg":      levelDebug,
+	"info":     levelInfo,
+	"notice":   levelInfo,
+	"warn":     levelWarn,
+	"warning":  levelWarn,
+	"error":    levelError,
+	"err":      levelError,
+	"fatal":    levelFatal,
+	"critical": levelFatal,
+	"crit":     levelFatal,
+	"panic":    levelFatal,
+}
+
+// logLevelPattern finds the first level keyword on a line
+var logLevelPattern = regexp.MustCompile(`(?i)\b(trace|debug|dbg|info|notice|warn|warning|error|err|fatal|critical|crit|panic)\b`)
+
+// isLogFile reports whether fileName should open in log mode
+func isLogFile(fileName string) bool {
+	return strings.EqualFold(filepath.Ext(fileName), ".log")
+}
+
+// parseLogLevel parses a level name such as "warn" used in :filter level>=warn
+func parseLogLevel(name string) (logLevel, bool) {
+	level, ok := logLevelNames[strings.ToLower(name)]
+	return level, ok
+}
+
+// detectLevels assigns a level to each line from start onward; lines without a
+// level keyword (stack traces, continuations) inherit the previous line's level
+func (fv *FileViewer) detectLevels(start int) {
+	if start > len(fv.levels) {
+		start = len(fv.levels)
+	}
+	fv.levels = fv.levels[:start]
+
+	previous := levelNone
+	if start > 0 {
+		previous = fv.levels[start-1]
+	}
+	for i := start; i < len(fv.Content); i++ {
+		level := previous
+		if match := logLevelPattern.FindString(fv.Content[i]); match != "" {
+			level = logLevelNames[strings.ToLower(match)]
+		}
+		fv.levels = append(fv.levels, level)
+		previous = level
+	}
+}
+
+// setLogMode turns log mode on or off
+func (fv *FileViewer) setLogMode(enabled bool) {
+	fv.LogMode = enabled
+	if enabled {
+		fv.detectLevels(0)
+		fv.detectTimes(0)
+	}
+}
+
+// styleLogLine colors line i of the plain content by its detected level
+func (fv *FileViewer) styleLogLine(i int) (string, bool) {
+	if i >= len(fv.levels) {
+		return "", false
+	}
+	style, ok := theme.Levels[fv.levels[i]]
+	if !ok {
+		return "", false
+	}
+	return style.Render(fv.Content[i]), true
+}
+
+// lineFilter hides lines that do not match a level threshold or pattern
+type lineFilter struct {
+	Desc     string         // Filter as typed, for display
+	MinLevel logLevel       // Minimum level to show (levelNone to ignore levels)
+	Pattern  *regexp.Regexp // Pattern lines must match (nil to ignore)
+}
+
+// matches reports whether line i passes the filter
+func (fv *FileViewer) filterMatches(f *lineFilter, i int) bool {
+	if f.MinLevel != levelNone {
+		if i >= len(fv.levels) || fv.levels[i] < f.MinLevel {
+			return false
+		}
+	}
+	if f.Pattern != nil && !f.Pattern.MatchString(fv.Content[i]) {
+		return false
+	}
+	return true
+}
+
+// parseFilter parses "level>=warn" or "/regex/" into a filter
+func parseFilter(expr string) (*lineFilter, error) {
+	expr = strings.TrimSpace(expr)
+
+	if strings.HasPrefix(expr, "/") {
+		pattern := strings.TrimPrefix(expr, "/")
+		pattern = strings.TrimSuffix(pattern, "/")
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %v", err)
+		}
+		return &lineFilter{Desc: expr, Pattern: re}, nil
+	}
+
+	if strings.HasPrefix(expr, "level") {
+		rest := strings.TrimSpace(strings.TrimPrefix(expr, "level"))
+		op := ""
+		for _, candidate := range []string{">=", "=", ">"} {
+			if strings.HasPrefix(rest, candidate) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			return nil, fmt.Errorf("usage: :filter level>=<level>")
+		}
+		level, ok := parseLogLevel(strings.TrimSpace(strings.TrimPrefix(rest, op)))
+		if !ok {
+			return nil, fmt.Errorf("unknown level (use trace, debug, info, warn, error or fatal)")
+		}
+		if op == ">" && level < levelFatal {
+			level++
+		}
+		return &lineFilter{Desc: expr, MinLevel: level}, nil
+	}
+
+	return nil, fmt.Errorf("usage: :filter level>=<level> | :filter /regex/")
+}
+
+// setFilter applies a filter, keeping the current line in view where possible
+func (fv *FileViewer) setFilter(f *lineFilter) {
+	line := fv.lineAt(fv.ScrollPos)
+	fv.Filter = f
+	fv.FilterEnabled = f != nil
+	if f != nil && f.MinLevel != levelNone && len(fv.levels) < len(fv.Content) {
+		// Level filters need levels even outside log mode
+		fv.detectLevels(0)
+	}
+	fv.refilter(0)
+	fv.ScrollPos = fv.rowOf(line)
+}
+
+// toggleFilter shows or hides filtered lines without discarding the filter
+func (fv *FileViewer) toggleFilter() {
+	if fv.Filter == nil {
+		fv.StatusMessage = "No filter set (try :filter level>=warn)"
+		return
+	}
+	line := fv.lineAt(fv.ScrollPos)
+	fv.FilterEnabled = !fv.FilterEnabled
+	fv.refilter(0)
+	fv.ScrollPos = fv.rowOf(line)
+	if fv.FilterEnabled {
+		fv.StatusMessage = fmt.Sprintf("Filter on: %s", fv.Filter.Desc)
+	} else {
+		fv.StatusMessage = "Filter off (showing all lines)"
+	}
+}
+
+// refilter rebuilds the list of visible lines from line start onward
+func (fv *FileViewer) refilter(start int) {
+	if !fv.FilterEnabled || fv.Filter == nil {
+		fv.filtered = nil
+		return
+	}
+
+	// Drop rows at or after start, then re-evaluate those lines
+	keep := sort.SearchInts(fv.filtered, start)
+	fv.filtered = fv.filtered[:keep]
+	for i := start; i < len(fv.Content); i++ {
+		if fv.filterMatches(fv.Filter, i) {
+			fv.filtered = append(fv.filtered, i)
+		}
+	}
+	if fv.filtered == nil {
+		fv.filtered = []int{}
+	}
+}
+
+// filterActive reports whether some lines are currently hidden
+func (fv *FileViewer) filterActive() bool {
+	return fv.FilterEnabled && fv.filtered != nil
+}
+
+// rowCount returns the number of display rows (lines remaining after filtering)
+func (fv *FileViewer) rowCount() int {
+	if fv.filterActive() {
+		return len(fv.filtered)
+	}
+	return len(fv.Content)
+}
+
+// lineAt returns the content line shown at display row
+func (fv *FileViewer) lineAt(row int) int {
+	if !fv.filterActive() {
+		return row
+	}
+	if row < 0 || len(fv.filtered) == 0 {
+		return 0
+	}
+	if row >= len(fv.filtered) {
+		return fv.filtered[len(fv.filtered)-1]
+	}
+	return fv.filtered[row]
+}
+
+// rowOf returns the display row showing line, or the next visible row after it
+func (fv *FileViewer) rowOf(line int) int {
+	if !fv.filterActive() {
+		return line
+	}
+	row := sort.SearchInts(fv.filtered, line)
+	if row >= len(fv.filtered) && row > 0 {
+		row = len(fv.filtered) - 1
+	}
+	return row
+}
@@ -0,0 +1,241 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultGapSeconds is the gap threshold used by ]g / [g until :gap sets another
+const defaultGapSeconds = 5
+
+// elapsedColumnWidth is the width of the elapsed-time column
+const elapsedColumnWidth = 11
+
+// Elapsed column modes
+const (
+	elapsedOff = iota
+	elapsedSinceStart
+	elapsedSincePrevious
+)
+
+// logTimestampPattern matches a timestamp at the start of a line, optionally bracketed
+var logTimestampPattern = regexp.MustCompile(`^\[?(` +
+	`\d{4}[-/]\d{2}[-/]\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?` + // 2024-01-02 15:04:05.000Z
+	`|[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}` + // Jan  2 15:04:05 (syslog)
+	`|\d{2}:\d{2}:\d{2}(?:[.,]\d+)?` + // 15:04:05.000
+	`)`)
+
+// logTimestampLayouts are tried in order to parse a matched timestamp
+var logTimestampLayouts = []string{
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006/01/02 15:04:05.999999999",
+	"Jan _2 15:04:05",
+	"15:04:05.999999999",
+}
+
+// parseLogTimestamp extracts the leading timestamp of a log line
+func parseLogTimestamp(line string) (time.Time, bool) {
+	match := logTimestampPattern.FindStringSubmatch(line)
+	if match == nil {
+		return time.Time{}, false
+	}
+
+	// Accept comma decimal separators (log4j style)
+	text := strings.Replace(match[1], ",", ".", 1)
+	for _, layout := range logTimestampLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseGotoTime parses the argument of :goto as a clock time ("14:32", "14:32:05")
+// or a full date and time ("2024-01-02 14:32")
+func parseGotoTime(arg string) (time.Time, bool, error) {
+	arg = strings.TrimSpace(arg)
+	clockLayouts := []string{"15:04:05.999999999", "15:04"}
+	for _, layout := range clockLayouts {
+		if t, err := time.Parse(layout, arg); err == nil {
+			return t, true, nil
+		}
+	}
+
+	fullLayouts := []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+	for _, layout := range fullLayouts {
+		if t, err := time.Parse(layout, arg); err == nil {
+			return t, false, nil
+		}
+	}
+
+	return time.Time{}, false, fmt.Errorf("invalid time '%s' (use HH:MM[:SS] or YYYY-MM-DD HH:MM)", arg)
+}
+
+// detectTimes parses leading timestamps of lines from start onward
+func (fv *FileViewer) detectTimes(start int) {
+	if start > len(fv.times) {
+		start = len(fv.times)
+	}
+	fv.times = fv.times[:start]
+	for i := start; i < len(fv.Content); i++ {
+		t, _ := parseLogTimestamp(fv.Content[i])
+		fv.times = append(fv.times, t)
+	}
+}
+
+// clockOf returns the time of day of t as a duration since midnight
+func clockOf(t time.Time) time.Duration {
+	h, m, s := t.Clock()
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute +
+		time.Duration(s)*time.Second + time.Duration(t.Nanosecond())
+}
+
+// gotoTime jumps to the first line at or after the given time
+func (fv *FileViewer) gotoTime(arg string) {
+	target, clockOnly, err := parseGotoTime(arg)
+	if err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	if len(fv.times) < len(fv.Content) {
+		fv.detectTimes(0)
+	}
+
+	for i, t := range fv.times {
+		if t.IsZero() || (fv.filterActive() && !fv.filterMatches(fv.Filter, i)) {
+			continue
+		}
+		reached := !t.Before(target)
+		if clockOnly {
+			reached = clockOf(t) >= clockOf(target)
+		}
+		if reached {
+			fv.ScrollPos = fv.rowOf(i)
+			fv.StatusMessage = fmt.Sprintf("Jumped to %s (line %d)", fv.times[i].Format("2006-01-02 15:04:05"), i+1)
+			return
+		}
+	}
+
+	fv.StatusMessage = fmt.Sprintf("No lines at or after %s", arg)
+}
+
+// previousTimestamp returns the index of the last timestamped line before i, or -1
+func (fv *FileViewer) previousTimestamp(i int) int {
+	for j := i - 1; j >= 0; j-- {
+		if !fv.times[j].IsZero() {
+			return j
+		}
+	}
+	return -1
+}
+
+// jumpGap moves to the next (dir > 0) or previous (dir < 0) line that follows a
+// pause of more than GapSeconds since the previous timestamped line
+func (fv *FileViewer) jumpGap(dir int) {
+	if len(fv.times) < len(fv.Content) {
+		fv.detectTimes(0)
+	}
+	if fv.GapSeconds <= 0 {
+		fv.GapSeconds = defaultGapSeconds
+	}
+	threshold := time.Duration(fv.GapSeconds) * time.Second
+	current := fv.lineAt(fv.ScrollPos)
+
+	isGap := func(i int) bool {
+		if fv.times[i].IsZero() {
+			return false
+		}
+		prev := fv.previousTimestamp(i)
+		return prev >= 0 && fv.times[i].Sub(fv.times[prev]) > threshold
+	}
+
+	for i := current + dir; i >= 0 && i < len(fv.times); i += dir {
+		if !isGap(i) {
+			continue
+		}
+		fv.ScrollPos = fv.rowOf(i)
+		prev := fv.previousTimestamp(i)
+		fv.StatusMessage = fmt.Sprintf("Gap of %s before line %d", fv.times[i].Sub(fv.times[prev]).Round(time.Millisecond), i+1)
+		return
+	}
+
+	fv.StatusMessage = fmt.Sprintf("No more gaps longer than %ds", fv.GapSeconds)
+}
+
+// setGap sets the gap threshold and jumps to the next gap
+func (fv *FileViewer) setGap(arg string) {
+	if arg != "" {
+		seconds, err := strconv.Atoi(arg)
+		if err != nil || seconds <= 0 {
+			fv.StatusMessage = "Usage: :gap [seconds]"
+			return
+		}
+		fv.GapSeconds = seconds
+	}
+	fv.jumpGap(1)
+}
+
+// cycleElapsed switches the elapsed column between off, since start and since previous line
+func (fv *FileViewer) cycleElapsed() {
+	if len(fv.times) < len(fv.Content) {
+		fv.detectTimes(0)
+	}
+	fv.ElapsedMode = (fv.ElapsedMode + 1) % 3
+	switch fv.ElapsedMode {
+	case elapsedOff:
+		fv.StatusMessage = "Elapsed column hidden"
+	case elapsedSinceStart:
+		fv.StatusMessage = "Elapsed time since first timestamp"
+	case elapsedSincePrevious:
+		fv.StatusMessage = "Elapsed time since previous timestamp"
+	}
+}
+
+// elapsedColumn renders the elapsed-time cell for line i
+func (fv *FileViewer) elapsedColumn(i int) string {
+	blank := strings.Repeat(" ", elapsedColumnWidth)
+	if i >= len(fv.times) || fv.times[i].IsZero() {
+		return blank
+	}
+
+	var ref int
+	switch fv.ElapsedMode {
+	case elapsedSinceStart:
+		ref = -1
+		for j, t := range fv.times {
+			if !t.IsZero() {
+				ref = j
+				break
+			}
+		}
+	case elapsedSincePrevious:
+		ref = fv.previousTimestamp(i)
+	}
+	if ref < 0 {
+		return theme.Dim.Render(fmt.Sprintf("%*s ", elapsedColumnWidth-1, "+0s"))
+	}
+
+	elapsed := fv.times[i].Sub(fv.times[ref])
+	return theme.Dim.Render(fmt.Sprintf("%*s ", elapsedColumnWidth-1, "+"+formatElapsed(elapsed)))
+}
+
+// formatElapsed formats a duration compactly, e.g. 850ms, 12.4s, 3m05s, 2h10m
+func formatElapsed(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
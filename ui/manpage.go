@@ -0,0 +1,418 @@
+package ui
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// manPageWidth is the fill width used when rendering roff sources
+const manPageWidth = 78
+
+// manExtensionPattern matches man page sources such as ls.1 or printf.3p
+var manExtensionPattern = regexp.MustCompile(`^\.([1-9][a-z]*|man)$`)
+
+// isManPage reports whether fileName looks like a roff man page source
+func isManPage(fileName string) bool {
+	return manExtensionPattern.MatchString(strings.ToLower(filepath.Ext(fileName)))
+}
+
+// hasOverstrike reports whether text uses backspace overstrike for bold/underline
+func hasOverstrike(text string) bool {
+	return strings.Contains(text, "\b")
+}
+
+// renderOverstrike converts backspace overstrike sequences ("X\bX" bold, "_\bX" underline)
+// into a plain line and an ANSI styled line
+func renderOverstrike(line string) (string, string) {
+	const (
+		attrNone = iota
+		attrBold
+		attrUnderline
+	)
+
+	runes := []rune(line)
+	var plain, styled strings.Builder
+	current := attrNone
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		attr := attrNone
+
+		// Collapse chains like "X\bX\bX" into a single character
+		for i+2 < len(runes) && runes[i+1] == '\b' {
+			next := runes[i+2]
+			switch {
+			case r == '_' && next != '_':
+				attr = attrUnderline
+			case r == next:
+				if attr != attrUnderline {
+					attr = attrBold
+				}
+			}
+			r = next
+			i += 2
+		}
+		if r == '\b' {
+			continue
+		}
+
+		if attr != current {
+			if current != attrNone {
+				styled.WriteString("\x1b[0m")
+			}
+			switch attr {
+			case attrBold:
+				styled.WriteString("\x1b[1m")
+			case attrUnderline:
+				styled.WriteString("\x1b[4m")
+			}
+			current = attr
+		}
+
+		plain.WriteRune(r)
+		styled.WriteRune(r)
+	}
+
+	if current != attrNone {
+		styled.WriteString("\x1b[0m")
+	}
+
+	return plain.String(), styled.String()
+}
+
+// detectSections returns the indexes of lines that look like section headings:
+// unindented all-caps lines (NAME, SYNOPSIS) or short unindented lines ending in ':'
+func detectSections(lines []string) []int {
+	var sections []int
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || line[0] == ' ' {
+			continue
+		}
+
+		if strings.HasSuffix(trimmed, ":") && len(strings.Fields(trimmed)) <= 4 {
+			sections = append(sections, i)
+			continue
+		}
+
+		hasLetter := false
+		allCaps := true
+		for _, r := range trimmed {
+			if unicode.IsLower(r) {
+				allCaps = false
+				break
+			}
+			if unicode.IsLetter(r) {
+				hasLetter = true
+			}
+		}
+		if allCaps && hasLetter {
+			sections = append(sections, i)
+		}
+	}
+	return sections
+}
+
+// roffWord is a word of filled text with its plain length
+type roffWord struct {
+	text  string // Word encoded with overstrike sequences
+	width int    // Visible width of the word
+}
+
+// roffRenderer converts a subset of man(7) roff markup into overstrike text
+type roffRenderer struct {
+	out      []string
+	words    []roffWord
+	indent   int
+	tagLine  bool // Next output line is a .TP tag
+	noFill   bool
+	baseFont byte // Font used by \fR / \fP: 'R', 'B' or 'I'
+}
+
+// renderRoff renders a roff man page source into overstrike-formatted text
+func renderRoff(source string) string {
+	r := &roffRenderer{indent: 7, baseFont: 'R'}
+
+	for _, line := range strings.Split(source, "\n") {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			r.request(line[1:])
+			continue
+		}
+		r.text(line, 'R')
+	}
+	r.flush()
+
+	return strings.Join(r.out, "\n")
+}
+
+// request handles a single roff request line
+func (r *roffRenderer) request(line string) {
+	name, args := splitRoffRequest(line)
+
+	switch name {
+	case `\"`, "":
+		// Comment or empty request
+
+	case "TH":
+		r.flush()
+		if len(args) > 0 {
+			title := args[0]
+			if len(args) > 1 {
+				title += "(" + args[1] + ")"
+			}
+			r.out = append(r.out, overstrike(title, 'B'), "")
+		}
+
+	case "SH":
+		r.flush()
+		r.indent = 7
+		r.blank()
+		r.out = append(r.out, overstrike(strings.ToUpper(strings.Join(args, " ")), 'B'))
+
+	case "SS":
+		r.flush()
+		r.indent = 7
+		r.blank()
+		r.out = append(r.out, "   "+overstrike(strings.Join(args, " "), 'B'))
+
+	case "PP", "LP", "P":
+		r.flush()
+		r.indent = 7
+		r.blank()
+
+	case "TP":
+		r.flush()
+		r.indent = 7
+		r.blank()
+		r.tagLine = true
+
+	case "IP":
+		r.flush()
+		r.indent = 7
+		r.blank()
+		if len(args) > 0 {
+			r.text(args[0], 'R')
+			r.flush()
+		}
+		r.indent = 11
+
+	case "RS":
+		r.flush()
+		r.indent += 4
+
+	case "RE":
+		r.flush()
+		r.indent -= 4
+		if r.indent < 7 {
+			r.indent = 7
+		}
+
+	case "br":
+		r.flush()
+
+	case "sp":
+		r.flush()
+		r.out = append(r.out, "")
+
+	case "nf", "EX":
+		r.flush()
+		r.noFill = true
+
+	case "fi", "EE":
+		r.flush()
+		r.noFill = false
+
+	case "B", "I", "SM", "SB":
+		font := byte('B')
+		if name == "I" {
+			font = 'I'
+		}
+		r.text(strings.Join(args, " "), font)
+
+	case "BR", "RB", "BI", "IB", "IR", "RI":
+		// Alternating fonts, joined without spaces
+		var joined strings.Builder
+		for i, arg := range args {
+			font := name[i%2]
+			joined.WriteString(`\f` + string(font) + arg)
+		}
+		r.text(joined.String()+`\fR`, 'R')
+	}
+}
+
+// text handles a line of text in the given starting font
+func (r *roffRenderer) text(line string, font byte) {
+	encoded := decodeRoffEscapes(line, font)
+
+	if r.noFill {
+		r.out = append(r.out, strings.Repeat(" ", r.indent)+encoded)
+		return
+	}
+
+	for _, word := range strings.Fields(encoded) {
+		plain, _ := renderOverstrike(word)
+		r.words = append(r.words, roffWord{text: word, width: len([]rune(plain))})
+	}
+
+	// A .TP tag occupies its own line
+	if r.tagLine {
+		r.flush()
+		r.tagLine = false
+		r.indent = 11
+	}
+}
+
+// flush wraps the pending words into output lines
+func (r *roffRenderer) flush() {
+	if len(r.words) == 0 {
+		return
+	}
+
+	prefix := strings.Repeat(" ", r.indent)
+	var line strings.Builder
+	width := 0
+	for _, word := range r.words {
+		if width > 0 && r.indent+width+1+word.width > manPageWidth {
+			r.out = append(r.out, prefix+line.String())
+			line.Reset()
+			width = 0
+		}
+		if width > 0 {
+			line.WriteString(" ")
+			width++
+		}
+		line.WriteString(word.text)
+		width += word.width
+	}
+	r.out = append(r.out, prefix+line.String())
+	r.words = nil
+}
+
+// blank appends an empty line unless the output already ends with one
+func (r *roffRenderer) blank() {
+	if len(r.out) > 0 && r.out[len(r.out)-1] != "" {
+		r.out = append(r.out, "")
+	}
+}
+
+// splitRoffRequest splits a request line into its name and (possibly quoted) arguments
+func splitRoffRequest(line string) (string, []string) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, `\"`) {
+		return `\"`, nil
+	}
+
+	var fields []string
+	var current strings.Builder
+	inQuote := false
+	hasField := false
+	for _, c := range line {
+		switch {
+		case c == '"':
+			inQuote = !inQuote
+			hasField = true
+		case c == ' ' && !inQuote:
+			if hasField {
+				fields = append(fields, current.String())
+				current.Reset()
+				hasField = false
+			}
+		default:
+			current.WriteRune(c)
+			hasField = true
+		}
+	}
+	if hasField {
+		fields = append(fields, current.String())
+	}
+
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return fields[0], fields[1:]
+}
+
+// roffSpecialChars maps \(xx escapes to their characters
+var roffSpecialChars = map[string]string{
+	"em": "—", "en": "–", "bu": "•", "co": "©", "rg": "®",
+	"lq": "“", "rq": "”", "aq": "'", "dq": "\"", "hy": "-",
+	"mi": "-", "pl": "+", "mu": "×", "<=": "≤", ">=": "≥",
+}
+
+// decodeRoffEscapes resolves roff escapes and encodes font changes as overstrike
+func decodeRoffEscapes(line string, font byte) string {
+	var b strings.Builder
+	runes := []rune(line)
+	previous := font
+
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		if c != '\\' || i+1 >= len(runes) {
+			b.WriteString(overstrike(string(c), font))
+			continue
+		}
+
+		i++
+		switch runes[i] {
+		case 'f':
+			// Font change: \fB, \fI, \fR, \fP
+			if i+1 < len(runes) {
+				i++
+				switch runes[i] {
+				case 'B', 'I', 'R':
+					previous, font = font, byte(runes[i])
+				case 'P':
+					font, previous = previous, font
+				}
+			}
+		case '(':
+			if i+2 < len(runes) {
+				name := string(runes[i+1 : i+3])
+				i += 2
+				if s, ok := roffSpecialChars[name]; ok {
+					b.WriteString(overstrike(s, font))
+				}
+			}
+		case '"':
+			// Comment to end of line
+			return b.String()
+		case '&', '^', '|':
+			// Zero-width characters
+		case 'e', '\\':
+			b.WriteString(overstrike(`\`, font))
+		case '-':
+			b.WriteString(overstrike("-", font))
+		case ' ', '~':
+			b.WriteString(" ")
+		default:
+			b.WriteString(overstrike(string(runes[i]), font))
+		}
+	}
+
+	return b.String()
+}
+
+// overstrike encodes text in the given font ('B' bold, 'I' underline, 'R' regular)
+func overstrike(text string, font byte) string {
+	if font == 'R' {
+		return text
+	}
+
+	var b strings.Builder
+	for _, r := range text {
+		if r == ' ' {
+			b.WriteRune(r)
+			continue
+		}
+		if font == 'B' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+		b.WriteRune('\b')
+		b.WriteRune(r)
+	}
+	return b.String()
+}
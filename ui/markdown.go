@@ -0,0 +1,953 @@
+package ui
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// markdownWidth is the width paragraphs of Markdown are filled to without
+// :wrap, like manPageWidth for man pages. Narrower windows fill to their width.
+const markdownWidth = 80
+
+// minMarkdownWidth is the narrowest fill width, for deeply nested blocks in
+// small windows
+const minMarkdownWidth = 20
+
+var (
+	mdHeadingPattern   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	mdSetextPattern    = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	mdRulePattern      = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	mdFencePattern     = regexp.MustCompile("^( {0,3})(```+|~~~+)[ \t]*([^`\\s]*)")
+	mdListPattern      = regexp.MustCompile(`^( {0,3})([-*+]|\d{1,9}[.)])([ \t]+|$)`)
+	mdQuotePattern     = regexp.MustCompile(`^ {0,3}> ?`)
+	mdTableRulePattern = regexp.MustCompile(`^[ \t]*\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	mdReferencePattern = regexp.MustCompile(`^ {0,3}\[([^\]]+)\]:[ \t]*<?(\S+?)>?(?:[ \t]+["'(].*["')])?[ \t]*$`)
+	mdHTMLPattern      = regexp.MustCompile(`^ {0,3}</?[A-Za-z][A-Za-z0-9-]*(\s|/?>|$)`)
+)
+
+// mdAttr is a set of text attributes of rendered Markdown
+type mdAttr int
+
+const (
+	mdBold mdAttr = 1 << iota
+	mdItalic
+	mdStrike
+	mdCode
+	mdLink
+	mdDim
+	mdHeading1
+	mdHeading2
+	mdHeading3
+)
+
+// sgr returns the escape sequence that turns on a set of attributes
+func (a mdAttr) sgr() string {
+	var codes []string
+	if a&mdBold != 0 {
+		codes = append(codes, "1")
+	}
+	if a&mdItalic != 0 {
+		codes = append(codes, "3")
+	}
+	if a&mdLink != 0 {
+		codes = append(codes, "4", theme.MDLink)
+	}
+	if a&mdStrike != 0 {
+		codes = append(codes, "9")
+	}
+	switch {
+	case a&mdCode != 0:
+		codes = append(codes, theme.MDCode)
+	case a&mdDim != 0:
+		codes = append(codes, theme.MDDim)
+	case a&mdHeading1 != 0:
+		codes = append(codes, theme.MDHeading1)
+	case a&mdHeading2 != 0:
+		codes = append(codes, theme.MDHeading2)
+	case a&mdHeading3 != 0:
+		codes = append(codes, theme.MDHeading3)
+	}
+	if len(codes) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m"
+}
+
+// mdRun is a piece of inline text with its attributes
+type mdRun struct {
+	Text string
+	Attr mdAttr
+}
+
+// styleRuns returns the plain text of runs and the text with their attributes
+func styleRuns(runs []mdRun) (plain, styled string) {
+	var p, s strings.Builder
+	for _, run := range runs {
+		p.WriteString(run.Text)
+		if on := run.Attr.sgr(); on != "" {
+			s.WriteString(on + run.Text + "\x1b[0m")
+		} else {
+			s.WriteString(run.Text)
+		}
+	}
+	return p.String(), s.String()
+}
+
+// markdownDoc is a Markdown file shown rendered rather than as its source
+type markdownDoc struct {
+	Source string // Markdown text, rendered again when the width changes
+	Width  int    // Width paragraphs were filled to
+	lines  []int  // Line of the source each rendered line comes from
+}
+
+// sourceLine returns the line of the source rendered line i comes from
+func (d *markdownDoc) sourceLine(i int) int {
+	if i < 0 || i >= len(d.lines) {
+		return 0
+	}
+	return d.lines[i]
+}
+
+// renderedLine returns the first rendered line coming from source line or
+// later
+func (d *markdownDoc) renderedLine(line int) int {
+	for i, source := range d.lines {
+		if source >= line {
+			return i
+		}
+	}
+	return max(len(d.lines)-1, 0)
+}
+
+// mdRenderer renders the blocks of a Markdown document into filled lines
+type mdRenderer struct {
+	width    int
+	refs     map[string]string // Link reference definitions, by lower case label
+	plain    []string
+	styled   []string
+	sources  []int // Source line of each output line
+	headings []int // Output lines that are headings
+	src      int   // Source line of the block being rendered
+	tight    bool  // Blocks are not separated, as in the items of a tight list
+}
+
+// renderMarkdown renders a Markdown source with paragraphs filled to width,
+// returning the plain and styled lines, the source line of each and the
+// lines of the headings
+func renderMarkdown(source string, width int) (plain, styled []string, sources, headings []int) {
+	lines := strings.Split(source, "\n")
+	r := &mdRenderer{width: max(width, minMarkdownWidth), refs: markdownRefs(lines)}
+	r.blocks(lines, 0)
+	// Drop the gap after the last block
+	for len(r.plain) > 0 && r.plain[len(r.plain)-1] == "" {
+		r.plain, r.styled, r.sources = r.plain[:len(r.plain)-1], r.styled[:len(r.styled)-1], r.sources[:len(r.sources)-1]
+	}
+	if len(r.plain) == 0 {
+		return []string{""}, []string{""}, []int{0}, nil
+	}
+	return r.plain, r.styled, r.sources, r.headings
+}
+
+// markdownRefs collects the link reference definitions ([label]: url)
+func markdownRefs(lines []string) map[string]string {
+	refs := make(map[string]string)
+	for _, line := range lines {
+		if m := mdReferencePattern.FindStringSubmatch(line); m != nil {
+			refs[strings.ToLower(m[1])] = m[2]
+		}
+	}
+	return refs
+}
+
+// sub returns a renderer for blocks nested in another, such as the items of a
+// list, filled to a narrower width
+func (r *mdRenderer) sub(width int) *mdRenderer {
+	return &mdRenderer{width: max(width, minMarkdownWidth), refs: r.refs}
+}
+
+// emit adds an output line
+func (r *mdRenderer) emit(plain, styled string) {
+	r.plain = append(r.plain, plain)
+	r.styled = append(r.styled, styled)
+	r.sources = append(r.sources, r.src)
+}
+
+// gap separates the next block from the previous one with a blank line
+func (r *mdRenderer) gap() {
+	if n := len(r.plain); n > 0 && r.plain[n-1] != "" && !r.tight {
+		r.emit("", "")
+	}
+}
+
+// nest adds the lines of a nested renderer, the first with a prefix such as a
+// list bullet and the rest with indent
+func (r *mdRenderer) nest(n *mdRenderer, first, indent mdRun) {
+	// The gap after the nested blocks is left to the caller
+	for len(n.plain) > 1 && n.plain[len(n.plain)-1] == "" {
+		n.plain, n.styled, n.sources = n.plain[:len(n.plain)-1], n.styled[:len(n.styled)-1], n.sources[:len(n.sources)-1]
+	}
+	for i := range n.plain {
+		prefix := indent
+		if i == 0 {
+			prefix = first
+		}
+		p, s := styleRuns([]mdRun{prefix})
+		if n.plain[i] == "" && strings.TrimSpace(p) == "" {
+			p, s = "", ""
+		}
+		r.plain = append(r.plain, p+n.plain[i])
+		r.styled = append(r.styled, s+n.styled[i])
+		r.sources = append(r.sources, n.sources[i])
+	}
+	for _, h := range n.headings {
+		r.headings = append(r.headings, len(r.plain)-len(n.plain)+h)
+	}
+}
+
+// blocks renders lines of Markdown, the first of which is source line start
+func (r *mdRenderer) blocks(lines []string, start int) {
+	var para []string
+	paraStart := 0
+	flush := func() {
+		if len(para) > 0 {
+			src := r.src
+			r.src = paraStart
+			r.paragraph(para)
+			r.src = src
+			para = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		r.src = start + i
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+			r.gap()
+
+		case len(para) > 0 && mdSetextPattern.MatchString(line):
+			// A paragraph underlined with === or --- is a heading
+			level := 2
+			if strings.HasPrefix(trimmed, "=") {
+				level = 1
+			}
+			text := strings.Join(para, " ")
+			para = nil
+			r.src = paraStart
+			r.heading(level, text)
+
+		case mdFencePattern.MatchString(line):
+			flush()
+			m := mdFencePattern.FindStringSubmatch(line)
+			end := i + 1
+			for end < len(lines) && !isClosingFence(lines[end], m[2]) {
+				end++
+			}
+			r.codeBlock(unindent(lines[i+1:min(end, len(lines))], len(m[1])), m[3], start+i+1)
+			i = end
+
+		case mdHeadingPattern.MatchString(line):
+			flush()
+			m := mdHeadingPattern.FindStringSubmatch(line)
+			r.heading(len(m[1]), m[2])
+
+		case mdRulePattern.MatchString(line):
+			flush()
+			r.gap()
+			rule := strings.Repeat("─", r.width)
+			r.emit(rule, mdDim.sgr()+rule+"\x1b[0m")
+			r.gap()
+
+		case mdReferencePattern.MatchString(line) && len(para) == 0:
+			// Definitions only give links their targets
+
+		case len(para) == 0 && strings.HasPrefix(trimmed, "<!--"):
+			// HTML comments are hidden
+			for i < len(lines) && !strings.Contains(lines[i], "-->") {
+				i++
+			}
+
+		case len(para) == 0 && mdHTMLPattern.MatchString(line):
+			// HTML blocks are shown as they are, up to a blank line
+			r.gap()
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				r.src = start + i
+				r.emit(lines[i], mdDim.sgr()+lines[i]+"\x1b[0m")
+			}
+			r.gap()
+
+		case mdQuotePattern.MatchString(line):
+			flush()
+			var quoted []string
+			for ; i < len(lines) && mdQuotePattern.MatchString(lines[i]); i++ {
+				quoted = append(quoted, mdQuotePattern.ReplaceAllString(lines[i], ""))
+			}
+			i--
+			r.gap()
+			q := r.sub(r.width - 2)
+			q.blocks(quoted, r.src)
+			bar := mdRun{Text: "│ ", Attr: mdDim}
+			r.nest(q, bar, bar)
+			r.gap()
+
+		case len(para) == 0 && strings.HasPrefix(line, "    "):
+			// Indented code, up to a line that is not indented or blank
+			end := i
+			for end < len(lines) && (strings.HasPrefix(lines[end], "    ") || strings.TrimSpace(lines[end]) == "") {
+				end++
+			}
+			for end > i && strings.TrimSpace(lines[end-1]) == "" {
+				end--
+			}
+			r.codeBlock(unindent(lines[i:end], 4), "", start+i)
+			i = end - 1
+
+		case mdListPattern.MatchString(line):
+			flush()
+			i = r.list(lines, i, start) - 1
+
+		case len(para) == 0 && i+1 < len(lines) && strings.Contains(line, "|") && mdTableRulePattern.MatchString(lines[i+1]):
+			end := i + 2
+			for end < len(lines) && strings.Contains(lines[end], "|") && strings.TrimSpace(lines[end]) != "" {
+				end++
+			}
+			r.table(lines[i], lines[i+1], lines[i+2:end])
+			i = end - 1
+
+		default:
+			if len(para) == 0 {
+				paraStart = start + i
+			}
+			para = append(para, line)
+		}
+	}
+	flush()
+}
+
+// isClosingFence reports whether line closes a code block opened by fence
+func isClosingFence(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, fence[:3]) && strings.Trim(trimmed, fence[:1]) == "" && len(trimmed) >= len(fence)
+}
+
+// unindent removes up to n leading spaces from each line
+func unindent(lines []string, n int) []string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		trim := 0
+		for trim < n && trim < len(line) && line[trim] == ' ' {
+			trim++
+		}
+		out[i] = line[trim:]
+	}
+	return out
+}
+
+// heading renders a heading, underlining the first two levels
+func (r *mdRenderer) heading(level int, text string) {
+	runs := r.inline(strings.TrimSpace(text))
+	attr := mdBold | mdHeading3
+	switch level {
+	case 1:
+		attr = mdBold | mdHeading1
+	case 2:
+		attr = mdBold | mdHeading2
+	}
+	for i := range runs {
+		runs[i].Attr |= attr
+	}
+
+	r.gap()
+	r.headings = append(r.headings, len(r.plain))
+	plain, styled := styleRuns(runs)
+	r.emit(plain, styled)
+	if level <= 2 {
+		char := "─"
+		if level == 1 {
+			char = "═"
+		}
+		rule := strings.Repeat(char, min(max(visualLength(plain), 1), r.width))
+		r.emit(rule, (attr&^mdBold).sgr()+rule+"\x1b[0m")
+	}
+	r.gap()
+}
+
+// paragraph fills the lines of a paragraph to the width, keeping hard line
+// breaks (two trailing spaces or a backslash)
+func (r *mdRenderer) paragraph(lines []string) {
+	var text []string
+	for i, line := range lines {
+		line = strings.TrimLeft(line, " \t")
+		hard := strings.HasSuffix(line, "  ") || strings.HasSuffix(line, `\`)
+		text = append(text, strings.TrimRight(strings.TrimSuffix(strings.TrimRight(line, " "), `\`), " "))
+		if hard && i < len(lines)-1 {
+			r.fill(r.inline(strings.Join(text, " ")))
+			text = nil
+		}
+	}
+	if len(text) > 0 {
+		r.fill(r.inline(strings.Join(text, " ")))
+	}
+}
+
+// fill breaks inline text into lines no wider than the width, breaking at
+// spaces; longer words get a line of their own
+func (r *mdRenderer) fill(runs []mdRun) {
+	var line, word []mdRun
+	lineWidth, wordWidth := 0, 0
+	pendingSpace := false
+
+	addWord := func() {
+		if len(word) == 0 {
+			return
+		}
+		if len(line) > 0 && lineWidth+1+wordWidth > r.width {
+			r.emitRuns(line)
+			line, lineWidth = nil, 0
+		}
+		if len(line) > 0 {
+			line = append(line, mdRun{Text: " ", Attr: word[0].Attr &^ mdLink})
+			lineWidth++
+		}
+		line = append(line, word...)
+		lineWidth += wordWidth
+		word, wordWidth = nil, 0
+	}
+
+	for _, run := range runs {
+		for _, field := range splitSpaces(run.Text) {
+			if field == " " {
+				pendingSpace = true
+				continue
+			}
+			if pendingSpace {
+				addWord()
+				pendingSpace = false
+			}
+			word = append(word, mdRun{Text: field, Attr: run.Attr})
+			wordWidth += visualLength(field)
+		}
+	}
+	addWord()
+	if len(line) > 0 {
+		r.emitRuns(line)
+	}
+}
+
+// splitSpaces splits text into words and single " " entries for each run of
+// white space between them
+func splitSpaces(text string) []string {
+	var parts []string
+	start := -1
+	for i, c := range text {
+		if unicode.IsSpace(c) {
+			if start >= 0 {
+				parts = append(parts, text[start:i])
+				start = -1
+			}
+			if len(parts) == 0 || parts[len(parts)-1] != " " {
+				parts = append(parts, " ")
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		parts = append(parts, text[start:])
+	}
+	return parts
+}
+
+// emitRuns adds a line of inline text
+func (r *mdRenderer) emitRuns(runs []mdRun) {
+	plain, styled := styleRuns(runs)
+	r.emit(plain, styled)
+}
+
+// codeBlock renders code, whose first line is source line first, indented and
+// highlighted as the language of a fenced block, without filling it
+func (r *mdRenderer) codeBlock(lines []string, lang string, first int) {
+	code := strings.Join(lines, "\n")
+	var spans [][]span
+	if lexer := lexers.Get(lang); lang != "" && lexer != nil {
+		_, spans, _ = newHighlighter(lexer, code).nextChunk(math.MaxInt)
+	}
+
+	r.gap()
+	for i, line := range lines {
+		r.src = first + i
+		styled := mdCode.sgr() + line + "\x1b[0m"
+		if i < len(spans) && len(spans[i]) > 0 {
+			styled = formatSpans(line, spans[i])
+		}
+		r.emit("    "+line, "    "+styled)
+	}
+	r.gap()
+}
+
+// list renders the list starting at lines[i] and returns the index of the line
+// after it
+func (r *mdRenderer) list(lines []string, i, start int) int {
+	m := mdListPattern.FindStringSubmatch(lines[i])
+	indent := len(m[1])
+	ordered := isOrderedMarker(m[2])
+	loose := false
+
+	r.gap()
+	for i < len(lines) {
+		m := mdListPattern.FindStringSubmatch(lines[i])
+		if m == nil || len(m[1]) != indent || isOrderedMarker(m[2]) != ordered || mdRulePattern.MatchString(lines[i]) {
+			break
+		}
+		marker := m[2]
+		// Text of the item starts after the marker and the spaces following it
+		contentIndent := len(m[0])
+		if strings.TrimSpace(m[3]) == "" && len(m[3]) > 4 {
+			contentIndent = len(m[1]) + len(marker) + 1
+		}
+		itemStart := i
+		item := []string{lines[i][min(contentIndent, len(lines[i])):]}
+		blank := false
+		i++
+		for i < len(lines) {
+			line := lines[i]
+			if strings.TrimSpace(line) == "" {
+				// A blank line continues the item if indented text follows
+				next := i + 1
+				for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+					next++
+				}
+				if next < len(lines) && leadingSpaces(lines[next]) >= contentIndent {
+					item = append(item, lines[i:next]...)
+					blank = true
+					i = next
+					continue
+				}
+				break
+			}
+			if leadingSpaces(line) >= contentIndent {
+				item = append(item, line[contentIndent:])
+			} else if mdListPattern.MatchString(line) || mdHeadingPattern.MatchString(line) || mdQuotePattern.MatchString(line) || mdFencePattern.MatchString(line) {
+				break
+			} else {
+				// Lazy continuation of the item's paragraph
+				item = append(item, strings.TrimLeft(line, " \t"))
+			}
+			i++
+		}
+
+		bullet := "• "
+		if ordered {
+			bullet = marker + " "
+		}
+		bulletRun := mdRun{Text: bullet, Attr: mdHeading2}
+		if check, rest, ok := taskItem(item[0]); ok {
+			item[0] = rest
+			bulletRun.Text += check
+		}
+		width := visualLength(bulletRun.Text)
+		n := r.sub(r.width - width)
+		n.tight = !blank
+		n.blocks(item, start+itemStart)
+		r.nest(n, bulletRun, mdRun{Text: strings.Repeat(" ", width)})
+
+		// Blank lines between the items of a loose list
+		next := i
+		for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+			next++
+		}
+		if next > i && next < len(lines) {
+			if m := mdListPattern.FindStringSubmatch(lines[next]); m != nil && len(m[1]) == indent {
+				loose = true
+				i = next
+			}
+		}
+		if loose {
+			r.gap()
+		}
+	}
+	r.gap()
+	return i
+}
+
+// isOrderedMarker reports whether a list item marker is a number, as in 1.
+func isOrderedMarker(marker string) bool {
+	return !strings.ContainsAny(marker, "-*+")
+}
+
+// taskItem splits the check box off a task list item such as "[x] Done"
+func taskItem(text string) (check, rest string, ok bool) {
+	switch {
+	case strings.HasPrefix(text, "[ ] "):
+		return "[ ] ", text[4:], true
+	case strings.HasPrefix(text, "[x] "), strings.HasPrefix(text, "[X] "):
+		return "[x] ", text[4:], true
+	}
+	return "", text, false
+}
+
+// leadingSpaces counts the spaces a line starts with
+func leadingSpaces(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// table renders a pipe table with its columns aligned. Tables wider than the
+// width are not filled.
+func (r *mdRenderer) table(header, rule string, rows []string) {
+	aligns := tableCells(rule)
+	cells := [][]string{tableCells(header)}
+	for _, row := range rows {
+		cells = append(cells, tableCells(row))
+	}
+
+	columns := len(aligns)
+	rendered := make([][][]mdRun, len(cells))
+	widths := make([]int, columns)
+	for i, row := range cells {
+		rendered[i] = make([][]mdRun, columns)
+		for c := 0; c < columns && c < len(row); c++ {
+			runs := r.inline(row[c])
+			if i == 0 {
+				for k := range runs {
+					runs[k].Attr |= mdBold
+				}
+			}
+			rendered[i][c] = runs
+			plain, _ := styleRuns(runs)
+			widths[c] = max(widths[c], visualLength(plain))
+		}
+	}
+
+	r.gap()
+	first := r.src
+	for i, row := range rendered {
+		var line []mdRun
+		for c, runs := range row {
+			if c > 0 {
+				line = append(line, mdRun{Text: " │ ", Attr: mdDim})
+			}
+			plain, _ := styleRuns(runs)
+			pad := widths[c] - visualLength(plain)
+			align := strings.TrimSpace(aligns[c])
+			switch {
+			case strings.HasPrefix(align, ":") && strings.HasSuffix(align, ":"):
+				line = append(line, mdRun{Text: strings.Repeat(" ", pad/2)})
+				line = append(line, runs...)
+				line = append(line, mdRun{Text: strings.Repeat(" ", pad-pad/2)})
+			case strings.HasSuffix(align, ":"):
+				line = append(line, mdRun{Text: strings.Repeat(" ", pad)})
+				line = append(line, runs...)
+			default:
+				line = append(line, runs...)
+				line = append(line, mdRun{Text: strings.Repeat(" ", pad)})
+			}
+		}
+		r.src = first + i
+		if i > 0 {
+			r.src++
+		}
+		plain, styled := styleRuns(line)
+		r.emit(strings.TrimRight(plain, " "), styled)
+		if i == 0 {
+			parts := make([]string, columns)
+			for c, w := range widths {
+				parts[c] = strings.Repeat("─", w)
+			}
+			sep := strings.Join(parts, "─┼─")
+			r.emit(sep, mdDim.sgr()+sep+"\x1b[0m")
+		}
+	}
+	r.gap()
+}
+
+// tableCells splits a table row at the pipes that are not escaped or in code
+func tableCells(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, `\|`) {
+		row = row[:len(row)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	inCode := false
+	for i := 0; i < len(row); i++ {
+		c := row[i]
+		switch {
+		case c == '\\' && i+1 < len(row) && row[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case c == '`':
+			inCode = !inCode
+			cell.WriteByte(c)
+		case c == '|' && !inCode:
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(c)
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// inline parses the emphasis, code spans, links and images of a line of text
+func (r *mdRenderer) inline(text string) []mdRun {
+	var runs []mdRun
+	var buf strings.Builder
+	attr := mdAttr(0)
+	add := func(s string, a mdAttr) {
+		if s == "" {
+			return
+		}
+		if n := len(runs); n > 0 && runs[n-1].Attr == a {
+			runs[n-1].Text += s
+			return
+		}
+		runs = append(runs, mdRun{Text: s, Attr: a})
+	}
+	flush := func() {
+		add(buf.String(), attr)
+		buf.Reset()
+	}
+	// toggle turns an emphasis on if it is closed later in the text, or off
+	toggle := func(i int, delim string, a mdAttr) (int, bool) {
+		if attr&a == 0 && !strings.Contains(text[i+len(delim):], delim) {
+			return i, false
+		}
+		flush()
+		attr ^= a
+		return i + len(delim) - 1, true
+	}
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte("\\`*_{}[]()#+-.!|~<>", text[i+1]) >= 0:
+			buf.WriteByte(text[i+1])
+			i++
+			continue
+
+		case c == '`':
+			ticks := i
+			for ticks < len(text) && text[ticks] == '`' {
+				ticks++
+			}
+			fence := text[i:ticks]
+			if end := strings.Index(text[ticks:], fence); end >= 0 {
+				flush()
+				code := text[ticks : ticks+end]
+				if len(code) > 1 && code[0] == ' ' && code[len(code)-1] == ' ' {
+					code = code[1 : len(code)-1]
+				}
+				add(code, attr|mdCode)
+				i = ticks + end + len(fence) - 1
+				continue
+			}
+			buf.WriteString(fence)
+			i = ticks - 1
+			continue
+
+		case c == '*' || c == '_':
+			// Underscores inside words, as in snake_case, are not emphasis
+			if c == '_' && i > 0 && i+1 < len(text) && isWordByte(text[i-1]) && isWordByte(text[i+1]) {
+				break
+			}
+			if strings.HasPrefix(text[i:], strings.Repeat(string(c), 2)) {
+				if next, ok := toggle(i, strings.Repeat(string(c), 2), mdBold); ok {
+					i = next
+					continue
+				}
+			}
+			if next, ok := toggle(i, string(c), mdItalic); ok {
+				i = next
+				continue
+			}
+
+		case c == '~' && strings.HasPrefix(text[i:], "~~"):
+			if next, ok := toggle(i, "~~", mdStrike); ok {
+				i = next
+				continue
+			}
+
+		case c == '<':
+			if end := strings.IndexByte(text[i:], '>'); end > 0 {
+				inner := text[i+1 : i+end]
+				if strings.Contains(inner, "://") || strings.HasPrefix(inner, "mailto:") {
+					flush()
+					add(inner, attr|mdLink)
+					i += end
+					continue
+				}
+			}
+
+		case c == '!' && i+1 < len(text) && text[i+1] == '[':
+			if label, target, next, ok := r.link(text, i+1); ok {
+				flush()
+				add("Image: "+label, attr|mdDim)
+				if target != "" {
+					add(" ("+target+")", attr|mdDim)
+				}
+				i = next - 1
+				continue
+			}
+
+		case c == '[':
+			if label, target, next, ok := r.link(text, i); ok {
+				flush()
+				for _, run := range r.inline(label) {
+					add(run.Text, attr|run.Attr|mdLink)
+				}
+				if target != "" && target != label {
+					add(" ("+target+")", attr|mdDim)
+				}
+				i = next - 1
+				continue
+			}
+		}
+		buf.WriteByte(c)
+	}
+	flush()
+	return runs
+}
+
+// isWordByte reports whether c is a letter or digit of ASCII text
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// link parses the link whose label starts at text[i], which is '[': inline,
+// as in [label](url "title"), or a reference, as in [label][ref] or [ref]. It
+// returns the index after the link.
+func (r *mdRenderer) link(text string, i int) (label, target string, next int, ok bool) {
+	depth := 0
+	end := -1
+	for j := i; j < len(text); j++ {
+		switch text[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+		if depth == 0 {
+			end = j
+			break
+		}
+	}
+	if end < 0 {
+		return "", "", 0, false
+	}
+	label = text[i+1 : end]
+	rest := text[end+1:]
+
+	switch {
+	case strings.HasPrefix(rest, "("):
+		close := strings.IndexByte(rest, ')')
+		if close < 0 {
+			return "", "", 0, false
+		}
+		fields := strings.Fields(rest[1:close])
+		if len(fields) > 0 {
+			target = strings.Trim(fields[0], "<>")
+		}
+		return label, target, end + 1 + close + 1, true
+
+	case strings.HasPrefix(rest, "["):
+		close := strings.IndexByte(rest, ']')
+		if close < 0 {
+			return "", "", 0, false
+		}
+		ref := rest[1:close]
+		if ref == "" {
+			ref = label
+		}
+		if target, found := r.refs[strings.ToLower(ref)]; found {
+			return label, target, end + 1 + close + 1, true
+		}
+
+	default:
+		if target, found := r.refs[strings.ToLower(label)]; found {
+			return label, target, end + 1, true
+		}
+	}
+	return "", "", 0, false
+}
+
+// setMarkdownContent shows a Markdown source rendered, with its headings as
+// sections for ]] and [[
+func (fv *FileViewer) setMarkdownContent(source string) {
+	width := fv.markdownFill()
+	plain, styled, sources, headings := renderMarkdown(source, width)
+	fv.markdown = &markdownDoc{Source: source, Width: width, lines: sources}
+	fv.Content = plain
+	fv.styledLines = styled
+	fv.Sections = headings
+	fv.spans = nil
+	fv.highlighter = nil
+	fv.fileType = "Markdown"
+}
+
+// markdownFill returns the width to fill Markdown paragraphs to: the width
+// of the text column with :wrap, else markdownWidth or less
+func (fv *FileViewer) markdownFill() int {
+	if fv.Width <= 0 {
+		return markdownWidth
+	}
+	if fv.WrapLines {
+		return fv.wrapWidth()
+	}
+	return min(markdownWidth, fv.Width-fv.gutterWidth()-10)
+}
+
+// fitMarkdown renders a Markdown file again after the width or wrapping
+// changed, keeping the same part of it in view
+func (fv *FileViewer) fitMarkdown() {
+	if fv.markdown == nil || fv.markdown.Width == fv.markdownFill() {
+		return
+	}
+	line := fv.markdown.sourceLine(fv.lineAt(fv.ScrollPos))
+	fv.replaceContent(fv.markdown.Source, func() int { return fv.markdown.renderedLine(line) })
+}
+
+// setRawMarkdown shows a Markdown file as its source, or rendered again
+// (:set raw / :set noraw)
+func (fv *FileViewer) setRawMarkdown(raw bool) {
+	if !isMarkdown(fv.FileName) || fv.hex || fv.window != nil {
+		fv.StatusMessage = "Only Markdown files are rendered"
+		return
+	}
+	if raw == fv.RawMarkdown {
+		return
+	}
+	fv.RawMarkdown = raw
+	if raw {
+		line := fv.markdown.sourceLine(fv.lineAt(fv.ScrollPos))
+		fv.replaceContent(fv.markdown.Source, func() int { return line })
+		fv.StatusMessage = "Showing the Markdown source"
+		return
+	}
+	line := fv.lineAt(fv.ScrollPos)
+	fv.replaceContent(strings.Join(fv.Content, "\n"), func() int { return fv.markdown.renderedLine(line) })
+	fv.StatusMessage = "Showing rendered Markdown"
+}
+
+// replaceContent shows content in place of the current content, scrolling to
+// the line returned by at and keeping the search and filter
+func (fv *FileViewer) replaceContent(content string, at func() int) {
+	fv.setContent(content)
+	if fv.LogMode {
+		fv.detectLevels(0)
+	}
+	fv.refilter(0)
+	if fv.searchRe != nil {
+		fv.findMatches()
+	}
+	fv.ScrollPos = fv.rowOf(at())
+}
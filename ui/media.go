@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/launch"
+	"github.com/HolyStarGazer/windows-tui-go/media"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// playMsg hands a file to the default player
+type playMsg struct {
+	Path string
+}
+
+// playedMsg reports whether a file was handed to the default player
+type playedMsg struct {
+	Path string
+	Err  error
+}
+
+// mediaRows describes an audio or video file for the properties panel: its
+// format, length, streams and tags
+func mediaRows(path string) []ListEntry {
+	f, err := vfs.Default.Open(path)
+	if err != nil {
+		return []ListEntry{propertyRow("Media", fmt.Sprintf("Error: %v", err))}
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return []ListEntry{propertyRow("Media", fmt.Sprintf("Error: %v", err))}
+	}
+	info, err := media.Probe(f, stat.Size())
+	if err != nil {
+		return []ListEntry{propertyRow("Media", theme.Dim.Render(err.Error()))}
+	}
+
+	rows := []ListEntry{propertyRow("Format", info.Format)}
+	if info.Duration > 0 {
+		rows = append(rows, propertyRow("Duration", formatPlayTime(info.Duration)))
+	}
+	if info.VideoCodec != "" {
+		video := info.VideoCodec
+		if info.Width > 0 && info.Height > 0 {
+			video += fmt.Sprintf(", %d×%d", info.Width, info.Height)
+		}
+		rows = append(rows, propertyRow("Video", video))
+	}
+	if info.AudioCodec != "" {
+		rows = append(rows, propertyRow("Audio", audioLabel(info)))
+	}
+	if info.Bitrate > 0 {
+		rows = append(rows, propertyRow("Bitrate", formatBitrate(info.Bitrate)))
+	}
+	for _, tag := range info.Tags {
+		// Comments can span lines
+		rows = append(rows, propertyRow(tag.Name, strings.Join(strings.Fields(tag.Value), " ")))
+	}
+	return rows
+}
+
+// audioLabel describes the audio stream of a file, e.g. "AAC, 44.1 kHz,
+// stereo"
+func audioLabel(info media.Info) string {
+	parts := []string{info.AudioCodec}
+	if info.SampleRate > 0 {
+		parts = append(parts, strconv.FormatFloat(float64(info.SampleRate)/1000, 'f', -1, 64)+" kHz")
+	}
+	switch info.Channels {
+	case 0:
+	case 1:
+		parts = append(parts, "mono")
+	case 2:
+		parts = append(parts, "stereo")
+	case 6:
+		parts = append(parts, "5.1")
+	case 8:
+		parts = append(parts, "7.1")
+	default:
+		parts = append(parts, fmt.Sprintf("%d channels", info.Channels))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatPlayTime formats the length of a recording like a player, e.g.
+// 3:07 or 1:02:03
+func formatPlayTime(d time.Duration) string {
+	total := int(d.Round(time.Second).Seconds())
+	if total >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, total/60%60, total%60)
+	}
+	return fmt.Sprintf("%d:%02d", total/60, total%60)
+}
+
+// formatBitrate formats bits per second, e.g. 320 kb/s or 4.5 Mb/s
+func formatBitrate(bps int) string {
+	if bps >= 1_000_000 {
+		return fmt.Sprintf("%.1f Mb/s", float64(bps)/1_000_000)
+	}
+	return fmt.Sprintf("%d kb/s", (bps+500)/1000)
+}
+
+// playCmd hands a file to the program the system plays it with, without
+// waiting for it
+func playCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		return playedMsg{Path: path, Err: launch.Open(path)}
+	}
+}
+
+// playCommand plays the audio or video file under the cursor in the default
+// player (:play)
+func (m *Model) playCommand() tea.Cmd {
+	if m.Cursor >= len(m.Items) {
+		m.StatusMessage = "No item selected"
+		return nil
+	}
+	if m.collapsedGroupStatus() {
+		return nil
+	}
+	item := m.Items[m.Cursor]
+	if item.IsDir || !media.IsMedia(item.Name) {
+		m.StatusMessage = "Not an audio or video file"
+		return nil
+	}
+	return playCmd(item.Path)
+}
+
+// finishPlay reports handing a file to the default player
+func (m *Model) finishPlay(msg playedMsg) {
+	if msg.Err != nil {
+		m.setStatus(fmt.Sprintf("Error: %v", msg.Err))
+		return
+	}
+	m.setStatus(fmt.Sprintf("Playing %s in the default player", filepath.Base(msg.Path)))
+}
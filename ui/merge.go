@@ -0,0 +1,187 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxMergeFileSize limits each file read by :merge
+const maxMergeFileSize = 10 * 1024 * 1024
+
+// sourceColors tag lines of merged logs by the file they came from
+var sourceColors = []string{"#5FD7FF", "#AF87FF", "#87D787", "#FFAF5F", "#FF87AF", "#5FAFAF", "#D7D787", "#AFAFFF"}
+
+// logSource is one of the files shown in a merged log view
+type logSource struct {
+	Name  string
+	Style lipgloss.Style
+}
+
+// mergedLogMsg delivers several log files interleaved by timestamp
+type mergedLogMsg struct {
+	Sources     []logSource
+	Lines       []string
+	LineSources []int // Index into Sources for each line
+	Err         error
+}
+
+// logEntry is a timestamped log line plus the continuation lines that follow it
+type logEntry struct {
+	Time  time.Time
+	Lines []string
+}
+
+// readLogEntries reads a log file and groups its lines into entries
+func readLogEntries(path string) ([]logEntry, error) {
+	info, err := vfs.Default.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxMergeFileSize {
+		return nil, fmt.Errorf("%s: file too large (max 10MB)", filepath.Base(path))
+	}
+
+	data, err := vfs.ReadFile(vfs.Default, path)
+	if err != nil {
+		return nil, err
+	}
+	text := normalizeText(string(data))
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil, nil
+	}
+
+	var entries []logEntry
+	for _, line := range strings.Split(text, "\n") {
+		t, ok := parseLogTimestamp(line)
+		if ok || len(entries) == 0 {
+			entries = append(entries, logEntry{Time: t})
+		}
+		last := &entries[len(entries)-1]
+		last.Lines = append(last.Lines, line)
+	}
+	return entries, nil
+}
+
+// mergeLogsCmd reads log files in the background and interleaves their entries
+// chronologically, keeping each file's own order
+func mergeLogsCmd(paths []string) tea.Cmd {
+	return func() tea.Msg {
+		var msg mergedLogMsg
+		files := make([][]logEntry, len(paths))
+		for i, path := range paths {
+			entries, err := readLogEntries(path)
+			if err != nil {
+				return mergedLogMsg{Err: err}
+			}
+			files[i] = entries
+			msg.Sources = append(msg.Sources, logSource{
+				Name:  filepath.Base(path),
+				Style: lipgloss.NewStyle().Foreground(lipgloss.Color(sourceColors[i%len(sourceColors)])),
+			})
+		}
+
+		// Repeatedly take the earliest head entry; ties go to the earlier file
+		next := make([]int, len(files))
+		for {
+			pick := -1
+			for i, entries := range files {
+				if next[i] >= len(entries) {
+					continue
+				}
+				if pick < 0 || entries[next[i]].Time.Before(files[pick][next[pick]].Time) {
+					pick = i
+				}
+			}
+			if pick < 0 {
+				break
+			}
+			entry := files[pick][next[pick]]
+			next[pick]++
+			for _, line := range entry.Lines {
+				msg.Lines = append(msg.Lines, line)
+				msg.LineSources = append(msg.LineSources, pick)
+			}
+		}
+		return msg
+	}
+}
+
+// mergeTargets resolves the files for :merge: the given glob patterns, or the
+// marked files when there are none
+func (m *Model) mergeTargets(patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		return m.markedPaths(), nil
+	}
+
+	var paths []string
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(m.CurrentPath, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s'", pattern)
+		}
+		for _, match := range matches {
+			if info, err := vfs.Default.Stat(match); err != nil || info.IsDir() || seen[match] {
+				continue
+			}
+			seen[match] = true
+			paths = append(paths, match)
+		}
+	}
+	return paths, nil
+}
+
+// openMergedLog shows merged log lines in a log-mode viewer tagged by source
+func (m *Model) openMergedLog(msg mergedLogMsg) {
+	names := make([]string, len(msg.Sources))
+	for i, source := range msg.Sources {
+		names[i] = source.Name
+	}
+
+	viewer := FileViewer{
+		FileName:          "merged: " + strings.Join(names, ", "),
+		SearchMatches:     []int{},
+		CurrentMatchIndex: -1,
+	}
+	viewer.setContent(strings.Join(msg.Lines, "\n"))
+	viewer.sources = msg.Sources
+	viewer.lineSources = msg.LineSources
+	viewer.setLogMode(true)
+	viewer.StatusMessage = fmt.Sprintf("Merged %d files, %d lines", len(msg.Sources), len(msg.Lines))
+	m.openViewer(&viewer)
+}
+
+// sourceTagWidth returns the width of the source tag column, or 0 if the
+// viewer does not show a merged log
+func (fv *FileViewer) sourceTagWidth() int {
+	if len(fv.lineSources) == 0 {
+		return 0
+	}
+	width := 0
+	for _, source := range fv.sources {
+		if w := visualLength(source.Name); w > width {
+			width = w
+		}
+	}
+	return width + 1
+}
+
+// sourceTag renders the colored name of the file line i came from
+func (fv *FileViewer) sourceTag(i int) string {
+	width := fv.sourceTagWidth()
+	if i >= len(fv.lineSources) {
+		return strings.Repeat(" ", width)
+	}
+	source := fv.sources[fv.lineSources[i]]
+	return source.Style.Render(fmt.Sprintf("%-*s", width-1, source.Name)) + " "
+}
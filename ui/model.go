@@ -2,24 +2,97 @@ package ui
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/HolyStarGazer/windows-tui-go/config"
+	"github.com/HolyStarGazer/windows-tui-go/ops"
+	"github.com/HolyStarGazer/windows-tui-go/project"
+	"github.com/HolyStarGazer/windows-tui-go/tasks"
 	"github.com/HolyStarGazer/windows-tui-go/types"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 // Model represents the application state
 type Model struct {
-	CurrentPath string
-	Items       []types.FileItem
-	Cursor      int
-	Width       int
-	Height      int
-	Err         error
-	Mode        ViewMode
-	FileViewer  *FileViewer
+	CurrentPath     string
+	Items           []types.FileItem
+	Cursor          int
+	Width           int
+	Height          int
+	Err             error
+	Mode            ViewMode
+	FileViewer      *FileViewer
+	List            *ListPanel                // Active picker-style panel (ListMode)
+	ReturnMode      ViewMode                  // Mode to return to when the file viewer closes
+	ListReturnMode  ViewMode                  // Mode to return to when the list panel closes
+	parentViewer    *FileViewer               // Viewer hidden by a viewer opened from it, such as a diff
+	parentList      *ListPanel                // List hidden by a list opened from the viewer
+	Prompt          *Prompt                   // Active text prompt, shown over any mode
+	DatePicker      *DatePicker               // Active date and time prompt, shown over any mode
+	Finder          *Finder                   // Fuzzy file finder shown over the browser (Ctrl+P)
+	Preview         *ImagePreview             // Image shown full size over the browser (Enter in the thumbnail grid)
+	Calibrator      *Calibrator               // Terminal test patterns shown over any mode (:calibrate)
+	Project         *project.Project          // Project containing CurrentPath, if any
+	Output          *OutputPane               // Output of the most recent task or shell command
+	OutputVisible   bool                      // Whether the output pane is docked under the browser
+	TaskRun         *tasks.Run                // Currently running task, if any
+	PagerMode       bool                      // Closing the viewer quits (viewing stdin)
+	Settings        config.Settings           // User preferences from config.json
+	Marked          map[string]bool           // Paths of items marked with Space in the current directory
+	dirSizes        map[string]int64          // Total sizes of marked directories, or sizing
+	folderCounts    map[string]folderCount    // Cached entry counts of directories
+	thumbnails      map[string]thumbnail      // Cached thumbnails of images, for the grid
+	linksRequested  string                    // Directory whose hard link counts were read
+	flat            flatView                  // Flattened listing of the files below the directory (:flatten)
+	groups          groupView                 // Groups of the listing under the group option
+	junk            []junkScan                // Locations shown in the cleanup panel
+	cleanAge        time.Duration             // Compiled Settings.CleanMinAge
+	Options         BrowseOptions             // Listing options of the browser (:setlocal)
+	globalOptions   BrowseOptions             // Options given to new panes (:set)
+	highlightRules  []lineRule                // Compiled Settings.HighlightRules
+	fileRules       []fileRule                // Compiled Settings.FileRules
+	typeAheadPrefix string                    // Characters typed to jump to an item
+	pendingKey      string                    // First key of a two-key sequence such as fd
+	cmdCompletion   completion                // Tab completion of the :cd path being typed
+	typeAheadSeq    int                       // Counts type-ahead keys so stale timeouts are ignored
+	freeSpace       string                    // Free space on the current volume, for {free}
+	gitBranch       string                    // Branch of the current repository, for {git_branch}
+	jumps           []jumpLocation            // Recently left locations (Ctrl+O / Ctrl+I)
+	jumpPos         int                       // Position in jumps while moving through them
+	jumped          bool                      // Whether the last update moved through the jump list
+	lastDir         jumpLocation              // Directory browsed before the current one (-)
+	history         dirHistory                // Directories to go back and forward to (Alt+Left / Alt+Right)
+	historyMoved    bool                      // Whether the last update moved through the history
+	quitPressed     time.Time                 // When q was last pressed, for the "double" quit setting
+	idleLock        time.Duration             // Compiled Settings.IdleLock, 0 if off
+	slideDelay      time.Duration             // Compiled Settings.SlideshowDelay
+	lastInput       time.Time                 // When the last key was pressed
+	idleSuspend     time.Duration             // Compiled Settings.IdleSuspend, 0 if off
+	suspended       []tea.Msg                 // Timer ticks held back while idle
+	frame           *frameCache               // Last frame drawn, nil to draw every frame
+	lock            *screenLock               // Blanked screen, nil while unlocked
+	prefetched      map[string]prefetchedFile // Siblings of the viewed file loaded ahead for ]f / [f
+	DualPane        bool                      // Whether two directories are shown side by side (F3)
+	otherPane       browserPane               // Listing of the inactive pane of the dual layout
+	rightActive     bool                      // Whether the right pane is the active one
+	PreviewPane     bool                      // Whether the entry under the cursor is previewed beside the listing (P)
+	preview         *previewCache             // Last preview drawn, nil to draw it every frame
+	paneSwitched    bool                      // Whether the last update switched panes or tabs
+	tabs            []browserTab              // Saved state of each tab when several are open
+	activeTab       int                       // Index in tabs of the shown tab
+	lastBuffer      int                       // Number given to the most recently opened buffer
+	jobs            []*job                    // Background operations whose progress is shown
+	jobTicking      bool                      // Whether the progress of jobs is being redrawn
+	interrupted     *transferMsg              // Last transfer that stopped early, for :resume
+
+	CommandMode   bool   // Whether in command mode
+	CommandBuffer string // Buffer for command input
+	StatusMessage string // Status or error messages
 }
 
 // NewModel creates and returns the initial model state
@@ -29,24 +102,170 @@ func NewModel() Model {
 	if err != nil {
 		currentPath = "."
 	}
+	return NewModelAt(currentPath)
+}
+
+// NewModelAt creates a model browsing the given directory
+func NewModelAt(path string) Model {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
 
 	m := Model{
-		CurrentPath: currentPath,
-		Cursor:      0,
-		Mode:        BrowseMode,
+		CurrentPath:   path,
+		Cursor:        0,
+		Mode:          BrowseMode,
+		Options:       DefaultBrowseOptions(),
+		globalOptions: DefaultBrowseOptions(),
+	}
+
+	settings, err := config.LoadSettings()
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Error loading settings: %v", err)
+	}
+	m.Settings = settings
+	m.highlightRules, err = compileHighlightRules(settings.HighlightRules)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Error: %v", err)
+	}
+	m.fileRules, err = compileFileRules(settings.FileRules)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Error: %v", err)
+	}
+	if err := setMaxViewSize(settings.MaxViewSize); err != nil {
+		m.StatusMessage = fmt.Sprintf("Error: %v", err)
+	}
+	m.idleLock, err = compileIdleLock(settings.IdleLock)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Error: %v", err)
+	}
+	if hash := settings.LockPasswordHash; hash != "" {
+		// A lock that no password opens would leave the console stuck
+		if err := config.CheckPasswordHash(hash); err != nil {
+			m.Settings.LockPasswordHash = ""
+			m.StatusMessage = fmt.Sprintf("Error: %v; any key unlocks for now", err)
+		}
+	}
+	m.slideDelay, err = compileSlideDelay(settings.SlideshowDelay)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Error: %v", err)
+	}
+	m.cleanAge, err = compileCleanAge(settings.CleanMinAge)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Error: %v", err)
+	}
+	m.idleSuspend, err = compileIdleSuspend(settings.IdleSuspend)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Error: %v", err)
+	}
+	if err := setMaxFPS(settings.MaxFPS); err != nil {
+		m.StatusMessage = fmt.Sprintf("Error: %v", err)
 	}
+	m.frame = &frameCache{}
+	m.preview = &previewCache{}
+	m.PreviewPane = settings.Preview
+	if err := compilePreviewTypes(settings.PreviewTypes); err != nil {
+		m.StatusMessage = fmt.Sprintf("Error: %v", err)
+	}
+	keymap, err = compileKeymap(settings.Keys)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Error: %v", err)
+	}
+	if err := loadCalibration(); err != nil {
+		m.StatusMessage = fmt.Sprintf("Error: %v", err)
+	}
+	if err := setTheme(settings.Theme); err != nil {
+		m.StatusMessage = fmt.Sprintf("Error: %v", err)
+	}
+	if err := setColorscheme(settings.Colorscheme); err != nil {
+		m.StatusMessage = fmt.Sprintf("Error: %v", err)
+	}
+	if err := m.loadSort(); err != nil {
+		m.StatusMessage = fmt.Sprintf("Error: %v", err)
+	}
+	if err := setLimits(settings.Workers, settings.IOLimit); err != nil {
+		m.StatusMessage = fmt.Sprintf("Error: %v", err)
+	}
+	ops.SetVerify(settings.VerifyCopies)
+	spellCheck = settings.SpellCheck
+	m.lastInput = time.Now()
+
 	m.loadDirectory()
 	return m
 }
 
+// NewPagerModel creates a model that only views the given content, like a pager.
+// If lang is not empty it forces the syntax highlighting language.
+func NewPagerModel(name, content, lang string) (Model, error) {
+	m := NewModel()
+	m.PagerMode = true
+
+	viewer := NewContentViewer(name, content)
+	if lang != "" {
+		if err := viewer.SetLanguage(lang); err != nil {
+			return m, err
+		}
+	} else if len(viewer.Sections) == 0 {
+		// Piped help output usually has "Usage:"/"Options:" style headings
+		viewer.Sections = detectSections(viewer.Content)
+	}
+	m.openViewer(&viewer)
+	return m, nil
+}
+
+// NewFileModel creates a model that only views the file at path, opened as
+// the browser opens it: files over max_view_size are read as they are
+// scrolled, and commands such as :tail, :follow and :saveas work on the file.
+// Closing the viewer quits. If lang is not empty it forces the syntax
+// highlighting language.
+func NewFileModel(path, lang string) (Model, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Model{}, err
+	}
+	m := NewModelAt(filepath.Dir(abs))
+	m.PagerMode = true
+
+	viewer := NewFileViewer(abs, filepath.Base(abs))
+	if viewer.Err != nil {
+		return m, viewer.Err
+	}
+	if lang != "" {
+		if err := viewer.SetLanguage(lang); err != nil {
+			return m, err
+		}
+	}
+	m.openViewer(&viewer)
+	return m, nil
+}
+
+// NewStreamPagerModel creates a pager model whose content is read from r as it
+// arrives, following new lines by default
+func NewStreamPagerModel(name string, r io.Reader, lang string) (Model, error) {
+	m, err := NewPagerModel(name, "", lang)
+	if err != nil {
+		return m, err
+	}
+	m.FileViewer.Content = nil
+	m.FileViewer.spans = nil
+	m.FileViewer.stream = streamLines(r)
+	m.FileViewer.Following = true
+	m.FileViewer.Live = true
+	return m, nil
+}
+
 // loadDirectory reads teh contents of the current directory
 func (m *Model) loadDirectory() {
 	m.Items = []types.FileItem{}
 	m.Cursor = 0
 	m.Err = nil
+	m.Marked = nil
+	m.dirSizes = nil
+	m.linksRequested = ""
 
-	// Add parent directory entry if not at root
-	if m.CurrentPath != filepath.VolumeName(m.CurrentPath)+string(filepath.Separator) {
+	// Add parent directory entry if not at root, which for UNC paths is the
+	// share (\\server\share\) rather than the drive
+	if filepath.Dir(m.CurrentPath) != m.CurrentPath {
 		m.Items = append(m.Items, types.FileItem{
 			Name:  "..",
 			Path:  filepath.Dir(m.CurrentPath),
@@ -54,9 +273,27 @@ func (m *Model) loadDirectory() {
 		})
 	}
 
-	entries, err := os.ReadDir(m.CurrentPath)
+	// Detect the enclosing project root
+	m.Project = nil
+	if p, ok := project.Detect(m.CurrentPath); ok {
+		m.Project = &p
+	}
+	m.refreshFormatInfo()
+
+	filter := m.Options.filter()
+	if m.flat.Root != "" && m.flat.Root != m.CurrentPath {
+		m.flat = flatView{}
+	}
+	if m.flat.Root != "" {
+		m.loadFlat(filter)
+		m.groupItems()
+		return
+	}
+
+	entries, err := vfs.Default.ReadDir(m.CurrentPath)
 	if err != nil {
 		m.Err = err
+		m.groupItems()
 		return
 	}
 
@@ -70,108 +307,766 @@ func (m *Model) loadDirectory() {
 			continue
 		}
 
-		item := types.FileItem{
-			Name:  entry.Name(),
-			Path:  filepath.Join(m.CurrentPath, entry.Name()),
-			IsDir: entry.IsDir(),
-			Size:  info.Size(),
+		item := types.NewFileItem(filepath.Join(m.CurrentPath, entry.Name()), info)
+		if !filter.Keep(item) {
+			continue
 		}
 
-		if entry.IsDir() {
+		if item.IsDir {
 			dirs = append(dirs, item)
 		} else {
 			files = append(files, item)
 		}
 	}
 
+	m.Options.sortItems(dirs)
+	m.Options.sortItems(files)
+
 	// Add directories first, then files
 	m.Items = append(m.Items, dirs...)
 	m.Items = append(m.Items, files...)
+	m.groupItems()
+}
+
+// reloadDirectory re-reads the current directory, keeping the cursor on the same item
+func (m *Model) reloadDirectory() {
+	selected, _ := m.selectedItemPath()
+	m.loadDirectory()
+	m.selectPath(selected)
+}
+
+// selectPath moves the cursor to the item with the given path, if listed
+func (m *Model) selectPath(path string) {
+	for i, item := range m.Items {
+		if item.Path == path {
+			m.Cursor = i
+			return
+		}
+	}
 }
 
 // Init initializes the model (called once at startup)
 func (m Model) Init() tea.Cmd {
-	return nil
+	var cmds []tea.Cmd
+	if m.Project != nil {
+		cmds = append(cmds, touchProjectCmd(*m.Project))
+	}
+	if m.FileViewer != nil {
+		cmds = append(cmds, m.FileViewer.Init())
+	} else {
+		cmds = append(cmds, prewarmCmd(m.CurrentPath))
+	}
+	if m.idleLock > 0 {
+		cmds = append(cmds, idleCheckCmd())
+	}
+	return tea.Batch(cmds...)
 }
 
 // Update handles incoming messages and updates the model
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	defer measure("update")()
+	if m.suspendWhileIdle(msg) {
+		return m, nil
+	}
+	prevProject := m.Project
+	prevLocation := m.location()
+	prevLock := m.lock
+	var resume tea.Cmd
+	if _, ok := msg.(tea.KeyMsg); ok {
+		resume = m.resumeTimers()
+	}
+
+	result, cmd := m.update(msg)
+	cmd = tea.Batch(cmd, resume)
+
+	// Draw the next frame again, unless the update was an idle check that
+	// changed nothing
+	if next, ok := result.(Model); ok && next.frame != nil {
+		if _, check := msg.(idleCheckMsg); !check || next.lock != prevLock {
+			next.frame.valid = false
+		}
+	}
+
+	// Start the background work of newly shown viewers and number them
+	if next, ok := result.(Model); ok && next.FileViewer != nil {
+		cmd = tea.Batch(cmd, next.FileViewer.Init())
+		next.numberBuffer()
+		result = next
+	}
+
+	// Count the entries of newly listed directories and the links of their
+	// files, and draw the thumbnails coming into view
+	if next, ok := result.(Model); ok {
+		if countCmd := next.startCounting(); countCmd != nil {
+			cmd = tea.Batch(cmd, countCmd)
+		}
+		if linkCmd := next.startLinkCounting(); linkCmd != nil {
+			cmd = tea.Batch(cmd, linkCmd)
+		}
+		if thumbCmd := next.startThumbnails(); thumbCmd != nil {
+			cmd = tea.Batch(cmd, thumbCmd)
+		}
+		result = next
+	}
+
+	// Remember the locations left for the jump list, the history and -
+	if next, ok := result.(Model); ok {
+		if next.CurrentPath != prevLocation.Dir && !next.paneSwitched {
+			next.lastDir = jumpLocation{Dir: prevLocation.Dir, Item: prevLocation.Item}
+			if !next.historyMoved {
+				next.history.push(next.lastDir)
+			}
+			result = next
+		}
+		if next.historyMoved {
+			next.historyMoved = false
+			result = next
+		}
+		if next.jumped || next.paneSwitched {
+			next.jumped = false
+			next.paneSwitched = false
+			result = next
+		} else if !next.location().samePlace(prevLocation) {
+			next.pushJump(prevLocation)
+			result = next
+		}
+	}
+
+	// Remember projects as they are entered
+	if next, ok := result.(Model); ok && next.Project != nil {
+		if prevProject == nil || prevProject.Root != next.Project.Root {
+			cmd = tea.Batch(cmd, touchProjectCmd(*next.Project))
+		}
+	}
+
+	return result, cmd
+}
+
+// update dispatches a message to the handler for the current mode
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
+		if profiler != nil {
+			// Keep a line for the debug overlay
+			msg.Height--
+		}
+		if sharing != nil {
+			// Keep a line for the sharing bar
+			msg.Height--
+		}
 		m.Height = msg.Height
 		m.Width = msg.Width
 		if m.FileViewer != nil {
-			m.FileViewer.Height = msg.Height
-			m.FileViewer.Width = msg.Width
+			m.FileViewer.Update(msg)
+		}
+		if m.List != nil {
+			m.List.Height = msg.Height
+			m.List.Width = msg.Width
+		}
+		if m.Finder != nil {
+			m.Finder.Height = msg.Height
+			m.Finder.Width = msg.Width
+		}
+		if m.Preview != nil {
+			m.Preview.resize(msg.Width, msg.Height)
+		}
+		if m.Output != nil {
+			m.Output.Height = outputPaneHeight(msg.Height)
+			m.Output.Width = msg.Width
+		}
+		return m, nil
+
+	case reloadMsg:
+		m.reloadDirectory()
+		return m, nil
+
+	case viewerLinesMsg:
+		return m, m.updateViewer(msg.Viewer, msg)
+
+	case followTickMsg:
+		return m, m.updateViewer(msg.Viewer, msg)
+
+	case highlightChunkMsg:
+		return m, m.updateViewer(msg.Viewer, msg)
+
+	case windowIndexMsg:
+		return m, m.updateViewer(msg.Viewer, msg)
+
+	case watchTickMsg:
+		return m, m.updateViewer(msg.Viewer, msg)
+
+	case runTaskMsg, taskOutputMsg, taskDoneMsg, outputSearchMsg, outputSaveMsg:
+		return m.updateTask(msg)
+
+	case openListMsg:
+		m.openList(msg.Panel)
+		return m, nil
+
+	case colorschemeMsg:
+		m.pickColorscheme(msg)
+		return m, nil
+
+	case showCommitMsg:
+		m.setStatus(fmt.Sprintf("Loading commit %s...", msg.Hash))
+		return m, loadCommitCmd(msg)
+
+	case commitLoadedMsg:
+		if msg.Err != nil {
+			m.setStatus(fmt.Sprintf("Error: %v", msg.Err))
+			return m, nil
+		}
+		m.setStatus("")
+		m.openContent(msg.Hash+".diff", msg.Content)
+		return m, nil
+
+	case appUninstallMsg, appUninstalledMsg:
+		return m.updateApps(msg)
+
+	case sysinfoMsg:
+		return m.updateSysinfo(msg)
+
+	case netstatMsg, netstatReloadMsg:
+		return m.updateNetstat(msg)
+
+	case schedTasksMsg, schedTaskActionMsg:
+		return m.updateSchedTasks(msg)
+
+	case showEventMsg:
+		m.openContent(eventFileName(msg.Event), eventDetails(msg.Channel, msg.Event))
+		return m, nil
+
+	case gitStageMsg, gitCommitMsg, gitDiffMsg:
+		return m.updateGit(msg)
+
+	case bookmarkJumpMsg, bookmarkDeleteMsg:
+		return m.updateBookmarks(msg)
+
+	case dirBookmarksMsg, addDirBookmarkMsg, renameDirBookmarkMsg, deleteDirBookmarkMsg, moveDirBookmarkMsg:
+		return m.updateDirBookmarks(msg)
+
+	case openTailMsg:
+		m.openTail(msg)
+		return m, nil
+
+	case transferMsg, transferDoneMsg, deleteMsg, deleteDoneMsg, renameMsg, mkdirMsg:
+		return m.updateFileOps(msg)
+
+	case clipboardDiffMsg:
+		m.showClipboardDiff(msg)
+		return m, nil
+
+	case siblingFileMsg:
+		return m, m.openSibling(msg)
+
+	case prefetchedMsg:
+		m.updatePrefetched(msg)
+		return m, nil
+
+	case junkScannedMsg, junkToggleMsg, junkReviewMsg, junkCleanMsg, junkCleanedMsg:
+		return m.updateJunk(msg)
+
+	case pruneMsg, prunedMsg:
+		return m.updatePrune(msg)
+
+	case folderCountsMsg:
+		m.updateFolderCounts(msg)
+		return m, nil
+
+	case thumbnailsMsg:
+		m.updateThumbnails(msg)
+		return m, nil
+
+	case imageLoadedMsg:
+		return m, m.showImage(msg)
+
+	case slideTickMsg:
+		return m, m.advanceSlideshow(msg)
+
+	case linkCountsMsg:
+		m.updateLinkCounts(msg)
+		return m, nil
+
+	case hardLinkMsg:
+		m.createHardLinks(msg)
+		return m, nil
+
+	case linkNamesMsg:
+		m.showLinkNames(msg)
+		return m, nil
+
+	case dirSizeMsg:
+		m.updateDirSize(msg)
+		return m, nil
+
+	case jobDoneMsg, jobTickMsg, jobPauseMsg, jobCancelMsg:
+		return m.updateJobs(msg)
+
+	case compressMsg:
+		return m, m.compressCmd(msg)
+
+	case compressDoneMsg:
+		m.finishCompress(msg)
+		return m, nil
+
+	case playMsg:
+		return m, playCmd(msg.Path)
+
+	case playedMsg:
+		m.finishPlay(msg)
+		return m, nil
+
+	case zipMsg:
+		return m, m.zipCmd(msg)
+
+	case zipDoneMsg:
+		return m, m.finishZip(msg)
+
+	case mailDraftMsg:
+		m.showMailDraft(msg)
+		return m, nil
+
+	case drivesMsg:
+		m.showDrives(msg)
+		return m, nil
+
+	case sharesMsg:
+		m.showShares(msg)
+		return m, nil
+
+	case quickFilterMsg:
+		m.setQuickFilter(msg.Quick)
+		return m, nil
+
+	case typeAheadExpiredMsg:
+		m.endTypeAhead(msg)
+		return m, nil
+
+	case screenshotMsg:
+		m.takeScreenshot(msg.Path)
+		return m, nil
+
+	case mergedLogMsg:
+		if msg.Err != nil {
+			m.setStatus(fmt.Sprintf("Error: %v", msg.Err))
+			return m, nil
+		}
+		m.setStatus("")
+		m.openMergedLog(msg)
+		return m, nil
+
+	case errorMsg:
+		m.setStatus(fmt.Sprintf("Error: %v", msg.Err))
+		return m, nil
+
+	case openPathMsg:
+		m.openPath(msg.Path)
+		return m, nil
+
+	case finderFilesMsg:
+		if msg.Finder != m.Finder {
+			return m, nil
 		}
+		return m, m.Finder.addFiles(msg)
+
+	case listBuffersMsg, cycleBufferMsg, showBufferMsg:
+		return m.updateBuffers(msg)
+
+	case openMatchMsg:
+		m.openMatch(msg)
+		return m, nil
+
+	case openLinkMsg, urlOpenedMsg:
+		return m.updateLinks(msg)
+
+	case revealPathMsg:
+		m.revealPath(msg.Path)
+		return m, nil
+
+	case changeDirMsg:
+		return m, m.changeDirectory(msg.Path)
+
+	case quitMsg:
+		return m, tea.Quit
+
+	case idleCheckMsg:
+		return m, m.checkIdle()
+
+	case openPromptMsg:
+		prompt := msg.Prompt
+		m.Prompt = &prompt
+		return m, nil
+
+	case openDatePickerMsg:
+		picker := msg.Picker
+		m.DatePicker = &picker
+		return m, nil
+
+	case touchMsg:
+		m.touchFiles(msg)
 		return m, nil
 
 	case tea.KeyMsg:
+		m.lastInput = time.Now()
+		if m.lock != nil {
+			m.updateLock(msg)
+			return m, nil
+		}
+
+		// Handle an active prompt before any mode
+		if m.Prompt != nil {
+			done, cmd := m.Prompt.Update(msg)
+			if done {
+				m.Prompt = nil
+			}
+			return m, cmd
+		}
+		if m.DatePicker != nil {
+			done, cmd := m.DatePicker.Update(msg)
+			if done {
+				m.DatePicker = nil
+			}
+			return m, cmd
+		}
+		if m.Finder != nil {
+			return m, m.updateFinder(msg)
+		}
+		if m.Preview != nil {
+			return m, m.updatePreview(msg)
+		}
+		if m.Calibrator != nil {
+			return m, m.updateCalibrator(msg)
+		}
+
+		// F12 saves a screenshot from any mode
+		if msg.String() == "f12" {
+			return m, screenshotPrompt
+		}
+
+		// Handle focused output pane
+		if m.Mode == OutputMode {
+			switch msg.String() {
+			case "esc", "`":
+				// Return focus to the browser, the task keeps running
+				m.blurOutput(false)
+			case "q":
+				m.blurOutput(true)
+			case "x":
+				if m.TaskRun != nil {
+					m.TaskRun.Kill()
+				}
+			case "ctrl+c":
+				return m, m.quit()
+			default:
+				if m.Output != nil {
+					return m, m.Output.Update(msg)
+				}
+			}
+			return m, nil
+		}
+
+		// Handle list panel mode
+		if m.Mode == ListMode {
+			switch msg.String() {
+			case "q", "esc":
+				// Return to the browser, or to the viewer the list was opened from
+				m.closeList()
+			case "ctrl+c":
+				return m, m.quit()
+			default:
+				if m.List != nil {
+					return m, m.List.Update(msg)
+				}
+			}
+			return m, nil
+		}
+
 		// Handle file viewer mode
 		if m.Mode == FileViewMode {
-			switch msg.String() {
+			key := msg.String()
+			if m.FileViewer != nil && !m.FileViewer.CommandMode {
+				key = keymap.translate("viewer", key)
+			}
+			switch key {
 			case "q", "esc":
-				// Return to browse mode
-				m.Mode = BrowseMode
+				if m.PagerMode {
+					return m, tea.Quit
+				}
+				m.prefetched = nil
+				if m.parentViewer != nil {
+					// Return to the viewer this one was opened from
+					m.FileViewer = m.parentViewer
+					m.parentViewer = nil
+					return m, nil
+				}
+				// Return to the mode the viewer was opened from
+				m.Mode = m.ReturnMode
 				m.FileViewer = nil
 			case "ctrl+c":
-				return m, tea.Quit
+				return m, m.quit()
+			case "ctrl+o", "tab":
+				// Move through the jump list, unless typing a viewer command
+				if !m.PagerMode && m.FileViewer != nil && !m.FileViewer.CommandMode {
+					if key == "ctrl+o" {
+						return m, m.jumpBack()
+					}
+					return m, m.jumpForward()
+				}
+				if m.FileViewer != nil {
+					_, cmd := m.FileViewer.Update(msg)
+					return m, cmd
+				}
 			default:
 				// Pass other keys to the file viewer
 				if m.FileViewer != nil {
-					m.FileViewer.Update(msg)
+					_, cmd := m.FileViewer.Update(msg)
+					return m, cmd
 				}
 			}
 			return m, nil
 		}
 
+		// Handle browser command mode
+		if m.CommandMode {
+			return m.updateCommand(msg)
+		}
+
+		// Keep extending an active type-ahead prefix, even with shortcut keys
+		if m.typeAheadPrefix != "" {
+			if text := inputText(msg); text != "" && text != ":" {
+				return m, m.typeAhead(text)
+			}
+			m.typeAheadPrefix = ""
+		}
+
+		// Combine f with the next key into a quick filter (fd, ff, fe), z
+		// into a group key (za, zM, zR) and s into a sort order (sn, ss, st)
+		if pending := m.pendingKey; pending != "" {
+			m.pendingKey = ""
+			switch pending {
+			case "z":
+				return m, m.groupKeyCmd(msg.String())
+			case "s":
+				m.sortKey(msg.String())
+				return m, nil
+			}
+			return m, m.quickFilterKey(msg.String())
+		}
+
+		key := keymap.translate("browser", msg.String())
+
+		// Move through the thumbnail grid with the arrow keys, whatever Left
+		// and Right are bound to
+		if m.showsGrid() {
+			gridKey := key
+			if raw := msg.String(); raw == "left" || raw == "right" {
+				gridKey = raw
+			}
+			if cmd, ok := m.gridKey(gridKey); ok {
+				return m, cmd
+			}
+		}
+
 		// Handle browse mode
-		switch msg.String() {
-		case "ctrl+c", "q":
-			return m, tea.Quit
+		switch key {
+		case "ctrl+c":
+			return m, m.quit()
 
-		case "up", "k":
-			if m.Cursor > 0 {
-				m.Cursor--
+		case "q":
+			return m, m.quitKey()
+
+		case ":":
+			// Enter command mode
+			m.CommandMode = true
+			m.CommandBuffer = ""
+			m.StatusMessage = ""
+
+		case "ctrl+o":
+			// Return to the previous file or directory in the jump list
+			return m, m.jumpBack()
+
+		case "tab":
+			// Switch panes, or as Ctrl+I move forward in the jump list
+			if m.DualPane {
+				m.switchPane()
+				return m, nil
 			}
+			return m, m.jumpForward()
 
-		case "down", "j":
-			if m.Cursor < len(m.Items)-1 {
-				m.Cursor++
+		case "ctrl+p":
+			// Find a file below the current directory by typing parts of its name
+			return m, m.openFinder()
+
+		case "ctrl+g":
+			// Go to a typed path
+			return m, m.gotoPathKey()
+
+		case "ctrl+t":
+			// Open a tab on the current directory
+			m.newTab()
+
+		case "ctrl+w":
+			// Close the tab
+			m.closeTab()
+
+		case "ctrl+pgdown":
+			// Next tab
+			m.cycleTab(1)
+
+		case "ctrl+pgup":
+			// Previous tab
+			m.cycleTab(-1)
+
+		case "f3":
+			// Show one or two panes
+			m.toggleDualPane()
+
+		case "P":
+			// Show or hide the preview of the entry under the cursor
+			m.togglePreview()
+
+		case "f2":
+			// Rename the selected item
+			return m, m.renameKey()
+
+		case "f5":
+			// Copy to the other pane or a typed directory
+			return m, m.transferKey(false)
+
+		case "f6":
+			// Move to the other pane or a typed directory
+			return m, m.transferKey(true)
+
+		case "f7":
+			// Create a directory
+			return m, m.mkdirKey()
+
+		case "f8", "delete":
+			// Delete the marked or selected items
+			return m, m.deleteKey()
+
+		case "-":
+			// Like cd -: switch to the previous directory
+			m.toggleDirectory()
+
+		case "alt+left", "H":
+			// Back to the directory browsed before
+			m.historyBack()
+
+		case "alt+right", "L":
+			// Forward again after going back
+			m.historyForward()
+
+		case "`":
+			// Focus the output pane
+			if m.Output == nil {
+				m.StatusMessage = "No command output yet"
+			} else {
+				m.focusOutput()
 			}
 
+		case "up", "k":
+			m.moveCursor(-1)
+
+		case "down", "j":
+			m.moveCursor(1)
+
 		case "enter", "l", "right":
-			if len(m.Items) > 0 {
+			if g, ok := m.groupAt(m.Cursor); ok && m.groups.Collapsed[g.Key] {
+				// Expand the collapsed group under the cursor
+				m.setCollapsed(g, false)
+			} else if len(m.Items) > 0 {
 				selected := m.Items[m.Cursor]
 				if selected.IsDir {
 					m.CurrentPath = selected.Path
 					m.loadDirectory()
+				} else if cmd, ok := m.openMarked(); ok {
+					// With files marked, open those instead
+					return m, cmd
 				} else {
 					// Open file viewer
 					viewer := NewFileViewer(selected.Path, selected.Name)
-					viewer.Height = m.Height
-					viewer.Width = m.Width
-					m.FileViewer = &viewer
-					m.Mode = FileViewMode
+					m.openViewer(&viewer)
+					return m, m.prefetchSiblings()
 				}
 			}
 
+		case " ":
+			// Mark or unmark the item for multi-file commands such as :merge
+			return m, m.toggleMark()
+
+		case "*":
+			// Invert the marks
+			return m, m.invertMarks()
+
 		case "h", "left", "backspace":
-			// Go to parent directory
+			// Go to parent directory, or from a root pick another share of
+			// its server or another drive
 			parent := filepath.Dir(m.CurrentPath)
-			if parent != m.CurrentPath {
-				m.CurrentPath = parent
-				m.loadDirectory()
+			if parent == m.CurrentPath {
+				if server := serverOf(m.CurrentPath); server != "" {
+					return m, sharesCmd(server, m.CurrentPath)
+				}
+				return m, drivesCmd(m.CurrentPath)
 			}
+			m.CurrentPath = parent
+			m.loadDirectory()
 
 		case "g":
 			// Go to top
 			m.Cursor = 0
 
 		case "G":
-			// Go to bottom
+			// Go to bottom, or the header of a collapsed last group
 			if len(m.Items) > 0 {
 				m.Cursor = len(m.Items) - 1
+				if g, ok := m.groupAt(m.Cursor); ok && m.groups.Collapsed[g.Key] {
+					m.Cursor = g.Start
+				}
+			}
+
+		case "T":
+			// Open the end of the selected file, however large
+			m.tailSelected(defaultTailLines)
+
+		case "b":
+			// Bookmark the current directory under a name
+			return m, m.bookmarkDirKey()
+
+		case "B":
+			// Pick a bookmarked directory
+			return m, dirBookmarksCmd()
+
+		case "D":
+			// Pick a drive
+			return m, drivesCmd(m.CurrentPath)
+
+		case "f":
+			// Start a quick filter, picked by the next key
+			m.pendingKey = "f"
+			m.StatusMessage = "Quick filter: d directories, f files, e extensions"
+
+		case "z":
+			// Collapse or expand groups, picked by the next key
+			m.pendingKey = "z"
+			m.StatusMessage = "Groups: a collapse or expand, M collapse all, R expand all"
+
+		case "s":
+			// Sort the listing, by the order picked by the next key
+			m.pendingKey = "s"
+			m.StatusMessage = fmt.Sprintf("Sort (now %s): n name, s size, t time, e extension, r reverse", m.Options.sortLabel())
+
+		case "esc":
+			// Clear the quick filter, then leave a flattened listing
+			if m.Options.Quick != "" {
+				m.setQuickFilter("")
+			} else if m.flat.Root != "" {
+				m.setFlatten(flatView{})
+			}
+
+		default:
+			// Other characters jump to the first item starting with them
+			if text := inputText(msg); text != "" && msg.Type == tea.KeyRunes {
+				return m, m.typeAhead(text)
 			}
 		}
 	}
@@ -179,82 +1074,255 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// View renders the current state of the model
+// updateViewer passes a message of a viewer's background work to the viewer.
+// Closed viewers keep what arrived but pause until they are shown again from
+// the jump list.
+func (m *Model) updateViewer(fv *FileViewer, msg tea.Msg) tea.Cmd {
+	_, cmd := fv.Update(msg)
+	if fv != m.FileViewer {
+		fv.pause()
+		return nil
+	}
+	return cmd
+}
+
+// View renders the current state of the model. The last frame is reused
+// while nothing has changed since it was drawn.
 func (m Model) View() string {
+	if m.frame == nil || profiler != nil {
+		return m.draw()
+	}
+	if !m.frame.valid {
+		m.frame.view, m.frame.valid = m.draw(), true
+	}
+	return m.frame.view
+}
+
+// draw renders the screen
+func (m Model) draw() string {
+	if m.lock != nil {
+		if sharing != nil {
+			return sharing.mirror(m.lockView())
+		}
+		return m.lockView()
+	}
+	done := measure("render")
+	view := m.renderMode()
+	if m.Finder != nil {
+		view = m.Finder.View()
+	}
+	if m.Preview != nil {
+		view = m.Preview.View()
+	}
+	if m.Calibrator != nil {
+		view = m.Calibrator.View()
+	}
+	if m.Prompt != nil {
+		view += "\n" + m.Prompt.View()
+	}
+	if m.DatePicker != nil {
+		view += "\n" + m.DatePicker.View()
+	}
+	done()
+	if profiler != nil {
+		view += "\n" + profiler.overlay()
+	}
+	if sharing != nil {
+		view = sharing.mirror(view)
+	}
+	return view
+}
+
+// renderMode renders the component for the current mode
+func (m Model) renderMode() string {
 	// If in file viewer mode, show the file viewer
 	if m.Mode == FileViewMode && m.FileViewer != nil {
 		return m.FileViewer.View()
 	}
 
+	// If a list panel is active, show it
+	if m.Mode == ListMode && m.List != nil {
+		return m.List.View()
+	}
+
 	// Otherwise show the file browser
-	if m.Err != nil {
+	if m.Err != nil && !m.DualPane {
 		return fmt.Sprintf("Error: %v\n\nPress q to quit.", m.Err)
 	}
 
 	var b strings.Builder
 
 	// Title
-	title := titleStyle.Render("📁 File Explorer")
+	title := theme.Title.Render("📁 File Explorer")
 	b.WriteString(title + "\n")
 
-	// Current Path
-	pathDisplay := fmt.Sprintf("Current Path: %s", m.CurrentPath)
-	b.WriteString(pathDisplay + "\n\n")
+	// Tab bar
+	tabBar := m.renderTabs()
+	if tabBar != "" {
+		b.WriteString(tabBar + "\n")
+	}
+
+	// Header
+	header := m.expandFormat(m.Settings.HeaderFormat)
+	if badge := m.listingBadges(); badge != "" && !m.DualPane {
+		header = strings.TrimSpace(header + "  " + badge)
+	}
+	if header != "" {
+		b.WriteString(header + "\n")
+	}
+
+	// Project
+	if m.Project != nil {
+		projectDisplay := fmt.Sprintf("Project: %s (%s)", m.Project.Name, strings.Join(m.Project.Kinds, ", "))
+		b.WriteString(theme.Dim.Render(projectDisplay) + "\n")
+	}
+	b.WriteString("\n")
 
 	// File list
-	visibleStart := 0
-	visibleEnd := len(m.Items)
 	maxVisible := m.Height - 8 // Reserve space for header and footer
+	if m.OutputVisible && m.Output != nil {
+		maxVisible -= m.Output.Height
+	}
+	if tabBar != "" {
+		maxVisible--
+	}
+	jobLines := m.renderJobs()
+	maxVisible -= len(jobLines)
+	if m.DualPane {
+		b.WriteString(m.renderPanes(maxVisible) + "\n")
+	} else if m.previewShown() {
+		b.WriteString(m.renderPreviewPanes(maxVisible) + "\n")
+	} else {
+		for _, line := range m.renderItems(maxVisible, true) {
+			b.WriteString(line + "\n")
+		}
+	}
 
-	if maxVisible > 0 && len(m.Items) > maxVisible {
+	// Status bar
+	if len(m.Items) > 0 {
+		counts := m.expandFormat(m.Settings.StatusFormat)
+		if len(m.Marked) > 0 && !m.usesPlaceholder("marked") {
+			counts += " | " + m.selectionSummary()
+		}
+		if m.Options.Filter != "" {
+			counts += fmt.Sprintf(" | filter: %s", m.Options.Filter)
+		}
+		if !m.usesPlaceholder("sort") {
+			counts += " | sort: " + m.Options.sortLabel()
+		}
+		status := theme.Status.Render("\n" + counts)
+		b.WriteString(status + "\n")
+	}
+
+	// Background jobs
+	for _, line := range jobLines {
+		b.WriteString(theme.Status.Render(line) + "\n")
+	}
+
+	if m.CommandMode {
+		// Show command prompt
+		b.WriteString("\n" + fmt.Sprintf(":%s", m.CommandBuffer))
+		return b.String()
+	}
+
+	if m.StatusMessage != "" {
+		b.WriteString(theme.Status.Render(m.StatusMessage) + "\n")
+	}
+
+	// Help text
+	help := keymap.help(browserHelp...)
+	if m.DualPane {
+		help = keymap.help(dualPaneHelp...)
+	}
+	b.WriteString(theme.Help.Render(help))
+
+	// Docked output pane
+	if m.OutputVisible && m.Output != nil {
+		b.WriteString("\n" + m.Output.View())
+	}
+
+	return b.String()
+}
+
+// renderItems renders the lines of the listing that fit in maxVisible rows,
+// keeping the cursor in view. The cursor line is highlighted when focused.
+func (m Model) renderItems(maxVisible int, focused bool) []string {
+	if m.showsGrid() {
+		return m.renderGrid(m.listWidth(), maxVisible, focused)
+	}
+	if len(m.groups.Groups) > 0 {
+		return m.renderGroupedItems(maxVisible, focused)
+	}
+	visibleStart, visibleEnd := visibleWindow(len(m.Items), m.Cursor, maxVisible)
+	var lines []string
+	for i := visibleStart; i < visibleEnd; i++ {
+		lines = append(lines, m.renderItem(i, focused))
+	}
+	return lines
+}
+
+// visibleWindow returns the rows of a listing of total rows that fit in
+// maxVisible, keeping the cursor row near the middle
+func visibleWindow(total, cursor, maxVisible int) (visibleStart, visibleEnd int) {
+	visibleEnd = total
+	if maxVisible > 0 && total > maxVisible {
 		// Calculate visible windows
-		if m.Cursor >= maxVisible/2 {
-			visibleStart = m.Cursor - maxVisible/2
+		if cursor >= maxVisible/2 {
+			visibleStart = cursor - maxVisible/2
 		}
 		visibleEnd = visibleStart + maxVisible
-		if visibleEnd > len(m.Items) {
-			visibleEnd = len(m.Items)
+		if visibleEnd > total {
+			visibleEnd = total
 			visibleStart = visibleEnd - maxVisible
 			if visibleStart < 0 {
 				visibleStart = 0
 			}
 		}
 	}
+	return visibleStart, visibleEnd
+}
 
-	for i := visibleStart; i < visibleEnd; i++ {
-		item := m.Items[i]
-		cursor := " "
-		if m.Cursor == i {
-			cursor = ">"
-		}
+// renderItem renders the line of item i, highlighted under the cursor when
+// focused
+func (m Model) renderItem(i int, focused bool) string {
+	item := m.Items[i]
+	cursor := " "
+	if m.Cursor == i {
+		cursor = ">"
+	}
+	mark := " "
+	if m.Marked[item.Path] {
+		mark = "*"
+	}
 
-		// Format the item
-		var itemStr string
-		if item.IsDir {
-			itemStr = directoryStyle.Render("📁 " + item.Name + "/")
-		} else {
-			sizeStr := FormatSize(item.Size)
-			itemStr = fileStyle.Render(fmt.Sprintf("📄 %s (%s)", item.Name, sizeStr))
+	// Format the item
+	var itemStr string
+	if item.IsDir {
+		label := item.Icon() + " " + item.DisplayName()
+		if item.IsLink() {
+			label += " → " + item.LinkTarget
 		}
-
-		// Apply selection style if this is the cursor position
-		line := fmt.Sprintf("%s %s", cursor, itemStr)
-		if m.Cursor == i {
-			line = selectedStyle.Render(line)
+		if m.Options.ShowCounts && item.Name != ".." {
+			label += m.folderCountLabel(item.Path)
 		}
-
-		b.WriteString(line + "\n")
+		itemStr = theme.Directory.Render(label)
+	} else {
+		sizeStr := FormatSize(item.Size)
+		if flags := storageFlags(item.Attributes); flags != "" {
+			sizeStr += ", " + flags
+		}
+		sizeStr += hardLinksLabel(item.Links)
+		label := fmt.Sprintf("%s %s (%s)", item.Icon(), item.DisplayName(), sizeStr)
+		if item.IsLink() {
+			label += " → " + item.LinkTarget
+		}
+		itemStr = m.fileStyleFor(item).Render(label)
 	}
 
-	// Status bar
-	if len(m.Items) > 0 {
-		status := statusStyle.Render(fmt.Sprintf("\n%d/%d items", m.Cursor+1, len(m.Items)))
-		b.WriteString(status + "\n")
+	// Apply selection style if this is the cursor position
+	line := fmt.Sprintf("%s%s %s", cursor, mark, itemStr)
+	if m.Cursor == i && focused {
+		line = theme.Selected.Render(line)
 	}
-
-	// Help text
-	help := helpStyle.Render("↑/k: Up  ↓/j: Down  Enter/l: Open  h/Backspace: Back | g: Top | G: Bottom | q: Quit")
-	b.WriteString(help)
-
-	return b.String()
+	return line
 }
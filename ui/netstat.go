@@ -0,0 +1,172 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/netstat"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// netstatTitle is the title of the network connections panel
+const netstatTitle = "🌐 Network Connections"
+
+// netstatSorts are the orders the s key cycles through
+var netstatSorts = []string{"port", "process", "remote", "state", "pid"}
+
+// netstatMsg delivers the connections along with how they are shown
+type netstatMsg struct {
+	Conns     []netstat.Conn
+	Sort      string // One of netstatSorts
+	Filter    string // Only connections containing this text
+	Listening bool   // Only listening ports
+}
+
+// netstatReloadMsg reads the connections again, keeping how they are shown
+type netstatReloadMsg struct {
+	View netstatMsg
+}
+
+// netstatCmd reads the connections in the background
+func netstatCmd(view netstatMsg) tea.Cmd {
+	return func() tea.Msg {
+		conns, err := netstat.List()
+		if err != nil {
+			return errorMsg{err}
+		}
+		view.Conns = conns
+		return view
+	}
+}
+
+// netstatMatches reports whether a connection contains the lowercase filter in
+// any of its columns
+func netstatMatches(c netstat.Conn, filter string) bool {
+	text := strings.ToLower(strings.Join([]string{
+		c.Proto, c.Local, c.Remote, c.State, strconv.Itoa(c.PID), c.Process}, " "))
+	return strings.Contains(text, filter)
+}
+
+// compareConns orders two connections by a netstatSorts column, then by local
+// port
+func compareConns(a, b netstat.Conn, order string) int {
+	_, portA := netstat.SplitAddr(a.Local)
+	_, portB := netstat.SplitAddr(b.Local)
+	var c int
+	switch order {
+	case "process":
+		c = cmp.Compare(strings.ToLower(a.Process), strings.ToLower(b.Process))
+	case "remote":
+		hostA, remoteA := netstat.SplitAddr(a.Remote)
+		hostB, remoteB := netstat.SplitAddr(b.Remote)
+		c = cmp.Or(cmp.Compare(hostA, hostB), cmp.Compare(remoteA, remoteB))
+	case "state":
+		c = cmp.Compare(a.State, b.State)
+	case "pid":
+		c = cmp.Compare(a.PID, b.PID)
+	}
+	return cmp.Or(c, cmp.Compare(portA, portB), cmp.Compare(a.Proto, b.Proto))
+}
+
+// netstatPanel lists the connections matching the view, with actions to filter,
+// sort and refresh them
+func netstatPanel(view netstatMsg) ListPanel {
+	filter := strings.ToLower(view.Filter)
+	var conns []netstat.Conn
+	listening := 0
+	for _, c := range view.Conns {
+		if (view.Listening && !c.Listening()) || !netstatMatches(c, filter) {
+			continue
+		}
+		if c.Listening() {
+			listening++
+		}
+		conns = append(conns, c)
+	}
+	slices.SortStableFunc(conns, func(a, b netstat.Conn) int {
+		return compareConns(a, b, view.Sort)
+	})
+
+	entries := make([]ListEntry, 0, len(conns))
+	for _, c := range conns {
+		process := c.Process
+		if process == "" {
+			process = theme.Dim.Render("?")
+		}
+		label := fmt.Sprintf("%-6s %-30s %-30s %-12s %7d  %s",
+			c.Proto,
+			truncateAtVisualWidth(c.Local, 30),
+			truncateAtVisualWidth(c.Remote, 30),
+			truncateAtVisualWidth(c.State, 12),
+			c.PID, process)
+		// Enter shows only the connections of the same process
+		only := view
+		only.Filter = c.Process
+		if c.Process == "" {
+			only.Filter = strconv.Itoa(c.PID)
+		}
+		entries = append(entries, ListEntry{Label: label, Data: c, Msg: only})
+	}
+
+	panel := NewListPanel(netstatTitle, entries)
+	panel.Subtitle = fmt.Sprintf("%d connections, %d listening, sorted by %s", len(conns), listening, view.Sort)
+	if view.Listening {
+		panel.Subtitle += ", listening only"
+	}
+	if view.Filter != "" {
+		panel.Subtitle += fmt.Sprintf(", filter %q", view.Filter)
+	}
+
+	panel.Actions = []ListAction{
+		{Key: "/", Desc: "filter", Msg: func(ListEntry) tea.Msg {
+			return openPromptMsg{Prompt{
+				Label:  "Filter connections: ",
+				Buffer: view.Filter,
+				Submit: func(value string) tea.Msg {
+					next := view
+					next.Filter = strings.TrimSpace(value)
+					return next
+				},
+			}}
+		}},
+		{Key: "s", Desc: "sort", Msg: func(ListEntry) tea.Msg {
+			next := view
+			next.Sort = netstatSorts[(slices.Index(netstatSorts, view.Sort)+1)%len(netstatSorts)]
+			return next
+		}},
+		{Key: "l", Desc: "listening only", Msg: func(ListEntry) tea.Msg {
+			next := view
+			next.Listening = !view.Listening
+			return next
+		}},
+		{Key: "r", Desc: "refresh", Msg: func(ListEntry) tea.Msg {
+			return netstatReloadMsg{View: view}
+		}},
+	}
+	return panel
+}
+
+// updateNetstat handles the network connections panel
+func (m Model) updateNetstat(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case netstatMsg:
+		m.setStatus("")
+		panel := netstatPanel(msg)
+		if m.Mode == ListMode && m.List != nil && m.List.Title == netstatTitle {
+			// Filtering, sorting and refreshing keep the panel
+			panel.Width, panel.Height = m.List.Width, m.List.Height
+			panel.SetCursor(m.List.Cursor)
+			m.List = &panel
+			return m, nil
+		}
+		m.openList(panel)
+
+	case netstatReloadMsg:
+		m.setStatus("Reading network connections...")
+		return m, netstatCmd(msg.View)
+	}
+	return m, nil
+}
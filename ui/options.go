@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/types"
+)
+
+// BrowseOptions are the listing options of a browser pane. :setlocal changes
+// them for the current pane, :set also changes the defaults given to new panes.
+type BrowseOptions struct {
+	ShowHidden bool   // List hidden files and directories
+	Sort       string // Name of a types.Sorter: name, size, time (newest first), ext or a registered order
+	Reverse    bool   // Sort the other way round
+	Filter     string // Glob pattern files must match to be listed; empty lists all
+	ShowCounts bool   // Show the number of items in each directory
+	Quick      string // Quick filter: "dirs", "files" or extensions such as ".go .md"; empty lists all
+	Group      string // Grouping of the listing under headers: ext, day or size; empty for none
+	Thumbs     bool   // Show directories of mostly images as a grid of thumbnails
+}
+
+// DefaultBrowseOptions returns the options of a new browser
+func DefaultBrowseOptions() BrowseOptions {
+	return BrowseOptions{ShowHidden: true, Sort: "name"}
+}
+
+// boolOption formats a boolean option the way :set accepts it
+func boolOption(name string, on bool) string {
+	if on {
+		return name
+	}
+	return "no" + name
+}
+
+// String formats the options the way :set accepts them
+func (o BrowseOptions) String() string {
+	return fmt.Sprintf("%s sort=%s %s filter=%s %s group=%s %s",
+		boolOption("hidden", o.ShowHidden), o.Sort, boolOption("reverse", o.Reverse), o.Filter, boolOption("counts", o.ShowCounts), o.Group, boolOption("thumbs", o.Thumbs))
+}
+
+// flag returns the boolean option called name
+func (o *BrowseOptions) flag(name string) *bool {
+	switch name {
+	case "hidden":
+		return &o.ShowHidden
+	case "counts":
+		return &o.ShowCounts
+	case "thumbs":
+		return &o.Thumbs
+	case "reverse":
+		return &o.Reverse
+	}
+	return nil
+}
+
+// set applies one option argument ("nohidden", "sort=size", "filter=*.log",
+// "group=ext") and returns the name of the option it changed
+func (o *BrowseOptions) set(arg string) (string, error) {
+	name, value, hasValue := strings.Cut(arg, "=")
+
+	// Boolean options: name, noname and name! to toggle
+	flagName, toggle := strings.CutSuffix(name, "!")
+	on := true
+	if o.flag(flagName) == nil && !toggle {
+		if rest, ok := strings.CutPrefix(flagName, "no"); ok {
+			flagName, on = rest, false
+		}
+	}
+	if flag := o.flag(flagName); flag != nil {
+		if hasValue {
+			return "", fmt.Errorf("%s takes no value", flagName)
+		}
+		if toggle {
+			on = !*flag
+		}
+		*flag = on
+		return flagName, nil
+	}
+
+	switch name {
+	case "sort":
+		if _, ok := types.SorterNamed(value); !ok {
+			return "", fmt.Errorf("sort must be one of %s", strings.Join(types.SorterNames(), ", "))
+		}
+		o.Sort = value
+		return name, nil
+
+	case "filter":
+		if _, err := filepath.Match(value, ""); err != nil {
+			return "", fmt.Errorf("filter %q: %v", value, err)
+		}
+		o.Filter = value
+		return name, nil
+
+	case "group":
+		if value != "" && !slices.Contains(groupings, value) {
+			return "", fmt.Errorf("group must be one of %s, or empty", strings.Join(groupings, ", "))
+		}
+		o.Group = value
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown option: %s", name)
+}
+
+// copyOption copies the named option from other
+func (o *BrowseOptions) copyOption(name string, other BrowseOptions) {
+	if flag := o.flag(name); flag != nil {
+		*flag = *other.flag(name)
+		return
+	}
+	switch name {
+	case "sort":
+		o.Sort = other.Sort
+	case "filter":
+		o.Filter = other.Filter
+	case "group":
+		o.Group = other.Group
+	}
+}
+
+// filter returns the filter of the entries listed under the options
+func (o BrowseOptions) filter() types.Filter {
+	var hidden types.Filter
+	if !o.ShowHidden {
+		hidden = types.NotHidden
+	}
+	return types.All(hidden, types.Glob(o.Filter), quickFilter(o.Quick))
+}
+
+// sortItems orders directory entries by the sort option. Entries arrive sorted
+// by name, so the name order needs no work. Reversed, items the order ranks
+// equal stay in name order.
+func (o BrowseOptions) sortItems(items []types.FileItem) {
+	sorter, ok := types.SorterNamed(o.Sort)
+	switch {
+	case !ok || sorter.Name() == types.ByName.Name() && !o.Reverse:
+	case o.Reverse:
+		types.Sort(items, types.SortFunc{ID: sorter.Name(), Func: func(a, b types.FileItem) int { return sorter.Compare(b, a) }})
+	default:
+		types.Sort(items, sorter)
+	}
+}
+
+// setOptions handles :set and :setlocal. Without arguments the current options
+// are shown.
+func (m *Model) setOptions(args []string, local bool) {
+	if len(args) == 0 {
+		m.StatusMessage = m.Options.String()
+		return
+	}
+	// Nothing changes unless every argument is valid
+	options, global := m.Options, m.globalOptions
+	for _, arg := range args {
+		name, err := options.set(arg)
+		if err != nil {
+			m.StatusMessage = fmt.Sprintf("Error: %v", err)
+			return
+		}
+		if !local {
+			global.copyOption(name, options)
+		}
+	}
+	m.Options, m.globalOptions = options, global
+	m.reloadDirectory()
+	m.StatusMessage = m.Options.String()
+}
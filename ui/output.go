@@ -0,0 +1,463 @@
+package ui
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/tasks"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxOutputBatch caps how many lines are delivered in a single output message
+const maxOutputBatch = 512
+
+// runTaskMsg requests that a task be started
+type runTaskMsg struct {
+	Task tasks.Task
+}
+
+// taskOutputMsg delivers lines printed by a running task
+type taskOutputMsg struct {
+	Run   *tasks.Run
+	Lines []string
+}
+
+// taskDoneMsg reports that a task has exited
+type taskDoneMsg struct {
+	Run *tasks.Run
+	Err error
+}
+
+// outputSearchMsg requests a search in the output pane
+type outputSearchMsg struct {
+	Term string
+}
+
+// outputSaveMsg requests that the output pane be written to a file
+type outputSaveMsg struct {
+	Path string
+}
+
+// OutputPane shows the scrollable output of tasks and shell commands,
+// docked at the bottom of the browser
+type OutputPane struct {
+	Title             string
+	Lines             []string
+	ScrollPos         int
+	Follow            bool // Auto-scroll to new output
+	Running           bool
+	Focused           bool // Whether keys are routed to the pane
+	Width             int
+	Height            int // Rows available to the pane, including its header
+	StatusMessage     string
+	SearchTerm        string // Current search term
+	SearchMatches     []int  // Line numbers with matches
+	CurrentMatchIndex int    // Index of the current match
+}
+
+// NewOutputPane creates an empty output pane that follows new output
+func NewOutputPane(title string) OutputPane {
+	return OutputPane{
+		Title:             title,
+		Follow:            true,
+		Running:           true,
+		CurrentMatchIndex: -1,
+	}
+}
+
+// outputPaneHeight returns the number of rows the docked pane takes for a window height
+func outputPaneHeight(windowHeight int) int {
+	height := windowHeight / 3
+	if height < 6 {
+		height = 6
+	}
+	return height
+}
+
+// maxVisible returns the number of output lines that fit in the pane
+func (op *OutputPane) maxVisible() int {
+	// Header line, status line and help line
+	visible := op.Height - 3
+	if visible < 1 {
+		visible = 1
+	}
+	return visible
+}
+
+// maxScroll returns the largest valid scroll position
+func (op *OutputPane) maxScroll() int {
+	maxScroll := len(op.Lines) - op.maxVisible()
+	if maxScroll < 0 {
+		maxScroll = 0
+	}
+	return maxScroll
+}
+
+// Append adds lines to the pane, scrolling to the bottom when following
+func (op *OutputPane) Append(lines ...string) {
+	start := len(op.Lines)
+	op.Lines = append(op.Lines, lines...)
+
+	// Keep an active search up to date with new output
+	if op.SearchTerm != "" {
+		for i := start; i < len(op.Lines); i++ {
+			if strings.Contains(strings.ToLower(op.Lines[i]), op.SearchTerm) {
+				op.SearchMatches = append(op.SearchMatches, i)
+			}
+		}
+	}
+
+	if op.Follow {
+		op.ScrollPos = op.maxScroll()
+	}
+}
+
+// Finish marks the output as complete
+func (op *OutputPane) Finish(err error) {
+	op.Running = false
+	if err != nil {
+		op.StatusMessage = fmt.Sprintf("Exited: %v", err)
+	} else {
+		op.StatusMessage = "Finished successfully"
+	}
+}
+
+// Search finds all lines containing term (case-insensitive) and jumps to the first
+func (op *OutputPane) Search(term string) {
+	op.SearchMatches = nil
+	op.CurrentMatchIndex = -1
+	op.SearchTerm = strings.ToLower(term)
+	if term == "" {
+		op.StatusMessage = "Search cleared"
+		return
+	}
+
+	for i, line := range op.Lines {
+		if strings.Contains(strings.ToLower(line), op.SearchTerm) {
+			op.SearchMatches = append(op.SearchMatches, i)
+		}
+	}
+
+	if len(op.SearchMatches) == 0 {
+		op.StatusMessage = fmt.Sprintf("Pattern not found: %s", term)
+		return
+	}
+	op.jumpToMatch(0)
+}
+
+// jumpToMatch scrolls to the match with the given index
+func (op *OutputPane) jumpToMatch(index int) {
+	op.CurrentMatchIndex = index
+	op.Follow = false
+	op.ScrollPos = op.SearchMatches[index]
+	if op.ScrollPos > op.maxScroll() {
+		op.ScrollPos = op.maxScroll()
+	}
+	op.StatusMessage = fmt.Sprintf("Match %d of %d", index+1, len(op.SearchMatches))
+}
+
+// Save writes the output to path
+func (op *OutputPane) Save(path string) error {
+	content := strings.Join(op.Lines, "\n") + "\n"
+	return vfs.Default.WriteFile(path, []byte(content), 0o644)
+}
+
+// Update handles keyboard input for the output pane
+func (op *OutputPane) Update(msg tea.KeyMsg) tea.Cmd {
+	maxVisible := op.maxVisible()
+
+	switch msg.String() {
+	case "up", "k":
+		if op.ScrollPos > 0 {
+			op.ScrollPos--
+		}
+		op.Follow = false
+
+	case "down", "j":
+		if op.ScrollPos < op.maxScroll() {
+			op.ScrollPos++
+		}
+
+	case "g":
+		op.ScrollPos = 0
+		op.Follow = false
+
+	case "G":
+		// Jump to bottom and resume following
+		op.ScrollPos = op.maxScroll()
+		op.Follow = true
+
+	case "pgup", "ctrl+u":
+		op.ScrollPos -= maxVisible / 2
+		if op.ScrollPos < 0 {
+			op.ScrollPos = 0
+		}
+		op.Follow = false
+
+	case "pgdown", "ctrl+d":
+		op.ScrollPos += maxVisible / 2
+		if op.ScrollPos > op.maxScroll() {
+			op.ScrollPos = op.maxScroll()
+		}
+
+	case "/":
+		return func() tea.Msg {
+			return openPromptMsg{Prompt{
+				Label:  "Search output: ",
+				Submit: func(value string) tea.Msg { return outputSearchMsg{Term: value} },
+			}}
+		}
+
+	case "n":
+		if len(op.SearchMatches) > 0 {
+			op.jumpToMatch((op.CurrentMatchIndex + 1) % len(op.SearchMatches))
+		}
+
+	case "N":
+		if len(op.SearchMatches) > 0 {
+			index := op.CurrentMatchIndex - 1
+			if index < 0 {
+				index = len(op.SearchMatches) - 1
+			}
+			op.jumpToMatch(index)
+		}
+
+	case "w":
+		return func() tea.Msg {
+			return openPromptMsg{Prompt{
+				Label:  "Save output to: ",
+				Submit: func(value string) tea.Msg { return outputSaveMsg{Path: value} },
+			}}
+		}
+	}
+
+	return nil
+}
+
+// View renders the output pane
+func (op OutputPane) View() string {
+	var b strings.Builder
+
+	// Header
+	state := "finished"
+	if op.Running {
+		state = "running"
+	}
+	follow := ""
+	if op.Follow {
+		follow = " | follow"
+	}
+	header := fmt.Sprintf("── %s ── %s | %d lines%s ", op.Title, state, len(op.Lines), follow)
+	if pad := op.Width - visualLength(header); pad > 0 {
+		header += strings.Repeat("─", pad)
+	}
+	style := theme.Dim
+	if op.Focused {
+		style = theme.Directory
+	}
+	b.WriteString(style.Render(header) + "\n")
+
+	// Visible output
+	visibleEnd := op.ScrollPos + op.maxVisible()
+	if visibleEnd > len(op.Lines) {
+		visibleEnd = len(op.Lines)
+	}
+	for i := op.ScrollPos; i < visibleEnd; i++ {
+		line := op.Lines[i]
+		if op.Width > 3 && visualLength(line) > op.Width {
+			line = truncateAtVisualWidth(line, op.Width-3) + "..."
+		}
+		if op.SearchTerm != "" {
+			line = highlightSearchMatches(line, op.SearchTerm)
+		}
+		b.WriteString(line + "\n")
+	}
+	for i := visibleEnd - op.ScrollPos; i < op.maxVisible(); i++ {
+		b.WriteString("\n")
+	}
+
+	// Status and help
+	b.WriteString(theme.Dim.Render(op.StatusMessage) + "\n")
+	if op.Focused {
+		b.WriteString(theme.Help.UnsetMarginTop().Render("↑/k ↓/j: scroll | G: follow | /: search | n/N: match | w: save | x: kill | Esc: browser | q: close"))
+	} else {
+		b.WriteString(theme.Help.UnsetMarginTop().Render("`: focus output | :close: hide"))
+	}
+
+	return b.String()
+}
+
+// tasksCmd discovers the tasks of the current project into a picker panel
+func (m Model) tasksCmd() tea.Cmd {
+	root := m.CurrentPath
+	if m.Project != nil {
+		root = m.Project.Root
+	}
+
+	return func() tea.Msg {
+		var items []ListEntry
+		for _, task := range tasks.Discover(root) {
+			label := fmt.Sprintf("%s %s  %s",
+				theme.Directory.Render(fmt.Sprintf("%-24s", task.Name)),
+				theme.Dim.Render(fmt.Sprintf("%-14s", task.Source)),
+				task.CommandLine())
+			items = append(items, ListEntry{Label: label, Data: task, Msg: runTaskMsg{task}})
+		}
+
+		panel := NewListPanel("⚙ Tasks", items)
+		panel.Subtitle = fmt.Sprintf("Project: %s", root)
+		if len(items) == 0 {
+			panel.StatusMessage = "No tasks found (Makefile, package.json, go.mod)"
+		}
+		return openListMsg{panel}
+	}
+}
+
+// waitForTaskOutput reads the next batch of output from a running task
+func waitForTaskOutput(run *tasks.Run) tea.Cmd {
+	return func() tea.Msg {
+		lines, ok := readLineBatch(run.Lines)
+		if !ok {
+			return taskDoneMsg{Run: run, Err: run.Wait()}
+		}
+		return taskOutputMsg{Run: run, Lines: lines}
+	}
+}
+
+// readLineBatch blocks for the next line and gathers whatever else is already
+// buffered, limiting message count. It reports false once the channel is closed.
+func readLineBatch(ch <-chan string) ([]string, bool) {
+	line, ok := <-ch
+	if !ok {
+		return nil, false
+	}
+
+	lines := []string{line}
+	for len(lines) < maxOutputBatch {
+		select {
+		case next, ok := <-ch:
+			if !ok {
+				return lines, true
+			}
+			lines = append(lines, next)
+		default:
+			return lines, true
+		}
+	}
+	return lines, true
+}
+
+// updateTask handles task lifecycle messages
+func (m Model) updateTask(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case runTaskMsg:
+		// Only one task runs at a time; the output of the one replaced is
+		// no longer read
+		if m.TaskRun != nil {
+			m.TaskRun.Discard()
+		}
+
+		run, err := tasks.Start(msg.Task)
+		if err != nil {
+			m.setStatus(fmt.Sprintf("Error: %v", err))
+			return m, nil
+		}
+
+		title := fmt.Sprintf("⚙ %s", msg.Task.CommandLine())
+		if msg.Task.Source == "shell" {
+			title = "$ " + msg.Task.Name
+		}
+		pane := NewOutputPane(title)
+		m.Output = &pane
+		m.TaskRun = run
+		m.List = nil
+		m.focusOutput()
+		return m, waitForTaskOutput(run)
+
+	case taskOutputMsg:
+		// Ignore output from tasks that have been replaced
+		if msg.Run != m.TaskRun || m.Output == nil {
+			return m, nil
+		}
+		m.Output.Append(msg.Lines...)
+		return m, waitForTaskOutput(msg.Run)
+
+	case taskDoneMsg:
+		if msg.Run != m.TaskRun {
+			return m, nil
+		}
+		m.TaskRun = nil
+		if m.Output != nil {
+			m.Output.Finish(msg.Err)
+		}
+		if !m.OutputVisible {
+			m.StatusMessage = fmt.Sprintf("Task finished: %s", msg.Run.Task.Name)
+		}
+
+	case outputSearchMsg:
+		if m.Output != nil {
+			m.Output.Search(msg.Term)
+		}
+
+	case outputSaveMsg:
+		if m.Output == nil || strings.TrimSpace(msg.Path) == "" {
+			return m, nil
+		}
+		path := strings.TrimSpace(msg.Path)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(m.CurrentPath, path)
+		}
+		if err := m.Output.Save(path); err != nil {
+			m.Output.StatusMessage = fmt.Sprintf("Error: %v", err)
+		} else {
+			m.Output.StatusMessage = fmt.Sprintf("Saved %d lines to %s", len(m.Output.Lines), path)
+		}
+	}
+
+	return m, nil
+}
+
+// focusOutput docks the output pane and routes keys to it
+func (m *Model) focusOutput() {
+	if m.Output == nil {
+		return
+	}
+	m.OutputVisible = true
+	m.Output.Focused = true
+	m.Output.Width = m.Width
+	m.Output.Height = outputPaneHeight(m.Height)
+	m.Mode = OutputMode
+}
+
+// blurOutput returns keys to the browser, optionally hiding the pane
+func (m *Model) blurOutput(hide bool) {
+	if m.Output != nil {
+		m.Output.Focused = false
+	}
+	if hide {
+		m.OutputVisible = false
+	}
+	m.Mode = BrowseMode
+}
+
+// runShellCmd runs a non-interactive shell command with output captured in the pane
+func (m Model) runShellCmd(commandLine string) tea.Cmd {
+	task := tasks.Shell(commandLine, m.CurrentPath)
+	return func() tea.Msg { return runTaskMsg{task} }
+}
+
+// execShellCmd suspends the TUI and runs an interactive shell command
+func (m Model) execShellCmd(commandLine string) tea.Cmd {
+	task := tasks.Shell(commandLine, m.CurrentPath)
+	cmd := exec.Command(task.Command, task.Args...)
+	cmd.Dir = task.Dir
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if err != nil {
+			return errorMsg{err}
+		}
+		return reloadMsg{}
+	})
+}
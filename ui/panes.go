@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/project"
+	"github.com/HolyStarGazer/windows-tui-go/types"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// browserPane is the listing state of a pane that is not active. The active
+// pane lives in the Model's own fields, so everything else keeps working on it.
+type browserPane struct {
+	CurrentPath string
+	Items       []types.FileItem
+	Cursor      int
+	Err         error
+	Marked      map[string]bool
+	dirSizes    map[string]int64
+	Options     BrowseOptions
+	flat        flatView
+	groups      groupView
+	Project     *project.Project
+	lastDir     jumpLocation
+	history     dirHistory
+	freeSpace   string
+	gitBranch   string
+}
+
+// savePane returns the listing state of the active pane
+func (m *Model) savePane() browserPane {
+	return browserPane{
+		CurrentPath: m.CurrentPath,
+		Items:       m.Items,
+		Cursor:      m.Cursor,
+		Err:         m.Err,
+		Marked:      m.Marked,
+		dirSizes:    m.dirSizes,
+		Options:     m.Options,
+		flat:        m.flat,
+		groups:      m.groups,
+		Project:     m.Project,
+		lastDir:     m.lastDir,
+		history:     m.history,
+		freeSpace:   m.freeSpace,
+		gitBranch:   m.gitBranch,
+	}
+}
+
+// restorePane makes a saved pane the active one
+func (m *Model) restorePane(p browserPane) {
+	m.CurrentPath = p.CurrentPath
+	m.Items = p.Items
+	m.Cursor = p.Cursor
+	m.Err = p.Err
+	m.Marked = p.Marked
+	m.dirSizes = p.dirSizes
+	m.Options = p.Options
+	m.flat = p.flat
+	m.groups = p.groups
+	m.Project = p.Project
+	m.lastDir = p.lastDir
+	m.history = p.history
+	m.freeSpace = p.freeSpace
+	m.gitBranch = p.gitBranch
+}
+
+// toggleDualPane shows or hides the second pane. The first time, it opens on
+// the current directory with the global options; after that it comes back as
+// it was left.
+func (m *Model) toggleDualPane() {
+	m.DualPane = !m.DualPane
+	if !m.DualPane || m.otherPane.CurrentPath != "" {
+		return
+	}
+	active := m.savePane()
+	selected, _ := m.selectedItemPath()
+	m.Options = m.globalOptions
+	m.flat = flatView{}
+	m.groups = groupView{}
+	m.loadDirectory()
+	m.selectPath(selected)
+	m.otherPane = m.savePane()
+	m.restorePane(active)
+}
+
+// switchPane makes the other pane active
+func (m *Model) switchPane() {
+	other := m.otherPane
+	m.otherPane = m.savePane()
+	m.restorePane(other)
+	m.rightActive = !m.rightActive
+	m.paneSwitched = true
+}
+
+// reloadOtherPane re-reads the directory of the inactive pane
+func (m *Model) reloadOtherPane() {
+	active := m.savePane()
+	m.restorePane(m.otherPane)
+	m.reloadDirectory()
+	m.otherPane = m.savePane()
+	m.restorePane(active)
+}
+
+// renderPanes renders the two panes side by side, the active one highlighted
+func (m Model) renderPanes(maxVisible int) string {
+	width := max(m.Width/2-2, 10)
+	other := m
+	other.restorePane(m.otherPane)
+
+	active := m.renderPane(width, maxVisible, true)
+	inactive := other.renderPane(width, maxVisible, false)
+	if m.rightActive {
+		return lipgloss.JoinHorizontal(lipgloss.Top, inactive, active)
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, active, inactive)
+}
+
+// renderPane renders the listing of one pane in a box width columns wide
+func (m Model) renderPane(width, maxVisible int, active bool) string {
+	// The box takes a row above and below, and the path another
+	rows := max(maxVisible-3, 1)
+	clip := lipgloss.NewStyle().MaxWidth(width)
+
+	path := theme.Directory.Render(m.CurrentPath)
+	if badge := m.listingBadges(); badge != "" {
+		path += " " + badge
+	}
+	lines := []string{clip.Render(path)}
+	if m.Err != nil {
+		lines = append(lines, clip.Render(fmt.Sprintf("Error: %v", m.Err)))
+	}
+	for _, line := range m.renderItems(rows, active) {
+		lines = append(lines, clip.Render(line))
+	}
+	for len(lines) < rows+1 {
+		lines = append(lines, "")
+	}
+
+	style := theme.Pane
+	if active {
+		style = theme.ActivePane
+	}
+	return style.Width(width).Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,382 @@
+package ui
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/media"
+	"github.com/HolyStarGazer/windows-tui-go/picture"
+	"github.com/HolyStarGazer/windows-tui-go/types"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The ways the preview pane shows a file, picked by type or by the
+// preview_types setting
+const (
+	previewView    = "view"    // As the viewer shows it: rendered Markdown, tables, highlighted code
+	previewText    = "text"    // The source text, highlighted but not rendered
+	previewImage   = "image"   // The image, drawn in the pane
+	previewArchive = "archive" // The entries of a zip or tar archive
+	previewSummary = "summary" // Size, type and the first bytes in hex
+	previewNone    = "none"    // Only the name and size
+)
+
+// previewKinds are the values of the preview_types setting
+var previewKinds = []string{previewView, previewText, previewImage, previewArchive, previewSummary, previewNone}
+
+// previewBytes is how much of a file the pane reads for text and summaries
+const previewBytes = 64 << 10
+
+// maxPreviewImage is the largest image the pane decodes; larger ones are
+// summarized
+const maxPreviewImage = 32 << 20
+
+// summaryBytes is how many of the first bytes a summary shows in hex
+const summaryBytes = 128
+
+// previewCache holds the lines of the last preview drawn, so moving around
+// the same entry or redrawing does not read it again. It is shared by the
+// copies of the model.
+type previewCache struct {
+	key   string
+	lines []string
+}
+
+// compilePreviewTypes checks the preview_types setting, which maps extensions
+// or file names to one of previewKinds
+func compilePreviewTypes(rules map[string]string) error {
+	var problems []string
+	for pattern, kind := range rules {
+		if !validPreviewKind(kind) {
+			problems = append(problems, fmt.Sprintf("%s: %q", pattern, kind))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("preview_types: %s (use %s)", strings.Join(problems, ", "), strings.Join(previewKinds, ", "))
+}
+
+// validPreviewKind reports whether kind is one of previewKinds
+func validPreviewKind(kind string) bool {
+	for _, k := range previewKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// isArchiveFile reports whether the preview pane can list the entries of name
+func isArchiveFile(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".zip", ".jar", ".war", ".whl", ".nupkg", ".tar", ".tgz", ".tar.gz"} {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// previewKind returns how the preview pane shows a file: as the preview_types
+// setting says for its name or extension, or else by its type. Binary files
+// shown in view or text are summarized instead once read.
+func (m Model) previewKind(name string) string {
+	if kind := typeRule(m.Settings.PreviewTypes, name); validPreviewKind(kind) {
+		return kind
+	}
+	switch {
+	case picture.IsImage(name):
+		return previewImage
+	case isArchiveFile(name):
+		return previewArchive
+	case media.IsMedia(name):
+		return previewSummary
+	}
+	return previewView
+}
+
+// previewShown reports whether the preview pane is shown next to the listing,
+// which it is not in two-pane mode
+func (m Model) previewShown() bool {
+	return m.PreviewPane && !m.DualPane
+}
+
+// togglePreview shows or hides the preview pane (P or :preview)
+func (m *Model) togglePreview() {
+	m.PreviewPane = !m.PreviewPane
+	switch {
+	case !m.PreviewPane:
+		m.StatusMessage = "Preview pane off"
+	case m.DualPane:
+		m.StatusMessage = "Preview pane on (shown with one pane)"
+	default:
+		m.StatusMessage = "Preview pane on"
+	}
+}
+
+// renderPreviewPanes renders the listing on the left and the preview of the
+// entry under the cursor on the right, in boxes like those of two panes
+func (m Model) renderPreviewPanes(maxVisible int) string {
+	width := max(m.Width/2-2, 10)
+	rows := max(maxVisible-3, 1)
+	clip := lipgloss.NewStyle().MaxWidth(width)
+
+	var lines []string
+	if m.Cursor < len(m.Items) {
+		item := m.Items[m.Cursor]
+		lines = append(lines, clip.Render(theme.Directory.Render(item.Icon()+" "+item.DisplayName())))
+		for _, line := range m.previewLines(item, width, rows) {
+			lines = append(lines, clip.Render(line))
+		}
+	}
+	for len(lines) < rows+1 {
+		lines = append(lines, "")
+	}
+	preview := theme.Pane.Width(width).Render(strings.Join(lines[:rows+1], "\n"))
+	return lipgloss.JoinHorizontal(lipgloss.Top, m.renderPane(width, maxVisible, true), preview)
+}
+
+// previewLines returns up to rows lines previewing item in width columns,
+// from the cache when the entry, the size and the colors are unchanged
+func (m Model) previewLines(item types.FileItem, width, rows int) []string {
+	key := fmt.Sprintf("%s|%d|%d|%d|%d|%s|%s", item.Path, item.Size, item.ModTime.UnixNano(), width, rows, theme.Name, colorscheme)
+	if m.preview != nil && m.preview.key == key {
+		return m.preview.lines
+	}
+	var lines []string
+	if item.IsDir {
+		lines = previewDir(item.Path, rows)
+	} else {
+		lines = m.previewFile(item, width, rows)
+	}
+	if len(lines) > rows {
+		lines = lines[:rows]
+	}
+	if m.preview != nil {
+		m.preview.key, m.preview.lines = key, lines
+	}
+	return lines
+}
+
+// previewFile returns the lines previewing a file, routed by previewKind
+func (m Model) previewFile(item types.FileItem, width, rows int) []string {
+	kind := m.previewKind(item.Name)
+	switch kind {
+	case previewNone:
+		return []string{theme.Dim.Render(FormatSize(item.Size))}
+	case previewImage:
+		return previewPicture(item, width, rows)
+	case previewArchive:
+		return previewArchiveEntries(item.Path, item.Name, rows)
+	}
+
+	head, err := readHead(item.Path, previewBytes)
+	if err != nil {
+		return []string{fmt.Sprintf("Error: %v", err)}
+	}
+	if kind == previewSummary || isBinary(string(head)) {
+		return previewFileSummary(item, head)
+	}
+	return m.previewContent(item, string(head), kind == previewText, width, rows)
+}
+
+// readHead reads up to n bytes from the start of a file
+func readHead(path string, n int64) ([]byte, error) {
+	f, err := vfs.Default.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, n))
+}
+
+// previewContent renders the start of a text file as the viewer does, with
+// Markdown rendered and CSV shown as a table unless raw is set. The lines
+// shown are highlighted at once rather than in the background.
+func (m Model) previewContent(item types.FileItem, content string, raw bool, width, rows int) []string {
+	if int64(len(content)) < item.Size {
+		// Leave out the line cut off by the end of what was read
+		if i := strings.LastIndexByte(content, '\n'); i >= 0 {
+			content = content[:i]
+		}
+	}
+	fv := FileViewer{
+		FileName:           item.Name,
+		UseSyntaxHighlight: true,
+		RawMarkdown:        raw,
+		RawTable:           raw,
+		SearchMatches:      []int{},
+		CurrentMatchIndex:  -1,
+		Width:              width,
+		Height:             rows + 6,
+	}
+	if lang := m.filetypeFor(item.Name); lang != "" && lexers.Get(lang) != nil {
+		fv.Language = lang
+	}
+	fv.setContent(content)
+	if fv.highlighter != nil {
+		start, spans, _ := fv.highlighter.nextChunk(rows)
+		fv.mergeHighlighted(start, spans)
+		fv.highlighter = nil
+	}
+
+	var lines []string
+	if fv.table != nil {
+		lines = append(lines, fv.tableHeader)
+	}
+	for i := 0; i < len(fv.Content) && len(lines) < rows; i++ {
+		lines = append(lines, fv.renderLine(i))
+	}
+	return lines
+}
+
+// previewPicture draws an image as large as fits in the pane, under a line
+// with its size
+func previewPicture(item types.FileItem, width, rows int) []string {
+	if item.Size > maxPreviewImage {
+		return []string{theme.Dim.Render(fmt.Sprintf("%s, too large to preview", FormatSize(item.Size)))}
+	}
+	data, err := vfs.ReadFile(vfs.Default, item.Path)
+	if err != nil {
+		return []string{fmt.Sprintf("Error: %v", err)}
+	}
+	img, err := picture.Decode(data)
+	if err != nil {
+		return []string{fmt.Sprintf("Error: %v", err)}
+	}
+	bounds := img.Bounds()
+	lines := []string{theme.Dim.Render(fmt.Sprintf("%d×%d, %s", bounds.Dx(), bounds.Dy(), FormatSize(item.Size)))}
+	cols, height := picture.Fit(img, width, rows-1)
+	if cols == 0 {
+		return lines
+	}
+	return append(lines, picture.Draw(img, cols, height)...)
+}
+
+// previewFileSummary describes a file that is not shown as text: its size,
+// its type sniffed from the first bytes, the details of audio and video,
+// and the first bytes in hex
+func previewFileSummary(item types.FileItem, head []byte) []string {
+	lines := []string{
+		fmt.Sprintf("%-10s %s", "Size", FormatSize(item.Size)),
+		fmt.Sprintf("%-10s %s", "Modified", item.ModTime.Format("2006-01-02 15:04")),
+		fmt.Sprintf("%-10s %s", "Type", http.DetectContentType(head)),
+	}
+	if media.IsMedia(item.Name) {
+		for _, row := range mediaRows(item.Path) {
+			lines = append(lines, row.Label)
+		}
+	}
+	if len(head) > 0 {
+		lines = append(lines, "")
+		for _, line := range hexDump(head[:min(len(head), summaryBytes)], 0) {
+			lines = append(lines, theme.Dim.Render(line))
+		}
+	}
+	return lines
+}
+
+// previewDir lists the first entries of a directory, folders marked with a
+// trailing separator
+func previewDir(path string, rows int) []string {
+	entries, err := vfs.Default.ReadDir(path)
+	if err != nil {
+		return []string{fmt.Sprintf("Error: %v", err)}
+	}
+	if len(entries) == 0 {
+		return []string{theme.Dim.Render("Empty folder")}
+	}
+	count := fmt.Sprintf("%d items", len(entries))
+	if len(entries) == 1 {
+		count = "1 item"
+	}
+	lines := []string{theme.Dim.Render(count)}
+	for _, entry := range entries {
+		if len(lines) == rows {
+			break
+		}
+		if entry.IsDir() {
+			lines = append(lines, theme.Directory.Render(entry.Name()+string(filepath.Separator)))
+		} else {
+			lines = append(lines, theme.File.Render(entry.Name()))
+		}
+	}
+	return lines
+}
+
+// previewArchiveEntries lists the first entries of a zip or tar archive with
+// their sizes
+func previewArchiveEntries(path, name string, rows int) []string {
+	f, err := vfs.Default.Open(path)
+	if err != nil {
+		return []string{fmt.Sprintf("Error: %v", err)}
+	}
+	defer f.Close()
+
+	var lines []string
+	entry := func(name string, size int64, dir bool) {
+		if dir {
+			lines = append(lines, theme.Directory.Render(name))
+		} else {
+			lines = append(lines, theme.File.Render(name)+theme.Dim.Render(" "+FormatSize(size)))
+		}
+	}
+
+	lower := strings.ToLower(name)
+	if !strings.HasSuffix(lower, ".tar") && !strings.HasSuffix(lower, ".tgz") && !strings.HasSuffix(lower, ".tar.gz") {
+		info, err := f.Stat()
+		if err != nil {
+			return []string{fmt.Sprintf("Error: %v", err)}
+		}
+		archive, err := zip.NewReader(f, info.Size())
+		if err != nil {
+			return []string{fmt.Sprintf("Error: %v", err)}
+		}
+		lines = append(lines, theme.Dim.Render(fmt.Sprintf("%d entries", len(archive.File))))
+		for _, file := range archive.File {
+			if len(lines) == rows {
+				break
+			}
+			entry(file.Name, int64(file.UncompressedSize64), file.FileInfo().IsDir())
+		}
+		return lines
+	}
+
+	var r io.Reader = f
+	if !strings.HasSuffix(lower, ".tar") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return []string{fmt.Sprintf("Error: %v", err)}
+		}
+		defer gz.Close()
+		r = gz
+	}
+	archive := tar.NewReader(r)
+	for len(lines) < rows {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("Error: %v", err))
+			break
+		}
+		entry(header.Name, header.Size, header.Typeflag == tar.TypeDir)
+	}
+	if len(lines) == 0 {
+		return []string{theme.Dim.Render("Empty archive")}
+	}
+	return lines
+}
@@ -0,0 +1,164 @@
+package ui
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/project"
+	"github.com/HolyStarGazer/windows-tui-go/search"
+	"github.com/HolyStarGazer/windows-tui-go/types"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxFindResults caps the number of results returned by a filename search
+const maxFindResults = 1000
+
+// skippedDirs are directory names never descended into by recursive searches
+var skippedDirs = search.DefaultSkipDirs
+
+// openPathMsg requests that a path be opened: directories are browsed, files are viewed
+type openPathMsg struct {
+	Path string
+}
+
+// revealPathMsg requests that the directory containing a path be browsed with
+// the cursor on it
+type revealPathMsg struct {
+	Path string
+}
+
+// touchProjectCmd records a project as recently used in the background
+func touchProjectCmd(p project.Project) tea.Cmd {
+	return func() tea.Msg {
+		if err := project.Touch(p); err != nil {
+			return errorMsg{fmt.Errorf("saving recent projects: %w", err)}
+		}
+		return nil
+	}
+}
+
+// projectsCmd loads the recently used projects into a switcher panel
+func projectsCmd() tea.Cmd {
+	return func() tea.Msg {
+		projects, err := project.LoadRecent()
+		if err != nil {
+			return errorMsg{err}
+		}
+
+		var items []ListEntry
+		for _, p := range projects {
+			label := fmt.Sprintf("%s %-14s %s  %s",
+				theme.Directory.Render(fmt.Sprintf("%-24s", p.Name)),
+				strings.Join(p.Kinds, ","),
+				theme.Dim.Render(p.LastUsed.Format(time.DateOnly)),
+				p.Root)
+			items = append(items, ListEntry{
+				Label: label,
+				Data:  p,
+				Msg:   openPathMsg{Path: p.Root},
+			})
+		}
+
+		panel := NewListPanel("🗂 Recent Projects", items)
+		if len(items) == 0 {
+			panel.StatusMessage = "No recent projects yet"
+		}
+		return openListMsg{panel}
+	}
+}
+
+// findFilesCmd searches root recursively for names containing pattern
+func findFilesCmd(root, pattern string) tea.Cmd {
+	return func() tea.Msg {
+		filter := types.NameContains(pattern)
+		var items []ListEntry
+		truncated := false
+
+		err := vfs.WalkDir(vfs.Default, root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// Skip unreadable entries instead of aborting the walk
+				if d != nil && d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if path == root {
+				return nil
+			}
+			if d.IsDir() && skippedDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			item := types.NewFileItem(path, info)
+			if !filter.Keep(item) {
+				return nil
+			}
+
+			rel, _ := filepath.Rel(root, path)
+			label := theme.File.Render(item.Icon() + " " + rel)
+			if item.IsDir {
+				label = theme.Directory.Render(item.Icon() + " " + rel + string(filepath.Separator))
+			}
+			items = append(items, ListEntry{Label: label, Msg: openPathMsg{Path: path}})
+
+			if len(items) >= maxFindResults {
+				truncated = true
+				return fs.SkipAll
+			}
+			return nil
+		})
+		if err != nil {
+			return errorMsg{err}
+		}
+
+		panel := NewListPanel(fmt.Sprintf("🔍 Find: %s", pattern), items)
+		panel.Subtitle = fmt.Sprintf("Scope: %s", root)
+		switch {
+		case len(items) == 0:
+			panel.StatusMessage = fmt.Sprintf("No files matching '%s'", pattern)
+		case truncated:
+			panel.StatusMessage = fmt.Sprintf("Showing first %d matches", maxFindResults)
+		}
+		return openListMsg{panel}
+	}
+}
+
+// openPath browses a directory or views a file
+func (m *Model) openPath(path string) {
+	info, err := vfs.Default.Stat(path)
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	if info.IsDir() {
+		m.List = nil
+		m.Mode = BrowseMode
+		m.CurrentPath = path
+		m.loadDirectory()
+		return
+	}
+
+	viewer := NewFileViewer(path, filepath.Base(path))
+	m.openViewer(&viewer)
+}
+
+// revealPath browses the directory containing path, selecting it
+func (m *Model) revealPath(path string) {
+	if _, err := vfs.Default.Stat(path); err != nil {
+		m.setStatus(fmt.Sprintf("Error: %v", err))
+		return
+	}
+	m.List = nil
+	m.Mode = BrowseMode
+	m.CurrentPath = filepath.Dir(path)
+	m.loadDirectory()
+	m.selectPath(path)
+}
@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Prompt is a single-line text input shown at the bottom of the screen
+type Prompt struct {
+	Label  string                     // Text shown before the input
+	Buffer string                     // Text typed so far
+	Submit func(value string) tea.Msg // Builds the message sent on Enter
+
+	// Other keys that finish the prompt, such as ctrl+l, with the message each sends
+	Keys map[string]func(value string) tea.Msg
+
+	// Complete lists the values Tab may complete the input to, if set
+	Complete   func(value string) []string
+	completion completion
+}
+
+// completion is the state of Tab completion in a text input. The first Tab
+// extends the input as far as all candidates agree, and later ones cycle
+// through them.
+type completion struct {
+	candidates []string
+	index      int
+}
+
+// next returns the completed input, cycling backward if delta is negative
+func (c *completion) next(value string, delta int, complete func(string) []string) string {
+	if len(c.candidates) > 0 {
+		c.index = (c.index + delta + len(c.candidates)) % len(c.candidates)
+		return c.candidates[c.index]
+	}
+	candidates := complete(value)
+	switch len(candidates) {
+	case 0:
+		return value
+	case 1:
+		return candidates[0]
+	}
+	if prefix := commonPrefix(candidates); len(prefix) > len(value) {
+		return prefix
+	}
+	c.candidates, c.index = candidates, 0
+	if delta < 0 {
+		c.index = len(candidates) - 1
+	}
+	return candidates[c.index]
+}
+
+// commonPrefix returns the longest prefix shared by all the values
+func commonPrefix(values []string) string {
+	prefix := values[0]
+	for _, value := range values[1:] {
+		n := 0
+		for n < len(prefix) && n < len(value) && prefix[n] == value[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return strings.ToValidUTF8(prefix, "")
+}
+
+// confirmPrompt asks a yes/no question, sending msg only if it is answered with
+// "y" or "yes"
+func confirmPrompt(label string, msg tea.Msg) tea.Msg {
+	return openPromptMsg{Prompt{
+		Label: label + " (y/N): ",
+		Submit: func(value string) tea.Msg {
+			if answer := strings.ToLower(strings.TrimSpace(value)); answer != "y" && answer != "yes" {
+				return nil
+			}
+			return msg
+		},
+	}}
+}
+
+// Update handles keyboard input for the prompt and reports whether it is finished
+func (p *Prompt) Update(msg tea.KeyMsg) (bool, tea.Cmd) {
+	key := msg.String()
+	if p.Complete != nil && (key == "tab" || key == "shift+tab") {
+		delta := 1
+		if key == "shift+tab" {
+			delta = -1
+		}
+		p.Buffer = p.completion.next(p.Buffer, delta, p.Complete)
+		return false, nil
+	}
+	p.completion = completion{}
+
+	switch key {
+	case "enter":
+		value := p.Buffer
+		submit := p.Submit
+		return true, func() tea.Msg { return submit(value) }
+
+	case "esc", "ctrl+c":
+		// Cancel prompt
+		return true, nil
+
+	case "backspace":
+		// Delete last character
+		p.Buffer = trimLastRune(p.Buffer)
+
+	default:
+		if submit, ok := p.Keys[key]; ok {
+			value := p.Buffer
+			return true, func() tea.Msg { return submit(value) }
+		}
+		// Add typed or pasted text (only printable characters)
+		p.Buffer += inputText(msg)
+	}
+
+	return false, nil
+}
+
+// View renders the prompt line
+func (p Prompt) View() string {
+	return p.Label + p.Buffer + "█"
+}
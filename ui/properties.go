@@ -0,0 +1,301 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/disk"
+	"github.com/HolyStarGazer/windows-tui-go/media"
+	"github.com/HolyStarGazer/windows-tui-go/ops"
+	"github.com/HolyStarGazer/windows-tui-go/types"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// propertiesTitle is the title of the :info panel, used to refresh it in place
+const propertiesTitle = "ℹ Properties"
+
+// attributeNames names the Windows attributes in the order they are listed
+var attributeNames = []struct {
+	Bit  uint32
+	Name string
+}{
+	{types.AttrReadOnly, "Read-only"},
+	{types.AttrHidden, "Hidden"},
+	{types.AttrSystem, "System"},
+	{types.AttrArchive, "Archive"},
+	{types.AttrCompressed, "Compressed"},
+	{types.AttrSparse, "Sparse"},
+}
+
+// compressMsg turns NTFS compression of paths on or off, with the trees
+// below directories
+type compressMsg struct {
+	Paths []string
+	On    bool
+}
+
+// compressDoneMsg reports a compression job: the files changed, those that
+// could not be, and what they took on disk before and after
+type compressDoneMsg struct {
+	On      bool
+	Done    int
+	Skipped int
+	Before  int64
+	After   int64
+	Err     error // First failure, or the error that stopped the job
+}
+
+// storageFlags describes how a file is stored, e.g. "compressed", or "" if
+// it is stored as is
+func storageFlags(attributes uint32) string {
+	var flags []string
+	if attributes&types.AttrCompressed != 0 {
+		flags = append(flags, "compressed")
+	}
+	if attributes&types.AttrSparse != 0 {
+		flags = append(flags, "sparse")
+	}
+	return strings.Join(flags, ", ")
+}
+
+// attributesLabel lists the set attributes, e.g. "Hidden, Compressed"
+func attributesLabel(attributes uint32) string {
+	var names []string
+	for _, a := range attributeNames {
+		if attributes&a.Bit != 0 {
+			names = append(names, a.Name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+// showProperties opens the properties of the item under the cursor (:info)
+func (m *Model) showProperties() {
+	if m.Cursor >= len(m.Items) || m.Items[m.Cursor].Name == ".." {
+		m.StatusMessage = "No item selected"
+		return
+	}
+	if m.collapsedGroupStatus() {
+		return
+	}
+	panel, err := propertiesPanel(m.Items[m.Cursor].Path)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	m.openList(panel)
+}
+
+// propertiesPanel lists what is known about a file or directory, with the
+// format, streams and tags of audio and video files; c turns its compression
+// on or off, and p plays a media file
+func propertiesPanel(path string) (ListPanel, error) {
+	info, err := vfs.Default.Lstat(path)
+	if err != nil {
+		return ListPanel{}, err
+	}
+	item := types.NewFileItem(path, info)
+	row := propertyRow
+
+	kind := "File"
+	switch {
+	case item.IsLink() && item.IsDir:
+		kind = "Link to a directory"
+	case item.IsLink():
+		kind = "Link to a file"
+	case item.IsDir:
+		kind = "Directory"
+	}
+	entries := []ListEntry{
+		row("Name", filepath.Base(item.Path)),
+		row("Path", filepath.Dir(path)),
+		row("Type", kind),
+	}
+	if item.IsLink() {
+		entries = append(entries, row("Target", item.LinkTarget))
+	}
+	if !item.IsDir {
+		entries = append(entries, row("Size", fmt.Sprintf("%s (%d bytes)", FormatSize(item.Size), item.Size)))
+		onDisk := theme.Dim.Render("unknown")
+		if size, err := disk.SizeOnDisk(path); err == nil {
+			onDisk = fmt.Sprintf("%s (%d bytes)", FormatSize(size), size)
+			if flags := storageFlags(item.Attributes); flags != "" {
+				onDisk += ", " + flags
+			}
+		}
+		entries = append(entries, row("On disk", onDisk))
+		if item.Links == 0 {
+			item.Links, _ = disk.LinkCount(path)
+		}
+		if item.Links > 1 {
+			entries = append(entries, row("Links", fmt.Sprintf("%d names (:links lists them)", item.Links)))
+		}
+	}
+	entries = append(entries,
+		row("Modified", item.ModTime.Format("2006-01-02 15:04:05")),
+	)
+	if created, ok := disk.CreationTime(info); ok {
+		entries = append(entries, row("Created", created.Format("2006-01-02 15:04:05")))
+	}
+	entries = append(entries,
+		row("Mode", item.Mode.String()),
+		row("Attributes", attributesLabel(item.Attributes)),
+	)
+	if item.MIMEType != "" {
+		entries = append(entries, row("MIME type", item.MIMEType))
+	}
+	isMedia := !item.IsDir && media.IsMedia(item.Name)
+	if isMedia {
+		entries = append(entries, mediaRows(path)...)
+	}
+
+	panel := NewListPanel(propertiesTitle, entries)
+	panel.Subtitle = path
+	on := item.Attributes&types.AttrCompressed == 0
+	desc := "compress"
+	if !on {
+		desc = "uncompress"
+	}
+	panel.Actions = []ListAction{{
+		Key:  "c",
+		Desc: desc,
+		Msg: func(ListEntry) tea.Msg {
+			return compressMsg{Paths: []string{path}, On: on}
+		},
+	}}
+	if isMedia {
+		panel.Actions = append(panel.Actions, ListAction{
+			Key:  "p",
+			Desc: "play",
+			Msg:  func(ListEntry) tea.Msg { return playMsg{Path: path} },
+		})
+	}
+	return panel, nil
+}
+
+// propertyRow is a line of the properties panel: a name and its value
+func propertyRow(name, value string) ListEntry {
+	return ListEntry{Label: fmt.Sprintf("%-10s %s", name, value), Separator: true}
+}
+
+// refreshPropertiesPanel reads the shown item's properties again, if the
+// panel is open
+func (m *Model) refreshPropertiesPanel(status string) {
+	if m.Mode != ListMode || m.List == nil || m.List.Title != propertiesTitle {
+		return
+	}
+	panel, err := propertiesPanel(m.List.Subtitle)
+	if err != nil {
+		m.List.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	panel.Width, panel.Height = m.List.Width, m.List.Height
+	panel.SetCursor(m.List.Cursor)
+	panel.StatusMessage = status
+	m.List = &panel
+}
+
+// compressKey compresses or uncompresses the marked items, or the one under
+// the cursor (:compress / :uncompress)
+func (m *Model) compressKey(on bool) tea.Cmd {
+	paths := m.operandPaths()
+	if len(paths) == 0 {
+		m.StatusMessage = "Nothing to compress"
+		return nil
+	}
+	return m.compressCmd(compressMsg{Paths: paths, On: on})
+}
+
+// compressCmd changes the compression of the paths in the background. Files
+// that are in use or protected are skipped rather than stopping the job.
+func (m *Model) compressCmd(msg compressMsg) tea.Cmd {
+	verb := "Uncompressing"
+	if msg.On {
+		verb = "Compressing"
+	}
+	cmd := m.startJob(fmt.Sprintf("%s %s", verb, describePaths(msg.Paths)), func(progress *ops.Progress) tea.Msg {
+		done := compressDoneMsg{On: msg.On}
+		ops.Work(func() {
+			progress.SetTotal(pathsSize(msg.Paths))
+			for _, root := range msg.Paths {
+				err := vfs.WalkDir(vfs.Default, root, func(path string, d fs.DirEntry, err error) error {
+					if err := progress.Check(); err != nil {
+						return err
+					}
+					if err != nil {
+						done.skip(err)
+						return nil
+					}
+					// Directories are changed too, so new files in them follow
+					if !d.IsDir() && !d.Type().IsRegular() {
+						return nil
+					}
+					before, _ := disk.SizeOnDisk(path)
+					if err := disk.SetCompression(path, msg.On); err != nil {
+						if errors.Is(err, disk.ErrNoCompression) {
+							return err
+						}
+						done.skip(err)
+						return nil
+					}
+					if d.IsDir() {
+						return nil
+					}
+					after, _ := disk.SizeOnDisk(path)
+					done.Done++
+					done.Before += before
+					done.After += after
+					if info, err := d.Info(); err == nil {
+						progress.AddBytes(info.Size())
+					}
+					progress.AddItem()
+					return nil
+				})
+				if err != nil {
+					done.Err = err
+					return
+				}
+			}
+		})
+		if progress.Canceled() {
+			return jobCanceledMsg{}
+		}
+		return done
+	})
+	m.refreshJobsPanel()
+	return cmd
+}
+
+// skip counts a file whose compression could not be changed, keeping the
+// first error to report
+func (msg *compressDoneMsg) skip(err error) {
+	msg.Skipped++
+	if msg.Err == nil {
+		msg.Err = err
+	}
+}
+
+// finishCompress reports a compression job and shows the new sizes
+func (m *Model) finishCompress(msg compressDoneMsg) {
+	m.reloadDirectory()
+	verb := "Uncompressed"
+	if msg.On {
+		verb = "Compressed"
+	}
+	status := fmt.Sprintf("%s %d files: %s on disk, was %s", verb, msg.Done, FormatSize(msg.After), FormatSize(msg.Before))
+	if msg.Skipped > 0 {
+		status += fmt.Sprintf(" (%d in use or protected were skipped: %v)", msg.Skipped, msg.Err)
+	}
+	if msg.Done == 0 && msg.Err != nil {
+		status = fmt.Sprintf("Error: %v", msg.Err)
+	}
+	m.setStatus(status)
+	m.refreshPropertiesPanel(status)
+}
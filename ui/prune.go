@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pruneMsg requests that the listed empty directories be deleted
+type pruneMsg struct {
+	Dirs []string
+}
+
+// prunedMsg reports the result of deleting empty directories
+type prunedMsg struct {
+	Removed int
+	Err     error // First deletion error, if any
+}
+
+// collectEmptyDirs appends the directories below dir that contain no files,
+// counting directories that only hold empty directories as empty too, and
+// reports whether dir itself is empty. Unreadable and skipped directories
+// count as not empty so they are never deleted.
+func collectEmptyDirs(dir string, empty *[]string) bool {
+	entries, err := vfs.Default.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	result := true
+	for _, entry := range entries {
+		if !entry.IsDir() || skippedDirs[entry.Name()] {
+			result = false
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if collectEmptyDirs(path, empty) {
+			*empty = append(*empty, path)
+		} else {
+			result = false
+		}
+	}
+	return result
+}
+
+// findEmptyDirsCmd lists the empty directories below root in a panel where
+// D deletes them all after confirmation
+func findEmptyDirsCmd(root string) tea.Cmd {
+	return func() tea.Msg {
+		var dirs []string
+		collectEmptyDirs(root, &dirs)
+		sort.Strings(dirs)
+
+		items := make([]ListEntry, 0, len(dirs))
+		for _, dir := range dirs {
+			rel, _ := filepath.Rel(root, dir)
+			items = append(items, ListEntry{
+				Label: theme.Directory.Render("📁 " + rel + string(filepath.Separator)),
+				Msg:   openPathMsg{Path: dir},
+			})
+		}
+
+		panel := NewListPanel(fmt.Sprintf("🧹 Empty directories: %d", len(dirs)), items)
+		panel.Subtitle = fmt.Sprintf("Scope: %s", root)
+		if len(dirs) == 0 {
+			panel.StatusMessage = "No empty directories"
+			return openListMsg{panel}
+		}
+		panel.Actions = []ListAction{{
+			Key:  "D",
+			Desc: "delete all",
+			Msg: func(ListEntry) tea.Msg {
+				return confirmPrompt(fmt.Sprintf("Delete %d empty directories?", len(dirs)), pruneMsg{Dirs: dirs})
+			},
+		}}
+		return openListMsg{panel}
+	}
+}
+
+// pruneDirsCmd deletes directories, children before their parents. os.Remove
+// refuses directories that are no longer empty.
+func pruneDirsCmd(dirs []string) tea.Cmd {
+	return func() tea.Msg {
+		var result prunedMsg
+		for i := len(dirs) - 1; i >= 0; i-- {
+			if err := vfs.Default.Remove(dirs[i]); err != nil {
+				if result.Err == nil {
+					result.Err = err
+				}
+				continue
+			}
+			result.Removed++
+		}
+		return result
+	}
+}
+
+// updatePrune handles the deletion of empty directories
+func (m Model) updatePrune(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case pruneMsg:
+		m.setStatus(fmt.Sprintf("Deleting %d empty directories...", len(msg.Dirs)))
+		return m, pruneDirsCmd(msg.Dirs)
+
+	case prunedMsg:
+		if m.Mode == ListMode {
+			m.closeList()
+		}
+		m.reloadDirectory()
+		status := fmt.Sprintf("Removed %d empty directories", msg.Removed)
+		if msg.Err != nil {
+			status += fmt.Sprintf(" (Error: %v)", msg.Err)
+		}
+		m.setStatus(status)
+	}
+	return m, nil
+}
@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// quickFilterMsg sets the quick filter of the browser, as picked with fe
+type quickFilterMsg struct {
+	Quick string
+}
+
+// quickFilter returns the filter a BrowseOptions.Quick value stands for
+func quickFilter(quick string) types.Filter {
+	switch quick {
+	case "":
+		return nil
+	case "dirs":
+		return types.DirsOnly
+	case "files":
+		return types.FilesOnly
+	}
+	return types.Extensions(strings.Fields(quick)...)
+}
+
+// quickFilterLabel describes a quick filter, e.g. "only .go .md files"
+func quickFilterLabel(quick string) string {
+	switch quick {
+	case "dirs":
+		return "only directories"
+	case "files":
+		return "only files"
+	}
+	return fmt.Sprintf("only %s files", quick)
+}
+
+// quickFilterBadge shows the active quick filter in the header, or nothing
+func (o BrowseOptions) quickFilterBadge() string {
+	if o.Quick == "" {
+		return ""
+	}
+	return theme.Banner.Render(fmt.Sprintf(" %s (Esc shows all) ", quickFilterLabel(o.Quick)))
+}
+
+// parseExtensions turns typed extensions such as "go, .MD *.txt" into the
+// quick filter value ".go .md .txt"
+func parseExtensions(value string) string {
+	fields := strings.FieldsFunc(value, func(r rune) bool { return r == ' ' || r == ',' || r == ';' })
+	exts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		ext := strings.TrimLeft(strings.ToLower(field), "*.")
+		if ext != "" {
+			exts = append(exts, "."+ext)
+		}
+	}
+	return strings.Join(exts, " ")
+}
+
+// quickFilterKey applies the quick filter picked by the key after f: fd lists
+// only directories, ff only files and fe asks for extensions. Picking the
+// active filter again turns it off.
+func (m *Model) quickFilterKey(key string) tea.Cmd {
+	switch key {
+	case "d":
+		m.toggleQuickFilter("dirs")
+	case "f":
+		m.toggleQuickFilter("files")
+	case "e":
+		ext := ""
+		if m.Cursor < len(m.Items) && !m.Items[m.Cursor].IsDir {
+			ext = strings.TrimPrefix(m.Items[m.Cursor].Ext(), ".")
+		}
+		return func() tea.Msg {
+			return openPromptMsg{Prompt{
+				Label:  "Show only files with the extensions: ",
+				Buffer: ext,
+				Submit: func(value string) tea.Msg {
+					quick := parseExtensions(value)
+					if quick == "" {
+						return nil
+					}
+					return quickFilterMsg{Quick: quick}
+				},
+			}}
+		}
+	case "esc":
+		m.StatusMessage = ""
+	default:
+		m.StatusMessage = fmt.Sprintf("No quick filter f%s (fd directories, ff files, fe extensions)", key)
+	}
+	return nil
+}
+
+// toggleQuickFilter turns a quick filter on, or off if it is already on
+func (m *Model) toggleQuickFilter(quick string) {
+	if m.Options.Quick == quick {
+		quick = ""
+	}
+	m.setQuickFilter(quick)
+}
+
+// setQuickFilter lists only the items the quick filter keeps, keeping the
+// cursor on the same item where it is still listed
+func (m *Model) setQuickFilter(quick string) {
+	m.Options.Quick = quick
+	m.reloadDirectory()
+	if quick == "" {
+		m.StatusMessage = "Showing all items"
+		return
+	}
+	m.StatusMessage = fmt.Sprintf("Showing %s", quickFilterLabel(quick))
+}
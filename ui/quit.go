@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// doubleQuitWindow is how soon the second q must follow the first when the
+// quit setting is "double"
+const doubleQuitWindow = time.Second
+
+// quitMsg quits once confirmed
+type quitMsg struct{}
+
+// quitKey handles q in the browser according to the quit setting
+func (m *Model) quitKey() tea.Cmd {
+	switch m.Settings.Quit {
+	case "command":
+		m.StatusMessage = "Type :q to quit"
+		return nil
+	case "double":
+		if time.Since(m.quitPressed) > doubleQuitWindow {
+			m.quitPressed = time.Now()
+			m.StatusMessage = "Press q again to quit"
+			return nil
+		}
+		m.quitPressed = time.Time{}
+	}
+	return m.quit()
+}
+
+// quit exits, first asking when a task is running or items are marked and
+// confirm_quit is set
+func (m *Model) quit() tea.Cmd {
+	var reasons []string
+	if m.Settings.ConfirmQuit {
+		if m.TaskRun != nil {
+			reasons = append(reasons, "a task is running")
+		}
+		if n := len(m.Marked); n == 1 {
+			reasons = append(reasons, "1 item is marked")
+		} else if n > 1 {
+			reasons = append(reasons, fmt.Sprintf("%d items are marked", n))
+		}
+	}
+	if len(reasons) == 0 {
+		return tea.Quit
+	}
+	label := fmt.Sprintf("Quit while %s?", strings.Join(reasons, " and "))
+	return func() tea.Msg { return confirmPrompt(label, quitMsg{}) }
+}
@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/clipboard"
+)
+
+// lineRange is an inclusive range of content lines, counted from 0
+type lineRange struct {
+	Start, End int
+}
+
+// parseAddress reads one line address from the start of s: a line number, .
+// for the line at the top of the screen or $ for the last line, followed by
+// any number of +N/-N offsets. An address of only offsets counts from the
+// current line. ok reports whether s started with an address.
+func (fv *FileViewer) parseAddress(s string) (line int, rest string, ok bool) {
+	line = fv.lineAt(fv.ScrollPos)
+	switch {
+	case s == "":
+		return 0, s, false
+	case s[0] == '.':
+		s, ok = s[1:], true
+	case s[0] == '$':
+		line, s, ok = len(fv.Content)-1, s[1:], true
+	case s[0] >= '0' && s[0] <= '9':
+		n := len(s) - len(strings.TrimLeft(s, "0123456789"))
+		line, _ = strconv.Atoi(s[:n])
+		// Line numbers of a windowed file count from the start of the file
+		line, s, ok = line-1-max(fv.firstLine(), 0), s[n:], true
+	}
+
+	for s != "" && (s[0] == '+' || s[0] == '-') {
+		sign := 1
+		if s[0] == '-' {
+			sign = -1
+		}
+		s = s[1:]
+		n := len(s) - len(strings.TrimLeft(s, "0123456789"))
+		offset := 1
+		if n > 0 {
+			offset, _ = strconv.Atoi(s[:n])
+		}
+		line, s, ok = line+sign*offset, s[n:], true
+	}
+	return line, s, ok
+}
+
+// parseRange splits a vim-style range off the front of a command, such as
+// "100,200", ".,+50", "$-10,$" or "%" for the whole file. ranged reports
+// whether the command had one; a single address is a range of one line.
+func (fv *FileViewer) parseRange(cmd string) (r lineRange, rest string, ranged bool, err error) {
+	if strings.HasPrefix(cmd, "%") {
+		return lineRange{0, len(fv.Content) - 1}, strings.TrimSpace(cmd[1:]), true, nil
+	}
+
+	start, rest, ok := fv.parseAddress(cmd)
+	if !ok {
+		return lineRange{}, cmd, false, nil
+	}
+	end := start
+	if after, found := strings.CutPrefix(strings.TrimSpace(rest), ","); found {
+		if end, rest, ok = fv.parseAddress(strings.TrimSpace(after)); !ok {
+			return lineRange{}, cmd, true, errors.New("missing end of range")
+		}
+	}
+
+	if start > end {
+		start, end = end, start
+	}
+	if start < 0 || end >= len(fv.Content) {
+		if first := fv.firstLine(); fv.window != nil && first >= 0 {
+			return lineRange{}, cmd, true, fmt.Errorf("invalid range: lines %d-%d are loaded", first+1, first+len(fv.Content))
+		}
+		return lineRange{}, cmd, true, fmt.Errorf("invalid range: the file has %d lines", len(fv.Content))
+	}
+	return lineRange{start, end}, strings.TrimSpace(rest), true, nil
+}
+
+// rangeLines returns the lines of a range that are shown, leaving out lines
+// hidden by the filter
+func (fv *FileViewer) rangeLines(r lineRange) []int {
+	var lines []int
+	for row := fv.rowOf(r.Start); row < fv.rowCount(); row++ {
+		i := fv.lineAt(row)
+		if i < r.Start || i > r.End {
+			break
+		}
+		lines = append(lines, i)
+	}
+	return lines
+}
+
+// rangeCommand runs a command given a line range. Without a command it goes
+// to the last line of the range, as in vim.
+func (fv *FileViewer) rangeCommand(r lineRange, cmd string) {
+	parts := strings.Fields(cmd)
+	if len(parts) == 0 {
+		fv.ScrollPos = fv.rowOf(r.End)
+		return
+	}
+
+	lines := fv.rangeLines(r)
+	if len(lines) == 0 {
+		fv.StatusMessage = "No lines shown in the range"
+		return
+	}
+	switch parts[0] {
+	case "y", "yank":
+		text := make([]string, len(lines))
+		for n, i := range lines {
+			text[n] = fv.Content[i]
+		}
+		if err := clipboard.SetText(strings.Join(text, "\n") + "\n"); err != nil {
+			fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+			return
+		}
+		fv.StatusMessage = fmt.Sprintf("Copied %d lines to the clipboard", len(lines))
+
+	case "w", "write":
+		if len(parts) < 2 {
+			fv.StatusMessage = "Usage: :<range> write <file>"
+			return
+		}
+		fv.writeLines(strings.Join(parts[1:], " "), lines)
+
+	case "export":
+		if len(parts) < 3 {
+			fv.StatusMessage = "Usage: :<range> export html|ansi <file>"
+			return
+		}
+		fv.exportView(parts[1], strings.Join(parts[2:], " "), lines)
+
+	default:
+		fv.StatusMessage = fmt.Sprintf("Command '%s' does not take a range (try yank, write or export)", parts[0])
+	}
+}
@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/scheduler"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// schedTasksTitle is the title of the scheduled tasks panel
+const schedTasksTitle = "⏰ Scheduled Tasks"
+
+// systemTaskFolder holds the tasks Windows itself installs, hidden unless
+// :schtasks all is used
+const systemTaskFolder = `\Microsoft\`
+
+// schedTasksMsg delivers the scheduled tasks
+type schedTasksMsg struct {
+	Tasks  []scheduler.Task
+	All    bool   // Whether Windows' own tasks are listed
+	Status string // Shown in the panel, e.g. the result of an action
+}
+
+// schedTaskActionMsg runs, enables or disables a task
+type schedTaskActionMsg struct {
+	Name   string
+	Action string // "run", "enable" or "disable"
+	All    bool
+}
+
+// loadSchedTasksCmd lists the scheduled tasks in the background
+func loadSchedTasksCmd(all bool, status string) tea.Cmd {
+	return func() tea.Msg {
+		tasks, err := scheduler.List()
+		if err != nil {
+			return errorMsg{err}
+		}
+		if !all {
+			var own []scheduler.Task
+			for _, task := range tasks {
+				if !strings.HasPrefix(task.Name, systemTaskFolder) {
+					own = append(own, task)
+				}
+			}
+			tasks = own
+		}
+		return schedTasksMsg{Tasks: tasks, All: all, Status: status}
+	}
+}
+
+// schedTaskActionCmd applies an action to a task, then lists the tasks again so
+// their status is current
+func schedTaskActionCmd(msg schedTaskActionMsg) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		var status string
+		switch msg.Action {
+		case "run":
+			err = scheduler.Run(msg.Name)
+			status = fmt.Sprintf("Started %s", msg.Name)
+		case "enable":
+			err = scheduler.SetEnabled(msg.Name, true)
+			status = fmt.Sprintf("Enabled %s", msg.Name)
+		case "disable":
+			err = scheduler.SetEnabled(msg.Name, false)
+			status = fmt.Sprintf("Disabled %s", msg.Name)
+		}
+		if err != nil {
+			status = fmt.Sprintf("Error: %v", err)
+		}
+		return loadSchedTasksCmd(msg.All, status)()
+	}
+}
+
+// schedTaskEntries converts tasks into list entries; failed last runs are shown
+// in red and disabled tasks dimmed
+func schedTaskEntries(tasks []scheduler.Task) []ListEntry {
+	entries := make([]ListEntry, 0, len(tasks))
+	for _, task := range tasks {
+		result := fmt.Sprintf("%-15s", scheduler.ResultText(task.LastResult))
+		if scheduler.Failed(task.LastResult) {
+			result = theme.Levels[levelError].Render(result)
+		}
+		label := fmt.Sprintf("%-48s %-10s %s last %-22s next %s",
+			truncateAtVisualWidth(task.Name, 48), task.Status, result, task.LastRun, task.NextRun)
+		if !task.Enabled {
+			label = theme.Dim.Render(fmt.Sprintf("%-48s %-10s", truncateAtVisualWidth(task.Name, 48), task.Status))
+		}
+		entries = append(entries, ListEntry{Label: label, Data: task})
+	}
+	return entries
+}
+
+// schedTasksPanel lists the tasks with actions to run, enable and disable them
+func schedTasksPanel(msg schedTasksMsg) ListPanel {
+	panel := NewListPanel(schedTasksTitle, schedTaskEntries(msg.Tasks))
+	panel.Subtitle = fmt.Sprintf("%d tasks", len(msg.Tasks))
+	if !msg.All {
+		panel.Subtitle += " (Windows tasks hidden, :schtasks all lists them)"
+	}
+
+	action := func(name string) func(ListEntry) tea.Msg {
+		return func(entry ListEntry) tea.Msg {
+			task, ok := entry.Data.(scheduler.Task)
+			if !ok {
+				return nil
+			}
+			return schedTaskActionMsg{Name: task.Name, Action: name, All: msg.All}
+		}
+	}
+	panel.Actions = []ListAction{
+		{Key: "r", Desc: "run now", Msg: action("run")},
+		{Key: "e", Desc: "enable", Msg: action("enable")},
+		{Key: "d", Desc: "disable", Msg: action("disable")},
+	}
+	return panel
+}
+
+// updateSchedTasks handles the scheduled tasks panel
+func (m Model) updateSchedTasks(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case schedTasksMsg:
+		m.setStatus("")
+		panel := schedTasksPanel(msg)
+		panel.StatusMessage = msg.Status
+		if m.Mode == ListMode && m.List != nil && m.List.Title == schedTasksTitle {
+			// Keep the cursor after an action
+			panel.Width, panel.Height = m.List.Width, m.List.Height
+			panel.SetCursor(m.List.Cursor)
+			m.List = &panel
+			return m, nil
+		}
+		m.openList(panel)
+
+	case schedTaskActionMsg:
+		m.setStatus(fmt.Sprintf("Updating %s...", msg.Name))
+		return m, schedTaskActionCmd(msg)
+	}
+	return m, nil
+}
@@ -0,0 +1,285 @@
+package ui
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// screenshotMsg requests that the current screen be saved to a file
+type screenshotMsg struct {
+	Path string
+}
+
+// screenshotPrompt asks where to save a screenshot
+func screenshotPrompt() tea.Msg {
+	return openPromptMsg{Prompt{
+		Label:  "Save screenshot to (.txt, .ansi or .html): ",
+		Submit: func(value string) tea.Msg { return screenshotMsg{Path: value} },
+	}}
+}
+
+// ansiColors are the 16 standard terminal colors (Windows Terminal "Campbell" scheme)
+var ansiColors = [16]string{
+	"#0C0C0C", "#C50F1F", "#13A10E", "#C19C00", "#0037DA", "#881798", "#3A96DD", "#CCCCCC",
+	"#767676", "#E74856", "#16C60C", "#F9F1A5", "#3B78FF", "#B4009E", "#61D6D6", "#F2F2F2",
+}
+
+// xtermColor converts a 256-color palette index to a hex color
+func xtermColor(n int) string {
+	switch {
+	case n < 16:
+		return ansiColors[n]
+	case n < 232:
+		n -= 16
+		level := func(v int) int {
+			if v == 0 {
+				return 0
+			}
+			return 55 + v*40
+		}
+		return fmt.Sprintf("#%02X%02X%02X", level(n/36), level(n/6%6), level(n%6))
+	default:
+		gray := 8 + (n-232)*10
+		return fmt.Sprintf("#%02X%02X%02X", gray, gray, gray)
+	}
+}
+
+// sgrState is the text style built up from SGR escape sequences
+type sgrState struct {
+	Fg, Bg                        string
+	Bold, Italic, Underline       bool
+	Faint, Reverse, Strikethrough bool
+}
+
+// apply updates the state from the parameters of an SGR sequence
+func (s *sgrState) apply(params string) {
+	if params == "" {
+		params = "0"
+	}
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		code, _ := strconv.Atoi(codes[i])
+		switch {
+		case code == 0:
+			*s = sgrState{}
+		case code == 1:
+			s.Bold = true
+		case code == 2:
+			s.Faint = true
+		case code == 3:
+			s.Italic = true
+		case code == 4:
+			s.Underline = true
+		case code == 7:
+			s.Reverse = true
+		case code == 9:
+			s.Strikethrough = true
+		case code == 22:
+			s.Bold, s.Faint = false, false
+		case code == 23:
+			s.Italic = false
+		case code == 24:
+			s.Underline = false
+		case code == 27:
+			s.Reverse = false
+		case code == 29:
+			s.Strikethrough = false
+		case code >= 30 && code <= 37:
+			s.Fg = ansiColors[code-30]
+		case code >= 90 && code <= 97:
+			s.Fg = ansiColors[code-90+8]
+		case code >= 40 && code <= 47:
+			s.Bg = ansiColors[code-40]
+		case code >= 100 && code <= 107:
+			s.Bg = ansiColors[code-100+8]
+		case code == 39:
+			s.Fg = ""
+		case code == 49:
+			s.Bg = ""
+		case code == 38 || code == 48:
+			// Extended colors: 5;n (256 colors) or 2;r;g;b (true color)
+			var color string
+			if i+2 < len(codes) && codes[i+1] == "5" {
+				n, _ := strconv.Atoi(codes[i+2])
+				color = xtermColor(n & 0xFF)
+				i += 2
+			} else if i+4 < len(codes) && codes[i+1] == "2" {
+				r, _ := strconv.Atoi(codes[i+2])
+				g, _ := strconv.Atoi(codes[i+3])
+				b, _ := strconv.Atoi(codes[i+4])
+				color = fmt.Sprintf("#%02X%02X%02X", r&0xFF, g&0xFF, b&0xFF)
+				i += 4
+			}
+			if code == 38 {
+				s.Fg = color
+			} else {
+				s.Bg = color
+			}
+		}
+	}
+}
+
+// css returns the inline style for the state
+func (s sgrState) css() string {
+	fg, bg := s.Fg, s.Bg
+	if s.Reverse {
+		fg, bg = bg, fg
+		if fg == "" {
+			fg = "#0C0C0C"
+		}
+		if bg == "" {
+			bg = "#CCCCCC"
+		}
+	}
+
+	var parts []string
+	if fg != "" {
+		parts = append(parts, "color:"+fg)
+	}
+	if bg != "" {
+		parts = append(parts, "background-color:"+bg)
+	}
+	if s.Bold {
+		parts = append(parts, "font-weight:bold")
+	}
+	if s.Faint {
+		parts = append(parts, "opacity:0.6")
+	}
+	if s.Italic {
+		parts = append(parts, "font-style:italic")
+	}
+	switch {
+	case s.Underline && s.Strikethrough:
+		parts = append(parts, "text-decoration:underline line-through")
+	case s.Underline:
+		parts = append(parts, "text-decoration:underline")
+	case s.Strikethrough:
+		parts = append(parts, "text-decoration:line-through")
+	}
+	return strings.Join(parts, ";")
+}
+
+// scanANSI walks s, calling text for runs of plain text and sgr for the
+// parameters of each SGR sequence; other escape sequences are dropped
+func scanANSI(s string, text func(string), sgr func(string)) {
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\x1b' || i+1 >= len(s) {
+			continue
+		}
+		if i > start {
+			text(s[start:i])
+		}
+
+		j := i + 2
+		switch s[i+1] {
+		case '[':
+			// CSI: parameters then a final byte in @..~
+			for j < len(s) && (s[j] < 0x40 || s[j] > 0x7E) {
+				j++
+			}
+			if j < len(s) && s[j] == 'm' {
+				sgr(s[i+2 : j])
+			}
+			j++
+		case ']':
+			// OSC (hyperlinks, titles): ends with BEL or ESC \
+			for j < len(s) && s[j] != '\a' && !(s[j] == '\x1b' && j+1 < len(s) && s[j+1] == '\\') {
+				j++
+			}
+			if j < len(s) && s[j] == '\x1b' {
+				j++
+			}
+			j++
+		}
+		if j > len(s) {
+			j = len(s)
+		}
+		start = j
+		i = j - 1
+	}
+	if start < len(s) {
+		text(s[start:])
+	}
+}
+
+// stripANSI removes escape sequences from s
+func stripANSI(s string) string {
+	var b strings.Builder
+	scanANSI(s, func(t string) { b.WriteString(t) }, func(string) {})
+	return b.String()
+}
+
+// ansiToHTML converts ANSI-colored text into a standalone HTML page
+func ansiToHTML(title, s string) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n</head>\n", html.EscapeString(title))
+	b.WriteString("<body style=\"background-color:#0C0C0C;color:#CCCCCC;\">\n")
+	b.WriteString("<pre style=\"font-family:'Cascadia Mono',Consolas,monospace;line-height:1.2;\">")
+	b.WriteString(ansiSpans(s))
+	b.WriteString("</pre>\n</body>\n</html>\n")
+	return b.String()
+}
+
+// ansiSpans converts ANSI-colored text into escaped HTML, with a styled span
+// for each run of text
+func ansiSpans(s string) string {
+	var b strings.Builder
+	var state sgrState
+	scanANSI(s,
+		func(t string) {
+			style := state.css()
+			if style != "" {
+				fmt.Fprintf(&b, "<span style=\"%s\">", style)
+			}
+			b.WriteString(html.EscapeString(t))
+			if style != "" {
+				b.WriteString("</span>")
+			}
+		},
+		func(params string) { state.apply(params) })
+	return b.String()
+}
+
+// saveScreenshot writes the rendered screen to path; the extension picks plain
+// text, raw ANSI (.ansi) or HTML (.html, .htm)
+func saveScreenshot(screen, path string) error {
+	var data string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		data = ansiToHTML(filepath.Base(path), screen)
+	case ".ansi":
+		data = screen + "\x1b[0m\n"
+	default:
+		lines := strings.Split(stripANSI(screen), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight(line, " ")
+		}
+		data = strings.Join(lines, "\n") + "\n"
+	}
+	return vfs.Default.WriteFile(path, []byte(data), 0o644)
+}
+
+// takeScreenshot saves the current screen, resolving relative paths against the
+// browsed directory
+func (m *Model) takeScreenshot(path string) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.CurrentPath, path)
+	}
+	if err := saveScreenshot(m.renderMode(), path); err != nil {
+		m.setStatus(fmt.Sprintf("Error: %v", err))
+		return
+	}
+	m.setStatus(fmt.Sprintf("Saved screenshot to %s", path))
+}
@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/HolyStarGazer/windows-tui-go/uitest"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+)
+
+// scriptDriver starts the explorer in dir of a tree served from memory, with
+// its settings in an empty folder
+func scriptDriver(t *testing.T, tree fstest.MapFS, dir string) (*uitest.Driver, string) {
+	t.Helper()
+	uitest.IsolateConfig(t)
+	root := uitest.Mount(t, tree)
+	return uitest.New(NewModelAt(filepath.Join(root, dir)), 100, 20), root
+}
+
+// TestCopyConflictScript copies a file onto one that exists, which must fail
+// and leave it alone, then copies another file next to it
+func TestCopyConflictScript(t *testing.T) {
+	d, root := scriptDriver(t, fstest.MapFS{
+		"src/a.txt":  {Data: []byte("new a\n")},
+		"src/b.txt":  {Data: []byte("new b\n")},
+		"dest/a.txt": {Data: []byte("old a\n")},
+	}, "src")
+	err := d.Run(`
+		keys j f5
+		expect Copy a.txt
+		type ../dest
+		keys enter
+		expect already exists
+		keys j f5
+		type ../dest
+		keys enter
+		reject Error
+		:cd ../dest
+		expect dest
+		expect a.txt
+		expect b.txt
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{"a.txt": "old a\n", "b.txt": "new b\n"} {
+		data, err := vfs.ReadFile(vfs.Default, filepath.Join(root, "dest", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("dest/%s holds %q, want %q", name, data, want)
+		}
+	}
+}
+
+// TestSearchJumpScript searches file contents and opens a match, which must
+// view the file at the matching line
+func TestSearchJumpScript(t *testing.T) {
+	lines := "first\n"
+	for range 30 {
+		lines += "filler\n"
+	}
+	d, _ := scriptDriver(t, fstest.MapFS{
+		"docs/notes.txt": {Data: []byte(lines + "the needle is here\nlast\n")},
+		"main.go":        {Data: []byte("package main\n")},
+	}, "")
+	err := d.Run(`
+		:grep needle
+		expect Grep: needle
+		expect notes.txt:32:
+		reject main.go
+		keys enter
+		expect Viewing: notes.txt
+		expect Match 1 of 1
+		expect the needle is here
+		reject first
+		keys q
+		expect Grep: needle
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
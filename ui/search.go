@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/HolyStarGazer/windows-tui-go/spell"
+)
+
+// searchOn and searchOff mark search matches: black on yellow
+const (
+	searchOn  = "\x1b[43m\x1b[30m"
+	searchOff = "\x1b[49m\x1b[39m"
+)
+
+// compileSearch returns the pattern of a search. Plain terms match anywhere,
+// ignoring case; regular expressions match as written, so (?i) ignores case.
+func compileSearch(term string, regex bool) (*regexp.Regexp, error) {
+	if !regex {
+		return regexp.MustCompile("(?i)" + regexp.QuoteMeta(term)), nil
+	}
+	return regexp.Compile(term)
+}
+
+// search finds the lines matching a term, or a regular expression if regex
+// is set, and jumps to the first
+func (fv *FileViewer) search(term string, regex bool) {
+	if term == "" {
+		fv.clearSearch()
+		fv.StatusMessage = "Search cleared"
+		return
+	}
+	re, err := compileSearch(term, regex)
+	if err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+
+	defer measure("search")()
+	fv.SearchTerm = term
+	fv.searchRe = re
+	fv.findMatches()
+
+	if len(fv.SearchMatches) > 0 {
+		fv.CurrentMatchIndex = 0
+		fv.ScrollPos = fv.rowOf(fv.SearchMatches[0])
+		fv.StatusMessage = fmt.Sprintf("Found %d match(es) - n: next, N: prev", len(fv.SearchMatches))
+	} else {
+		fv.StatusMessage = fmt.Sprintf("Pattern not found: %s", term)
+	}
+}
+
+// clearSearch drops the search and its matches
+func (fv *FileViewer) clearSearch() {
+	fv.SearchTerm = ""
+	fv.searchRe = nil
+	fv.SearchMatches = []int{}
+	fv.CurrentMatchIndex = -1
+}
+
+// findMatches lists the lines matching the search, skipping lines hidden by a
+// filter, without moving
+func (fv *FileViewer) findMatches() {
+	fv.SearchMatches = []int{}
+	fv.CurrentMatchIndex = -1
+	for i := range fv.Content {
+		if fv.lineMatches(i) {
+			fv.SearchMatches = append(fv.SearchMatches, i)
+		}
+	}
+}
+
+// lineMatches reports whether line i matches the search and is not hidden by
+// a filter
+func (fv *FileViewer) lineMatches(i int) bool {
+	if fv.searchRe == nil || !fv.searchRe.MatchString(fv.Content[i]) {
+		return false
+	}
+	return !fv.filterActive() || fv.filterMatches(fv.Filter, i)
+}
+
+// matchRanges returns the byte ranges of the search matches in line i. Empty
+// matches, such as of ^ or \b, have nothing to mark.
+func (fv *FileViewer) matchRanges(i int) []spell.Range {
+	if fv.searchRe == nil || i >= len(fv.Content) {
+		return nil
+	}
+	var ranges []spell.Range
+	for _, m := range fv.searchRe.FindAllStringIndex(fv.Content[i], -1) {
+		if m[1] > m[0] {
+			ranges = append(ranges, spell.Range{Start: m[0], End: m[1]})
+		}
+	}
+	return ranges
+}
+
+// setRegex makes later searches use regular expressions, or plain text, and
+// searches again for the current term (:set regex / :set noregex)
+func (fv *FileViewer) setRegex(on bool) {
+	fv.SearchRegex = on
+	mode := "Searches use plain text, ignoring case"
+	if on {
+		mode = "Searches use regular expressions ((?i) ignores case)"
+	}
+	if fv.SearchTerm == "" {
+		fv.StatusMessage = mode
+		return
+	}
+	re, err := compileSearch(fv.SearchTerm, on)
+	if err != nil {
+		fv.clearSearch()
+		fv.StatusMessage = fmt.Sprintf("%s (search cleared: %v)", mode, err)
+		return
+	}
+	fv.searchRe = re
+	fv.findMatches()
+	fv.StatusMessage = fmt.Sprintf("%s: %d match(es)", mode, len(fv.SearchMatches))
+}
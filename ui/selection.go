@@ -0,0 +1,172 @@
+package ui
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/HolyStarGazer/windows-tui-go/ops"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sizing marks a directory whose size is still being computed
+const sizing = -1
+
+// dirSizeMsg delivers the total size of the files below a marked directory
+type dirSizeMsg struct {
+	Path string
+	Size int64
+}
+
+// treeSize adds up the sizes of the regular files below dir, counting them in
+// progress, which may be nil. Unreadable entries are skipped, so the size is a
+// lower bound for them, as it is when the job is canceled.
+func treeSize(dir string, progress *ops.Progress) (size int64, files int) {
+	vfs.WalkDir(vfs.Default, dir, func(path string, d fs.DirEntry, err error) error {
+		if progress.Check() != nil {
+			return fs.SkipAll
+		}
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+			files++
+			progress.AddBytes(info.Size())
+			progress.AddItem()
+		}
+		return nil
+	})
+	return size, files
+}
+
+// dirSizeCmd computes the size of a marked directory in the background
+func (m *Model) dirSizeCmd(dir string) tea.Cmd {
+	return m.startJob("Sizing "+filepath.Base(dir), func(progress *ops.Progress) tea.Msg {
+		var size int64
+		ops.Work(func() { size, _ = treeSize(dir, progress) })
+		return dirSizeMsg{Path: dir, Size: size}
+	})
+}
+
+// toggleMark marks or unmarks the selected item, or every item of a
+// collapsed group, and moves down. Marking a directory starts computing its
+// size.
+func (m *Model) toggleMark() tea.Cmd {
+	if g, ok := m.groupAt(m.Cursor); ok && m.groups.Collapsed[g.Key] {
+		return m.toggleGroupMarks(g)
+	}
+	if len(m.Items) == 0 || m.Items[m.Cursor].Name == ".." {
+		return nil
+	}
+	item := m.Items[m.Cursor]
+	m.moveCursor(1)
+
+	if m.Marked[item.Path] {
+		delete(m.Marked, item.Path)
+		return nil
+	}
+	if m.Marked == nil {
+		m.Marked = make(map[string]bool)
+	}
+	m.Marked[item.Path] = true
+
+	if !item.IsDir {
+		return nil
+	}
+	if _, ok := m.dirSizes[item.Path]; ok {
+		return nil
+	}
+	if m.dirSizes == nil {
+		m.dirSizes = make(map[string]int64)
+	}
+	m.dirSizes[item.Path] = sizing
+	return m.dirSizeCmd(item.Path)
+}
+
+// invertMarks marks the unmarked items and unmarks the marked ones. Newly
+// marked directories are sized in the background.
+func (m *Model) invertMarks() tea.Cmd {
+	marked := make(map[string]bool)
+	var cmds []tea.Cmd
+	for _, item := range m.Items {
+		if item.Name == ".." || m.Marked[item.Path] {
+			continue
+		}
+		marked[item.Path] = true
+		if _, ok := m.dirSizes[item.Path]; item.IsDir && !ok {
+			if m.dirSizes == nil {
+				m.dirSizes = make(map[string]int64)
+			}
+			m.dirSizes[item.Path] = sizing
+			cmds = append(cmds, m.dirSizeCmd(item.Path))
+		}
+	}
+	m.Marked = marked
+	return tea.Batch(cmds...)
+}
+
+// openMarked opens the marked file under the cursor, or else the first marked
+// file, for ]f and [f to step through the rest. It reports false if no files
+// are marked.
+func (m *Model) openMarked() (tea.Cmd, bool) {
+	paths := m.markedPaths()
+	if len(paths) == 0 {
+		return nil, false
+	}
+	path := paths[0]
+	if item := m.Items[m.Cursor]; m.Marked[item.Path] && !item.IsDir {
+		path = item.Path
+	}
+	m.selectPath(path)
+	viewer := NewFileViewer(path, filepath.Base(path))
+	if len(paths) > 1 {
+		viewer.StatusMessage = fmt.Sprintf("%d marked files, ]f / [f for the others", len(paths))
+	}
+	m.openViewer(&viewer)
+	return m.prefetchSiblings(), true
+}
+
+// updateDirSize records a computed directory size
+func (m *Model) updateDirSize(msg dirSizeMsg) {
+	// Drop sizes for directories left behind
+	if _, ok := m.dirSizes[msg.Path]; !ok {
+		return
+	}
+	m.dirSizes[msg.Path] = msg.Size
+}
+
+// markedTotal adds up the sizes of the marked items; pending reports
+// directories that are still being sized
+func (m *Model) markedTotal() (total int64, pending bool) {
+	for _, item := range m.Items {
+		if !m.Marked[item.Path] {
+			continue
+		}
+		if !item.IsDir {
+			total += item.Size
+		} else if size := m.dirSizes[item.Path]; size == sizing {
+			pending = true
+		} else {
+			total += size
+		}
+	}
+	return total, pending
+}
+
+// markedSize returns the size of the marked items sized so far
+func (m *Model) markedSize() int64 {
+	total, _ := m.markedTotal()
+	return total
+}
+
+// selectionSummary describes the marked items, e.g. "3 marked, 1.2 GB"
+func (m *Model) selectionSummary() string {
+	total, pending := m.markedTotal()
+	summary := fmt.Sprintf("%d marked, %s", len(m.Marked), FormatSize(total))
+	if pending {
+		summary += " so far (sizing...)"
+	}
+	return summary
+}
@@ -0,0 +1,437 @@
+package ui
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// shareFrameInterval limits how often a watcher is sent a new frame
+const shareFrameInterval = 50 * time.Millisecond
+
+// shareSniffTimeout is how long a new connection may take to start an HTTP
+// request before it is treated as a terminal (nc sends nothing first)
+const shareSniffTimeout = 500 * time.Millisecond
+
+// keyFailureDelay is how long a client waits to be told its key was wrong,
+// which slows down guessing
+var keyFailureDelay = time.Second
+
+// maxKeyFailures is how many wrong keys a client address may give before it
+// is turned away for keyLockout
+const maxKeyFailures = 5
+
+// keyLockout is how long a client address is turned away after too many
+// wrong keys
+const keyLockout = 10 * time.Minute
+
+// errWrongKey and errTooManyKeys are the refusals given by keyGuard.check
+var (
+	errWrongKey    = errors.New("wrong key")
+	errTooManyKeys = errors.New("too many wrong keys, try again later")
+)
+
+// telnetCharMode asks telnet clients not to echo locally and to send keys as
+// they are typed (IAC WILL ECHO, IAC WILL SUPPRESS-GO-AHEAD)
+var telnetCharMode = []byte{255, 251, 1, 255, 251, 3}
+
+// sharing mirrors the screen to watchers; nil unless --share is given
+var sharing *shareSession
+
+// shareSession serves a read-only copy of every rendered frame. Frames are
+// published from View and sent by a goroutine per watcher, so access is locked.
+type shareSession struct {
+	Addr    string // Address watchers connect to
+	Key     string // Key watchers must give
+	guard   keyGuard
+	mu      sync.Mutex
+	frame   string
+	changed chan struct{} // Closed and replaced when the frame changes
+	viewers int
+}
+
+// EnableSharing serves a read-only live view of the screen on addr. Terminals
+// connect with telnet or nc, browsers with http://addr/, and each is asked for
+// the key shown in the sharing bar. A bare port listens on this machine only
+// (see ListenAddr).
+func EnableSharing(addr string) error {
+	listener, err := net.Listen("tcp", ListenAddr(addr))
+	if err != nil {
+		return err
+	}
+	key, err := randomKey()
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	sharing = &shareSession{
+		Addr:    shareAddr(listener.Addr()),
+		Key:     key,
+		changed: make(chan struct{}),
+	}
+	go sharing.serve(listener)
+	return nil
+}
+
+// randomKey returns a new key for watchers of --share and visitors of --web
+func randomKey() (string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// sameKey reports whether a given key matches the expected one, taking the
+// same time whatever it is
+func sameKey(given, want string) bool {
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(given)), []byte(want)) == 1
+}
+
+// ListenAddr returns the address --share and --web listen on: a bare port
+// such as "7000" or ":7000" listens on localhost only, so another machine can
+// only connect when a host such as 0.0.0.0 is given
+func ListenAddr(addr string) string {
+	if !strings.Contains(addr, ":") {
+		return net.JoinHostPort("localhost", addr)
+	}
+	if strings.HasPrefix(addr, ":") {
+		return "localhost" + addr
+	}
+	return addr
+}
+
+// keyGuard checks the keys given by clients, slowing down and then turning
+// away an address that keeps giving wrong ones
+type keyGuard struct {
+	mu       sync.Mutex
+	failures map[string]keyFailures // By client host
+}
+
+// keyFailures counts the wrong keys given from one address
+type keyFailures struct {
+	count int
+	last  time.Time
+}
+
+// check returns nil if the client at remote (host:port) gave the key want,
+// errTooManyKeys while its address is turned away, and errWrongKey after a
+// delay otherwise
+func (g *keyGuard) check(remote, given, want string) error {
+	host, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		host = remote
+	}
+	g.mu.Lock()
+	f, ok := g.failures[host]
+	if ok && time.Since(f.last) >= keyLockout {
+		delete(g.failures, host)
+		f = keyFailures{}
+	}
+	if f.count >= maxKeyFailures {
+		g.mu.Unlock()
+		return errTooManyKeys
+	}
+	if sameKey(given, want) {
+		delete(g.failures, host)
+		g.mu.Unlock()
+		return nil
+	}
+	if g.failures == nil {
+		g.failures = make(map[string]keyFailures)
+	}
+	g.failures[host] = keyFailures{count: f.count + 1, last: time.Now()}
+	g.mu.Unlock()
+	time.Sleep(keyFailureDelay)
+	return errWrongKey
+}
+
+// keyRefusal writes the HTTP error for a key refused by keyGuard.check, with
+// message explaining how to give the key when it was only wrong
+func keyRefusal(w http.ResponseWriter, err error, message string) {
+	if err == errTooManyKeys {
+		http.Error(w, "Too many wrong keys, try again later", http.StatusTooManyRequests)
+		return
+	}
+	http.Error(w, message, http.StatusForbidden)
+}
+
+// shareAddr is the address to give watchers and visitors: the host name when
+// listening on every interface
+func shareAddr(addr net.Addr) string {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+		if name, err := os.Hostname(); err == nil {
+			host = name
+		}
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// mirror publishes a frame to the watchers and returns it with the sharing
+// bar, which the window size leaves a line for
+func (s *shareSession) mirror(view string) string {
+	view += "\n" + s.bar()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if view != s.frame {
+		s.frame = view
+		close(s.changed)
+		s.changed = make(chan struct{})
+	}
+	return view
+}
+
+// bar is the line telling the user the screen is being shared, and how to
+// watch it
+func (s *shareSession) bar() string {
+	s.mu.Lock()
+	viewers := s.viewers
+	s.mu.Unlock()
+	return theme.Banner.Render(fmt.Sprintf(" Sharing read-only on %s (key %s) │ %d watching ", s.Addr, s.Key, viewers))
+}
+
+// current returns the latest frame and a channel closed when it changes
+func (s *shareSession) current() (string, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.frame, s.changed
+}
+
+// join counts a watcher in (1) or out (-1)
+func (s *shareSession) join(n int) {
+	s.mu.Lock()
+	s.viewers += n
+	s.mu.Unlock()
+}
+
+// checkKey checks the key given by the watcher at remote (see keyGuard.check)
+func (s *shareSession) checkKey(remote, key string) error {
+	return s.guard.check(remote, key, s.Key)
+}
+
+// serve accepts watchers, handing HTTP requests to the web view and other
+// connections to the terminal view
+func (s *shareSession) serve(listener net.Listener) {
+	web := &connListener{conns: make(chan net.Conn), addr: listener.Addr()}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.servePage)
+	mux.HandleFunc("/frames", s.serveFrames)
+	go http.Serve(web, mux)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.route(conn, web)
+	}
+}
+
+// route sends a connection to the web view if it starts an HTTP request, or
+// the terminal view otherwise
+func (s *shareSession) route(conn net.Conn, web *connListener) {
+	reader := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(shareSniffTimeout))
+	head, _ := reader.Peek(4)
+	conn.SetReadDeadline(time.Time{})
+	buffered := &bufferedConn{Conn: conn, reader: reader}
+	switch string(head) {
+	case "GET ", "HEAD":
+		web.conns <- buffered
+	default:
+		s.watchTerminal(buffered)
+	}
+}
+
+// watchTerminal asks a terminal for the key, then redraws the screen on it
+// until it disconnects. Keys typed afterwards are ignored.
+func (s *shareSession) watchTerminal(conn net.Conn) {
+	defer conn.Close()
+	conn.Write(telnetCharMode)
+	io.WriteString(conn, "Key: ")
+	conn.SetReadDeadline(time.Now().Add(time.Minute))
+	key, err := readTelnetLine(conn)
+	conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		return
+	}
+	switch s.checkKey(conn.RemoteAddr().String(), key) {
+	case nil:
+	case errTooManyKeys:
+		io.WriteString(conn, "\r\nToo many wrong keys, try again later\r\n")
+		return
+	default:
+		io.WriteString(conn, "\r\nWrong key\r\n")
+		return
+	}
+
+	s.join(1)
+	defer s.join(-1)
+	closed := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, conn)
+		close(closed)
+	}()
+
+	io.WriteString(conn, "\x1b[?25l\x1b[2J")
+	defer io.WriteString(conn, "\x1b[0m\x1b[?25h\r\n")
+	for {
+		frame, changed := s.current()
+		if _, err := io.WriteString(conn, terminalFrame(frame)); err != nil {
+			return
+		}
+		select {
+		case <-changed:
+		case <-closed:
+			return
+		}
+		time.Sleep(shareFrameInterval)
+	}
+}
+
+// readTelnetLine reads a line from a telnet or raw TCP client, skipping
+// telnet option negotiation
+func readTelnetLine(r io.Reader) (string, error) {
+	var line []byte
+	buf := make([]byte, 1)
+	skip := 0
+	for len(line) < 256 {
+		if _, err := r.Read(buf); err != nil {
+			return "", err
+		}
+		c := buf[0]
+		switch {
+		case skip > 0:
+			skip--
+		case c == 255:
+			// IAC, followed by a command and an option
+			skip = 2
+		case c == '\r' || c == '\n':
+			return string(line), nil
+		default:
+			line = append(line, c)
+		}
+	}
+	return string(line), nil
+}
+
+// terminalFrame redraws a frame over the previous one: each line is cleared
+// to its end and lines left over from a taller frame are erased
+func terminalFrame(frame string) string {
+	lines := strings.Split(frame, "\n")
+	return "\x1b[H" + strings.Join(lines, "\x1b[0m\x1b[K\r\n") + "\x1b[0m\x1b[K\x1b[J"
+}
+
+// sharePage is the web view; it replaces the screen with each frame sent to
+// /frames
+const sharePage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Shared session</title>
+</head>
+<body style="background-color:#0C0C0C;color:#CCCCCC;">
+<pre id="screen" style="font-family:'Cascadia Mono',Consolas,monospace;line-height:1.2;">Connecting...</pre>
+<script>
+const screen = document.getElementById("screen");
+const frames = new EventSource("frames" + location.search);
+frames.onmessage = (e) => { screen.innerHTML = e.data; };
+frames.onerror = () => { document.title = "Shared session (disconnected)"; };
+</script>
+</body>
+</html>
+`
+
+// servePage serves the web view to a browser that gave the key
+func (s *shareSession) servePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if err := s.checkKey(r.RemoteAddr, r.URL.Query().Get("key")); err != nil {
+		keyRefusal(w, err, "Open this page with ?key=<key>, using the key shown in the sharing bar")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	io.WriteString(w, sharePage)
+}
+
+// serveFrames streams frames to the web view as server-sent events, each one
+// as HTML
+func (s *shareSession) serveFrames(w http.ResponseWriter, r *http.Request) {
+	if err := s.checkKey(r.RemoteAddr, r.URL.Query().Get("key")); err != nil {
+		keyRefusal(w, err, "Wrong key")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	s.join(1)
+	defer s.join(-1)
+	for {
+		frame, changed := s.current()
+		// Each line of the event is sent as its own data field
+		event := "data: " + strings.ReplaceAll(ansiSpans(frame), "\n", "\ndata: ") + "\n\n"
+		if _, err := io.WriteString(w, event); err != nil {
+			return
+		}
+		flusher.Flush()
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+		time.Sleep(shareFrameInterval)
+	}
+}
+
+// connListener hands connections accepted elsewhere to an http.Server
+type connListener struct {
+	conns chan net.Conn
+	addr  net.Addr
+}
+
+// Accept returns the next connection routed to the web view
+func (l *connListener) Accept() (net.Conn, error) {
+	return <-l.conns, nil
+}
+
+// Close does nothing; the shared listener is never closed
+func (l *connListener) Close() error {
+	return nil
+}
+
+// Addr returns the shared listener's address
+func (l *connListener) Addr() net.Addr {
+	return l.addr
+}
+
+// bufferedConn is a connection whose first bytes were read ahead into a buffer
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// Read reads the buffered bytes, then the rest of the connection
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
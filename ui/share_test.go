@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+// TestListenAddr checks a bare port listens on localhost only and a named
+// host is kept
+func TestListenAddr(t *testing.T) {
+	for addr, want := range map[string]string{
+		"7000":           "localhost:7000",
+		":7000":          "localhost:7000",
+		"0.0.0.0:7000":   "0.0.0.0:7000",
+		"myserver:8080":  "myserver:8080",
+		"[::1]:8080":     "[::1]:8080",
+		"localhost:7000": "localhost:7000",
+	} {
+		if got := ListenAddr(addr); got != want {
+			t.Errorf("ListenAddr(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
+
+// TestKeyGuardLockout checks an address that keeps giving wrong keys is
+// turned away, even with the right key, while others still get in
+func TestKeyGuardLockout(t *testing.T) {
+	defer func(delay time.Duration) { keyFailureDelay = delay }(keyFailureDelay)
+	keyFailureDelay = 0
+	key, err := randomKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) < 32 {
+		t.Fatalf("key %q is shorter than 16 bytes", key)
+	}
+
+	var guard keyGuard
+	if err := guard.check("10.0.0.1:5000", key, key); err != nil {
+		t.Fatalf("right key: %v", err)
+	}
+	for i := 0; i < maxKeyFailures; i++ {
+		if err := guard.check("10.0.0.1:5000", "guess", key); err != errWrongKey {
+			t.Fatalf("wrong key %d: got %v, want %v", i+1, err, errWrongKey)
+		}
+	}
+	if err := guard.check("10.0.0.1:5001", key, key); err != errTooManyKeys {
+		t.Fatalf("right key after %d wrong ones: got %v, want %v", maxKeyFailures, err, errTooManyKeys)
+	}
+	if err := guard.check("10.0.0.2:5000", key, key); err != nil {
+		t.Fatalf("right key from another address: %v", err)
+	}
+
+	guard.failures["10.0.0.1"] = keyFailures{count: maxKeyFailures, last: time.Now().Add(-keyLockout)}
+	if err := guard.check("10.0.0.1:5000", key, key); err != nil {
+		t.Fatalf("right key after the lockout: %v", err)
+	}
+}
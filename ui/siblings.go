@@ -0,0 +1,178 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// prefetchHighlightLines is how many lines of a prefetched file are highlighted
+// ahead of time; the rest is highlighted once the file is shown
+const prefetchHighlightLines = 20000
+
+// siblingFileMsg asks for the next (1) or previous (-1) file of the browsed
+// directory, sent by ]f and [f in the viewer
+type siblingFileMsg struct {
+	Viewer *FileViewer
+	Step   int
+}
+
+// prefetchedFile is a sibling loaded ahead of time, with the state of the file
+// it was read from so changed files are read again
+type prefetchedFile struct {
+	Viewer  *FileViewer
+	ModTime time.Time
+	Size    int64
+}
+
+// prefetchedMsg delivers the siblings loaded around a viewer
+type prefetchedMsg struct {
+	From  *FileViewer
+	Files map[string]prefetchedFile
+}
+
+// siblingIndex returns the index in the browser listing of the file a viewer
+// shows, or -1 if it does not show a file of the browsed directory
+func (m *Model) siblingIndex(fv *FileViewer) int {
+	if fv == nil || fv.FilePath == "" || filepath.Dir(fv.FilePath) != filepath.Clean(m.CurrentPath) {
+		return -1
+	}
+	for i, item := range m.Items {
+		if item.Path == fv.FilePath {
+			return i
+		}
+	}
+	return -1
+}
+
+// siblingPath returns the path of the nearest file step places from index in
+// the browser listing, skipping directories. From a marked file only the other
+// marked files are visited.
+func (m *Model) siblingPath(index, step int) (string, bool) {
+	onlyMarked := m.Marked[m.Items[index].Path]
+	for i := index + step; i >= 0 && i < len(m.Items); i += step {
+		if !m.Items[i].IsDir && (!onlyMarked || m.Marked[m.Items[i].Path]) {
+			return m.Items[i].Path, true
+		}
+	}
+	return "", false
+}
+
+// openSibling replaces the viewer with the next or previous file of the browsed
+// directory, in the browser's order, using the prefetched viewer if the file
+// has not changed since
+func (m *Model) openSibling(msg siblingFileMsg) tea.Cmd {
+	if msg.Viewer != m.FileViewer {
+		return nil
+	}
+	index := m.siblingIndex(m.FileViewer)
+	if index < 0 {
+		m.FileViewer.StatusMessage = "Not a file of the browsed directory"
+		return nil
+	}
+	path, ok := m.siblingPath(index, msg.Step)
+	if !ok {
+		what := "file in the directory"
+		if m.Marked[m.Items[index].Path] {
+			what = "marked file"
+		}
+		if msg.Step > 0 {
+			m.FileViewer.StatusMessage = "Last " + what
+		} else {
+			m.FileViewer.StatusMessage = "First " + what
+		}
+		return nil
+	}
+
+	var viewer *FileViewer
+	if cached, ok := m.prefetched[path]; ok {
+		if info, err := vfs.Default.Stat(path); err == nil && info.ModTime().Equal(cached.ModTime) && info.Size() == cached.Size {
+			viewer = cached.Viewer
+		}
+	}
+	if viewer == nil {
+		fv := NewFileViewer(path, filepath.Base(path))
+		viewer = &fv
+	}
+	// Shown viewers are no longer the prefetcher's
+	m.prefetched = nil
+
+	// The sibling takes the viewer's place rather than opening over it
+	parent := m.parentViewer
+	m.openViewer(viewer)
+	m.parentViewer = parent
+	m.selectPath(path)
+	return m.prefetchSiblings()
+}
+
+// prefetchSiblings loads and highlights the files before and after the one
+// being viewed in the background, so ]f and [f show them at once
+func (m *Model) prefetchSiblings() tea.Cmd {
+	index := m.siblingIndex(m.FileViewer)
+	if index < 0 {
+		return nil
+	}
+	type sibling struct {
+		Path string
+		Lang string
+	}
+	var siblings []sibling
+	for _, step := range []int{1, -1} {
+		if path, ok := m.siblingPath(index, step); ok {
+			siblings = append(siblings, sibling{path, m.filetypeFor(filepath.Base(path))})
+		}
+	}
+	if len(siblings) == 0 {
+		return nil
+	}
+
+	from, old := m.FileViewer, m.prefetched
+	rules := m.highlightRules
+	return func() tea.Msg {
+		files := make(map[string]prefetchedFile)
+		for _, s := range siblings {
+			info, err := vfs.Default.Stat(s.Path)
+			if err != nil {
+				continue
+			}
+			if cached, ok := old[s.Path]; ok && info.ModTime().Equal(cached.ModTime) && info.Size() == cached.Size {
+				files[s.Path] = cached
+				continue
+			}
+			files[s.Path] = prefetchedFile{
+				Viewer:  prefetchViewer(s.Path, s.Lang, rules),
+				ModTime: info.ModTime(),
+				Size:    info.Size(),
+			}
+		}
+		return prefetchedMsg{From: from, Files: files}
+	}
+}
+
+// prefetchViewer loads a file and highlights it ahead of time. The viewer is
+// not shown yet, so its highlighter can run here rather than chunk by chunk.
+func prefetchViewer(path, lang string, rules []lineRule) *FileViewer {
+	fv := NewFileViewer(path, filepath.Base(path))
+	fv.HighlightRules = rules
+	if lang != "" {
+		fv.configLanguage = lang
+		if err := fv.SetLanguage(lang); err != nil {
+			fv.StatusMessage = fmt.Sprintf("Error: filetypes: %v", err)
+		}
+	}
+	for fv.highlighter != nil && fv.highlightedLines < prefetchHighlightLines {
+		fv.receiveHighlight(fv.highlighter.chunk(&fv))
+	}
+	return &fv
+}
+
+// updatePrefetched keeps the siblings prefetched for the viewer still shown
+func (m *Model) updatePrefetched(msg prefetchedMsg) {
+	if msg.From != m.FileViewer {
+		return
+	}
+	m.prefetched = msg.Files
+}
@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/picture"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// minSlideDelay is the shortest time a slideshow shows an image
+const minSlideDelay = time.Second
+
+// slideTickMsg moves a slideshow on to the next image, unless the
+// slideshow was stopped or restarted since
+type slideTickMsg struct {
+	Preview *ImagePreview
+	Seq     int
+}
+
+// compileSlideDelay parses the slideshow_delay setting
+func compileSlideDelay(setting string) (time.Duration, error) {
+	d, err := parseSlideDelay(setting)
+	if err != nil {
+		return 5 * time.Second, fmt.Errorf("invalid slideshow_delay %q (use a duration such as 5s)", setting)
+	}
+	return d, nil
+}
+
+// parseSlideDelay parses a slideshow delay: a duration such as "2.5s", or a
+// number of seconds
+func parseSlideDelay(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, numErr := strconv.ParseFloat(value, 64)
+		if numErr != nil {
+			return 0, err
+		}
+		d = time.Duration(seconds * float64(time.Second))
+	}
+	if d < minSlideDelay {
+		return 0, fmt.Errorf("the delay must be at least %v", minSlideDelay)
+	}
+	return d, nil
+}
+
+// slideshowCommand previews the image under the cursor, or the first listed
+// image, and moves on to the next one every delay (:slideshow [delay])
+func (m *Model) slideshowCommand(args []string) tea.Cmd {
+	delay := m.slideDelay
+	if len(args) > 0 {
+		d, err := parseSlideDelay(args[0])
+		if err != nil {
+			m.StatusMessage = fmt.Sprintf("Error: %v", err)
+			return nil
+		}
+		delay = d
+	}
+	start := -1
+	for i, item := range m.Items {
+		if !item.IsDir && picture.IsImage(item.Name) && (start < 0 || i == m.Cursor) {
+			start = i
+		}
+	}
+	if start < 0 {
+		m.StatusMessage = "No images listed here"
+		return nil
+	}
+	m.Cursor = start
+	cmd := m.openImagePreview(m.Items[start].Path)
+	m.Preview.Slideshow, m.Preview.Delay = true, delay
+	return cmd
+}
+
+// toggleSlideshow starts or stops moving on through the images (s)
+func (m *Model) toggleSlideshow() tea.Cmd {
+	p := m.Preview
+	if p.Count < 2 && !p.Slideshow {
+		p.status = "No other images to show"
+		return nil
+	}
+	p.Slideshow = !p.Slideshow
+	if p.Delay == 0 {
+		p.Delay = m.slideDelay
+	}
+	if !p.Slideshow || p.img == nil && p.err == nil {
+		// A loading image schedules the next one once shown
+		return nil
+	}
+	return p.nextSlide()
+}
+
+// changeSlideDelay lengthens or shortens the slideshow delay by a second
+// (+ and -), counting from now
+func (m *Model) changeSlideDelay(delta time.Duration) tea.Cmd {
+	p := m.Preview
+	if p.Delay == 0 {
+		p.Delay = m.slideDelay
+	}
+	p.Delay = max((p.Delay + delta).Truncate(time.Second), minSlideDelay)
+	p.status = fmt.Sprintf("Showing each image for %v", p.Delay)
+	if !p.Slideshow || p.img == nil && p.err == nil {
+		return nil
+	}
+	return p.nextSlide()
+}
+
+// nextSlide schedules the move to the next image, replacing any scheduled
+// before
+func (p *ImagePreview) nextSlide() tea.Cmd {
+	p.seq++
+	msg := slideTickMsg{Preview: p, Seq: p.seq}
+	return tea.Tick(p.Delay, func(time.Time) tea.Msg { return msg })
+}
+
+// advanceSlideshow shows the next image when a slideshow's time is up
+func (m *Model) advanceSlideshow(msg slideTickMsg) tea.Cmd {
+	if msg.Preview != m.Preview || !msg.Preview.Slideshow || msg.Seq != msg.Preview.seq {
+		return nil
+	}
+	return m.stepImage(1)
+}
+
+// slideshowLabel describes a running slideshow, or returns ""
+func (p *ImagePreview) slideshowLabel() string {
+	if !p.Slideshow {
+		return ""
+	}
+	return fmt.Sprintf("Slideshow: every %v (s stops, +/- change)", p.Delay)
+}
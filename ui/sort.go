@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/config"
+	"github.com/HolyStarGazer/windows-tui-go/types"
+)
+
+// sortFile is the state file holding the sort order picked with :sort or s
+const sortFile = "sort.json"
+
+// savedSort is the sort order kept between sessions
+type savedSort struct {
+	Sort    string `json:"sort"`
+	Reverse bool   `json:"reverse"`
+}
+
+// sortKeys are the orders picked by the key after s
+var sortKeys = map[string]string{"n": "name", "s": "size", "t": "time", "e": "ext"}
+
+// descendingSorts are the built-in orders that put the largest values first
+// unless reversed
+var descendingSorts = map[string]bool{"size": true, "time": true}
+
+// descending reports whether the listing runs from the largest value down
+func (o BrowseOptions) descending() bool {
+	return descendingSorts[o.Sort] != o.Reverse
+}
+
+// sortLabel describes the sort order, e.g. "size, largest first"
+func (o BrowseOptions) sortLabel() string {
+	var first, last string
+	switch o.Sort {
+	case "name", "ext":
+		first, last = "Z to A", "A to Z"
+	case "size":
+		first, last = "largest first", "smallest first"
+	case "time":
+		first, last = "newest first", "oldest first"
+	default:
+		first, last = "descending", "ascending"
+	}
+	if o.descending() {
+		return o.Sort + ", " + first
+	}
+	return o.Sort + ", " + last
+}
+
+// loadSort applies the sort order saved by :sort or s, if any
+func (m *Model) loadSort() error {
+	var saved savedSort
+	if err := config.Load(sortFile, &saved); err != nil {
+		return fmt.Errorf("%s: %v", sortFile, err)
+	}
+	if saved.Sort == "" {
+		return nil
+	}
+	if _, ok := types.SorterNamed(saved.Sort); !ok {
+		return fmt.Errorf("%s: unknown sort %q", sortFile, saved.Sort)
+	}
+	m.globalOptions.Sort, m.globalOptions.Reverse = saved.Sort, saved.Reverse
+	m.Options.Sort, m.Options.Reverse = saved.Sort, saved.Reverse
+	return nil
+}
+
+// setSort sorts the listings of every pane and tab, and the ones opened later,
+// by name in either direction, and saves the order for the next session.
+// Hidden tabs are listed again when shown.
+func (m *Model) setSort(name string, descending bool) {
+	options := []*BrowseOptions{&m.Options, &m.globalOptions, &m.otherPane.Options}
+	for i := range m.tabs {
+		if i != m.activeTab {
+			options = append(options, &m.tabs[i].pane.Options, &m.tabs[i].otherPane.Options)
+			m.tabs[i].resorted = true
+		}
+	}
+	for _, o := range options {
+		o.Sort = name
+		o.Reverse = descending != descendingSorts[name]
+	}
+	m.reloadDirectory()
+	if m.otherPane.CurrentPath != "" {
+		m.reloadOtherPane()
+	}
+	if err := config.Save(sortFile, savedSort{Sort: m.Options.Sort, Reverse: m.Options.Reverse}); err != nil {
+		m.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	m.StatusMessage = "Sorted by " + m.Options.sortLabel()
+}
+
+// sortCommand handles :sort [name|size|time|ext] [asc|desc|reverse]. A new
+// order starts in its usual direction; without arguments the order in use is
+// shown.
+func (m *Model) sortCommand(args []string) {
+	usage := fmt.Sprintf("Usage: :sort [%s] [asc|desc|reverse]", strings.Join(types.SorterNames(), "|"))
+	if len(args) == 0 {
+		m.StatusMessage = fmt.Sprintf("Sorted by %s (%s, or s)", m.Options.sortLabel(), strings.TrimPrefix(usage, "Usage: "))
+		return
+	}
+	if len(args) > 2 {
+		m.StatusMessage = usage
+		return
+	}
+	name, descending := m.Options.Sort, m.Options.descending()
+	for i, arg := range args {
+		switch arg {
+		case "asc":
+			descending = false
+		case "desc":
+			descending = true
+		case "reverse":
+			descending = !descending
+		default:
+			if _, ok := types.SorterNamed(arg); !ok || i > 0 {
+				m.StatusMessage = usage
+				return
+			}
+			name, descending = arg, descendingSorts[arg]
+		}
+	}
+	m.setSort(name, descending)
+}
+
+// sortKey applies the sort order picked by the key after s: sn by name, ss
+// by size, st by time and se by extension. Picking the order in use again
+// reverses it, as does sr.
+func (m *Model) sortKey(key string) {
+	if key == "r" {
+		m.setSort(m.Options.Sort, !m.Options.descending())
+		return
+	}
+	name, ok := sortKeys[key]
+	switch {
+	case key == "esc":
+		m.StatusMessage = ""
+		return
+	case !ok:
+		m.StatusMessage = fmt.Sprintf("No sort s%s (sn name, ss size, st time, se extension, sr reverse)", key)
+		return
+	}
+	if name == m.Options.Sort {
+		m.setSort(name, !m.Options.descending())
+		return
+	}
+	m.setSort(name, descendingSorts[name])
+}
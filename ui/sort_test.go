@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/HolyStarGazer/windows-tui-go/types"
+	"github.com/HolyStarGazer/windows-tui-go/uitest"
+)
+
+// TestSortEveryPane checks a new sort order reaches both panes and the
+// hidden tabs, not just the pane it was picked in
+func TestSortEveryPane(t *testing.T) {
+	uitest.IsolateConfig(t)
+	root := uitest.Mount(t, fstest.MapFS{
+		"work/a.txt": {Data: []byte("a")},
+		"work/b.txt": {Data: []byte(strings.Repeat("b", 300))},
+		"work/c.txt": {Data: []byte(strings.Repeat("c", 20))},
+	})
+	m := NewModelAt(filepath.Join(root, "work"))
+	m.toggleDualPane()
+	m.newTab()
+	m.toggleDualPane()
+	m.cycleTab(1)
+
+	m.sortKey("s")
+	names := func(items []types.FileItem) string {
+		var names []string
+		for _, item := range items {
+			if item.Name != ".." {
+				names = append(names, item.Name)
+			}
+		}
+		return strings.Join(names, " ")
+	}
+	want := "b.txt c.txt a.txt"
+	check := func(where string, options BrowseOptions, items []types.FileItem) {
+		t.Helper()
+		if options.Sort != "size" || options.Reverse {
+			t.Errorf("%s: sort %q reverse %v, want size largest first", where, options.Sort, options.Reverse)
+		}
+		if got := names(items); got != want {
+			t.Errorf("%s: listed %s, want %s", where, got, want)
+		}
+	}
+	check("active pane", m.Options, m.Items)
+	check("other pane", m.otherPane.Options, m.otherPane.Items)
+
+	m.cycleTab(1)
+	check("other tab", m.Options, m.Items)
+	check("other tab's other pane", m.otherPane.Options, m.otherPane.Items)
+	if m.globalOptions.Sort != "size" || m.globalOptions.Reverse {
+		t.Errorf("new panes: sort %q reverse %v, want size largest first", m.globalOptions.Sort, m.globalOptions.Reverse)
+	}
+}
@@ -0,0 +1,210 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/HolyStarGazer/windows-tui-go/spell"
+)
+
+// spellCheck turns spell checking on for newly opened text and Markdown
+// files, from the spell_check setting
+var spellCheck bool
+
+// underlineOn and underlineOff surround misspelled words
+const (
+	underlineOn  = "\x1b[4m"
+	underlineOff = "\x1b[24m"
+)
+
+// spellLine is the spell check of one content line
+type spellLine struct {
+	Misspelled []spell.Range
+	InCode     bool // Whether the line ends inside a Markdown code block
+}
+
+// spellCache holds the lines checked so far. It is shared by copies of the
+// viewer, so lines checked while rendering stay checked.
+type spellCache struct {
+	lines []spellLine
+}
+
+// isSpellFile reports whether a file is prose that spell checking applies to
+func isSpellFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".markdown", ".txt":
+		return true
+	}
+	return false
+}
+
+// isMarkdown reports whether a file is Markdown, whose code is not spell checked
+func isMarkdown(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".md" || ext == ".markdown"
+}
+
+// setSpell turns spell checking on or off (:set spell / :set nospell)
+func (fv *FileViewer) setSpell(on bool) {
+	fv.Spell = on
+	fv.resetSpelling()
+	if !on {
+		fv.StatusMessage = "Spell checking disabled"
+		return
+	}
+	fv.StatusMessage = "Spell checking enabled (]s/[s: next/prev misspelling)"
+}
+
+// resetSpelling drops the spell check of the content, to check it again
+func (fv *FileViewer) resetSpelling() {
+	fv.spelling = nil
+	if fv.Spell {
+		fv.spelling = &spellCache{}
+	}
+}
+
+// truncateSpelling drops the spell check from line start onward, for lines
+// that changed
+func (fv *FileViewer) truncateSpelling(start int) {
+	if fv.spelling != nil && len(fv.spelling.lines) > start {
+		fv.spelling.lines = fv.spelling.lines[:start]
+	}
+}
+
+// misspelled returns the misspelled words of line i, checking the lines up to
+// it first since Markdown code blocks carry over from line to line
+func (fv *FileViewer) misspelled(i int) []spell.Range {
+	if fv.spelling == nil || fv.hex || i >= len(fv.Content) {
+		return nil
+	}
+	checker := spell.Default()
+	markdown := isMarkdown(fv.FileName)
+	lines := fv.spelling.lines
+	for n := len(lines); n <= i; n++ {
+		inCode := n > 0 && lines[n-1].InCode
+		line := fv.Content[n]
+		switch {
+		case markdown && strings.HasPrefix(strings.TrimSpace(line), "```"):
+			lines = append(lines, spellLine{InCode: !inCode})
+		case inCode:
+			lines = append(lines, spellLine{InCode: true})
+		case markdown:
+			lines = append(lines, spellLine{Misspelled: checker.Check(blankCodeSpans(line))})
+		default:
+			lines = append(lines, spellLine{Misspelled: checker.Check(line)})
+		}
+	}
+	fv.spelling.lines = lines
+	return lines[i].Misspelled
+}
+
+// blankCodeSpans replaces Markdown `code` spans with spaces, keeping the
+// positions of the rest of the line
+func blankCodeSpans(line string) string {
+	if !strings.Contains(line, "`") {
+		return line
+	}
+	b := []byte(line)
+	inCode := false
+	for i, c := range b {
+		if c == '`' {
+			inCode = !inCode
+		}
+		if inCode || c == '`' {
+			b[i] = ' '
+		}
+	}
+	return string(b)
+}
+
+// underlineRanges underlines byte ranges of the text of a styled line
+func underlineRanges(styled string, ranges []spell.Range) string {
+	return markRanges(styled, ranges, underlineOn, underlineOff)
+}
+
+// markRanges surrounds byte ranges of the text of a styled line with the on and
+// off sequences. Escape sequences inside a range may end the mark, so it is
+// restarted after each one.
+func markRanges(styled string, ranges []spell.Range, on, off string) string {
+	if len(ranges) == 0 {
+		return styled
+	}
+	var b strings.Builder
+	plain := 0
+	next := 0
+	inside := false
+	for i := 0; i < len(styled); {
+		if styled[i] == '\x1b' {
+			end := strings.IndexByte(styled[i:], 'm')
+			if end < 0 {
+				b.WriteString(styled[i:])
+				break
+			}
+			b.WriteString(styled[i : i+end+1])
+			i += end + 1
+			if inside {
+				b.WriteString(on)
+			}
+			continue
+		}
+		if !inside && next < len(ranges) && plain == ranges[next].Start {
+			b.WriteString(on)
+			inside = true
+		}
+		b.WriteByte(styled[i])
+		i++
+		plain++
+		if inside && plain == ranges[next].End {
+			b.WriteString(off)
+			inside = false
+			next++
+		}
+	}
+	if inside {
+		b.WriteString(off)
+	}
+	return b.String()
+}
+
+// jumpMisspelling moves to the next (dir > 0) or previous (dir < 0) line with
+// a misspelled word, remembering the word for :spellgood
+func (fv *FileViewer) jumpMisspelling(dir int) {
+	if !fv.Spell {
+		fv.StatusMessage = "Spell checking is off (:set spell)"
+		return
+	}
+	current := fv.lineAt(fv.ScrollPos)
+	for i := current + dir; i >= 0 && i < len(fv.Content); i += dir {
+		if fv.filterActive() && !fv.filterMatches(fv.Filter, i) {
+			continue
+		}
+		if words := fv.misspelled(i); len(words) > 0 {
+			fv.ScrollPos = fv.rowOf(i)
+			fv.spellWord = fv.Content[i][words[0].Start:words[0].End]
+			fv.StatusMessage = fmt.Sprintf("Misspelled: %s (:spellgood to accept)", fv.spellWord)
+			return
+		}
+	}
+	fv.StatusMessage = "No more misspelled words"
+}
+
+// spellGood adds a word, or the misspelling last jumped to, to the user's word
+// list (:spellgood [word])
+func (fv *FileViewer) spellGood(args []string) {
+	word := fv.spellWord
+	if len(args) > 0 {
+		word = args[0]
+	}
+	if word == "" {
+		fv.StatusMessage = "Usage: :spellgood <word>, or use ]s to find a misspelling first"
+		return
+	}
+	if err := spell.AddUserWord(word); err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	fv.resetSpelling()
+	fv.spellWord = ""
+	fv.StatusMessage = fmt.Sprintf("Added %q to your word list", word)
+}
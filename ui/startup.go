@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/HolyStarGazer/windows-tui-go/startup"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// startupCmd lists the auto-start entries in the background
+func startupCmd(all bool) tea.Cmd {
+	return func() tea.Msg {
+		items, err := startup.List(all)
+		if err != nil && len(items) == 0 {
+			return errorMsg{err}
+		}
+
+		entries := make([]ListEntry, 0, len(items))
+		for _, item := range items {
+			entries = append(entries, startupEntry(item))
+		}
+
+		panel := NewListPanel("🚀 Startup Items", entries)
+		panel.Subtitle = fmt.Sprintf("%d entries", len(items))
+		if !all {
+			panel.Subtitle += " (Windows tasks hidden, :startup all lists them)"
+		}
+		if err != nil {
+			panel.StatusMessage = fmt.Sprintf("Error: scheduled tasks: %v", err)
+		}
+		return openListMsg{panel}
+	}
+}
+
+// startupEntry shows an auto-start entry; Enter reveals the file it starts.
+// Entries disabled in Task Manager are dimmed.
+func startupEntry(item startup.Item) ListEntry {
+	label := fmt.Sprintf("%-26s %-32s %s",
+		item.Source, truncateAtVisualWidth(item.Name, 32), item.Command)
+	if !item.Enabled {
+		label = theme.Dim.Render(label + " (disabled)")
+	}
+
+	entry := ListEntry{Label: label, Data: item}
+	if item.Path != "" {
+		entry.Msg = revealPathMsg{Path: item.Path}
+	} else {
+		entry.Msg = errorMsg{fmt.Errorf("cannot find the file %s starts", item.Name)}
+	}
+	return entry
+}
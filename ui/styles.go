@@ -1,31 +1,227 @@
 package ui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"fmt"
+	"strconv"
 
-// Styles for the TUI
-var (
-	titleStyle = lipgloss.NewStyle().
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Theme holds the styles every part of the TUI draws with. The active one is
+// theme; :theme and the theme setting switch it.
+type Theme struct {
+	Name string
+
+	Title      lipgloss.Style
+	Selected   lipgloss.Style
+	Directory  lipgloss.Style
+	File       lipgloss.Style
+	Status     lipgloss.Style
+	Match      lipgloss.Style
+	Banner     lipgloss.Style
+	Dim        lipgloss.Style
+	Group      lipgloss.Style
+	Help       lipgloss.Style
+	Pane       lipgloss.Style
+	ActivePane lipgloss.Style
+	Tab        lipgloss.Style
+	ActiveTab  lipgloss.Style
+
+	// Levels colors log lines by level
+	Levels map[logLevel]lipgloss.Style
+
+	// Escape sequences of prerendered views: CSV tables and JSON trees
+	TableHeader, TableRule                                string
+	JSONKey, JSONString, JSONNumber, JSONLiteral, JSONDim string
+
+	// SGR color parameters of rendered Markdown
+	MDCode, MDLink, MDDim, MDHeading1, MDHeading2, MDHeading3 string
+
+	// Syntax is the colorscheme the viewer highlights with, unless one is
+	// picked with :set colorscheme
+	Syntax string
+}
+
+// palette is the colors of a theme, as hex values
+type palette struct {
+	Accent      string // Titles, the selection, group headers, the active pane and tab
+	OnAccent    string // The selected item
+	TabText     string // The active tab
+	Text        string // Files
+	Directory   string // Directories, table headers, links
+	Highlight   string // Search matches, banners and warnings
+	OnHighlight string // Banners
+	Muted       string // The status line and dimmed text
+	Help        string // Help lines, inactive tabs, JSON punctuation
+	Border      string // Inactive panes
+	Code        string // Markdown code
+	Key         string // JSON keys
+	String      string // JSON strings
+	Number      string // JSON numbers
+	Literal     string // JSON true, false and null
+
+	// Log levels; warnings use Highlight
+	Trace, Debug, Info, Error, Fatal string
+
+	Syntax string
+}
+
+// themes are the named themes, the default first
+var themes = []struct {
+	Name   string
+	Colors palette
+}{
+	{"dark", palette{
+		Accent: "#7D56F4", OnAccent: "#000000", TabText: "#FFFFFF", Text: "#FFFFFF",
+		Directory: "#00D7FF", Highlight: "#FFD75F", OnHighlight: "#000000",
+		Muted: "#666666", Help: "#888888", Border: "#444444", Code: "#FD971F",
+		Key: "#66D9EF", String: "#E6DB74", Number: "#AE81FF", Literal: "#F92672",
+		Trace: "#585858", Debug: "#808080", Info: "#D0D0D0", Error: "#FF5F5F", Fatal: "#FF0000",
+		Syntax: "monokai",
+	}},
+	{"light", palette{
+		Accent: "#5F3FD0", OnAccent: "#FFFFFF", TabText: "#FFFFFF", Text: "#1C1C1C",
+		Directory: "#005FAF", Highlight: "#AF5F00", OnHighlight: "#FFFFFF",
+		Muted: "#8A8A8A", Help: "#6C6C6C", Border: "#BCBCBC", Code: "#D75F00",
+		Key: "#005FAF", String: "#5F8700", Number: "#8700AF", Literal: "#D70057",
+		Trace: "#B2B2B2", Debug: "#8A8A8A", Info: "#3A3A3A", Error: "#D70000", Fatal: "#AF0000",
+		Syntax: "monokailight",
+	}},
+	{"high-contrast", palette{
+		Accent: "#FFFF00", OnAccent: "#000000", TabText: "#000000", Text: "#FFFFFF",
+		Directory: "#00FFFF", Highlight: "#FF87FF", OnHighlight: "#000000",
+		Muted: "#C0C0C0", Help: "#D0D0D0", Border: "#FFFFFF", Code: "#FFAF00",
+		Key: "#00FFFF", String: "#00FF00", Number: "#FF87FF", Literal: "#FFFF00",
+		Trace: "#A8A8A8", Debug: "#C0C0C0", Info: "#FFFFFF", Error: "#FF5F5F", Fatal: "#FF0000",
+		Syntax: "github-dark",
+	}},
+	{"solarized-dark", palette{
+		Accent: "#268BD2", OnAccent: "#FDF6E3", TabText: "#FDF6E3", Text: "#93A1A1",
+		Directory: "#2AA198", Highlight: "#B58900", OnHighlight: "#002B36",
+		Muted: "#586E75", Help: "#657B83", Border: "#073642", Code: "#CB4B16",
+		Key: "#268BD2", String: "#2AA198", Number: "#D33682", Literal: "#CB4B16",
+		Trace: "#586E75", Debug: "#657B83", Info: "#93A1A1", Error: "#DC322F", Fatal: "#DC322F",
+		Syntax: "solarized-dark",
+	}},
+	{"solarized-light", palette{
+		Accent: "#268BD2", OnAccent: "#FDF6E3", TabText: "#FDF6E3", Text: "#586E75",
+		Directory: "#2AA198", Highlight: "#B58900", OnHighlight: "#FDF6E3",
+		Muted: "#93A1A1", Help: "#839496", Border: "#EEE8D5", Code: "#CB4B16",
+		Key: "#268BD2", String: "#2AA198", Number: "#D33682", Literal: "#CB4B16",
+		Trace: "#93A1A1", Debug: "#839496", Info: "#586E75", Error: "#DC322F", Fatal: "#DC322F",
+		Syntax: "solarized-light",
+	}},
+}
+
+// theme is the active theme
+var theme = newTheme(themes[0].Name, themes[0].Colors)
+
+// newTheme builds the styles of a theme from its colors
+func newTheme(name string, p palette) Theme {
+	color := func(hex string) lipgloss.Color { return lipgloss.Color(hex) }
+	border := lipgloss.RoundedBorder()
+	if !calibration.BoxDrawing {
+		border = lipgloss.ASCIIBorder()
+	}
+	pane := lipgloss.NewStyle().
+		Border(border).
+		BorderForeground(color(p.Border))
+
+	return Theme{
+		Name: name,
+
+		Title: lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("#7D56F4")).
-			MarginBottom(1)
+			Foreground(color(p.Accent)).
+			MarginBottom(1),
 
-	selectedStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#000000")).
-			Background(lipgloss.Color("#7D56F4")).
-			Bold(true)
+		Selected: lipgloss.NewStyle().
+			Foreground(color(p.OnAccent)).
+			Background(color(p.Accent)).
+			Bold(true),
 
-	directoryStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#00D7FF")).
-			Bold(true)
+		Directory: lipgloss.NewStyle().
+			Foreground(color(p.Directory)).
+			Bold(true),
 
-	fileStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FFFFFF"))
+		File: lipgloss.NewStyle().
+			Foreground(color(p.Text)),
 
-	statusStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#666666")).
-			MarginTop(1)
+		Status: lipgloss.NewStyle().
+			Foreground(color(p.Muted)).
+			MarginTop(1),
 
-	helpStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#888888")).
-			MarginTop(1)
-)
+		Match: lipgloss.NewStyle().
+			Foreground(color(p.Highlight)).
+			Bold(true),
+
+		Banner: lipgloss.NewStyle().
+			Foreground(color(p.OnHighlight)).
+			Background(color(p.Highlight)),
+
+		Dim: lipgloss.NewStyle().
+			Foreground(color(p.Muted)),
+
+		Group: lipgloss.NewStyle().
+			Foreground(color(p.Accent)).
+			Bold(true),
+
+		Help: lipgloss.NewStyle().
+			Foreground(color(p.Help)).
+			MarginTop(1),
+
+		Pane: pane,
+
+		ActivePane: pane.
+			BorderForeground(color(p.Accent)),
+
+		Tab: lipgloss.NewStyle().
+			Foreground(color(p.Help)),
+
+		ActiveTab: lipgloss.NewStyle().
+			Foreground(color(p.TabText)).
+			Background(color(p.Accent)).
+			Bold(true),
+
+		Levels: map[logLevel]lipgloss.Style{
+			levelTrace: lipgloss.NewStyle().Foreground(color(p.Trace)),
+			levelDebug: lipgloss.NewStyle().Foreground(color(p.Debug)),
+			levelInfo:  lipgloss.NewStyle().Foreground(color(p.Info)),
+			levelWarn:  lipgloss.NewStyle().Foreground(color(p.Highlight)),
+			levelError: lipgloss.NewStyle().Foreground(color(p.Error)),
+			levelFatal: lipgloss.NewStyle().Foreground(color(p.Fatal)).Bold(true),
+		},
+
+		TableHeader: "\x1b[1;" + sgrColor(p.Directory) + "m",
+		TableRule:   "\x1b[" + sgrColor(p.Muted) + "m",
+		JSONKey:     "\x1b[" + sgrColor(p.Key) + "m",
+		JSONString:  "\x1b[" + sgrColor(p.String) + "m",
+		JSONNumber:  "\x1b[" + sgrColor(p.Number) + "m",
+		JSONLiteral: "\x1b[" + sgrColor(p.Literal) + "m",
+		JSONDim:     "\x1b[" + sgrColor(p.Help) + "m",
+
+		MDCode:     sgrColor(p.Code),
+		MDLink:     sgrColor(p.Directory),
+		MDDim:      sgrColor(p.Help),
+		MDHeading1: sgrColor(p.Accent),
+		MDHeading2: sgrColor(p.Directory),
+		MDHeading3: sgrColor(p.Highlight),
+
+		Syntax: p.Syntax,
+	}
+}
+
+// sgrColor returns the SGR parameters of a foreground color given as
+// "#RRGGBB": 24-bit, or the nearest color the terminal shows where it does
+// not show 24-bit colors
+func sgrColor(hex string) string {
+	if profile := colorProfile(); profile != termenv.TrueColor {
+		return profile.Color(hex).Sequence(false)
+	}
+	rgb, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		panic(fmt.Sprintf("bad theme color %q", hex))
+	}
+	return fmt.Sprintf("38;2;%d;%d;%d", rgb>>16, rgb>>8&0xFF, rgb&0xFF)
+}
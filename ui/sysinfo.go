@@ -0,0 +1,171 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/disk"
+	"github.com/HolyStarGazer/windows-tui-go/sysinfo"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sysinfoTitle is the title of the system information panel
+const sysinfoTitle = "🖥 System Information"
+
+// sysinfoInterval is how often the open panel is refreshed
+const sysinfoInterval = 2 * time.Second
+
+// sysinfoBarWidth is the width of the usage bars
+const sysinfoBarWidth = 30
+
+// driveUsage is the space of a volume
+type driveUsage struct {
+	Root      string
+	Total     uint64
+	Available uint64
+	Err       error
+}
+
+// sysinfoMsg delivers a sample of the system information
+type sysinfoMsg struct {
+	Info   sysinfo.Info
+	CPU    float64 // Usage since the previous sample, -1 if unknown
+	Drives []driveUsage
+	List   *ListPanel // Panel the sample refreshes, nil to open one
+}
+
+// readSysinfo samples the system, measuring CPU usage since prev
+func readSysinfo(prev sysinfo.CPUTimes, list *ListPanel) sysinfoMsg {
+	msg := sysinfoMsg{Info: sysinfo.Read(), List: list}
+	msg.CPU = sysinfo.CPUUsage(prev, msg.Info.CPUTimes)
+	for _, root := range disk.Volumes() {
+		d := driveUsage{Root: root}
+		d.Total, d.Available, d.Err = disk.Usage(root)
+		msg.Drives = append(msg.Drives, d)
+	}
+	return msg
+}
+
+// sysinfoCmd reads the system information in the background, taking two
+// samples a moment apart so the CPU usage is known right away
+func sysinfoCmd() tea.Cmd {
+	return func() tea.Msg {
+		first := sysinfo.Read()
+		time.Sleep(250 * time.Millisecond)
+		return readSysinfo(first.CPUTimes, nil)
+	}
+}
+
+// sysinfoTickCmd refreshes the panel after sysinfoInterval
+func sysinfoTickCmd(msg sysinfoMsg) tea.Cmd {
+	return tea.Tick(sysinfoInterval, func(time.Time) tea.Msg {
+		return readSysinfo(msg.Info.CPUTimes, msg.List)
+	})
+}
+
+// usageBar draws a bar filled to percent, yellow from 75% and red from 90%
+func usageBar(percent float64) string {
+	filled := int(percent*sysinfoBarWidth/100 + 0.5)
+	filled = min(max(filled, 0), sysinfoBarWidth)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", sysinfoBarWidth-filled)
+	switch {
+	case percent >= 90:
+		bar = theme.Levels[levelError].Render(bar)
+	case percent >= 75:
+		bar = theme.Levels[levelWarn].Render(bar)
+	}
+	return fmt.Sprintf("%s %3.0f%%", bar, percent)
+}
+
+// formatUptime shows a duration in days, hours and minutes
+func formatUptime(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+	}
+	return fmt.Sprintf("%dh %dm", hours, minutes)
+}
+
+// sysinfoPanel shows a sample; the drives are entries that open in the browser
+func sysinfoPanel(msg sysinfoMsg) ListPanel {
+	info := msg.Info
+	unknown := theme.Dim.Render("unknown")
+	row := func(name, value string) ListEntry {
+		if value == "" {
+			value = unknown
+		}
+		return ListEntry{Label: fmt.Sprintf("%-10s %s", name, value), Separator: true}
+	}
+
+	cpu := info.CPU
+	if cpu == "" {
+		cpu = "CPU"
+	}
+	cpu = fmt.Sprintf("%s (%d logical processors)", cpu, info.Cores)
+	load := unknown
+	if msg.CPU >= 0 {
+		load = usageBar(msg.CPU)
+	}
+	memory := unknown
+	if info.MemTotal > 0 {
+		used := info.MemTotal - info.MemAvailable
+		memory = fmt.Sprintf("%s  %s of %s", usageBar(float64(used)*100/float64(info.MemTotal)),
+			FormatSize(int64(used)), FormatSize(int64(info.MemTotal)))
+	}
+	uptime := ""
+	if info.Uptime > 0 {
+		uptime = formatUptime(info.Uptime)
+	}
+
+	entries := []ListEntry{
+		row("Host", info.Hostname),
+		row("OS", info.OS),
+		row("Uptime", uptime),
+		row("CPU", cpu),
+		row("CPU usage", load),
+		row("Memory", memory),
+		{Label: "", Separator: true},
+		{Label: theme.Title.Render("Drives"), Separator: true},
+	}
+	width := 10
+	for _, d := range msg.Drives {
+		width = max(width, visualLength(d.Root))
+	}
+	for _, d := range msg.Drives {
+		label := fmt.Sprintf("%-*s %s", width, d.Root, theme.Dim.Render(fmt.Sprintf("Error: %v", d.Err)))
+		if d.Err == nil && d.Total > 0 {
+			used := d.Total - d.Available
+			label = fmt.Sprintf("%-*s %s  %s free of %s", width, d.Root,
+				usageBar(float64(used)*100/float64(d.Total)), FormatSize(int64(d.Available)), FormatSize(int64(d.Total)))
+		}
+		entries = append(entries, ListEntry{Label: label, Data: d, Msg: openPathMsg{Path: d.Root}})
+	}
+
+	panel := NewListPanel(sysinfoTitle, entries)
+	panel.Subtitle = fmt.Sprintf("Refreshed every %v at %s", sysinfoInterval, time.Now().Format("15:04:05"))
+	return panel
+}
+
+// updateSysinfo opens or refreshes the system information panel; refreshes
+// stop once their panel is closed
+func (m Model) updateSysinfo(msg sysinfoMsg) (tea.Model, tea.Cmd) {
+	panel := sysinfoPanel(msg)
+	if msg.List == nil {
+		m.setStatus("")
+		m.openList(panel)
+		msg.List = m.List
+		return m, sysinfoTickCmd(msg)
+	}
+	if m.Mode != ListMode || m.List != msg.List {
+		return m, nil
+	}
+	// Refresh in place so the pointer identifies the panel
+	panel.Width, panel.Height = m.List.Width, m.List.Height
+	panel.SetCursor(m.List.Cursor)
+	panel.StatusMessage = m.List.StatusMessage
+	*m.List = panel
+	return m, sysinfoTickCmd(msg)
+}
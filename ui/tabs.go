@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// browserTab is the browser state of a tab that is not shown. Like panes, the
+// shown tab lives in the Model's own fields.
+type browserTab struct {
+	pane        browserPane
+	otherPane   browserPane
+	dualPane    bool
+	rightActive bool
+	jumps       []jumpLocation
+	jumpPos     int
+	resorted    bool // Whether the sort order changed while the tab was hidden
+}
+
+// saveTab returns the browser state of the shown tab
+func (m *Model) saveTab() browserTab {
+	return browserTab{
+		pane:        m.savePane(),
+		otherPane:   m.otherPane,
+		dualPane:    m.DualPane,
+		rightActive: m.rightActive,
+		jumps:       m.jumps,
+		jumpPos:     m.jumpPos,
+	}
+}
+
+// restoreTab shows a saved tab
+func (m *Model) restoreTab(t browserTab) {
+	m.restorePane(t.pane)
+	m.otherPane = t.otherPane
+	m.DualPane = t.dualPane
+	m.rightActive = t.rightActive
+	m.jumps = t.jumps
+	m.jumpPos = t.jumpPos
+	m.paneSwitched = true
+	if t.resorted {
+		m.reloadDirectory()
+		if m.otherPane.CurrentPath != "" {
+			m.reloadOtherPane()
+		}
+	}
+}
+
+// newTab opens a tab on the current directory with the global options and
+// switches to it
+func (m *Model) newTab() {
+	if len(m.tabs) == 0 {
+		m.tabs = []browserTab{{}}
+	}
+	m.tabs[m.activeTab] = m.saveTab()
+
+	selected, _ := m.selectedItemPath()
+	m.Options = m.globalOptions
+	m.flat = flatView{}
+	m.groups = groupView{}
+	m.loadDirectory()
+	m.selectPath(selected)
+	m.otherPane = browserPane{}
+	m.DualPane = false
+	m.rightActive = false
+	m.jumps = nil
+	m.jumpPos = 0
+	m.lastDir = jumpLocation{}
+	m.history = dirHistory{}
+	m.paneSwitched = true
+
+	// Open it to the right of the current tab
+	m.activeTab++
+	m.tabs = append(m.tabs[:m.activeTab], append([]browserTab{{}}, m.tabs[m.activeTab:]...)...)
+}
+
+// closeTab closes the shown tab and shows the one to its right, or the last
+func (m *Model) closeTab() {
+	if len(m.tabs) < 2 {
+		m.StatusMessage = "Only one tab is open"
+		return
+	}
+	m.tabs = append(m.tabs[:m.activeTab], m.tabs[m.activeTab+1:]...)
+	m.activeTab = min(m.activeTab, len(m.tabs)-1)
+	m.restoreTab(m.tabs[m.activeTab])
+	if len(m.tabs) == 1 {
+		m.tabs = nil
+		m.activeTab = 0
+	}
+}
+
+// cycleTab shows the tab step places away, wrapping around
+func (m *Model) cycleTab(step int) {
+	if len(m.tabs) < 2 {
+		m.StatusMessage = "Only one tab is open (Ctrl+T opens another)"
+		return
+	}
+	m.tabs[m.activeTab] = m.saveTab()
+	m.activeTab = (m.activeTab + step + len(m.tabs)) % len(m.tabs)
+	m.restoreTab(m.tabs[m.activeTab])
+}
+
+// tabName is the label of a tab: the name of its directory
+func tabName(path string) string {
+	if name := filepath.Base(path); name != "." && name != string(filepath.Separator) && name != "" {
+		return name
+	}
+	return path
+}
+
+// renderTabs renders the tab bar, or nothing with a single tab
+func (m Model) renderTabs() string {
+	if len(m.tabs) < 2 {
+		return ""
+	}
+	labels := make([]string, len(m.tabs))
+	for i, t := range m.tabs {
+		path := t.pane.CurrentPath
+		if i == m.activeTab {
+			path = m.CurrentPath
+		}
+		label := fmt.Sprintf(" %d %s ", i+1, tabName(path))
+		if i == m.activeTab {
+			labels[i] = theme.ActiveTab.Render(label)
+		} else {
+			labels[i] = theme.Tab.Render(label)
+		}
+	}
+	bar := lipgloss.JoinHorizontal(lipgloss.Top, labels...)
+	return lipgloss.NewStyle().MaxWidth(max(m.Width, 1)).Render(strings.TrimRight(bar, " "))
+}
@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// themeNames returns the names of the themes, the default first
+func themeNames() []string {
+	names := make([]string, len(themes))
+	for i, t := range themes {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// setTheme makes the named theme the active one; an empty name picks the
+// default
+func setTheme(name string) error {
+	if name == "" {
+		name = themes[0].Name
+	}
+	for _, t := range themes {
+		if strings.EqualFold(t.Name, name) {
+			theme = newTheme(t.Name, t.Colors)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown theme %q (themes: %s)", name, strings.Join(themeNames(), ", "))
+}
+
+// completeTheme lists the themes whose names start with value, for Tab
+func completeTheme(value string) []string {
+	var names []string
+	for _, name := range themeNames() {
+		if strings.HasPrefix(name, strings.ToLower(value)) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// themeCommand handles :theme. Without a name it shows the active theme and
+// the others.
+func (m *Model) themeCommand(args []string) {
+	if len(args) == 0 {
+		m.StatusMessage = fmt.Sprintf("Theme: %s (themes: %s)", theme.Name, strings.Join(themeNames(), ", "))
+		return
+	}
+	if err := setTheme(args[0]); err != nil {
+		m.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	m.restyleViewers()
+	m.StatusMessage = fmt.Sprintf("Theme: %s", theme.Name)
+}
+
+// restyleViewers draws every viewer kept in memory again after the theme,
+// the colorscheme or the calibration changed: the open buffers, the one a
+// shown viewer was opened from, and the siblings loaded ahead for ]f / [f
+func (m *Model) restyleViewers() {
+	viewers := m.buffers()
+	for _, fv := range []*FileViewer{m.FileViewer, m.parentViewer} {
+		if fv != nil && !slices.Contains(viewers, fv) {
+			viewers = append(viewers, fv)
+		}
+	}
+	for _, p := range m.prefetched {
+		if p.Viewer != nil && !slices.Contains(viewers, p.Viewer) {
+			viewers = append(viewers, p.Viewer)
+		}
+	}
+	for _, fv := range viewers {
+		fv.restyle()
+	}
+}
+
+// restyle renders the views whose colors are baked into their lines again
+// after the theme or colorscheme changed: rendered Markdown with its code
+// blocks, JSON trees and CSV tables. Highlighted and log lines keep only
+// their token types and take the colors as they are drawn.
+func (fv *FileViewer) restyle() {
+	switch {
+	case fv.markdown != nil:
+		line := fv.markdown.sourceLine(fv.lineAt(fv.ScrollPos))
+		fv.replaceContent(fv.markdown.Source, func() int { return fv.markdown.renderedLine(line) })
+	case fv.tree != nil:
+		fv.Content, fv.styledLines = fv.tree.render()
+	case fv.table != nil:
+		fv.showTable()
+	}
+}
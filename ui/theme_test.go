@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"testing"
+)
+
+// TestThemeSwitchRestyles switches themes with a Go file and a Markdown file
+// open, and checks both are drawn as if they had been opened in the new theme
+func TestThemeSwitchRestyles(t *testing.T) {
+	defer setTheme("")
+	const source = "package main\n\n// main runs\nfunc main() { println(\"hi\", 42) }\n"
+	const markdown = "# Notes\n\nSome `code` here.\n\n```go\nfunc main() { println(\"hi\", 42) }\n```\n"
+	open := func() (*FileViewer, *FileViewer) {
+		code, notes := NewContentViewer("main.go", source), NewContentViewer("notes.md", markdown)
+		for _, fv := range []*FileViewer{&code, &notes} {
+			fv.Width, fv.Height = 80, 24
+			fv.fitMarkdown()
+			if fv.highlighter != nil {
+				start, spans, _ := fv.highlighter.nextChunk(len(fv.Content) + 1)
+				fv.mergeHighlighted(start, spans)
+			}
+		}
+		return &code, &notes
+	}
+
+	for _, name := range themeNames()[1:] {
+		if err := setTheme(themeNames()[0]); err != nil {
+			t.Fatal(err)
+		}
+		code, notes := open()
+		m := Model{Mode: FileViewMode, FileViewer: code, parentViewer: notes}
+		m.themeCommand([]string{name})
+		freshCode, freshNotes := open()
+		for _, pair := range [][2]*FileViewer{{code, freshCode}, {notes, freshNotes}} {
+			got, want := pair[0], pair[1]
+			for i := range want.Content {
+				if got.renderLine(i) != want.renderLine(i) {
+					t.Errorf("%s in %s, line %d: drawn as %q, want %q", want.FileName, name, i+1, got.renderLine(i), want.renderLine(i))
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/ops"
+	"github.com/HolyStarGazer/windows-tui-go/picture"
+	"github.com/HolyStarGazer/windows-tui-go/types"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// thumbCols and thumbRows are the size in cells of a thumbnail, and a tile
+// adds the name under it and a gap around it
+const (
+	thumbCols  = 16
+	thumbRows  = 6
+	tileWidth  = thumbCols + 2
+	tileHeight = thumbRows + 2
+)
+
+// thumbnail is a cached drawing of an image, valid while its modification
+// time matches
+type thumbnail struct {
+	ModTime time.Time
+	Lines   []string // thumbRows lines of thumbCols cells
+	Err     error
+	Done    bool
+}
+
+// thumbnailsMsg delivers drawn thumbnails, keyed by path
+type thumbnailsMsg struct {
+	Thumbs map[string]thumbnail
+}
+
+// thumbnailsCmd decodes and draws images in the background
+func thumbnailsCmd(images map[string]time.Time) tea.Cmd {
+	return func() tea.Msg {
+		paths := make([]string, 0, len(images))
+		for path := range images {
+			paths = append(paths, path)
+		}
+		drawn := make([]thumbnail, len(paths))
+		ops.Parallel(len(paths), func(i int) {
+			drawn[i] = drawThumbnail(paths[i])
+			drawn[i].ModTime = images[paths[i]]
+		})
+
+		thumbs := make(map[string]thumbnail, len(paths))
+		for i, path := range paths {
+			thumbs[path] = drawn[i]
+		}
+		return thumbnailsMsg{Thumbs: thumbs}
+	}
+}
+
+// drawThumbnail draws an image as large as fits in a thumbnail, centered
+func drawThumbnail(path string) thumbnail {
+	data, err := vfs.ReadFile(vfs.Default, path)
+	if err != nil {
+		return thumbnail{Err: err, Done: true}
+	}
+	img, err := picture.Decode(data)
+	if err != nil {
+		return thumbnail{Err: err, Done: true}
+	}
+	cols, rows := picture.Fit(img, thumbCols, thumbRows)
+	left := strings.Repeat(" ", (thumbCols-cols)/2)
+	right := strings.Repeat(" ", thumbCols-cols-len(left))
+	lines := make([]string, 0, thumbRows)
+	for range (thumbRows - rows) / 2 {
+		lines = append(lines, strings.Repeat(" ", thumbCols))
+	}
+	for _, line := range picture.Draw(img, cols, rows) {
+		lines = append(lines, left+line+right)
+	}
+	for len(lines) < thumbRows {
+		lines = append(lines, strings.Repeat(" ", thumbCols))
+	}
+	return thumbnail{Lines: lines, Done: true}
+}
+
+// isImageDir reports whether the listed files are mostly images, at least
+// half of them
+func (m *Model) isImageDir() bool {
+	files, images := 0, 0
+	for _, item := range m.Items {
+		if item.IsDir {
+			continue
+		}
+		files++
+		if picture.IsImage(item.Name) {
+			images++
+		}
+	}
+	return images > 0 && images*2 >= files
+}
+
+// showsGrid reports whether the listing is shown as a thumbnail grid: with
+// the thumbs option on, in a directory of mostly images
+func (m *Model) showsGrid() bool {
+	return m.Options.Thumbs && m.isImageDir()
+}
+
+// listWidth returns the width the listing of the active pane is drawn in
+func (m *Model) listWidth() int {
+	if m.DualPane || m.previewShown() {
+		// The pane's box takes a column on each side
+		return max(m.Width/2-2, 10) - 2
+	}
+	return m.Width
+}
+
+// gridColumns returns the number of tiles in a row of a grid width columns
+// wide
+func gridColumns(width int) int {
+	return max(width/tileWidth, 1)
+}
+
+// gridRows returns the number of tile rows that fit in maxVisible lines
+func gridRows(maxVisible int) int {
+	return max(maxVisible/tileHeight, 1)
+}
+
+// startThumbnails requests thumbnails for the images on screen, and a screen
+// of them either side, that have none, when the grid is shown
+func (m *Model) startThumbnails() tea.Cmd {
+	if !m.showsGrid() {
+		return nil
+	}
+	cols := gridColumns(m.listWidth())
+	screen := gridRows(m.Height) * cols
+	start := max(m.Cursor/cols*cols-screen, 0)
+	end := min(m.Cursor/cols*cols+2*screen, len(m.Items))
+
+	images := make(map[string]time.Time)
+	for _, item := range m.Items[start:end] {
+		if item.IsDir || !picture.IsImage(item.Name) {
+			continue
+		}
+		if thumb, ok := m.thumbnails[item.Path]; ok && thumb.ModTime.Equal(item.ModTime) {
+			continue
+		}
+		images[item.Path] = item.ModTime
+	}
+	if len(images) == 0 {
+		return nil
+	}
+
+	// Record the requests so they are not repeated while drawing
+	if m.thumbnails == nil {
+		m.thumbnails = make(map[string]thumbnail)
+	}
+	for path, modTime := range images {
+		m.thumbnails[path] = thumbnail{ModTime: modTime}
+	}
+	return thumbnailsCmd(images)
+}
+
+// updateThumbnails caches drawn thumbnails
+func (m *Model) updateThumbnails(msg thumbnailsMsg) {
+	for path, thumb := range msg.Thumbs {
+		m.thumbnails[path] = thumb
+	}
+}
+
+// gridKey moves the cursor through the grid with the arrow keys and opens
+// images with Enter, reporting false for keys the browser handles as usual.
+// Keys are translated by the keymap, but for Left and Right.
+func (m *Model) gridKey(key string) (tea.Cmd, bool) {
+	cols := gridColumns(m.listWidth())
+	switch key {
+	case "left":
+		m.Cursor = max(m.Cursor-1, 0)
+	case "right":
+		m.Cursor = min(m.Cursor+1, len(m.Items)-1)
+	case "up":
+		if m.Cursor >= cols {
+			m.Cursor -= cols
+		}
+	case "down":
+		if m.Cursor+cols < len(m.Items) {
+			m.Cursor += cols
+		} else if m.Cursor/cols < (len(m.Items)-1)/cols {
+			// Onto the last, shorter row
+			m.Cursor = len(m.Items) - 1
+		}
+	case "enter":
+		if m.Cursor >= len(m.Items) || !picture.IsImage(m.Items[m.Cursor].Name) || m.Items[m.Cursor].IsDir {
+			return nil, false
+		}
+		return m.openImagePreview(m.Items[m.Cursor].Path), true
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+// renderGrid renders the listing as rows of tiles that fit in width columns
+// and maxVisible lines, keeping the cursor's row in view
+func (m Model) renderGrid(width, maxVisible int, focused bool) []string {
+	cols := gridColumns(width)
+	rows := (len(m.Items) + cols - 1) / cols
+	start, end := visibleWindow(rows, m.Cursor/cols, gridRows(maxVisible))
+
+	var lines []string
+	for row := start; row < end; row++ {
+		tiles := m.Items[row*cols : min((row+1)*cols, len(m.Items))]
+		for y := range thumbRows {
+			var b strings.Builder
+			for _, item := range tiles {
+				b.WriteString(" " + m.tileLine(item, y) + " ")
+			}
+			lines = append(lines, b.String())
+		}
+		var b strings.Builder
+		for i := range tiles {
+			b.WriteString(m.tileName(row*cols+i, focused))
+		}
+		lines = append(lines, b.String(), "")
+	}
+	return lines
+}
+
+// tileLine renders line y of the picture of a tile: the thumbnail of an
+// image, or the item's icon
+func (m Model) tileLine(item types.FileItem, y int) string {
+	thumb, ok := m.thumbnails[item.Path]
+	if ok && thumb.Done && thumb.Err == nil && y < len(thumb.Lines) {
+		return thumb.Lines[y]
+	}
+	if y != thumbRows/2 {
+		return strings.Repeat(" ", thumbCols)
+	}
+	label := item.Icon()
+	switch {
+	case ok && thumb.Err != nil:
+		label = "⚠ unreadable"
+	case picture.IsImage(item.Name) && !item.IsDir:
+		label = "…"
+	}
+	pad := thumbCols - visualLength(label)
+	return theme.Dim.Render(strings.Repeat(" ", pad/2) + label + strings.Repeat(" ", pad-pad/2))
+}
+
+// tileName renders the name line of tile i, highlighted under the cursor
+func (m Model) tileName(i int, focused bool) string {
+	item := m.Items[i]
+	mark := " "
+	if m.Marked[item.Path] {
+		mark = "*"
+	}
+	name := item.DisplayName()
+	if visualLength(name) > thumbCols {
+		name = truncateAtVisualWidth(name, thumbCols-1) + "…"
+	}
+	name += strings.Repeat(" ", max(thumbCols-visualLength(name), 0))
+	style := theme.File
+	if item.IsDir {
+		style = theme.Directory
+	}
+	if i == m.Cursor && focused {
+		return theme.Selected.Render(mark+name) + " "
+	}
+	return mark + style.Render(name) + " "
+}
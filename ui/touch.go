@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/ops"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// touchMsg sets the times of paths
+type touchMsg struct {
+	Paths   []string
+	Time    time.Time
+	Created bool // Set the creation time too
+}
+
+// parseTouchTime parses the time of :touch -t: "now", an offset from now
+// ("-2h", "+30m", "-7d"), a local date and time ("2024-01-02 14:00") or
+// touch's [[CC]YY]MMDDhhmm[.ss]
+func parseTouchTime(arg string, now time.Time) (time.Time, error) {
+	arg = strings.TrimSpace(arg)
+	if arg == "now" {
+		return now, nil
+	}
+	if strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "+") {
+		if days, ok := strings.CutSuffix(arg, "d"); ok {
+			if n, err := strconv.Atoi(days); err == nil {
+				return now.AddDate(0, 0, n), nil
+			}
+		}
+		if d, err := time.ParseDuration(arg); err == nil {
+			return now.Add(d), nil
+		}
+	}
+	layouts := []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, arg, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, ok := parsePOSIXTouchTime(arg, now); ok {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time '%s' (use now, -2h, -7d, YYYY-MM-DD [HH:MM[:SS]] or [[CC]YY]MMDDhhmm[.ss])", arg)
+}
+
+// parsePOSIXTouchTime parses touch -t's [[CC]YY]MMDDhhmm[.ss]. Without a
+// century, years 69 to 99 are in the 1900s; without a year it is this year's.
+func parsePOSIXTouchTime(arg string, now time.Time) (time.Time, bool) {
+	digits, seconds, hasSeconds := strings.Cut(arg, ".")
+	layout := map[int]string{8: "01021504", 10: "0601021504", 12: "200601021504"}[len(digits)]
+	if layout == "" || hasSeconds && len(seconds) != 2 {
+		return time.Time{}, false
+	}
+	noYear := len(digits) == 8
+	if hasSeconds {
+		digits, layout = digits+seconds, layout+"05"
+	}
+	t, err := time.ParseInLocation(layout, digits, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if noYear {
+		t = t.AddDate(now.Year()-t.Year(), 0, 0)
+	}
+	return t, true
+}
+
+// touchCommand sets the times of the marked items, or the one under the
+// cursor (:touch [-b] [-t <time>]). Without a time it opens a date picker at
+// the item's modification time; -b sets the creation time too.
+func (m *Model) touchCommand(args []string) tea.Cmd {
+	paths := m.operandPaths()
+	if len(paths) == 0 {
+		m.StatusMessage = "Nothing to touch"
+		return nil
+	}
+
+	created := len(args) > 0 && args[0] == "-b"
+	if created {
+		args = args[1:]
+	}
+	if len(args) > 0 && args[0] == "-t" {
+		args = args[1:]
+	}
+	if len(args) > 0 {
+		t, err := parseTouchTime(strings.Join(args, " "), time.Now())
+		if err != nil {
+			m.StatusMessage = fmt.Sprintf("Error: %v", err)
+			return nil
+		}
+		return func() tea.Msg { return touchMsg{Paths: paths, Time: t, Created: created} }
+	}
+
+	start := time.Now()
+	if m.Cursor < len(m.Items) && m.Items[m.Cursor].Name != ".." {
+		start = m.Items[m.Cursor].ModTime
+	}
+	which := "modification"
+	if created {
+		which = "modification and creation"
+	}
+	return func() tea.Msg {
+		return openDatePickerMsg{DatePicker{
+			Label: fmt.Sprintf("Set the %s time of %s to: ", which, describePaths(paths)),
+			Time:  start.Truncate(time.Second),
+			Submit: func(t time.Time) tea.Msg {
+				return touchMsg{Paths: paths, Time: t, Created: created}
+			},
+		}}
+	}
+}
+
+// touchFiles sets the times, stopping at the first path that fails
+func (m *Model) touchFiles(msg touchMsg) {
+	done := 0
+	var err error
+	for _, path := range msg.Paths {
+		if err = ops.Touch(path, msg.Time, msg.Created); err != nil {
+			break
+		}
+		done++
+	}
+	m.reloadDirectory()
+	if m.DualPane {
+		m.reloadOtherPane()
+	}
+	stamp := msg.Time.Format(datePickerLayout)
+	switch {
+	case err != nil && done > 0:
+		m.setStatus(fmt.Sprintf("Error: %v (set the time of %d items first)", err, done))
+	case err != nil:
+		m.setStatus(fmt.Sprintf("Error: %v", err))
+	case msg.Created:
+		m.setStatus(fmt.Sprintf("Set the modification and creation time of %s to %s", describePaths(msg.Paths), stamp))
+	default:
+		m.setStatus(fmt.Sprintf("Set the modification time of %s to %s", describePaths(msg.Paths), stamp))
+	}
+}
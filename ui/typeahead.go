@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// typeAheadTimeout is how long after the last key typed characters keep
+// extending the type-ahead prefix
+const typeAheadTimeout = time.Second
+
+// typeAheadExpiredMsg ends a type-ahead prefix unless more keys were typed since
+type typeAheadExpiredMsg struct {
+	Seq int
+}
+
+// typeAhead extends the type-ahead prefix and moves the cursor to the first item
+// whose name starts with it
+func (m *Model) typeAhead(text string) tea.Cmd {
+	m.typeAheadPrefix += strings.ToLower(text)
+	m.typeAheadSeq++
+
+	// A new prefix starts after the cursor so repeating a letter cycles through
+	// items starting with it, like Explorer
+	prefix := m.typeAheadPrefix
+	from := m.Cursor
+	if repeatsOneLetter(prefix) && !m.itemHasPrefix(m.Cursor, prefix) {
+		prefix = prefix[:1]
+		from = m.Cursor + 1
+	} else if len(prefix) == len(text) {
+		from = m.Cursor + 1
+	}
+
+	if i, ok := m.findPrefix(prefix, from); ok {
+		m.Cursor = i
+		m.StatusMessage = "Jump: " + m.typeAheadPrefix
+	} else {
+		m.StatusMessage = "Jump: " + m.typeAheadPrefix + " (no match)"
+	}
+
+	seq := m.typeAheadSeq
+	return tea.Tick(typeAheadTimeout, func(time.Time) tea.Msg {
+		return typeAheadExpiredMsg{Seq: seq}
+	})
+}
+
+// endTypeAhead clears the prefix when its timeout passes without more typing
+func (m *Model) endTypeAhead(msg typeAheadExpiredMsg) {
+	if msg.Seq != m.typeAheadSeq || m.typeAheadPrefix == "" {
+		return
+	}
+	m.typeAheadPrefix = ""
+	if strings.HasPrefix(m.StatusMessage, "Jump: ") {
+		m.StatusMessage = ""
+	}
+}
+
+// findPrefix returns the first item at or after from (wrapping around) whose
+// name starts with prefix, ignoring case
+func (m *Model) findPrefix(prefix string, from int) (int, bool) {
+	for n := 0; n < len(m.Items); n++ {
+		i := (from + n) % len(m.Items)
+		if m.itemHasPrefix(i, prefix) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// itemHasPrefix reports whether item i's name starts with prefix, ignoring
+// case. Items of collapsed groups are not shown, so they never match.
+func (m *Model) itemHasPrefix(i int, prefix string) bool {
+	return i >= 0 && i < len(m.Items) && strings.HasPrefix(strings.ToLower(m.Items[i].Name), prefix) && !m.isCollapsedAt(i)
+}
+
+// repeatsOneLetter reports whether s is one character typed more than once, e.g. "mmm"
+func repeatsOneLetter(s string) bool {
+	return len(s) > 1 && strings.Count(s, s[:1]) == len(s)
+}
@@ -1,15 +1,19 @@
 package ui
 
 import (
-	"bytes"
 	"fmt"
-	"os"
+	"io"
+	"io/fs"
+	"math"
+	"regexp"
 	"strings"
+	"time"
 
-	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	"github.com/alecthomas/chroma/v2"
 	"github.com/alecthomas/chroma/v2/lexers"
-	"github.com/alecthomas/chroma/v2/styles"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/rivo/uniseg"
 )
 
 // ViewMode represents the current mode of the application
@@ -18,6 +22,8 @@ type ViewMode int
 const (
 	BrowseMode ViewMode = iota
 	FileViewMode
+	ListMode
+	OutputMode
 )
 
 // FileViewer handles file content viewing
@@ -25,7 +31,6 @@ type FileViewer struct {
 	FilePath           string
 	FileName           string
 	Content            []string // Lines of the file
-	HighlightedContent []string // Lines with syntax highlighting
 	ScrollPos          int      // Current scroll position
 	Width              int
 	Height             int
@@ -38,6 +43,64 @@ type FileViewer struct {
 	SearchTerm         string // Current search term
 	SearchMatches      []int  // Line numbers with matches
 	CurrentMatchIndex  int    // Index of the current match
+	SearchRegex        bool   // Search terms are regular expressions (:set regex)
+	Language           string // Forced syntax highlighting language (empty to auto-detect)
+	Sections           []int  // Line numbers of section headings (man pages, help output)
+	PendingKey         string // First key of a two-key sequence such as ]]
+	Following          bool   // Auto-scroll as new content arrives
+	Live               bool   // Content is a live stream or followed file; highlight rules apply
+	HighlightRules     []lineRule
+	LogMode            bool        // Color lines by detected log level
+	Filter             *lineFilter // Filter hiding non-matching lines
+	FilterEnabled      bool        // Whether Filter is currently applied
+	ElapsedMode        int         // Elapsed-time column: off, since start, since previous
+	GapSeconds         int         // Pause length that ]g / [g treat as a gap
+	Bookmarks          []Bookmark  // Annotated lines, sorted by line
+	Spell              bool        // Underline misspelled words
+	RawMarkdown        bool        // Show Markdown files as their source instead of rendered (:set raw)
+	JSONTree           bool        // Show JSON files as a foldable tree (:set tree)
+	RawTable           bool        // Show CSV and TSV files as their text instead of a table (:set raw)
+
+	stream           <-chan string  // Lines still arriving from a streamed source
+	readOffset       int64          // Bytes of the file read so far
+	part             filePart       // Part of a large file shown, if not all of it
+	window           *lineWindow    // Lines loaded of a file over max_view_size, which is read as it is scrolled
+	polling          bool           // Whether a follow poll is scheduled
+	followPaused     bool           // Following was stopped by scrolling up, and G resumes it
+	watching         bool           // Whether a check for deletion or renaming is scheduled
+	fileInfo         fs.FileInfo    // The viewed file when it was loaded, to find it if renamed
+	missingSince     time.Time      // When a followed file was first found missing
+	gone             *goneFile      // Set once the viewed file was deleted or renamed
+	hex              bool           // Whether the content is shown as a hex dump
+	forceText        bool           // Show the content as text even if it looks binary (:hex)
+	raw              []byte         // Bytes shown in the hex dump
+	buffer           int            // Buffer number, given when first shown
+	overstrike       bool           // Content uses overstrike formatting instead of syntax highlighting
+	levels           []logLevel     // Detected level of each line (log mode)
+	times            []time.Time    // Leading timestamp of each line (zero if none)
+	filtered         []int          // Lines visible through the filter
+	sources          []logSource    // Files shown in a merged log view
+	lineSources      []int          // Index into sources for each line (merged logs)
+	reading          bool           // Whether the next batch of streamed lines is awaited
+	pendingCmd       tea.Cmd        // Command for the model to run after this update
+	highlighter      *highlighter   // Background highlighting of the content, if running
+	highlighting     bool           // Whether a background chunk is being highlighted
+	highlightLimit   int            // Lines the background highlighter may still replace
+	highlightedLines int            // Lines highlighted so far, for progress
+	fileType         string         // Name of the lexer used for highlighting
+	configLanguage   string         // Language from the filetypes setting, used by :set filetype=auto
+	spans            [][]span       // Syntax highlighting of each line, formatted when shown
+	styledLines      []string       // Overstrike-formatted lines (man pages, help output) or rendered Markdown
+	markdown         *markdownDoc   // Markdown file shown rendered, nil when shown as text
+	tree             *jsonTree      // JSON file shown as a tree, nil when shown as text
+	table            *csvTable      // CSV or TSV file shown as a table, nil when shown as text
+	tableHeader      string         // Styled header row of the table, kept above the rows
+	searchRe         *regexp.Regexp // Compiled search, nil when there is none
+	spelling         *spellCache    // Spell check of the lines checked so far, if checking
+	spellWord        string         // Misspelled word last jumped to, for :spellgood
+	links            *linkCache     // URLs and paths of the lines looked at so far
+	selectedLink     *linkRef       // Link picked with ]u/[u, opened with o
+	gFrom            *scrollMark    // Position before g, which gf returns to
 }
 
 // NewFileViewer creates a new file viewer for the given file path
@@ -54,11 +117,41 @@ func NewFileViewer(filePath, fileName string) FileViewer {
 		SearchTerm:         "",
 		SearchMatches:      []int{},
 		CurrentMatchIndex:  -1,
+		Spell:              spellCheck && isSpellFile(fileName),
 	}
 	fv.loadFile()
+	if isLogFile(fileName) {
+		fv.setLogMode(true)
+	}
+	return fv
+}
+
+// NewContentViewer creates a file viewer for in-memory content such as a commit diff.
+// The name is used for the title and for picking a syntax highlighter.
+func NewContentViewer(name, content string) FileViewer {
+	fv := FileViewer{
+		FileName:           name,
+		UseSyntaxHighlight: true,
+		SearchMatches:      []int{},
+		CurrentMatchIndex:  -1,
+	}
+	fv.setContent(content)
 	return fv
 }
 
+// SetLanguage forces the syntax highlighting language and re-highlights the content
+func (fv *FileViewer) SetLanguage(lang string) error {
+	if lang != "" && lexers.Get(lang) == nil {
+		return fmt.Errorf("unknown language '%s'", lang)
+	}
+	fv.Language = lang
+	fv.spans = nil
+	if fv.UseSyntaxHighlight && !fv.overstrike && fv.markdown == nil && fv.tree == nil && fv.table == nil {
+		fv.highlightContent(strings.Join(fv.Content, "\n"))
+	}
+	return nil
+}
+
 // executeCommand parses and executes a command
 func (fv *FileViewer) executeCommand(cmd string) {
 	cmd = strings.TrimSpace(cmd)
@@ -74,6 +167,22 @@ func (fv *FileViewer) executeCommand(cmd string) {
 		return
 	}
 
+	// Line numbers and percentages, such as :123 and :50%
+	if fv.gotoTarget(cmd) {
+		return
+	}
+
+	// Commands given a line range, such as :100,200 yank
+	r, rest, ranged, err := fv.parseRange(cmd)
+	if err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	if ranged {
+		fv.rangeCommand(r, rest)
+		return
+	}
+
 	// Split command into parts
 	parts := strings.Fields(cmd)
 	if len(parts) == 0 {
@@ -83,6 +192,14 @@ func (fv *FileViewer) executeCommand(cmd string) {
 	command := parts[0]
 
 	switch command {
+	case "re", "regex":
+		// Regular expression search, whatever :set regex says
+		if len(parts) < 2 {
+			fv.StatusMessage = "Usage: :re <pattern>"
+			return
+		}
+		fv.search(strings.TrimSpace(strings.TrimPrefix(cmd, command)), true)
+
 	case "/", "search":
 		// Search command
 		if len(parts) < 2 {
@@ -92,6 +209,10 @@ func (fv *FileViewer) executeCommand(cmd string) {
 		searchTerm := strings.Join(parts[1:], " ")
 		fv.performSearch(searchTerm)
 
+	case "col":
+		// Sort or scroll the columns of a table
+		fv.columnCommand(parts[1:])
+
 	case "set":
 		// Set options
 		if len(parts) < 2 {
@@ -99,26 +220,69 @@ func (fv *FileViewer) executeCommand(cmd string) {
 			return
 		}
 		option := parts[1]
+		if name, value, ok := strings.Cut(option, "="); ok && (name == "filetype" || name == "ft") {
+			fv.setFiletype(value)
+			return
+		}
+		if name, value, ok := strings.Cut(option, "="); ok && (name == "colorscheme" || name == "cs") {
+			fv.setColorschemeOption(value)
+			return
+		}
 
 		switch option {
 		case "wrap":
 			fv.WrapLines = true
+			fv.fitMarkdown()
 			fv.StatusMessage = "Line wrapping enabled"
 		case "nowrap":
 			fv.WrapLines = false
+			fv.fitMarkdown()
 			fv.StatusMessage = "Line wrapping disabled"
+		case "raw", "noraw":
+			if isTable(fv.FileName) {
+				fv.setRawTable(option == "raw")
+			} else {
+				fv.setRawMarkdown(option == "raw")
+			}
+		case "tree":
+			fv.setJSONTree(true)
+		case "notree":
+			fv.setJSONTree(false)
 		case "syntax":
 			fv.UseSyntaxHighlight = true
 			fv.StatusMessage = "Syntax highlighting enabled"
 		case "nosyntax":
 			fv.UseSyntaxHighlight = false
 			fv.StatusMessage = "Syntax highlighting disabled"
+		case "filetype", "ft":
+			if fv.Language != "" {
+				fv.StatusMessage = fmt.Sprintf("Filetype: %s (forced)", fv.Language)
+			} else {
+				fv.StatusMessage = fv.detectedFiletype()
+			}
+		case "colorscheme", "cs":
+			fv.setColorschemeOption(strings.Join(parts[2:], " "))
+		case "log":
+			fv.setLogMode(true)
+			fv.StatusMessage = "Log mode enabled"
+		case "nolog":
+			fv.setLogMode(false)
+			fv.StatusMessage = "Log mode disabled"
+		case "spell":
+			fv.setSpell(true)
+		case "regex":
+			fv.setRegex(true)
+		case "noregex":
+			fv.setRegex(false)
+		case "nospell":
+			fv.setSpell(false)
 		default:
 			fv.StatusMessage = fmt.Sprintf("Unknown option '%s'", option)
 		}
 
 	case "wrap":
 		fv.WrapLines = !fv.WrapLines
+		fv.fitMarkdown()
 		if fv.WrapLines {
 			fv.StatusMessage = "Line wrapping enabled"
 		} else {
@@ -133,8 +297,134 @@ func (fv *FileViewer) executeCommand(cmd string) {
 			fv.StatusMessage = "Syntax highlighting disabled"
 		}
 
+	case "follow":
+		fv.toggleFollow()
+
+	case "hex":
+		fv.toggleHex()
+
+	case "ls", "buffers":
+		fv.pendingCmd = func() tea.Msg { return listBuffersMsg{} }
+
+	case "bn", "bnext":
+		fv.pendingCmd = func() tea.Msg { return cycleBufferMsg{Step: 1} }
+
+	case "bp", "bprev", "bprevious":
+		fv.pendingCmd = func() tea.Msg { return cycleBufferMsg{Step: -1} }
+
+	case "goto":
+		if len(parts) < 2 {
+			fv.StatusMessage = "Usage: :goto <line>, :goto <percent>%, :goto <HH:MM[:SS]> or :goto <YYYY-MM-DD HH:MM>"
+			return
+		}
+		if !fv.gotoTarget(parts[1]) {
+			fv.gotoTime(strings.Join(parts[1:], " "))
+		}
+
+	case "gap":
+		arg := ""
+		if len(parts) > 1 {
+			arg = parts[1]
+		}
+		fv.setGap(arg)
+
+	case "elapsed":
+		fv.cycleElapsed()
+
+	case "bm", "bookmark":
+		fv.addBookmark(parseNote(strings.TrimSpace(strings.TrimPrefix(cmd, command))))
+
+	case "bmdel":
+		if bm, ok := fv.deleteBookmark(fv.bookmarkAt(fv.lineAt(fv.ScrollPos))); ok {
+			fv.StatusMessage = fmt.Sprintf("Deleted bookmark on line %d", bm.Line+1)
+		} else {
+			fv.StatusMessage = "No bookmark on this line"
+		}
+
+	case "bms", "bookmarks":
+		fv.showBookmarks()
+
+	case "export":
+		if len(parts) < 3 {
+			fv.StatusMessage = "Usage: :export html|ansi <file>"
+			return
+		}
+		fv.exportView(parts[1], strings.Join(parts[2:], " "), fv.exportedLines())
+
+	case "spellgood":
+		fv.spellGood(parts[1:])
+
+	case "tail":
+		lines, err := parseTailLines(parts[1:])
+		switch {
+		case err != nil:
+			fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		case fv.FilePath == "":
+			fv.StatusMessage = "Only files can be reopened at their tail"
+		default:
+			path := fv.FilePath
+			fv.pendingCmd = func() tea.Msg { return openTailMsg{Path: path, Lines: lines} }
+		}
+
+	case "saveas":
+		if len(parts) < 2 {
+			fv.StatusMessage = "Usage: :saveas <file>"
+			return
+		}
+		fv.saveAs(strings.Join(parts[1:], " "))
+
+	case "writevisible":
+		if len(parts) < 2 {
+			fv.StatusMessage = "Usage: :writevisible <file>"
+			return
+		}
+		fv.writeVisible(strings.Join(parts[1:], " "))
+
+	case "screenshot":
+		if len(parts) < 2 {
+			fv.pendingCmd = screenshotPrompt
+		} else {
+			path := strings.Join(parts[1:], " ")
+			fv.pendingCmd = func() tea.Msg { return screenshotMsg{Path: path} }
+		}
+
+	case "diff":
+		if len(parts) != 2 || parts[1] != "clipboard" {
+			fv.StatusMessage = "Usage: :diff clipboard"
+			return
+		}
+		fv.StatusMessage = "Comparing with the clipboard..."
+		fv.pendingCmd = fv.clipboardDiffCmd()
+
+	case "bmexport":
+		if len(parts) < 2 {
+			fv.StatusMessage = "Usage: :bmexport <file>"
+			return
+		}
+		fv.exportBookmarks(strings.Join(parts[1:], " "))
+
+	case "filter":
+		// Without arguments, toggle the current filter
+		if len(parts) < 2 {
+			fv.toggleFilter()
+			return
+		}
+		expr := strings.Join(parts[1:], " ")
+		if expr == "off" || expr == "clear" {
+			fv.setFilter(nil)
+			fv.StatusMessage = "Filter cleared"
+			return
+		}
+		filter, err := parseFilter(expr)
+		if err != nil {
+			fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+			return
+		}
+		fv.setFilter(filter)
+		fv.StatusMessage = fmt.Sprintf("Showing %d of %d lines", len(fv.filtered), len(fv.Content))
+
 	case "help", "h":
-		fv.StatusMessage = "Commands: :set [wrap|nowrap] | :set [syntax|nosyntax] | :/ or :search <term> | :help"
+		fv.StatusMessage = "Commands: :follow | :hex | :ls | :bn | :bp | :filter [level>=warn|/re/|off] | :<line> | :<percent>% | :goto <line|time> | :gap [s] | :elapsed | :bm \"note\" | :bmdel | :bookmarks | :bmexport <file> | :export html|ansi <file> | :tail [lines] | :saveas <file> | :writevisible <file> | :<from>,<to> yank|write <file>|export html|ansi <file> | :diff clipboard | :screenshot [file] | :set [log|nolog] | :set [spell|nospell] | :spellgood [word] | :set [wrap|nowrap] | :set [raw|noraw] | :set [tree|notree] | :col sort <n> [desc] | :col <n> | :set [syntax|nosyntax] | :set filetype=<lang|auto> | :set colorscheme[=<name>|auto] | :/ or :search <term> | :re <pattern> | :set [regex|noregex] | :section <name> | :help"
 
 	case "n", "next":
 		fv.nextMatch()
@@ -145,39 +435,22 @@ func (fv *FileViewer) executeCommand(cmd string) {
 	case "clear", "clearsearch":
 		fv.performSearch("")
 
+	case "section", "sec":
+		if len(parts) < 2 {
+			fv.StatusMessage = "Usage: :section <name>"
+			return
+		}
+		fv.gotoSection(strings.Join(parts[1:], " "))
+
 	default:
 		fv.StatusMessage = fmt.Sprintf("Unknown command '%s' (try :help)", command)
 	}
 }
 
-// performSearch searches for a term in the file content
+// performSearch searches for a term in the file content, as a regular
+// expression if :set regex is on
 func (fv *FileViewer) performSearch(term string) {
-	if term == "" {
-		fv.SearchTerm = ""
-		fv.SearchMatches = []int{}
-		fv.CurrentMatchIndex = -1
-		fv.StatusMessage = "Search cleared"
-		return
-	}
-
-	fv.SearchTerm = strings.ToLower(term)
-	fv.SearchMatches = []int{}
-
-	// Search through content (case-insensitive)
-	for i, line := range fv.Content {
-		if strings.Contains(strings.ToLower(line), fv.SearchTerm) {
-			fv.SearchMatches = append(fv.SearchMatches, i)
-		}
-	}
-
-	if len(fv.SearchMatches) > 0 {
-		fv.CurrentMatchIndex = 0
-		fv.ScrollPos = fv.SearchMatches[0]
-		fv.StatusMessage = fmt.Sprintf("Found %d match(es) - n: next, N: prev", len(fv.SearchMatches))
-	} else {
-		fv.CurrentMatchIndex = -1
-		fv.StatusMessage = fmt.Sprintf("Pattern not found: %s", term)
-	}
+	fv.search(term, fv.SearchRegex)
 }
 
 // nextMatch jumps to the next search match
@@ -188,7 +461,7 @@ func (fv *FileViewer) nextMatch() {
 	}
 
 	fv.CurrentMatchIndex = (fv.CurrentMatchIndex + 1) % len(fv.SearchMatches)
-	fv.ScrollPos = fv.SearchMatches[fv.CurrentMatchIndex]
+	fv.ScrollPos = fv.rowOf(fv.SearchMatches[fv.CurrentMatchIndex])
 	fv.StatusMessage = fmt.Sprintf("Match %d of %d", fv.CurrentMatchIndex+1, len(fv.SearchMatches))
 }
 
@@ -203,100 +476,288 @@ func (fv *FileViewer) prevMatch() {
 	if fv.CurrentMatchIndex < 0 {
 		fv.CurrentMatchIndex = len(fv.SearchMatches) - 1
 	}
-	fv.ScrollPos = fv.SearchMatches[fv.CurrentMatchIndex]
+	fv.ScrollPos = fv.rowOf(fv.SearchMatches[fv.CurrentMatchIndex])
 	fv.StatusMessage = fmt.Sprintf("Match %d of %d", fv.CurrentMatchIndex+1, len(fv.SearchMatches))
 }
 
-// loadFile reads the file content into memory
+// loadFile reads the file content into memory. Files over maxViewSize are
+// read a window at a time instead.
 func (fv *FileViewer) loadFile() {
-	// Read file with size limit to prevent loading huge files
-	const maxFileSize = 10 * 1024 * 1024 // 10 MB limit
-
-	fileInfo, err := os.Stat(fv.FilePath)
+	fileInfo, err := vfs.Default.Stat(fv.FilePath)
 	if err != nil {
 		fv.Err = err
 		return
 	}
 
-	if fileInfo.Size() > maxFileSize {
-		fv.Err = fmt.Errorf("file too large (max 10MB)")
+	fv.fileInfo = fileInfo
+	if maxViewSize > 0 && fileInfo.Size() > maxViewSize {
+		fv.loadWindowed(fileInfo.Size())
 		return
 	}
 
-	data, err := os.ReadFile(fv.FilePath)
+	text, err := readText(fv.FilePath, fileInfo.Size())
 	if err != nil {
 		fv.Err = err
 		return
 	}
 
-	// Split into lines - handle both Windows (\r\n) and Unix (\n) line endings
-	content := string(data)
-	// Normalize line endings to \n
-	content = strings.ReplaceAll(content, "\r\n", "\n")
-	// Remove any remaining \r (carriage return) characters
-	content = strings.ReplaceAll(content, "\r", "")
-	// Convert tabs to spaces BEFORE highlighting for consistent display
-	content = strings.ReplaceAll(content, "\t", "    ")
+	fv.readOffset = int64(len(text))
+	fv.setContent(text)
+}
+
+// readText reads a whole file as a string, straight into a buffer of its
+// size, so the text is not copied again
+func readText(path string, size int64) (string, error) {
+	file, err := vfs.Default.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	var b strings.Builder
+	b.Grow(int(size))
+	if _, err := io.Copy(&b, file); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// textNormalizer drops carriage returns, which turns Windows (\r\n) line
+// endings into \n, and turns tabs into spaces before highlighting for
+// consistent display
+var textNormalizer = strings.NewReplacer("\r", "", "\t", "    ")
+
+// normalizeText prepares text for the viewer in a single pass. Text without
+// carriage returns or tabs is returned as it is, without a copy.
+func normalizeText(text string) string {
+	return textNormalizer.Replace(text)
+}
+
+// setContent splits raw text into display lines and applies highlighting
+func (fv *FileViewer) setContent(content string) {
+	fv.resetSpelling()
+	fv.resetLinks()
+
+	// Binary data is shown as a hex dump
+	if !fv.forceText && (fv.hex || isBinary(content)) {
+		fv.setHexContent([]byte(content))
+		return
+	}
+
+	// Show CSV and TSV files as tables unless their text was asked for; tabs and
+	// line breaks inside quotes are left to the CSV reader
+	fv.table = nil
+	if isTable(fv.FileName) && !fv.RawTable {
+		ok, err := fv.setTableContent(content)
+		if ok {
+			return
+		}
+		if err != nil {
+			fv.StatusMessage = fmt.Sprintf("Error: %v (showing the text)", err)
+		}
+	}
+
+	content = normalizeText(content)
+
+	// Render Markdown unless its source was asked for
+	fv.markdown = nil
+	if isMarkdown(fv.FileName) && !fv.RawMarkdown {
+		fv.setMarkdownContent(content)
+		return
+	}
+
+	// Show JSON as a tree when asked to, or as text if it does not parse
+	fv.tree = nil
+	if fv.JSONTree && isJSON(fv.FileName) {
+		err := fv.setTreeContent(content)
+		if err == nil {
+			return
+		}
+		fv.JSONTree = false
+		fv.StatusMessage = fmt.Sprintf("Error: %v (showing the text)", err)
+	}
+
+	// Render man page sources and overstrike-formatted help text
+	if isManPage(fv.FileName) {
+		content = renderRoff(content)
+	}
+	if hasOverstrike(content) {
+		fv.setOverstrikeContent(content)
+		return
+	}
+
+	// The lines are slices of content, so the text is held once
 	fv.Content = strings.Split(content, "\n")
 
 	// Optionally apply syntax highlighting
 	if fv.UseSyntaxHighlight {
-		fv.applySyntaxHighlighting(content)
+		fv.highlightContent(content)
+	}
+}
+
+// setOverstrikeContent converts overstrike bold/underline to ANSI and indexes sections
+func (fv *FileViewer) setOverstrikeContent(content string) {
+	fv.overstrike = true
+	lines := strings.Split(content, "\n")
+	fv.Content = make([]string, len(lines))
+	fv.styledLines = make([]string, len(lines))
+	for i, line := range lines {
+		fv.Content[i], fv.styledLines[i] = renderOverstrike(line)
+	}
+	fv.Sections = detectSections(fv.Content)
+}
+
+// jumpSection moves to the next (dir > 0) or previous (dir < 0) section heading
+func (fv *FileViewer) jumpSection(dir int) {
+	if len(fv.Sections) == 0 {
+		fv.StatusMessage = "No sections in this file"
+		return
+	}
+
+	current := fv.lineAt(fv.ScrollPos)
+	if dir > 0 {
+		for _, line := range fv.Sections {
+			if line > current {
+				fv.ScrollPos = fv.rowOf(line)
+				return
+			}
+		}
+		fv.StatusMessage = "Last section"
+		return
+	}
+
+	for i := len(fv.Sections) - 1; i >= 0; i-- {
+		if fv.Sections[i] < current {
+			fv.ScrollPos = fv.rowOf(fv.Sections[i])
+			return
+		}
+	}
+	fv.StatusMessage = "First section"
+}
+
+// gotoSection jumps to the first section heading starting with name (case-insensitive)
+func (fv *FileViewer) gotoSection(name string) {
+	name = strings.ToLower(name)
+	for _, line := range fv.Sections {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(fv.Content[line])), name) {
+			fv.ScrollPos = fv.rowOf(line)
+			return
+		}
 	}
+	fv.StatusMessage = fmt.Sprintf("Section not found: %s", name)
 }
 
-// applySyntaxHighlighting applies syntax highlighting to the file content
+// applySyntaxHighlighting applies syntax highlighting to the file content at once
 func (fv *FileViewer) applySyntaxHighlighting(content string) {
-	// Get lexer based on file extension
-	lexer := lexers.Match(fv.FileName)
+	h := newHighlighter(fv.lexerFor(content), content)
+
+	// Tokenize everything; lexers may add or drop a trailing line, so keep
+	// one entry per content line
+	_, lines, _ := h.nextChunk(math.MaxInt)
+	fv.spans = make([][]span, len(fv.Content))
+	copy(fv.spans, lines)
+}
+
+// lexerFor picks a lexer from the forced language, the file name, a shebang line,
+// then the content, and records its name for the header
+func (fv *FileViewer) lexerFor(content string) chroma.Lexer {
+	lexer := fv.knownLexer(content)
 	if lexer == nil {
-		// Fallback to analzing content
-		lexer = lexers.Analyse(content)
+		lexer = analyseLexer(content)
+	}
+	fv.fileType = lexerName(lexer)
+	return lexer
+}
+
+// knownLexer returns the lexer of the forced language, the file name or a
+// shebang line, or nil if the content has to be analysed
+func (fv *FileViewer) knownLexer(content string) chroma.Lexer {
+	var lexer chroma.Lexer
+	if fv.Language != "" {
+		lexer = lexers.Get(fv.Language)
 	}
 	if lexer == nil {
-		// If still no lexer found, use plaintext
-		lexer = lexers.Fallback
+		lexer = matchLexer(fv.FileName)
 	}
-
-	// Use a terminal-friendly style
-	style := styles.Get("monokai")
-	if style == nil {
-		style = styles.Fallback
+	if lexer == nil {
+		// Scripts without an extension usually name their interpreter
+		lexer = shebangLexer(content)
 	}
+	return lexer
+}
 
-	// Create a terminal formatter with 16 colors for better compatibility
-	formatter := formatters.Get("terminal16m")
-	if formatter == nil {
-		formatter = formatters.Fallback
+// analyseLexer guesses the lexer from the content, falling back to plain text
+func analyseLexer(content string) chroma.Lexer {
+	if lexer := lexers.Analyse(content); lexer != nil {
+		return lexer
 	}
+	return lexers.Fallback
+}
 
-	// Tokenize and format
-	iterator, err := lexer.Tokenise(nil, content)
-	if err != nil {
-		// If highlighting fails, just use plain content
-		fv.HighlightedContent = fv.Content
-		return
+// lexerName is the name of a lexer shown in the header
+func lexerName(lexer chroma.Lexer) string {
+	if lexer == lexers.Fallback {
+		return "Plain text"
 	}
+	return lexer.Config().Name
+}
 
-	// Format to ANSI colors
-	var buf bytes.Buffer
-	err = formatter.Format(&buf, style, iterator)
-	if err != nil {
-		// If formatting fails, just use plain content
-		fv.HighlightedContent = fv.Content
-		return
+// Init starts the viewer's background work: reading streamed input, polling a
+// followed file, highlighting large files, indexing the lines of windowed files
+// and watching for the file to vanish. Work that is already running is not
+// started again, so it can be called whenever the viewer is shown.
+func (fv *FileViewer) Init() tea.Cmd {
+	return tea.Batch(fv.readStream(), fv.startFollowing(), fv.startHighlighting(), fv.startIndexing(), fv.startWatching())
+}
+
+// Update handles keys and the results of the viewer's background work,
+// returning the commands to run next
+func (fv *FileViewer) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		fv.Width = msg.Width
+		fv.Height = msg.Height
+		fv.fitMarkdown()
+
+	case tea.KeyMsg:
+		fv.updateKey(msg)
+
+	case viewerLinesMsg:
+		fv.receiveLines(msg)
+
+	case followTickMsg:
+		fv.polling = false
+		if fv.Following {
+			fv.pollFile()
+		}
+
+	case highlightChunkMsg:
+		fv.receiveHighlight(msg)
+
+	case windowIndexMsg:
+		fv.receiveIndex(msg)
+
+	case watchTickMsg:
+		fv.watching = false
+		fv.checkFile()
 	}
 
-	// Split highlighted content into lines
-	highlightedContent := buf.String()
-	// Normalize line endings to match how we handled the plain content
-	highlightedContent = strings.ReplaceAll(highlightedContent, "\r\n", "\n")
-	highlightedContent = strings.ReplaceAll(highlightedContent, "\r", "")
-	fv.HighlightedContent = strings.Split(highlightedContent, "\n")
+	return fv, tea.Batch(fv.takePendingCmd(), fv.Init())
 }
 
-// Update handles keyboard input for the file viewer
-func (fv *FileViewer) Update(msg tea.KeyMsg) {
+// pause marks the background work of a closed viewer as stopped, so that Init
+// starts it again when the viewer is shown
+func (fv *FileViewer) pause() {
+	fv.reading = false
+	fv.polling = false
+	fv.highlighting = false
+	fv.watching = false
+	if fv.window != nil {
+		fv.window.indexing = false
+	}
+}
+
+// updateKey handles keyboard input for the file viewer
+func (fv *FileViewer) updateKey(msg tea.KeyMsg) {
 	// Handle command mode
 	if fv.CommandMode {
 		switch msg.String() {
@@ -314,30 +775,147 @@ func (fv *FileViewer) Update(msg tea.KeyMsg) {
 
 		case "backspace":
 			// Delete last character
-			if len(fv.CommandBuffer) > 0 {
-				fv.CommandBuffer = fv.CommandBuffer[:len(fv.CommandBuffer)-1]
-			}
+			fv.CommandBuffer = trimLastRune(fv.CommandBuffer)
 
 		default:
-			// Add character to command buffer (only printable characters)
-			if len(msg.String()) == 1 {
-				fv.CommandBuffer += msg.String()
-			}
+			// Add typed or pasted text (only printable characters)
+			fv.CommandBuffer += inputText(msg)
 		}
 
 		return
 	}
 
+	if fv.updateGoneKey(msg) {
+		return
+	}
+
 	// Normal navigation mode
 	maxVisible := fv.Height - 6 // Reserve space for header and footer
 
-	switch msg.String() {
+	// Combine two-key sequences such as ]] and [[, and gf; g moves at once, and
+	// g followed by another key is two keys
+	key := msg.String()
+	pending := fv.PendingKey
+	fv.PendingKey = ""
+	switch {
+	case pending == "g" && key == "f":
+		key = "gf"
+	case pending == "]" || pending == "[" || pending == "z":
+		key = pending + key
+	case key == "]" || key == "[" || key == "z" && fv.tree != nil:
+		fv.PendingKey = key
+		return
+	default:
+		key = keymap.translate("viewer", key)
+	}
+
+	switch key {
+	case "F":
+		// Toggle follow mode
+		fv.toggleFollow()
+
+	case "enter", " ":
+		// Fold or unfold the JSON value on the top line
+		if fv.tree != nil {
+			fv.toggleFold()
+		}
+
+	case "zM":
+		// Fold every JSON value
+		if fv.tree != nil {
+			fv.foldAll(true)
+		}
+
+	case "zR":
+		// Unfold every JSON value
+		if fv.tree != nil {
+			fv.foldAll(false)
+		}
+
+	case "left", "h":
+		// Previous column of a table
+		if fv.table != nil {
+			fv.scrollColumns(-1)
+		}
+
+	case "right", "l":
+		// Next column of a table
+		if fv.table != nil {
+			fv.scrollColumns(1)
+		}
+
+	case "]]":
+		// Next section
+		fv.jumpSection(1)
+
+	case "[[":
+		// Previous section
+		fv.jumpSection(-1)
+
+	case "]b":
+		// Next bookmark
+		fv.jumpBookmark(1)
+
+	case "[b":
+		// Previous bookmark
+		fv.jumpBookmark(-1)
+
+	case "]f":
+		// Next file of the browsed directory
+		fv.pendingCmd = func() tea.Msg { return siblingFileMsg{Viewer: fv, Step: 1} }
+
+	case "[f":
+		// Previous file of the browsed directory
+		fv.pendingCmd = func() tea.Msg { return siblingFileMsg{Viewer: fv, Step: -1} }
+
+	case "]g":
+		// Next time gap in a log
+		fv.jumpGap(1)
+
+	case "]s":
+		// Next misspelled word
+		fv.jumpMisspelling(1)
+
+	case "[s":
+		// Previous misspelled word
+		fv.jumpMisspelling(-1)
+
+	case "[g":
+		// Previous time gap in a log
+		fv.jumpGap(-1)
+
+	case "]u":
+		// Next URL or file path
+		fv.jumpLink(1)
+
+	case "[u":
+		// Previous URL or file path
+		fv.jumpLink(-1)
+
+	case "o":
+		// Open the selected link, or the first on screen
+		fv.openLink()
+
+	case "gf":
+		// Open the file of an include or import statement, from where g left
+		if fv.gFrom != nil && fv.gFrom.First == fv.firstLine() {
+			fv.ScrollPos = fv.gFrom.Row
+		}
+		fv.gFrom = nil
+		fv.followInclude()
+
 	case ":":
 		// Enter command mode
 		fv.CommandMode = true
 		fv.CommandBuffer = ""
 		fv.StatusMessage = ""
 
+	case "/":
+		// Type a search term on the command line
+		fv.CommandMode = true
+		fv.CommandBuffer = "/"
+		fv.StatusMessage = ""
+
 	case "n":
 		// Next search match
 		fv.nextMatch()
@@ -347,41 +925,63 @@ func (fv *FileViewer) Update(msg tea.KeyMsg) {
 		fv.prevMatch()
 
 	case "up", "k":
-		if fv.ScrollPos > 0 {
-			fv.ScrollPos--
+		if fv.ScrollPos > 0 || fv.loadEarlier() {
+			fv.ScrollPos = max(fv.ScrollPos-1, 0)
 		}
+		fv.pauseFollow()
 
 	case "down", "j":
-		maxScroll := len(fv.Content) - maxVisible
+		maxScroll := fv.rowCount() - maxVisible
 		if maxScroll < 0 {
 			maxScroll = 0
 		}
-		if fv.ScrollPos < maxScroll {
+		if fv.ScrollPos < maxScroll || fv.loadLater() {
 			fv.ScrollPos++
 		}
 
 	case "g":
-		// Jump to top
+		// Jump to top, remembering the position in case this starts gf
+		fv.gFrom = &scrollMark{First: fv.firstLine(), Row: fv.ScrollPos}
+		fv.PendingKey = "g"
 		fv.ScrollPos = 0
+		fv.pauseFollow()
+		if fv.window != nil {
+			fv.loadStart()
+		}
 
 	case "G":
-		// Jump to bottom
-		maxScroll := len(fv.Content) - maxVisible
+		// Jump to bottom, resuming follow mode if scrolling stopped it
+		if fv.followPaused {
+			fv.Following, fv.followPaused = true, false
+			fv.StatusMessage = "Following (F to stop)"
+		}
+		if fv.window != nil {
+			fv.loadEnd()
+			break
+		}
+		maxScroll := fv.rowCount() - maxVisible
 		if maxScroll < 0 {
 			maxScroll = 0
 		}
 		fv.ScrollPos = maxScroll
 
-	case "pageup", "ctrl+u":
-		// Scroll up half a page
+	case "ctrl+u", "pgup":
+		// Scroll up half a page, loading more of a tail at its top
+		if fv.ScrollPos < maxVisible/2 {
+			fv.loadEarlier()
+		}
 		fv.ScrollPos -= maxVisible / 2
 		if fv.ScrollPos < 0 {
 			fv.ScrollPos = 0
 		}
+		fv.pauseFollow()
 
-	case "pagedown", "ctrl+d":
-		// Scroll down half a page
-		maxScroll := len(fv.Content) - maxVisible
+	case "ctrl+d", "pgdown":
+		// Scroll down half a page, loading more of a windowed file at its end
+		maxScroll := fv.rowCount() - maxVisible
+		if fv.ScrollPos+maxVisible/2 > maxScroll && fv.loadLater() {
+			maxScroll = fv.rowCount() - maxVisible
+		}
 		if maxScroll < 0 {
 			maxScroll = 0
 		}
@@ -392,15 +992,25 @@ func (fv *FileViewer) Update(msg tea.KeyMsg) {
 	}
 }
 
-// wrapLine wraps a line to fit within the given width, preserving ANSI color codes
-func wrapLine(line string, width int, lineNum int) []string {
+// lineNumberWidth is the width of the line number column: "    1 | "
+const lineNumberWidth = 8
+
+// wrapWidth returns the columns left for text beside the line numbers and the
+// gutter when lines are wrapped: at least 1, or 0 before the viewer is sized
+func (fv *FileViewer) wrapWidth() int {
+	if fv.Width <= 0 {
+		return 0
+	}
+	return max(fv.Width-lineNumberWidth-fv.gutterWidth(), 1)
+}
+
+// wrapLine wraps a line to fit within the given width of text, preserving ANSI
+// color codes. A width of 0 or less leaves the line whole.
+func wrapLine(line string, width int) []string {
 	if width <= 0 {
 		return []string{line}
 	}
-
-	// Calculate available width (accounting for line number column: "1234 | ")
-	lineNumWidth := 8 // "    1 | " = 8 characters
-	availableWidth := width - lineNumWidth
+	availableWidth := width
 
 	// If line is short enough, return as is
 	visualLen := visualLength(line)
@@ -416,7 +1026,7 @@ func wrapLine(line string, width int, lineNum int) []string {
 		// Find a good breaking point
 		breakPoint := findBreakPoint(remaining, availableWidth)
 		if breakPoint <= 0 {
-			breakPoint = availableWidth
+			breakPoint = min(availableWidth, len(remaining))
 		}
 
 		// Split at the break point
@@ -434,52 +1044,53 @@ func wrapLine(line string, width int, lineNum int) []string {
 	return wrapped
 }
 
-// visualLength calculates the visible length of a string, ignoring ANSI escape codes
+// visualLength calculates the visible width of a string in terminal columns,
+// ignoring ANSI escape codes
 func visualLength(s string) int {
 	length := 0
-	inEscape := false
-
-	for i := 0; i < len(s); i++ {
-		if s[i] == '\x1b' { // ESC character starts ANSI sequence
-			inEscape = true
-		} else if inEscape {
-			if s[i] == 'm' { // 'm' ends ANSI color sequence
-				inEscape = false
-			}
-		} else {
-			length++
-		}
+	for i := 0; i < len(s); {
+		n, width := nextCell(s[i:])
+		length += width
+		i += n
 	}
-
 	return length
 }
 
-// truncateAtVisualWidth truncates a string at a visual width, preserving ANSI codes
+// nextCell returns the length in bytes of the ANSI escape code or the
+// character at the start of s, and the columns it takes. Characters are
+// grapheme clusters, so an emoji with a variation selector counts once, and
+// their widths follow the :calibrate results.
+func nextCell(s string) (int, int) {
+	if s[0] == '\x1b' {
+		// 'm' ends ANSI color sequences
+		if end := strings.IndexByte(s, 'm'); end >= 0 {
+			return end + 1, 0
+		}
+		return len(s), 0
+	}
+	cluster, _, width, _ := uniseg.FirstGraphemeClusterInString(s, -1)
+	return len(cluster), clusterWidth(cluster, width)
+}
+
+// truncateAtVisualWidth truncates a string at a visual width, preserving ANSI
+// codes. A wide character that would end past the width is left out.
 func truncateAtVisualWidth(s string, maxWidth int) string {
 	if maxWidth <= 0 {
 		return ""
 	}
 
 	visualPos := 0
-	inEscape := false
-
-	for i := 0; i < len(s); i++ {
-		if s[i] == '\x1b' {
-			inEscape = true
-			continue
-		} else if inEscape {
-			if s[i] == 'm' {
-				inEscape = false
-			}
-			continue
+	for i := 0; i < len(s); {
+		n, width := nextCell(s[i:])
+		if width > 0 && visualPos+width > maxWidth {
+			return s[:i]
 		}
+		visualPos += width
+		i += n
 
-		// Count visible character
-		visualPos++
-
-		// If we've reached max width, truncate here
+		// If we've reached max width, truncate here, after the whole character
 		if visualPos >= maxWidth {
-			return s[:i+1]
+			return s[:i]
 		}
 	}
 
@@ -494,21 +1105,14 @@ func findBreakPoint(s string, maxWidth int) int {
 
 	visualPos := 0
 	lastSpace := -1
-	inEscape := false
 
-	for i := 0; i < len(s); i++ {
-		if s[i] == '\x1b' {
-			inEscape = true
-			continue
-		} else if inEscape {
-			if s[i] == 'm' {
-				inEscape = false
-			}
+	for i := 0; i < len(s); {
+		n, width := nextCell(s[i:])
+		if width == 0 {
+			i += n
 			continue
 		}
-
-		// Count visible character
-		visualPos++
+		visualPos += width
 
 		// Track spaces as potential break points
 		if s[i] == ' ' || s[i] == '\t' || s[i] == '-' || s[i] == ',' || s[i] == '.' {
@@ -521,9 +1125,10 @@ func findBreakPoint(s string, maxWidth int) int {
 			if lastSpace > 0 && lastSpace > i-20 { // Within last 20 chars
 				return lastSpace + 1
 			}
-			// Otherwise break here
-			return i
+			// Otherwise break here, keeping at least one character
+			return max(i, n)
 		}
+		i += n
 	}
 
 	return len(s)
@@ -569,6 +1174,63 @@ func highlightSearchMatches(line, searchTerm string) string {
 	return result.String()
 }
 
+// displayLine returns line i with its syntax or overstrike styling if enabled,
+// otherwise the plain line
+func (fv *FileViewer) displayLine(i int) string {
+	if fv.UseSyntaxHighlight {
+		if (fv.overstrike || fv.markdown != nil || fv.tree != nil || fv.table != nil) && i < len(fv.styledLines) {
+			return fv.styledLines[i]
+		}
+		if i < len(fv.spans) && len(fv.spans[i]) > 0 {
+			return formatSpans(fv.Content[i], fv.spans[i])
+		}
+	}
+	return fv.Content[i]
+}
+
+// gutterWidth returns the width of the columns shown after the line numbers
+func (fv *FileViewer) gutterWidth() int {
+	width := fv.sourceTagWidth()
+	if fv.ElapsedMode != elapsedOff {
+		width += elapsedColumnWidth
+	}
+	return width
+}
+
+// renderLine styles line i with syntax, log, highlight rule and search coloring
+func (fv *FileViewer) renderLine(i int) string {
+	line := fv.displayLine(i)
+
+	// Color log lines by level
+	if fv.LogMode {
+		if styled, ok := fv.styleLogLine(i); ok {
+			line = styled
+		}
+	}
+
+	// Color live content by the configured highlight rules
+	if fv.Live {
+		if styled, ok := fv.styleLiveLine(i); ok {
+			line = styled
+		}
+	}
+
+	// Mark the exact spans of search matches
+	if fv.searchRe != nil {
+		line = markRanges(line, fv.matchRanges(i), searchOn, searchOff)
+	}
+
+	// Underline URLs and file paths
+	line = fv.markLinks(i, line)
+
+	// Underline misspelled words
+	if fv.Spell {
+		line = underlineRanges(line, fv.misspelled(i))
+	}
+
+	return line
+}
+
 // View renders the file viewer
 func (fv FileViewer) View() string {
 	if fv.Err != nil {
@@ -578,7 +1240,7 @@ func (fv FileViewer) View() string {
 	var b strings.Builder
 
 	// Title
-	title := titleStyle.Render(fmt.Sprintf("📄 Viewing: %s", fv.FileName))
+	title := theme.Title.Render(fmt.Sprintf("📄 Viewing: %s%s", fv.FileName, fv.goneLabel()))
 	b.WriteString(title + "\n")
 
 	// File info
@@ -587,42 +1249,92 @@ func (fv FileViewer) View() string {
 		wrapStatus = "Wrap: ON"
 	}
 	info := fmt.Sprintf("Lines: %d | Position: %d | %s", len(fv.Content), fv.ScrollPos+1, wrapStatus)
-	b.WriteString(info + "\n\n")
+	if fv.window != nil && !fv.hex {
+		info = fmt.Sprintf("%s | Position: %s | %s", fv.windowInfo(), strings.TrimSpace(fv.lineLabel(fv.lineAt(fv.ScrollPos))), wrapStatus)
+	}
+	if fv.hex {
+		offset := fv.part.Start + int64(fv.lineAt(fv.ScrollPos)*hexBytesPerLine)
+		info = fmt.Sprintf("Bytes: %d | Offset: %08x | %s | Hex", len(fv.raw), offset, wrapStatus)
+	}
+	if fv.markdown != nil {
+		info += " | Markdown (:set raw for the source)"
+	} else if fv.tree != nil {
+		info += " | JSON tree (Enter/Space fold, zM/zR all)"
+	} else if fv.table != nil {
+		info += " | " + fv.table.describe()
+	} else if fv.fileType != "" && fv.UseSyntaxHighlight && !fv.overstrike {
+		info += " | " + fv.fileType
+	}
+	if len(fv.Sections) > 0 {
+		info += fmt.Sprintf(" | Sections: %d (]]/[[)", len(fv.Sections))
+	}
+	if part := fv.partInfo(); part != "" {
+		info += " | " + part
+	}
+	if fv.Following {
+		info += " | Follow: ON"
+	}
+	if fv.LogMode {
+		info += " | Log"
+	}
+	if fv.Spell {
+		info += " | Spell"
+	}
+	if fv.SearchRegex {
+		info += " | Regex"
+	}
+	if len(fv.sources) > 0 {
+		info += fmt.Sprintf(" | Merged: %d files", len(fv.sources))
+	}
+	if fv.highlighter != nil && fv.UseSyntaxHighlight && len(fv.Content) > highlightChunkLines {
+		info += fmt.Sprintf(" | Highlighting %d%%", fv.highlightProgress())
+	}
+	if fv.filterActive() {
+		info += fmt.Sprintf(" | Filter: %s (%d hidden)", fv.Filter.Desc, len(fv.Content)-len(fv.filtered))
+	}
+	if banner := fv.goneBanner(); banner != "" {
+		// The banner takes the place of the blank line under the info
+		b.WriteString(info + "\n" + banner + "\n")
+	} else if fv.table != nil {
+		// So does the header row of a table
+		b.WriteString(info + "\n" + fv.tableHeaderLine() + "\n")
+	} else {
+		b.WriteString(info + "\n\n")
+	}
 
-	// Calculate visible range
+	// Calculate visible range of display rows
 	maxVisible := fv.Height - 6
 	visibleStart := fv.ScrollPos
 	visibleEnd := visibleStart + maxVisible
 
-	if visibleEnd > len(fv.Content) {
-		visibleEnd = len(fv.Content)
+	if visibleEnd > fv.rowCount() {
+		visibleEnd = fv.rowCount()
 	}
 
 	// Display file content with line numbers
-	// Use highlighted content if available, otherwise use plain content
-	contentToDisplay := fv.Content
-	if len(fv.HighlightedContent) > 0 && fv.UseSyntaxHighlight {
-		contentToDisplay = fv.HighlightedContent
-	}
-
 	linesRendered := 0
-	for i := visibleStart; i < visibleEnd && linesRendered < maxVisible; i++ {
-		if i >= len(contentToDisplay) {
+	for row := visibleStart; row < visibleEnd && linesRendered < maxVisible; row++ {
+		i := fv.lineAt(row)
+		if i >= len(fv.Content) {
 			break
 		}
 
-		line := contentToDisplay[i]
+		line := fv.renderLine(i)
 
-		// Apply search highlighting if active
-		if fv.SearchTerm != "" {
-			line = highlightSearchMatches(line, fv.SearchTerm)
+		lineNum := fv.lineLabel(i) + " │ "
+		if fv.bookmarkIndex(fv.bookmarkAt(i)) >= 0 {
+			lineNum = fmt.Sprintf("%s %s ", fv.lineLabel(i), theme.Directory.Render("●"))
+		}
+		if fv.ElapsedMode != elapsedOff {
+			lineNum += fv.elapsedColumn(i)
+		}
+		if fv.sourceTagWidth() > 0 {
+			lineNum += fv.sourceTag(i)
 		}
-
-		lineNum := fmt.Sprintf("%4d │ ", i+1)
 
 		if fv.WrapLines {
 			// Wrap the line if wrapping is enabled
-			wrappedLines := wrapLine(line, fv.Width, i+1)
+			wrappedLines := wrapLine(line, fv.wrapWidth())
 
 			// Render first line with line number
 			if len(wrappedLines) > 0 {
@@ -638,7 +1350,7 @@ func (fv FileViewer) View() string {
 		} else {
 			// No wrapping - truncate long lines with indicator
 			visualLen := visualLength(line)
-			availableWidth := fv.Width - 10 // Account for line numbers and margin
+			availableWidth := fv.Width - 10 - fv.gutterWidth() // Account for line numbers and margin
 
 			if availableWidth > 0 && visualLen > availableWidth {
 				// Truncate at visual width (accounting for ANSI codes)
@@ -660,13 +1372,13 @@ func (fv FileViewer) View() string {
 		b.WriteString(commandPrompt)
 	} else if fv.StatusMessage != "" {
 		// Show status message
-		status := statusStyle.Render(fv.StatusMessage)
+		status := theme.Status.Render(fv.StatusMessage)
 		b.WriteString(status + "\n")
-		help := helpStyle.Render("↑/k: up | ↓/j: down | g: top | G: bottom | Ctrl+u/d: page | :: command | q/Esc: back")
+		help := theme.Help.Render(keymap.help(viewerHelp...))
 		b.WriteString(help)
 	} else {
 		// Show normal help
-		help := helpStyle.Render("↑/k: up | ↓/j: down | g: top | G: bottom | Ctrl+u/d: page | :: command | q/Esc: back")
+		help := theme.Help.Render(keymap.help(viewerHelp...))
 		b.WriteString(help)
 	}
 
@@ -0,0 +1,301 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+	"unsafe"
+
+	"github.com/HolyStarGazer/windows-tui-go/uitest"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TestWrapNarrowWidths resizes a wrapping viewer with the elapsed column on
+// to every width from 1 to 40, where the gutter leaves little or no room for
+// text
+func TestWrapNarrowWidths(t *testing.T) {
+	var lines []string
+	for i := range 20 {
+		lines = append(lines, fmt.Sprintf("2024-01-02 15:04:%02d INFO request %d handled in 12ms with a long trailing message📁 %s", i, i, strings.Repeat("x", i*3)))
+	}
+	for _, markdown := range []bool{false, true} {
+		name := "app.log"
+		if markdown {
+			name = "notes.md"
+		}
+		viewer := NewContentViewer(name, strings.Join(lines, "\n"))
+		viewer.WrapLines = true
+		viewer.cycleElapsed()
+		for width := 1; width <= 40; width++ {
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				viewer.Update(tea.WindowSizeMsg{Width: width, Height: 20})
+				viewer.View()
+				viewer.visibleLines()
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s at width %d: rendering did not finish", name, width)
+			}
+		}
+	}
+}
+
+// TestContentSharesText checks clean text is kept without a copy and the
+// viewer's lines are slices of the one normalized text, not a copy each
+func TestContentSharesText(t *testing.T) {
+	clean := strings.Repeat("plain line of text\n", 100)
+	if got := normalizeText(clean); unsafe.StringData(got) != unsafe.StringData(clean) {
+		t.Error("text needing no changes was copied")
+	}
+	if got, want := normalizeText("a\tb\r\nc\rd"), "a    b\ncd"; got != want {
+		t.Errorf("normalizeText = %q, want %q", got, want)
+	}
+
+	for name, text := range map[string]string{"clean": clean, "crlf": strings.Repeat("key\t= value\r\n", 100)} {
+		viewer := NewContentViewer("data.txt", text)
+		for i := 1; i < len(viewer.Content); i++ {
+			prev, line := viewer.Content[i-1], viewer.Content[i]
+			if line == "" {
+				continue
+			}
+			if unsafe.Pointer(unsafe.StringData(line)) != unsafe.Add(unsafe.Pointer(unsafe.StringData(prev)), len(prev)+1) {
+				t.Fatalf("%s: line %d does not follow line %d in the text", name, i+1, i)
+			}
+		}
+	}
+}
+
+// TestPageKeys checks PgUp and PgDn page the viewer as Bubble Tea reports
+// them, and that pageup and pagedown are taken for them in the keys setting
+func TestPageKeys(t *testing.T) {
+	defer func(saved keyMap) { keymap = saved }(keymap)
+	keymap = keyMap{}
+	viewer := NewContentViewer("notes.txt", strings.Repeat("line\n", 200))
+	viewer.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	viewer.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	if viewer.ScrollPos == 0 {
+		t.Fatal("PgDn did not scroll down")
+	}
+	viewer.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	if viewer.ScrollPos != 0 {
+		t.Fatalf("PgUp left the viewer at %d, want 0", viewer.ScrollPos)
+	}
+
+	var err error
+	keymap, err = compileKeymap(map[string][]string{"viewer.page_down": {"pagedown", "x"}, "viewer.page_up": {"pageup"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := keymap.help([]helpEntry{{Actions: []string{"viewer.page_down"}, Label: "down"}}); got != "PgDn/x: down" {
+		t.Errorf("help = %q, want %q", got, "PgDn/x: down")
+	}
+	viewer.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	if viewer.ScrollPos == 0 {
+		t.Fatal("PgDn bound as pagedown did not scroll down")
+	}
+	viewer.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	if viewer.ScrollPos != 0 {
+		t.Fatalf("PgUp bound as pageup left the viewer at %d, want 0", viewer.ScrollPos)
+	}
+}
+
+// TestFileModelWindowed opens a file over max_view_size as one given on the
+// command line, which must read it a window at a time and keep its path for
+// :tail, :follow and the check that it still exists
+func TestFileModelWindowed(t *testing.T) {
+	configDir := uitest.IsolateConfig(t)
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(`{"max_view_size": "4KB"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer setMaxViewSize("")
+
+	var log strings.Builder
+	for i := 1; i <= 2000; i++ {
+		fmt.Fprintf(&log, "line %d\n", i)
+	}
+	root := uitest.Mount(t, fstest.MapFS{"logs/big.log": {Data: []byte(log.String())}})
+	path := filepath.Join(root, "logs", "big.log")
+
+	m, err := NewFileModel(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fv := m.FileViewer
+	switch {
+	case fv == nil:
+		t.Fatal("no viewer opened")
+	case fv.window == nil:
+		t.Errorf("a file over max_view_size was read whole (%d lines)", len(fv.Content))
+	case fv.FilePath != path:
+		t.Errorf("FilePath = %q, want %q", fv.FilePath, path)
+	case !m.PagerMode:
+		t.Error("closing the viewer does not quit")
+	}
+	if _, err := NewFileModel(filepath.Join(root, "logs", "missing.log"), ""); err == nil {
+		t.Error("no error for a missing file")
+	}
+}
+
+// TestWindowedBookmarks bookmarks lines at both ends of a file loaded as it
+// is scrolled, which must keep their line numbers as the window moves and be
+// jumped to and exported from wherever the window is
+func TestWindowedBookmarks(t *testing.T) {
+	defer setMaxViewSize("")
+	maxViewSize = 64 << 10
+	const lines = 300000
+	var log strings.Builder
+	for i := 1; i <= lines; i++ {
+		fmt.Fprintf(&log, "line %06d\n", i)
+	}
+	root := uitest.Mount(t, fstest.MapFS{"big.txt": {Data: []byte(log.String())}})
+	path := filepath.Join(root, "big.txt")
+
+	open := func() *FileViewer {
+		fv := NewFileViewer(path, "big.txt")
+		if fv.window == nil {
+			t.Fatal("the file was read whole")
+		}
+		fv.Width, fv.Height = 80, 24
+		return &fv
+	}
+	top := func(fv *FileViewer) string {
+		return fv.Content[fv.lineAt(fv.ScrollPos)]
+	}
+
+	fv := open()
+	fv.loadEnd()
+	fv.addBookmark("too soon")
+	if len(fv.Bookmarks) != 0 || !strings.Contains(fv.StatusMessage, "still being counted") {
+		t.Fatalf("bookmarked a line before its number was known: %q", fv.StatusMessage)
+	}
+
+	fv = open()
+	for cmd := fv.startIndexing(); cmd != nil; cmd = fv.startIndexing() {
+		fv.receiveIndex(cmd().(windowIndexMsg))
+	}
+	fv.loadEnd()
+	fv.ScrollPos = fv.rowOf(len(fv.Content) - 10)
+	want := top(fv)
+	var end int
+	if _, err := fmt.Sscanf(want, "line %d", &end); err != nil || end < lines-20 {
+		t.Fatalf("top line near the end is %q", want)
+	}
+	fv.addBookmark("near the end")
+	if fv.StatusMessage != fmt.Sprintf("Bookmarked line %d (1 bookmarks)", end) {
+		t.Fatalf("bookmark near the end: %q", fv.StatusMessage)
+	}
+	fv.loadStart()
+	fv.addBookmark("start")
+	if fv.StatusMessage != "Bookmarked line 1 (2 bookmarks)" {
+		t.Fatalf("bookmark at the start: %q", fv.StatusMessage)
+	}
+
+	fv.jumpBookmark(1)
+	if got := top(fv); got != want || fv.StatusMessage != fmt.Sprintf("Bookmark line %d: near the end", end) {
+		t.Fatalf("next bookmark: top line %q (%q), want %q", got, fv.StatusMessage, want)
+	}
+	if fv.bookmarkIndex(fv.bookmarkAt(fv.lineAt(fv.ScrollPos))) < 0 {
+		t.Error("the bookmarked line has no bookmark in the gutter")
+	}
+	fv.jumpBookmark(-1)
+	if got := top(fv); got != "line 000001" {
+		t.Fatalf("previous bookmark: top line %q (%q)", got, fv.StatusMessage)
+	}
+
+	fv.exportBookmarks(filepath.Join(root, "bookmarks.txt"))
+	data, err := vfs.ReadFile(vfs.Default, filepath.Join(root, "bookmarks.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		fmt.Sprintf("> %5d │ %s", end, want),
+		fmt.Sprintf("  %5d │ line %06d", end-2, end-2),
+		fmt.Sprintf("  %5d │ line %06d", end+2, end+2),
+		">     1 │ line 000001",
+		"      3 │ line 000003",
+	} {
+		if !strings.Contains(string(data), line+"\n") {
+			t.Errorf("export lacks %q:\n%s", line, data)
+		}
+	}
+
+	if bm, ok := fv.deleteBookmark(fv.bookmarkAt(fv.lineAt(fv.ScrollPos))); !ok || bm.Line != 0 {
+		t.Errorf("deleting the bookmark at the start: %v %v", bm, ok)
+	}
+}
+
+// TestWindowedHex opens a file over max_view_size as a hex dump, and switches
+// a windowed file between text and hex dump in the middle of it, which must
+// stay at the line at the top of the view
+func TestWindowedHex(t *testing.T) {
+	defer setMaxViewSize("")
+	maxViewSize = 64 << 10
+	var log strings.Builder
+	for i := 1; i <= 300000; i++ {
+		fmt.Fprintf(&log, "line %06d\n", i)
+	}
+	root := uitest.Mount(t, fstest.MapFS{"big.txt": {Data: []byte(log.String())}})
+	path := filepath.Join(root, "big.txt")
+
+	hex := NewHexViewer(path, "big.txt")
+	if hex.window == nil || !hex.hex || !strings.HasPrefix(hex.Content[0], "00000000  6c 69 6e 65") {
+		t.Fatalf("hex viewer: windowed %v, hex %v, first line %q", hex.window != nil, hex.hex, hex.Content[0])
+	}
+
+	fv := NewFileViewer(path, "big.txt")
+	fv.Width, fv.Height = 80, 24
+	for fv.part.Start == 0 {
+		if !fv.loadLater() {
+			t.Fatal("the window did not move")
+		}
+	}
+	fv.ScrollPos = fv.rowOf(7)
+	want := fv.Content[fv.lineAt(fv.ScrollPos)]
+	offset := fv.lineOffset(fv.lineAt(fv.ScrollPos))
+
+	fv.toggleHex()
+	row := fv.Content[fv.lineAt(fv.ScrollPos)]
+	if !fv.hex || !strings.HasPrefix(row, fmt.Sprintf("%08x", offset/hexBytesPerLine*hexBytesPerLine)) {
+		t.Fatalf("hex dump of %q at %x starts with %q", want, offset, row)
+	}
+	fv.loadLater()
+	fv.loadBefore()
+	fv.ScrollPos = fv.rowOf(int(offset/hexBytesPerLine - fv.part.Start/hexBytesPerLine))
+
+	fv.toggleHex()
+	if got := fv.Content[fv.lineAt(fv.ScrollPos)]; fv.hex || got != want {
+		t.Fatalf("back to text: top line %q, want %q (hex %v)", got, want, fv.hex)
+	}
+	if fv.lineOffset(1) != offset+int64(len(want))+1 {
+		t.Errorf("line offsets after the switch: %d, want %d", fv.lineOffset(1), offset+int64(len(want))+1)
+	}
+}
+
+// TestFollowWhileIdle checks a followed file keeps being polled once idle,
+// while the other background timers are held back until a key
+func TestFollowWhileIdle(t *testing.T) {
+	m := Model{idleSuspend: time.Minute, lastInput: time.Now().Add(-time.Hour)}
+	followed := &FileViewer{FilePath: "app.log", Following: true}
+	viewed := &FileViewer{FilePath: "notes.txt"}
+	for _, msg := range []tea.Msg{followTickMsg{Viewer: followed}, watchTickMsg{Viewer: followed}} {
+		if m.suspendWhileIdle(msg) {
+			t.Errorf("%T of a followed file was held back", msg)
+		}
+	}
+	for _, msg := range []tea.Msg{watchTickMsg{Viewer: viewed}, followTickMsg{Viewer: viewed}, sysinfoMsg{}, jobTickMsg{}} {
+		if !m.suspendWhileIdle(msg) {
+			t.Errorf("%T ran while idle", msg)
+		}
+	}
+	if len(m.suspended) != 4 {
+		t.Errorf("%d ticks held back, want 4", len(m.suspended))
+	}
+}
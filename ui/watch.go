@@ -0,0 +1,173 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// watchInterval is how often a viewed file is checked for having been deleted
+// or renamed
+const watchInterval = 2 * time.Second
+
+// watchTickMsg triggers a check that a viewed file still exists
+type watchTickMsg struct {
+	Viewer *FileViewer
+}
+
+// goneFile describes a viewed file that was deleted or renamed
+type goneFile struct {
+	RenamedTo string // New path, if the file turned up under another name
+	Kept      bool   // Whether the banner was dismissed to keep viewing the buffer
+}
+
+// startWatching schedules the next check of the viewed file, unless one is
+// scheduled or the file is already known to be gone
+func (fv *FileViewer) startWatching() tea.Cmd {
+	if fv.FilePath == "" || fv.Err != nil || fv.watching || fv.gone != nil {
+		return nil
+	}
+	fv.watching = true
+	return tea.Tick(watchInterval, func(time.Time) tea.Msg {
+		return watchTickMsg{Viewer: fv}
+	})
+}
+
+// checkFile notices when the viewed file has been deleted or renamed. Other
+// errors, such as a network share going offline, are checked again later. A
+// followed file is left to pollFile, which waits for rotated logs to return.
+func (fv *FileViewer) checkFile() {
+	info, err := vfs.Default.Stat(fv.FilePath)
+	if err == nil {
+		if fv.fileInfo == nil {
+			fv.fileInfo = info
+		}
+		return
+	}
+	if errors.Is(err, fs.ErrNotExist) && !fv.Following {
+		fv.markGone()
+	}
+}
+
+// markGone records that the viewed file disappeared, looking for it under a
+// new name, and stops following it
+func (fv *FileViewer) markGone() {
+	fv.gone = &goneFile{RenamedTo: findRenamed(fv.FilePath, fv.fileInfo)}
+	fv.Following = false
+	fv.followPaused = false
+}
+
+// findRenamed looks for a vanished file in its directory under another name.
+// The file system's identity of the file is used where it has one; otherwise a
+// single file with the same size and modification time is taken to be it.
+func findRenamed(path string, old fs.FileInfo) string {
+	if old == nil {
+		return ""
+	}
+	dir := filepath.Dir(path)
+	entries, err := vfs.Default.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	var lookalikes []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		candidate := filepath.Join(dir, entry.Name())
+		info, err := vfs.Default.Stat(candidate)
+		if err != nil || info.Size() != old.Size() {
+			continue
+		}
+		if os.SameFile(old, info) {
+			return candidate
+		}
+		if info.ModTime().Equal(old.ModTime()) {
+			lookalikes = append(lookalikes, candidate)
+		}
+	}
+	if len(lookalikes) == 1 {
+		return lookalikes[0]
+	}
+	return ""
+}
+
+// updateGoneKey handles the choices of the banner shown for a vanished file
+// and reports whether the key was used
+func (fv *FileViewer) updateGoneKey(msg tea.KeyMsg) bool {
+	if fv.gone == nil || fv.gone.Kept {
+		return false
+	}
+	switch msg.String() {
+	case "enter":
+		fv.gone.Kept = true
+		fv.StatusMessage = "Viewing the last loaded content"
+		return true
+	case "r":
+		if fv.gone.RenamedTo != "" {
+			fv.reopen(fv.gone.RenamedTo)
+			return true
+		}
+	}
+	return false
+}
+
+// reopen replaces the viewer with one of the file at path, keeping the scroll
+// position and display settings
+func (fv *FileViewer) reopen(path string) {
+	reopened := NewFileViewer(path, filepath.Base(path))
+	if reopened.Err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", reopened.Err)
+		return
+	}
+	reopened.Width, reopened.Height = fv.Width, fv.Height
+	reopened.HighlightRules = fv.HighlightRules
+	reopened.WrapLines = fv.WrapLines
+	reopened.fitMarkdown()
+	if fv.RawMarkdown {
+		reopened.setRawMarkdown(true)
+	}
+	if fv.JSONTree {
+		reopened.setJSONTree(true)
+	}
+	if fv.RawTable {
+		reopened.setRawTable(true)
+	}
+	reopened.buffer = fv.buffer
+	reopened.ScrollPos = min(fv.ScrollPos, max(reopened.rowCount()-1, 0))
+	reopened.StatusMessage = fmt.Sprintf("Reopened %s", path)
+	*fv = reopened
+}
+
+// goneBanner describes what happened to a vanished file and the choices,
+// until one is made
+func (fv *FileViewer) goneBanner() string {
+	if fv.gone == nil || fv.gone.Kept {
+		return ""
+	}
+	text := fmt.Sprintf(" ⚠ %s was deleted. Enter: keep viewing | q: close ", fv.FileName)
+	if fv.gone.RenamedTo != "" {
+		text = fmt.Sprintf(" ⚠ %s was renamed to %s. r: reopen it | Enter: keep viewing | q: close ",
+			fv.FileName, filepath.Base(fv.gone.RenamedTo))
+	}
+	return theme.Banner.Render(text)
+}
+
+// goneLabel is added to the title of a vanished file
+func (fv *FileViewer) goneLabel() string {
+	switch {
+	case fv.gone == nil:
+		return ""
+	case fv.gone.RenamedTo != "":
+		return fmt.Sprintf(" (renamed to %s)", filepath.Base(fv.gone.RenamedTo))
+	default:
+		return " (deleted)"
+	}
+}
@@ -0,0 +1,348 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+)
+
+// WebServer serves a read-only web page for browsing and viewing the files
+// under a directory (--web). Every request must carry the key as ?key=.
+type WebServer struct {
+	Root  string // Directory served; paths in requests are relative to it
+	Key   string // Key visitors must give
+	base  Model  // Browser settings used for listings and viewing
+	root  string // Root with its links followed, which requests must stay in
+	guard keyGuard
+}
+
+// webItem is an entry of a directory listing in the JSON API
+type webItem struct {
+	Name     string    `json:"name"`
+	Path     string    `json:"path"` // Relative to the root, separated by /
+	Dir      bool      `json:"dir"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Link     string    `json:"link,omitempty"` // Target of a link or junction
+}
+
+// webListing is a directory in the JSON API
+type webListing struct {
+	Path   string    `json:"path"`
+	Parent *string   `json:"parent"` // Null at the root
+	Items  []webItem `json:"items"`
+}
+
+// NewWebServer prepares to serve the directory dir, loading the settings as
+// the browser does
+func NewWebServer(dir string) (*WebServer, error) {
+	base := NewModelAt(dir)
+	info, err := vfs.Default.Stat(base.CurrentPath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", base.CurrentPath)
+	}
+	root, err := vfs.Default.EvalSymlinks(base.CurrentPath)
+	if err != nil {
+		return nil, err
+	}
+	key, err := randomKey()
+	if err != nil {
+		return nil, err
+	}
+	return &WebServer{Root: base.CurrentPath, Key: key, base: base, root: root}, nil
+}
+
+// URL returns the address of the start page for a listener
+func (s *WebServer) URL(addr net.Addr) string {
+	return fmt.Sprintf("http://%s/?key=%s", shareAddr(addr), s.Key)
+}
+
+// ServeHTTP serves the start page, the JSON listing API (/api/list), files as
+// highlighted HTML (/view) and as they are (/raw)
+func (s *WebServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := s.guard.check(r.RemoteAddr, r.URL.Query().Get("key"), s.Key); err != nil {
+		keyRefusal(w, err, "Open this page with ?key=<key>, using the key printed when the server started")
+		return
+	}
+	rel := cleanWebPath(r.URL.Query().Get("path"))
+	switch r.URL.Path {
+	case "/":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, webPage)
+	case "/api/list":
+		s.serveList(w, rel)
+	case "/view":
+		s.serveView(w, r, rel)
+	case "/raw":
+		s.serveRaw(w, r, rel)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// cleanWebPath turns a requested path into one relative to the root, so that
+// .. cannot leave it
+func cleanWebPath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(p, `\`, "/")), "/")
+}
+
+// local returns the file system path of a path relative to the root, with
+// its links followed. Links and junctions leading out of the root are refused.
+func (s *WebServer) local(rel string) (string, error) {
+	name, err := vfs.Default.EvalSymlinks(filepath.Join(s.Root, filepath.FromSlash(rel)))
+	if err != nil {
+		return "", err
+	}
+	inside, err := filepath.Rel(s.root, name)
+	if err != nil || inside == ".." || strings.HasPrefix(inside, ".."+string(filepath.Separator)) {
+		return "", &fs.PathError{Op: "open", Path: rel, Err: fs.ErrPermission}
+	}
+	return name, nil
+}
+
+// serveList lists a directory as JSON, with the browser's filter and sort
+// order
+func (s *WebServer) serveList(w http.ResponseWriter, rel string) {
+	dir, err := s.local(rel)
+	if err != nil {
+		webError(w, err)
+		return
+	}
+	m := s.base
+	m.CurrentPath = dir
+	m.loadDirectory()
+	if m.Err != nil {
+		webError(w, m.Err)
+		return
+	}
+
+	listing := webListing{Path: rel, Items: []webItem{}}
+	if rel != "" {
+		parent := strings.TrimPrefix(path.Dir("/"+rel), "/")
+		listing.Parent = &parent
+	}
+	for _, item := range m.Items {
+		if item.Name == ".." {
+			continue
+		}
+		listing.Items = append(listing.Items, webItem{
+			Name:     item.Name,
+			Path:     path.Join(rel, item.Name),
+			Dir:      item.IsDir,
+			Size:     item.Size,
+			Modified: item.ModTime,
+			Link:     item.LinkTarget,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listing)
+}
+
+// serveView shows a file as the viewer would: highlighted HTML, or a hex dump
+// for binary files. Files over max_view_size show their first part.
+func (s *WebServer) serveView(w http.ResponseWriter, r *http.Request, rel string) {
+	name, err := s.local(rel)
+	if err != nil {
+		webError(w, err)
+		return
+	}
+	info, err := vfs.Default.Stat(name)
+	if err != nil {
+		webError(w, err)
+		return
+	}
+	if info.IsDir() {
+		http.Redirect(w, r, "/?"+url.Values{"key": {s.Key}, "path": {rel}}.Encode(), http.StatusSeeOther)
+		return
+	}
+
+	fv := NewFileViewer(name, path.Base("/"+rel))
+	if lang := s.base.filetypeFor(fv.FileName); lang != "" && !fv.hex {
+		fv.SetLanguage(lang)
+	}
+	if fv.Err != nil {
+		webError(w, fv.Err)
+		return
+	}
+
+	var page []byte
+	if fv.hex {
+		page = []byte(ansiToHTML(fv.FileName, strings.Join(fv.Content, "\n")))
+	} else if page, err = fv.renderHTML(fv.exportedLines()); err != nil {
+		webError(w, err)
+		return
+	}
+
+	note := ""
+	if fv.window != nil {
+		note = fmt.Sprintf(" │ Showing the first %s of %s (raw has all of it)", FormatSize(fv.part.Size), FormatSize(fv.part.Total))
+	}
+	nav := fmt.Sprintf(`<div style="font-family:sans-serif;padding:4px;background-color:#333;color:#CCC;">%s │ <a style="color:#8CF;" href="/raw?%s">raw</a>%s</div>`,
+		webBreadcrumbs(s.Key, rel), webQuery(s.Key, rel), html.EscapeString(note))
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(withNav(page, nav))
+}
+
+// serveRaw sends a file as it is on disk, as a download
+func (s *WebServer) serveRaw(w http.ResponseWriter, r *http.Request, rel string) {
+	name, err := s.local(rel)
+	if err != nil {
+		webError(w, err)
+		return
+	}
+	file, err := vfs.Default.Open(name)
+	if err != nil {
+		webError(w, err)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		webError(w, err)
+		return
+	}
+	if info.IsDir() {
+		http.Error(w, "Not a file", http.StatusBadRequest)
+		return
+	}
+	// Served files are downloaded, never shown as pages of this origin where
+	// their scripts could read the key
+	header := w.Header()
+	header.Set("Content-Type", "application/octet-stream")
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": info.Name()}))
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Content-Security-Policy", "sandbox")
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
+
+// webError reports a file system error with a matching status
+func webError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		status = http.StatusNotFound
+	case errors.Is(err, fs.ErrPermission):
+		status = http.StatusForbidden
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// webQuery returns the query string giving the key and a path, escaped for an
+// attribute
+func webQuery(key, rel string) string {
+	return html.EscapeString(url.Values{"key": {key}, "path": {rel}}.Encode())
+}
+
+// webBreadcrumbs links each directory leading to a path back to its listing
+func webBreadcrumbs(key, rel string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<a style="color:#8CF;" href="/?%s">root</a>`, webQuery(key, ""))
+	parts := strings.Split(rel, "/")
+	for i, part := range parts {
+		if i == len(parts)-1 {
+			fmt.Fprintf(&b, " / %s", html.EscapeString(part))
+			break
+		}
+		fmt.Fprintf(&b, ` / <a style="color:#8CF;" href="/?%s">%s</a>`,
+			webQuery(key, strings.Join(parts[:i+1], "/")), html.EscapeString(part))
+	}
+	return b.String()
+}
+
+// withNav puts a navigation bar at the top of the body of an HTML page
+func withNav(page []byte, nav string) []byte {
+	body := bytes.Index(page, []byte("<body"))
+	if body < 0 {
+		return append([]byte(nav), page...)
+	}
+	end := bytes.IndexByte(page[body:], '>')
+	if end < 0 {
+		return append([]byte(nav), page...)
+	}
+	at := body + end + 1
+	return append(append(page[:at:at], nav...), page[at:]...)
+}
+
+// webPage is the start page. It lists directories through the JSON API and
+// opens files in /view.
+const webPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>File Explorer</title>
+<style>
+body { font-family: sans-serif; background-color: #1E1E1E; color: #CCCCCC; }
+a { color: #88CCFF; text-decoration: none; }
+a:hover { text-decoration: underline; }
+td { padding: 2px 12px 2px 0; }
+td.size, td.time { color: #888888; white-space: nowrap; }
+td.size { text-align: right; }
+</style>
+</head>
+<body>
+<h3 id="title">Loading...</h3>
+<table><tbody id="items"></tbody></table>
+<script>
+const params = new URLSearchParams(location.search);
+const key = params.get("key") || "";
+const query = (path) => "key=" + encodeURIComponent(key) + "&path=" + encodeURIComponent(path);
+
+function size(n) {
+	const units = ["B", "KB", "MB", "GB", "TB"];
+	let i = 0;
+	while (n >= 1024 && i < units.length - 1) { n /= 1024; i++; }
+	return (i ? n.toFixed(1) : n) + " " + units[i];
+}
+
+function row(name, href, sizeText, timeText) {
+	const tr = document.createElement("tr");
+	const link = document.createElement("a");
+	link.href = href;
+	link.textContent = name;
+	const cells = [link, sizeText, timeText];
+	["name", "size", "time"].forEach((cls, i) => {
+		const td = document.createElement("td");
+		td.className = cls;
+		td.append(cells[i]);
+		tr.append(td);
+	});
+	return tr;
+}
+
+fetch("api/list?" + query(params.get("path") || ""))
+	.then((r) => r.ok ? r.json() : r.text().then((t) => { throw new Error(t); }))
+	.then((listing) => {
+		document.getElementById("title").textContent = "/" + listing.path;
+		document.title = "/" + listing.path;
+		const items = document.getElementById("items");
+		if (listing.parent !== null) {
+			items.append(row("..", "?" + query(listing.parent), "", ""));
+		}
+		for (const item of listing.items) {
+			const href = item.dir ? "?" + query(item.path) : "view?" + query(item.path);
+			const name = item.dir ? item.name + "/" : item.name;
+			items.append(row(name, href, item.dir ? "" : size(item.size), new Date(item.modified).toLocaleString()));
+		}
+	})
+	.catch((e) => { document.getElementById("title").textContent = "Error: " + e.message; });
+</script>
+</body>
+</html>
+`
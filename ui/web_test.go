@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/HolyStarGazer/windows-tui-go/uitest"
+)
+
+// TestWebStaysInRoot checks links inside the served directory are followed
+// while links leading out of it are refused, however they are reached
+func TestWebStaysInRoot(t *testing.T) {
+	uitest.IsolateConfig(t)
+	link := func(target string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(target), Mode: fs.ModeSymlink | 0o777}
+	}
+	root := uitest.Mount(t, fstest.MapFS{
+		"site/notes.txt":     {Data: []byte("inside\n")},
+		"site/docs/guide.md": {Data: []byte("# Guide\n")},
+		"site/same.txt":      link("notes.txt"),
+		"site/docs/up.txt":   link("../notes.txt"),
+		"site/escape.txt":    link("../secret.txt"),
+		"site/outside":       link(".."),
+		"secret.txt":         {Data: []byte("secret\n")},
+	})
+	server, err := NewWebServer(filepath.Join(root, "site"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(page, rel string) *httptest.ResponseRecorder {
+		query := url.Values{"key": {server.Key}, "path": {rel}}.Encode()
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", page+"?"+query, nil))
+		return w
+	}
+	for _, rel := range []string{"notes.txt", "same.txt", "docs/up.txt"} {
+		if w := get("/raw", rel); w.Code != http.StatusOK || w.Body.String() != "inside\n" {
+			t.Errorf("/raw %s: %d %q, want the file inside", rel, w.Code, w.Body.String())
+		}
+	}
+	for _, rel := range []string{"escape.txt", "outside/secret.txt", "../secret.txt"} {
+		for _, page := range []string{"/raw", "/view"} {
+			if w := get(page, rel); w.Code == http.StatusOK || strings.Contains(w.Body.String(), "secret\n") {
+				t.Errorf("%s %s: %d %q, want a refusal", page, rel, w.Code, w.Body.String())
+			}
+		}
+	}
+	if w := get("/api/list", "outside"); w.Code != http.StatusForbidden {
+		t.Errorf("/api/list outside: %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if w := get("/api/list", "docs"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"guide.md"`) {
+		t.Errorf("/api/list docs: %d %q", w.Code, w.Body.String())
+	}
+}
+
+// TestWebRawDownloads checks /raw sends an HTML file as a download that the
+// browser neither sniffs nor runs, so its script cannot read the key
+func TestWebRawDownloads(t *testing.T) {
+	uitest.IsolateConfig(t)
+	page := "<script>fetch('/api/list?' + location.search.slice(1))</script>"
+	root := uitest.Mount(t, fstest.MapFS{"site/page.html": {Data: []byte(page)}, "site/logo.svg": {Data: []byte("<svg onload=alert(1)/>")}})
+	server, err := NewWebServer(filepath.Join(root, "site"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"page.html", "logo.svg"} {
+		query := url.Values{"key": {server.Key}, "path": {name}}.Encode()
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/raw?"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("/raw %s: %d %q", name, w.Code, w.Body.String())
+		}
+		for header, want := range map[string]string{
+			"Content-Type":            "application/octet-stream",
+			"Content-Disposition":     `attachment; filename=` + name,
+			"X-Content-Type-Options":  "nosniff",
+			"Content-Security-Policy": "sandbox",
+		} {
+			if got := w.Header().Get(header); got != want {
+				t.Errorf("/raw %s: %s = %q, want %q", name, header, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/HolyStarGazer/windows-tui-go/ops"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// windowSize is how much of a file over max_view_size is loaded at a time.
+// Scrolling past either end of the window slides it by half its size.
+const windowSize = 1 << 20
+
+// indexChunkSize is how much of the file each step of the background line
+// index reads
+const indexChunkSize = 4 << 20
+
+// indexStep is the number of lines between the offsets kept by the line index
+const indexStep = 1024
+
+// lineWindow tracks the lines of a file over max_view_size that are loaded:
+// fv.part holds their byte range, and the line index built in the background
+// gives their line numbers
+type lineWindow struct {
+	First   int     // Line number of the first loaded line, counted from 0, or -1 until known
+	offsets []int64 // File offset of each loaded line
+
+	marks    []int64 // Offset of every indexStep-th line
+	lines    int     // Line breaks counted by the index so far
+	indexed  int64   // Bytes of the file indexed so far
+	indexing bool    // Whether an index chunk is being read
+	indexErr error   // Why indexing stopped early, if it did
+	trailing bool    // Whether the indexed text ends in the middle of a line
+}
+
+// windowIndexMsg delivers one chunk of a viewer's line index
+type windowIndexMsg struct {
+	Viewer   *FileViewer
+	Read     int64   // Bytes indexed
+	Lines    int     // Line breaks found
+	Marks    []int64 // Offsets of the lines at multiples of indexStep
+	Trailing bool    // Whether the chunk ends in the middle of a line
+	Err      error
+}
+
+// loadWindowed opens a file over max_view_size at its start, saying how else
+// it can be viewed
+func (fv *FileViewer) loadWindowed(total int64) {
+	fv.window = &lineWindow{marks: []int64{0}}
+	fv.part = filePart{Total: total}
+	if err := fv.loadWindow(0, 0); err != nil {
+		fv.Err = err
+		return
+	}
+	other := ":hex for a hex dump"
+	if fv.hex {
+		other = ":hex for text"
+	}
+	fv.StatusMessage = fmt.Sprintf("Large file (%s), loaded as you scroll: G for the end, :tail for its last lines, F to follow, %s", FormatSize(total), other)
+}
+
+// loadWindow loads the lines starting at offset start, keeping only lines that
+// end inside the window. first is the line number of the line at start, or -1
+// to look it up in the index.
+func (fv *FileViewer) loadWindow(start int64, first int) error {
+	if first < 0 {
+		first = fv.lineNumberAt(start)
+	}
+	file, err := vfs.Default.Open(fv.FilePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	total := fv.part.Total
+	data := make([]byte, min(windowSize, total-start))
+	n, err := file.ReadAt(data, start)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	data = data[:n]
+
+	// Lines shown as text end at a line break; hex dump lines are whole rows
+	binary := !fv.forceText && (fv.hex || isBinary(string(data[:min(len(data), binarySniffSize)])))
+	if start+int64(len(data)) < total {
+		if binary {
+			data = data[:len(data)/hexBytesPerLine*hexBytesPerLine]
+		} else if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+			data = data[:i+1]
+		}
+	}
+
+	w := fv.window
+	w.First = first
+	w.offsets = append(w.offsets[:0], start)
+	for i, c := range data {
+		if c == '\n' && i < len(data)-1 {
+			w.offsets = append(w.offsets, start+int64(i+1))
+		}
+	}
+	fv.part.Start, fv.part.Size = start, int64(len(data))
+
+	fv.styledLines = nil
+	fv.spans = nil
+	fv.setContent(string(data))
+	if fv.LogMode {
+		fv.setLogMode(true)
+	}
+	fv.refilter(0)
+	fv.rematch()
+	return nil
+}
+
+// rematch finds the search term again in newly loaded lines, without moving
+func (fv *FileViewer) rematch() {
+	if fv.searchRe != nil {
+		fv.findMatches()
+	}
+}
+
+// lineOffset returns the file offset of loaded line i
+func (fv *FileViewer) lineOffset(i int) int64 {
+	if fv.hex {
+		return fv.part.Start + int64(i*hexBytesPerLine)
+	}
+	return fv.window.offsets[min(i, len(fv.window.offsets)-1)]
+}
+
+// slideWindow loads the window at start and keeps the line that was at the top
+// of the screen there. shift is how many lines earlier (negative: later) that
+// line is in the new window.
+func (fv *FileViewer) slideWindow(start int64, first, shift int) bool {
+	top := fv.lineAt(fv.ScrollPos)
+	if err := fv.loadWindow(start, first); err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return false
+	}
+	fv.ScrollPos = fv.rowOf(max(top+shift, 0))
+	return true
+}
+
+// loadLater slides the window forward by half once scrolling reaches its end.
+// It reports whether there was more of the file to load.
+func (fv *FileViewer) loadLater() bool {
+	w := fv.window
+	if w == nil || fv.part.Start+fv.part.Size >= fv.part.Total {
+		return false
+	}
+	drop := len(fv.Content) / 2
+	if drop == 0 {
+		// A line longer than the window moves on to the rest of it
+		return fv.slideWindow(fv.part.Start+fv.part.Size, -1, -len(fv.Content))
+	}
+	return fv.slideWindow(fv.lineOffset(drop), addLines(w.First, drop), -drop)
+}
+
+// loadBefore slides the window back by half once scrolling reaches its top.
+// It reports whether there was more of the file to load.
+func (fv *FileViewer) loadBefore() bool {
+	w := fv.window
+	if w == nil || fv.part.Start == 0 {
+		return false
+	}
+	from := max(0, fv.part.Start-windowSize/2)
+	if fv.hex {
+		from = from / hexBytesPerLine * hexBytesPerLine
+	}
+	data, err := fv.readRange(from, fv.part.Start)
+	if err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", err)
+		return false
+	}
+
+	added := int(fv.part.Start-from) / hexBytesPerLine
+	if !fv.hex {
+		// Start at a line break, unless a single line fills the whole read
+		if i := bytes.IndexByte(data, '\n'); from > 0 && i >= 0 && i < len(data)-1 {
+			from += int64(i + 1)
+			data = data[i+1:]
+		}
+		added = bytes.Count(data, []byte{'\n'})
+	}
+	first := addLines(w.First, -added)
+	if !fv.hex && from > 0 && (len(data) == 0 || data[len(data)-1] != '\n') {
+		// The window starts inside a line
+		first = -1
+	}
+	return fv.slideWindow(from, first, added)
+}
+
+// loadStart shows the start of the file (g)
+func (fv *FileViewer) loadStart() {
+	if fv.part.Start > 0 {
+		fv.slideWindow(0, 0, 0)
+	}
+	fv.ScrollPos = 0
+}
+
+// loadEnd shows the end of the file (G). Its line numbers are known once the
+// index has reached it.
+func (fv *FileViewer) loadEnd() {
+	from := max(0, fv.part.Total-windowSize)
+	if fv.part.Start+fv.part.Size < fv.part.Total {
+		if fv.hex {
+			from = from / hexBytesPerLine * hexBytesPerLine
+		} else if data, err := fv.readRange(from, min(from+tailReadSize, fv.part.Total)); err == nil {
+			if i := bytes.IndexByte(data, '\n'); i >= 0 {
+				from += int64(i + 1)
+			}
+		}
+		fv.slideWindow(from, -1, 0)
+	}
+	fv.scrollToBottom()
+}
+
+// readRange reads the bytes of the file from start up to end
+func (fv *FileViewer) readRange(start, end int64) ([]byte, error) {
+	file, err := vfs.Default.Open(fv.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data := make([]byte, end-start)
+	n, err := file.ReadAt(data, start)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return data[:n], nil
+}
+
+// lineNumberAt returns the number of the line starting at offset, counted
+// from 0, or -1 if the index has not got that far
+func (fv *FileViewer) lineNumberAt(offset int64) int {
+	w := fv.window
+	if fv.hex {
+		return int(offset / hexBytesPerLine)
+	}
+	if offset > w.indexed {
+		return -1
+	}
+	// Count the line breaks after the nearest indexed line
+	k := sort.Search(len(w.marks), func(i int) bool { return w.marks[i] > offset }) - 1
+	data, err := fv.readRange(w.marks[k], offset)
+	if err != nil {
+		return -1
+	}
+	return k*indexStep + bytes.Count(data, []byte{'\n'})
+}
+
+// addLines offsets a line number that may not be known yet
+func addLines(line, n int) int {
+	if line < 0 {
+		return -1
+	}
+	return max(line+n, 0)
+}
+
+// startIndexing reads the next chunk of the line index in the background,
+// unless one is being read or the whole file is indexed
+func (fv *FileViewer) startIndexing() tea.Cmd {
+	w := fv.window
+	if w == nil || w.indexing || w.indexErr != nil || w.indexed >= fv.part.Total {
+		return nil
+	}
+	w.indexing = true
+	path, from, lines := fv.FilePath, w.indexed, w.lines
+	size := min(indexChunkSize, fv.part.Total-from)
+	return func() tea.Msg {
+		msg := windowIndexMsg{Viewer: fv}
+		ops.Work(func() {
+			file, err := vfs.Default.Open(path)
+			if err != nil {
+				msg.Err = err
+				return
+			}
+			defer file.Close()
+			data, err := io.ReadAll(ops.Throttle(io.NewSectionReader(file, from, size)))
+			if err != nil {
+				msg.Err = err
+				return
+			}
+			msg.Read = int64(len(data))
+			msg.Trailing = len(data) > 0 && data[len(data)-1] != '\n'
+			for i, c := range data {
+				if c != '\n' {
+					continue
+				}
+				msg.Lines++
+				if (lines+msg.Lines)%indexStep == 0 {
+					msg.Marks = append(msg.Marks, from+int64(i+1))
+				}
+			}
+		})
+		return msg
+	}
+}
+
+// receiveIndex adds a chunk to the line index, numbering the loaded lines if
+// the index has now reached them
+func (fv *FileViewer) receiveIndex(msg windowIndexMsg) {
+	w := fv.window
+	w.indexing = false
+	if msg.Err == nil && msg.Read == 0 {
+		msg.Err = errors.New("the file shrank")
+	}
+	if msg.Err != nil {
+		// Line numbers past the error stay unknown
+		w.indexErr = msg.Err
+		fv.StatusMessage = fmt.Sprintf("Error indexing lines: %v", msg.Err)
+		return
+	}
+	w.indexed += msg.Read
+	w.lines += msg.Lines
+	w.trailing = msg.Trailing
+	w.marks = append(w.marks, msg.Marks...)
+	if w.First < 0 && w.indexed >= fv.part.Start {
+		w.First = fv.lineNumberAt(fv.part.Start)
+	}
+}
+
+// totalLines returns the number of lines in the file, or -1 until indexed
+func (w *lineWindow) totalLines(total int64) int {
+	if w.indexed < total || w.indexErr != nil {
+		return -1
+	}
+	// A last line without a line break still counts
+	if w.trailing {
+		return w.lines + 1
+	}
+	return w.lines
+}
+
+// firstLine returns the line number of the first loaded line, counted from 0,
+// or -1 if it is not known yet
+func (fv *FileViewer) firstLine() int {
+	switch {
+	case fv.window == nil:
+		return 0
+	case fv.hex:
+		return int(fv.part.Start / hexBytesPerLine)
+	}
+	return fv.window.First
+}
+
+// lineLabel formats the line number of loaded line i for the gutter
+func (fv *FileViewer) lineLabel(i int) string {
+	first := fv.firstLine()
+	if first < 0 {
+		return "   ?"
+	}
+	return fmt.Sprintf("%4d", first+i+1)
+}
+
+// windowInfo describes the loaded lines of a windowed file for the header
+func (fv *FileViewer) windowInfo() string {
+	w := fv.window
+	lines := "?"
+	if w.First >= 0 {
+		lines = fmt.Sprintf("%d-%d", w.First+1, w.First+len(fv.Content))
+	}
+	of := fmt.Sprintf("indexing %d%%", w.indexed*100/max(fv.part.Total, 1))
+	if total := w.totalLines(fv.part.Total); total >= 0 {
+		of = fmt.Sprintf("of %d", total)
+	} else if w.indexErr != nil {
+		of = "of ?"
+	}
+	return fmt.Sprintf("Lines: %s %s (%s, loaded as you scroll)", lines, of, FormatSize(fv.part.Total))
+}
+
+// followWindowed switches a windowed file to its end so that it can be
+// followed, since only the end of a growing file is needed
+func (fv *FileViewer) followWindowed() {
+	tail := NewPartialViewer(fv.FilePath, fv.FileName, true)
+	if tail.Err != nil {
+		fv.StatusMessage = fmt.Sprintf("Error: %v", tail.Err)
+		return
+	}
+	tail.Width, tail.Height = fv.Width, fv.Height
+	tail.HighlightRules = fv.HighlightRules
+	tail.WrapLines = fv.WrapLines
+	tail.buffer = fv.buffer
+	*fv = tail
+}
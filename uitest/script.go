@@ -0,0 +1,153 @@
+package uitest
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HolyStarGazer/windows-tui-go/config"
+	"github.com/HolyStarGazer/windows-tui-go/vfs"
+)
+
+// MountRoot is the directory Mount serves trees at, "/mem" or a drive's \mem
+var MountRoot = func() string {
+	root, err := filepath.Abs(string(filepath.Separator) + "mem")
+	if err != nil {
+		return string(filepath.Separator) + "mem"
+	}
+	return root
+}()
+
+// Mount serves a copy of fsys, such as a testing/fstest.MapFS, from memory at
+// MountRoot as vfs.Default until the test ends, and returns MountRoot. The
+// copy can be changed like a disk, so file operations run without one. Tests
+// that mount a tree cannot run in parallel.
+func Mount(t testing.TB, fsys fs.FS) string {
+	t.Helper()
+	mem, err := vfs.NewMemFS(MountRoot, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := vfs.Default
+	vfs.Default = mem
+	t.Cleanup(func() { vfs.Default = previous })
+	return MountRoot
+}
+
+// IsolateConfig points the configuration directory and the home folder at
+// empty temporary folders for the rest of the test, so the user's settings
+// and state are neither read nor changed, and returns the configuration
+// directory, created for config.json and the state files
+func IsolateConfig(t testing.TB) string {
+	t.Helper()
+	base := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", base)
+	t.Setenv("APPDATA", base)
+	t.Setenv("HOME", t.TempDir())
+	dir, err := config.Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// WriteTree writes the files of fsys, such as a testing/fstest.MapFS, to a
+// temporary directory removed after the test, and returns the directory. Use
+// it for code that works on the disk directly rather than through vfs.
+func WriteTree(t testing.TB, fsys fs.FS) string {
+	t.Helper()
+	dir := t.TempDir()
+	err := fs.WalkDir(fsys, ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || path == "." {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(path))
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, info.Mode().Perm()|0o200); err != nil {
+			return err
+		}
+		if modTime := info.ModTime(); !modTime.IsZero() {
+			return os.Chtimes(target, modTime, modTime)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// Run plays a session script, one step per line. Empty lines and lines starting
+// with # are skipped. The steps are:
+//
+//	keys j j enter    press keys by name
+//	type some text    type the rest of the line
+//	:set nu           enter a command
+//	expect text       fail unless the screen shows text
+//	reject text       fail if the screen shows text
+//	quit              fail unless the model has quit
+//
+// The first failing step is returned with its line number.
+func (d *Driver) Run(script string) error {
+	scanner := bufio.NewScanner(strings.NewReader(script))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := d.step(line); err != nil {
+			return fmt.Errorf("line %d: %s: %w", n, line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// step runs one line of a script
+func (d *Driver) step(line string) error {
+	if command, ok := strings.CutPrefix(line, ":"); ok {
+		d.Command(command)
+		return nil
+	}
+	verb, arg, _ := strings.Cut(line, " ")
+	switch verb {
+	case "keys":
+		d.Keys(strings.Fields(arg)...)
+	case "type":
+		d.Type(arg)
+	case "expect":
+		if !strings.Contains(d.Frame(), arg) {
+			return fmt.Errorf("not on screen:\n%s", d.Frame())
+		}
+	case "reject":
+		if strings.Contains(d.Frame(), arg) {
+			return fmt.Errorf("on screen:\n%s", d.Frame())
+		}
+	case "quit":
+		if !d.Quit {
+			return fmt.Errorf("the model has not quit")
+		}
+	default:
+		return fmt.Errorf("unknown step %q", verb)
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+// Package uitest drives the explorer's models with synthetic key and window
+// messages, runs the commands they return and compares rendered frames with
+// golden files, so features can be tested without a terminal
+package uitest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// DefaultSettle is how long a command may take before the driver gives up on
+// it. Timers such as follow polling and idle checks never finish in time, which
+// keeps them from running forever.
+const DefaultSettle = 200 * time.Millisecond
+
+// update rewrites the golden files with the frames rendered, as in
+// go test ./ui -update
+var update = flag.Bool("update", false, "rewrite golden files with the rendered frames")
+
+// maxMessages caps the messages handled for a single Send, in case commands
+// keep producing new ones
+const maxMessages = 10000
+
+// Driver holds a model and feeds it messages the way a tea.Program would
+type Driver struct {
+	Settle time.Duration // How long each command may take (DefaultSettle if zero)
+	Quit   bool          // Whether the model asked to quit
+
+	model tea.Model
+}
+
+// New starts a driver on m with a terminal of the given size, running its Init
+// commands and sending the window size
+func New(m tea.Model, width, height int) *Driver {
+	d := &Driver{model: m}
+	d.run(m.Init())
+	d.Send(tea.WindowSizeMsg{Width: width, Height: height})
+	return d
+}
+
+// Model returns the current state of the model
+func (d *Driver) Model() tea.Model {
+	return d.model
+}
+
+// Send delivers messages to the model one at a time, running the commands each
+// returns until they settle
+func (d *Driver) Send(msgs ...tea.Msg) {
+	for _, msg := range msgs {
+		if d.Quit {
+			return
+		}
+		d.deliver(msg)
+	}
+}
+
+// Keys presses keys given by name, such as "j", "enter", "ctrl+o" or "f3"
+func (d *Driver) Keys(keys ...string) {
+	for _, k := range keys {
+		d.Send(Key(k))
+	}
+}
+
+// Type types text one character at a time
+func (d *Driver) Type(text string) {
+	for _, r := range text {
+		d.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+// Command enters a browser or viewer command, as typed after :
+func (d *Driver) Command(command string) {
+	d.Keys(":")
+	d.Type(command)
+	d.Keys("enter")
+}
+
+// Frame returns the rendered screen with escape sequences and trailing spaces
+// removed. Each pair of replacements swaps text such as a temporary directory
+// for a fixed placeholder.
+func (d *Driver) Frame(replacements ...string) string {
+	return Normalize(d.model.View(), replacements...)
+}
+
+// deliver updates the model with a message and follows up on its commands
+func (d *Driver) deliver(msg tea.Msg) {
+	queue := []tea.Msg{msg}
+	for n := 0; len(queue) > 0 && n < maxMessages; n++ {
+		next := queue[0]
+		queue = queue[1:]
+		if _, ok := next.(tea.QuitMsg); ok {
+			d.Quit = true
+			return
+		}
+		var cmd tea.Cmd
+		d.model, cmd = d.model.Update(next)
+		queue = append(queue, d.collect(cmd)...)
+	}
+}
+
+// run delivers the messages of a command without a message of its own
+func (d *Driver) run(cmd tea.Cmd) {
+	for _, msg := range d.collect(cmd) {
+		d.Send(msg)
+	}
+}
+
+// collect runs commands side by side and returns the messages they produced
+// within the settle time, in order, flattening batches and sequences
+func (d *Driver) collect(cmds ...tea.Cmd) []tea.Msg {
+	settle := d.Settle
+	if settle == 0 {
+		settle = DefaultSettle
+	}
+	results := make([]chan tea.Msg, len(cmds))
+	for i, cmd := range cmds {
+		if cmd == nil {
+			continue
+		}
+		results[i] = make(chan tea.Msg, 1)
+		go func(done chan<- tea.Msg) { done <- cmd() }(results[i])
+	}
+
+	var msgs []tea.Msg
+	deadline := time.After(settle)
+	expired := false
+	for _, done := range results {
+		if done == nil {
+			continue
+		}
+		var msg tea.Msg
+		if expired {
+			// Only take what has finished, the rest is given up on
+			select {
+			case msg = <-done:
+			default:
+				continue
+			}
+		} else {
+			select {
+			case msg = <-done:
+			case <-deadline:
+				expired = true
+				continue
+			}
+		}
+		if nested, ok := commandList(msg); ok {
+			msgs = append(msgs, d.collect(nested...)...)
+		} else if msg != nil {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+// commandList returns the commands of a batch or sequence message. Sequences
+// have an unexported type, so they are recognized by their shape.
+func commandList(msg tea.Msg) ([]tea.Cmd, bool) {
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		return batch, true
+	}
+	v := reflect.ValueOf(msg)
+	if v.Kind() != reflect.Slice || v.Type().Elem() != reflect.TypeOf(tea.Cmd(nil)) {
+		return nil, false
+	}
+	cmds := make([]tea.Cmd, v.Len())
+	for i := range cmds {
+		cmds[i] = v.Index(i).Interface().(tea.Cmd)
+	}
+	return cmds, true
+}
+
+// Key returns the key message for a key name as reported by tea.KeyMsg.String,
+// or "space" for the space bar
+func Key(name string) tea.KeyMsg {
+	if name == "space" {
+		name = " "
+	}
+	if t, ok := keyTypes[name]; ok {
+		return tea.KeyMsg{Type: t}
+	}
+	if rest, ok := strings.CutPrefix(name, "alt+"); ok && rest != "" {
+		k := Key(rest)
+		k.Alt = true
+		return k
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name)}
+}
+
+// keyTypes maps the names of special keys to their types
+var keyTypes = func() map[string]tea.KeyType {
+	types := make(map[string]tea.KeyType)
+	for t := tea.KeyType(-200); t < 128; t++ {
+		if name := t.String(); name != "" && t != tea.KeyRunes {
+			types[name] = t
+		}
+	}
+	return types
+}()
+
+// Normalize removes escape sequences and trailing spaces from a rendered
+// frame, drops trailing empty lines and applies pairs of replacements
+func Normalize(frame string, replacements ...string) string {
+	frame = ansi.Strip(frame)
+	if len(replacements) > 1 {
+		frame = strings.NewReplacer(replacements...).Replace(frame)
+	}
+	lines := strings.Split(frame, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Golden compares a frame with testdata/<name>.golden in the test's directory,
+// writing the file instead when the tests run with -update
+func Golden(t testing.TB, name, frame string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(frame), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run the tests with -update to create it)", err)
+	}
+	if string(want) != frame {
+		t.Errorf("frame differs from %s:\n--- want\n%s--- got\n%s", path, want, frame)
+	}
+}
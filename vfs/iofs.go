@@ -0,0 +1,177 @@
+package vfs
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ioFS serves an io/fs file system below a directory path
+type ioFS struct {
+	root string
+	fsys fs.FS
+}
+
+// FromFS serves fsys, such as a testing/fstest.MapFS or an opened zip archive,
+// as the directory root: root\a\b is a/b in fsys. Paths outside root do not
+// exist, and changes fail with ErrReadOnly.
+func FromFS(root string, fsys fs.FS) FS {
+	return ioFS{root: filepath.Clean(root), fsys: fsys}
+}
+
+// rel converts a path below the root to a path in fsys
+func (f ioFS) rel(op, name string) (string, error) {
+	rel, err := filepath.Rel(f.root, filepath.Clean(name))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// readOnly is the error for changes to name
+func readOnly(op, name string) error {
+	return &fs.PathError{Op: op, Path: name, Err: ErrReadOnly}
+}
+
+// Open opens a file for reading
+func (f ioFS) Open(name string) (File, error) {
+	rel, err := f.rel("open", name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := f.fsys.Open(rel)
+	if err != nil {
+		return nil, err
+	}
+	if random, ok := file.(File); ok {
+		return random, nil
+	}
+
+	// Files that can only be read in order, such as those of zip archives, are
+	// read into memory
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	return &memFile{Reader: bytes.NewReader(data), info: info}, nil
+}
+
+// ReadDir lists a directory, sorted by name
+func (f ioFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	rel, err := f.rel("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadDir(f.fsys, rel)
+}
+
+// Stat describes a file, following links
+func (f ioFS) Stat(name string) (fs.FileInfo, error) {
+	rel, err := f.rel("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(f.fsys, rel)
+}
+
+// Lstat describes a file without following links
+func (f ioFS) Lstat(name string) (fs.FileInfo, error) {
+	rel, err := f.rel("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Lstat(f.fsys, rel)
+}
+
+// Readlink returns the target of a link
+func (f ioFS) Readlink(name string) (string, error) {
+	rel, err := f.rel("readlink", name)
+	if err != nil {
+		return "", err
+	}
+	return fs.ReadLink(f.fsys, rel)
+}
+
+// EvalSymlinks follows the links in name
+func (f ioFS) EvalSymlinks(name string) (string, error) {
+	return evalSymlinks(f, name)
+}
+
+// Create fails, the file system is read-only
+func (f ioFS) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	return nil, readOnly("create", name)
+}
+
+// Append fails, the file system is read-only
+func (f ioFS) Append(name string) (io.WriteCloser, error) {
+	return nil, readOnly("append", name)
+}
+
+// WriteFile fails, the file system is read-only
+func (f ioFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return readOnly("write", name)
+}
+
+// Mkdir fails, the file system is read-only
+func (f ioFS) Mkdir(name string, perm fs.FileMode) error {
+	return readOnly("mkdir", name)
+}
+
+// MkdirAll fails, the file system is read-only
+func (f ioFS) MkdirAll(name string, perm fs.FileMode) error {
+	return readOnly("mkdir", name)
+}
+
+// Remove fails, the file system is read-only
+func (f ioFS) Remove(name string) error {
+	return readOnly("remove", name)
+}
+
+// RemoveAll fails, the file system is read-only
+func (f ioFS) RemoveAll(name string) error {
+	return readOnly("remove", name)
+}
+
+// Rename fails, the file system is read-only
+func (f ioFS) Rename(oldname, newname string) error {
+	return readOnly("rename", oldname)
+}
+
+// Symlink fails, the file system is read-only
+func (f ioFS) Symlink(oldname, newname string) error {
+	return readOnly("symlink", newname)
+}
+
+// Link fails, the file system is read-only
+func (f ioFS) Link(oldname, newname string) error {
+	return readOnly("link", newname)
+}
+
+// Chtimes fails, the file system is read-only
+func (f ioFS) Chtimes(name string, atime, mtime time.Time) error {
+	return readOnly("chtimes", name)
+}
+
+// memFile is a file read into memory
+type memFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+// Stat describes the file
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// Close does nothing, the file is in memory
+func (f *memFile) Close() error {
+	return nil
+}
@@ -0,0 +1,100 @@
+package vfs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Local is the file system of the operating system
+var Local FS = localFS{}
+
+// localFS passes every call to package os
+type localFS struct{}
+
+// Open opens a file for reading
+func (localFS) Open(name string) (File, error) {
+	return os.Open(name)
+}
+
+// ReadDir lists a directory, sorted by name
+func (localFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+// Stat describes a file, following links
+func (localFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+// Lstat describes a file without following links
+func (localFS) Lstat(name string) (fs.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+// Readlink returns the target of a link
+func (localFS) Readlink(name string) (string, error) {
+	return os.Readlink(name)
+}
+
+// EvalSymlinks follows the links in name, including junctions on Windows
+func (localFS) EvalSymlinks(name string) (string, error) {
+	return filepath.EvalSymlinks(name)
+}
+
+// Create creates a new file for writing
+func (localFS) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	return os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+}
+
+// Append opens an existing file for writing at its end
+func (localFS) Append(name string) (io.WriteCloser, error) {
+	return os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0)
+}
+
+// WriteFile writes a whole file
+func (localFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// Mkdir creates a directory
+func (localFS) Mkdir(name string, perm fs.FileMode) error {
+	return os.Mkdir(name, perm)
+}
+
+// MkdirAll creates a directory and any missing parents
+func (localFS) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+
+// Remove removes a file or empty directory
+func (localFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+// RemoveAll removes a file or directory tree
+func (localFS) RemoveAll(name string) error {
+	return os.RemoveAll(name)
+}
+
+// Rename renames or moves a file
+func (localFS) Rename(oldname, newname string) error {
+	return os.Rename(oldname, newname)
+}
+
+// Symlink creates a link
+func (localFS) Symlink(oldname, newname string) error {
+	return os.Symlink(oldname, newname)
+}
+
+// Link creates a hard link
+func (localFS) Link(oldname, newname string) error {
+	return os.Link(oldname, newname)
+}
+
+// Chtimes changes the access and modification times
+func (localFS) Chtimes(name string, atime, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
@@ -0,0 +1,569 @@
+package vfs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errNotEmpty is returned for removing or replacing a directory with entries
+var errNotEmpty = errors.New("directory not empty")
+
+// errNotDir is returned for paths going through something other than a directory
+var errNotDir = errors.New("not a directory")
+
+// errIsDir is returned for opening a directory for writing, or linking it
+var errIsDir = errors.New("is a directory")
+
+// errLinkLoop is returned for links that lead back to themselves
+var errLinkLoop = errors.New("too many levels of symbolic links")
+
+// maxLinks is the most links followed while looking a path up
+const maxLinks = 40
+
+// memFS is a file system kept in memory below a directory path. Paths are
+// keyed relative to the root in slash form, with "." for the root itself.
+type memFS struct {
+	root  string
+	mu    sync.Mutex
+	nodes map[string]*memNode
+}
+
+// memNode is a file, directory or link. Hard links share a node.
+type memNode struct {
+	mode    fs.FileMode
+	data    []byte // Contents of a file, or the target of a link
+	modTime time.Time
+}
+
+// NewMemFS returns a file system held in memory as the directory root, filled
+// with a copy of fsys, such as a testing/fstest.MapFS. Unlike FromFS it can be
+// changed, so file operations can be tested without touching the disk. Paths
+// outside root do not exist.
+func NewMemFS(root string, fsys fs.FS) (FS, error) {
+	m := &memFS{root: filepath.Clean(root), nodes: map[string]*memNode{
+		".": {mode: fs.ModeDir | 0o755, modTime: time.Now()},
+	}}
+	if fsys == nil {
+		return m, nil
+	}
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || name == "." {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		node := &memNode{mode: info.Mode(), modTime: info.ModTime()}
+		if node.modTime.IsZero() {
+			node.modTime = time.Now()
+		}
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			target, err := fs.ReadLink(fsys, name)
+			if err != nil {
+				return err
+			}
+			node.data = []byte(target)
+		case !info.IsDir():
+			if node.data, err = fs.ReadFile(fsys, name); err != nil {
+				return err
+			}
+		}
+		// Parents missing from a flat tree such as a MapFS are made on the way
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			if _, ok := m.nodes[dir]; !ok {
+				m.nodes[dir] = &memNode{mode: fs.ModeDir | 0o755, modTime: node.modTime}
+			}
+		}
+		m.nodes[name] = node
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// rel converts a path below the root to a key of nodes
+func (m *memFS) rel(op, name string) (string, error) {
+	rel, err := filepath.Rel(m.root, filepath.Clean(name))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// resolve follows the links on the way to key, and the one at key itself if
+// follow is set, returning the key of what was reached. The entry reached
+// need not exist, but its parent must be a directory.
+func (m *memFS) resolve(key string, follow bool) (string, error) {
+	for links := 0; ; {
+		parts := strings.Split(key, "/")
+		resolved := "."
+		restart := false
+		for i, part := range parts {
+			if part == "." {
+				continue
+			}
+			next := path.Join(resolved, part)
+			node, ok := m.nodes[next]
+			last := i == len(parts)-1
+			if !ok {
+				if !last {
+					return "", fs.ErrNotExist
+				}
+				return next, nil
+			}
+			if node.mode&fs.ModeSymlink != 0 && (!last || follow) {
+				if links++; links > maxLinks {
+					return "", errLinkLoop
+				}
+				target, err := m.linkTarget(resolved, string(node.data))
+				if err != nil {
+					return "", err
+				}
+				key = path.Join(append([]string{target}, parts[i+1:]...)...)
+				restart = true
+				break
+			}
+			if !last && !node.mode.IsDir() {
+				return "", errNotDir
+			}
+			resolved = next
+		}
+		if !restart {
+			return resolved, nil
+		}
+	}
+}
+
+// linkTarget converts the target of a link in the directory dir to a key
+func (m *memFS) linkTarget(dir, target string) (string, error) {
+	if !filepath.IsAbs(target) && !strings.HasPrefix(target, "/") {
+		return path.Join(dir, filepath.ToSlash(target)), nil
+	}
+	rel, err := filepath.Rel(m.root, filepath.Clean(target))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fs.ErrNotExist
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// lookup finds the node at name for op, following the link at name if follow
+// is set
+func (m *memFS) lookup(op, name string, follow bool) (string, *memNode, error) {
+	key, err := m.rel(op, name)
+	if err == nil {
+		key, err = m.resolve(key, follow)
+	}
+	if err != nil {
+		return "", nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	node, ok := m.nodes[key]
+	if !ok {
+		return key, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return key, node, nil
+}
+
+// place finds where a new entry at name goes for op, failing unless its
+// parent is a directory. The node already there, if any, is returned too.
+func (m *memFS) place(op, name string) (string, *memNode, error) {
+	key, err := m.rel(op, name)
+	if err == nil {
+		key, err = m.resolve(key, false)
+	}
+	if err == nil && key == "." {
+		err = fs.ErrExist
+	}
+	if err == nil {
+		if parent, ok := m.nodes[path.Dir(key)]; !ok {
+			err = fs.ErrNotExist
+		} else if !parent.mode.IsDir() {
+			err = errNotDir
+		}
+	}
+	if err != nil {
+		return "", nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return key, m.nodes[key], nil
+}
+
+// children returns the keys of the entries directly in the directory key,
+// sorted by name
+func (m *memFS) children(key string) []string {
+	prefix := key + "/"
+	if key == "." {
+		prefix = ""
+	}
+	var keys []string
+	for k := range m.nodes {
+		if rest, ok := strings.CutPrefix(k, prefix); ok && k != "." && rest != "" && !strings.Contains(rest, "/") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// below reports whether key is dir or inside it
+func below(key, dir string) bool {
+	return dir == "." || key == dir || strings.HasPrefix(key, dir+"/")
+}
+
+// Open opens a file for reading
+func (m *memFS) Open(name string) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, node, err := m.lookup("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	info := node.info(path.Base(key))
+	if node.mode.IsDir() {
+		return &memFile{Reader: bytes.NewReader(nil), info: info}, nil
+	}
+	return &memFile{Reader: bytes.NewReader(bytes.Clone(node.data)), info: info}, nil
+}
+
+// ReadDir lists a directory, sorted by name
+func (m *memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, node, err := m.lookup("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if !node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errNotDir}
+	}
+	var entries []fs.DirEntry
+	for _, k := range m.children(key) {
+		entries = append(entries, fs.FileInfoToDirEntry(m.nodes[k].info(path.Base(k))))
+	}
+	return entries, nil
+}
+
+// Stat describes a file, following links
+func (m *memFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, node, err := m.lookup("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return node.info(filepath.Base(name)), nil
+}
+
+// Lstat describes a file without following links
+func (m *memFS) Lstat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, node, err := m.lookup("lstat", name, false)
+	if err != nil {
+		return nil, err
+	}
+	return node.info(filepath.Base(name)), nil
+}
+
+// Readlink returns the target of a link
+func (m *memFS) Readlink(name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, node, err := m.lookup("readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+	if node.mode&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return string(node.data), nil
+}
+
+// EvalSymlinks follows the links in name
+func (m *memFS) EvalSymlinks(name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, _, err := m.lookup("evalsymlinks", name, true)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(m.root, filepath.FromSlash(key)), nil
+}
+
+// Create creates a new file for writing, failing if name exists
+func (m *memFS) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, existing, err := m.place("create", name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: fs.ErrExist}
+	}
+	node := &memNode{mode: perm.Perm(), modTime: time.Now()}
+	m.nodes[key] = node
+	return &memWriter{fsys: m, node: node}, nil
+}
+
+// Append opens an existing file for writing at its end
+func (m *memFS) Append(name string) (io.WriteCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, node, err := m.lookup("append", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "append", Path: name, Err: errIsDir}
+	}
+	return &memWriter{fsys: m, node: node}, nil
+}
+
+// WriteFile writes data to name, creating or truncating it
+func (m *memFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, err := m.rel("write", name)
+	if err == nil {
+		key, err = m.resolve(key, true)
+	}
+	if err != nil {
+		return &fs.PathError{Op: "write", Path: name, Err: err}
+	}
+	if node, ok := m.nodes[key]; ok {
+		if node.mode.IsDir() {
+			return &fs.PathError{Op: "write", Path: name, Err: errIsDir}
+		}
+		node.data, node.modTime = bytes.Clone(data), time.Now()
+		return nil
+	}
+	if parent, ok := m.nodes[path.Dir(key)]; !ok || !parent.mode.IsDir() {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrNotExist}
+	}
+	m.nodes[key] = &memNode{mode: perm.Perm(), data: bytes.Clone(data), modTime: time.Now()}
+	return nil
+}
+
+// Mkdir creates a directory, failing if name exists
+func (m *memFS) Mkdir(name string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, existing, err := m.place("mkdir", name)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrExist}
+	}
+	m.nodes[key] = &memNode{mode: fs.ModeDir | perm.Perm(), modTime: time.Now()}
+	return nil
+}
+
+// MkdirAll creates a directory and the parents it is missing
+func (m *memFS) MkdirAll(name string, perm fs.FileMode) error {
+	if info, err := m.Stat(name); err == nil {
+		if info.IsDir() {
+			return nil
+		}
+		return &fs.PathError{Op: "mkdir", Path: name, Err: errNotDir}
+	}
+	if parent := filepath.Dir(filepath.Clean(name)); parent != filepath.Clean(name) {
+		if err := m.MkdirAll(parent, perm); err != nil {
+			return err
+		}
+	}
+	if err := m.Mkdir(name, perm); err != nil && !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	return nil
+}
+
+// Remove removes a file, link or empty directory
+func (m *memFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, node, err := m.lookup("remove", name, false)
+	if err != nil {
+		return err
+	}
+	if key == "." || node.mode.IsDir() && len(m.children(key)) > 0 {
+		return &fs.PathError{Op: "remove", Path: name, Err: errNotEmpty}
+	}
+	delete(m.nodes, key)
+	return nil
+}
+
+// RemoveAll removes name and everything below it. A missing name is not an
+// error.
+func (m *memFS) RemoveAll(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, _, err := m.lookup("remove", name, false)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if key == "." {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrPermission}
+	}
+	for k := range m.nodes {
+		if below(k, key) {
+			delete(m.nodes, k)
+		}
+	}
+	return nil
+}
+
+// Rename moves oldname and everything below it to newname, replacing a file
+// or empty directory there
+func (m *memFS) Rename(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	from, node, err := m.lookup("rename", oldname, false)
+	if err != nil {
+		return err
+	}
+	to, existing, err := m.place("rename", newname)
+	if err != nil {
+		return err
+	}
+	switch {
+	case from == to:
+		return nil
+	case from == "." || below(to, from):
+		return &fs.PathError{Op: "rename", Path: oldname, Err: fs.ErrInvalid}
+	case existing != nil && existing.mode.IsDir() != node.mode.IsDir():
+		return &fs.PathError{Op: "rename", Path: newname, Err: fs.ErrExist}
+	case existing != nil && existing.mode.IsDir() && len(m.children(to)) > 0:
+		return &fs.PathError{Op: "rename", Path: newname, Err: errNotEmpty}
+	}
+	moved := make(map[string]*memNode)
+	for k, n := range m.nodes {
+		if below(k, from) {
+			moved[to+strings.TrimPrefix(k, from)] = n
+			delete(m.nodes, k)
+		}
+	}
+	for k, n := range moved {
+		m.nodes[k] = n
+	}
+	return nil
+}
+
+// Symlink creates newname as a link to oldname
+func (m *memFS) Symlink(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, existing, err := m.place("symlink", newname)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return &fs.PathError{Op: "symlink", Path: newname, Err: fs.ErrExist}
+	}
+	m.nodes[key] = &memNode{mode: fs.ModeSymlink | 0o777, data: []byte(oldname), modTime: time.Now()}
+	return nil
+}
+
+// Link creates a hard link: newname becomes another name of the file oldname
+func (m *memFS) Link(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, node, err := m.lookup("link", oldname, false)
+	if err != nil {
+		return err
+	}
+	if node.mode.IsDir() {
+		return &fs.PathError{Op: "link", Path: oldname, Err: errIsDir}
+	}
+	key, existing, err := m.place("link", newname)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return &fs.PathError{Op: "link", Path: newname, Err: fs.ErrExist}
+	}
+	m.nodes[key] = node
+	return nil
+}
+
+// Chtimes sets the modification time of a file, following links. Access
+// times are not kept.
+func (m *memFS) Chtimes(name string, atime, mtime time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, node, err := m.lookup("chtimes", name, true)
+	if err != nil {
+		return err
+	}
+	node.modTime = mtime
+	return nil
+}
+
+// info describes the node under a name
+func (n *memNode) info(name string) fs.FileInfo {
+	size := int64(len(n.data))
+	if n.mode.IsDir() {
+		size = 0
+	}
+	return memInfo{name: name, size: size, mode: n.mode, modTime: n.modTime}
+}
+
+// memInfo describes a node of a memFS as it was when asked
+type memInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// Name returns the base name
+func (i memInfo) Name() string { return i.name }
+
+// Size returns the length of a file in bytes
+func (i memInfo) Size() int64 { return i.size }
+
+// Mode returns the type and permission bits
+func (i memInfo) Mode() fs.FileMode { return i.mode }
+
+// ModTime returns the modification time
+func (i memInfo) ModTime() time.Time { return i.modTime }
+
+// IsDir reports whether the node is a directory
+func (i memInfo) IsDir() bool { return i.mode.IsDir() }
+
+// Sys returns nil, there is no underlying data source
+func (i memInfo) Sys() any { return nil }
+
+// memWriter writes to the end of a file of a memFS
+type memWriter struct {
+	fsys *memFS
+	node *memNode
+}
+
+// Write appends p to the file
+func (w *memWriter) Write(p []byte) (int, error) {
+	w.fsys.mu.Lock()
+	defer w.fsys.mu.Unlock()
+	w.node.data = append(w.node.data, p...)
+	w.node.modTime = time.Now()
+	return len(p), nil
+}
+
+// Close finishes writing
+func (w *memWriter) Close() error {
+	return nil
+}
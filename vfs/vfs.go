@@ -0,0 +1,158 @@
+// Package vfs is the file system layer under the browser, the viewer and the
+// file operations. Paths are operating system paths; the local disk is used
+// unless Default is replaced, for example by an in-memory tree in tests or the
+// contents of an archive.
+package vfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrReadOnly is returned for changes to a file system that cannot be written
+var ErrReadOnly = errors.New("read-only file system")
+
+// File is an open file that can be read in any order
+type File interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
+	io.Closer
+	Stat() (fs.FileInfo, error)
+}
+
+// FS is a file system the explorer can browse and change. The methods behave
+// like their counterparts in package os.
+type FS interface {
+	Open(name string) (File, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Stat(name string) (fs.FileInfo, error)
+	Lstat(name string) (fs.FileInfo, error)
+	Readlink(name string) (string, error)
+	// EvalSymlinks returns the path name leads to once every link on the way
+	// is followed, like filepath.EvalSymlinks
+	EvalSymlinks(name string) (string, error)
+
+	// Create creates a new file for writing, failing if name exists
+	Create(name string, perm fs.FileMode) (io.WriteCloser, error)
+	// Append opens an existing file for writing at its end
+	Append(name string) (io.WriteCloser, error)
+	// WriteFile writes data to name, creating or truncating it
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	Mkdir(name string, perm fs.FileMode) error
+	MkdirAll(name string, perm fs.FileMode) error
+	Remove(name string) error
+	RemoveAll(name string) error
+	Rename(oldname, newname string) error
+	Symlink(oldname, newname string) error
+	// Link creates a hard link: newname becomes another name of the file oldname
+	Link(oldname, newname string) error
+	Chtimes(name string, atime, mtime time.Time) error
+}
+
+// Default is the file system the explorer works on
+var Default FS = Local
+
+// evalSymlinks follows the links in the absolute path name one element at a
+// time, for file systems that can only look at a link at a time
+func evalSymlinks(fsys FS, name string) (string, error) {
+	name = filepath.Clean(name)
+	sep := string(filepath.Separator)
+	volume := filepath.VolumeName(name)
+	resolved := volume + sep
+	rest := strings.Split(name[len(volume):], sep)
+	for links := 0; len(rest) > 0; {
+		part := rest[0]
+		rest = rest[1:]
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+		next := filepath.Join(resolved, part)
+		info, err := fsys.Lstat(next)
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+		if links++; links > maxLinks {
+			return "", &fs.PathError{Op: "evalsymlinks", Path: name, Err: errLinkLoop}
+		}
+		target, err := fsys.Readlink(next)
+		if err != nil {
+			return "", err
+		}
+		target = filepath.FromSlash(target)
+		if filepath.IsAbs(target) {
+			volume = filepath.VolumeName(target)
+			resolved = volume + sep
+			target = target[len(volume):]
+		}
+		rest = append(strings.Split(target, sep), rest...)
+	}
+	return resolved, nil
+}
+
+// ReadFile reads a whole file from fsys
+func ReadFile(fsys FS, name string) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// WalkDir walks the tree below root like filepath.WalkDir, calling fn for
+// every entry including root. Links are reported but not followed.
+func WalkDir(fsys FS, root string, fn fs.WalkDirFunc) error {
+	info, err := fsys.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkDir(fsys, root, fs.FileInfoToDirEntry(info), fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// walkDir visits path and, for directories, everything below it
+func walkDir(fsys FS, path string, entry fs.DirEntry, fn fs.WalkDirFunc) error {
+	if err := fn(path, entry, nil); err != nil || !entry.IsDir() {
+		if err == filepath.SkipDir && entry.IsDir() {
+			err = nil
+		}
+		return err
+	}
+
+	entries, err := fsys.ReadDir(path)
+	if err != nil {
+		// Report the error for the directory a second time, as filepath.WalkDir does
+		if err = fn(path, entry, err); err != nil {
+			if err == filepath.SkipDir {
+				err = nil
+			}
+			return err
+		}
+	}
+	for _, child := range entries {
+		if err := walkDir(fsys, filepath.Join(path, child.Name()), child, fn); err != nil {
+			if err == filepath.SkipDir {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
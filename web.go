@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/HolyStarGazer/windows-tui-go/ui"
+)
+
+// serveWeb serves the web page for the directory root, or the current
+// directory, until the program is stopped. A bare port listens on this
+// machine only.
+func serveWeb(addr, root string) error {
+	if root == "" {
+		var err error
+		if root, err = os.Getwd(); err != nil {
+			return err
+		}
+	}
+	server, err := ui.NewWebServer(root)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", ui.ListenAddr(addr))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Serving %s read-only on %s\n", server.Root, server.URL(listener.Addr()))
+	fmt.Println("Press Ctrl+C to stop")
+	return http.Serve(listener, server)
+}